@@ -0,0 +1,154 @@
+package docs_test
+
+import (
+	"encoding/json"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/go-openapi/spec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/swaggo/swag"
+
+	_ "github.com/yeegeek/uyou-go-api-starter/api/docs"
+)
+
+// 这些测试针对 swag init 生成的文档，注释缺失或写错时会失败，提示重新执行 make swag
+
+func loadSpec(t *testing.T) *spec.Swagger {
+	t.Helper()
+	raw, err := os.ReadFile("swagger.json")
+	require.NoError(t, err, "run `make swag` to generate api/docs")
+
+	var doc spec.Swagger
+	require.NoError(t, json.Unmarshal(raw, &doc))
+	return &doc
+}
+
+func operations(item spec.PathItem) map[string]*spec.Operation {
+	ops := map[string]*spec.Operation{}
+	for method, op := range map[string]*spec.Operation{
+		"get": item.Get, "post": item.Post, "put": item.Put, "patch": item.Patch, "delete": item.Delete,
+	} {
+		if op != nil {
+			ops[method] = op
+		}
+	}
+	return ops
+}
+
+func requiresBearer(op *spec.Operation) bool {
+	for _, requirement := range op.Security {
+		if _, ok := requirement["BearerAuth"]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+func TestSwagger_RegisteredDocMatchesFile(t *testing.T) {
+	raw, err := swag.ReadDoc()
+	require.NoError(t, err)
+
+	var registered spec.Swagger
+	require.NoError(t, json.Unmarshal([]byte(raw), &registered))
+	assert.Equal(t, len(loadSpec(t).Paths.Paths), len(registered.Paths.Paths), "docs.go and swagger.json are out of sync")
+}
+
+func TestSwagger_AuthEndpointsDocumented(t *testing.T) {
+	doc := loadSpec(t)
+	require.Contains(t, doc.SecurityDefinitions, "BearerAuth")
+
+	tests := []struct {
+		path         string
+		method       string
+		secured      bool
+		requestBody  bool
+		responseCode int
+	}{
+		{path: "/api/v1/auth/register", method: "post", requestBody: true, responseCode: 200},
+		{path: "/api/v1/auth/login", method: "post", requestBody: true, responseCode: 200},
+		{path: "/api/v1/auth/refresh", method: "post", requestBody: true, responseCode: 200},
+		{path: "/api/v1/auth/logout", method: "post", secured: true, requestBody: true, responseCode: 200},
+		{path: "/api/v1/auth/logout-all", method: "post", secured: true, responseCode: 200},
+		{path: "/api/v1/auth/me", method: "get", secured: true, responseCode: 200},
+		{path: "/api/v1/auth/me", method: "patch", secured: true, requestBody: true, responseCode: 200},
+		{path: "/api/v1/auth/me", method: "delete", secured: true, requestBody: true, responseCode: 204},
+		{path: "/api/v1/auth/me/permissions", method: "get", secured: true, responseCode: 200},
+	}
+
+	for _, tt := range tests {
+		t.Run(strings.ToUpper(tt.method)+" "+tt.path, func(t *testing.T) {
+			item, ok := doc.Paths.Paths[tt.path]
+			require.True(t, ok, "path not documented")
+			op := operations(item)[tt.method]
+			require.NotNil(t, op, "method not documented")
+
+			assert.Equal(t, tt.secured, requiresBearer(op), "security requirement")
+			if tt.secured {
+				assert.Contains(t, op.Responses.StatusCodeResponses, 401, "secured endpoints document 401")
+			}
+
+			var body *spec.Parameter
+			for i := range op.Parameters {
+				if op.Parameters[i].In == "body" {
+					body = &op.Parameters[i]
+				}
+			}
+			if tt.requestBody {
+				require.NotNil(t, body, "request body schema")
+				assert.NotEmpty(t, body.Schema.Ref.String())
+			}
+
+			success, ok := op.Responses.StatusCodeResponses[tt.responseCode]
+			require.True(t, ok, "success response %d", tt.responseCode)
+			if tt.responseCode != 204 {
+				assert.NotNil(t, success.Schema, "success response schema")
+			}
+		})
+	}
+}
+
+var pathParam = regexp.MustCompile(`\{([^}]+)\}`)
+
+func TestSwagger_OperationsAreComplete(t *testing.T) {
+	doc := loadSpec(t)
+
+	for path, item := range doc.Paths.Paths {
+		for method, op := range operations(item) {
+			name := strings.ToUpper(method) + " " + path
+
+			assert.NotEmpty(t, op.Summary, "%s: summary", name)
+			assert.NotEmpty(t, op.Responses.StatusCodeResponses, "%s: responses", name)
+
+			declared := map[string]bool{}
+			for _, p := range op.Parameters {
+				if p.In == "path" {
+					declared[p.Name] = true
+				}
+			}
+			for _, m := range pathParam.FindAllStringSubmatch(path, -1) {
+				assert.True(t, declared[m[1]], "%s: path parameter %q not declared", name, m[1])
+			}
+
+			if strings.HasPrefix(path, "/api/v1/admin/") {
+				assert.True(t, requiresBearer(op), "%s: admin endpoints require BearerAuth", name)
+			}
+		}
+	}
+}
+
+func TestSwagger_ReferencesResolve(t *testing.T) {
+	raw, err := os.ReadFile("swagger.json")
+	require.NoError(t, err)
+
+	refs := regexp.MustCompile(`"\$ref":\s*"#/definitions/([^"]+)"`).FindAllStringSubmatch(string(raw), -1)
+	require.NotEmpty(t, refs)
+
+	doc := loadSpec(t)
+	for _, ref := range refs {
+		assert.Contains(t, doc.Definitions, ref[1], "unresolved $ref %s", ref[1])
+	}
+}
@@ -3,21 +3,129 @@ package main
 import (
 	"bufio"
 	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"log"
+	"log/slog"
 	"os"
 	"regexp"
 	"strings"
 
 	"golang.org/x/term"
-	"gorm.io/driver/postgres"
-	"gorm.io/gorm"
 
+	"github.com/yeegeek/uyou-go-api-starter/internal/buildinfo"
 	"github.com/yeegeek/uyou-go-api-starter/internal/config"
+	"github.com/yeegeek/uyou-go-api-starter/internal/db"
 	"github.com/yeegeek/uyou-go-api-starter/internal/user"
 )
 
+// 退出码，供 CI 和基础设施脚本区分失败原因
+const (
+	exitOK         = 0
+	exitFailure    = 1 // 配置加载失败、用户取消等其他错误
+	exitValidation = 2 // 参数或输入校验失败，或 --promote 指定的用户不存在
+	exitUserExists = 3 // 邮箱已被占用（--if-not-exists 时仅当该用户不是管理员）
+	exitDatabase   = 4 // 数据库连接或读写失败
+)
+
+var (
+	errValidation = errors.New("validation failed")
+	errAborted    = errors.New("aborted by user")
+)
+
+// options 命令行参数；指定 --email、--name 或密码来源任一项即进入非交互模式
+type options struct {
+	promoteID     int
+	email         string
+	name          string
+	passwordStdin bool
+	passwordEnv   string
+	yes           bool
+	ifNotExists   bool
+}
+
+func (o options) nonInteractive() bool {
+	return o.email != "" || o.name != "" || o.passwordStdin || o.passwordEnv != ""
+}
+
+func parseFlags(args []string) (options, error) {
+	var opts options
+	fs := flag.NewFlagSet("createadmin", flag.ContinueOnError)
+	fs.IntVar(&opts.promoteID, "promote", 0, "Promote existing user ID to admin")
+	fs.StringVar(&opts.email, "email", "", "Admin email (non-interactive mode)")
+	fs.StringVar(&opts.name, "name", "", "Admin name (non-interactive mode)")
+	fs.BoolVar(&opts.passwordStdin, "password-stdin", false, "Read the admin password from the first line of stdin")
+	fs.StringVar(&opts.passwordEnv, "password-env", "", "Read the admin password from the named environment variable")
+	fs.BoolVar(&opts.yes, "yes", false, "Skip confirmation prompts")
+	fs.BoolVar(&opts.ifNotExists, "if-not-exists", false, "Exit 0 if the email already belongs to an admin")
+	if err := fs.Parse(args); err != nil {
+		return options{}, validationError(err.Error())
+	}
+	if fs.NArg() > 0 {
+		return options{}, validationError(fmt.Sprintf("unexpected arguments: %s", strings.Join(fs.Args(), " ")))
+	}
+	return opts, nil
+}
+
+func validationError(msg string) error {
+	return fmt.Errorf("%w: %s", errValidation, msg)
+}
+
+// exitCodeFor 将错误映射为退出码，未识别的错误按数据库错误处理
+func exitCodeFor(err error) int {
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.Is(err, errValidation), errors.Is(err, user.ErrUserNotFound):
+		return exitValidation
+	case errors.Is(err, user.ErrEmailExists):
+		return exitUserExists
+	case errors.Is(err, errAborted):
+		return exitFailure
+	default:
+		return exitDatabase
+	}
+}
+
+// resolvePassword 从 stdin 第一行或环境变量读取密码，二者必须且只能指定一个
+// 不提供 --password 参数，避免密码出现在 ps 输出和 shell 历史中
+func resolvePassword(opts options, stdin io.Reader, getenv func(string) string) (string, error) {
+	switch {
+	case opts.passwordStdin && opts.passwordEnv != "":
+		return "", validationError("--password-stdin and --password-env are mutually exclusive")
+	case opts.passwordStdin:
+		line, err := bufio.NewReader(stdin).ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return "", fmt.Errorf("failed to read password from stdin: %w", err)
+		}
+		return strings.TrimSpace(line), nil
+	case opts.passwordEnv != "":
+		password := getenv(opts.passwordEnv)
+		if password == "" {
+			return "", validationError(fmt.Sprintf("environment variable %s is empty or not set", opts.passwordEnv))
+		}
+		return password, nil
+	default:
+		return "", validationError("non-interactive mode requires --password-stdin or --password-env")
+	}
+}
+
+// validateAdminInput 校验非交互模式下的邮箱、姓名和密码
+func validateAdminInput(email, name, password string) error {
+	if err := validateEmail(email); err != nil {
+		return validationError(fmt.Sprintf("invalid email: %v", err))
+	}
+	if err := validateName(name); err != nil {
+		return validationError(fmt.Sprintf("invalid name: %v", err))
+	}
+	if err := validatePassword(password); err != nil {
+		return validationError(fmt.Sprintf("invalid password: %v", err))
+	}
+	return nil
+}
+
 var emailRegex = regexp.MustCompile(`^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`)
 
 func validateEmail(email string) error {
@@ -86,24 +194,43 @@ func registerAndPromoteUser(ctx context.Context, service user.Service, email, pa
 }
 
 func main() {
-	promoteID := flag.Int("promote", 0, "Promote existing user ID to admin")
-	flag.Parse()
+	slog.Info("Starting createadmin", buildinfo.Get().Attrs()...)
+	os.Exit(run(os.Args[1:]))
+}
 
-	cfg, err := config.LoadConfig("")
+func run(args []string) int {
+	opts, err := parseFlags(args)
 	if err != nil {
-		log.Fatalf("Failed to load config: %v", err)
+		log.Printf("Error: %v", err)
+		return exitCodeFor(err)
 	}
 
-	dsn := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
-		cfg.Database.Host, cfg.Database.Port, cfg.Database.User,
-		cfg.Database.Password, cfg.Database.Name, cfg.Database.SSLMode)
+	// 先校验参数和读取密码，参数错误时无需连接数据库
+	var password string
+	if opts.promoteID == 0 && opts.nonInteractive() {
+		if password, err = resolvePassword(opts, os.Stdin, os.Getenv); err == nil {
+			err = validateAdminInput(opts.email, opts.name, password)
+		}
+		if err != nil {
+			log.Printf("Error: %v", err)
+			return exitCodeFor(err)
+		}
+	}
 
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	cfg, err := config.LoadConfig("")
 	if err != nil {
-		log.Fatalf("Failed to connect to database: %v", err)
+		log.Printf("Failed to load config: %v", err)
+		return exitFailure
 	}
 
-	repo := user.NewRepository(db)
+	// 与服务端使用同一连接逻辑，SSL 和连接池配置保持一致
+	database, err := db.NewPostgresDBFromDatabaseConfig(cfg.Database)
+	if err != nil {
+		log.Printf("Failed to connect to database: %v", err)
+		return exitDatabase
+	}
+
+	repo := user.NewRepository(database)
 	// 使用默认安全配置
 	securityCfg := &config.SecurityConfig{
 		BcryptCost:            12,
@@ -119,20 +246,78 @@ func main() {
 
 	ctx := context.Background()
 
-	if *promoteID > 0 {
-		promoteExistingUser(ctx, service, uint(*promoteID))
-	} else {
-		createNewAdmin(ctx, service)
+	switch {
+	case opts.promoteID > 0:
+		err = promoteUserToAdmin(ctx, service, uint(opts.promoteID))
+	case opts.nonInteractive():
+		err = createAdminNonInteractive(ctx, service, repo.FindByEmail, opts, password)
+	default:
+		err = createNewAdmin(ctx, service, opts)
+	}
+	if err != nil {
+		log.Printf("Error: %v", err)
 	}
+	return exitCodeFor(err)
 }
 
-func promoteExistingUser(ctx context.Context, service user.Service, userID uint) {
-	if err := promoteUserToAdmin(ctx, service, userID); err != nil {
-		log.Fatalf("Error: %v", err)
+// createAdminNonInteractive 使用命令行参数创建管理员，供 Terraform/Ansible 等引导脚本调用
+// 未指定 --yes 时需要终端确认；密码从 stdin 读取时 stdin 已被占用，必须指定 --yes
+func createAdminNonInteractive(ctx context.Context, service user.Service, findByEmail func(context.Context, string) (*user.User, error), opts options, password string) error {
+	if !opts.yes {
+		if opts.passwordStdin || !term.IsTerminal(int(os.Stdin.Fd())) {
+			return validationError("confirmation required; pass --yes to run without a terminal")
+		}
+		if !confirm(bufio.NewReader(os.Stdin), fmt.Sprintf("Create admin user %s (%s)?", opts.name, opts.email)) {
+			return errAborted
+		}
+	}
+
+	newUser, err := ensureAdmin(ctx, service, findByEmail, opts.email, password, opts.name, opts.ifNotExists)
+	if err != nil {
+		return err
 	}
+	if newUser == nil {
+		fmt.Printf("Admin user %s already exists\n", opts.email)
+		return nil
+	}
+	printCreatedAdmin(newUser)
+	return nil
 }
 
-func createNewAdmin(ctx context.Context, service user.Service) {
+// ensureAdmin 注册并提升管理员；ifNotExists 为 true 且该邮箱已是管理员时返回 nil, nil，使引导脚本可重复执行
+// 邮箱已存在但不是管理员时仍返回 user.ErrEmailExists，避免静默接管普通用户账号
+func ensureAdmin(ctx context.Context, service user.Service, findByEmail func(context.Context, string) (*user.User, error), email, password, name string, ifNotExists bool) (*user.User, error) {
+	newUser, err := registerAndPromoteUser(ctx, service, email, password, name)
+	if err == nil || !ifNotExists || !errors.Is(err, user.ErrEmailExists) {
+		return newUser, err
+	}
+
+	existing, findErr := findByEmail(ctx, email)
+	if findErr != nil {
+		return nil, fmt.Errorf("failed to find existing user: %w", findErr)
+	}
+	if existing == nil || !existing.IsAdmin() {
+		return nil, fmt.Errorf("user %s exists but is not an admin (use --promote): %w", email, user.ErrEmailExists)
+	}
+	return nil, nil
+}
+
+func confirm(reader *bufio.Reader, prompt string) bool {
+	fmt.Printf("%s [y/N]: ", prompt)
+	answer, _ := reader.ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+func printCreatedAdmin(newUser *user.User) {
+	fmt.Printf("\nAdmin user created successfully:\n")
+	fmt.Printf("ID: %d\n", newUser.ID)
+	fmt.Printf("Email: %s\n", newUser.Email)
+	fmt.Printf("Name: %s\n", newUser.Name)
+	fmt.Printf("Roles: admin, user\n")
+}
+
+func createNewAdmin(ctx context.Context, service user.Service, opts options) error {
 	reader := bufio.NewReader(os.Stdin)
 
 	fmt.Print("Enter admin email: ")
@@ -140,7 +325,7 @@ func createNewAdmin(ctx context.Context, service user.Service) {
 	email = strings.TrimSpace(email)
 
 	if err := validateEmail(email); err != nil {
-		log.Fatalf("Invalid email: %v", err)
+		return validationError(fmt.Sprintf("invalid email: %v", err))
 	}
 
 	fmt.Print("Enter admin name: ")
@@ -148,7 +333,7 @@ func createNewAdmin(ctx context.Context, service user.Service) {
 	name = strings.TrimSpace(name)
 
 	if err := validateName(name); err != nil {
-		log.Fatalf("Invalid name: %v", err)
+		return validationError(fmt.Sprintf("invalid name: %v", err))
 	}
 
 	fmt.Println("\nPassword requirements:")
@@ -161,24 +346,25 @@ func createNewAdmin(ctx context.Context, service user.Service) {
 
 	password := readPassword("Enter admin password: ")
 	if err := validatePassword(password); err != nil {
-		log.Fatalf("Invalid password: %v", err)
+		return validationError(fmt.Sprintf("invalid password: %v", err))
 	}
 
 	confirmPassword := readPassword("Confirm password: ")
 	if err := checkPasswordsMatch(password, confirmPassword); err != nil {
-		log.Fatalf("Password mismatch: %v", err)
+		return validationError(err.Error())
+	}
+
+	if !opts.yes && !confirm(reader, fmt.Sprintf("Create admin user %s (%s)?", name, email)) {
+		return errAborted
 	}
 
 	newUser, err := registerAndPromoteUser(ctx, service, email, password, name)
 	if err != nil {
-		log.Fatalf("Error: %v", err)
+		return err
 	}
 
-	fmt.Printf("\nAdmin user created successfully:\n")
-	fmt.Printf("ID: %d\n", newUser.ID)
-	fmt.Printf("Email: %s\n", newUser.Email)
-	fmt.Printf("Name: %s\n", newUser.Name)
-	fmt.Printf("Roles: admin, user\n")
+	printCreatedAdmin(newUser)
+	return nil
 }
 
 func readPassword(prompt string) string {
@@ -2,69 +2,18 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 
+	"github.com/yeegeek/uyou-go-api-starter/internal/testutil/usertest"
 	"github.com/yeegeek/uyou-go-api-starter/internal/user"
 )
 
-type MockService struct {
-	mock.Mock
-}
-
-func (m *MockService) RegisterUser(ctx context.Context, req user.RegisterRequest) (*user.User, error) {
-	args := m.Called(ctx, req)
-	if args.Get(0) == nil {
-		return nil, args.Error(1)
-	}
-	return args.Get(0).(*user.User), args.Error(1)
-}
-
-func (m *MockService) AuthenticateUser(ctx context.Context, req user.LoginRequest) (*user.User, error) {
-	args := m.Called(ctx, req)
-	if args.Get(0) == nil {
-		return nil, args.Error(1)
-	}
-	return args.Get(0).(*user.User), args.Error(1)
-}
-
-func (m *MockService) GetUserByID(ctx context.Context, id uint) (*user.User, error) {
-	args := m.Called(ctx, id)
-	if args.Get(0) == nil {
-		return nil, args.Error(1)
-	}
-	return args.Get(0).(*user.User), args.Error(1)
-}
-
-func (m *MockService) UpdateUser(ctx context.Context, id uint, req user.UpdateUserRequest) (*user.User, error) {
-	args := m.Called(ctx, id, req)
-	if args.Get(0) == nil {
-		return nil, args.Error(1)
-	}
-	return args.Get(0).(*user.User), args.Error(1)
-}
-
-func (m *MockService) DeleteUser(ctx context.Context, id uint) error {
-	args := m.Called(ctx, id)
-	return args.Error(0)
-}
-
-func (m *MockService) ListUsers(ctx context.Context, filters user.UserFilterParams, page, perPage int) ([]user.User, int64, error) {
-	args := m.Called(ctx, filters, page, perPage)
-	if args.Get(0) == nil {
-		return nil, args.Get(1).(int64), args.Error(2)
-	}
-	return args.Get(0).([]user.User), args.Get(1).(int64), args.Error(2)
-}
-
-func (m *MockService) PromoteToAdmin(ctx context.Context, userID uint) error {
-	args := m.Called(ctx, userID)
-	return args.Error(0)
-}
-
 func TestValidatePassword(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -759,20 +708,15 @@ func TestPromoteUserToAdmin(t *testing.T) {
 	tests := []struct {
 		name      string
 		userID    uint
-		setupMock func(*MockService)
+		setupMock func(*usertest.MockService)
 		wantErr   bool
 		errMsg    string
 	}{
 		{
 			name:   "successful promotion",
 			userID: 1,
-			setupMock: func(ms *MockService) {
-				existingUser := &user.User{
-					ID:    1,
-					Email: "user@example.com",
-					Name:  "Test User",
-					Roles: []user.Role{{ID: 2, Name: "user"}},
-				}
+			setupMock: func(ms *usertest.MockService) {
+				existingUser := usertest.NewUser().WithID(1).WithEmail("user@example.com").Build()
 				ms.On("GetUserByID", mock.Anything, uint(1)).Return(existingUser, nil)
 				ms.On("PromoteToAdmin", mock.Anything, uint(1)).Return(nil)
 			},
@@ -781,7 +725,7 @@ func TestPromoteUserToAdmin(t *testing.T) {
 		{
 			name:   "user not found",
 			userID: 999,
-			setupMock: func(ms *MockService) {
+			setupMock: func(ms *usertest.MockService) {
 				ms.On("GetUserByID", mock.Anything, uint(999)).Return(nil, fmt.Errorf("user not found"))
 			},
 			wantErr: true,
@@ -790,13 +734,8 @@ func TestPromoteUserToAdmin(t *testing.T) {
 		{
 			name:   "user already admin",
 			userID: 2,
-			setupMock: func(ms *MockService) {
-				adminUser := &user.User{
-					ID:    2,
-					Email: "admin@example.com",
-					Name:  "Admin User",
-					Roles: []user.Role{{ID: 1, Name: "admin"}},
-				}
+			setupMock: func(ms *usertest.MockService) {
+				adminUser := usertest.NewUser().WithID(2).WithEmail("admin@example.com").WithName("Admin User").WithRole(user.RoleAdmin).Build()
 				ms.On("GetUserByID", mock.Anything, uint(2)).Return(adminUser, nil)
 			},
 			wantErr: false,
@@ -804,13 +743,8 @@ func TestPromoteUserToAdmin(t *testing.T) {
 		{
 			name:   "promotion fails",
 			userID: 3,
-			setupMock: func(ms *MockService) {
-				existingUser := &user.User{
-					ID:    3,
-					Email: "user@example.com",
-					Name:  "Test User",
-					Roles: []user.Role{{ID: 2, Name: "user"}},
-				}
+			setupMock: func(ms *usertest.MockService) {
+				existingUser := usertest.NewUser().WithID(3).WithEmail("user@example.com").Build()
 				ms.On("GetUserByID", mock.Anything, uint(3)).Return(existingUser, nil)
 				ms.On("PromoteToAdmin", mock.Anything, uint(3)).Return(fmt.Errorf("database error"))
 			},
@@ -821,7 +755,7 @@ func TestPromoteUserToAdmin(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			mockService := new(MockService)
+			mockService := new(usertest.MockService)
 			tt.setupMock(mockService)
 
 			err := promoteUserToAdmin(context.Background(), mockService, tt.userID)
@@ -846,7 +780,7 @@ func TestRegisterAndPromoteUser(t *testing.T) {
 		email     string
 		password  string
 		userName  string
-		setupMock func(*MockService)
+		setupMock func(*usertest.MockService)
 		wantErr   bool
 		errMsg    string
 	}{
@@ -855,7 +789,7 @@ func TestRegisterAndPromoteUser(t *testing.T) {
 			email:    "newadmin@example.com",
 			password: "Password123!",
 			userName: "New Admin",
-			setupMock: func(ms *MockService) {
+			setupMock: func(ms *usertest.MockService) {
 				newUser := &user.User{
 					ID:    1,
 					Email: "newadmin@example.com",
@@ -875,7 +809,7 @@ func TestRegisterAndPromoteUser(t *testing.T) {
 			email:    "exists@example.com",
 			password: "Password123!",
 			userName: "Existing User",
-			setupMock: func(ms *MockService) {
+			setupMock: func(ms *usertest.MockService) {
 				ms.On("RegisterUser", mock.Anything, mock.Anything).Return(nil, fmt.Errorf("email already exists"))
 			},
 			wantErr: true,
@@ -886,7 +820,7 @@ func TestRegisterAndPromoteUser(t *testing.T) {
 			email:    "newuser@example.com",
 			password: "Password123!",
 			userName: "New User",
-			setupMock: func(ms *MockService) {
+			setupMock: func(ms *usertest.MockService) {
 				newUser := &user.User{
 					ID:    2,
 					Email: "newuser@example.com",
@@ -902,7 +836,7 @@ func TestRegisterAndPromoteUser(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			mockService := new(MockService)
+			mockService := new(usertest.MockService)
 			tt.setupMock(mockService)
 
 			result, err := registerAndPromoteUser(context.Background(), mockService, tt.email, tt.password, tt.userName)
@@ -924,3 +858,127 @@ func TestRegisterAndPromoteUser(t *testing.T) {
 		})
 	}
 }
+
+func TestParseFlags(t *testing.T) {
+	opts, err := parseFlags([]string{"--email", "admin@example.com", "--name", "Admin", "--password-env", "ADMIN_PASSWORD", "--yes", "--if-not-exists"})
+	assert.NoError(t, err)
+	assert.Equal(t, options{email: "admin@example.com", name: "Admin", passwordEnv: "ADMIN_PASSWORD", yes: true, ifNotExists: true}, opts)
+	assert.True(t, opts.nonInteractive())
+
+	opts, err = parseFlags([]string{"--promote=12"})
+	assert.NoError(t, err)
+	assert.Equal(t, 12, opts.promoteID)
+	assert.False(t, opts.nonInteractive())
+
+	_, err = parseFlags([]string{"--password", "Secret123!"})
+	assert.Equal(t, exitValidation, exitCodeFor(err))
+
+	_, err = parseFlags([]string{"--email", "admin@example.com", "extra"})
+	assert.Equal(t, exitValidation, exitCodeFor(err))
+}
+
+func TestResolvePassword(t *testing.T) {
+	env := map[string]string{"ADMIN_PASSWORD": "EnvPass123!"}
+	getenv := func(key string) string { return env[key] }
+
+	tests := []struct {
+		name     string
+		opts     options
+		stdin    string
+		want     string
+		wantCode int
+	}{
+		{name: "stdin first line", opts: options{passwordStdin: true}, stdin: "StdinPass123!\nignored\n", want: "StdinPass123!"},
+		{name: "stdin without newline", opts: options{passwordStdin: true}, stdin: "StdinPass123!", want: "StdinPass123!"},
+		{name: "stdin with CRLF", opts: options{passwordStdin: true}, stdin: "StdinPass123!\r\n", want: "StdinPass123!"},
+		{name: "environment variable", opts: options{passwordEnv: "ADMIN_PASSWORD"}, want: "EnvPass123!"},
+		{name: "unset environment variable", opts: options{passwordEnv: "MISSING"}, wantCode: exitValidation},
+		{name: "both sources", opts: options{passwordStdin: true, passwordEnv: "ADMIN_PASSWORD"}, wantCode: exitValidation},
+		{name: "no source", opts: options{email: "admin@example.com"}, wantCode: exitValidation},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolvePassword(tt.opts, strings.NewReader(tt.stdin), getenv)
+			assert.Equal(t, tt.wantCode, exitCodeFor(err))
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestValidateAdminInput(t *testing.T) {
+	assert.NoError(t, validateAdminInput("admin@example.com", "Admin", "Password123!"))
+
+	for _, input := range [][3]string{
+		{"not-an-email", "Admin", "Password123!"},
+		{"admin@example.com", "", "Password123!"},
+		{"admin@example.com", "Admin", "weak"},
+	} {
+		err := validateAdminInput(input[0], input[1], input[2])
+		assert.Equal(t, exitValidation, exitCodeFor(err), input)
+	}
+}
+
+func TestEnsureAdmin(t *testing.T) {
+	adminUser := usertest.NewUser().WithID(7).WithEmail("admin@example.com").WithName("Admin").WithRole(user.RoleAdmin).Build()
+	regularUser := usertest.NewUser().WithID(8).WithEmail("admin@example.com").WithName("Admin").Build()
+
+	tests := []struct {
+		name        string
+		ifNotExists bool
+		registerErr error
+		existing    *user.User
+		findErr     error
+		wantCreated bool
+		wantCode    int
+	}{
+		{name: "creates admin", wantCreated: true, wantCode: exitOK},
+		{name: "existing user without if-not-exists", registerErr: user.ErrEmailExists, wantCode: exitUserExists},
+		{name: "existing admin with if-not-exists", ifNotExists: true, registerErr: user.ErrEmailExists, existing: adminUser, wantCode: exitOK},
+		{name: "existing non-admin with if-not-exists", ifNotExists: true, registerErr: user.ErrEmailExists, existing: regularUser, wantCode: exitUserExists},
+		{name: "lookup fails", ifNotExists: true, registerErr: user.ErrEmailExists, findErr: errors.New("connection refused"), wantCode: exitDatabase},
+		{name: "database error", ifNotExists: true, registerErr: errors.New("connection refused"), wantCode: exitDatabase},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(usertest.MockService)
+			if tt.registerErr != nil {
+				mockService.On("RegisterUser", mock.Anything, mock.Anything).Return(nil, tt.registerErr)
+			} else {
+				mockService.On("RegisterUser", mock.Anything, mock.Anything).Return(&user.User{ID: 1, Email: "admin@example.com", Name: "Admin"}, nil)
+				mockService.On("PromoteToAdmin", mock.Anything, uint(1)).Return(nil)
+			}
+			findByEmail := func(ctx context.Context, email string) (*user.User, error) {
+				assert.Equal(t, "admin@example.com", email)
+				return tt.existing, tt.findErr
+			}
+
+			created, err := ensureAdmin(context.Background(), mockService, findByEmail, "admin@example.com", "Password123!", "Admin", tt.ifNotExists)
+
+			assert.Equal(t, tt.wantCode, exitCodeFor(err))
+			assert.Equal(t, tt.wantCreated, created != nil)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestCreateAdminNonInteractive_RequiresYesWithoutTerminal(t *testing.T) {
+	mockService := new(usertest.MockService)
+	opts := options{email: "admin@example.com", name: "Admin", passwordStdin: true}
+
+	err := createAdminNonInteractive(context.Background(), mockService, nil, opts, "Password123!")
+
+	assert.Equal(t, exitValidation, exitCodeFor(err))
+	assert.Contains(t, err.Error(), "--yes")
+	mockService.AssertNotCalled(t, "RegisterUser", mock.Anything, mock.Anything)
+}
+
+func TestExitCodeFor(t *testing.T) {
+	assert.Equal(t, exitOK, exitCodeFor(nil))
+	assert.Equal(t, exitValidation, exitCodeFor(validationError("bad input")))
+	assert.Equal(t, exitValidation, exitCodeFor(user.ErrUserNotFound))
+	assert.Equal(t, exitUserExists, exitCodeFor(fmt.Errorf("failed to create user: %w", user.ErrEmailExists)))
+	assert.Equal(t, exitFailure, exitCodeFor(errAborted))
+	assert.Equal(t, exitDatabase, exitCodeFor(errors.New("connection refused")))
+}
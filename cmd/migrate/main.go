@@ -9,7 +9,11 @@ import (
 	"strconv"
 	"time"
 
+	"gorm.io/gorm"
+
+	"github.com/yeegeek/uyou-go-api-starter/internal/buildinfo"
 	"github.com/yeegeek/uyou-go-api-starter/internal/config"
+	"github.com/yeegeek/uyou-go-api-starter/internal/configcheck"
 	"github.com/yeegeek/uyou-go-api-starter/internal/db"
 	"github.com/yeegeek/uyou-go-api-starter/internal/migrate"
 )
@@ -27,6 +31,16 @@ func main() {
 	}
 
 	command := args[0]
+	slog.Info("Starting migrate", append([]any{"command", command}, buildinfo.Get().Attrs()...)...)
+
+	// configcheck 自行加载配置并连接依赖，失败时也要输出完整报告
+	if command == "configcheck" {
+		report := configcheck.Run(context.Background(), "")
+		if err := report.Write(os.Stdout); err != nil {
+			slog.Error("Failed to write config check report", "err", err)
+		}
+		os.Exit(report.ExitCode())
+	}
 
 	cfg, err := config.LoadConfig("")
 	if err != nil {
@@ -105,6 +119,8 @@ func main() {
 		handleDrop(migrator, *forceFlag)
 	case "create":
 		handleCreate(cfg.Migrations.Directory, args)
+	case "data-migrate":
+		handleDataMigrate(ctx, database, cfg.Migrations.GetDataBatchSize(), args)
 	default:
 		slog.Error("Unknown command", "command", command)
 		printUsage()
@@ -297,6 +313,49 @@ COMMIT;
 	slog.Info("Migration files created", "up", upFile, "down", downFile)
 }
 
+func handleDataMigrate(ctx context.Context, database *gorm.DB, defaultBatchSize int, args []string) {
+	if len(args) < 2 {
+		fmt.Println("Usage: migrate data-migrate NAME [--batch-size=N] [--dry-run] [--pause=DURATION]")
+		fmt.Println("\nRegistered data migrations:")
+		for _, m := range migrate.DataMigrations() {
+			fmt.Printf("  %-24s %s\n", m.Name(), m.Description())
+		}
+		os.Exit(1)
+	}
+
+	fs := flag.NewFlagSet("data-migrate", flag.ExitOnError)
+	batchSize := fs.Int("batch-size", defaultBatchSize, "Rows per batch transaction")
+	dryRun := fs.Bool("dry-run", false, "Only report the number of rows that would change")
+	pause := fs.Duration("pause", 0, "Sleep between batches (e.g., 100ms)")
+	if err := fs.Parse(args[2:]); err != nil {
+		os.Exit(1)
+	}
+
+	m, err := migrate.LookupDataMigration(args[1])
+	if err != nil {
+		slog.Error("Unknown data migration", "name", args[1])
+		os.Exit(1)
+	}
+
+	result, err := migrate.RunDataMigration(ctx, database, m, migrate.DataMigrationOptions{
+		BatchSize: *batchSize,
+		DryRun:    *dryRun,
+		Pause:     *pause,
+	})
+	if err != nil {
+		slog.Error("Data migration error", "err", err)
+		fmt.Println("\nRe-run the same command to resume from the last committed batch")
+		os.Exit(1)
+	}
+
+	if result.DryRun {
+		fmt.Printf("Dry run: %d row(s) would be changed by %s\n", result.Pending, result.Name)
+		return
+	}
+	fmt.Printf("Data migration %s: %d row(s) changed, %d skipped in %d batch(es)\n",
+		result.Name, result.Affected, result.Skipped, result.Batches)
+}
+
 func printUsage() {
 	fmt.Println("Usage: migrate COMMAND [args] [flags]")
 	fmt.Println("")
@@ -308,6 +367,9 @@ func printUsage() {
 	fmt.Println("  force VERSION    Force set migration version (recovery)")
 	fmt.Println("  drop             Drop all tables (requires confirmation)")
 	fmt.Println("  create NAME      Create new migration files")
+	fmt.Println("  data-migrate NAME [--batch-size=N] [--dry-run] [--pause=DURATION]")
+	fmt.Println("                   Run a batched, resumable data migration (no NAME lists them)")
+	fmt.Println("  configcheck      Validate config, database, redis, rabbitmq and migrations; print a JSON report")
 	fmt.Println("")
 	fmt.Println("Flags:")
 	fmt.Println("  --timeout DURATION        Override migration timeout (e.g., 5m, 30s, 1h)")
@@ -321,5 +383,7 @@ func printUsage() {
 	fmt.Println("  migrate goto 5")
 	fmt.Println("  migrate version")
 	fmt.Println("  migrate create add_user_avatar")
+	fmt.Println("  migrate data-migrate lowercase_user_emails --dry-run")
+	fmt.Println("  migrate configcheck")
 	fmt.Println("  migrate up --timeout=30m --lock-timeout=1m")
 }
@@ -6,10 +6,19 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
+	"github.com/gin-gonic/gin"
+
+	"github.com/yeegeek/uyou-go-api-starter/internal/audit"
+	"github.com/yeegeek/uyou-go-api-starter/internal/auth"
+	"github.com/yeegeek/uyou-go-api-starter/internal/buildinfo"
 	"github.com/yeegeek/uyou-go-api-starter/internal/config"
+	"github.com/yeegeek/uyou-go-api-starter/internal/db"
+	"github.com/yeegeek/uyou-go-api-starter/internal/middleware"
 	"github.com/yeegeek/uyou-go-api-starter/internal/scheduler"
 	"github.com/yeegeek/uyou-go-api-starter/internal/scheduler/tasks"
+	"github.com/yeegeek/uyou-go-api-starter/internal/user"
 )
 
 func main() {
@@ -25,10 +34,18 @@ func main() {
 		Level: slog.LevelInfo,
 	}))
 
-	logger.Info("定时任务调度器启动中...",
+	logger.Info("定时任务调度器启动中...", append([]any{
 		"app_name", cfg.App.Name,
 		"environment", cfg.App.Environment,
-	)
+	}, buildinfo.Get().Attrs()...)...)
+
+	// 连接数据库，供清理任务删除过期数据
+	database, err := db.NewPostgresDBFromDatabaseConfig(cfg.Database)
+	if err != nil {
+		logger.Error("连接数据库失败", "error", err)
+		os.Exit(1)
+	}
+	userRepo := user.NewRepository(database)
 
 	// 创建任务管理器
 	manager := scheduler.NewManager(cfg, logger)
@@ -41,14 +58,20 @@ func main() {
 			Task: tasks.NewHelloWorldTask(logger),
 		},
 		{
-			// 每小时执行一次清理任务
-			Spec: "0 0 */1 * * *",
-			Task: tasks.NewCleanupTask(logger),
+			// 每小时执行一次清理任务，连续失败会使就绪探针失败
+			Spec:     "0 0 */1 * * *",
+			Critical: true,
+			Timeout:  10 * time.Minute,
+			Task: tasks.NewCleanupTask(logger,
+				tasks.WithPruner("login_attempts", user.LoginHistoryPruner(userRepo, cfg.Security.GetLoginHistoryRetention())),
+				tasks.WithPruner("orphaned_user_roles", user.OrphanedRoleAssignmentsPruner(userRepo)),
+			),
 		},
 		{
 			// 每天凌晨 2 点执行统计任务
-			Spec: "0 0 2 * * *",
-			Task: tasks.NewStatisticsTask(logger),
+			Spec:    "0 0 2 * * *",
+			Timeout: 30 * time.Minute,
+			Task:    tasks.NewStatisticsTask(logger),
 		},
 	}
 
@@ -57,6 +80,29 @@ func main() {
 		os.Exit(1)
 	}
 
+	// 管理接口（手动执行任务、查看执行记录）与 API 服务的管理员接口使用相同的 IP 白名单、令牌校验、权限和审计日志
+	adminAllowlist, err := middleware.IPAllowlist(cfg.Security.AdminIPAllowlistFor(cfg.App.Environment))
+	if err != nil {
+		logger.Error("解析管理员 IP 白名单失败", "error", err)
+		os.Exit(1)
+	}
+	authService := auth.NewServiceWithRepo(&cfg.JWT, database)
+	manager.EnableAdminAPI(
+		adminAllowlist,
+		auth.AuthMiddleware(authService),
+		middleware.RequirePermission(user.PermissionAdminAll),
+		middleware.AdminAudit(audit.NewRecorder(audit.NewRepository(database))),
+	)
+
+	// 启动健康检查服务，供编排系统探测调度器进程
+	if cfg.App.Environment == "production" {
+		gin.SetMode(gin.ReleaseMode)
+	}
+	if err := manager.StartHealthServer(":" + cfg.Scheduler.GetHealthPort()); err != nil {
+		logger.Error("启动健康检查服务失败", "error", err)
+		os.Exit(1)
+	}
+
 	// 启动调度器
 	manager.Start()
 
@@ -79,9 +125,13 @@ func main() {
 
 	logger.Info("收到停止信号，开始优雅关闭...")
 
-	// 停止调度器
+	// 停止调度器和健康检查服务
 	manager.Stop()
 
+	if sqlDB, err := database.DB(); err == nil {
+		_ = sqlDB.Close()
+	}
+
 	logger.Info("定时任务调度器已停止")
 }
 
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"log/slog"
 	"net/http"
@@ -14,10 +15,16 @@ import (
 
 	_ "github.com/yeegeek/uyou-go-api-starter/api/docs"
 	"github.com/yeegeek/uyou-go-api-starter/internal/auth"
+	"github.com/yeegeek/uyou-go-api-starter/internal/buildinfo"
 	"github.com/yeegeek/uyou-go-api-starter/internal/config"
+	"github.com/yeegeek/uyou-go-api-starter/internal/configcheck"
 	"github.com/yeegeek/uyou-go-api-starter/internal/db"
 	"github.com/yeegeek/uyou-go-api-starter/internal/migrate"
+	"github.com/yeegeek/uyou-go-api-starter/internal/featureflags"
 	"github.com/yeegeek/uyou-go-api-starter/internal/friend"
+	"github.com/yeegeek/uyou-go-api-starter/internal/httpclient"
+	"github.com/yeegeek/uyou-go-api-starter/internal/mail"
+	"github.com/yeegeek/uyou-go-api-starter/internal/oauth"
 	"github.com/yeegeek/uyou-go-api-starter/internal/server"
 	"github.com/yeegeek/uyou-go-api-starter/internal/user"
 )
@@ -34,7 +41,6 @@ import (
 // @license.name MIT
 // @license.url https://opensource.org/licenses/MIT
 
-// @host localhost:8080
 // @BasePath /
 
 // @securityDefinitions.apikey BearerAuth
@@ -43,6 +49,19 @@ import (
 // @description Type "Bearer" followed by a space and JWT token.
 
 func main() {
+	checkConfig := flag.Bool("check-config", false, "Validate configuration and dependencies, print a JSON report and exit (0 = ok, 1 = failed)")
+	preflight := flag.Bool("preflight", false, "Alias of --check-config: run the pre-traffic checks without starting the HTTP listener")
+	flag.Parse()
+
+	// 自检模式：不启动服务，适合作为 Kubernetes initContainer
+	if *checkConfig || *preflight {
+		report := configcheck.Run(context.Background(), "")
+		if err := report.Write(os.Stdout); err != nil {
+			slog.Error("Failed to write config check report", "error", err)
+		}
+		os.Exit(report.ExitCode())
+	}
+
 	if err := run(); err != nil {
 		os.Exit(1)
 	}
@@ -50,7 +69,7 @@ func main() {
 
 func run() error {
 	logger := slog.Default()
-	logger.Info("Starting Go REST API Boilerplate...")
+	logger.Info("Starting Go REST API Boilerplate...", buildinfo.Get().Attrs()...)
 
 	cfg, err := config.LoadConfig("")
 	if err != nil {
@@ -63,14 +82,42 @@ func run() error {
 		return err
 	}
 
+	// 日志级别由配置快照控制，启用 app.watch_config 后修改 logging.level 无需重启
+	store := config.NewStore(cfg)
+	slog.SetDefault(newLogger(cfg.Logging, store.LogLevel()))
+	logger = slog.Default()
+
 	cfg.LogSafeConfig(logger)
 
+	if cfg.App.WatchConfig {
+		if err := store.Watch(""); err != nil {
+			logger.Warn("Config watching disabled", "error", err)
+		} else {
+			logger.Info("Watching config file for changes")
+		}
+	}
+
 	database, err := db.NewPostgresDBFromDatabaseConfig(cfg.Database)
 	if err != nil {
 		logger.Error("Failed to connect to database", "error", err)
 		return err
 	}
 
+	if len(cfg.Database.Replicas) > 0 {
+		resolver, err := db.NewResolverFromDatabaseConfig(cfg.Database)
+		if err != nil {
+			logger.Error("Failed to configure database replicas", "error", err)
+			return err
+		}
+		if err := database.Use(resolver); err != nil {
+			logger.Error("Failed to register database replicas", "error", err)
+			return err
+		}
+		resolver.Start()
+		defer resolver.Stop()
+		logger.Info("Database read replicas enabled", "replicas", len(cfg.Database.Replicas), "health_interval", cfg.Database.GetReplicaHealthInterval())
+	}
+
 	if os.Getenv("SKIP_MIGRATION_CHECK") == "" {
 		if err := checkMigrationStatus(database, &cfg.Migrations); err != nil {
 			logger.Warn("Migration check", "status", "⚠️", "error", err)
@@ -79,16 +126,46 @@ func run() error {
 		}
 	}
 
-	authService := auth.NewServiceWithRepo(&cfg.JWT, database)
+	authService := auth.NewServiceWithRepo(&cfg.JWT, database,
+		auth.WithSessionAnomalyAction(cfg.Security.GetSessionAnomalyAction()),
+		auth.WithOrganizationClaims(),
+	)
+	// 邮件异步发送，未启用时只写入日志
+	mailer := mail.NewQueue(mail.New(cfg.Mail), cfg.Mail)
+	mailRenderer, err := mail.NewRenderer(map[string]any{"AppName": cfg.App.Name})
+	if err != nil {
+		logger.Error("Failed to parse mail templates", "error", err)
+		return err
+	}
+
 	userRepo := user.NewRepository(database)
-	userService := user.NewService(userRepo, &cfg.Security)
-	userHandler := user.NewHandler(userService, authService)
+	loginAttempts := user.NewLoginAttemptWriter(userRepo, 0)
+	userService := user.NewServiceWithPagination(userRepo, &cfg.Security, cfg.Pagination,
+		user.WithRoleCacheInvalidator(authService),
+		user.WithLoginAttemptRecorder(loginAttempts),
+		user.WithPolicies(cfg.Policies),
+		user.WithEmailChangeNotifier(user.NewMailEmailChangeNotifier(mailer, mailRenderer)),
+	)
+	userHandler := user.NewHandler(userService, authService,
+		user.WithRefreshCookie(auth.NewRefreshCookie(&cfg.JWT)),
+		user.WithAccessCookie(auth.NewAccessCookie(&cfg.JWT)),
+		user.WithOAuthProviders(oauth.NewRegistry(cfg.OAuth, httpclient.New(cfg.HTTPClient))),
+		user.WithPolicyService(user.NewPolicyService(userRepo, cfg.Policies)),
+		user.WithPreferenceService(user.NewPreferenceService(userRepo)),
+	)
+	roleService := user.NewRoleService(userRepo, user.WithRoleServiceCacheInvalidator(authService))
+	roleHandler := user.NewRoleHandler(roleService)
 
 	friendRepo := friend.NewRepository(database)
 	friendService := friend.NewService(friendRepo)
 	friendHandler := friend.NewHandler(friendService)
 
-	router := server.SetupRouter(userHandler, friendHandler, authService, cfg, database)
+	flagsService := featureflags.NewService(featureflags.NewRepository(database), cfg.FeatureFlags,
+		featureflags.WithCacheTTLFunc(func() time.Duration { return store.Load().FeatureFlags.CacheTTL }),
+	)
+	flagsHandler := featureflags.NewHandler(flagsService)
+
+	router := server.SetupRouterWithStore(userHandler, roleHandler, friendHandler, flagsHandler, authService, store, database)
 
 	port := cfg.Server.Port
 	if port == "" {
@@ -111,10 +188,16 @@ func run() error {
 
 	go func() {
 		logger.Info("Server starting", "address", srv.Addr)
-		logger.Info("Swagger UI available", "url", fmt.Sprintf("http://localhost:%s/swagger/index.html", port))
+		if cfg.Swagger.UIEnabled {
+			logger.Info("Swagger UI available", "url", fmt.Sprintf("http://localhost:%s/swagger/index.html", port))
+		}
+		logger.Info("OpenAPI spec available", "url", fmt.Sprintf("http://localhost:%s%s/v1/openapi.json", port, cfg.API.GetBasePath()))
 		logger.Info("Health check available", "url", fmt.Sprintf("http://localhost:%s/health", port))
 		logger.Info("Liveness probe available", "url", fmt.Sprintf("http://localhost:%s/health/live", port))
 		logger.Info("Readiness probe available", "url", fmt.Sprintf("http://localhost:%s/health/ready", port))
+		if cfg.Metrics.Enabled {
+			logger.Info("Metrics available", "url", fmt.Sprintf("http://localhost:%s%s", port, cfg.Metrics.GetPath()))
+		}
 
 		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			logger.Error("Server error", "error", err)
@@ -129,14 +212,6 @@ func run() error {
 	logger.Info("Received shutdown signal", "signal", sig)
 	logger.Info("Shutting down server gracefully...")
 
-	sqlDB, err := database.DB()
-	if err == nil {
-		logger.Info("Closing database connections...")
-		if err := sqlDB.Close(); err != nil {
-			logger.Error("Error closing database", "error", err)
-		}
-	}
-
 	shutdownTimeout := time.Duration(cfg.Server.ShutdownTimeout) * time.Second
 	if shutdownTimeout == 0 {
 		shutdownTimeout = 30 * time.Second
@@ -150,10 +225,38 @@ func run() error {
 		return err
 	}
 
+	logger.Info("Flushing mail queue...")
+	if err := mailer.Close(ctx); err != nil {
+		logger.Warn("Mail queue not fully flushed", "error", err)
+	}
+
+	logger.Info("Flushing login history...")
+	if err := loginAttempts.Close(ctx); err != nil {
+		logger.Warn("Login history not fully flushed", "error", err)
+	}
+
+	// 登录历史写入完成后再关闭数据库连接
+	sqlDB, err := database.DB()
+	if err == nil {
+		logger.Info("Closing database connections...")
+		if err := sqlDB.Close(); err != nil {
+			logger.Error("Error closing database", "error", err)
+		}
+	}
+
 	logger.Info("Server exited gracefully")
 	return nil
 }
 
+// newLogger 按 logging.format 创建输出到标准输出的日志，级别由 level 实时控制
+func newLogger(cfg config.LoggingConfig, level slog.Leveler) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: level}
+	if cfg.Format == "text" {
+		return slog.New(slog.NewTextHandler(os.Stdout, opts))
+	}
+	return slog.New(slog.NewJSONHandler(os.Stdout, opts))
+}
+
 func checkMigrationStatus(database *gorm.DB, cfg *config.MigrationsConfig) error {
 	sqlDB, err := database.DB()
 	if err != nil {
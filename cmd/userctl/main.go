@@ -0,0 +1,336 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/yeegeek/uyou-go-api-starter/internal/auth"
+	"github.com/yeegeek/uyou-go-api-starter/internal/buildinfo"
+	"github.com/yeegeek/uyou-go-api-starter/internal/config"
+	"github.com/yeegeek/uyou-go-api-starter/internal/db"
+	"github.com/yeegeek/uyou-go-api-starter/internal/user"
+)
+
+// 退出码：0 成功，1 执行失败，2 用法或参数错误
+const (
+	exitOK    = 0
+	exitError = 1
+	exitUsage = 2
+)
+
+const usage = `Usage: userctl <command> [flags] [args]
+
+Commands:
+  list                 List users (--role, --search, --sort, --order, --page, --per-page, --format)
+  show <id|email>      Show a user's profile, roles and session count (--format)
+  disable <id>         Disable an account and revoke its sessions
+  enable <id>          Re-enable a disabled account
+  reset-password <id>  Replace the password with a temporary one and revoke sessions
+`
+
+var errUsage = errors.New("usage error")
+
+func usageError(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", errUsage, fmt.Sprintf(format, args...))
+}
+
+// app 运维命令，全部经过 user.Service / auth.Service，业务规则与审计日志与 HTTP 接口一致
+type app struct {
+	users user.Service
+	auth  auth.Service
+	out   io.Writer
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(exitUsage)
+	}
+
+	slog.Info("Starting userctl", append([]any{"command", os.Args[1]}, buildinfo.Get().Attrs()...)...)
+
+	cfg, err := config.LoadConfig("")
+	if err != nil {
+		log.Printf("Failed to load config: %v", err)
+		os.Exit(exitError)
+	}
+
+	// 与服务端使用同一连接逻辑，SSL 和连接池配置保持一致
+	database, err := db.NewPostgresDBFromDatabaseConfig(cfg.Database)
+	if err != nil {
+		log.Printf("Failed to connect to database: %v", err)
+		os.Exit(exitError)
+	}
+
+	authService := auth.NewServiceWithRepo(&cfg.JWT, database)
+	userService := user.NewServiceWithPagination(user.NewRepository(database), &cfg.Security, cfg.Pagination,
+		user.WithRoleCacheInvalidator(authService),
+	)
+
+	a := &app{users: userService, auth: authService, out: os.Stdout}
+	os.Exit(exitCodeFor(a.run(context.Background(), os.Args[1:])))
+}
+
+func exitCodeFor(err error) int {
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.Is(err, errUsage):
+		log.Printf("Error: %v", err)
+		fmt.Fprint(os.Stderr, usage)
+		return exitUsage
+	default:
+		log.Printf("Error: %v", err)
+		return exitError
+	}
+}
+
+func (a *app) run(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return usageError("missing command")
+	}
+	command, args := args[0], args[1:]
+	switch command {
+	case "list":
+		return a.list(ctx, args)
+	case "show":
+		return a.show(ctx, args)
+	case "disable":
+		return a.setActive(ctx, args, false)
+	case "enable":
+		return a.setActive(ctx, args, true)
+	case "reset-password":
+		return a.resetPassword(ctx, args)
+	default:
+		return usageError("unknown command %q", command)
+	}
+}
+
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	return fs
+}
+
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		return usageError("%s: %v", fs.Name(), err)
+	}
+	return nil
+}
+
+func checkFormat(format string) error {
+	if format != "table" && format != "json" {
+		return usageError("--format must be table or json")
+	}
+	return nil
+}
+
+// listResult list 命令的 JSON 输出
+type listResult struct {
+	Users   []user.UserResponse `json:"users"`
+	Total   int64               `json:"total"`
+	Page    int                 `json:"page"`
+	PerPage int                 `json:"per_page"`
+}
+
+func (a *app) list(ctx context.Context, args []string) error {
+	fs := newFlagSet("list")
+	role := fs.String("role", "", "Only users with this role")
+	search := fs.String("search", "", "Match name or email")
+	sortField := fs.String("sort", user.DefaultUserSort, "Sort field: "+strings.Join(user.SortableUserFields(), ", "))
+	order := fs.String("order", "desc", "Sort order: asc or desc")
+	page := fs.Int("page", 1, "Page number")
+	perPage := fs.Int("per-page", 20, "Users per page")
+	format := fs.String("format", "table", "Output format: table or json")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := checkFormat(*format); err != nil {
+		return err
+	}
+	if !slices.Contains(user.SortableUserFields(), *sortField) {
+		return usageError("--sort must be one of %s", strings.Join(user.SortableUserFields(), ", "))
+	}
+	if *order != "asc" && *order != "desc" {
+		return usageError("--order must be asc or desc")
+	}
+	if *page < 1 || *perPage < 1 {
+		return usageError("--page and --per-page must be positive")
+	}
+
+	filters := user.UserFilterParams{Role: *role, Search: strings.TrimSpace(*search), Sort: *sortField, Order: *order}
+	users, total, err := a.users.ListUsers(ctx, filters, *page, *perPage)
+	if err != nil {
+		return fmt.Errorf("failed to list users: %w", err)
+	}
+
+	if *format == "json" {
+		result := listResult{Users: make([]user.UserResponse, 0, len(users)), Total: total, Page: *page, PerPage: *perPage}
+		for i := range users {
+			result.Users = append(result.Users, user.ToUserResponse(&users[i]))
+		}
+		return a.writeJSON(result)
+	}
+
+	w := tabwriter.NewWriter(a.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tEMAIL\tNAME\tROLES\tACTIVE\tCREATED")
+	for i := range users {
+		u := &users[i]
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%t\t%s\n", u.ID, u.Email, u.Name, strings.Join(u.GetRoleNames(), ","), u.Active, u.CreatedAt.UTC().Format("2006-01-02"))
+	}
+	fmt.Fprintf(w, "\n%d of %d users (page %d)\n", len(users), total, *page)
+	return w.Flush()
+}
+
+// showResult show 命令的 JSON 输出
+type showResult struct {
+	user.UserResponse
+	ActiveSessions int `json:"active_sessions"`
+}
+
+func (a *app) show(ctx context.Context, args []string) error {
+	fs := newFlagSet("show")
+	format := fs.String("format", "table", "Output format: table or json")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := checkFormat(*format); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return usageError("show requires exactly one <id|email>")
+	}
+
+	u, err := a.findUser(ctx, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	sessions, err := a.auth.ListSessions(ctx, auth.SessionFilter{UserID: u.ID, ActiveOnly: true})
+	if err != nil {
+		return fmt.Errorf("failed to list sessions: %w", err)
+	}
+
+	result := showResult{UserResponse: user.ToUserResponse(u), ActiveSessions: len(sessions)}
+	if *format == "json" {
+		return a.writeJSON(result)
+	}
+
+	w := tabwriter.NewWriter(a.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "ID:\t%d\n", result.ID)
+	fmt.Fprintf(w, "Email:\t%s\n", result.Email)
+	fmt.Fprintf(w, "Name:\t%s\n", result.Name)
+	fmt.Fprintf(w, "Roles:\t%s\n", strings.Join(result.Roles, ", "))
+	fmt.Fprintf(w, "Active:\t%t\n", result.Active)
+	fmt.Fprintf(w, "Created:\t%s\n", result.CreatedAt)
+	fmt.Fprintf(w, "Updated:\t%s\n", result.UpdatedAt)
+	fmt.Fprintf(w, "Active sessions:\t%d\n", result.ActiveSessions)
+	return w.Flush()
+}
+
+// findUser 按 ID 或邮箱查找用户；邮箱通过 ListUsers 搜索后精确匹配，不绕过服务层
+func (a *app) findUser(ctx context.Context, ref string) (*user.User, error) {
+	if id, err := strconv.ParseUint(ref, 10, 32); err == nil {
+		u, err := a.users.GetUserByID(ctx, uint(id))
+		if err != nil {
+			return nil, fmt.Errorf("user %s: %w", ref, err)
+		}
+		return u, nil
+	}
+	if !strings.Contains(ref, "@") {
+		return nil, usageError("%q is neither a user ID nor an email", ref)
+	}
+
+	users, _, err := a.users.ListUsers(ctx, user.UserFilterParams{Search: ref, CountMode: user.CountNone}, 1, 20)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up %s: %w", ref, err)
+	}
+	for i := range users {
+		if strings.EqualFold(users[i].Email, ref) {
+			return a.users.GetUserByID(ctx, users[i].ID)
+		}
+	}
+	return nil, fmt.Errorf("user %s: %w", ref, user.ErrUserNotFound)
+}
+
+func parseUserID(command string, args []string) (uint, error) {
+	if len(args) != 1 {
+		return 0, usageError("%s requires exactly one <id>", command)
+	}
+	id, err := strconv.ParseUint(args[0], 10, 32)
+	if err != nil || id == 0 {
+		return 0, usageError("invalid user ID %q", args[0])
+	}
+	return uint(id), nil
+}
+
+// setActive 停用时与管理员接口一致，同时吊销该用户全部刷新令牌
+func (a *app) setActive(ctx context.Context, args []string, active bool) error {
+	command := "enable"
+	if !active {
+		command = "disable"
+	}
+	id, err := parseUserID(command, args)
+	if err != nil {
+		return err
+	}
+
+	u, err := a.users.SetUserActive(ctx, id, active)
+	if err != nil {
+		return fmt.Errorf("failed to %s user %d: %w", command, id, err)
+	}
+
+	var revoked int64
+	if !active {
+		if revoked, err = a.auth.RevokeAllUserTokens(ctx, u.ID); err != nil {
+			return fmt.Errorf("user %d disabled but revoking sessions failed: %w", id, err)
+		}
+	}
+
+	slog.InfoContext(ctx, "User account status changed by userctl",
+		"user_id", u.ID,
+		"active", u.Active,
+		"revoked_sessions", revoked,
+	)
+	fmt.Fprintf(a.out, "User %d (%s) active=%t, revoked sessions: %d\n", u.ID, u.Email, u.Active, revoked)
+	return nil
+}
+
+// resetPassword 生成临时密码并吊销全部会话；临时密码只输出一次，需通过安全渠道交给用户
+func (a *app) resetPassword(ctx context.Context, args []string) error {
+	id, err := parseUserID("reset-password", args)
+	if err != nil {
+		return err
+	}
+
+	password, err := a.users.ResetPassword(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to reset password for user %d: %w", id, err)
+	}
+	revoked, err := a.auth.RevokeAllUserTokens(ctx, id)
+	if err != nil {
+		return fmt.Errorf("password reset but revoking sessions failed for user %d: %w", id, err)
+	}
+
+	slog.InfoContext(ctx, "User password reset by userctl", "user_id", id, "revoked_sessions", revoked)
+	fmt.Fprintf(a.out, "Temporary password for user %d: %s\n", id, password)
+	fmt.Fprintf(a.out, "Revoked sessions: %d\n", revoked)
+	return nil
+}
+
+func (a *app) writeJSON(v any) error {
+	encoder := json.NewEncoder(a.out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yeegeek/uyou-go-api-starter/internal/auth"
+	"github.com/yeegeek/uyou-go-api-starter/internal/config"
+	"github.com/yeegeek/uyou-go-api-starter/internal/testutil"
+	"github.com/yeegeek/uyou-go-api-starter/internal/user"
+)
+
+// setupTestApp wires userctl to the real services over the shared in-memory SQLite user schema
+func setupTestApp(t *testing.T) (*app, *bytes.Buffer) {
+	db := testutil.NewSQLiteDB(t)
+	authService := auth.NewServiceWithRepo(&config.JWTConfig{Secret: "test-secret-key-for-userctl", RefreshTokenTTL: time.Hour}, db)
+	securityCfg := &config.SecurityConfig{
+		BcryptCost:               4,
+		PasswordMinLength:        8,
+		PasswordRequireUppercase: true,
+		PasswordRequireLowercase: true,
+		PasswordRequireNumber:    true,
+		PasswordRequireSpecial:   true,
+	}
+	userService := user.NewService(user.NewRepository(db), securityCfg, user.WithRoleCacheInvalidator(authService))
+
+	var out bytes.Buffer
+	return &app{users: userService, auth: authService, out: &out}, &out
+}
+
+func registerUser(t *testing.T, a *app, name, email string) *user.User {
+	t.Helper()
+	u, err := a.users.RegisterUser(context.Background(), user.RegisterRequest{Name: name, Email: email, Password: "Password123!"})
+	require.NoError(t, err)
+	return u
+}
+
+func TestList(t *testing.T) {
+	a, out := setupTestApp(t)
+	ctx := context.Background()
+	registerUser(t, a, "Alice Admin", "alice@example.com")
+	registerUser(t, a, "Bob User", "bob@example.com")
+	require.NoError(t, a.users.PromoteToAdmin(ctx, 1))
+
+	require.NoError(t, a.run(ctx, []string{"list", "--format", "json", "--sort", "email", "--order", "asc"}))
+	var result listResult
+	require.NoError(t, json.Unmarshal(out.Bytes(), &result))
+	assert.EqualValues(t, 2, result.Total)
+	require.Len(t, result.Users, 2)
+	assert.Equal(t, "alice@example.com", result.Users[0].Email)
+	assert.ElementsMatch(t, []string{"user", "admin"}, result.Users[0].Roles)
+
+	out.Reset()
+	require.NoError(t, a.run(ctx, []string{"list", "--role", "admin", "--format", "json"}))
+	require.NoError(t, json.Unmarshal(out.Bytes(), &result))
+	require.Len(t, result.Users, 1)
+	assert.Equal(t, "alice@example.com", result.Users[0].Email)
+
+	out.Reset()
+	require.NoError(t, a.run(ctx, []string{"list", "--search", "bob"}))
+	assert.Contains(t, out.String(), "ID  EMAIL")
+	assert.Contains(t, out.String(), "bob@example.com")
+	assert.NotContains(t, out.String(), "alice@example.com")
+	assert.Contains(t, out.String(), "1 of 1 users")
+}
+
+func TestList_InvalidFlags(t *testing.T) {
+	a, _ := setupTestApp(t)
+	ctx := context.Background()
+
+	for _, args := range [][]string{
+		{"list", "--sort", "password_hash"},
+		{"list", "--order", "sideways"},
+		{"list", "--format", "yaml"},
+		{"list", "--page", "0"},
+		{"list", "--unknown"},
+		{"frobnicate"},
+		{},
+	} {
+		err := a.run(ctx, args)
+		assert.ErrorIs(t, err, errUsage, args)
+	}
+}
+
+func TestShow(t *testing.T) {
+	a, out := setupTestApp(t)
+	ctx := context.Background()
+	jane := registerUser(t, a, "Jane Doe", "jane@example.com")
+	for i := 0; i < 2; i++ {
+		_, err := a.auth.GenerateTokenPair(ctx, jane.ID, jane.Email, jane.Name)
+		require.NoError(t, err)
+	}
+
+	for _, ref := range []string{"1", "JANE@example.com"} {
+		out.Reset()
+		require.NoError(t, a.run(ctx, []string{"show", "--format", "json", ref}))
+		var result showResult
+		require.NoError(t, json.Unmarshal(out.Bytes(), &result))
+		assert.Equal(t, jane.ID, result.ID)
+		assert.Equal(t, "jane@example.com", result.Email)
+		assert.Equal(t, []string{"user"}, result.Roles)
+		assert.Equal(t, 2, result.ActiveSessions)
+	}
+
+	out.Reset()
+	require.NoError(t, a.run(ctx, []string{"show", "jane@example.com"}))
+	assert.Contains(t, out.String(), "Active sessions:  2")
+
+	assert.ErrorIs(t, a.run(ctx, []string{"show", "nobody@example.com"}), user.ErrUserNotFound)
+	assert.ErrorIs(t, a.run(ctx, []string{"show", "42"}), user.ErrUserNotFound)
+	assert.ErrorIs(t, a.run(ctx, []string{"show", "jane"}), errUsage)
+}
+
+func TestDisableEnable(t *testing.T) {
+	a, out := setupTestApp(t)
+	ctx := context.Background()
+	jane := registerUser(t, a, "Jane Doe", "jane@example.com")
+	_, err := a.auth.GenerateTokenPair(ctx, jane.ID, jane.Email, jane.Name)
+	require.NoError(t, err)
+
+	require.NoError(t, a.run(ctx, []string{"disable", "1"}))
+	assert.Contains(t, out.String(), "active=false, revoked sessions: 1")
+	_, err = a.users.AuthenticateUser(ctx, user.LoginRequest{Email: "jane@example.com", Password: "Password123!"})
+	assert.ErrorIs(t, err, user.ErrAccountDisabled)
+
+	require.NoError(t, a.run(ctx, []string{"enable", "1"}))
+	_, err = a.users.AuthenticateUser(ctx, user.LoginRequest{Email: "jane@example.com", Password: "Password123!"})
+	assert.NoError(t, err)
+
+	assert.ErrorIs(t, a.run(ctx, []string{"disable", "42"}), user.ErrUserNotFound)
+	assert.ErrorIs(t, a.run(ctx, []string{"disable", "abc"}), errUsage)
+	assert.ErrorIs(t, a.run(ctx, []string{"enable"}), errUsage)
+}
+
+func TestResetPassword(t *testing.T) {
+	a, out := setupTestApp(t)
+	ctx := context.Background()
+	jane := registerUser(t, a, "Jane Doe", "jane@example.com")
+	_, err := a.auth.GenerateTokenPair(ctx, jane.ID, jane.Email, jane.Name)
+	require.NoError(t, err)
+
+	require.NoError(t, a.run(ctx, []string{"reset-password", "1"}))
+
+	var password string
+	_, err = fmt.Sscanf(out.String(), "Temporary password for user 1: %s", &password)
+	require.NoError(t, err)
+	assert.Contains(t, out.String(), "Revoked sessions: 1")
+
+	_, err = a.users.AuthenticateUser(ctx, user.LoginRequest{Email: "jane@example.com", Password: "Password123!"})
+	assert.ErrorIs(t, err, user.ErrInvalidCredentials)
+	_, err = a.users.AuthenticateUser(ctx, user.LoginRequest{Email: "jane@example.com", Password: password})
+	assert.NoError(t, err)
+
+	sessions, err := a.auth.ListSessions(ctx, auth.SessionFilter{UserID: jane.ID, ActiveOnly: true})
+	require.NoError(t, err)
+	assert.Empty(t, sessions)
+
+	assert.ErrorIs(t, a.run(ctx, []string{"reset-password", "42"}), user.ErrUserNotFound)
+}
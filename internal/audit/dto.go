@@ -0,0 +1,72 @@
+package audit
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EntryResponse represents an audit entry in API responses
+type EntryResponse struct {
+	ID        uint   `json:"id"`
+	ActorID   uint   `json:"actor_id"`
+	Action    string `json:"action"`
+	TargetID  string `json:"target_id,omitempty"`
+	Method    string `json:"method"`
+	Path      string `json:"path"`
+	Status    int    `json:"status"`
+	IP        string `json:"ip"`
+	CreatedAt string `json:"created_at"`
+}
+
+// EntryListResponse represents a page of audit entries, newest first, using the same
+// pagination fields as the user list
+type EntryListResponse struct {
+	Entries    []EntryResponse `json:"entries"`
+	Total      int64           `json:"total"`
+	Page       int             `json:"page"`
+	PerPage    int             `json:"per_page"`
+	TotalPages int             `json:"total_pages"`
+	HasNext    bool            `json:"has_next"`
+}
+
+// ToEntryResponse converts an Entry to EntryResponse
+func ToEntryResponse(e *Entry) EntryResponse {
+	return EntryResponse{
+		ID:        e.ID,
+		ActorID:   e.ActorID,
+		Action:    e.Action,
+		TargetID:  e.TargetID,
+		Method:    e.Method,
+		Path:      e.Path,
+		Status:    e.Status,
+		IP:        e.IP,
+		CreatedAt: e.CreatedAt.UTC().Format(time.RFC3339),
+	}
+}
+
+// csvHeader is the first row of the CSV export; csvRecord produces the matching columns
+var csvHeader = []string{"id", "created_at", "actor_id", "action", "target_id", "method", "path", "status", "ip"}
+
+func csvRecord(e *Entry) []string {
+	return []string{
+		strconv.FormatUint(uint64(e.ID), 10),
+		e.CreatedAt.UTC().Format(time.RFC3339),
+		strconv.FormatUint(uint64(e.ActorID), 10),
+		csvSafe(e.Action),
+		csvSafe(e.TargetID),
+		e.Method,
+		csvSafe(e.Path),
+		strconv.Itoa(e.Status),
+		e.IP,
+	}
+}
+
+// csvSafe prefixes values starting with a formula character with a quote; route parameters and
+// paths come from the request, and spreadsheets would otherwise evaluate them
+func csvSafe(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
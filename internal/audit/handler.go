@@ -0,0 +1,185 @@
+package audit
+
+import (
+	"encoding/csv"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	apiErrors "github.com/yeegeek/uyou-go-api-starter/internal/errors"
+	"github.com/yeegeek/uyou-go-api-starter/internal/middleware"
+)
+
+// csvFlushRows is how many rows the export buffers before flushing to the client
+const csvFlushRows = 500
+
+var errInvalidFilter = errors.New("invalid audit filter")
+
+// Handler handles admin audit log HTTP requests
+type Handler struct {
+	repo Repository
+}
+
+// NewHandler creates a new audit log handler
+func NewHandler(repo Repository) *Handler {
+	return &Handler{repo: repo}
+}
+
+// ListEntries godoc
+// @Summary List admin audit entries (Admin only)
+// @Description Page through recorded admin requests, newest first. Filters combine with AND. Dates accept RFC 3339 timestamps or YYYY-MM-DD; a date-only `to` includes that whole day.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param actor_id query int false "Only requests made by this admin"
+// @Param action query string false "Exact action, e.g. POST /admin/users/:id/deactivate"
+// @Param target_id query string false "Only requests on this target (user ID, flag name or session family)"
+// @Param from query string false "Entries at or after this time"
+// @Param to query string false "Entries before this time"
+// @Param page query int false "Page number" default(1)
+// @Param per_page query int false "Items per page (default and max set by pagination config)" default(20)
+// @Success 200 {object} errors.Response{success=bool,data=EntryListResponse} "Audit entries"
+// @Failure 400 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Invalid filter"
+// @Failure 401 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Unauthorized"
+// @Failure 403 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Admin access required"
+// @Failure 500 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Failed to list audit entries"
+// @Router /api/v1/admin/audit [get]
+func (h *Handler) ListEntries(c *gin.Context) {
+	filter, apiErr := parseFilter(c)
+	if apiErr != nil {
+		_ = c.Error(apiErr)
+		return
+	}
+
+	pagination := middleware.ParsePaginationParams(c)
+	entries, total, err := h.repo.List(c.Request.Context(), filter, pagination.Page, pagination.PerPage)
+	if err != nil {
+		_ = c.Error(apiErrors.InternalServerError(err))
+		return
+	}
+
+	totalPages := int((total + int64(pagination.PerPage) - 1) / int64(pagination.PerPage))
+	response := EntryListResponse{
+		Entries:    make([]EntryResponse, len(entries)),
+		Total:      total,
+		Page:       pagination.Page,
+		PerPage:    pagination.PerPage,
+		TotalPages: totalPages,
+		HasNext:    pagination.Page < totalPages,
+	}
+	for i := range entries {
+		response.Entries[i] = ToEntryResponse(&entries[i])
+	}
+	c.JSON(http.StatusOK, apiErrors.Success(response))
+}
+
+// ExportEntries godoc
+// @Summary Export admin audit entries as CSV (Admin only)
+// @Description Stream every audit entry matching the filters as CSV, newest first. Accepts the same filters as the list endpoint; pagination parameters are ignored.
+// @Tags admin
+// @Produce text/csv
+// @Security BearerAuth
+// @Param actor_id query int false "Only requests made by this admin"
+// @Param action query string false "Exact action, e.g. POST /admin/users/:id/deactivate"
+// @Param target_id query string false "Only requests on this target (user ID, flag name or session family)"
+// @Param from query string false "Entries at or after this time"
+// @Param to query string false "Entries before this time"
+// @Success 200 {string} string "CSV with columns id, created_at, actor_id, action, target_id, method, path, status, ip"
+// @Failure 400 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Invalid filter"
+// @Failure 401 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Unauthorized"
+// @Failure 403 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Admin access required"
+// @Failure 500 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Failed to export audit entries"
+// @Router /api/v1/admin/audit/export [get]
+func (h *Handler) ExportEntries(c *gin.Context) {
+	filter, apiErr := parseFilter(c)
+	if apiErr != nil {
+		_ = c.Error(apiErr)
+		return
+	}
+
+	w := csv.NewWriter(c.Writer)
+	rows := 0
+	err := h.repo.Each(c.Request.Context(), filter, func(e *Entry) error {
+		if rows == 0 {
+			startCSV(c, w)
+		}
+		rows++
+		if err := w.Write(csvRecord(e)); err != nil {
+			return err
+		}
+		if rows%csvFlushRows == 0 {
+			w.Flush()
+			return w.Error()
+		}
+		return nil
+	})
+	if err != nil {
+		// WHY: Once rows are streamed the status is sent, so a failure can only truncate the file
+		if rows == 0 {
+			_ = c.Error(apiErrors.InternalServerError(err))
+			return
+		}
+		slog.ErrorContext(c.Request.Context(), "Audit export aborted", "rows", rows, "error", err)
+		return
+	}
+
+	if rows == 0 {
+		startCSV(c, w)
+	}
+	w.Flush()
+}
+
+// startCSV sends the download headers and the header row
+func startCSV(c *gin.Context, w *csv.Writer) {
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", `attachment; filename="audit-`+time.Now().UTC().Format("20060102T150405Z")+`.csv"`)
+	c.Status(http.StatusOK)
+	_ = w.Write(csvHeader)
+}
+
+// parseFilter reads the filters shared by the list and export endpoints
+func parseFilter(c *gin.Context) (Filter, *apiErrors.APIError) {
+	filter := Filter{Action: c.Query("action"), TargetID: c.Query("target_id")}
+	if raw := c.Query("actor_id"); raw != "" {
+		actorID, err := strconv.ParseUint(raw, 10, 32)
+		if err != nil || actorID == 0 {
+			return Filter{}, apiErrors.BadRequest("Invalid actor_id filter")
+		}
+		filter.ActorID = uint(actorID)
+	}
+
+	var err error
+	if filter.From, err = parseTime(c.Query("from"), false); err != nil {
+		return Filter{}, apiErrors.BadRequest("Invalid from filter, expected RFC 3339 or YYYY-MM-DD")
+	}
+	if filter.To, err = parseTime(c.Query("to"), true); err != nil {
+		return Filter{}, apiErrors.BadRequest("Invalid to filter, expected RFC 3339 or YYYY-MM-DD")
+	}
+	if !filter.From.IsZero() && !filter.To.IsZero() && !filter.From.Before(filter.To) {
+		return Filter{}, apiErrors.BadRequest("Invalid date range, from must be before to")
+	}
+	return filter, nil
+}
+
+// parseTime parses an RFC 3339 timestamp or a UTC date; an end date moves to the next midnight
+// so the range includes the whole day
+func parseTime(raw string, end bool) (time.Time, error) {
+	if raw == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.DateOnly, raw)
+	if err != nil {
+		return time.Time{}, errInvalidFilter
+	}
+	if end {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
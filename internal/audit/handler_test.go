@@ -0,0 +1,110 @@
+package audit
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yeegeek/uyou-go-api-starter/internal/errors"
+)
+
+func setupTestRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	h := NewHandler(setupTestRepository(t))
+	router := gin.New()
+	router.Use(errors.ErrorHandler())
+	router.GET("/admin/audit", h.ListEntries)
+	router.GET("/admin/audit/export", h.ExportEntries)
+	return router
+}
+
+func TestHandler_ListEntries(t *testing.T) {
+	router := setupTestRouter(t)
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantIDs    []uint
+		wantTotal  int64
+		wantNext   bool
+	}{
+		{name: "first page", query: "?per_page=2", wantStatus: http.StatusOK, wantIDs: []uint{5, 4}, wantTotal: 5, wantNext: true},
+		{name: "last page", query: "?per_page=2&page=3", wantStatus: http.StatusOK, wantIDs: []uint{1}, wantTotal: 5},
+		{name: "actor filter", query: "?actor_id=2", wantStatus: http.StatusOK, wantIDs: []uint{4, 3}, wantTotal: 2},
+		{name: "action filter", query: "?action=DELETE+/admin/users/:id", wantStatus: http.StatusOK, wantIDs: []uint{4}, wantTotal: 1},
+		{name: "target filter", query: "?target_id=8", wantStatus: http.StatusOK, wantIDs: []uint{3}, wantTotal: 1},
+		{name: "date-only to includes the whole day", query: "?from=2026-03-01T12:00:00Z&to=2026-03-01", wantStatus: http.StatusOK, wantIDs: []uint{5, 4, 3}, wantTotal: 3},
+		{name: "date before data", query: "?to=2026-02-28", wantStatus: http.StatusOK, wantIDs: []uint{}, wantTotal: 0},
+		{name: "combined filters", query: "?actor_id=1&action=POST+/admin/users/:id/deactivate&to=2026-03-01T11:00:00Z", wantStatus: http.StatusOK, wantIDs: []uint{1}, wantTotal: 1},
+		{name: "invalid actor", query: "?actor_id=abc", wantStatus: http.StatusBadRequest},
+		{name: "invalid date", query: "?from=yesterday", wantStatus: http.StatusBadRequest},
+		{name: "inverted range", query: "?from=2026-03-02&to=2026-03-01T00:00:00Z", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/audit"+tt.query, nil))
+
+			require.Equal(t, tt.wantStatus, w.Code, w.Body.String())
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var resp struct {
+				Data EntryListResponse `json:"data"`
+			}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			ids := make([]uint, len(resp.Data.Entries))
+			for i, e := range resp.Data.Entries {
+				ids[i] = e.ID
+			}
+			assert.Equal(t, tt.wantIDs, ids)
+			assert.Equal(t, tt.wantTotal, resp.Data.Total)
+			assert.Equal(t, tt.wantNext, resp.Data.HasNext)
+		})
+	}
+}
+
+func TestHandler_ExportEntries(t *testing.T) {
+	router := setupTestRouter(t)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/audit/export?action=POST+/admin/users/:id/deactivate&actor_id=1&page=2&per_page=1", nil))
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Header().Get("Content-Disposition"), "attachment")
+
+	records, err := csv.NewReader(strings.NewReader(w.Body.String())).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 3, "header plus every matching row; pagination is ignored")
+	assert.Equal(t, csvHeader, records[0])
+	assert.Equal(t, []string{"5", "2026-03-01T14:00:00Z", "1", "POST /admin/users/:id/deactivate", "9", "POST", "/api/v1/admin/users/9/deactivate", "404", "192.0.2.1"}, records[1])
+	assert.Equal(t, "1", records[2][0])
+}
+
+func TestHandler_ExportEntries_Empty(t *testing.T) {
+	router := setupTestRouter(t)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/audit/export?actor_id=3", nil))
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, strings.Join(csvHeader, ",")+"\n", w.Body.String())
+}
+
+func TestCSVRecord_EscapesFormulas(t *testing.T) {
+	record := csvRecord(&Entry{TargetID: "=HYPERLINK(\"x\")", Path: "/admin/flags/=1"})
+	assert.Equal(t, "'=HYPERLINK(\"x\")", record[4])
+	assert.Equal(t, "/admin/flags/=1", record[6])
+}
@@ -0,0 +1,33 @@
+// Package audit 持久化管理员操作审计记录，提供按条件分页查询和 CSV 导出
+package audit
+
+import "time"
+
+// Entry 一次管理员请求的审计记录，只追加不修改
+type Entry struct {
+	ID      uint `gorm:"primaryKey"`
+	ActorID uint `gorm:"not null"`
+	// Action 为请求方法加 /admin 之后的路由模板，例如 "POST /admin/users/:id/deactivate"
+	Action string `gorm:"size:150;not null"`
+	// TargetID 取路由中的 :id、:name 或 :family 参数，没有时为空
+	TargetID  string    `gorm:"size:100;not null;default:''"`
+	Method    string    `gorm:"size:10;not null"`
+	Path      string    `gorm:"size:255;not null"`
+	Status    int       `gorm:"not null"`
+	IP        string    `gorm:"column:ip_address;size:45;not null;default:''"`
+	CreatedAt time.Time `gorm:"not null"`
+}
+
+// TableName 指定审计记录对应的数据库表名
+func (Entry) TableName() string {
+	return "audit_logs"
+}
+
+// Filter 审计记录的筛选条件，零值字段不筛选；时间范围为 [From, To)
+type Filter struct {
+	ActorID  uint
+	Action   string
+	TargetID string
+	From     time.Time
+	To       time.Time
+}
@@ -0,0 +1,29 @@
+package audit
+
+import (
+	"context"
+
+	"github.com/yeegeek/uyou-go-api-starter/internal/middleware"
+)
+
+type recorder struct {
+	repo Repository
+}
+
+// NewRecorder returns a middleware.AuditRecorder that stores admin requests in the audit table
+func NewRecorder(repo Repository) middleware.AuditRecorder {
+	return &recorder{repo: repo}
+}
+
+func (r *recorder) RecordAudit(ctx context.Context, record middleware.AuditRecord) error {
+	return r.repo.Create(ctx, &Entry{
+		ActorID:   record.ActorID,
+		Action:    record.Action,
+		TargetID:  record.TargetID,
+		Method:    record.Method,
+		Path:      record.Path,
+		Status:    record.Status,
+		IP:        record.IP,
+		CreatedAt: record.At,
+	})
+}
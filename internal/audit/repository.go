@@ -0,0 +1,85 @@
+package audit
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// Repository defines persistence for admin audit entries
+type Repository interface {
+	Create(ctx context.Context, entry *Entry) error
+	// List returns one page of matching entries, newest first, and the total number of matches
+	List(ctx context.Context, filter Filter, page, perPage int) ([]Entry, int64, error)
+	// Each calls fn for every matching entry, newest first, without loading the whole result into memory
+	Each(ctx context.Context, filter Filter, fn func(*Entry) error) error
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+// NewRepository creates a new audit entry repository
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) Create(ctx context.Context, entry *Entry) error {
+	return r.db.WithContext(ctx).Create(entry).Error
+}
+
+func (r *repository) List(ctx context.Context, filter Filter, page, perPage int) ([]Entry, int64, error) {
+	var total int64
+	if err := r.filtered(ctx, filter).Model(&Entry{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var entries []Entry
+	err := r.filtered(ctx, filter).
+		Order("created_at DESC, id DESC").
+		Offset((page - 1) * perPage).
+		Limit(perPage).
+		Find(&entries).Error
+	return entries, total, err
+}
+
+func (r *repository) Each(ctx context.Context, filter Filter, fn func(*Entry) error) error {
+	rows, err := r.filtered(ctx, filter).Model(&Entry{}).Order("created_at DESC, id DESC").Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var entry Entry
+		if err := r.db.ScanRows(rows, &entry); err != nil {
+			return err
+		}
+		if err := fn(&entry); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// filtered applies the filter conditions; each one is backed by an index that ends in created_at
+// so the newest-first ordering does not need a sort
+func (r *repository) filtered(ctx context.Context, filter Filter) *gorm.DB {
+	query := r.db.WithContext(ctx)
+	if filter.ActorID != 0 {
+		query = query.Where("actor_id = ?", filter.ActorID)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.TargetID != "" {
+		query = query.Where("target_id = ?", filter.TargetID)
+	}
+	if !filter.From.IsZero() {
+		query = query.Where("created_at >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		query = query.Where("created_at < ?", filter.To)
+	}
+	return query
+}
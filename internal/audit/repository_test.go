@@ -0,0 +1,101 @@
+package audit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// auditBase is the time of the oldest seeded entry; each later entry is one hour newer
+var auditBase = time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+
+func setupTestRepository(t *testing.T) Repository {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&Entry{}))
+
+	repo := NewRepository(db)
+	seed := []Entry{
+		{ActorID: 1, Action: "POST /admin/users/:id/deactivate", TargetID: "7", Method: "POST", Path: "/api/v1/admin/users/7/deactivate", Status: 200},
+		{ActorID: 1, Action: "GET /admin/users", Method: "GET", Path: "/api/v1/admin/users", Status: 200},
+		{ActorID: 2, Action: "POST /admin/users/:id/deactivate", TargetID: "8", Method: "POST", Path: "/api/v1/admin/users/8/deactivate", Status: 200},
+		{ActorID: 2, Action: "DELETE /admin/users/:id", TargetID: "7", Method: "DELETE", Path: "/api/v1/admin/users/7", Status: 204},
+		{ActorID: 1, Action: "POST /admin/users/:id/deactivate", TargetID: "9", Method: "POST", Path: "/api/v1/admin/users/9/deactivate", Status: 404},
+	}
+	for i := range seed {
+		seed[i].IP = "192.0.2.1"
+		seed[i].CreatedAt = auditBase.Add(time.Duration(i) * time.Hour)
+		require.NoError(t, repo.Create(context.Background(), &seed[i]))
+	}
+	return repo
+}
+
+func entryIDs(entries []Entry) []uint {
+	ids := make([]uint, len(entries))
+	for i := range entries {
+		ids[i] = entries[i].ID
+	}
+	return ids
+}
+
+func TestRepository_ListFilters(t *testing.T) {
+	repo := setupTestRepository(t)
+
+	tests := []struct {
+		name   string
+		filter Filter
+		want   []uint
+	}{
+		{name: "no filter returns newest first", filter: Filter{}, want: []uint{5, 4, 3, 2, 1}},
+		{name: "actor", filter: Filter{ActorID: 2}, want: []uint{4, 3}},
+		{name: "action", filter: Filter{Action: "POST /admin/users/:id/deactivate"}, want: []uint{5, 3, 1}},
+		{name: "target", filter: Filter{TargetID: "7"}, want: []uint{4, 1}},
+		{name: "from is inclusive", filter: Filter{From: auditBase.Add(3 * time.Hour)}, want: []uint{5, 4}},
+		{name: "to is exclusive", filter: Filter{To: auditBase.Add(2 * time.Hour)}, want: []uint{2, 1}},
+		{name: "date range", filter: Filter{From: auditBase.Add(time.Hour), To: auditBase.Add(4 * time.Hour)}, want: []uint{4, 3, 2}},
+		{
+			name:   "combined filters",
+			filter: Filter{ActorID: 1, Action: "POST /admin/users/:id/deactivate", From: auditBase.Add(time.Minute)},
+			want:   []uint{5},
+		},
+		{name: "target and actor", filter: Filter{ActorID: 1, TargetID: "7"}, want: []uint{1}},
+		{name: "no match", filter: Filter{ActorID: 3}, want: []uint{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, total, err := repo.List(context.Background(), tt.filter, 1, 20)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, entryIDs(entries))
+			assert.Equal(t, int64(len(tt.want)), total)
+		})
+	}
+}
+
+func TestRepository_ListPagination(t *testing.T) {
+	repo := setupTestRepository(t)
+
+	entries, total, err := repo.List(context.Background(), Filter{}, 2, 2)
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), total)
+	assert.Equal(t, []uint{3, 2}, entryIDs(entries))
+}
+
+func TestRepository_Each(t *testing.T) {
+	repo := setupTestRepository(t)
+
+	var ids []uint
+	err := repo.Each(context.Background(), Filter{TargetID: "7"}, func(e *Entry) error {
+		ids = append(ids, e.ID)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []uint{4, 1}, ids)
+}
@@ -0,0 +1,91 @@
+package auth
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/yeegeek/uyou-go-api-starter/internal/config"
+)
+
+const defaultAccessCookieName = "access_token"
+
+// AccessCookie 在 HttpOnly Cookie 中下发访问令牌，浏览器客户端无需在脚本中保存令牌
+// 只随刷新令牌 Cookie 一起下发，复用其域名和 CSRF Cookie；Path 固定为 "/"，以便所有接口都能收到
+type AccessCookie struct {
+	enabled  bool
+	name     string
+	csrfName string
+	domain   string
+	maxAge   time.Duration
+}
+
+// NewAccessCookie 根据 JWT 配置创建访问令牌 Cookie，Cookie 有效期与访问令牌一致
+func NewAccessCookie(cfg *config.JWTConfig) *AccessCookie {
+	name := cfg.AccessCookie.Name
+	if name == "" {
+		name = defaultAccessCookieName
+	}
+	csrfName := cfg.RefreshCookie.CSRFCookieName
+	if csrfName == "" {
+		csrfName = defaultCSRFCookieName
+	}
+
+	return &AccessCookie{
+		enabled:  cfg.AccessCookie.Enabled && cfg.RefreshCookie.Enabled,
+		name:     name,
+		csrfName: csrfName,
+		domain:   cfg.RefreshCookie.Domain,
+		maxAge:   cfg.GetAccessTokenTTL(),
+	}
+}
+
+// Enabled 是否启用访问令牌 Cookie，nil 表示未启用
+func (ac *AccessCookie) Enabled() bool {
+	return ac != nil && ac.enabled
+}
+
+// Set 写入访问令牌 Cookie
+func (ac *AccessCookie) Set(c *gin.Context, accessToken string) {
+	http.SetCookie(c.Writer, ac.cookie(accessToken, int(ac.maxAge.Seconds())))
+}
+
+// Clear 删除访问令牌 Cookie
+func (ac *AccessCookie) Clear(c *gin.Context) {
+	http.SetCookie(c.Writer, ac.cookie("", -1))
+}
+
+// Token 读取请求中的访问令牌 Cookie
+func (ac *AccessCookie) Token(c *gin.Context) (string, bool) {
+	if !ac.Enabled() {
+		return "", false
+	}
+	token, err := c.Cookie(ac.name)
+	if err != nil || token == "" {
+		return "", false
+	}
+	return token, true
+}
+
+// VerifyCSRF 校验请求头中的 CSRF 令牌与 CSRF Cookie 一致；GET、HEAD、OPTIONS 请求无需校验
+func (ac *AccessCookie) VerifyCSRF(c *gin.Context) bool {
+	switch c.Request.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	return verifyCSRF(c, ac.csrfName)
+}
+
+func (ac *AccessCookie) cookie(value string, maxAge int) *http.Cookie {
+	return &http.Cookie{
+		Name:     ac.name,
+		Value:    value,
+		Domain:   ac.domain,
+		Path:     "/",
+		MaxAge:   maxAge,
+		Secure:   true,
+		HttpOnly: true,
+		SameSite: http.SameSiteStrictMode,
+	}
+}
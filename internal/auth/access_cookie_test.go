@@ -0,0 +1,101 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yeegeek/uyou-go-api-starter/internal/config"
+)
+
+func accessCookieConfig() *config.JWTConfig {
+	return &config.JWTConfig{
+		Secret:         "test-secret-that-is-long-enough-123",
+		AccessTokenTTL: 15 * time.Minute,
+		RefreshCookie:  config.RefreshCookieConfig{Enabled: true, Domain: "example.com"},
+		AccessCookie:   config.AccessCookieConfig{Enabled: true},
+	}
+}
+
+func TestAccessCookie_Set(t *testing.T) {
+	ac := NewAccessCookie(accessCookieConfig())
+	require.True(t, ac.Enabled())
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	ac.Set(c, "access-value")
+
+	cookies := w.Result().Cookies()
+	require.Len(t, cookies, 1)
+	access := cookies[0]
+	assert.Equal(t, "access_token", access.Name)
+	assert.Equal(t, "access-value", access.Value)
+	assert.Equal(t, "example.com", access.Domain)
+	assert.Equal(t, "/", access.Path)
+	assert.Equal(t, 900, access.MaxAge)
+	assert.True(t, access.HttpOnly)
+	assert.True(t, access.Secure)
+	assert.Equal(t, http.SameSiteStrictMode, access.SameSite)
+}
+
+func TestAccessCookie_RequiresRefreshCookie(t *testing.T) {
+	cfg := accessCookieConfig()
+	cfg.RefreshCookie.Enabled = false
+	assert.False(t, NewAccessCookie(cfg).Enabled())
+
+	var ac *AccessCookie
+	assert.False(t, ac.Enabled())
+}
+
+func TestAuthMiddleware_AccessCookie(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := accessCookieConfig()
+	svc := NewService(cfg)
+	token, err := svc.GenerateToken(7, "cookie@example.com", "Cookie User")
+	require.NoError(t, err)
+
+	router := gin.New()
+	handler := func(c *gin.Context) { c.Status(http.StatusOK) }
+	router.GET("/me", AuthMiddleware(svc, WithAccessCookie(NewAccessCookie(cfg))), handler)
+	router.POST("/me", AuthMiddleware(svc, WithAccessCookie(NewAccessCookie(cfg))), handler)
+	router.GET("/plain", AuthMiddleware(svc), handler)
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		header     map[string]string
+		cookies    map[string]string
+		wantStatus int
+	}{
+		{"GET via cookie", http.MethodGet, "/me", nil, map[string]string{"access_token": token}, http.StatusOK},
+		{"POST via cookie without CSRF", http.MethodPost, "/me", nil, map[string]string{"access_token": token}, http.StatusForbidden},
+		{"POST via cookie with CSRF", http.MethodPost, "/me", map[string]string{CSRFTokenHeader: "csrf"},
+			map[string]string{"access_token": token, "csrf_token": "csrf"}, http.StatusOK},
+		{"invalid cookie token", http.MethodGet, "/me", nil, map[string]string{"access_token": "garbage"}, http.StatusUnauthorized},
+		{"header takes precedence", http.MethodGet, "/me", map[string]string{"Authorization": "Bearer garbage"},
+			map[string]string{"access_token": token}, http.StatusUnauthorized},
+		{"no token", http.MethodGet, "/me", nil, nil, http.StatusUnauthorized},
+		{"cookie ignored without option", http.MethodGet, "/plain", nil, map[string]string{"access_token": token}, http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			for k, v := range tt.header {
+				req.Header.Set(k, v)
+			}
+			for name, value := range tt.cookies {
+				req.AddCookie(&http.Cookie{Name: name, Value: value})
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			assert.Equal(t, tt.wantStatus, w.Code)
+		})
+	}
+}
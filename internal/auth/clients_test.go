@@ -0,0 +1,101 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yeegeek/uyou-go-api-starter/internal/config"
+)
+
+func setupClientServiceTest(t *testing.T) (*service, func(refreshToken string) RefreshToken) {
+	svc, db := setupServiceTest(t)
+	svc.clients = newClientPolicies([]config.ClientConfig{
+		{ID: "web", Name: "Web app", AccessTokenTTL: 10 * time.Minute, RefreshTokenTTL: 7 * 24 * time.Hour},
+		{ID: "mobile", Name: "Mobile app", AccessTokenTTL: 30 * time.Minute, RefreshTokenTTL: 30 * 24 * time.Hour},
+		{ID: "kiosk", Grants: []string{config.ClientGrantPassword}},
+	})
+	stored := func(refreshToken string) RefreshToken {
+		var token RefreshToken
+		require.NoError(t, db.Where("token_hash = ?", HashToken(refreshToken)).First(&token).Error)
+		return token
+	}
+	return svc, stored
+}
+
+func TestService_GenerateTokenPair_PerClientLifetimes(t *testing.T) {
+	svc, stored := setupClientServiceTest(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name          string
+		clientID      string
+		wantClientID  string
+		wantExpiresIn int64
+		wantRefresh   time.Duration
+	}{
+		{name: "web", clientID: "web", wantClientID: "web", wantExpiresIn: 600, wantRefresh: 7 * 24 * time.Hour},
+		{name: "mobile", clientID: "mobile", wantClientID: "mobile", wantExpiresIn: 1800, wantRefresh: 30 * 24 * time.Hour},
+		{name: "unknown client uses global lifetimes", clientID: "tv", wantExpiresIn: 900, wantRefresh: 7 * 24 * time.Hour},
+		{name: "no client uses global lifetimes", wantExpiresIn: 900, wantRefresh: 7 * 24 * time.Hour},
+		{name: "client without lifetimes uses global lifetimes", clientID: "kiosk", wantClientID: "kiosk", wantExpiresIn: 900, wantRefresh: 7 * 24 * time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pair, err := svc.GenerateTokenPair(ctx, 1, "test@example.com", "Test User", WithClient(tt.clientID, config.ClientGrantPassword))
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantExpiresIn, pair.ExpiresIn)
+
+			claims, err := svc.ValidateToken(pair.AccessToken)
+			require.NoError(t, err)
+			assert.WithinDuration(t, time.Now().Add(time.Duration(tt.wantExpiresIn)*time.Second), claims.ExpiresAt, 5*time.Second)
+
+			token := stored(pair.RefreshToken)
+			assert.Equal(t, tt.wantClientID, token.ClientID)
+			assert.WithinDuration(t, time.Now().Add(tt.wantRefresh), token.ExpiresAt, time.Minute)
+		})
+	}
+}
+
+func TestService_RefreshAccessToken_KeepsClientLifetimes(t *testing.T) {
+	svc, stored := setupClientServiceTest(t)
+	ctx := context.Background()
+
+	pair, err := svc.GenerateTokenPair(ctx, 1, "test@example.com", "Test User", WithClient("mobile", config.ClientGrantPassword))
+	require.NoError(t, err)
+
+	rotated, err := svc.RefreshAccessToken(ctx, pair.RefreshToken)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1800), rotated.ExpiresIn)
+
+	token := stored(rotated.RefreshToken)
+	assert.Equal(t, "mobile", token.ClientID)
+	assert.WithinDuration(t, time.Now().Add(30*24*time.Hour), token.ExpiresAt, time.Minute)
+
+	sessions, err := svc.ListSessions(ctx, SessionFilter{UserID: 1})
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	assert.Equal(t, "mobile", sessions[0].ClientID)
+}
+
+func TestService_ClientGrants(t *testing.T) {
+	svc, _ := setupClientServiceTest(t)
+	ctx := context.Background()
+
+	assert.NoError(t, svc.CheckClientGrant("kiosk", config.ClientGrantPassword))
+	assert.ErrorIs(t, svc.CheckClientGrant("kiosk", config.ClientGrantRegister), ErrGrantNotAllowed)
+	assert.NoError(t, svc.CheckClientGrant("web", config.ClientGrantOAuth), "clients without grants allow every grant")
+	assert.NoError(t, svc.CheckClientGrant("unknown", config.ClientGrantRegister), "unknown clients are not restricted")
+
+	_, err := svc.GenerateTokenPair(ctx, 1, "test@example.com", "Test User", WithClient("kiosk", config.ClientGrantOAuth))
+	assert.ErrorIs(t, err, ErrGrantNotAllowed)
+
+	pair, err := svc.GenerateTokenPair(ctx, 1, "test@example.com", "Test User", WithClient("kiosk", config.ClientGrantPassword))
+	require.NoError(t, err)
+	_, err = svc.RefreshAccessToken(ctx, pair.RefreshToken)
+	assert.ErrorIs(t, err, ErrGrantNotAllowed, "kiosk sessions cannot be refreshed")
+}
@@ -1,11 +1,25 @@
 package auth
 
+import "time"
+
 // Claims 表示 JWT 令牌的声明信息
 type Claims struct {
 	UserID uint     `json:"user_id"` // 用户ID
 	Email  string   `json:"email"`   // 用户邮箱
 	Name   string   `json:"name"`    // 用户姓名
 	Roles  []string `json:"roles"`   // 用户角色列表
+	// Permissions 用户通过角色获得的权限列表（如 "users:delete"）
+	Permissions []string `json:"permissions,omitempty"`
+	// Orgs 用户所属组织 ID 到组织角色（owner/admin/member）的映射，启用组织声明时写入
+	Orgs map[uint]string `json:"orgs,omitempty"`
+	// TokenVersion 签发时的用户令牌版本（仅在启用 EnforceTokenVersion 时写入）
+	TokenVersion int `json:"token_version,omitempty"`
+	// ExpiresAt 访问令牌的过期时间，用于判断是否需要自动续期
+	ExpiresAt time.Time `json:"-"`
+	// NotBefore 令牌生效时间（nbf），签发时为未来时间则令牌在此之前不可用
+	NotBefore time.Time `json:"-"`
+	// ImpersonatorID 管理员模拟登录时的管理员ID，普通令牌为 0；令牌中同时写入 impersonator_id 和 impersonated_by 两个声明
+	ImpersonatorID uint `json:"impersonator_id,omitempty"`
 }
 
 // TokenResponse 表示令牌响应（已废弃：请使用 TokenPairResponse）
@@ -15,13 +29,13 @@ type TokenResponse struct {
 
 // TokenPairResponse 表示访问令牌和刷新令牌对的响应
 type TokenPairResponse struct {
-	AccessToken  string `json:"access_token"`  // 访问令牌
-	RefreshToken string `json:"refresh_token"` // 刷新令牌
-	TokenType    string `json:"token_type"`    // 令牌类型（通常为 "Bearer"）
-	ExpiresIn    int64  `json:"expires_in"`    // 访问令牌过期时间（秒）
+	AccessToken  string `json:"access_token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`    // 访问令牌
+	RefreshToken string `json:"refresh_token,omitempty" example:"3q2-7wQ1RnKbq0vzWm4XbA.Yh2k9..."` // 刷新令牌（Cookie 模式下通过 Cookie 下发，不返回）
+	TokenType    string `json:"token_type" example:"Bearer"`                                       // 令牌类型（通常为 "Bearer"）
+	ExpiresIn    int64  `json:"expires_in" example:"900"`                                          // 访问令牌过期时间（秒）
 }
 
 // RefreshTokenRequest 表示刷新令牌请求
 type RefreshTokenRequest struct {
-	RefreshToken string `json:"refresh_token" binding:"required"` // 刷新令牌
+	RefreshToken string `json:"refresh_token" binding:"required" example:"3q2-7wQ1RnKbq0vzWm4XbA.Yh2k9..."` // 刷新令牌
 }
@@ -0,0 +1,65 @@
+// Package auth 提供管理员模拟登录授权记录的数据模型和仓库接口
+package auth
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ImpersonationGrant records an admin impersonating a user; it doubles as the audit trail
+type ImpersonationGrant struct {
+	ID             uuid.UUID `gorm:"type:uuid;primary_key"`
+	ImpersonatorID uint      `gorm:"not null;index"`
+	TargetUserID   uint      `gorm:"not null;index"`
+	Reason         string    `gorm:"type:varchar(255);not null"`
+	ExpiresAt      time.Time `gorm:"not null;index"`
+	CreatedAt      time.Time `gorm:"default:CURRENT_TIMESTAMP"`
+}
+
+// BeforeCreate is a GORM hook that sets the ID and CreatedAt before creating the record
+func (g *ImpersonationGrant) BeforeCreate(tx *gorm.DB) error {
+	if g.ID == uuid.Nil {
+		g.ID = uuid.New()
+	}
+	if g.CreatedAt.IsZero() {
+		g.CreatedAt = time.Now()
+	}
+	return nil
+}
+
+// TableName specifies the table name for ImpersonationGrant
+func (ImpersonationGrant) TableName() string {
+	return "impersonation_grants"
+}
+
+// ImpersonationRepository defines the interface for impersonation grant operations
+type ImpersonationRepository interface {
+	Create(ctx context.Context, grant *ImpersonationGrant) error
+	ListActive(ctx context.Context, now time.Time) ([]ImpersonationGrant, error)
+}
+
+type impersonationRepository struct {
+	db *gorm.DB
+}
+
+// NewImpersonationRepository creates a new impersonation grant repository
+func NewImpersonationRepository(db *gorm.DB) ImpersonationRepository {
+	return &impersonationRepository{db: db}
+}
+
+func (r *impersonationRepository) Create(ctx context.Context, grant *ImpersonationGrant) error {
+	return r.db.WithContext(ctx).Create(grant).Error
+}
+
+// ListActive returns grants whose tokens have not expired yet, newest first
+func (r *impersonationRepository) ListActive(ctx context.Context, now time.Time) ([]ImpersonationGrant, error) {
+	var grants []ImpersonationGrant
+	err := r.db.WithContext(ctx).
+		Where("expires_at > ?", now).
+		Order("created_at DESC").
+		Find(&grants).Error
+	return grants, err
+}
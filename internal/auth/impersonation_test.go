@@ -0,0 +1,166 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func setupImpersonationTest(t *testing.T) (*service, *gorm.DB) {
+	svc, db := setupServiceTest(t)
+	require.NoError(t, db.AutoMigrate(&ImpersonationGrant{}))
+	svc.impersonationRepo = NewImpersonationRepository(db)
+	svc.impersonationTTL = 5 * time.Minute
+
+	require.NoError(t, db.Create(&testRole{ID: 2, Name: "admin"}).Error)
+	require.NoError(t, db.Create(&testUser{ID: 2, Name: "Admin", Email: "admin@example.com", PasswordHash: "hash"}).Error)
+	require.NoError(t, db.Create(&testUserRole{UserID: 2, RoleID: 2}).Error)
+	require.NoError(t, db.Create(&testUser{ID: 3, Name: "Other Admin", Email: "other@example.com", PasswordHash: "hash"}).Error)
+	require.NoError(t, db.Create(&testUserRole{UserID: 3, RoleID: 2}).Error)
+
+	return svc, db
+}
+
+func TestService_GenerateImpersonationToken(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("token carries target identity and impersonator", func(t *testing.T) {
+		svc, db := setupImpersonationTest(t)
+
+		token, err := svc.GenerateImpersonationToken(ctx, 2, 1, "test@example.com", "Test User", "ticket #42")
+		require.NoError(t, err)
+		assert.Equal(t, "Bearer", token.TokenType)
+		assert.Equal(t, int64(300), token.ExpiresIn)
+
+		claims, err := svc.ValidateToken(token.AccessToken)
+		require.NoError(t, err)
+		assert.Equal(t, uint(1), claims.UserID)
+		assert.Equal(t, "test@example.com", claims.Email)
+		assert.Equal(t, []string{"user"}, claims.Roles)
+		assert.Equal(t, uint(2), claims.ImpersonatorID)
+		assert.WithinDuration(t, time.Now().Add(5*time.Minute), claims.ExpiresAt, 5*time.Second)
+
+		raw := jwt.MapClaims{}
+		_, err = jwt.ParseWithClaims(token.AccessToken, raw, func(*jwt.Token) (interface{}, error) {
+			return []byte(svc.jwtSecret), nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "2", raw["impersonator_id"])
+		assert.Equal(t, "2", raw["impersonated_by"])
+
+		var grant ImpersonationGrant
+		require.NoError(t, db.First(&grant, "id = ?", token.GrantID).Error)
+		assert.Equal(t, uint(2), grant.ImpersonatorID)
+		assert.Equal(t, uint(1), grant.TargetUserID)
+		assert.Equal(t, "ticket #42", grant.Reason)
+	})
+
+	t.Run("no refresh token is issued", func(t *testing.T) {
+		svc, db := setupImpersonationTest(t)
+
+		_, err := svc.GenerateImpersonationToken(ctx, 2, 1, "test@example.com", "Test User", "ticket #42")
+		require.NoError(t, err)
+
+		var count int64
+		require.NoError(t, db.Model(&RefreshToken{}).Count(&count).Error)
+		assert.Zero(t, count)
+	})
+
+	t.Run("impersonation tokens cannot be renewed", func(t *testing.T) {
+		svc, _ := setupImpersonationTest(t)
+
+		token, err := svc.GenerateImpersonationToken(ctx, 2, 1, "test@example.com", "Test User", "ticket #42")
+		require.NoError(t, err)
+		claims, err := svc.ValidateToken(token.AccessToken)
+		require.NoError(t, err)
+
+		_, err = svc.RenewAccessToken(ctx, claims)
+		assert.ErrorIs(t, err, ErrImpersonationNotRenewable)
+	})
+
+	t.Run("impersonated_by alone identifies the impersonator", func(t *testing.T) {
+		svc, _ := setupImpersonationTest(t)
+
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+			"sub":             "1",
+			"exp":             time.Now().Add(time.Minute).Unix(),
+			"impersonated_by": "2",
+		})
+		signed, err := token.SignedString([]byte(svc.jwtSecret))
+		require.NoError(t, err)
+
+		claims, err := svc.ValidateToken(signed)
+		require.NoError(t, err)
+		assert.Equal(t, uint(2), claims.ImpersonatorID)
+	})
+
+	t.Run("renewal middleware does not extend impersonation tokens", func(t *testing.T) {
+		svc, _ := setupImpersonationTest(t)
+		// Short enough that a regular token would be renewed on this request
+		svc.impersonationTTL = 30 * time.Second
+
+		token, err := svc.GenerateImpersonationToken(ctx, 2, 1, "test@example.com", "Test User", "ticket #42")
+		require.NoError(t, err)
+
+		gin.SetMode(gin.TestMode)
+		router := gin.New()
+		router.GET("/me", AuthMiddleware(svc), TokenRenewalMiddleware(svc, time.Minute), func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/me", nil)
+		req.Header.Set(AuthorizationHeader, "Bearer "+token.AccessToken)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "true", w.Header().Get(ImpersonatingHeader))
+		assert.Empty(t, w.Header().Get(NewAccessTokenHeader))
+	})
+
+	t.Run("self impersonation is rejected", func(t *testing.T) {
+		svc, _ := setupImpersonationTest(t)
+
+		_, err := svc.GenerateImpersonationToken(ctx, 2, 2, "admin@example.com", "Admin", "ticket #42")
+		assert.ErrorIs(t, err, ErrImpersonateSelf)
+	})
+
+	t.Run("admin target requires config flag", func(t *testing.T) {
+		svc, _ := setupImpersonationTest(t)
+
+		_, err := svc.GenerateImpersonationToken(ctx, 2, 3, "other@example.com", "Other Admin", "ticket #42")
+		assert.ErrorIs(t, err, ErrImpersonateAdmin)
+
+		svc.allowAdminImpersonation = true
+		token, err := svc.GenerateImpersonationToken(ctx, 2, 3, "other@example.com", "Other Admin", "ticket #42")
+		require.NoError(t, err)
+		assert.NotEmpty(t, token.AccessToken)
+	})
+}
+
+func TestService_ListActiveImpersonations(t *testing.T) {
+	svc, db := setupImpersonationTest(t)
+	ctx := context.Background()
+
+	require.NoError(t, db.Create(&ImpersonationGrant{
+		ImpersonatorID: 2,
+		TargetUserID:   1,
+		Reason:         "expired session",
+		ExpiresAt:      time.Now().Add(-time.Minute),
+	}).Error)
+
+	token, err := svc.GenerateImpersonationToken(ctx, 2, 1, "test@example.com", "Test User", "ticket #42")
+	require.NoError(t, err)
+
+	grants, err := svc.ListActiveImpersonations(ctx)
+	require.NoError(t, err)
+	require.Len(t, grants, 1)
+	assert.Equal(t, token.GrantID, grants[0].ID)
+}
@@ -2,8 +2,10 @@
 package auth
 
 import (
+	"errors"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -15,32 +17,89 @@ const (
 	UserIDKey = "userID"
 	// KeyUser is the context key for JWT claims
 	KeyUser = "user"
+	// NewAccessTokenHeader is the response header carrying a renewed access token
+	NewAccessTokenHeader = "X-New-Access-Token"
+	// ImpersonatingHeader is set on responses to requests made with an impersonation token
+	ImpersonatingHeader = "X-Impersonating"
 )
 
+// defaultAutoRenewWindow is used when no renewal window is configured
+const defaultAutoRenewWindow = 2 * time.Minute
+
+// MiddlewareOption configures AuthMiddleware and OptionalAuthMiddleware
+type MiddlewareOption func(*middlewareOptions)
+
+type middlewareOptions struct {
+	accessCookie *AccessCookie
+}
+
+// WithAccessCookie accepts the access token from the access cookie when the Authorization header is absent.
+// Cookie-authenticated requests other than GET, HEAD and OPTIONS must echo the CSRF cookie in X-CSRF-Token.
+func WithAccessCookie(ac *AccessCookie) MiddlewareOption {
+	return func(o *middlewareOptions) {
+		o.accessCookie = ac
+	}
+}
+
+func newMiddlewareOptions(opts []MiddlewareOption) middlewareOptions {
+	var options middlewareOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return options
+}
+
 // AuthMiddleware creates a middleware that validates JWT tokens
-func AuthMiddleware(authService Service) gin.HandlerFunc {
+func AuthMiddleware(authService Service, opts ...MiddlewareOption) gin.HandlerFunc {
+	options := newMiddlewareOptions(opts)
+
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader(AuthorizationHeader)
+		var tokenString string
 		if authHeader == "" {
+			token, ok := options.accessCookie.Token(c)
+			if !ok {
+				c.JSON(http.StatusUnauthorized, gin.H{
+					"error": "authorization header required",
+				})
+				c.Abort()
+				return
+			}
+			if !options.accessCookie.VerifyCSRF(c) {
+				c.JSON(http.StatusForbidden, gin.H{
+					"error": "invalid CSRF token",
+				})
+				c.Abort()
+				return
+			}
+			tokenString = token
+		} else {
+			parts := strings.SplitN(authHeader, " ", 2)
+			if len(parts) != 2 || parts[0] != "Bearer" {
+				c.JSON(http.StatusUnauthorized, gin.H{
+					"error": "invalid authorization header format",
+				})
+				c.Abort()
+				return
+			}
+			tokenString = parts[1]
+		}
+
+		claims, err := authService.ValidateToken(tokenString)
+		if errors.Is(err, ErrStaleToken) {
 			c.JSON(http.StatusUnauthorized, gin.H{
-				"error": "authorization header required",
+				"error": "token is stale, please refresh",
 			})
 			c.Abort()
 			return
 		}
-
-		parts := strings.SplitN(authHeader, " ", 2)
-		if len(parts) != 2 || parts[0] != "Bearer" {
+		if errors.Is(err, ErrTokenNotYetValid) {
 			c.JSON(http.StatusUnauthorized, gin.H{
-				"error": "invalid authorization header format",
+				"error": "token is not yet valid",
 			})
 			c.Abort()
 			return
 		}
-
-		tokenString := parts[1]
-
-		claims, err := authService.ValidateToken(tokenString)
 		if err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{
 				"error": "invalid or expired token",
@@ -49,11 +108,101 @@ func AuthMiddleware(authService Service) gin.HandlerFunc {
 			return
 		}
 
+		if claims.ImpersonatorID != 0 {
+			c.Header(ImpersonatingHeader, "true")
+		}
+
 		c.Set(KeyUser, claims)
 		c.Next()
 	}
 }
 
+// OptionalAuthMiddleware attaches the JWT claims when a valid Bearer token is present.
+// Missing or invalid tokens are ignored so the request continues anonymously;
+// use it for endpoints that adapt to the caller but must also serve guests.
+func OptionalAuthMiddleware(authService Service, opts ...MiddlewareOption) gin.HandlerFunc {
+	options := newMiddlewareOptions(opts)
+
+	return func(c *gin.Context) {
+		var tokenString string
+		parts := strings.SplitN(c.GetHeader(AuthorizationHeader), " ", 2)
+		if len(parts) == 2 && parts[0] == "Bearer" {
+			tokenString = parts[1]
+		} else if token, ok := options.accessCookie.Token(c); ok && options.accessCookie.VerifyCSRF(c) {
+			tokenString = token
+		}
+		if tokenString != "" {
+			if claims, err := authService.ValidateToken(tokenString); err == nil {
+				c.Set(KeyUser, claims)
+			}
+		}
+		c.Next()
+	}
+}
+
+// TokenRenewalMiddleware issues a fresh access token in the X-New-Access-Token
+// response header when the validated token expires within window.
+// The token is only issued for requests the handler completed successfully.
+// It must run after AuthMiddleware and never rotates the refresh token.
+func TokenRenewalMiddleware(authService Service, window time.Duration) gin.HandlerFunc {
+	if window <= 0 {
+		window = defaultAutoRenewWindow
+	}
+
+	return func(c *gin.Context) {
+		value, exists := c.Get(KeyUser)
+		claims, ok := value.(*Claims)
+		if !exists || !ok || claims.ExpiresAt.IsZero() || time.Until(claims.ExpiresAt) > window {
+			c.Next()
+			return
+		}
+
+		w := &renewalWriter{ResponseWriter: c.Writer, ctx: c, authService: authService, claims: claims}
+		c.Writer = w
+		c.Next()
+		// WHY: Handlers that only set a status (e.g. 204) never write through c.Writer; gin commits their headers after this returns
+		w.renew()
+	}
+}
+
+// renewalWriter 在响应头提交前签发续期令牌，处理器返回错误或 4xx/5xx 时不签发
+type renewalWriter struct {
+	gin.ResponseWriter
+	ctx         *gin.Context
+	authService Service
+	claims      *Claims
+	done        bool
+}
+
+func (w *renewalWriter) renew() {
+	if w.done || w.ResponseWriter.Written() {
+		return
+	}
+	w.done = true
+	if len(w.ctx.Errors) > 0 || w.Status() >= http.StatusBadRequest {
+		return
+	}
+	// WHY: Renewal is best-effort - the current token is still valid, so a failure must not fail the request
+	if token, err := w.authService.RenewAccessToken(w.ctx.Request.Context(), w.claims); err == nil {
+		w.Header().Set(NewAccessTokenHeader, token)
+	}
+}
+
+func (w *renewalWriter) WriteHeaderNow() {
+	w.renew()
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *renewalWriter) Write(b []byte) (int, error) {
+	w.renew()
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *renewalWriter) WriteString(s string) (int, error) {
+	w.renew()
+	return w.ResponseWriter.WriteString(s)
+}
+
 // GetUserIDFromContext extracts user ID from gin context
 func GetUserIDFromContext(c *gin.Context) (uint, bool) {
 	userID, exists := c.Get(UserIDKey)
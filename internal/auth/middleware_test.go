@@ -1,4 +1,4 @@
-package auth
+package auth_test
 
 import (
 	"context"
@@ -6,67 +6,25 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
-)
-
-// MockAuthService is a mock implementation of Service interface
-type MockAuthService struct {
-	mock.Mock
-}
-
-func (m *MockAuthService) GenerateToken(userID uint, email string, name string) (string, error) {
-	args := m.Called(userID, email, name)
-	return args.String(0), args.Error(1)
-}
-
-func (m *MockAuthService) GenerateTokenPair(ctx context.Context, userID uint, email string, name string) (*TokenPair, error) {
-	args := m.Called(ctx, userID, email, name)
-	if args.Get(0) == nil {
-		return nil, args.Error(1)
-	}
-	return args.Get(0).(*TokenPair), args.Error(1)
-}
-
-func (m *MockAuthService) RefreshAccessToken(ctx context.Context, refreshToken string) (*TokenPair, error) {
-	args := m.Called(ctx, refreshToken)
-	if args.Get(0) == nil {
-		return nil, args.Error(1)
-	}
-	return args.Get(0).(*TokenPair), args.Error(1)
-}
-
-func (m *MockAuthService) ValidateToken(tokenString string) (*Claims, error) {
-	args := m.Called(tokenString)
-	if args.Get(0) == nil {
-		return nil, args.Error(1)
-	}
-	return args.Get(0).(*Claims), args.Error(1)
-}
-
-func (m *MockAuthService) RevokeRefreshToken(ctx context.Context, refreshToken string) error {
-	args := m.Called(ctx, refreshToken)
-	return args.Error(0)
-}
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
 
-func (m *MockAuthService) RevokeUserRefreshToken(ctx context.Context, userID uint, refreshToken string) error {
-	args := m.Called(ctx, userID, refreshToken)
-	return args.Error(0)
-}
-
-func (m *MockAuthService) RevokeAllUserTokens(ctx context.Context, userID uint) error {
-	args := m.Called(ctx, userID)
-	return args.Error(0)
-}
+	"github.com/yeegeek/uyou-go-api-starter/internal/auth"
+	"github.com/yeegeek/uyou-go-api-starter/internal/config"
+	"github.com/yeegeek/uyou-go-api-starter/internal/testutil"
+)
 
-func setupTestRouter(authService Service) *gin.Engine {
+func setupTestRouter(authService auth.Service) *gin.Engine {
 	gin.SetMode(gin.TestMode)
 	r := gin.New()
 
 	protected := r.Group("/api")
-	protected.Use(AuthMiddleware(authService))
+	protected.Use(auth.AuthMiddleware(authService))
 	protected.GET("/protected", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{"message": "success"})
 	})
@@ -78,15 +36,15 @@ func TestAuthMiddleware(t *testing.T) {
 	tests := []struct {
 		name           string
 		authHeader     string
-		setupMock      func(*MockAuthService)
+		setupMock      func(*testutil.MockAuthService)
 		expectedStatus int
 		expectedBody   string
 	}{
 		{
 			name:       "successful authentication",
 			authHeader: "Bearer valid-token",
-			setupMock: func(m *MockAuthService) {
-				claims := &Claims{
+			setupMock: func(m *testutil.MockAuthService) {
+				claims := &auth.Claims{
 					UserID: 123,
 					Email:  "test@example.com",
 					Name:   "Test User",
@@ -99,45 +57,63 @@ func TestAuthMiddleware(t *testing.T) {
 		{
 			name:           "missing authorization header",
 			authHeader:     "",
-			setupMock:      func(m *MockAuthService) {},
+			setupMock:      func(m *testutil.MockAuthService) {},
 			expectedStatus: http.StatusUnauthorized,
 			expectedBody:   `{"error":"authorization header required"}`,
 		},
 		{
 			name:           "invalid authorization header format - no Bearer",
 			authHeader:     "invalid-token",
-			setupMock:      func(m *MockAuthService) {},
+			setupMock:      func(m *testutil.MockAuthService) {},
 			expectedStatus: http.StatusUnauthorized,
 			expectedBody:   `{"error":"invalid authorization header format"}`,
 		},
 		{
 			name:           "invalid authorization header format - wrong scheme",
 			authHeader:     "Basic dGVzdDp0ZXN0",
-			setupMock:      func(m *MockAuthService) {},
+			setupMock:      func(m *testutil.MockAuthService) {},
 			expectedStatus: http.StatusUnauthorized,
 			expectedBody:   `{"error":"invalid authorization header format"}`,
 		},
 		{
 			name:           "invalid authorization header format - no token",
 			authHeader:     "Bearer",
-			setupMock:      func(m *MockAuthService) {},
+			setupMock:      func(m *testutil.MockAuthService) {},
 			expectedStatus: http.StatusUnauthorized,
 			expectedBody:   `{"error":"invalid authorization header format"}`,
 		},
 		{
 			name:       "invalid token",
 			authHeader: "Bearer invalid-token",
-			setupMock: func(m *MockAuthService) {
-				m.On("ValidateToken", "invalid-token").Return(nil, ErrInvalidToken)
+			setupMock: func(m *testutil.MockAuthService) {
+				m.On("ValidateToken", "invalid-token").Return(nil, auth.ErrInvalidToken)
 			},
 			expectedStatus: http.StatusUnauthorized,
 			expectedBody:   `{"error":"invalid or expired token"}`,
 		},
+		{
+			name:       "stale token after role change",
+			authHeader: "Bearer stale-token",
+			setupMock: func(m *testutil.MockAuthService) {
+				m.On("ValidateToken", "stale-token").Return(nil, auth.ErrStaleToken)
+			},
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   `{"error":"token is stale, please refresh"}`,
+		},
+		{
+			name:       "token used before nbf",
+			authHeader: "Bearer scheduled-token",
+			setupMock: func(m *testutil.MockAuthService) {
+				m.On("ValidateToken", "scheduled-token").Return(nil, auth.ErrTokenNotYetValid)
+			},
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   `{"error":"token is not yet valid"}`,
+		},
 		{
 			name:       "expired token",
 			authHeader: "Bearer expired-token",
-			setupMock: func(m *MockAuthService) {
-				m.On("ValidateToken", "expired-token").Return(nil, ErrExpiredToken)
+			setupMock: func(m *testutil.MockAuthService) {
+				m.On("ValidateToken", "expired-token").Return(nil, auth.ErrExpiredToken)
 			},
 			expectedStatus: http.StatusUnauthorized,
 			expectedBody:   `{"error":"invalid or expired token"}`,
@@ -145,7 +121,7 @@ func TestAuthMiddleware(t *testing.T) {
 		{
 			name:       "service error",
 			authHeader: "Bearer error-token",
-			setupMock: func(m *MockAuthService) {
+			setupMock: func(m *testutil.MockAuthService) {
 				m.On("ValidateToken", "error-token").Return(nil, errors.New("service error"))
 			},
 			expectedStatus: http.StatusUnauthorized,
@@ -155,14 +131,14 @@ func TestAuthMiddleware(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			mockService := &MockAuthService{}
+			mockService := &testutil.MockAuthService{}
 			tt.setupMock(mockService)
 
 			router := setupTestRouter(mockService)
 
 			req, _ := http.NewRequest("GET", "/api/protected", nil)
 			if tt.authHeader != "" {
-				req.Header.Set(AuthorizationHeader, tt.authHeader)
+				req.Header.Set(auth.AuthorizationHeader, tt.authHeader)
 			}
 
 			w := httptest.NewRecorder()
@@ -177,8 +153,8 @@ func TestAuthMiddleware(t *testing.T) {
 }
 
 func TestAuthMiddleware_ContextSetting(t *testing.T) {
-	mockService := &MockAuthService{}
-	claims := &Claims{
+	mockService := &testutil.MockAuthService{}
+	claims := &auth.Claims{
 		UserID: 123,
 		Email:  "test@example.com",
 		Name:   "Test User",
@@ -188,15 +164,15 @@ func TestAuthMiddleware_ContextSetting(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	r := gin.New()
 
-	r.Use(AuthMiddleware(mockService))
+	r.Use(auth.AuthMiddleware(mockService))
 	r.GET("/test", func(c *gin.Context) {
-		user, exists := c.Get(KeyUser)
+		user, exists := c.Get(auth.KeyUser)
 		if !exists {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "user not found in context"})
 			return
 		}
 
-		userClaims, ok := user.(*Claims)
+		userClaims, ok := user.(*auth.Claims)
 		if !ok {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "invalid user type in context"})
 			return
@@ -210,7 +186,7 @@ func TestAuthMiddleware_ContextSetting(t *testing.T) {
 	})
 
 	req, _ := http.NewRequest("GET", "/test", nil)
-	req.Header.Set(AuthorizationHeader, "Bearer valid-token")
+	req.Header.Set(auth.AuthorizationHeader, "Bearer valid-token")
 
 	w := httptest.NewRecorder()
 	r.ServeHTTP(w, req)
@@ -222,14 +198,244 @@ func TestAuthMiddleware_ContextSetting(t *testing.T) {
 	mockService.AssertExpectations(t)
 }
 
+func TestAuthMiddleware_ImpersonatingHeader(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		claims     *auth.Claims
+		wantHeader string
+	}{
+		{
+			name:       "impersonation token",
+			claims:     &auth.Claims{UserID: 1, ImpersonatorID: 2},
+			wantHeader: "true",
+		},
+		{
+			name:       "regular token",
+			claims:     &auth.Claims{UserID: 1},
+			wantHeader: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &testutil.MockAuthService{}
+			mockService.On("ValidateToken", "token").Return(tt.claims, nil)
+
+			r := gin.New()
+			r.Use(auth.AuthMiddleware(mockService))
+			r.GET("/test", func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			req, _ := http.NewRequest("GET", "/test", nil)
+			req.Header.Set(auth.AuthorizationHeader, "Bearer token")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, tt.wantHeader, w.Header().Get(auth.ImpersonatingHeader))
+		})
+	}
+}
+
+func TestOptionalAuthMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		authHeader string
+		wantUserID uint
+	}{
+		{name: "valid token sets claims", authHeader: "Bearer valid-token", wantUserID: 1},
+		{name: "missing header is anonymous", authHeader: "", wantUserID: 0},
+		{name: "invalid token is anonymous", authHeader: "Bearer invalid-token", wantUserID: 0},
+		{name: "malformed header is anonymous", authHeader: "valid-token", wantUserID: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &testutil.MockAuthService{}
+			mockService.On("ValidateToken", "valid-token").Return(&auth.Claims{UserID: 1}, nil)
+			mockService.On("ValidateToken", "invalid-token").Return(nil, errors.New("invalid token"))
+
+			var gotUserID uint
+			r := gin.New()
+			r.Use(auth.OptionalAuthMiddleware(mockService))
+			r.GET("/test", func(c *gin.Context) {
+				if claims, ok := c.Get(auth.KeyUser); ok {
+					gotUserID = claims.(*auth.Claims).UserID
+				}
+				c.Status(http.StatusOK)
+			})
+
+			req, _ := http.NewRequest("GET", "/test", nil)
+			if tt.authHeader != "" {
+				req.Header.Set(auth.AuthorizationHeader, tt.authHeader)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, tt.wantUserID, gotUserID)
+		})
+	}
+}
+
+// setupRenewalTest signs in user 123 against a SQLite-backed service whose access tokens live for ttl
+func setupRenewalTest(t *testing.T, ttl time.Duration) (auth.Service, *gorm.DB, string) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	db := testutil.NewSQLiteDB(t)
+	require.NoError(t, db.AutoMigrate(&auth.RefreshToken{}))
+	require.NoError(t, db.Exec(`INSERT INTO users (id, name, email, password_hash) VALUES (123, 'Test User', 'test@example.com', 'hash')`).Error)
+	require.NoError(t, db.Exec(`INSERT INTO user_roles (user_id, role_id) SELECT 123, id FROM roles WHERE name IN ('user', 'admin')`).Error)
+
+	svc := auth.NewServiceWithRepo(&config.JWTConfig{
+		Secret:          "test-secret-key-for-token-renewal",
+		AccessTokenTTL:  ttl,
+		RefreshTokenTTL: time.Hour,
+	}, db)
+	pair, err := svc.GenerateTokenPair(context.Background(), 123, "test@example.com", "Test User")
+	require.NoError(t, err)
+	return svc, db, pair.AccessToken
+}
+
+// renew sends token to a route responding with status behind the renewal middleware and returns the renewed token
+func renew(t *testing.T, svc auth.Service, token string, status int) string {
+	t.Helper()
+
+	r := gin.New()
+	r.Use(auth.AuthMiddleware(svc), auth.TokenRenewalMiddleware(svc, time.Minute))
+	r.GET("/test", func(c *gin.Context) {
+		c.JSON(status, gin.H{"message": http.StatusText(status)})
+	})
+
+	req, _ := http.NewRequest("GET", "/test", nil)
+	req.Header.Set(auth.AuthorizationHeader, "Bearer "+token)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	require.Equal(t, status, w.Code)
+	return w.Header().Get(auth.NewAccessTokenHeader)
+}
+
+func TestTokenRenewalMiddleware(t *testing.T) {
+	tests := []struct {
+		name       string
+		tokenTTL   time.Duration
+		wantHeader bool
+	}{
+		{name: "near-expiry token is renewed", tokenTTL: 30 * time.Second, wantHeader: true},
+		{name: "fresh token is not renewed", tokenTTL: 15 * time.Minute, wantHeader: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, _, token := setupRenewalTest(t, tt.tokenTTL)
+
+			renewed := renew(t, svc, token, http.StatusOK)
+			if !tt.wantHeader {
+				assert.Empty(t, renewed)
+				return
+			}
+
+			require.NotEmpty(t, renewed)
+			claims, err := svc.ValidateToken(renewed)
+			require.NoError(t, err)
+			assert.Equal(t, uint(123), claims.UserID)
+			assert.Equal(t, "test@example.com", claims.Email)
+			assert.Equal(t, "Test User", claims.Name)
+		})
+	}
+}
+
+func TestTokenRenewalMiddleware_RechecksUser(t *testing.T) {
+	t.Run("failed request is not renewed", func(t *testing.T) {
+		svc, _, token := setupRenewalTest(t, 30*time.Second)
+
+		assert.Empty(t, renew(t, svc, token, http.StatusForbidden))
+		assert.Empty(t, renew(t, svc, token, http.StatusInternalServerError))
+	})
+
+	t.Run("disabled user is not renewed", func(t *testing.T) {
+		svc, db, token := setupRenewalTest(t, 30*time.Second)
+		require.NoError(t, db.Exec(`UPDATE users SET active = FALSE WHERE id = 123`).Error)
+
+		assert.Empty(t, renew(t, svc, token, http.StatusOK))
+	})
+
+	t.Run("deleted user is not renewed", func(t *testing.T) {
+		svc, db, token := setupRenewalTest(t, 30*time.Second)
+		require.NoError(t, db.Exec(`UPDATE users SET deleted_at = CURRENT_TIMESTAMP WHERE id = 123`).Error)
+
+		assert.Empty(t, renew(t, svc, token, http.StatusOK))
+	})
+
+	t.Run("logged out user is not renewed", func(t *testing.T) {
+		svc, _, token := setupRenewalTest(t, 30*time.Second)
+		_, err := svc.RevokeAllUserTokens(context.Background(), 123)
+		require.NoError(t, err)
+
+		assert.Empty(t, renew(t, svc, token, http.StatusOK))
+	})
+
+	t.Run("demoted user loses roles", func(t *testing.T) {
+		svc, db, token := setupRenewalTest(t, 30*time.Second)
+		claims, err := svc.ValidateToken(token)
+		require.NoError(t, err)
+		require.Contains(t, claims.Roles, "admin")
+
+		require.NoError(t, db.Exec(`DELETE FROM user_roles WHERE user_id = 123 AND role_id = (SELECT id FROM roles WHERE name = 'admin')`).Error)
+		svc.InvalidateUserRoles(123)
+
+		renewed := renew(t, svc, token, http.StatusOK)
+		require.NotEmpty(t, renewed)
+		claims, err = svc.ValidateToken(renewed)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"user"}, claims.Roles)
+	})
+}
+
+func TestTokenRenewalMiddleware_DoesNotRotateRefreshToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	claims := &auth.Claims{
+		UserID:    123,
+		Email:     "test@example.com",
+		ExpiresAt: time.Now().Add(10 * time.Second),
+	}
+	mockService := &testutil.MockAuthService{}
+	mockService.On("ValidateToken", "expiring-token").Return(claims, nil)
+	mockService.On("RenewAccessToken", mock.Anything, claims).Return("renewed-token", nil)
+
+	r := gin.New()
+	r.Use(auth.AuthMiddleware(mockService), auth.TokenRenewalMiddleware(mockService, time.Minute))
+	r.GET("/test", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	req, _ := http.NewRequest("GET", "/test", nil)
+	req.Header.Set(auth.AuthorizationHeader, "Bearer expiring-token")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, "renewed-token", w.Header().Get(auth.NewAccessTokenHeader))
+	mockService.AssertExpectations(t)
+	mockService.AssertNotCalled(t, "RefreshAccessToken", mock.Anything, mock.Anything)
+	mockService.AssertNotCalled(t, "GenerateTokenPair", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
 func TestGetUserIDFromContext(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	t.Run("user ID exists in context", func(t *testing.T) {
 		c, _ := gin.CreateTestContext(httptest.NewRecorder())
-		c.Set(UserIDKey, uint(123))
+		c.Set(auth.UserIDKey, uint(123))
 
-		userID, exists := GetUserIDFromContext(c)
+		userID, exists := auth.GetUserIDFromContext(c)
 		assert.True(t, exists)
 		assert.Equal(t, uint(123), userID)
 	})
@@ -237,16 +443,16 @@ func TestGetUserIDFromContext(t *testing.T) {
 	t.Run("user ID does not exist in context", func(t *testing.T) {
 		c, _ := gin.CreateTestContext(httptest.NewRecorder())
 
-		userID, exists := GetUserIDFromContext(c)
+		userID, exists := auth.GetUserIDFromContext(c)
 		assert.False(t, exists)
 		assert.Equal(t, uint(0), userID)
 	})
 
 	t.Run("user ID has wrong type in context", func(t *testing.T) {
 		c, _ := gin.CreateTestContext(httptest.NewRecorder())
-		c.Set(UserIDKey, "not-a-uint")
+		c.Set(auth.UserIDKey, "not-a-uint")
 
-		userID, exists := GetUserIDFromContext(c)
+		userID, exists := auth.GetUserIDFromContext(c)
 		assert.False(t, exists)
 		assert.Equal(t, uint(0), userID)
 	})
@@ -0,0 +1,74 @@
+package auth
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// WithOrganizationClaims adds the user's organization memberships to issued access tokens as the
+// "orgs" claim, mapping organization ID to organization role, so downstream services can authorize
+// organization requests without a lookup. Requires the organization_members table.
+// Membership changes take effect on the next issued token; call InvalidateUserRoles after changing them.
+func WithOrganizationClaims() ServiceOption {
+	return func(s *service) {
+		s.orgClaims = true
+	}
+}
+
+// queryOrganizations reads the user's organization roles keyed by organization ID; nil when the user has none
+func (s *service) queryOrganizations(userID uint) (map[uint]string, error) {
+	var memberships []struct {
+		OrganizationID uint
+		Role           string
+	}
+	err := s.db.Table("organization_members").
+		Select("organization_id, role").
+		Where("user_id = ?", userID).
+		Find(&memberships).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch user organizations: %w", err)
+	}
+	if len(memberships) == 0 {
+		return nil, nil
+	}
+
+	orgs := make(map[uint]string, len(memberships))
+	for _, m := range memberships {
+		orgs[m.OrganizationID] = m.Role
+	}
+	return orgs, nil
+}
+
+// encodeOrgClaims converts organization roles to the JSON object stored in the token; JSON keys must be strings
+func encodeOrgClaims(orgs map[uint]string) map[string]string {
+	encoded := make(map[string]string, len(orgs))
+	for id, role := range orgs {
+		encoded[strconv.FormatUint(uint64(id), 10)] = role
+	}
+	return encoded
+}
+
+// decodeOrgClaims parses the "orgs" claim; a missing claim yields nil, a malformed one an error
+func decodeOrgClaims(raw any) (map[uint]string, error) {
+	if raw == nil {
+		return nil, nil
+	}
+	encoded, ok := raw.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("orgs claim is not an object")
+	}
+
+	orgs := make(map[uint]string, len(encoded))
+	for key, value := range encoded {
+		id, err := strconv.ParseUint(key, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid organization ID %q in orgs claim", key)
+		}
+		role, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid role for organization %s in orgs claim", key)
+		}
+		orgs[uint(id)] = role
+	}
+	return orgs, nil
+}
@@ -0,0 +1,65 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_OrganizationClaims(t *testing.T) {
+	svc, db := setupServiceTest(t)
+	require.NoError(t, db.Exec(`CREATE TABLE organization_members (
+		organization_id INTEGER NOT NULL,
+		user_id INTEGER NOT NULL,
+		role TEXT NOT NULL,
+		PRIMARY KEY (organization_id, user_id)
+	)`).Error)
+	ctx := context.Background()
+
+	pair, err := svc.GenerateTokenPair(ctx, 1, "test@example.com", "Test User")
+	require.NoError(t, err)
+	claims, err := svc.ValidateToken(pair.AccessToken)
+	require.NoError(t, err)
+	assert.Nil(t, claims.Orgs, "organization claims are opt-in")
+
+	WithOrganizationClaims()(svc)
+	pair, err = svc.GenerateTokenPair(ctx, 1, "test@example.com", "Test User")
+	require.NoError(t, err)
+	claims, err = svc.ValidateToken(pair.AccessToken)
+	require.NoError(t, err)
+	assert.Nil(t, claims.Orgs, "users without memberships get no orgs claim")
+
+	require.NoError(t, db.Exec(`INSERT INTO organization_members (organization_id, user_id, role) VALUES (3, 1, 'owner'), (5, 1, 'member'), (5, 2, 'admin')`).Error)
+	pair, err = svc.GenerateTokenPair(ctx, 1, "test@example.com", "Test User")
+	require.NoError(t, err)
+	claims, err = svc.ValidateToken(pair.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, map[uint]string{3: "owner", 5: "member"}, claims.Orgs)
+
+	renewed, err := svc.RenewAccessToken(ctx, claims)
+	require.NoError(t, err)
+	renewedClaims, err := svc.ValidateToken(renewed)
+	require.NoError(t, err)
+	assert.Equal(t, claims.Orgs, renewedClaims.Orgs)
+}
+
+func TestService_ValidateToken_MalformedOrgsClaim(t *testing.T) {
+	svc, _ := setupServiceTest(t)
+
+	for _, orgs := range []any{"owner", map[string]any{"abc": "owner"}, map[string]any{"3": 1}} {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+			"sub":  "1",
+			"orgs": orgs,
+			"exp":  time.Now().Add(time.Minute).Unix(),
+		})
+		signed, err := token.SignedString([]byte(svc.jwtSecret))
+		require.NoError(t, err)
+
+		_, err = svc.ValidateToken(signed)
+		assert.ErrorIs(t, err, ErrInvalidToken)
+	}
+}
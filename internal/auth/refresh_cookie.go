@@ -0,0 +1,155 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/yeegeek/uyou-go-api-starter/internal/config"
+)
+
+const (
+	// CSRFTokenHeader 携带 CSRF Cookie 值的请求头，Cookie 模式下刷新和登出必须提供
+	CSRFTokenHeader = "X-CSRF-Token"
+	// RefreshTokenTransportHeader 登录和注册时选择刷新令牌的下发方式，移动端传 "body" 仍在响应体中获取
+	RefreshTokenTransportHeader = "X-Refresh-Token-Transport"
+	// RefreshTokenTransportBody 在响应体中返回刷新令牌
+	RefreshTokenTransportBody = "body"
+
+	defaultRefreshCookieName = "refresh_token"
+	defaultCSRFCookieName    = "csrf_token"
+	defaultRefreshCookiePath = "/"
+)
+
+// RefreshCookie 在 HttpOnly Cookie 中存取刷新令牌，避免浏览器脚本读取
+// 采用双重提交防御 CSRF：同时下发一个脚本可读的 CSRF Cookie，客户端需在请求头中回传其值
+type RefreshCookie struct {
+	enabled  bool
+	name     string
+	csrfName string
+	domain   string
+	path     string
+	maxAge   time.Duration
+}
+
+// NewRefreshCookie 根据 JWT 配置创建刷新令牌 Cookie，Cookie 有效期与刷新令牌一致
+func NewRefreshCookie(cfg *config.JWTConfig) *RefreshCookie {
+	rc := cfg.RefreshCookie
+
+	name := rc.Name
+	if name == "" {
+		name = defaultRefreshCookieName
+	}
+	csrfName := rc.CSRFCookieName
+	if csrfName == "" {
+		csrfName = defaultCSRFCookieName
+	}
+	path := rc.Path
+	if path == "" {
+		path = defaultRefreshCookiePath
+	}
+	maxAge := cfg.RefreshTokenTTL
+	if maxAge == 0 {
+		maxAge = 168 * time.Hour
+	}
+
+	return &RefreshCookie{
+		enabled:  rc.Enabled,
+		name:     name,
+		csrfName: csrfName,
+		domain:   rc.Domain,
+		path:     path,
+		maxAge:   maxAge,
+	}
+}
+
+// Enabled 是否启用 Cookie 模式，nil 表示未启用
+func (rc *RefreshCookie) Enabled() bool {
+	return rc != nil && rc.enabled
+}
+
+// WantsCookie 判断本次登录或注册是否通过 Cookie 下发刷新令牌
+func (rc *RefreshCookie) WantsCookie(c *gin.Context) bool {
+	if !rc.Enabled() {
+		return false
+	}
+	return !strings.EqualFold(c.GetHeader(RefreshTokenTransportHeader), RefreshTokenTransportBody)
+}
+
+// Set 写入刷新令牌 Cookie，并轮换 CSRF Cookie
+func (rc *RefreshCookie) Set(c *gin.Context, refreshToken string) error {
+	csrfToken, err := generateCSRFToken()
+	if err != nil {
+		return fmt.Errorf("failed to generate csrf token: %w", err)
+	}
+
+	maxAge := int(rc.maxAge.Seconds())
+	http.SetCookie(c.Writer, rc.cookie(rc.name, refreshToken, maxAge, true))
+	// WHY: The CSRF cookie must be readable by scripts on any page so it uses "/" rather than the refresh cookie path
+	csrf := rc.cookie(rc.csrfName, csrfToken, maxAge, false)
+	csrf.Path = "/"
+	http.SetCookie(c.Writer, csrf)
+	return nil
+}
+
+// Clear 删除刷新令牌和 CSRF Cookie
+func (rc *RefreshCookie) Clear(c *gin.Context) {
+	http.SetCookie(c.Writer, rc.cookie(rc.name, "", -1, true))
+	csrf := rc.cookie(rc.csrfName, "", -1, false)
+	csrf.Path = "/"
+	http.SetCookie(c.Writer, csrf)
+}
+
+// Token 读取请求中的刷新令牌 Cookie
+func (rc *RefreshCookie) Token(c *gin.Context) (string, bool) {
+	if !rc.Enabled() {
+		return "", false
+	}
+	token, err := c.Cookie(rc.name)
+	if err != nil || token == "" {
+		return "", false
+	}
+	return token, true
+}
+
+// VerifyCSRF 校验请求头中的 CSRF 令牌与 CSRF Cookie 一致
+func (rc *RefreshCookie) VerifyCSRF(c *gin.Context) bool {
+	return verifyCSRF(c, rc.csrfName)
+}
+
+// verifyCSRF 双重提交校验：X-CSRF-Token 请求头必须与名为 csrfName 的 Cookie 一致
+func verifyCSRF(c *gin.Context, csrfName string) bool {
+	cookie, err := c.Cookie(csrfName)
+	if err != nil || cookie == "" {
+		return false
+	}
+	header := c.GetHeader(CSRFTokenHeader)
+	return subtle.ConstantTimeCompare([]byte(cookie), []byte(header)) == 1
+}
+
+func (rc *RefreshCookie) cookie(name, value string, maxAge int, httpOnly bool) *http.Cookie {
+	return &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Domain:   rc.domain,
+		Path:     rc.path,
+		MaxAge:   maxAge,
+		Secure:   true,
+		HttpOnly: httpOnly,
+		SameSite: http.SameSiteStrictMode,
+	}
+}
+
+func generateCSRFToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,107 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/yeegeek/uyou-go-api-starter/internal/config"
+)
+
+func TestRefreshCookie_Set(t *testing.T) {
+	rc := NewRefreshCookie(&config.JWTConfig{
+		RefreshTokenTTL: time.Hour,
+		RefreshCookie: config.RefreshCookieConfig{
+			Enabled: true,
+			Name:    "rt",
+			Domain:  "example.com",
+			Path:    "/api/v1/auth",
+		},
+	})
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	assert.NoError(t, rc.Set(c, "refresh-value"))
+
+	cookies := map[string]*http.Cookie{}
+	for _, ck := range w.Result().Cookies() {
+		cookies[ck.Name] = ck
+	}
+
+	refresh := cookies["rt"]
+	if assert.NotNil(t, refresh) {
+		assert.Equal(t, "refresh-value", refresh.Value)
+		assert.Equal(t, "example.com", refresh.Domain)
+		assert.Equal(t, "/api/v1/auth", refresh.Path)
+		assert.Equal(t, 3600, refresh.MaxAge)
+		assert.True(t, refresh.HttpOnly)
+		assert.True(t, refresh.Secure)
+		assert.Equal(t, http.SameSiteStrictMode, refresh.SameSite)
+	}
+
+	csrf := cookies["csrf_token"]
+	if assert.NotNil(t, csrf) {
+		assert.NotEmpty(t, csrf.Value)
+		assert.Equal(t, "/", csrf.Path)
+		assert.False(t, csrf.HttpOnly, "csrf cookie must be readable by scripts")
+		assert.True(t, csrf.Secure)
+	}
+}
+
+func TestRefreshCookie_VerifyCSRF(t *testing.T) {
+	rc := NewRefreshCookie(&config.JWTConfig{RefreshCookie: config.RefreshCookieConfig{Enabled: true}})
+
+	tests := []struct {
+		name   string
+		cookie string
+		header string
+		want   bool
+	}{
+		{name: "matching", cookie: "abc", header: "abc", want: true},
+		{name: "mismatch", cookie: "abc", header: "abd", want: false},
+		{name: "missing header", cookie: "abc", want: false},
+		{name: "missing cookie", header: "abc", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodPost, "/", nil)
+			if tt.cookie != "" {
+				c.Request.AddCookie(&http.Cookie{Name: "csrf_token", Value: tt.cookie})
+			}
+			if tt.header != "" {
+				c.Request.Header.Set(CSRFTokenHeader, tt.header)
+			}
+
+			assert.Equal(t, tt.want, rc.VerifyCSRF(c))
+		})
+	}
+}
+
+func TestRefreshCookie_WantsCookie(t *testing.T) {
+	enabled := NewRefreshCookie(&config.JWTConfig{RefreshCookie: config.RefreshCookieConfig{Enabled: true}})
+	disabled := NewRefreshCookie(&config.JWTConfig{})
+
+	newContext := func(transport string) *gin.Context {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodPost, "/", nil)
+		if transport != "" {
+			c.Request.Header.Set(RefreshTokenTransportHeader, transport)
+		}
+		return c
+	}
+
+	assert.True(t, enabled.WantsCookie(newContext("")))
+	assert.False(t, enabled.WantsCookie(newContext("body")))
+	assert.False(t, disabled.WantsCookie(newContext("")))
+
+	var unset *RefreshCookie
+	assert.False(t, unset.WantsCookie(newContext("")))
+	_, ok := unset.Token(newContext(""))
+	assert.False(t, ok)
+}
@@ -0,0 +1,57 @@
+package auth
+
+import (
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hashicorp/golang-lru/v2/expirable"
+)
+
+const (
+	// defaultRefreshMaxFailures 同一令牌族连续刷新失败达到该次数后吊销整个令牌族
+	defaultRefreshMaxFailures = 5
+	// refreshFailureWindow 失败计数在最后一次失败后保留的时间
+	refreshFailureWindow = 15 * time.Minute
+	// refreshFailureCacheSize 最多跟踪的令牌族数量
+	refreshFailureCacheSize = 10000
+)
+
+// refreshFailureTracker 统计每个令牌族的刷新失败次数
+type refreshFailureTracker struct {
+	mu     sync.Mutex
+	counts *expirable.LRU[uuid.UUID, int]
+	max    int
+}
+
+func newRefreshFailureTracker(max int, window time.Duration) *refreshFailureTracker {
+	if max <= 0 {
+		max = defaultRefreshMaxFailures
+	}
+	return &refreshFailureTracker{
+		counts: expirable.NewLRU[uuid.UUID, int](refreshFailureCacheSize, nil, window),
+		max:    max,
+	}
+}
+
+// record 记录一次失败，达到上限时返回 true 并清除计数
+func (t *refreshFailureTracker) record(family uuid.UUID) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	count, _ := t.counts.Get(family)
+	count++
+	if count >= t.max {
+		t.counts.Remove(family)
+		return true
+	}
+	t.counts.Add(family, count)
+	return false
+}
+
+// reset 刷新成功后清除计数
+func (t *refreshFailureTracker) reset(family uuid.UUID) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.counts.Remove(family)
+}
@@ -0,0 +1,58 @@
+package auth
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxRefreshBodyPeek 限流键读取请求体的上限，刷新请求体远小于该值
+const maxRefreshBodyPeek = 4 << 10
+
+// RefreshIPThrottleKey 返回刷新接口按客户端 IP 限流的键，clientIP 负责解析可信代理后的真实 IP
+// 令牌族前缀由客户端提供，攻击者可以为每次请求伪造新的前缀，因此需要同时按 IP 限流
+func RefreshIPThrottleKey(clientIP func(*gin.Context) string) func(*gin.Context) string {
+	return func(c *gin.Context) string {
+		return "refresh:" + clientIP(c)
+	}
+}
+
+// RefreshFamilyThrottleKey 返回刷新接口按客户端 IP 加令牌族前缀限流的键
+// 刷新令牌从请求体读取，请求体未携带时读取 Cookie；无法解析令牌族时只按 IP 计数
+func RefreshFamilyThrottleKey(rc *RefreshCookie, clientIP func(*gin.Context) string) func(*gin.Context) string {
+	return func(c *gin.Context) string {
+		token := peekRefreshToken(c)
+		if token == "" {
+			token, _ = rc.Token(c)
+		}
+
+		family := "-"
+		if id, ok := RefreshTokenFamily(token); ok {
+			family = id.String()
+		}
+		return "refresh:" + clientIP(c) + ":" + family
+	}
+}
+
+// peekRefreshToken 读取请求体中的 refresh_token 并还原请求体，供后续处理器再次绑定
+func peekRefreshToken(c *gin.Context) string {
+	if c.Request.Body == nil {
+		return ""
+	}
+
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxRefreshBodyPeek))
+	c.Request.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), c.Request.Body))
+	if err != nil {
+		return ""
+	}
+
+	var req struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		return ""
+	}
+	return req.RefreshToken
+}
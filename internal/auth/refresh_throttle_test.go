@@ -0,0 +1,54 @@
+package auth
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yeegeek/uyou-go-api-starter/internal/config"
+)
+
+func TestRefreshFamilyThrottleKey(t *testing.T) {
+	family := uuid.New()
+	token, err := generateRefreshToken(family)
+	require.NoError(t, err)
+
+	keyFunc := RefreshFamilyThrottleKey(NewRefreshCookie(&config.JWTConfig{RefreshCookie: config.RefreshCookieConfig{Enabled: true}}), (*gin.Context).ClientIP)
+
+	newContext := func(body string) *gin.Context {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodPost, "/api/v1/auth/refresh", strings.NewReader(body))
+		c.Request.RemoteAddr = "203.0.113.7:1234"
+		return c
+	}
+
+	t.Run("family from body and body is preserved", func(t *testing.T) {
+		body := `{"refresh_token":"` + token + `"}`
+		c := newContext(body)
+
+		assert.Equal(t, "refresh:203.0.113.7:"+family.String(), keyFunc(c))
+
+		rest, err := io.ReadAll(c.Request.Body)
+		require.NoError(t, err)
+		assert.Equal(t, body, string(rest))
+	})
+
+	t.Run("family from cookie", func(t *testing.T) {
+		c := newContext("")
+		c.Request.AddCookie(&http.Cookie{Name: "refresh_token", Value: token})
+
+		assert.Equal(t, "refresh:203.0.113.7:"+family.String(), keyFunc(c))
+	})
+
+	t.Run("unparseable token shares the ip bucket", func(t *testing.T) {
+		assert.Equal(t, "refresh:203.0.113.7:-", keyFunc(newContext(`{"refresh_token":"garbage"}`)))
+		assert.Equal(t, "refresh:203.0.113.7:-", keyFunc(newContext(`not json`)))
+	})
+}
@@ -4,8 +4,12 @@ package auth
 import (
 	"context"
 	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
 	"encoding/hex"
 	"errors"
+	"regexp"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -14,6 +18,8 @@ import (
 
 var (
 	ErrTokenDoesNotBelongToUser = errors.New("token does not belong to user")
+	// ErrTokenAlreadyUsed is returned when marking a refresh token that was already used or no longer exists
+	ErrTokenAlreadyUsed = errors.New("token already used or not found")
 )
 
 // RefreshToken represents a refresh token in the database
@@ -23,8 +29,14 @@ type RefreshToken struct {
 	TokenHash   string    `gorm:"type:varchar(64);not null;index"`
 	TokenFamily uuid.UUID `gorm:"type:uuid;not null;index"`
 	ExpiresAt   time.Time `gorm:"not null;index"`
+	NotBefore   *time.Time
 	UsedAt      *time.Time
 	RevokedAt   *time.Time
+	RememberMe  bool      `gorm:"not null;default:false"`
+	IP          string    `gorm:"type:varchar(45);not null;default:''"`  // client the token was issued to
+	UserAgent   string    `gorm:"type:varchar(512);not null;default:''"` // client the token was issued to
+	Geo         string    `gorm:"type:varchar(8);not null;default:''"`   // ISO country code resolved from IP, empty when unknown
+	ClientID    string    `gorm:"type:varchar(64);not null;default:''"`  // registered client (jwt.clients) the session was issued to
 	CreatedAt   time.Time `gorm:"default:CURRENT_TIMESTAMP"`
 }
 
@@ -50,9 +62,12 @@ type RefreshTokenRepository interface {
 	FindByTokenHash(ctx context.Context, tokenHash string) (*RefreshToken, error)
 	FindByTokenFamily(ctx context.Context, tokenFamily uuid.UUID) ([]*RefreshToken, error)
 	MarkAsUsed(ctx context.Context, id uuid.UUID) error
+	Rotate(ctx context.Context, usedID uuid.UUID, next *RefreshToken) error
 	RevokeTokenFamily(ctx context.Context, tokenFamily uuid.UUID) error
-	RevokeByUserID(ctx context.Context, userID uint) error
+	RevokeByUserID(ctx context.Context, userID uint) (int64, error)
 	DeleteExpired(ctx context.Context) error
+	CountActiveFamilies(ctx context.Context) (int64, error)
+	ListFamilies(ctx context.Context, filter SessionFilter) ([]SessionFamily, error)
 }
 
 type refreshTokenRepository struct {
@@ -70,6 +85,51 @@ func HashToken(token string) string {
 	return hex.EncodeToString(hash[:])
 }
 
+// 刷新令牌格式为 "{令牌族ID}.{随机部分}"，两段均为无填充 base64url：
+// 令牌族 UUID 16 字节编码为 22 个字符，随机部分 32 字节（256 位熵）编码为 43 个字符。
+// 令牌族前缀用于按令牌族限流和统计失败次数，无需查询数据库
+var (
+	refreshTokenPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{22}\.[A-Za-z0-9_-]{43}$`)
+	// legacyRefreshTokenPattern 旧版本签发的令牌（32 字节带填充 base64url，不含令牌族前缀）
+	legacyRefreshTokenPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{43}=$`)
+)
+
+// generateRefreshToken generates a refresh token for tokenFamily
+func generateRefreshToken(tokenFamily uuid.UUID) (string, error) {
+	secret, err := generateRandomToken()
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(tokenFamily[:]) + "." + secret, nil
+}
+
+// IsWellFormedRefreshToken 检查令牌长度和字符集是否符合签发格式，用于在查询数据库前拒绝伪造的令牌
+func IsWellFormedRefreshToken(token string) bool {
+	return refreshTokenPattern.MatchString(token) || legacyRefreshTokenPattern.MatchString(token)
+}
+
+// RefreshTokenFamily 解析令牌中的令牌族前缀，旧格式或格式错误的令牌返回 false
+func RefreshTokenFamily(token string) (uuid.UUID, bool) {
+	if !refreshTokenPattern.MatchString(token) {
+		return uuid.Nil, false
+	}
+	prefix, _, _ := strings.Cut(token, ".")
+	b, err := base64.RawURLEncoding.DecodeString(prefix)
+	if err != nil {
+		return uuid.Nil, false
+	}
+	family, err := uuid.FromBytes(b)
+	if err != nil {
+		return uuid.Nil, false
+	}
+	return family, true
+}
+
+// tokenHashEqual compares token hashes in constant time
+func tokenHashEqual(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
 func (r *refreshTokenRepository) Create(ctx context.Context, token *RefreshToken) error {
 	return r.db.WithContext(ctx).Create(token).Error
 }
@@ -110,12 +170,35 @@ func (r *refreshTokenRepository) MarkAsUsed(ctx context.Context, id uuid.UUID) e
 	}
 
 	if result.RowsAffected == 0 {
-		return errors.New("token already used or not found")
+		return ErrTokenAlreadyUsed
 	}
 
 	return nil
 }
 
+// Rotate stores next and marks usedID as used in one transaction, so a failure on either
+// write leaves the old token unused and the client can retry with it.
+// Returns ErrTokenAlreadyUsed (and stores nothing) when a concurrent request rotated usedID first.
+func (r *refreshTokenRepository) Rotate(ctx context.Context, usedID uuid.UUID, next *RefreshToken) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(next).Error; err != nil {
+			return err
+		}
+
+		result := tx.Model(&RefreshToken{}).
+			Where("id = ?", usedID).
+			Where("used_at IS NULL").
+			Update("used_at", time.Now())
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrTokenAlreadyUsed
+		}
+		return nil
+	})
+}
+
 func (r *refreshTokenRepository) RevokeTokenFamily(ctx context.Context, tokenFamily uuid.UUID) error {
 	now := time.Now()
 	return r.db.WithContext(ctx).
@@ -125,13 +208,31 @@ func (r *refreshTokenRepository) RevokeTokenFamily(ctx context.Context, tokenFam
 		Update("revoked_at", now).Error
 }
 
-func (r *refreshTokenRepository) RevokeByUserID(ctx context.Context, userID uint) error {
-	now := time.Now()
-	return r.db.WithContext(ctx).
-		Model(&RefreshToken{}).
-		Where("user_id = ?", userID).
-		Where("revoked_at IS NULL").
-		Update("revoked_at", now).Error
+// RevokeByUserID revokes every refresh token of a user and returns the number of
+// sessions (token families with a usable token) that were active before revocation
+func (r *refreshTokenRepository) RevokeByUserID(ctx context.Context, userID uint) (int64, error) {
+	var sessions int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := tx.Model(&RefreshToken{}).
+			Where("user_id = ?", userID).
+			Where("used_at IS NULL").
+			Where("revoked_at IS NULL").
+			Where("expires_at > ?", now).
+			Distinct("token_family").
+			Count(&sessions).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&RefreshToken{}).
+			Where("user_id = ?", userID).
+			Where("revoked_at IS NULL").
+			Update("revoked_at", now).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+	return sessions, nil
 }
 
 func (r *refreshTokenRepository) DeleteExpired(ctx context.Context) error {
@@ -139,3 +240,54 @@ func (r *refreshTokenRepository) DeleteExpired(ctx context.Context) error {
 		Where("expires_at < ?", time.Now()).
 		Delete(&RefreshToken{}).Error
 }
+
+// CountActiveFamilies counts token families that still hold a usable refresh token,
+// i.e. one active session per login
+func (r *refreshTokenRepository) CountActiveFamilies(ctx context.Context) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&RefreshToken{}).
+		Where("used_at IS NULL").
+		Where("revoked_at IS NULL").
+		Where("expires_at > ?", time.Now()).
+		Distinct("token_family").
+		Count(&count).Error
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// ListFamilies returns one summary per token family matching filter, newest login first.
+// Families are paged in SQL; their tokens are then summarized in Go so the query stays portable across drivers.
+func (r *refreshTokenRepository) ListFamilies(ctx context.Context, filter SessionFilter) ([]SessionFamily, error) {
+	now := time.Now()
+	query := r.db.WithContext(ctx).
+		Model(&RefreshToken{}).
+		Select("token_family").
+		Group("token_family")
+	if filter.UserID != 0 {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+	if filter.ActiveOnly {
+		query = query.Having("SUM(CASE WHEN used_at IS NULL AND revoked_at IS NULL AND expires_at > ? THEN 1 ELSE 0 END) > 0", now)
+	}
+	query = query.Order("MIN(created_at) DESC").Order("token_family")
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit).Offset(filter.Offset)
+	}
+
+	var families []uuid.UUID
+	if err := query.Pluck("token_family", &families).Error; err != nil {
+		return nil, err
+	}
+	if len(families) == 0 {
+		return []SessionFamily{}, nil
+	}
+
+	var tokens []*RefreshToken
+	if err := r.db.WithContext(ctx).Where("token_family IN ?", families).Find(&tokens).Error; err != nil {
+		return nil, err
+	}
+	return summarizeFamilies(tokens, now), nil
+}
@@ -0,0 +1,198 @@
+package auth
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// memoryRefreshTokenRepository 进程内的刷新令牌存储，供未配置数据库的本地开发和测试使用
+// 行为与数据库实现一致（未找到时返回 gorm.ErrRecordNotFound），但重启后所有令牌失效且不跨实例共享
+type memoryRefreshTokenRepository struct {
+	mu     sync.RWMutex
+	tokens map[uuid.UUID]*RefreshToken
+}
+
+// NewMemoryRefreshTokenRepository creates a refresh token repository that keeps tokens in memory
+func NewMemoryRefreshTokenRepository() RefreshTokenRepository {
+	return &memoryRefreshTokenRepository{tokens: make(map[uuid.UUID]*RefreshToken)}
+}
+
+func (r *memoryRefreshTokenRepository) Create(ctx context.Context, token *RefreshToken) error {
+	if token.ID == uuid.Nil {
+		token.ID = uuid.New()
+	}
+	if token.CreatedAt.IsZero() {
+		token.CreatedAt = time.Now()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.tokens[token.ID]; exists {
+		return gorm.ErrDuplicatedKey
+	}
+	stored := *token
+	r.tokens[token.ID] = &stored
+	return nil
+}
+
+func (r *memoryRefreshTokenRepository) FindByTokenHash(ctx context.Context, tokenHash string) (*RefreshToken, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, token := range r.tokens {
+		if token.TokenHash == tokenHash {
+			found := *token
+			return &found, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *memoryRefreshTokenRepository) FindByTokenFamily(ctx context.Context, tokenFamily uuid.UUID) ([]*RefreshToken, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	tokens := make([]*RefreshToken, 0)
+	for _, token := range r.tokens {
+		if token.TokenFamily == tokenFamily {
+			found := *token
+			tokens = append(tokens, &found)
+		}
+	}
+	sort.Slice(tokens, func(i, j int) bool { return tokens[i].CreatedAt.After(tokens[j].CreatedAt) })
+	return tokens, nil
+}
+
+func (r *memoryRefreshTokenRepository) MarkAsUsed(ctx context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	token, ok := r.tokens[id]
+	if !ok || token.UsedAt != nil {
+		return ErrTokenAlreadyUsed
+	}
+	now := time.Now()
+	token.UsedAt = &now
+	return nil
+}
+
+// Rotate stores next and marks usedID as used atomically under the write lock
+func (r *memoryRefreshTokenRepository) Rotate(ctx context.Context, usedID uuid.UUID, next *RefreshToken) error {
+	if next.ID == uuid.Nil {
+		next.ID = uuid.New()
+	}
+	if next.CreatedAt.IsZero() {
+		next.CreatedAt = time.Now()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	used, ok := r.tokens[usedID]
+	if !ok || used.UsedAt != nil {
+		return ErrTokenAlreadyUsed
+	}
+	if _, exists := r.tokens[next.ID]; exists {
+		return gorm.ErrDuplicatedKey
+	}
+	stored := *next
+	r.tokens[next.ID] = &stored
+	now := time.Now()
+	used.UsedAt = &now
+	return nil
+}
+
+func (r *memoryRefreshTokenRepository) RevokeTokenFamily(ctx context.Context, tokenFamily uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	for _, token := range r.tokens {
+		if token.TokenFamily == tokenFamily && token.RevokedAt == nil {
+			token.RevokedAt = &now
+		}
+	}
+	return nil
+}
+
+// RevokeByUserID revokes every refresh token of a user and returns the number of
+// sessions (token families with a usable token) that were active before revocation
+func (r *memoryRefreshTokenRepository) RevokeByUserID(ctx context.Context, userID uint) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	sessions := make(map[uuid.UUID]struct{})
+	for _, token := range r.tokens {
+		if token.UserID != userID {
+			continue
+		}
+		if token.isUsable(now) {
+			sessions[token.TokenFamily] = struct{}{}
+		}
+		if token.RevokedAt == nil {
+			token.RevokedAt = &now
+		}
+	}
+	return int64(len(sessions)), nil
+}
+
+func (r *memoryRefreshTokenRepository) DeleteExpired(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	for id, token := range r.tokens {
+		if token.ExpiresAt.Before(now) {
+			delete(r.tokens, id)
+		}
+	}
+	return nil
+}
+
+// CountActiveFamilies counts token families that still hold a usable refresh token,
+// i.e. one active session per login
+func (r *memoryRefreshTokenRepository) CountActiveFamilies(ctx context.Context) (int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	now := time.Now()
+	families := make(map[uuid.UUID]struct{})
+	for _, token := range r.tokens {
+		if token.isUsable(now) {
+			families[token.TokenFamily] = struct{}{}
+		}
+	}
+	return int64(len(families)), nil
+}
+
+// ListFamilies returns one summary per token family matching filter, newest login first
+func (r *memoryRefreshTokenRepository) ListFamilies(ctx context.Context, filter SessionFilter) ([]SessionFamily, error) {
+	r.mu.RLock()
+	tokens := make([]*RefreshToken, 0, len(r.tokens))
+	for _, token := range r.tokens {
+		if filter.UserID == 0 || token.UserID == filter.UserID {
+			found := *token
+			tokens = append(tokens, &found)
+		}
+	}
+	r.mu.RUnlock()
+
+	families := summarizeFamilies(tokens, time.Now())
+	if filter.ActiveOnly {
+		active := families[:0]
+		for _, family := range families {
+			if family.Active {
+				active = append(active, family)
+			}
+		}
+		families = active
+	}
+	if filter.Limit > 0 {
+		start := min(filter.Offset, len(families))
+		families = families[start:min(start+filter.Limit, len(families))]
+	}
+	return families, nil
+}
+
+// isUsable reports whether the token can still be exchanged: not used, not revoked and not expired
+func (rt *RefreshToken) isUsable(now time.Time) bool {
+	return rt.UsedAt == nil && rt.RevokedAt == nil && rt.ExpiresAt.After(now)
+}
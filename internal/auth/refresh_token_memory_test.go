@@ -0,0 +1,323 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/yeegeek/uyou-go-api-starter/internal/config"
+)
+
+// refreshStores returns the database and in-memory repositories so each test proves they behave the same
+func refreshStores(t *testing.T) map[string]RefreshTokenRepository {
+	return map[string]RefreshTokenRepository{
+		"database": NewRefreshTokenRepository(setupTestDB(t)),
+		"memory":   NewMemoryRefreshTokenRepository(),
+	}
+}
+
+func TestRefreshTokenStores_Parity(t *testing.T) {
+	for name, repo := range refreshStores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			family := uuid.New()
+			now := time.Now()
+
+			older := &RefreshToken{UserID: 1, TokenHash: "hash-1", TokenFamily: family, ExpiresAt: now.Add(time.Hour), CreatedAt: now.Add(-time.Minute)}
+			newer := &RefreshToken{UserID: 1, TokenHash: "hash-2", TokenFamily: family, ExpiresAt: now.Add(time.Hour)}
+			other := &RefreshToken{UserID: 1, TokenHash: "hash-3", TokenFamily: uuid.New(), ExpiresAt: now.Add(time.Hour)}
+			expired := &RefreshToken{UserID: 2, TokenHash: "hash-4", TokenFamily: uuid.New(), ExpiresAt: now.Add(-time.Hour)}
+			for _, token := range []*RefreshToken{older, newer, other, expired} {
+				require.NoError(t, repo.Create(ctx, token))
+				assert.NotEqual(t, uuid.Nil, token.ID)
+			}
+
+			found, err := repo.FindByTokenHash(ctx, "hash-2")
+			require.NoError(t, err)
+			assert.Equal(t, newer.ID, found.ID)
+			_, err = repo.FindByTokenHash(ctx, "missing")
+			assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+			tokens, err := repo.FindByTokenFamily(ctx, family)
+			require.NoError(t, err)
+			require.Len(t, tokens, 2)
+			assert.Equal(t, newer.ID, tokens[0].ID, "newest token first")
+
+			active, err := repo.CountActiveFamilies(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, int64(2), active)
+
+			require.NoError(t, repo.MarkAsUsed(ctx, older.ID))
+			assert.Error(t, repo.MarkAsUsed(ctx, older.ID), "a token can only be used once")
+			assert.Error(t, repo.MarkAsUsed(ctx, uuid.New()))
+
+			require.NoError(t, repo.RevokeTokenFamily(ctx, family))
+			found, err = repo.FindByTokenHash(ctx, "hash-2")
+			require.NoError(t, err)
+			assert.NotNil(t, found.RevokedAt)
+
+			sessions, err := repo.RevokeByUserID(ctx, 1)
+			require.NoError(t, err)
+			assert.Equal(t, int64(1), sessions, "only the other family was still usable")
+			active, err = repo.CountActiveFamilies(ctx)
+			require.NoError(t, err)
+			assert.Zero(t, active)
+
+			require.NoError(t, repo.DeleteExpired(ctx))
+			_, err = repo.FindByTokenHash(ctx, "hash-4")
+			assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+		})
+	}
+}
+
+func TestRefreshTokenStores_Rotate(t *testing.T) {
+	for name, repo := range refreshStores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			family := uuid.New()
+			expires := time.Now().Add(time.Hour)
+
+			old := &RefreshToken{UserID: 1, TokenHash: "hash-old", TokenFamily: family, ExpiresAt: expires}
+			require.NoError(t, repo.Create(ctx, old))
+
+			next := &RefreshToken{UserID: 1, TokenHash: "hash-next", TokenFamily: family, ExpiresAt: expires}
+			require.NoError(t, repo.Rotate(ctx, old.ID, next))
+			assert.NotEqual(t, uuid.Nil, next.ID)
+
+			found, err := repo.FindByTokenHash(ctx, "hash-old")
+			require.NoError(t, err)
+			assert.NotNil(t, found.UsedAt)
+			_, err = repo.FindByTokenHash(ctx, "hash-next")
+			require.NoError(t, err)
+
+			// Losing a concurrent rotation stores nothing
+			loser := &RefreshToken{UserID: 1, TokenHash: "hash-loser", TokenFamily: family, ExpiresAt: expires}
+			assert.ErrorIs(t, repo.Rotate(ctx, old.ID, loser), ErrTokenAlreadyUsed)
+			_, err = repo.FindByTokenHash(ctx, "hash-loser")
+			assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+			// A failed write leaves the old token unused
+			fresh := &RefreshToken{UserID: 1, TokenHash: "hash-fresh", TokenFamily: family, ExpiresAt: expires}
+			require.NoError(t, repo.Create(ctx, fresh))
+			duplicate := &RefreshToken{ID: next.ID, UserID: 1, TokenHash: "hash-dup", TokenFamily: family, ExpiresAt: expires}
+			assert.Error(t, repo.Rotate(ctx, fresh.ID, duplicate))
+			found, err = repo.FindByTokenHash(ctx, "hash-fresh")
+			require.NoError(t, err)
+			assert.Nil(t, found.UsedAt)
+		})
+	}
+}
+
+func TestRefreshTokenStores_ListFamilies(t *testing.T) {
+	for name, repo := range refreshStores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			now := time.Now()
+
+			// User 1: a rotated session and an older revoked one; user 2: one session
+			rotated, revoked, other := uuid.New(), uuid.New(), uuid.New()
+			used := now.Add(-time.Minute)
+			tokens := []*RefreshToken{
+				{UserID: 1, TokenHash: "hash-1", TokenFamily: rotated, ExpiresAt: now.Add(time.Hour), CreatedAt: now.Add(-2 * time.Minute), UsedAt: &used},
+				{UserID: 1, TokenHash: "hash-2", TokenFamily: rotated, ExpiresAt: now.Add(2 * time.Hour), CreatedAt: now.Add(-time.Minute)},
+				{UserID: 1, TokenHash: "hash-3", TokenFamily: revoked, ExpiresAt: now.Add(time.Hour), CreatedAt: now.Add(-time.Hour), RevokedAt: &used},
+				{UserID: 2, TokenHash: "hash-4", TokenFamily: other, ExpiresAt: now.Add(time.Hour), CreatedAt: now},
+			}
+			for _, token := range tokens {
+				require.NoError(t, repo.Create(ctx, token))
+			}
+
+			all, err := repo.ListFamilies(ctx, SessionFilter{})
+			require.NoError(t, err)
+			require.Len(t, all, 3)
+			assert.Equal(t, []uuid.UUID{other, rotated, revoked}, []uuid.UUID{all[0].TokenFamily, all[1].TokenFamily, all[2].TokenFamily}, "newest login first")
+
+			mine, err := repo.ListFamilies(ctx, SessionFilter{UserID: 1})
+			require.NoError(t, err)
+			require.Len(t, mine, 2)
+			session := mine[0]
+			assert.Equal(t, rotated, session.TokenFamily)
+			assert.Equal(t, uint(1), session.UserID)
+			assert.WithinDuration(t, now.Add(-2*time.Minute), session.CreatedAt, time.Second, "created is the first login")
+			require.NotNil(t, session.LastUsedAt)
+			assert.WithinDuration(t, used, *session.LastUsedAt, time.Second)
+			assert.WithinDuration(t, now.Add(2*time.Hour), session.ExpiresAt, time.Second, "expiry of the newest token")
+			assert.True(t, session.Active)
+			assert.Nil(t, session.RevokedAt)
+			assert.False(t, mine[1].Active)
+			assert.NotNil(t, mine[1].RevokedAt)
+
+			active, err := repo.ListFamilies(ctx, SessionFilter{UserID: 1, ActiveOnly: true})
+			require.NoError(t, err)
+			require.Len(t, active, 1)
+			assert.Equal(t, rotated, active[0].TokenFamily)
+
+			page, err := repo.ListFamilies(ctx, SessionFilter{Limit: 1, Offset: 1})
+			require.NoError(t, err)
+			require.Len(t, page, 1)
+			assert.Equal(t, rotated, page[0].TokenFamily)
+
+			none, err := repo.ListFamilies(ctx, SessionFilter{UserID: 99})
+			require.NoError(t, err)
+			assert.Empty(t, none)
+		})
+	}
+}
+
+func TestService_RefreshStores_RevokeSession(t *testing.T) {
+	for name, svc := range refreshServices(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			pair, err := svc.GenerateTokenPair(ctx, 1, "test@example.com", "Test User")
+			require.NoError(t, err)
+			rotated, err := svc.RefreshAccessToken(ctx, pair.RefreshToken)
+			require.NoError(t, err)
+			kept, err := svc.GenerateTokenPair(ctx, 1, "test@example.com", "Test User")
+			require.NoError(t, err)
+
+			require.NoError(t, svc.RevokeSession(ctx, pair.TokenFamily))
+
+			sessions, err := svc.ListSessions(ctx, SessionFilter{UserID: 1})
+			require.NoError(t, err)
+			require.Len(t, sessions, 2)
+			for _, session := range sessions {
+				if session.TokenFamily == pair.TokenFamily {
+					assert.NotNil(t, session.RevokedAt)
+					assert.False(t, session.Active)
+				} else {
+					assert.True(t, session.Active, "other sessions stay signed in")
+				}
+			}
+
+			_, err = svc.RefreshAccessToken(ctx, rotated.RefreshToken)
+			assert.ErrorIs(t, err, ErrTokenRevoked, "every token of the family is revoked")
+			_, err = svc.RefreshAccessToken(ctx, kept.RefreshToken)
+			assert.NoError(t, err)
+
+			assert.ErrorIs(t, svc.RevokeSession(ctx, uuid.New()), ErrSessionNotFound)
+		})
+	}
+}
+
+func TestMemoryRefreshTokenRepository_ReturnsCopies(t *testing.T) {
+	repo := NewMemoryRefreshTokenRepository()
+	ctx := context.Background()
+
+	token := &RefreshToken{UserID: 1, TokenHash: "hash", TokenFamily: uuid.New(), ExpiresAt: time.Now().Add(time.Hour)}
+	require.NoError(t, repo.Create(ctx, token))
+	token.UserID = 99
+
+	found, err := repo.FindByTokenHash(ctx, "hash")
+	require.NoError(t, err)
+	found.RevokedAt = &found.ExpiresAt
+
+	again, err := repo.FindByTokenHash(ctx, "hash")
+	require.NoError(t, err)
+	assert.Equal(t, uint(1), again.UserID)
+	assert.Nil(t, again.RevokedAt)
+}
+
+// refreshServices returns a database-backed service and a standalone service on the memory store, both knowing user 1
+func refreshServices(t *testing.T) map[string]Service {
+	dbService, _ := setupServiceTest(t)
+	return map[string]Service{
+		"database": dbService,
+		"memory": NewService(&config.JWTConfig{
+			Secret:          "test-secret-for-jwt-tokens-min-32-chars",
+			AccessTokenTTL:  15 * time.Minute,
+			RefreshTokenTTL: 7 * 24 * time.Hour,
+			RefreshStore:    config.RefreshStoreMemory,
+		}),
+	}
+}
+
+func TestService_RefreshStores_GenerateRefreshRevoke(t *testing.T) {
+	for name, svc := range refreshServices(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			pair, err := svc.GenerateTokenPair(ctx, 1, "test@example.com", "Test User")
+			require.NoError(t, err)
+
+			rotated, err := svc.RefreshAccessToken(ctx, pair.RefreshToken)
+			require.NoError(t, err)
+			assert.Equal(t, pair.TokenFamily, rotated.TokenFamily)
+			assert.NotEqual(t, pair.RefreshToken, rotated.RefreshToken)
+
+			claims, err := svc.ValidateToken(rotated.AccessToken)
+			require.NoError(t, err)
+			assert.Equal(t, uint(1), claims.UserID)
+			assert.Equal(t, "test@example.com", claims.Email)
+			assert.Equal(t, "Test User", claims.Name)
+
+			sessions, err := svc.CountActiveSessions(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, int64(1), sessions)
+
+			require.NoError(t, svc.RevokeRefreshToken(ctx, rotated.RefreshToken))
+			_, err = svc.RefreshAccessToken(ctx, rotated.RefreshToken)
+			assert.ErrorIs(t, err, ErrTokenRevoked)
+
+			_, err = svc.RefreshAccessToken(ctx, "not-a-token")
+			assert.ErrorIs(t, err, ErrInvalidToken)
+		})
+	}
+}
+
+func TestService_RefreshStores_ReuseDetection(t *testing.T) {
+	for name, svc := range refreshServices(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			pair, err := svc.GenerateTokenPair(ctx, 1, "test@example.com", "Test User")
+			require.NoError(t, err)
+			rotated, err := svc.RefreshAccessToken(ctx, pair.RefreshToken)
+			require.NoError(t, err)
+
+			_, err = svc.RefreshAccessToken(ctx, pair.RefreshToken)
+			assert.ErrorIs(t, err, ErrTokenReuse)
+
+			_, err = svc.RefreshAccessToken(ctx, rotated.RefreshToken)
+			assert.ErrorIs(t, err, ErrTokenRevoked, "reuse revokes the whole family")
+		})
+	}
+}
+
+func TestService_RefreshStores_RevokeAllUserTokens(t *testing.T) {
+	for name, svc := range refreshServices(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			first, err := svc.GenerateTokenPair(ctx, 1, "test@example.com", "Test User")
+			require.NoError(t, err)
+			_, err = svc.GenerateTokenPair(ctx, 1, "test@example.com", "Test User")
+			require.NoError(t, err)
+
+			revoked, err := svc.RevokeAllUserTokens(ctx, 1)
+			require.NoError(t, err)
+			assert.Equal(t, int64(2), revoked)
+
+			_, err = svc.RefreshAccessToken(ctx, first.RefreshToken)
+			assert.ErrorIs(t, err, ErrTokenRevoked)
+		})
+	}
+}
+
+func TestNewService_MemoryStoreUnknownUser(t *testing.T) {
+	svc := NewService(&config.JWTConfig{Secret: "test-secret-for-jwt-tokens-min-32-chars", RefreshStore: config.RefreshStoreMemory})
+	ctx := context.Background()
+
+	pair, err := svc.GenerateTokenPair(ctx, 1, "test@example.com", "Test User")
+	require.NoError(t, err)
+
+	// The identity was evicted while the refresh token is still valid
+	svc.(*service).identities.Purge()
+	_, err = svc.RefreshAccessToken(ctx, pair.RefreshToken)
+	assert.ErrorContains(t, err, "failed to fetch user for token claims")
+}
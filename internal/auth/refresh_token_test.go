@@ -251,8 +251,13 @@ func TestRefreshTokenRepository_RevokeByUserID(t *testing.T) {
 	err = repo.Create(ctx, token3)
 	require.NoError(t, err)
 
-	err = repo.RevokeByUserID(ctx, 1)
+	revoked, err := repo.RevokeByUserID(ctx, 1)
 	assert.NoError(t, err)
+	assert.Equal(t, int64(2), revoked)
+
+	revoked, err = repo.RevokeByUserID(ctx, 1)
+	assert.NoError(t, err)
+	assert.Zero(t, revoked, "already revoked sessions are not counted again")
 
 	var user1Tokens []RefreshToken
 	err = db.Where("user_id = ?", 1).Find(&user1Tokens).Error
@@ -304,3 +309,30 @@ func TestRefreshTokenRepository_DeleteExpired(t *testing.T) {
 func ptrTime(t time.Time) *time.Time {
 	return &t
 }
+
+func TestRefreshTokenRepository_CountActiveFamilies(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewRefreshTokenRepository(db)
+	ctx := context.Background()
+
+	now := time.Now()
+	rotatedFamily := uuid.New()
+	usedAt := now.Add(-time.Minute)
+	revokedAt := now.Add(-time.Minute)
+
+	tokens := []*RefreshToken{
+		// Rotated session: one used token and its active successor count once
+		{UserID: 1, TokenHash: "rotated-old", TokenFamily: rotatedFamily, ExpiresAt: now.Add(time.Hour), UsedAt: &usedAt},
+		{UserID: 1, TokenHash: "rotated-new", TokenFamily: rotatedFamily, ExpiresAt: now.Add(time.Hour)},
+		{UserID: 2, TokenHash: "active", TokenFamily: uuid.New(), ExpiresAt: now.Add(time.Hour)},
+		{UserID: 3, TokenHash: "expired", TokenFamily: uuid.New(), ExpiresAt: now.Add(-time.Hour)},
+		{UserID: 4, TokenHash: "revoked", TokenFamily: uuid.New(), ExpiresAt: now.Add(time.Hour), RevokedAt: &revokedAt},
+	}
+	for _, token := range tokens {
+		require.NoError(t, repo.Create(ctx, token))
+	}
+
+	count, err := repo.CountActiveFamilies(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+}
@@ -0,0 +1,111 @@
+package auth
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/hashicorp/golang-lru/v2/expirable"
+
+	"github.com/yeegeek/uyou-go-api-starter/internal/metrics"
+)
+
+const (
+	defaultRoleCacheTTL  = 60 * time.Second
+	defaultRoleCacheSize = 10000
+
+	// roleCacheMetricName cache_hits_total / cache_misses_total 中的 cache_name 标签
+	roleCacheMetricName = "auth_roles"
+)
+
+// authorization 用户的角色与权限名称，签发令牌时写入声明
+// 缓存中的切片和映射被多个令牌共享，只读不可修改
+type authorization struct {
+	roles       []string
+	permissions []string
+	// orgs 组织 ID 到组织角色，未启用组织声明时为 nil
+	orgs map[uint]string
+}
+
+// roleCache 按用户 ID 缓存角色与权限，减少登录和刷新时的数据库查询
+// 只缓存查询成功的结果；进程内缓存，多实例部署时其他实例最多滞后一个 TTL
+type roleCache struct {
+	entries *expirable.LRU[uint, authorization]
+	// generation 每次失效时递增，失效前开始的查询结果不再写入缓存
+	generation atomic.Uint64
+	hits       atomic.Uint64
+	misses     atomic.Uint64
+}
+
+// RoleCacheStats 角色缓存命中统计
+type RoleCacheStats struct {
+	Hits   uint64
+	Misses uint64
+	Size   int
+}
+
+func newRoleCache(size int, ttl time.Duration) *roleCache {
+	if size <= 0 {
+		size = defaultRoleCacheSize
+	}
+	if ttl <= 0 {
+		ttl = defaultRoleCacheTTL
+	}
+	return &roleCache{entries: expirable.NewLRU[uint, authorization](size, nil, ttl)}
+}
+
+// get 查询缓存并记录命中指标，未命中时同时返回当前 generation 供 add 使用
+func (c *roleCache) get(userID uint) (authorization, uint64, bool) {
+	generation := c.generation.Load()
+	if authz, ok := c.entries.Get(userID); ok {
+		c.hits.Add(1)
+		metrics.RecordCacheHit(roleCacheMetricName)
+		return authz, generation, true
+	}
+	c.misses.Add(1)
+	metrics.RecordCacheMiss(roleCacheMetricName)
+	return authorization{}, generation, false
+}
+
+// add 写入查询结果；查询期间发生过失效则丢弃，避免缓存变更前的旧角色
+func (c *roleCache) add(userID uint, generation uint64, authz authorization) {
+	if c.generation.Load() != generation {
+		return
+	}
+	c.entries.Add(userID, authz)
+}
+
+func (c *roleCache) invalidate(userID uint) {
+	c.generation.Add(1)
+	c.entries.Remove(userID)
+}
+
+func (c *roleCache) purge() {
+	c.generation.Add(1)
+	c.entries.Purge()
+}
+
+func (c *roleCache) stats() RoleCacheStats {
+	return RoleCacheStats{Hits: c.hits.Load(), Misses: c.misses.Load(), Size: c.entries.Len()}
+}
+
+// InvalidateUserRoles drops the cached roles of one user; call after assigning or removing their roles
+func (s *service) InvalidateUserRoles(userID uint) {
+	if s.roleCache != nil {
+		s.roleCache.invalidate(userID)
+	}
+}
+
+// InvalidateAllRoles drops every cached entry; call after a role's permissions change
+func (s *service) InvalidateAllRoles() {
+	if s.roleCache != nil {
+		s.roleCache.purge()
+	}
+}
+
+// RoleCacheStats reports role cache hits, misses and size; zero when the cache is disabled
+func (s *service) RoleCacheStats() RoleCacheStats {
+	if s.roleCache == nil {
+		return RoleCacheStats{}
+	}
+	return s.roleCache.stats()
+}
@@ -0,0 +1,188 @@
+package auth
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// countRoleQueries counts queries against the roles table, i.e. authorization lookups
+func countRoleQueries(tb testing.TB, db *gorm.DB) *atomic.Int64 {
+	tb.Helper()
+	var count atomic.Int64
+	err := db.Callback().Query().After("gorm:query").Register("test:count_role_queries", func(tx *gorm.DB) {
+		if tx.Statement.Table == "roles" {
+			count.Add(1)
+		}
+	})
+	require.NoError(tb, err)
+	return &count
+}
+
+func setupRoleCacheTest(t *testing.T) (*service, *gorm.DB, *atomic.Int64) {
+	svc, db := setupServiceTest(t)
+	svc.roleCache = newRoleCache(0, 0)
+	return svc, db, countRoleQueries(t, db)
+}
+
+func TestService_RoleCache_RepeatedRefreshesHitCache(t *testing.T) {
+	svc, _, queries := setupRoleCacheTest(t)
+	ctx := context.Background()
+
+	pair, err := svc.GenerateTokenPair(ctx, 1, "test@example.com", "Test User")
+	require.NoError(t, err)
+	for i := 0; i < 5; i++ {
+		pair, err = svc.RefreshAccessToken(ctx, pair.RefreshToken)
+		require.NoError(t, err)
+
+		claims, err := svc.ValidateToken(pair.AccessToken)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"user"}, claims.Roles)
+	}
+
+	assert.Equal(t, int64(1), queries.Load())
+	assert.Equal(t, RoleCacheStats{Hits: 5, Misses: 1, Size: 1}, svc.RoleCacheStats())
+}
+
+func TestService_RoleCache_InvalidateUserRoles(t *testing.T) {
+	svc, db, queries := setupRoleCacheTest(t)
+
+	_, err := svc.GenerateToken(1, "test@example.com", "Test User")
+	require.NoError(t, err)
+
+	require.NoError(t, db.Create(&testRole{ID: 2, Name: "admin"}).Error)
+	require.NoError(t, db.Create(&testUserRole{UserID: 1, RoleID: 2}).Error)
+	svc.InvalidateUserRoles(1)
+
+	token, err := svc.GenerateToken(1, "test@example.com", "Test User")
+	require.NoError(t, err)
+	claims, err := svc.ValidateToken(token)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"user", "admin"}, claims.Roles)
+	assert.Equal(t, int64(2), queries.Load())
+}
+
+func TestService_RoleCache_InvalidateAllRoles(t *testing.T) {
+	svc, db, _ := setupRoleCacheTest(t)
+
+	_, err := svc.GenerateToken(1, "test@example.com", "Test User")
+	require.NoError(t, err)
+
+	require.NoError(t, db.Create(&testPermission{ID: 1, Name: "users:read"}).Error)
+	require.NoError(t, db.Create(&testRolePermission{RoleID: 1, PermissionID: 1}).Error)
+	svc.InvalidateAllRoles()
+	assert.Zero(t, svc.RoleCacheStats().Size)
+
+	token, err := svc.GenerateToken(1, "test@example.com", "Test User")
+	require.NoError(t, err)
+	claims, err := svc.ValidateToken(token)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"users:read"}, claims.Permissions)
+}
+
+func TestService_RoleCache_DoesNotCacheErrors(t *testing.T) {
+	svc, db, _ := setupRoleCacheTest(t)
+
+	require.NoError(t, db.Migrator().RenameTable("roles", "roles_offline"))
+	_, err := svc.GenerateToken(1, "test@example.com", "Test User")
+	require.Error(t, err)
+	assert.Zero(t, svc.RoleCacheStats().Size)
+
+	require.NoError(t, db.Migrator().RenameTable("roles_offline", "roles"))
+	token, err := svc.GenerateToken(1, "test@example.com", "Test User")
+	require.NoError(t, err)
+	claims, err := svc.ValidateToken(token)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"user"}, claims.Roles)
+}
+
+func TestService_RoleCache_Expires(t *testing.T) {
+	svc, _, queries := setupRoleCacheTest(t)
+	svc.roleCache = newRoleCache(10, 50*time.Millisecond)
+
+	_, err := svc.GenerateToken(1, "test@example.com", "Test User")
+	require.NoError(t, err)
+	time.Sleep(100 * time.Millisecond)
+	_, err = svc.GenerateToken(1, "test@example.com", "Test User")
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(2), queries.Load())
+}
+
+func TestRoleCache_StaleLoadAfterInvalidationIsDropped(t *testing.T) {
+	cache := newRoleCache(10, time.Minute)
+
+	_, generation, ok := cache.get(1)
+	require.False(t, ok)
+	// A role change lands while the lookup is still running
+	cache.invalidate(1)
+	cache.add(1, generation, authorization{roles: []string{"user"}})
+
+	_, _, ok = cache.get(1)
+	assert.False(t, ok)
+}
+
+func TestRoleCache_ConcurrentAccess(t *testing.T) {
+	cache := newRoleCache(10, time.Minute)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			userID := uint(i % 5)
+			if _, generation, ok := cache.get(userID); !ok {
+				cache.add(userID, generation, authorization{roles: []string{"user"}})
+			}
+			if i%10 == 0 {
+				cache.invalidate(userID)
+			}
+			if i == 25 {
+				cache.purge()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	stats := cache.stats()
+	assert.Equal(t, uint64(50), stats.Hits+stats.Misses)
+}
+
+// BenchmarkService_RefreshAccessToken compares role queries per refresh with and without the role cache
+func BenchmarkService_RefreshAccessToken(b *testing.B) {
+	for _, bc := range []struct {
+		name   string
+		cached bool
+	}{
+		{name: "uncached"},
+		{name: "cached", cached: true},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			svc, db := setupServiceTest(b)
+			if bc.cached {
+				svc.roleCache = newRoleCache(0, 0)
+			}
+			queries := countRoleQueries(b, db)
+			ctx := context.Background()
+
+			pair, err := svc.GenerateTokenPair(ctx, 1, "test@example.com", "Test User")
+			require.NoError(b, err)
+			queries.Store(0)
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				pair, err = svc.RefreshAccessToken(ctx, pair.RefreshToken)
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(queries.Load())/float64(b.N), "role_queries/op")
+		})
+	}
+}
@@ -7,14 +7,17 @@ import (
 	"encoding/base64"
 	"errors"
 	"fmt"
+	"log/slog"
 	"strconv"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
+	"github.com/hashicorp/golang-lru/v2/expirable"
 	"gorm.io/gorm"
 
 	"github.com/yeegeek/uyou-go-api-starter/internal/config"
+	"github.com/yeegeek/uyou-go-api-starter/internal/metrics"
 )
 
 var (
@@ -22,10 +25,37 @@ var (
 	ErrInvalidToken = errors.New("invalid token")
 	// ErrExpiredToken is returned when token is expired
 	ErrExpiredToken = errors.New("token expired")
+	// ErrTokenNotYetValid is returned when a token is used before its nbf (not before) time
+	ErrTokenNotYetValid = errors.New("token not yet valid")
 	// ErrTokenReuse is returned when a refresh token is reused
 	ErrTokenReuse = errors.New("token reuse detected")
 	// ErrTokenRevoked is returned when a refresh token has been revoked
 	ErrTokenRevoked = errors.New("token has been revoked")
+	// ErrStaleToken is returned when an access token was issued before the user's roles changed
+	ErrStaleToken = errors.New("token is stale")
+	// ErrImpersonateSelf is returned when an admin tries to impersonate themselves
+	ErrImpersonateSelf = errors.New("cannot impersonate yourself")
+	// ErrImpersonateAdmin is returned when impersonating another admin is not allowed by config
+	ErrImpersonateAdmin = errors.New("impersonating an admin is not allowed")
+	// ErrImpersonationNotRenewable is returned when renewing a token issued for impersonation
+	ErrImpersonationNotRenewable = errors.New("impersonation tokens cannot be renewed")
+	// ErrRefreshStoreUnavailable is returned when refresh token persistence is not configured
+	ErrRefreshStoreUnavailable = errors.New("refresh token repository not initialized")
+	// ErrRotationFailed is returned when the refresh token store fails while rotating; it wraps the storage error.
+	// The presented refresh token is left unused, so the client may retry with it.
+	ErrRotationFailed = errors.New("refresh token rotation failed")
+	// ErrAccountDisabled is returned when refreshing or renewing a session of a user an administrator has disabled
+	ErrAccountDisabled = errors.New("account disabled")
+	// ErrNoActiveSession is returned when renewing an access token of a user whose refresh tokens were all revoked or expired
+	ErrNoActiveSession = errors.New("no active session")
+)
+
+const (
+	defaultTokenVersionCacheTTL  = 10 * time.Second
+	defaultTokenVersionCacheSize = 10000
+	defaultImpersonationTTL      = 15 * time.Minute
+	defaultRememberMeTTL         = 30 * 24 * time.Hour
+	defaultIdentityCacheSize     = 10000
 )
 
 // TokenPair represents an access and refresh token pair
@@ -37,106 +67,424 @@ type TokenPair struct {
 	TokenFamily  uuid.UUID `json:"-"`
 }
 
+// ImpersonationToken is a short-lived access token issued to an admin acting as another user.
+// No refresh token is ever issued for impersonation sessions.
+type ImpersonationToken struct {
+	AccessToken string
+	TokenType   string
+	ExpiresIn   int64
+	ExpiresAt   time.Time
+	GrantID     uuid.UUID
+}
+
 // Service defines authentication service interface
 type Service interface {
-	GenerateToken(userID uint, email string, name string) (string, error)
-	GenerateTokenPair(ctx context.Context, userID uint, email string, name string) (*TokenPair, error)
+	GenerateToken(userID uint, email string, name string, opts ...TokenPairOption) (string, error)
+	GenerateTokenPair(ctx context.Context, userID uint, email string, name string, opts ...TokenPairOption) (*TokenPair, error)
 	RefreshAccessToken(ctx context.Context, refreshToken string) (*TokenPair, error)
 	ValidateToken(tokenString string) (*Claims, error)
+	RenewAccessToken(ctx context.Context, claims *Claims) (string, error)
 	RevokeRefreshToken(ctx context.Context, refreshToken string) error
 	RevokeUserRefreshToken(ctx context.Context, userID uint, refreshToken string) error
-	RevokeAllUserTokens(ctx context.Context, userID uint) error
+	RevokeAllUserTokens(ctx context.Context, userID uint) (int64, error)
+	CountActiveSessions(ctx context.Context) (int64, error)
+	ListSessions(ctx context.Context, filter SessionFilter) ([]SessionFamily, error)
+	RevokeSession(ctx context.Context, tokenFamily uuid.UUID) error
+	GenerateImpersonationToken(ctx context.Context, impersonatorID, targetUserID uint, email, name, reason string) (*ImpersonationToken, error)
+	ListActiveImpersonations(ctx context.Context) ([]ImpersonationGrant, error)
+	InvalidateUserRoles(userID uint)
+	InvalidateAllRoles()
+	CheckClientGrant(clientID, grant string) error
+}
+
+// TokenPairOption customizes how a token pair is issued
+type TokenPairOption func(*tokenPairOptions)
+
+type tokenPairOptions struct {
+	rememberMe bool
+	clientID   string
+	grant      string
+	notBefore  time.Time
+}
+
+// WithRememberMe issues the refresh token with the extended remember-me lifetime.
+// The choice is stored with the token, so rotations keep the same lifetime.
+func WithRememberMe(enabled bool) TokenPairOption {
+	return func(o *tokenPairOptions) {
+		o.rememberMe = enabled
+	}
+}
+
+// WithNotBefore issues tokens that are not valid until t, for scheduled access. The access token
+// lifetime starts at t, and the refresh token cannot be used before t either. A zero or past t
+// issues tokens valid immediately. This is the only option GenerateToken honours.
+func WithNotBefore(t time.Time) TokenPairOption {
+	return func(o *tokenPairOptions) {
+		o.notBefore = t
+	}
 }
 
 type service struct {
-	jwtSecret        string
-	accessTokenTTL   time.Duration
-	refreshTokenTTL  time.Duration
-	refreshTokenRepo RefreshTokenRepository
-	db               *gorm.DB
-}
-
-// NewService creates a new authentication service using typed config
-func NewService(cfg *config.JWTConfig) Service {
-	// JWT Secret 必须通过配置验证，不再提供默认值
-	jwtSecret := cfg.Secret
-
-	accessTokenTTL := cfg.AccessTokenTTL
-	if accessTokenTTL == 0 {
-		if cfg.TTLHours > 0 {
-			accessTokenTTL = time.Duration(cfg.TTLHours) * time.Hour
-		} else {
-			accessTokenTTL = 15 * time.Minute
+	jwtSecret               string
+	accessTokenTTL          time.Duration
+	refreshTokenTTL         time.Duration
+	clockSkew               time.Duration
+	rememberMeTTL           time.Duration
+	refreshTokenRepo        RefreshTokenRepository
+	db                      *gorm.DB
+	enforceTokenVersion     bool
+	tokenVersions           *expirable.LRU[uint, int]
+	roleCache               *roleCache
+	impersonationRepo       ImpersonationRepository
+	impersonationTTL        time.Duration
+	allowAdminImpersonation bool
+	refreshFailures         *refreshFailureTracker
+	// identities 无数据库时登录时的用户邮箱和姓名，刷新令牌时用于签发访问令牌
+	identities *expirable.LRU[uint, userIdentity]
+	// anomalyAction 刷新令牌客户端与会话历史不符时的处理方式（config.SessionAnomaly*）
+	anomalyAction string
+	geo           GeoResolver
+	// clients 按 ID 索引的已登记客户端（jwt.clients）
+	clients map[string]config.ClientConfig
+	// orgClaims 签发令牌时是否写入用户所属组织及组织角色
+	orgClaims bool
+}
+
+// userIdentity is the part of the user record copied into access token claims
+type userIdentity struct {
+	Email  string
+	Name   string
+	Active bool
+}
+
+// NewService creates a new authentication service using typed config.
+// Refresh tokens are only available when jwt.refresh_store is "memory"; use NewServiceWithRepo for the database store.
+func NewService(cfg *config.JWTConfig, opts ...ServiceOption) Service {
+	svc := newService(cfg)
+	if cfg.RefreshStore == config.RefreshStoreMemory {
+		svc.useMemoryRefreshStore(cfg)
+	}
+	for _, opt := range opts {
+		opt(svc)
+	}
+	return svc
+}
+
+// NewServiceWithRepo creates a new authentication service with refresh token repository
+func NewServiceWithRepo(cfg *config.JWTConfig, db *gorm.DB, opts ...ServiceOption) Service {
+	svc := newService(cfg)
+	svc.refreshTokenRepo = NewRefreshTokenRepository(db)
+	svc.db = db
+	svc.impersonationRepo = NewImpersonationRepository(db)
+	svc.impersonationTTL = cfg.ImpersonationTTL
+	svc.allowAdminImpersonation = cfg.AllowAdminImpersonation
+	svc.refreshFailures = newRefreshFailureTracker(cfg.RefreshMaxFailures, refreshFailureWindow)
+
+	// WHY: The memory store is opt-in for local development; the database stays the default
+	if cfg.RefreshStore == config.RefreshStoreMemory {
+		svc.useMemoryRefreshStore(cfg)
+	}
+
+	if db != nil {
+		svc.roleCache = newRoleCache(cfg.RoleCacheSize, cfg.RoleCacheTTL)
+	}
+
+	// WHY: Version checks need the users table, so they are only available with a DB
+	if cfg.EnforceTokenVersion && db != nil {
+		cacheTTL := cfg.TokenVersionCacheTTL
+		if cacheTTL <= 0 {
+			cacheTTL = defaultTokenVersionCacheTTL
 		}
+		svc.enforceTokenVersion = true
+		svc.tokenVersions = expirable.NewLRU[uint, int](defaultTokenVersionCacheSize, nil, cacheTTL)
 	}
 
+	for _, opt := range opts {
+		opt(svc)
+	}
+	return svc
+}
+
+// newService applies the token lifetimes from cfg; the secret must already be validated, there is no default
+func newService(cfg *config.JWTConfig) *service {
+	accessTokenTTL := cfg.GetAccessTokenTTL()
+
 	refreshTokenTTL := cfg.RefreshTokenTTL
 	if refreshTokenTTL == 0 {
 		refreshTokenTTL = 168 * time.Hour
 	}
 
+	rememberMeTTL := cfg.RememberMeRefreshTokenTTL
+	if rememberMeTTL == 0 {
+		rememberMeTTL = defaultRememberMeTTL
+	}
+
 	return &service{
-		jwtSecret:       jwtSecret,
+		jwtSecret:       cfg.Secret,
 		accessTokenTTL:  accessTokenTTL,
 		refreshTokenTTL: refreshTokenTTL,
+		rememberMeTTL:   rememberMeTTL,
+		anomalyAction:   config.SessionAnomalyLog,
+		geo:             NoopGeoResolver{},
+		clients:         newClientPolicies(cfg.Clients),
+		clockSkew:       cfg.ClockSkew,
 	}
 }
 
-// NewServiceWithRepo creates a new authentication service with refresh token repository
-func NewServiceWithRepo(cfg *config.JWTConfig, db *gorm.DB) Service {
-	// JWT Secret 必须通过配置验证，不再提供默认值
-	jwtSecret := cfg.Secret
-
-	accessTokenTTL := cfg.AccessTokenTTL
-	if accessTokenTTL == 0 {
-		if cfg.TTLHours > 0 {
-			accessTokenTTL = time.Duration(cfg.TTLHours) * time.Hour
-		} else {
-			accessTokenTTL = 15 * time.Minute
+// useMemoryRefreshStore keeps refresh tokens in process memory. Without a users table the
+// identity given at login is remembered so that rotated access tokens keep the email and name.
+func (s *service) useMemoryRefreshStore(cfg *config.JWTConfig) {
+	s.refreshTokenRepo = NewMemoryRefreshTokenRepository()
+	if s.refreshFailures == nil {
+		s.refreshFailures = newRefreshFailureTracker(cfg.RefreshMaxFailures, refreshFailureWindow)
+	}
+	if s.db == nil {
+		ttl := max(s.refreshTokenTTL, s.rememberMeTTL)
+		for _, client := range s.clients {
+			ttl = max(ttl, client.RefreshTokenTTL)
 		}
+		s.identities = expirable.NewLRU[uint, userIdentity](defaultIdentityCacheSize, nil, ttl)
 	}
+}
 
-	refreshTokenTTL := cfg.RefreshTokenTTL
-	if refreshTokenTTL == 0 {
-		refreshTokenTTL = 168 * time.Hour
+// GenerateToken generates a JWT token for a user (deprecated: use GenerateTokenPair)
+func (s *service) GenerateToken(userID uint, email string, name string, opts ...TokenPairOption) (string, error) {
+	var options tokenPairOptions
+	for _, opt := range opts {
+		opt(&options)
 	}
+	return s.generateAccessToken(userID, email, name, s.accessTokenTTL, options.notBefore)
+}
 
-	return &service{
-		jwtSecret:        jwtSecret,
-		accessTokenTTL:   accessTokenTTL,
-		refreshTokenTTL:  refreshTokenTTL,
-		refreshTokenRepo: NewRefreshTokenRepository(db),
-		db:               db,
+// generateAccessToken loads the user's current roles and signs an access token valid from notBefore
+// (now when zero) for ttl
+func (s *service) generateAccessToken(userID uint, email string, name string, ttl time.Duration, notBefore time.Time) (string, error) {
+	authz, err := s.loadAuthorization(userID)
+	if err != nil {
+		return "", err
 	}
+
+	claims := &Claims{
+		UserID:      userID,
+		Email:       email,
+		Name:        name,
+		Roles:       authz.roles,
+		Permissions: authz.permissions,
+		Orgs:        authz.orgs,
+		NotBefore:   notBefore,
+	}
+
+	if s.enforceTokenVersion {
+		version, err := s.loadTokenVersion(context.Background(), userID)
+		if err != nil {
+			return "", fmt.Errorf("failed to fetch token version: %w", err)
+		}
+		claims.TokenVersion = version
+	}
+
+	return s.signAccessToken(claims, ttl)
 }
 
-// GenerateToken generates a JWT token for a user (deprecated: use GenerateTokenPair)
-func (s *service) GenerateToken(userID uint, email string, name string) (string, error) {
-	now := time.Now()
-	expirationTime := now.Add(s.accessTokenTTL)
+// loadAuthorization loads the user's role and permission names, plus their organization roles when
+// organization claims are enabled; all are empty without a DB.
+// Successful lookups are served from the role cache until it expires or is invalidated.
+func (s *service) loadAuthorization(userID uint) (authorization, error) {
+	if s.db == nil {
+		return authorization{}, nil
+	}
+	if s.roleCache == nil {
+		return s.queryAuthorization(userID)
+	}
 
+	authz, generation, ok := s.roleCache.get(userID)
+	if ok {
+		return authz, nil
+	}
+	authz, err := s.queryAuthorization(userID)
+	if err != nil {
+		return authorization{}, err
+	}
+	s.roleCache.add(userID, generation, authz)
+	return authz, nil
+}
+
+// queryAuthorization reads the user's role and permission names from the database
+func (s *service) queryAuthorization(userID uint) (authorization, error) {
 	var roles []string
-	if s.db != nil {
-		var roleNames []string
-		err := s.db.Table("roles").
-			Select("roles.name").
-			Joins("JOIN user_roles ON user_roles.role_id = roles.id").
-			Where("user_roles.user_id = ?", userID).
-			Find(&roleNames).Error
+	err := s.db.Table("roles").
+		Select("roles.name").
+		Joins("JOIN user_roles ON user_roles.role_id = roles.id").
+		Where("user_roles.user_id = ?", userID).
+		Find(&roles).Error
+	if err != nil {
+		// WHY: Security-critical - token with empty roles bypasses authorization
+		return authorization{}, fmt.Errorf("failed to fetch user roles: %w", err)
+	}
+
+	var permissions []string
+	err = s.db.Table("permissions").
+		Distinct("permissions.name").
+		Joins("JOIN role_permissions ON role_permissions.permission_id = permissions.id").
+		Joins("JOIN user_roles ON user_roles.role_id = role_permissions.role_id").
+		Where("user_roles.user_id = ?", userID).
+		Order("permissions.name").
+		Pluck("permissions.name", &permissions).Error
+	if err != nil {
+		return authorization{}, fmt.Errorf("failed to fetch user permissions: %w", err)
+	}
+
+	authz := authorization{roles: roles, permissions: permissions}
+	if s.orgClaims {
+		if authz.orgs, err = s.queryOrganizations(userID); err != nil {
+			return authorization{}, err
+		}
+	}
+	return authz, nil
+}
+
+// GenerateImpersonationToken issues a short-lived access token for targetUserID that also
+// carries the impersonating admin's ID. The grant is recorded for auditing and no refresh
+// token is created, so the session ends when the access token expires.
+func (s *service) GenerateImpersonationToken(ctx context.Context, impersonatorID, targetUserID uint, email, name, reason string) (*ImpersonationToken, error) {
+	if s.impersonationRepo == nil {
+		return nil, errors.New("impersonation repository not initialized")
+	}
+	if impersonatorID == targetUserID {
+		return nil, ErrImpersonateSelf
+	}
+
+	authz, err := s.loadAuthorization(targetUserID)
+	if err != nil {
+		return nil, err
+	}
+	if !s.allowAdminImpersonation {
+		for _, role := range authz.roles {
+			if role == "admin" {
+				return nil, ErrImpersonateAdmin
+			}
+		}
+	}
+
+	claims := &Claims{
+		UserID:         targetUserID,
+		Email:          email,
+		Name:           name,
+		Roles:          authz.roles,
+		Permissions:    authz.permissions,
+		Orgs:           authz.orgs,
+		ImpersonatorID: impersonatorID,
+	}
+	if s.enforceTokenVersion {
+		version, err := s.loadTokenVersion(ctx, targetUserID)
 		if err != nil {
-			// WHY: Security-critical - token with empty roles bypasses authorization
-			return "", fmt.Errorf("failed to fetch user roles: %w", err)
+			return nil, fmt.Errorf("failed to fetch token version: %w", err)
 		}
-		roles = roleNames
+		claims.TokenVersion = version
+	}
+
+	ttl := s.impersonationTTL
+	if ttl <= 0 {
+		ttl = defaultImpersonationTTL
+	}
+
+	grant := &ImpersonationGrant{
+		ImpersonatorID: impersonatorID,
+		TargetUserID:   targetUserID,
+		Reason:         reason,
+		ExpiresAt:      time.Now().Add(ttl),
+	}
+	if err := s.impersonationRepo.Create(ctx, grant); err != nil {
+		return nil, fmt.Errorf("failed to record impersonation grant: %w", err)
+	}
+
+	accessToken, err := s.signAccessToken(claims, ttl)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ImpersonationToken{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(ttl.Seconds()),
+		ExpiresAt:   grant.ExpiresAt,
+		GrantID:     grant.ID,
+	}, nil
+}
+
+// ListActiveImpersonations returns impersonation grants whose tokens are still valid
+func (s *service) ListActiveImpersonations(ctx context.Context) ([]ImpersonationGrant, error) {
+	if s.impersonationRepo == nil {
+		return nil, errors.New("impersonation repository not initialized")
+	}
+	return s.impersonationRepo.ListActive(ctx, time.Now())
+}
+
+// RenewAccessToken issues a fresh access token for the user of the given (already validated) claims.
+// The user's active flag, roles and permissions are reloaded, so a disabled, deleted or demoted user
+// cannot extend access with a token issued earlier. No refresh token is rotated.
+func (s *service) RenewAccessToken(ctx context.Context, claims *Claims) (string, error) {
+	if claims == nil || claims.UserID == 0 {
+		return "", ErrInvalidToken
+	}
+	// WHY: Impersonation sessions are deliberately short-lived and must not be extended
+	if claims.ImpersonatorID != 0 {
+		return "", ErrImpersonationNotRenewable
+	}
+
+	user, err := s.loadIdentity(ctx, claims.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", ErrInvalidToken
+		}
+		return "", fmt.Errorf("failed to fetch user for token claims: %w", err)
+	}
+	if !user.Active {
+		return "", ErrAccountDisabled
+	}
+	// WHY: Without version checks, logout-all and force-logout only revoke refresh tokens; requiring a
+	// live session keeps renewal from outlasting them
+	if s.refreshTokenRepo != nil {
+		sessions, err := s.refreshTokenRepo.ListFamilies(ctx, SessionFilter{UserID: claims.UserID, ActiveOnly: true, Limit: 1})
+		if err != nil {
+			return "", fmt.Errorf("failed to check active sessions: %w", err)
+		}
+		if len(sessions) == 0 {
+			return "", ErrNoActiveSession
+		}
+	}
+
+	return s.generateAccessToken(claims.UserID, user.Email, user.Name, s.accessTokenTTL, time.Time{})
+}
+
+// signAccessToken signs an access token for the claims, valid from c.NotBefore (or now, whichever
+// is later) for ttl
+func (s *service) signAccessToken(c *Claims, ttl time.Duration) (string, error) {
+	now := time.Now()
+	validFrom := now
+	if c.NotBefore.After(now) {
+		validFrom = c.NotBefore
 	}
 
 	claims := jwt.MapClaims{
-		"sub":   fmt.Sprintf("%d", userID),
-		"email": email,
-		"name":  name,
-		"roles": roles,
-		"exp":   expirationTime.Unix(),
+		"sub":   fmt.Sprintf("%d", c.UserID),
+		"email": c.Email,
+		"name":  c.Name,
+		"roles": c.Roles,
+		"perms": c.Permissions,
+		"exp":   validFrom.Add(ttl).Unix(),
 		"iat":   now.Unix(),
+		"nbf":   validFrom.Unix(),
+	}
+	if s.enforceTokenVersion {
+		claims["tv"] = c.TokenVersion
+	}
+	if len(c.Orgs) > 0 {
+		claims["orgs"] = encodeOrgClaims(c.Orgs)
+	}
+	if c.ImpersonatorID != 0 {
+		impersonator := strconv.FormatUint(uint64(c.ImpersonatorID), 10)
+		claims["impersonator_id"] = impersonator
+		claims["impersonated_by"] = impersonator
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
@@ -148,19 +496,25 @@ func (s *service) GenerateToken(userID uint, email string, name string) (string,
 	return tokenString, nil
 }
 
-// ValidateToken validates a JWT token and returns the claims
+// ValidateToken validates a JWT token and returns the claims.
+// exp and nbf are checked with the configured clock skew as leeway.
+// WHY: only HS256 tokens with an exp claim and a non-zero subject are accepted, matching what
+// signAccessToken issues; anything else signed with the secret is not a token this service created.
 func (s *service) ValidateToken(tokenString string) (*Claims, error) {
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
 		return []byte(s.jwtSecret), nil
-	})
+	}, jwt.WithLeeway(s.clockSkew), jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired())
 
 	if err != nil {
 		if errors.Is(err, jwt.ErrTokenExpired) {
 			return nil, ErrExpiredToken
 		}
+		if errors.Is(err, jwt.ErrTokenNotValidYet) {
+			return nil, ErrTokenNotYetValid
+		}
 		return nil, ErrInvalidToken
 	}
 
@@ -179,7 +533,7 @@ func (s *service) ValidateToken(tokenString string) (*Claims, error) {
 	}
 
 	userID, err := strconv.ParseUint(subStr, 10, 32)
-	if err != nil {
+	if err != nil || userID == 0 {
 		return nil, ErrInvalidToken
 	}
 
@@ -195,49 +549,175 @@ func (s *service) ValidateToken(tokenString string) (*Claims, error) {
 		}
 	}
 
+	var permissions []string
+	if permsInterface, ok := claims["perms"].([]interface{}); ok {
+		for _, perm := range permsInterface {
+			if permStr, ok := perm.(string); ok {
+				permissions = append(permissions, permStr)
+			}
+		}
+	}
+
+	var expiresAt time.Time
+	if exp, err := claims.GetExpirationTime(); err == nil && exp != nil {
+		expiresAt = exp.Time
+	}
+
+	var notBefore time.Time
+	if nbf, err := claims.GetNotBefore(); err == nil && nbf != nil {
+		notBefore = nbf.Time
+	}
+
+	var tokenVersion int
+	if tv, ok := claims["tv"].(float64); ok {
+		tokenVersion = int(tv)
+	}
+
+	var impersonatorID uint
+	imp, ok := claims["impersonator_id"].(string)
+	if !ok {
+		imp, ok = claims["impersonated_by"].(string)
+	}
+	if ok {
+		id, err := strconv.ParseUint(imp, 10, 32)
+		if err != nil {
+			return nil, ErrInvalidToken
+		}
+		impersonatorID = uint(id)
+	}
+
+	orgs, err := decodeOrgClaims(claims["orgs"])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	if s.enforceTokenVersion {
+		if err := s.checkTokenVersion(uint(userID), tokenVersion); err != nil {
+			return nil, err
+		}
+	}
+
 	return &Claims{
-		UserID: uint(userID),
-		Email:  email,
-		Name:   name,
-		Roles:  roles,
+		UserID:         uint(userID),
+		Email:          email,
+		Name:           name,
+		Roles:          roles,
+		Permissions:    permissions,
+		Orgs:           orgs,
+		TokenVersion:   tokenVersion,
+		ExpiresAt:      expiresAt,
+		NotBefore:      notBefore,
+		ImpersonatorID: impersonatorID,
 	}, nil
 }
 
+// checkTokenVersion rejects tokens whose version no longer matches the user's current version.
+// A cached value is trusted only when it matches; on mismatch the DB is consulted so that
+// freshly refreshed tokens are never rejected because of a stale cache entry.
+func (s *service) checkTokenVersion(userID uint, tokenVersion int) error {
+	if cached, ok := s.tokenVersions.Get(userID); ok && cached == tokenVersion {
+		return nil
+	}
+
+	current, err := s.loadTokenVersion(context.Background(), userID)
+	if err != nil {
+		return ErrInvalidToken
+	}
+	if current != tokenVersion {
+		return ErrStaleToken
+	}
+	return nil
+}
+
+// loadTokenVersion reads the user's current token version and refreshes the cache.
+// A missing or soft-deleted user yields ErrInvalidToken.
+func (s *service) loadTokenVersion(ctx context.Context, userID uint) (int, error) {
+	var version int
+	// WHY: Scan leaves version at 0 when no row matches, which would accept version-0 tokens
+	// of deleted users; Take reports the missing row instead
+	err := s.db.WithContext(ctx).Table("users").
+		Select("token_version").
+		Where("id = ? AND deleted_at IS NULL", userID).
+		Take(&version).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, ErrInvalidToken
+	}
+	if err != nil {
+		return 0, err
+	}
+	s.tokenVersions.Add(userID, version)
+	return version, nil
+}
+
+// refreshTTL returns the refresh token lifetime for a session
+func (s *service) refreshTTL(rememberMe bool) time.Duration {
+	if rememberMe && s.rememberMeTTL > 0 {
+		return s.rememberMeTTL
+	}
+	return s.refreshTokenTTL
+}
+
 // GenerateTokenPair generates both access and refresh tokens with rotation support
-func (s *service) GenerateTokenPair(ctx context.Context, userID uint, email string, name string) (*TokenPair, error) {
+func (s *service) GenerateTokenPair(ctx context.Context, userID uint, email string, name string, opts ...TokenPairOption) (*TokenPair, error) {
 	if s.refreshTokenRepo == nil {
-		return nil, errors.New("refresh token repository not initialized")
+		return nil, ErrRefreshStoreUnavailable
 	}
 
-	accessToken, err := s.GenerateToken(userID, email, name)
+	var options tokenPairOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if err := s.CheckClientGrant(options.clientID, options.grant); err != nil {
+		return nil, err
+	}
+	clientID, client := s.resolveClient(options.clientID)
+	accessTTL := s.accessTTLFor(client)
+
+	accessToken, err := s.generateAccessToken(userID, email, name, accessTTL, options.notBefore)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate access token: %w", err)
 	}
 
-	refreshToken, err := generateRandomToken()
+	// WHY: A delayed pair's refresh token must not mint an access token before activation,
+	// and both lifetimes start at activation
+	var delay time.Duration
+	var notBefore *time.Time
+	if d := time.Until(options.notBefore); d > 0 {
+		delay = d
+		notBefore = &options.notBefore
+	}
+
+	tokenFamily := uuid.New()
+	refreshToken, err := generateRefreshToken(tokenFamily)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate refresh token: %w", err)
 	}
 
-	tokenFamily := uuid.New()
 	refreshTokenHash := HashToken(refreshToken)
 
 	dbToken := &RefreshToken{
 		UserID:      userID,
 		TokenHash:   refreshTokenHash,
 		TokenFamily: tokenFamily,
-		ExpiresAt:   time.Now().Add(s.refreshTokenTTL),
+		ExpiresAt:   time.Now().Add(delay + s.refreshTTLFor(client, options.rememberMe)),
+		NotBefore:   notBefore,
+		RememberMe:  options.rememberMe,
+		ClientID:    clientID,
 	}
+	s.issuedTo(ctx, dbToken)
 
 	if err := s.refreshTokenRepo.Create(ctx, dbToken); err != nil {
 		return nil, fmt.Errorf("failed to store refresh token: %w", err)
 	}
+	if s.identities != nil {
+		s.identities.Add(userID, userIdentity{Email: email, Name: name, Active: true})
+	}
 
 	return &TokenPair{
 		AccessToken:  accessToken,
 		RefreshToken: refreshToken,
 		TokenType:    "Bearer",
-		ExpiresIn:    int64(s.accessTokenTTL.Seconds()),
+		ExpiresIn:    int64((delay + accessTTL).Seconds()),
 		TokenFamily:  tokenFamily,
 	}, nil
 }
@@ -245,17 +725,33 @@ func (s *service) GenerateTokenPair(ctx context.Context, userID uint, email stri
 // RefreshAccessToken validates refresh token and generates new token pair with rotation
 func (s *service) RefreshAccessToken(ctx context.Context, refreshToken string) (*TokenPair, error) {
 	if s.refreshTokenRepo == nil {
-		return nil, errors.New("refresh token repository not initialized")
+		return nil, ErrRefreshStoreUnavailable
+	}
+
+	// WHY: Malformed tokens cannot match any issued token, so reject them without a database round trip
+	if !IsWellFormedRefreshToken(refreshToken) {
+		return nil, ErrInvalidToken
 	}
+	family, hasFamily := RefreshTokenFamily(refreshToken)
 
 	tokenHash := HashToken(refreshToken)
 
 	storedToken, err := s.refreshTokenRepo.FindByTokenHash(ctx, tokenHash)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
+			if hasFamily {
+				s.recordRefreshFailure(ctx, family)
+			}
 			return nil, ErrInvalidToken
 		}
-		return nil, fmt.Errorf("failed to find refresh token: %w", err)
+		return nil, fmt.Errorf("%w: failed to find refresh token: %w", ErrRotationFailed, err)
+	}
+
+	if !tokenHashEqual(storedToken.TokenHash, tokenHash) || (hasFamily && storedToken.TokenFamily != family) {
+		if hasFamily {
+			s.recordRefreshFailure(ctx, family)
+		}
+		return nil, ErrInvalidToken
 	}
 
 	if storedToken.RevokedAt != nil {
@@ -266,33 +762,40 @@ func (s *service) RefreshAccessToken(ctx context.Context, refreshToken string) (
 		return nil, ErrExpiredToken
 	}
 
+	if storedToken.NotBefore != nil && time.Now().Before(*storedToken.NotBefore) {
+		return nil, ErrTokenNotYetValid
+	}
+
 	if storedToken.UsedAt != nil {
 		if err := s.refreshTokenRepo.RevokeTokenFamily(ctx, storedToken.TokenFamily); err != nil {
 			return nil, fmt.Errorf("failed to revoke token family: %w", err)
 		}
+		metrics.RecordTokenRefresh(metrics.TokenRefreshReuse)
 		return nil, ErrTokenReuse
 	}
 
-	if err := s.refreshTokenRepo.MarkAsUsed(ctx, storedToken.ID); err != nil {
-		return nil, fmt.Errorf("failed to mark token as used: %w", err)
+	// WHY: Rotations keep the client the session was issued to; a client removed from the config falls back to the global lifetimes
+	if err := s.CheckClientGrant(storedToken.ClientID, config.ClientGrantRefresh); err != nil {
+		return nil, err
 	}
+	clientID, client := s.resolveClient(storedToken.ClientID)
+	accessTTL := s.accessTTLFor(client)
 
-	type userModel struct {
-		ID    uint
-		Email string
-		Name  string
-	}
-	var user userModel
-	if err := s.db.WithContext(ctx).Table("users").Select("id, email, name").Where("id = ?", storedToken.UserID).First(&user).Error; err != nil {
+	user, err := s.loadIdentity(ctx, storedToken.UserID)
+	if err != nil {
 		return nil, fmt.Errorf("failed to fetch user for token claims: %w", err)
 	}
+	// WHY: Disabling revokes refresh tokens, but a rotation racing the revocation must not keep the session alive
+	if !user.Active {
+		return nil, ErrAccountDisabled
+	}
 
-	accessToken, err := s.GenerateToken(storedToken.UserID, user.Email, user.Name)
+	accessToken, err := s.generateAccessToken(storedToken.UserID, user.Email, user.Name, accessTTL, time.Time{})
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate access token: %w", err)
 	}
 
-	newRefreshToken, err := generateRandomToken()
+	newRefreshToken, err := generateRefreshToken(storedToken.TokenFamily)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate new refresh token: %w", err)
 	}
@@ -302,26 +805,64 @@ func (s *service) RefreshAccessToken(ctx context.Context, refreshToken string) (
 		UserID:      storedToken.UserID,
 		TokenHash:   newTokenHash,
 		TokenFamily: storedToken.TokenFamily,
-		ExpiresAt:   time.Now().Add(s.refreshTokenTTL),
+		ExpiresAt:   time.Now().Add(s.refreshTTLFor(client, storedToken.RememberMe)),
+		RememberMe:  storedToken.RememberMe,
+		ClientID:    clientID,
 	}
+	s.issuedTo(ctx, newDBToken)
 
-	if err := s.refreshTokenRepo.Create(ctx, newDBToken); err != nil {
-		return nil, fmt.Errorf("failed to store new refresh token: %w", err)
+	if err := s.checkSessionAnomaly(ctx, storedToken, newDBToken); err != nil {
+		return nil, err
 	}
 
+	// WHY: The old token is marked used in the same transaction that stores the new one.
+	// Marking it first would strand the client when the second write fails: its only token
+	// would be spent and a retry would look like reuse and revoke the whole family.
+	if err := s.refreshTokenRepo.Rotate(ctx, storedToken.ID, newDBToken); err != nil {
+		if errors.Is(err, ErrTokenAlreadyUsed) {
+			// A concurrent request with the same token won the rotation
+			return nil, ErrInvalidToken
+		}
+		return nil, fmt.Errorf("%w: %w", ErrRotationFailed, err)
+	}
+	if s.refreshFailures != nil {
+		s.refreshFailures.reset(storedToken.TokenFamily)
+	}
+
+	metrics.RecordTokenRefresh(metrics.TokenRefreshSuccess)
 	return &TokenPair{
 		AccessToken:  accessToken,
 		RefreshToken: newRefreshToken,
 		TokenType:    "Bearer",
-		ExpiresIn:    int64(s.accessTokenTTL.Seconds()),
+		ExpiresIn:    int64(accessTTL.Seconds()),
 		TokenFamily:  storedToken.TokenFamily,
 	}, nil
 }
 
+// loadIdentity reads the email, name and active flag for new access token claims from the users table,
+// or from the identities remembered at login when running without a database. Soft-deleted users are not found.
+func (s *service) loadIdentity(ctx context.Context, userID uint) (userIdentity, error) {
+	var user userIdentity
+	if s.db == nil {
+		if s.identities != nil {
+			if identity, ok := s.identities.Get(userID); ok {
+				return identity, nil
+			}
+		}
+		return user, gorm.ErrRecordNotFound
+	}
+	err := s.db.WithContext(ctx).Table("users").Select("email, name, active").Where("id = ? AND deleted_at IS NULL", userID).Take(&user).Error
+	return user, err
+}
+
 // RevokeRefreshToken revokes a specific refresh token
 func (s *service) RevokeRefreshToken(ctx context.Context, refreshToken string) error {
 	if s.refreshTokenRepo == nil {
-		return errors.New("refresh token repository not initialized")
+		return ErrRefreshStoreUnavailable
+	}
+
+	if !IsWellFormedRefreshToken(refreshToken) {
+		return nil
 	}
 
 	tokenHash := HashToken(refreshToken)
@@ -339,7 +880,11 @@ func (s *service) RevokeRefreshToken(ctx context.Context, refreshToken string) e
 // RevokeUserRefreshToken revokes a specific refresh token for an authenticated user
 func (s *service) RevokeUserRefreshToken(ctx context.Context, userID uint, refreshToken string) error {
 	if s.refreshTokenRepo == nil {
-		return errors.New("refresh token repository not initialized")
+		return ErrRefreshStoreUnavailable
+	}
+
+	if !IsWellFormedRefreshToken(refreshToken) {
+		return nil
 	}
 
 	tokenHash := HashToken(refreshToken)
@@ -358,20 +903,78 @@ func (s *service) RevokeUserRefreshToken(ctx context.Context, userID uint, refre
 	return s.refreshTokenRepo.RevokeTokenFamily(ctx, storedToken.TokenFamily)
 }
 
-// RevokeAllUserTokens revokes all refresh tokens for a user
-func (s *service) RevokeAllUserTokens(ctx context.Context, userID uint) error {
+// recordRefreshFailure counts a failed refresh for the token family named by the presented
+// token and revokes the whole family once the failure limit is reached
+func (s *service) recordRefreshFailure(ctx context.Context, family uuid.UUID) {
+	if s.refreshFailures == nil || !s.refreshFailures.record(family) {
+		return
+	}
+
+	if err := s.refreshTokenRepo.RevokeTokenFamily(ctx, family); err != nil {
+		slog.ErrorContext(ctx, "Failed to revoke refresh token family after repeated failures", "token_family", family, "error", err)
+		return
+	}
+	slog.WarnContext(ctx, "Security event: refresh token family revoked after repeated invalid refresh attempts",
+		"event", "refresh_token_bruteforce",
+		"token_family", family,
+	)
+}
+
+// RevokeAllUserTokens revokes all refresh tokens for a user and returns the number of sessions that were still active.
+// Access tokens already issued stay valid until they expire.
+func (s *service) RevokeAllUserTokens(ctx context.Context, userID uint) (int64, error) {
 	if s.refreshTokenRepo == nil {
-		return errors.New("refresh token repository not initialized")
+		return 0, ErrRefreshStoreUnavailable
 	}
 
 	return s.refreshTokenRepo.RevokeByUserID(ctx, userID)
 }
 
-// generateRandomToken generates a cryptographically secure random token
+// CountActiveSessions returns the number of sessions with a usable refresh token
+func (s *service) CountActiveSessions(ctx context.Context) (int64, error) {
+	if s.refreshTokenRepo == nil {
+		return 0, ErrRefreshStoreUnavailable
+	}
+
+	return s.refreshTokenRepo.CountActiveFamilies(ctx)
+}
+
+// ListSessions returns login sessions (refresh token families) matching filter, newest first
+func (s *service) ListSessions(ctx context.Context, filter SessionFilter) ([]SessionFamily, error) {
+	if s.refreshTokenRepo == nil {
+		return nil, ErrRefreshStoreUnavailable
+	}
+
+	return s.refreshTokenRepo.ListFamilies(ctx, filter)
+}
+
+// RevokeSession revokes every refresh token of a token family, signing out that one session.
+// Returns ErrSessionNotFound when the family has no tokens.
+func (s *service) RevokeSession(ctx context.Context, tokenFamily uuid.UUID) error {
+	if s.refreshTokenRepo == nil {
+		return ErrRefreshStoreUnavailable
+	}
+
+	tokens, err := s.refreshTokenRepo.FindByTokenFamily(ctx, tokenFamily)
+	if err != nil {
+		return fmt.Errorf("failed to find token family: %w", err)
+	}
+	if len(tokens) == 0 {
+		return ErrSessionNotFound
+	}
+
+	return s.refreshTokenRepo.RevokeTokenFamily(ctx, tokenFamily)
+}
+
+// refreshTokenEntropyBytes is the size of the random part of a refresh token (256 bits)
+const refreshTokenEntropyBytes = 32
+
+// generateRandomToken generates a cryptographically secure random token of
+// refreshTokenEntropyBytes, encoded as unpadded base64url (43 characters)
 func generateRandomToken() (string, error) {
-	b := make([]byte, 32)
+	b := make([]byte, refreshTokenEntropyBytes)
 	if _, err := rand.Read(b); err != nil {
 		return "", err
 	}
-	return base64.URLEncoding.EncodeToString(b), nil
+	return base64.RawURLEncoding.EncodeToString(b), nil
 }
@@ -0,0 +1,132 @@
+package auth
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/yeegeek/uyou-go-api-starter/internal/config"
+)
+
+const fuzzSecret = "fuzz-secret"
+
+// Token mutations applied by FuzzValidateToken before validation
+const (
+	mutateNone = iota
+	mutateFlipSignature
+	mutateAlgNone
+	mutateTruncate
+	mutateFlipAny
+	mutateCount
+)
+
+func signFuzzToken(f *testing.F, method jwt.SigningMethod, claims jwt.MapClaims) string {
+	f.Helper()
+	token, err := jwt.NewWithClaims(method, claims).SignedString([]byte(fuzzSecret))
+	if err != nil {
+		f.Fatal(err)
+	}
+	return token
+}
+
+// mutateToken applies one of the mutate* operations; pos selects the byte to flip or the cut point
+func mutateToken(token string, op uint8, pos uint16) string {
+	switch op % mutateCount {
+	case mutateFlipSignature:
+		sig := strings.LastIndexByte(token, '.') + 1
+		if sig == 0 || sig >= len(token) {
+			return token
+		}
+		b := []byte(token)
+		b[sig+int(pos)%(len(token)-sig)] ^= byte(pos>>8) | 1
+		return string(b)
+	case mutateAlgNone:
+		parts := strings.Split(token, ".")
+		if len(parts) != 3 {
+			return token
+		}
+		header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none","typ":"JWT"}`))
+		return header + "." + parts[1] + "."
+	case mutateTruncate:
+		if token == "" {
+			return token
+		}
+		return token[:int(pos)%len(token)]
+	case mutateFlipAny:
+		if token == "" {
+			return token
+		}
+		b := []byte(token)
+		b[int(pos)%len(b)] ^= byte(pos>>8) | 1
+		return string(b)
+	}
+	return token
+}
+
+func FuzzValidateToken(f *testing.F) {
+	svc := NewService(&config.JWTConfig{Secret: fuzzSecret, AccessTokenTTL: 15 * time.Minute})
+	now := time.Now()
+
+	// Tokens signed with the real secret; only these may ever validate successfully
+	issued := map[string]bool{}
+	for _, claims := range []jwt.MapClaims{
+		{"sub": "123", "email": "test@example.com", "roles": []any{"user", "admin"}, "exp": now.Add(time.Hour).Unix()},
+		{"sub": "999", "roles": []any{123, 456}, "exp": now.Add(time.Hour).Unix()},
+		{"sub": "999", "roles": "admin", "perms": map[string]any{"a": 1}, "exp": now.Add(time.Hour).Unix()},
+		{"sub": "1", "orgs": map[string]any{"7": "owner"}, "exp": now.Add(time.Hour).Unix()},
+		{"sub": "1", "impersonator_id": "2", "tv": 1e300, "exp": now.Add(time.Hour).Unix()},
+	} {
+		token := signFuzzToken(f, jwt.SigningMethodHS256, claims)
+		issued[token] = true
+		f.Add(token, uint8(mutateNone), uint16(0))
+		for op := uint8(mutateFlipSignature); op < mutateCount; op++ {
+			f.Add(token, op, uint16(7))
+		}
+	}
+
+	// Tokens that must be rejected even unmutated
+	for _, token := range []string{
+		signFuzzToken(f, jwt.SigningMethodHS256, jwt.MapClaims{"sub": "123", "exp": now.Add(-time.Hour).Unix()}),
+		signFuzzToken(f, jwt.SigningMethodHS256, jwt.MapClaims{"sub": "invalid-id", "exp": now.Add(time.Hour).Unix()}),
+		signFuzzToken(f, jwt.SigningMethodHS256, jwt.MapClaims{"sub": "0", "exp": now.Add(time.Hour).Unix()}),
+		signFuzzToken(f, jwt.SigningMethodHS256, jwt.MapClaims{"sub": 123, "exp": now.Add(time.Hour).Unix()}),
+		signFuzzToken(f, jwt.SigningMethodHS256, jwt.MapClaims{"sub": "123"}),
+		signFuzzToken(f, jwt.SigningMethodHS512, jwt.MapClaims{"sub": "123", "exp": now.Add(time.Hour).Unix()}),
+		signFuzzToken(f, jwt.SigningMethodHS256, jwt.MapClaims{"sub": "1", "impersonator_id": "x", "exp": now.Add(time.Hour).Unix()}),
+		signFuzzToken(f, jwt.SigningMethodHS256, jwt.MapClaims{"sub": "1", "orgs": "owner", "exp": now.Add(time.Hour).Unix()}),
+		"",
+		"not-a-jwt",
+		"a.b.c",
+		"..",
+		"eyJhbGciOiJub25lIn0.eyJzdWIiOiIxIn0.",
+	} {
+		f.Add(token, uint8(mutateNone), uint16(0))
+	}
+
+	f.Fuzz(func(t *testing.T, token string, op uint8, pos uint16) {
+		mutated := mutateToken(token, op, pos)
+		claims, err := svc.ValidateToken(mutated)
+		if err == nil {
+			if claims == nil {
+				t.Fatalf("nil claims without error for %q", mutated)
+			}
+			if !issued[mutated] {
+				t.Fatalf("token that was never issued validated successfully: %q", mutated)
+			}
+			if claims.UserID == 0 {
+				t.Fatalf("zero user ID accepted for %q", mutated)
+			}
+			return
+		}
+		if claims != nil {
+			t.Fatalf("claims returned with error %v", err)
+		}
+		if !errors.Is(err, ErrInvalidToken) && !errors.Is(err, ErrExpiredToken) {
+			t.Fatalf("unexpected error %v for %q", err, mutated)
+		}
+	})
+}
@@ -2,16 +2,21 @@ package auth
 
 import (
 	"context"
+	"encoding/base64"
+	"errors"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 
 	"github.com/yeegeek/uyou-go-api-starter/internal/config"
+	"github.com/yeegeek/uyou-go-api-starter/internal/metrics"
 )
 
 // testUser is a minimal user struct for testing
@@ -20,6 +25,7 @@ type testUser struct {
 	Name         string `gorm:"not null"`
 	Email        string `gorm:"uniqueIndex;not null"`
 	PasswordHash string `gorm:"not null"`
+	Active       bool   `gorm:"not null;default:true"`
 	CreatedAt    time.Time
 	UpdatedAt    time.Time
 	DeletedAt    gorm.DeletedAt `gorm:"index"`
@@ -50,11 +56,29 @@ func (testUserRole) TableName() string {
 	return "user_roles"
 }
 
-func setupServiceTest(t *testing.T) (*service, *gorm.DB) {
+type testPermission struct {
+	ID   uint   `gorm:"primaryKey"`
+	Name string `gorm:"uniqueIndex;not null"`
+}
+
+func (testPermission) TableName() string {
+	return "permissions"
+}
+
+type testRolePermission struct {
+	RoleID       uint `gorm:"primaryKey"`
+	PermissionID uint `gorm:"primaryKey"`
+}
+
+func (testRolePermission) TableName() string {
+	return "role_permissions"
+}
+
+func setupServiceTest(t testing.TB) (*service, *gorm.DB) {
 	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
 	require.NoError(t, err)
 
-	err = db.AutoMigrate(&RefreshToken{}, &testUser{}, &testRole{}, &testUserRole{})
+	err = db.AutoMigrate(&RefreshToken{}, &testUser{}, &testRole{}, &testUserRole{}, &testPermission{}, &testRolePermission{})
 	require.NoError(t, err)
 
 	testRoleData := &testRole{
@@ -95,8 +119,10 @@ func setupServiceTest(t *testing.T) (*service, *gorm.DB) {
 		jwtSecret:        cfg.Secret,
 		accessTokenTTL:   cfg.AccessTokenTTL,
 		refreshTokenTTL:  cfg.RefreshTokenTTL,
+		rememberMeTTL:    defaultRememberMeTTL,
 		refreshTokenRepo: NewRefreshTokenRepository(db),
 		db:               db,
+		refreshFailures:  newRefreshFailureTracker(defaultRefreshMaxFailures, refreshFailureWindow),
 	}
 
 	return svc, db
@@ -120,6 +146,106 @@ func TestService_GenerateTokenPair(t *testing.T) {
 	assert.Equal(t, "Test User", claims.Name)
 }
 
+func TestService_GenerateTokenPair_RememberMe(t *testing.T) {
+	tests := []struct {
+		name       string
+		opts       []TokenPairOption
+		rememberMe bool
+		wantTTL    time.Duration
+	}{
+		{name: "default lifetime", wantTTL: 7 * 24 * time.Hour},
+		{name: "remember me disabled", opts: []TokenPairOption{WithRememberMe(false)}, wantTTL: 7 * 24 * time.Hour},
+		{name: "remember me enabled", opts: []TokenPairOption{WithRememberMe(true)}, rememberMe: true, wantTTL: defaultRememberMeTTL},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, db := setupServiceTest(t)
+			ctx := context.Background()
+
+			pair, err := svc.GenerateTokenPair(ctx, 1, "test@example.com", "Test User", tt.opts...)
+			require.NoError(t, err)
+
+			var stored RefreshToken
+			require.NoError(t, db.Where("token_hash = ?", HashToken(pair.RefreshToken)).First(&stored).Error)
+			assert.Equal(t, tt.rememberMe, stored.RememberMe)
+			assert.WithinDuration(t, time.Now().Add(tt.wantTTL), stored.ExpiresAt, time.Minute)
+		})
+	}
+}
+
+func TestService_GenerateTokenPair_NotBefore(t *testing.T) {
+	svc, db := setupServiceTest(t)
+	ctx := context.Background()
+	activateAt := time.Now().Add(time.Hour)
+
+	pair, err := svc.GenerateTokenPair(ctx, 1, "test@example.com", "Test User", WithNotBefore(activateAt))
+	require.NoError(t, err)
+	assert.InDelta(t, (time.Hour + svc.accessTokenTTL).Seconds(), float64(pair.ExpiresIn), 2)
+
+	_, err = svc.ValidateToken(pair.AccessToken)
+	assert.ErrorIs(t, err, ErrTokenNotYetValid)
+
+	var stored RefreshToken
+	require.NoError(t, db.Where("token_hash = ?", HashToken(pair.RefreshToken)).First(&stored).Error)
+	require.NotNil(t, stored.NotBefore)
+	assert.WithinDuration(t, activateAt, *stored.NotBefore, time.Second)
+	assert.WithinDuration(t, activateAt.Add(7*24*time.Hour), stored.ExpiresAt, time.Minute)
+
+	// The refresh token cannot mint an active access token early, and stays usable afterwards
+	_, err = svc.RefreshAccessToken(ctx, pair.RefreshToken)
+	assert.ErrorIs(t, err, ErrTokenNotYetValid)
+
+	require.NoError(t, db.Model(&RefreshToken{}).Where("id = ?", stored.ID).
+		Update("not_before", time.Now().Add(-time.Second)).Error)
+	rotated, err := svc.RefreshAccessToken(ctx, pair.RefreshToken)
+	require.NoError(t, err)
+	_, err = svc.ValidateToken(rotated.AccessToken)
+	assert.NoError(t, err)
+}
+
+func TestService_RefreshAccessToken_RememberMeKeepsLifetime(t *testing.T) {
+	svc, db := setupServiceTest(t)
+	ctx := context.Background()
+
+	originalPair, err := svc.GenerateTokenPair(ctx, 1, "test@example.com", "Test User", WithRememberMe(true))
+	require.NoError(t, err)
+
+	rotated, err := svc.RefreshAccessToken(ctx, originalPair.RefreshToken)
+	require.NoError(t, err)
+	assert.Equal(t, originalPair.TokenFamily, rotated.TokenFamily)
+
+	var stored RefreshToken
+	require.NoError(t, db.Where("token_hash = ?", HashToken(rotated.RefreshToken)).First(&stored).Error)
+	assert.True(t, stored.RememberMe)
+	assert.WithinDuration(t, time.Now().Add(defaultRememberMeTTL), stored.ExpiresAt, time.Minute)
+
+	// Reuse detection still revokes the whole extended-lifetime family
+	_, err = svc.RefreshAccessToken(ctx, originalPair.RefreshToken)
+	assert.ErrorIs(t, err, ErrTokenReuse)
+
+	_, err = svc.RefreshAccessToken(ctx, rotated.RefreshToken)
+	assert.ErrorIs(t, err, ErrTokenRevoked)
+}
+
+func TestService_GenerateToken_IncludesRolePermissions(t *testing.T) {
+	svc, db := setupServiceTest(t)
+
+	require.NoError(t, db.Create(&testPermission{ID: 1, Name: "users:read"}).Error)
+	require.NoError(t, db.Create(&testPermission{ID: 2, Name: "users:delete"}).Error)
+	require.NoError(t, db.Create(&testPermission{ID: 3, Name: "stats:read"}).Error)
+	require.NoError(t, db.Create(&testRolePermission{RoleID: 1, PermissionID: 1}).Error)
+	require.NoError(t, db.Create(&testRolePermission{RoleID: 1, PermissionID: 2}).Error)
+
+	token, err := svc.GenerateToken(1, "test@example.com", "Test User")
+	require.NoError(t, err)
+
+	claims, err := svc.ValidateToken(token)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"user"}, claims.Roles)
+	assert.Equal(t, []string{"users:delete", "users:read"}, claims.Permissions)
+}
+
 func TestService_RefreshAccessToken_Success(t *testing.T) {
 	svc, _ := setupServiceTest(t)
 	ctx := context.Background()
@@ -159,6 +285,46 @@ func TestService_RefreshAccessToken_ReuseDetection(t *testing.T) {
 	}
 }
 
+func TestService_RefreshAccessToken_RecordsMetrics(t *testing.T) {
+	svc, _ := setupServiceTest(t)
+	ctx := context.Background()
+	successes := counterValue(t, "auth_token_refresh_total", "result", metrics.TokenRefreshSuccess)
+	reuses := counterValue(t, "auth_token_refresh_total", "result", metrics.TokenRefreshReuse)
+
+	pair, err := svc.GenerateTokenPair(ctx, 1, "test@example.com", "Test User")
+	require.NoError(t, err)
+	_, err = svc.RefreshAccessToken(ctx, pair.RefreshToken)
+	require.NoError(t, err)
+	_, err = svc.RefreshAccessToken(ctx, pair.RefreshToken)
+	require.ErrorIs(t, err, ErrTokenReuse)
+
+	assert.Equal(t, successes+1, counterValue(t, "auth_token_refresh_total", "result", metrics.TokenRefreshSuccess))
+	assert.Equal(t, reuses+1, counterValue(t, "auth_token_refresh_total", "result", metrics.TokenRefreshReuse))
+}
+
+// counterValue reads a counter from the default Prometheus registry; label selects one series of a vector
+func counterValue(t *testing.T, name, label, value string) float64 {
+	t.Helper()
+	families, err := prometheus.DefaultGatherer.Gather()
+	require.NoError(t, err)
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			if label == "" {
+				return metric.GetCounter().GetValue()
+			}
+			for _, pair := range metric.GetLabel() {
+				if pair.GetName() == label && pair.GetValue() == value {
+					return metric.GetCounter().GetValue()
+				}
+			}
+		}
+	}
+	return 0
+}
+
 func TestService_RefreshAccessToken_InvalidToken(t *testing.T) {
 	svc, _ := setupServiceTest(t)
 	ctx := context.Background()
@@ -172,17 +338,19 @@ func TestService_RefreshAccessToken_ExpiredToken(t *testing.T) {
 	ctx := context.Background()
 
 	tokenFamily := uuid.New()
+	expiredRefreshToken, err := generateRefreshToken(tokenFamily)
+	require.NoError(t, err)
 	expiredToken := &RefreshToken{
 		UserID:      1,
-		TokenHash:   HashToken("expired-refresh-token"),
+		TokenHash:   HashToken(expiredRefreshToken),
 		TokenFamily: tokenFamily,
 		ExpiresAt:   time.Now().Add(-1 * time.Hour),
 	}
 
-	err := db.Create(expiredToken).Error
+	err = db.Create(expiredToken).Error
 	require.NoError(t, err)
 
-	_, err = svc.RefreshAccessToken(ctx, "expired-refresh-token")
+	_, err = svc.RefreshAccessToken(ctx, expiredRefreshToken)
 	assert.ErrorIs(t, err, ErrExpiredToken)
 }
 
@@ -191,22 +359,41 @@ func TestService_RefreshAccessToken_RevokedToken(t *testing.T) {
 	ctx := context.Background()
 
 	tokenFamily := uuid.New()
+	revokedRefreshToken, err := generateRefreshToken(tokenFamily)
+	require.NoError(t, err)
 	now := time.Now()
 	revokedToken := &RefreshToken{
 		UserID:      1,
-		TokenHash:   HashToken("revoked-refresh-token"),
+		TokenHash:   HashToken(revokedRefreshToken),
 		TokenFamily: tokenFamily,
 		ExpiresAt:   time.Now().Add(7 * 24 * time.Hour),
 		RevokedAt:   &now,
 	}
 
-	err := db.Create(revokedToken).Error
+	err = db.Create(revokedToken).Error
 	require.NoError(t, err)
 
-	_, err = svc.RefreshAccessToken(ctx, "revoked-refresh-token")
+	_, err = svc.RefreshAccessToken(ctx, revokedRefreshToken)
 	assert.ErrorIs(t, err, ErrTokenRevoked)
 }
 
+func TestService_RefreshAccessToken_DisabledAccount(t *testing.T) {
+	svc, db := setupServiceTest(t)
+	ctx := context.Background()
+
+	pair, err := svc.GenerateTokenPair(ctx, 1, "test@example.com", "Test User")
+	require.NoError(t, err)
+	require.NoError(t, db.Model(&testUser{}).Where("id = ?", 1).Update("active", false).Error)
+
+	_, err = svc.RefreshAccessToken(ctx, pair.RefreshToken)
+	assert.ErrorIs(t, err, ErrAccountDisabled)
+
+	// The token was not consumed, so it works again once the account is re-enabled
+	require.NoError(t, db.Model(&testUser{}).Where("id = ?", 1).Update("active", true).Error)
+	_, err = svc.RefreshAccessToken(ctx, pair.RefreshToken)
+	assert.NoError(t, err)
+}
+
 func TestService_RevokeRefreshToken(t *testing.T) {
 	svc, db := setupServiceTest(t)
 	ctx := context.Background()
@@ -237,8 +424,9 @@ func TestService_RevokeAllUserTokens(t *testing.T) {
 	pair3, err := svc.GenerateTokenPair(ctx, 2, "user2@example.com", "User 2")
 	require.NoError(t, err)
 
-	err = svc.RevokeAllUserTokens(ctx, 1)
+	revoked, err := svc.RevokeAllUserTokens(ctx, 1)
 	assert.NoError(t, err)
+	assert.Equal(t, int64(2), revoked)
 
 	var user1Tokens []RefreshToken
 	err = db.Where("user_id = ?", 1).Find(&user1Tokens).Error
@@ -269,6 +457,85 @@ func TestGenerateRandomToken(t *testing.T) {
 	assert.NotEmpty(t, token2)
 
 	assert.NotEqual(t, token1, token2, "Each token should be unique")
+
+	raw, err := base64.RawURLEncoding.DecodeString(token1)
+	require.NoError(t, err, "token should be unpadded base64url")
+	assert.Len(t, raw, 32, "token should carry 256 bits of entropy")
+}
+
+func TestRefreshTokenFormat(t *testing.T) {
+	family := uuid.New()
+	token, err := generateRefreshToken(family)
+	require.NoError(t, err)
+
+	assert.Len(t, token, 66)
+	assert.True(t, IsWellFormedRefreshToken(token))
+	parsed, ok := RefreshTokenFamily(token)
+	assert.True(t, ok)
+	assert.Equal(t, family, parsed)
+
+	legacy := base64.URLEncoding.EncodeToString(make([]byte, 32))
+	assert.True(t, IsWellFormedRefreshToken(legacy), "tokens issued before the family prefix must keep working")
+	_, ok = RefreshTokenFamily(legacy)
+	assert.False(t, ok)
+
+	for _, bad := range []string{"", "invalid-token", token + "x", token[:65], strings.Replace(token, ".", "$", 1), strings.Repeat("a", 22) + ".!" + strings.Repeat("a", 42)} {
+		assert.False(t, IsWellFormedRefreshToken(bad), "token %q should be rejected", bad)
+	}
+}
+
+func TestService_RefreshAccessToken_MalformedTokenSkipsDatabase(t *testing.T) {
+	svc, db := setupServiceTest(t)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	require.NoError(t, sqlDB.Close())
+
+	_, err = svc.RefreshAccessToken(context.Background(), "not-a-refresh-token")
+	assert.ErrorIs(t, err, ErrInvalidToken, "malformed tokens must be rejected before any query")
+}
+
+func TestService_RefreshAccessToken_RepeatedFailuresRevokeFamily(t *testing.T) {
+	svc, db := setupServiceTest(t)
+	ctx := context.Background()
+
+	pair, err := svc.GenerateTokenPair(ctx, 1, "test@example.com", "Test User")
+	require.NoError(t, err)
+
+	for i := 0; i < defaultRefreshMaxFailures; i++ {
+		guess, err := generateRefreshToken(pair.TokenFamily)
+		require.NoError(t, err)
+		_, err = svc.RefreshAccessToken(ctx, guess)
+		assert.ErrorIs(t, err, ErrInvalidToken)
+	}
+
+	var stored RefreshToken
+	require.NoError(t, db.Where("token_family = ?", pair.TokenFamily).First(&stored).Error)
+	assert.NotNil(t, stored.RevokedAt, "family should be revoked after repeated failures")
+
+	_, err = svc.RefreshAccessToken(ctx, pair.RefreshToken)
+	assert.ErrorIs(t, err, ErrTokenRevoked)
+}
+
+func TestService_RefreshAccessToken_SuccessResetsFailures(t *testing.T) {
+	svc, _ := setupServiceTest(t)
+	ctx := context.Background()
+
+	pair, err := svc.GenerateTokenPair(ctx, 1, "test@example.com", "Test User")
+	require.NoError(t, err)
+
+	current := pair.RefreshToken
+	for round := 0; round < 2; round++ {
+		for i := 0; i < defaultRefreshMaxFailures-1; i++ {
+			guess, err := generateRefreshToken(pair.TokenFamily)
+			require.NoError(t, err)
+			_, err = svc.RefreshAccessToken(ctx, guess)
+			assert.ErrorIs(t, err, ErrInvalidToken)
+		}
+
+		next, err := svc.RefreshAccessToken(ctx, current)
+		require.NoError(t, err, "round %d", round)
+		current = next.RefreshToken
+	}
 }
 
 func TestService_GenerateTokenPair_NilRepository(t *testing.T) {
@@ -326,8 +593,9 @@ func TestService_RevokeAllUserTokens_NilRepository(t *testing.T) {
 	svc := NewService(cfg)
 	ctx := context.Background()
 
-	err := svc.RevokeAllUserTokens(ctx, 1)
-	assert.Error(t, err)
+	revoked, err := svc.RevokeAllUserTokens(ctx, 1)
+	assert.ErrorIs(t, err, ErrRefreshStoreUnavailable)
+	assert.Zero(t, revoked)
 	assert.Contains(t, err.Error(), "refresh token repository not initialized")
 }
 
@@ -427,7 +695,7 @@ func TestService_GenerateTokenPair_DatabaseError(t *testing.T) {
 	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
 	require.NoError(t, err)
 
-	err = db.AutoMigrate(&RefreshToken{}, &testUser{}, &testRole{}, &testUserRole{})
+	err = db.AutoMigrate(&RefreshToken{}, &testUser{}, &testRole{}, &testUserRole{}, &testPermission{}, &testRolePermission{})
 	require.NoError(t, err)
 
 	testRoleData := &testRole{
@@ -487,11 +755,66 @@ func TestService_RefreshAccessToken_MarkAsUsedError(t *testing.T) {
 	assert.Error(t, err)
 }
 
+// failingRotateRepo fails the next Rotate calls after the new token was written, like a
+// connection dropped mid-transaction
+type failingRotateRepo struct {
+	RefreshTokenRepository
+	failures int
+}
+
+func (r *failingRotateRepo) Rotate(ctx context.Context, usedID uuid.UUID, next *RefreshToken) error {
+	if r.failures > 0 {
+		r.failures--
+		return errors.New("driver: bad connection")
+	}
+	return r.RefreshTokenRepository.Rotate(ctx, usedID, next)
+}
+
+func TestService_RefreshAccessToken_RotationFailureIsRetryable(t *testing.T) {
+	svc, db := setupServiceTest(t)
+	ctx := context.Background()
+
+	pair, err := svc.GenerateTokenPair(ctx, 1, "test@example.com", "Test User")
+	require.NoError(t, err)
+
+	svc.refreshTokenRepo = &failingRotateRepo{RefreshTokenRepository: svc.refreshTokenRepo, failures: 1}
+
+	_, err = svc.RefreshAccessToken(ctx, pair.RefreshToken)
+	require.ErrorIs(t, err, ErrRotationFailed)
+	assert.ErrorContains(t, err, "bad connection", "the storage cause is wrapped")
+
+	var stored RefreshToken
+	require.NoError(t, db.Where("token_hash = ?", HashToken(pair.RefreshToken)).First(&stored).Error)
+	assert.Nil(t, stored.UsedAt, "a failed rotation must not consume the old token")
+
+	newPair, err := svc.RefreshAccessToken(ctx, pair.RefreshToken)
+	require.NoError(t, err, "the old token still refreshes on retry")
+	assert.Equal(t, pair.TokenFamily, newPair.TokenFamily)
+
+	_, err = svc.RefreshAccessToken(ctx, pair.RefreshToken)
+	assert.ErrorIs(t, err, ErrTokenReuse, "once rotated the old token is spent")
+}
+
+func TestService_RefreshAccessToken_StoreUnavailableIsRetryable(t *testing.T) {
+	svc, db := setupServiceTest(t)
+	ctx := context.Background()
+
+	pair, err := svc.GenerateTokenPair(ctx, 1, "test@example.com", "Test User")
+	require.NoError(t, err)
+
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	require.NoError(t, sqlDB.Close())
+
+	_, err = svc.RefreshAccessToken(ctx, pair.RefreshToken)
+	assert.ErrorIs(t, err, ErrRotationFailed)
+}
+
 func TestService_GenerateTokenPair_InvalidSecret(t *testing.T) {
 	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
 	require.NoError(t, err)
 
-	err = db.AutoMigrate(&RefreshToken{}, &testUser{}, &testRole{}, &testUserRole{})
+	err = db.AutoMigrate(&RefreshToken{}, &testUser{}, &testRole{}, &testUserRole{}, &testPermission{}, &testRolePermission{})
 	require.NoError(t, err)
 
 	testRoleData := &testRole{
@@ -444,6 +444,102 @@ func TestService_ValidateToken(t *testing.T) {
 	})
 }
 
+func TestService_ValidateToken_ClockSkew(t *testing.T) {
+	signWithExp := func(t *testing.T, exp time.Time) string {
+		t.Helper()
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+			"sub":   "123",
+			"email": "test@example.com",
+			"name":  "Test User",
+			"exp":   exp.Unix(),
+			"iat":   exp.Add(-time.Hour).Unix(),
+		})
+		tokenString, err := token.SignedString([]byte("test-secret"))
+		assert.NoError(t, err)
+		return tokenString
+	}
+
+	skewed := NewService(&config.JWTConfig{Secret: "test-secret", ClockSkew: 30 * time.Second})
+
+	t.Run("expired within skew is accepted", func(t *testing.T) {
+		claims, err := skewed.ValidateToken(signWithExp(t, time.Now().Add(-10*time.Second)))
+		assert.NoError(t, err)
+		if assert.NotNil(t, claims) {
+			assert.Equal(t, uint(123), claims.UserID)
+		}
+	})
+
+	t.Run("expired beyond skew is rejected", func(t *testing.T) {
+		claims, err := skewed.ValidateToken(signWithExp(t, time.Now().Add(-time.Minute)))
+		assert.Equal(t, ErrExpiredToken, err)
+		assert.Nil(t, claims)
+	})
+
+	t.Run("not before within skew is accepted", func(t *testing.T) {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+			"sub": "123",
+			"exp": time.Now().Add(time.Hour).Unix(),
+			"nbf": time.Now().Add(10 * time.Second).Unix(),
+		})
+		tokenString, err := token.SignedString([]byte("test-secret"))
+		assert.NoError(t, err)
+
+		_, err = skewed.ValidateToken(tokenString)
+		assert.NoError(t, err)
+	})
+
+	t.Run("zero skew rejects a just-expired token", func(t *testing.T) {
+		strict := NewService(&config.JWTConfig{Secret: "test-secret"})
+		claims, err := strict.ValidateToken(signWithExp(t, time.Now().Add(-10*time.Second)))
+		assert.Equal(t, ErrExpiredToken, err)
+		assert.Nil(t, claims)
+	})
+}
+
+func TestService_GenerateToken_NotBefore(t *testing.T) {
+	svc := NewService(&config.JWTConfig{Secret: "test-secret", AccessTokenTTL: 15 * time.Minute})
+
+	t.Run("defaults to now", func(t *testing.T) {
+		token, err := svc.GenerateToken(123, "test@example.com", "Test User")
+		assert.NoError(t, err)
+
+		claims, err := svc.ValidateToken(token)
+		assert.NoError(t, err)
+		if assert.NotNil(t, claims) {
+			assert.WithinDuration(t, time.Now(), claims.NotBefore, 2*time.Second)
+		}
+	})
+
+	t.Run("future nbf is rejected before and accepted after", func(t *testing.T) {
+		activateAt := time.Now().Add(time.Second)
+		token, err := svc.GenerateToken(123, "test@example.com", "Test User", WithNotBefore(activateAt))
+		assert.NoError(t, err)
+
+		claims, err := svc.ValidateToken(token)
+		assert.Equal(t, ErrTokenNotYetValid, err)
+		assert.Nil(t, claims)
+
+		time.Sleep(time.Until(activateAt) + 100*time.Millisecond)
+
+		claims, err = svc.ValidateToken(token)
+		assert.NoError(t, err)
+		if assert.NotNil(t, claims) {
+			assert.Equal(t, uint(123), claims.UserID)
+			// The lifetime starts at activation
+			assert.WithinDuration(t, activateAt.Add(15*time.Minute), claims.ExpiresAt, 2*time.Second)
+		}
+	})
+
+	t.Run("clock skew covers a slightly early use", func(t *testing.T) {
+		skewed := NewService(&config.JWTConfig{Secret: "test-secret", ClockSkew: time.Minute})
+		token, err := skewed.GenerateToken(123, "test@example.com", "Test User", WithNotBefore(time.Now().Add(30*time.Second)))
+		assert.NoError(t, err)
+
+		_, err = skewed.ValidateToken(token)
+		assert.NoError(t, err)
+	})
+}
+
 func TestService_GenerateToken_RoleFetchError(t *testing.T) {
 	db := setupTestDB(t)
 	cfg := &config.JWTConfig{
@@ -0,0 +1,176 @@
+// Package auth 提供刷新令牌的客户端信息记录和会话异常（疑似劫持）检测
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/yeegeek/uyou-go-api-starter/internal/config"
+	"github.com/yeegeek/uyou-go-api-starter/internal/metrics"
+)
+
+// ErrSessionAnomaly is returned when a refresh arrives from a client the session has never been
+// seen on and the anomaly policy revokes the session
+var ErrSessionAnomaly = errors.New("session anomaly detected")
+
+// ClientInfo describes the client making a login or refresh request
+type ClientInfo struct {
+	IP        string
+	UserAgent string
+}
+
+type clientInfoKey struct{}
+
+// WithClientInfo attaches the requesting client to ctx; tokens issued under ctx record it
+func WithClientInfo(ctx context.Context, info ClientInfo) context.Context {
+	return context.WithValue(ctx, clientInfoKey{}, info)
+}
+
+// ClientInfoFromContext returns the client attached by WithClientInfo, or the zero value
+func ClientInfoFromContext(ctx context.Context) ClientInfo {
+	info, _ := ctx.Value(clientInfoKey{}).(ClientInfo)
+	return info
+}
+
+// GeoResolver maps a client IP to a coarse location, typically an ISO country code.
+// It returns "" when the location is unknown; unknown locations never count as an anomaly.
+type GeoResolver interface {
+	Country(ctx context.Context, ip string) string
+}
+
+// NoopGeoResolver resolves every IP to an unknown location, disabling the country check
+type NoopGeoResolver struct{}
+
+// Country always returns ""
+func (NoopGeoResolver) Country(context.Context, string) string { return "" }
+
+// ServiceOption configures optional Service behaviour
+type ServiceOption func(*service)
+
+// WithSessionAnomalyAction sets how refreshes from an unfamiliar client are handled:
+// config.SessionAnomalyOff, config.SessionAnomalyLog or config.SessionAnomalyRevoke
+func WithSessionAnomalyAction(action string) ServiceOption {
+	return func(s *service) {
+		s.anomalyAction = action
+	}
+}
+
+// WithGeoResolver sets the resolver used to compare refresh locations; nil keeps NoopGeoResolver
+func WithGeoResolver(resolver GeoResolver) ServiceOption {
+	return func(s *service) {
+		if resolver != nil {
+			s.geo = resolver
+		}
+	}
+}
+
+// issuedTo records the requesting client on a refresh token about to be stored
+func (s *service) issuedTo(ctx context.Context, token *RefreshToken) {
+	client := ClientInfoFromContext(ctx)
+	token.IP = client.IP
+	token.UserAgent = truncate(client.UserAgent, 512)
+	if s.geo != nil && client.IP != "" {
+		token.Geo = s.geo.Country(ctx, client.IP)
+	}
+}
+
+// checkSessionAnomaly compares the client presenting stored with every client its family was issued to.
+// Depending on the policy an anomaly is only logged, or the family is revoked and ErrSessionAnomaly returned.
+func (s *service) checkSessionAnomaly(ctx context.Context, stored *RefreshToken, next *RefreshToken) error {
+	if s.anomalyAction == config.SessionAnomalyOff {
+		return nil
+	}
+
+	history, err := s.refreshTokenRepo.FindByTokenFamily(ctx, stored.TokenFamily)
+	if err != nil {
+		// WHY: Detection is best effort; the rotation itself still reports storage failures
+		slog.WarnContext(ctx, "Failed to load token family for anomaly detection", "token_family", stored.TokenFamily, "error", err)
+		return nil
+	}
+	reasons := sessionAnomalies(history, next)
+	if len(reasons) == 0 {
+		return nil
+	}
+
+	metrics.RecordTokenRefresh(metrics.TokenRefreshAnomaly)
+	revoke := s.anomalyAction == config.SessionAnomalyRevoke
+	slog.WarnContext(ctx, "Security event: refresh token used from an unfamiliar client",
+		"event", "refresh_token_anomaly",
+		"audit", true,
+		"user_id", stored.UserID,
+		"token_family", stored.TokenFamily,
+		"reasons", reasons,
+		"ip", next.IP,
+		"geo", next.Geo,
+		"user_agent", next.UserAgent,
+		"revoked", revoke,
+	)
+	if !revoke {
+		return nil
+	}
+
+	if err := s.refreshTokenRepo.RevokeTokenFamily(ctx, stored.TokenFamily); err != nil {
+		return fmt.Errorf("failed to revoke token family: %w", err)
+	}
+	return ErrSessionAnomaly
+}
+
+// sessionAnomalies lists why next looks like a different client than every token in history:
+// "country" when its country was never seen, "user_agent" when its client class was never seen.
+// Values unknown on either side are ignored, so tokens issued before metadata was recorded never trigger.
+func sessionAnomalies(history []*RefreshToken, next *RefreshToken) []string {
+	countries := make(map[string]bool)
+	agents := make(map[string]bool)
+	for _, token := range history {
+		if token.Geo != "" {
+			countries[token.Geo] = true
+		}
+		if class := userAgentClass(token.UserAgent); class != "" {
+			agents[class] = true
+		}
+	}
+
+	var reasons []string
+	if next.Geo != "" && len(countries) > 0 && !countries[next.Geo] {
+		reasons = append(reasons, "country")
+	}
+	if class := userAgentClass(next.UserAgent); class != "" && len(agents) > 0 && !agents[class] {
+		reasons = append(reasons, "user_agent")
+	}
+	return reasons
+}
+
+// userAgentClass reduces a User-Agent to its product and platform, e.g. "mozilla windows" or "curl".
+// Browser upgrades and switching browsers on the same OS keep the class; moving from a desktop
+// browser to a phone or a script does not.
+func userAgentClass(userAgent string) string {
+	userAgent = strings.TrimSpace(userAgent)
+	if userAgent == "" {
+		return ""
+	}
+	product, rest, _ := strings.Cut(userAgent, "/")
+	product = strings.ToLower(strings.TrimSpace(product))
+
+	open := strings.Index(rest, "(")
+	if open < 0 {
+		return product
+	}
+	platform := rest[open+1:]
+	if end := strings.IndexAny(platform, ";)"); end >= 0 {
+		platform = platform[:end]
+	}
+	if fields := strings.Fields(platform); len(fields) > 0 {
+		return product + " " + strings.ToLower(fields[0])
+	}
+	return product
+}
+
+func truncate(value string, limit int) string {
+	if len(value) <= limit {
+		return value
+	}
+	return value[:limit]
+}
@@ -0,0 +1,126 @@
+package auth
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yeegeek/uyou-go-api-starter/internal/config"
+)
+
+// fakeGeoResolver resolves IPs from a fixed table
+type fakeGeoResolver map[string]string
+
+func (f fakeGeoResolver) Country(_ context.Context, ip string) string {
+	return f[ip]
+}
+
+const (
+	desktopAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 Chrome/120.0 Safari/537.36"
+	phoneAgent   = "Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) AppleWebKit/605.1.15 Mobile/15E148"
+)
+
+var testGeo = fakeGeoResolver{"198.51.100.1": "DE", "198.51.100.2": "DE", "203.0.113.9": "BR"}
+
+func clientCtx(ip, userAgent string) context.Context {
+	return WithClientInfo(context.Background(), ClientInfo{IP: ip, UserAgent: userAgent})
+}
+
+func TestService_GenerateTokenPair_RecordsClient(t *testing.T) {
+	svc, db := setupServiceTest(t)
+	svc.geo = testGeo
+
+	pair, err := svc.GenerateTokenPair(clientCtx("198.51.100.1", desktopAgent), 1, "test@example.com", "Test User")
+	require.NoError(t, err)
+
+	var stored RefreshToken
+	require.NoError(t, db.Where("token_hash = ?", HashToken(pair.RefreshToken)).First(&stored).Error)
+	assert.Equal(t, "198.51.100.1", stored.IP)
+	assert.Equal(t, desktopAgent, stored.UserAgent)
+	assert.Equal(t, "DE", stored.Geo)
+}
+
+func TestService_RefreshAccessToken_SessionAnomaly(t *testing.T) {
+	tests := []struct {
+		name      string
+		action    string
+		ip        string
+		userAgent string
+		wantErr   error
+	}{
+		{name: "same country new IP", action: config.SessionAnomalyRevoke, ip: "198.51.100.2", userAgent: desktopAgent},
+		{name: "browser upgrade on the same platform", action: config.SessionAnomalyRevoke, ip: "198.51.100.1",
+			userAgent: "Mozilla/5.0 (Windows NT 10.0; Win64; x64) Gecko/20100101 Firefox/121.0"},
+		{name: "unknown location is not an anomaly", action: config.SessionAnomalyRevoke, ip: "192.0.2.77", userAgent: desktopAgent},
+		{name: "new country is logged only", action: config.SessionAnomalyLog, ip: "203.0.113.9", userAgent: desktopAgent},
+		{name: "new country revokes", action: config.SessionAnomalyRevoke, ip: "203.0.113.9", userAgent: desktopAgent, wantErr: ErrSessionAnomaly},
+		{name: "new client class revokes", action: config.SessionAnomalyRevoke, ip: "198.51.100.1", userAgent: "curl/8.4.0", wantErr: ErrSessionAnomaly},
+		{name: "detection off", action: config.SessionAnomalyOff, ip: "203.0.113.9", userAgent: phoneAgent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, _ := setupServiceTest(t)
+			svc.geo = testGeo
+			svc.anomalyAction = tt.action
+
+			pair, err := svc.GenerateTokenPair(clientCtx("198.51.100.1", desktopAgent), 1, "test@example.com", "Test User")
+			require.NoError(t, err)
+
+			rotated, err := svc.RefreshAccessToken(clientCtx(tt.ip, tt.userAgent), pair.RefreshToken)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				sessions, err := svc.ListSessions(context.Background(), SessionFilter{UserID: 1})
+				require.NoError(t, err)
+				require.Len(t, sessions, 1)
+				assert.NotNil(t, sessions[0].RevokedAt, "the whole family is revoked")
+
+				_, err = svc.RefreshAccessToken(clientCtx("198.51.100.1", desktopAgent), pair.RefreshToken)
+				assert.ErrorIs(t, err, ErrTokenRevoked, "the user must log in again")
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, pair.TokenFamily, rotated.TokenFamily)
+		})
+	}
+}
+
+func TestService_RefreshAccessToken_AnomalyComparesWholeFamily(t *testing.T) {
+	svc, _ := setupServiceTest(t)
+	svc.geo = testGeo
+	svc.anomalyAction = config.SessionAnomalyRevoke
+
+	pair, err := svc.GenerateTokenPair(clientCtx("198.51.100.1", desktopAgent), 1, "test@example.com", "Test User")
+	require.NoError(t, err)
+
+	// The log-only period let the session move to a phone; the phone is now part of its history
+	svc.anomalyAction = config.SessionAnomalyLog
+	fromPhone, err := svc.RefreshAccessToken(clientCtx("198.51.100.2", phoneAgent), pair.RefreshToken)
+	require.NoError(t, err)
+
+	svc.anomalyAction = config.SessionAnomalyRevoke
+	backOnDesktop, err := svc.RefreshAccessToken(clientCtx("198.51.100.1", desktopAgent), fromPhone.RefreshToken)
+	require.NoError(t, err)
+	_, err = svc.RefreshAccessToken(clientCtx("198.51.100.2", phoneAgent), backOnDesktop.RefreshToken)
+	assert.NoError(t, err, "clients the family has been seen on are not anomalies")
+}
+
+func TestUserAgentClass(t *testing.T) {
+	tests := []struct {
+		userAgent string
+		want      string
+	}{
+		{desktopAgent, "mozilla windows"},
+		{phoneAgent, "mozilla iphone"},
+		{"Mozilla/5.0 (X11; Linux x86_64) Gecko/20100101 Firefox/121.0", "mozilla x11"},
+		{"curl/8.4.0", "curl"},
+		{"okhttp/4.12.0", "okhttp"},
+		{"  ", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, userAgentClass(tt.userAgent), tt.userAgent)
+	}
+}
@@ -0,0 +1,85 @@
+// Package auth 提供登录会话（刷新令牌族）的汇总查询
+package auth
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrSessionNotFound is returned when no refresh token belongs to the requested token family
+var ErrSessionNotFound = errors.New("session not found")
+
+// SessionFamily summarizes one login session: all refresh tokens rotated from the same login share a token family
+type SessionFamily struct {
+	TokenFamily uuid.UUID
+	UserID      uint
+	// ClientID is the registered client the session was issued to, empty for unknown clients
+	ClientID string
+	// CreatedAt is when the session logged in (the first token of the family)
+	CreatedAt time.Time
+	// LastUsedAt is the latest rotation, nil when the session never refreshed
+	LastUsedAt *time.Time
+	// ExpiresAt is the expiry of the newest token of the family
+	ExpiresAt time.Time
+	// RevokedAt is set once the family was revoked
+	RevokedAt *time.Time
+	// Active reports whether the family still holds a usable refresh token
+	Active bool
+}
+
+// SessionFilter selects token families; zero values mean no filter
+type SessionFilter struct {
+	UserID     uint
+	ActiveOnly bool
+	Limit      int
+	Offset     int
+}
+
+// summarizeFamilies folds refresh tokens into one SessionFamily per token family, newest login first
+func summarizeFamilies(tokens []*RefreshToken, now time.Time) []SessionFamily {
+	byFamily := make(map[uuid.UUID]*SessionFamily)
+	for _, token := range tokens {
+		family, ok := byFamily[token.TokenFamily]
+		if !ok {
+			family = &SessionFamily{
+				TokenFamily: token.TokenFamily,
+				UserID:      token.UserID,
+				ClientID:    token.ClientID,
+				CreatedAt:   token.CreatedAt,
+				ExpiresAt:   token.ExpiresAt,
+			}
+			byFamily[token.TokenFamily] = family
+		}
+		if token.CreatedAt.Before(family.CreatedAt) {
+			family.CreatedAt = token.CreatedAt
+		}
+		if token.ExpiresAt.After(family.ExpiresAt) {
+			family.ExpiresAt = token.ExpiresAt
+		}
+		family.LastUsedAt = latest(family.LastUsedAt, token.UsedAt)
+		family.RevokedAt = latest(family.RevokedAt, token.RevokedAt)
+		family.Active = family.Active || token.isUsable(now)
+	}
+
+	families := make([]SessionFamily, 0, len(byFamily))
+	for _, family := range byFamily {
+		families = append(families, *family)
+	}
+	sort.Slice(families, func(i, j int) bool {
+		if !families[i].CreatedAt.Equal(families[j].CreatedAt) {
+			return families[i].CreatedAt.After(families[j].CreatedAt)
+		}
+		return families[i].TokenFamily.String() < families[j].TokenFamily.String()
+	})
+	return families
+}
+
+func latest(a, b *time.Time) *time.Time {
+	if b == nil || (a != nil && a.After(*b)) {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,100 @@
+// Package buildinfo 提供当前二进制的构建信息（版本、提交、构建时间）
+//
+// 构建时通过 -ldflags 注入：
+//
+//	go build -ldflags "-X github.com/yeegeek/uyou-go-api-starter/internal/buildinfo.Version=v1.2.0 \
+//	  -X github.com/yeegeek/uyou-go-api-starter/internal/buildinfo.Commit=$(git rev-parse HEAD) \
+//	  -X github.com/yeegeek/uyou-go-api-starter/internal/buildinfo.BuildDate=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+//
+// 未注入时回退到 debug.ReadBuildInfo 中的模块版本和 VCS 信息，仍然缺失的字段显示为 "dev"
+package buildinfo
+
+import (
+	"runtime"
+	"runtime/debug"
+	"sync"
+)
+
+// 通过 -ldflags -X 注入，为空表示未注入
+var (
+	Version   string
+	Commit    string
+	BuildDate string
+)
+
+// Unknown 未注入且无法从构建信息中读取时的取值
+const Unknown = "dev"
+
+// Info 构建信息
+type Info struct {
+	Version   string `json:"version" example:"v1.2.0"`
+	Commit    string `json:"commit" example:"4f2c9e1b7a3d"`
+	BuildDate string `json:"build_date" example:"2026-03-01T08:00:00Z"`
+	GoVersion string `json:"go_version" example:"go1.24.0"`
+}
+
+var (
+	once   sync.Once
+	cached Info
+)
+
+// Get 返回当前二进制的构建信息，结果在首次调用后缓存
+func Get() Info {
+	once.Do(func() {
+		cached = resolve(Version, Commit, BuildDate, debug.ReadBuildInfo)
+	})
+	return cached
+}
+
+// Attrs 返回用于 slog 的键值对，启动日志统一使用
+func (i Info) Attrs() []any {
+	return []any{
+		"version", i.Version,
+		"commit", i.Commit,
+		"build_date", i.BuildDate,
+		"go_version", i.GoVersion,
+	}
+}
+
+// resolve 优先使用注入值，缺失的字段从构建信息中补齐
+func resolve(version, commit, buildDate string, read func() (*debug.BuildInfo, bool)) Info {
+	info := Info{
+		Version:   version,
+		Commit:    commit,
+		BuildDate: buildDate,
+		GoVersion: runtime.Version(),
+	}
+
+	if bi, ok := read(); ok && bi != nil {
+		// go run / 本地构建时主模块版本为 "(devel)"，不作为版本号
+		if info.Version == "" && bi.Main.Version != "" && bi.Main.Version != "(devel)" {
+			info.Version = bi.Main.Version
+		}
+		if bi.GoVersion != "" {
+			info.GoVersion = bi.GoVersion
+		}
+		for _, s := range bi.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				if info.Commit == "" {
+					info.Commit = s.Value
+				}
+			case "vcs.time":
+				if info.BuildDate == "" {
+					info.BuildDate = s.Value
+				}
+			}
+		}
+	}
+
+	if info.Version == "" {
+		info.Version = Unknown
+	}
+	if info.Commit == "" {
+		info.Commit = Unknown
+	}
+	if info.BuildDate == "" {
+		info.BuildDate = Unknown
+	}
+	return info
+}
@@ -0,0 +1,77 @@
+package buildinfo
+
+import (
+	"runtime"
+	"runtime/debug"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolve(t *testing.T) {
+	vcs := func() (*debug.BuildInfo, bool) {
+		return &debug.BuildInfo{
+			GoVersion: "go1.24.1",
+			Main:      debug.Module{Version: "v0.9.0"},
+			Settings: []debug.BuildSetting{
+				{Key: "vcs.revision", Value: "abc123"},
+				{Key: "vcs.time", Value: "2026-02-01T10:00:00Z"},
+			},
+		}, true
+	}
+	devel := func() (*debug.BuildInfo, bool) {
+		return &debug.BuildInfo{GoVersion: "go1.24.1", Main: debug.Module{Version: "(devel)"}}, true
+	}
+	unavailable := func() (*debug.BuildInfo, bool) { return nil, false }
+
+	tests := []struct {
+		name                       string
+		version, commit, buildDate string
+		read                       func() (*debug.BuildInfo, bool)
+		want                       Info
+	}{
+		{
+			name:    "ldflags win over build info",
+			version: "v1.2.0", commit: "def456", buildDate: "2026-03-01T08:00:00Z",
+			read: vcs,
+			want: Info{Version: "v1.2.0", Commit: "def456", BuildDate: "2026-03-01T08:00:00Z", GoVersion: "go1.24.1"},
+		},
+		{
+			name: "falls back to module version and vcs settings",
+			read: vcs,
+			want: Info{Version: "v0.9.0", Commit: "abc123", BuildDate: "2026-02-01T10:00:00Z", GoVersion: "go1.24.1"},
+		},
+		{
+			name: "devel build without vcs shows dev",
+			read: devel,
+			want: Info{Version: Unknown, Commit: Unknown, BuildDate: Unknown, GoVersion: "go1.24.1"},
+		},
+		{
+			name: "no build info",
+			read: unavailable,
+			want: Info{Version: Unknown, Commit: Unknown, BuildDate: Unknown, GoVersion: runtime.Version()},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, resolve(tt.version, tt.commit, tt.buildDate, tt.read))
+		})
+	}
+}
+
+func TestGet_TestBinary(t *testing.T) {
+	info := Get()
+
+	// Test binaries carry no ldflags, so every field must still be filled
+	assert.NotEmpty(t, info.Version)
+	assert.NotEmpty(t, info.Commit)
+	assert.NotEmpty(t, info.BuildDate)
+	assert.NotEmpty(t, info.GoVersion)
+	assert.Equal(t, info, Get())
+}
+
+func TestInfo_Attrs(t *testing.T) {
+	info := Info{Version: "v1", Commit: "c", BuildDate: "d", GoVersion: "go"}
+	assert.Equal(t, []any{"version", "v1", "commit", "c", "build_date", "d", "go_version", "go"}, info.Attrs())
+}
@@ -6,9 +6,11 @@ import (
 	"log/slog"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"time"
 
+	"github.com/go-viper/mapstructure/v2"
 	"github.com/spf13/viper"
 )
 
@@ -28,12 +30,240 @@ type Config struct {
 	Metrics    MetricsConfig    `mapstructure:"metrics" yaml:"metrics"`
 	Scheduler  SchedulerConfig  `mapstructure:"scheduler" yaml:"scheduler"`
 	Security   SecurityConfig   `mapstructure:"security" yaml:"security"`
+	Swagger    SwaggerConfig    `mapstructure:"swagger" yaml:"swagger"`
+	API        APIConfig        `mapstructure:"api" yaml:"api"`
+	FeatureFlags FeatureFlagsConfig `mapstructure:"feature_flags" yaml:"feature_flags"`
+	Mail         MailConfig         `mapstructure:"mail" yaml:"mail"`
+	Pagination   PaginationConfig   `mapstructure:"pagination" yaml:"pagination"`
+	OAuth        OAuthConfig        `mapstructure:"oauth" yaml:"oauth"`
+	HTTPClient   HTTPClientConfig   `mapstructure:"http_client" yaml:"http_client"`
+	Policies     PoliciesConfig     `mapstructure:"policies" yaml:"policies"`
+}
+
+// PoliciesConfig 用户需要接受的协议（服务条款、隐私政策等）
+// 未配置任何协议时注册不要求 accept_terms，也不记录接受情况
+type PoliciesConfig struct {
+	Documents []PolicyDocumentConfig `mapstructure:"documents" yaml:"documents"`
+}
+
+// PolicyDocumentConfig 单个协议当前要求的版本
+type PolicyDocumentConfig struct {
+	// Type 协议类型，如 terms、privacy
+	Type string `mapstructure:"type" yaml:"type"`
+	// Version 当前要求的版本；只比较是否相同，修改后所有用户都需要重新接受
+	Version string `mapstructure:"version" yaml:"version"`
+	// Enforcement 用户未接受当前版本时的处理：flag（默认）只在 /auth/me 中标记，block 拒绝其他已登录请求直到接受
+	Enforcement string `mapstructure:"enforcement" yaml:"enforcement"`
+}
+
+// 协议未接受时的处理方式
+const (
+	PolicyEnforcementFlag  = "flag"
+	PolicyEnforcementBlock = "block"
+)
+
+// Blocks 用户未接受当前版本时是否拒绝请求
+func (d PolicyDocumentConfig) Blocks() bool {
+	return d.Enforcement == PolicyEnforcementBlock
+}
+
+// OAuthConfig 第三方登录配置
+type OAuthConfig struct {
+	Google OAuthProviderConfig `mapstructure:"google" yaml:"google"`
+}
+
+// OAuthProviderConfig 单个 OAuth2/OIDC 提供方的客户端配置
+type OAuthProviderConfig struct {
+	Enabled      bool   `mapstructure:"enabled" yaml:"enabled"`
+	ClientID     string `mapstructure:"client_id" yaml:"client_id"`
+	ClientSecret string `mapstructure:"client_secret" yaml:"client_secret"`
+	// RedirectURL 提供方回调地址，需与提供方控制台登记的一致，如 https://api.example.com/api/v1/auth/oauth/google/callback
+	RedirectURL string `mapstructure:"redirect_url" yaml:"redirect_url"`
+}
+
+// HTTPClientConfig 对外 HTTP 调用（OAuth 提供方等）的客户端配置，未配置的字段使用 httpclient 包中的默认值
+type HTTPClientConfig struct {
+	// Timeout 单次请求（含读取响应体）的总超时，默认 10s
+	Timeout time.Duration `mapstructure:"timeout" yaml:"timeout"`
+	// DialTimeout 建立 TCP 连接的超时，默认 5s
+	DialTimeout time.Duration `mapstructure:"dial_timeout" yaml:"dial_timeout"`
+	// ResponseHeaderTimeout 发送请求后等待响应头的超时，默认 10s
+	ResponseHeaderTimeout time.Duration `mapstructure:"response_header_timeout" yaml:"response_header_timeout"`
+	// MaxIdleConns 空闲连接总数上限，默认 100
+	MaxIdleConns int `mapstructure:"max_idle_conns" yaml:"max_idle_conns"`
+	// MaxIdleConnsPerHost 每个主机的空闲连接上限，默认 10
+	MaxIdleConnsPerHost int `mapstructure:"max_idle_conns_per_host" yaml:"max_idle_conns_per_host"`
+	// TLSMinVersion 最低 TLS 版本：1.2（默认）或 1.3
+	TLSMinVersion string `mapstructure:"tls_min_version" yaml:"tls_min_version"`
+	// MaxRetries 连接错误和 5xx 响应的最大重试次数，0 表示使用默认值 2，负数表示不重试
+	MaxRetries int `mapstructure:"max_retries" yaml:"max_retries"`
+	// RetryBackoff 首次重试的等待时间，之后每次翻倍并加入随机抖动，默认 200ms
+	RetryBackoff time.Duration `mapstructure:"retry_backoff" yaml:"retry_backoff"`
+	// Breaker 按目标主机分别熔断，熔断期间请求直接失败
+	Breaker BreakerConfig `mapstructure:"breaker" yaml:"breaker"`
+}
+
+// PaginationConfig 列表接口分页配置
+type PaginationConfig struct {
+	// DefaultPageSize 请求未携带 per_page 时的每页数量，未配置时为 20
+	DefaultPageSize int `mapstructure:"default_page_size" yaml:"default_page_size"`
+	// MaxPageSize 每页数量上限，未配置时为 100
+	MaxPageSize int `mapstructure:"max_page_size" yaml:"max_page_size"`
+}
+
+// GetDefaultPageSize 返回每页默认数量，未配置时为 20
+func (p PaginationConfig) GetDefaultPageSize() int {
+	if p.DefaultPageSize <= 0 {
+		return 20
+	}
+	return p.DefaultPageSize
+}
+
+// GetMaxPageSize 返回每页数量上限，未配置时为 100
+func (p PaginationConfig) GetMaxPageSize() int {
+	if p.MaxPageSize <= 0 {
+		return 100
+	}
+	return p.MaxPageSize
+}
+
+// MailConfig 邮件发送配置
+// 未启用时邮件只写入日志，开发环境下注册、验证等流程仍可正常完成
+type MailConfig struct {
+	Enabled  bool   `mapstructure:"enabled" yaml:"enabled"`
+	Host     string `mapstructure:"host" yaml:"host"`
+	Port     int    `mapstructure:"port" yaml:"port"`
+	Username string `mapstructure:"username" yaml:"username"`
+	Password string `mapstructure:"password" yaml:"password"`
+	// From 发件人地址，如 "UYou <no-reply@example.com>"
+	From string `mapstructure:"from" yaml:"from"`
+	// TLSMode 连接加密方式：none、starttls（默认，587 端口）或 tls（隐式 TLS，465 端口）
+	TLSMode string `mapstructure:"tls_mode" yaml:"tls_mode"`
+	// Timeout 单次 SMTP 连接和发送的超时时间
+	Timeout time.Duration `mapstructure:"timeout" yaml:"timeout"`
+	// Workers 发送协程数量
+	Workers int `mapstructure:"workers" yaml:"workers"`
+	// QueueSize 待发送队列容量，队列已满时发送请求直接失败而不阻塞调用方
+	QueueSize int `mapstructure:"queue_size" yaml:"queue_size"`
+	// MaxRetries 临时性失败（如 4xx 响应、网络错误）的最大重试次数
+	MaxRetries int `mapstructure:"max_retries" yaml:"max_retries"`
+	// RetryBackoff 首次重试的等待时间，之后每次翻倍
+	RetryBackoff time.Duration `mapstructure:"retry_backoff" yaml:"retry_backoff"`
+	// Breaker SMTP 临时性失败后熔断，熔断期间发送直接失败并进入重试队列，不再等待连接超时
+	Breaker BreakerConfig `mapstructure:"breaker" yaml:"breaker"`
+}
+
+// 邮件 TLS 模式
+const (
+	MailTLSNone     = "none"
+	MailTLSStartTLS = "starttls"
+	MailTLSImplicit = "tls"
+)
+
+// FeatureFlagsConfig 功能开关配置
+type FeatureFlagsConfig struct {
+	// CacheTTL 开关定义的缓存时间，管理接口的修改最迟在该时间后对所有实例生效
+	CacheTTL time.Duration `mapstructure:"cache_ttl" yaml:"cache_ttl"`
+	// Flags 配置文件中定义的开关，数据库中的同名开关优先
+	Flags []FeatureFlagConfig `mapstructure:"flags" yaml:"flags"`
+}
+
+// FeatureFlagConfig 单个功能开关定义
+type FeatureFlagConfig struct {
+	Name        string `mapstructure:"name" yaml:"name"`
+	Description string `mapstructure:"description" yaml:"description"`
+	// Enabled 默认开关状态，匿名用户和未命中灰度的用户使用该值
+	Enabled bool `mapstructure:"enabled" yaml:"enabled"`
+	// Percentage 灰度比例（0-100），按用户 ID 和开关名称哈希分桶
+	Percentage int `mapstructure:"percentage" yaml:"percentage"`
+	// AllowUsers 始终开启的用户 ID
+	AllowUsers []uint `mapstructure:"allow_users" yaml:"allow_users"`
+	// DenyUsers 始终关闭的用户 ID，优先于 AllowUsers
+	DenyUsers []uint `mapstructure:"deny_users" yaml:"deny_users"`
+}
+
+// APIConfig API 路由前缀和版本配置
+type APIConfig struct {
+	// BasePath 所有版本路由的公共前缀，版本组挂载在 {base_path}/v1、{base_path}/v2 下
+	BasePath string `mapstructure:"base_path" yaml:"base_path"`
+	// V1DeprecatedAt v1 接口的弃用时间（RFC3339），设置后 v1 响应携带 Deprecation 头
+	V1DeprecatedAt string `mapstructure:"v1_deprecated_at" yaml:"v1_deprecated_at"`
+	// V1SunsetAt v1 接口的下线时间（RFC3339），设置后 v1 响应携带 Sunset 头
+	V1SunsetAt string `mapstructure:"v1_sunset_at" yaml:"v1_sunset_at"`
+}
+
+// GetBasePath 返回规范化的路由前缀：以 "/" 开头、不以 "/" 结尾，未配置时为 "/api"
+// 配置为 "/" 时返回空字符串，版本组直接挂载在根路径下
+func (a APIConfig) GetBasePath() string {
+	p := strings.TrimSpace(a.BasePath)
+	if p == "" {
+		return "/api"
+	}
+	if !strings.HasPrefix(p, "/") {
+		p = "/" + p
+	}
+	return strings.TrimRight(p, "/")
+}
+
+// V1Deprecation 解析 v1 的弃用和下线时间，未配置的字段返回零值
+func (a APIConfig) V1Deprecation() (deprecatedAt, sunsetAt time.Time, err error) {
+	if a.V1DeprecatedAt != "" {
+		if deprecatedAt, err = time.Parse(time.RFC3339, a.V1DeprecatedAt); err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("api.v1_deprecated_at must be RFC3339: %w", err)
+		}
+	}
+	if a.V1SunsetAt != "" {
+		if sunsetAt, err = time.Parse(time.RFC3339, a.V1SunsetAt); err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("api.v1_sunset_at must be RFC3339: %w", err)
+		}
+	}
+	return deprecatedAt, sunsetAt, nil
+}
+
+// SwaggerConfig API 文档配置
+type SwaggerConfig struct {
+	// UIEnabled 是否提供 Swagger UI（关闭后 JSON 规范仍然可用，供内部工具使用）
+	UIEnabled bool `mapstructure:"ui_enabled" yaml:"ui_enabled"`
+	// Host 文档中的服务地址，留空时使用请求的 Host
+	Host string `mapstructure:"host" yaml:"host"`
+	// Schemes 文档中的协议列表，留空时根据请求推断
+	Schemes []string `mapstructure:"schemes" yaml:"schemes"`
 }
 
 // SchedulerConfig 定时任务配置
 type SchedulerConfig struct {
 	Enabled  bool   `mapstructure:"enabled" yaml:"enabled"`
 	Timezone string `mapstructure:"timezone" yaml:"timezone"`
+	// HealthPort 调度器进程健康检查服务（/health/live、/health/ready）监听的端口，默认 9092
+	HealthPort string `mapstructure:"health_port" yaml:"health_port"`
+	// CriticalFailureThreshold 关键任务连续失败达到该次数后就绪探针返回 503，默认 3
+	CriticalFailureThreshold int `mapstructure:"critical_failure_threshold" yaml:"critical_failure_threshold"`
+	// DrainTimeout 停止时等待正在执行的任务完成的最长时间，超时后取消任务的 context，默认 30s
+	DrainTimeout time.Duration `mapstructure:"drain_timeout" yaml:"drain_timeout"`
+}
+
+// GetDrainTimeout 返回停止时等待任务完成的时间，未配置时为 30s
+func (s SchedulerConfig) GetDrainTimeout() time.Duration {
+	if s.DrainTimeout <= 0 {
+		return 30 * time.Second
+	}
+	return s.DrainTimeout
+}
+
+// GetHealthPort 返回调度器健康检查端口，未配置时为 9092
+func (s SchedulerConfig) GetHealthPort() string {
+	if s.HealthPort == "" {
+		return "9092"
+	}
+	return s.HealthPort
+}
+
+// GetCriticalFailureThreshold 返回关键任务连续失败阈值，未配置时为 3
+func (s SchedulerConfig) GetCriticalFailureThreshold() int {
+	if s.CriticalFailureThreshold <= 0 {
+		return 3
+	}
+	return s.CriticalFailureThreshold
 }
 
 type AppConfig struct {
@@ -41,6 +271,8 @@ type AppConfig struct {
 	Version     string `mapstructure:"version" yaml:"version"`
 	Environment string `mapstructure:"environment" yaml:"environment"`
 	Debug       bool   `mapstructure:"debug" yaml:"debug"`
+	// WatchConfig 监听配置文件变化并热加载可实时生效的配置，见 Store
+	WatchConfig bool `mapstructure:"watch_config" yaml:"watch_config"`
 }
 
 type DatabaseConfig struct {
@@ -54,13 +286,203 @@ type DatabaseConfig struct {
 	MaxIdleConns    int    `mapstructure:"max_idle_conns" yaml:"max_idle_conns"`
 	ConnMaxLifetime int    `mapstructure:"conn_max_lifetime" yaml:"conn_max_lifetime"` // 秒
 	ConnMaxIdleTime int    `mapstructure:"conn_max_idle_time" yaml:"conn_max_idle_time"` // 秒
+	// ConnectMaxAttempts 启动时连接数据库的最大尝试次数，与 ConnectRetryTimeout 均为 0 时只尝试一次
+	ConnectMaxAttempts int `mapstructure:"connect_max_attempts" yaml:"connect_max_attempts"`
+	// ConnectRetryTimeout 启动时重试连接的总时长上限（秒）
+	ConnectRetryTimeout int `mapstructure:"connect_retry_timeout" yaml:"connect_retry_timeout"`
+	// Replicas 只读副本；配置后事务外的查询路由到健康的副本，副本 ping 失败时读请求回退到主库，恢复后自动切回
+	Replicas []DatabaseReplicaConfig `mapstructure:"replicas" yaml:"replicas"`
+	// ReplicaHealthInterval 副本健康检查间隔，默认 10s
+	ReplicaHealthInterval time.Duration `mapstructure:"replica_health_interval" yaml:"replica_health_interval"`
+}
+
+// DatabaseReplicaConfig 只读副本的连接信息，未填写的字段使用主库的配置（库名、sslmode 和连接池参数始终与主库一致）
+type DatabaseReplicaConfig struct {
+	// Name 副本名称，用于日志、指标和健康检查，默认 replica-<序号>
+	Name     string `mapstructure:"name" yaml:"name"`
+	Host     string `mapstructure:"host" yaml:"host"`
+	Port     int    `mapstructure:"port" yaml:"port"`
+	User     string `mapstructure:"user" yaml:"user"`
+	Password string `mapstructure:"password" yaml:"password"`
+}
+
+// GetReplicaHealthInterval 返回副本健康检查间隔，未配置时为 10s
+func (d DatabaseConfig) GetReplicaHealthInterval() time.Duration {
+	if d.ReplicaHealthInterval <= 0 {
+		return 10 * time.Second
+	}
+	return d.ReplicaHealthInterval
+}
+
+// ReplicaName 返回第 i 个副本的名称，未配置时为 replica-<i+1>
+func (d DatabaseConfig) ReplicaName(i int) string {
+	if name := strings.TrimSpace(d.Replicas[i].Name); name != "" {
+		return name
+	}
+	return fmt.Sprintf("replica-%d", i+1)
+}
+
+// ReplicaDatabaseConfig 返回连接第 i 个副本所用的完整配置，未填写的字段继承主库
+func (d DatabaseConfig) ReplicaDatabaseConfig(i int) DatabaseConfig {
+	r := d.Replicas[i]
+	cfg := d
+	cfg.Replicas = nil
+	cfg.Host = r.Host
+	if r.Port != 0 {
+		cfg.Port = r.Port
+	}
+	if r.User != "" {
+		cfg.User = r.User
+	}
+	if r.Password != "" {
+		cfg.Password = r.Password
+	}
+	return cfg
 }
 
 type JWTConfig struct {
 	Secret          string        `mapstructure:"secret" yaml:"secret"`
 	AccessTokenTTL  time.Duration `mapstructure:"access_token_ttl" yaml:"access_token_ttl"`
 	RefreshTokenTTL time.Duration `mapstructure:"refresh_token_ttl" yaml:"refresh_token_ttl"`
+	// RememberMeRefreshTokenTTL 登录时勾选"记住我"的刷新令牌有效期，默认 30 天
+	RememberMeRefreshTokenTTL time.Duration `mapstructure:"remember_me_refresh_token_ttl" yaml:"remember_me_refresh_token_ttl"`
 	TTLHours        int           `mapstructure:"ttlhours" yaml:"ttlhours"` // Deprecated: kept for backward compatibility
+	// EnforceTokenVersion 启用后访问令牌携带 token_version 声明，角色变更后旧令牌将被拒绝（每个请求增加一次查询）
+	EnforceTokenVersion bool `mapstructure:"enforce_token_version" yaml:"enforce_token_version"`
+	// TokenVersionCacheTTL token_version 查询结果的内存缓存时间
+	TokenVersionCacheTTL time.Duration `mapstructure:"token_version_cache_ttl" yaml:"token_version_cache_ttl"`
+	// RoleCacheTTL 签发令牌时角色与权限查询结果的内存缓存时间，默认 60s；角色变更时会主动失效
+	RoleCacheTTL time.Duration `mapstructure:"role_cache_ttl" yaml:"role_cache_ttl"`
+	// RoleCacheSize 角色缓存最多保存的用户数，默认 10000
+	RoleCacheSize int `mapstructure:"role_cache_size" yaml:"role_cache_size"`
+	// AutoRenewEnabled 启用后，临近过期的访问令牌会在请求成功时通过 X-New-Access-Token 响应头返回新令牌（不轮换刷新令牌）。
+	// 续期时重新读取用户状态和角色，已停用、已删除或没有有效会话的用户不会续期
+	AutoRenewEnabled bool `mapstructure:"auto_renew_enabled" yaml:"auto_renew_enabled"`
+	// AutoRenewWindow 距离过期多久以内触发自动续期
+	AutoRenewWindow time.Duration `mapstructure:"auto_renew_window" yaml:"auto_renew_window"`
+	// ImpersonationTTL 管理员模拟登录令牌的有效期
+	ImpersonationTTL time.Duration `mapstructure:"impersonation_ttl" yaml:"impersonation_ttl"`
+	// ClockSkew 校验访问令牌 exp/nbf 时容忍的时钟偏差，多台机器时钟不完全同步时避免令牌提前失效，默认 0
+	ClockSkew time.Duration `mapstructure:"clock_skew" yaml:"clock_skew"`
+	// AllowAdminImpersonation 是否允许模拟其他管理员
+	AllowAdminImpersonation bool `mapstructure:"allow_admin_impersonation" yaml:"allow_admin_impersonation"`
+	// RefreshMaxFailures 同一令牌族的刷新失败达到该次数后吊销整个令牌族，默认 5
+	RefreshMaxFailures int `mapstructure:"refresh_max_failures" yaml:"refresh_max_failures"`
+	// RefreshCookie 浏览器客户端的刷新令牌 Cookie 模式
+	RefreshCookie RefreshCookieConfig `mapstructure:"refresh_cookie" yaml:"refresh_cookie"`
+	// AccessCookie 浏览器客户端的访问令牌 Cookie，需同时启用 RefreshCookie
+	AccessCookie AccessCookieConfig `mapstructure:"access_cookie" yaml:"access_cookie"`
+	// RefreshStore 刷新令牌存储：database（默认）或 memory（仅用于本地开发和测试，重启后令牌全部失效，生产环境禁止）
+	RefreshStore string `mapstructure:"refresh_store" yaml:"refresh_store"`
+	// Clients 登记的客户端（如 web、mobile），请求通过 X-Client-Id 头或请求体 client_id 指定，未登记或未指定时使用上面的全局有效期
+	Clients []ClientConfig `mapstructure:"clients" yaml:"clients"`
+	// MaxClientAccessTokenTTL 客户端访问令牌有效期上限，默认 24 小时
+	MaxClientAccessTokenTTL time.Duration `mapstructure:"max_client_access_token_ttl" yaml:"max_client_access_token_ttl"`
+	// MaxClientRefreshTokenTTL 客户端刷新令牌有效期上限，默认 90 天
+	MaxClientRefreshTokenTTL time.Duration `mapstructure:"max_client_refresh_token_ttl" yaml:"max_client_refresh_token_ttl"`
+}
+
+// maxJWTClockSkew 时钟偏差容忍上限
+const maxJWTClockSkew = 5 * time.Minute
+
+// 客户端可使用的签发方式
+const (
+	ClientGrantPassword = "password"
+	ClientGrantRegister = "register"
+	ClientGrantRefresh  = "refresh"
+	ClientGrantOAuth    = "oauth"
+)
+
+// ClientConfig 单个客户端的令牌策略
+type ClientConfig struct {
+	// ID 客户端标识，即 X-Client-Id 的取值
+	ID   string `mapstructure:"id" yaml:"id"`
+	Name string `mapstructure:"name" yaml:"name"`
+	// AccessTokenTTL / RefreshTokenTTL 为 0 时使用全局有效期；"记住我"仍使用 remember_me_refresh_token_ttl
+	AccessTokenTTL  time.Duration `mapstructure:"access_token_ttl" yaml:"access_token_ttl"`
+	RefreshTokenTTL time.Duration `mapstructure:"refresh_token_ttl" yaml:"refresh_token_ttl"`
+	// Grants 允许的签发方式（password、register、refresh、oauth），为空时全部允许
+	Grants []string `mapstructure:"grants" yaml:"grants"`
+}
+
+// AllowsGrant 判断客户端是否允许以 grant 方式签发令牌
+func (c ClientConfig) AllowsGrant(grant string) bool {
+	return len(c.Grants) == 0 || slices.Contains(c.Grants, grant)
+}
+
+// FindClient 返回 id 对应的已登记客户端
+func (j JWTConfig) FindClient(id string) (ClientConfig, bool) {
+	if id == "" {
+		return ClientConfig{}, false
+	}
+	for _, client := range j.Clients {
+		if client.ID == id {
+			return client, true
+		}
+	}
+	return ClientConfig{}, false
+}
+
+// GetAccessTokenTTL 返回访问令牌有效期：优先 access_token_ttl，其次已废弃的 ttlhours，都未配置时为 15 分钟
+func (j JWTConfig) GetAccessTokenTTL() time.Duration {
+	if j.AccessTokenTTL != 0 {
+		return j.AccessTokenTTL
+	}
+	if j.TTLHours > 0 {
+		return time.Duration(j.TTLHours) * time.Hour
+	}
+	return 15 * time.Minute
+}
+
+// GetMaxClientAccessTokenTTL 返回客户端访问令牌有效期上限，未配置时为 24 小时
+func (j JWTConfig) GetMaxClientAccessTokenTTL() time.Duration {
+	if j.MaxClientAccessTokenTTL <= 0 {
+		return 24 * time.Hour
+	}
+	return j.MaxClientAccessTokenTTL
+}
+
+// GetMaxClientRefreshTokenTTL 返回客户端刷新令牌有效期上限，未配置时为 90 天
+func (j JWTConfig) GetMaxClientRefreshTokenTTL() time.Duration {
+	if j.MaxClientRefreshTokenTTL <= 0 {
+		return 90 * 24 * time.Hour
+	}
+	return j.MaxClientRefreshTokenTTL
+}
+
+// 全局限流算法
+const (
+	RateLimitStrategyTokenBucket = "token_bucket"
+	RateLimitStrategyFixedWindow = "fixed_window"
+)
+
+// 刷新令牌存储类型
+const (
+	RefreshStoreDatabase = "database"
+	RefreshStoreMemory   = "memory"
+)
+
+// RefreshCookieConfig 刷新令牌 Cookie 配置
+// 启用后登录、注册和刷新接口通过 HttpOnly; Secure; SameSite=Strict Cookie 下发刷新令牌，不再写入响应体
+type RefreshCookieConfig struct {
+	Enabled bool `mapstructure:"enabled" yaml:"enabled"`
+	// Name 刷新令牌 Cookie 名称，默认 refresh_token
+	Name string `mapstructure:"name" yaml:"name"`
+	// Domain Cookie 作用域名，留空时仅限当前主机
+	Domain string `mapstructure:"domain" yaml:"domain"`
+	// Path Cookie 作用路径，默认 "/"，可收窄为 /api/v1/auth
+	Path string `mapstructure:"path" yaml:"path"`
+	// CSRFCookieName 双重提交 CSRF Cookie 名称，默认 csrf_token
+	CSRFCookieName string `mapstructure:"csrf_cookie_name" yaml:"csrf_cookie_name"`
+}
+
+// AccessCookieConfig 访问令牌 Cookie 配置
+// 启用后通过 Cookie 下发刷新令牌的请求同时下发 HttpOnly 访问令牌 Cookie（与刷新令牌 Cookie 共用域名），
+// 请求未携带 Authorization 头时认证中间件从该 Cookie 读取令牌；通过 Cookie 认证的写请求需在 X-CSRF-Token 中回传 CSRF Cookie
+type AccessCookieConfig struct {
+	Enabled bool `mapstructure:"enabled" yaml:"enabled"`
+	// Name 访问令牌 Cookie 名称，默认 access_token
+	Name string `mapstructure:"name" yaml:"name"`
 }
 
 type ServerConfig struct {
@@ -70,6 +492,51 @@ type ServerConfig struct {
 	IdleTimeout     int    `mapstructure:"idletimeout" yaml:"idletimeout"`
 	ShutdownTimeout int    `mapstructure:"shutdowntimeout" yaml:"shutdowntimeout"`
 	MaxHeaderBytes  int    `mapstructure:"maxheaderbytes" yaml:"maxheaderbytes"`
+	// TrustedProxies 可信反向代理的 CIDR 或 IP，只有来自这些地址的请求才采信 Forwarded/X-Forwarded-For/X-Real-IP
+	// 留空表示不信任任何代理，客户端 IP 取连接的对端地址
+	TrustedProxies []string `mapstructure:"trusted_proxies" yaml:"trusted_proxies"`
+	// Compression 响应压缩配置
+	Compression CompressionConfig `mapstructure:"compression" yaml:"compression"`
+	// ETagEnabled 为 GET /users/:id 和 /auth/me 生成 ETag，支持 If-None-Match 条件请求返回 304
+	ETagEnabled bool `mapstructure:"etag_enabled" yaml:"etag_enabled"`
+	// MaxInFlight API 路由同时处理的请求数上限，超出的请求排队等待，0 表示不限制
+	// 健康检查、指标和管理员接口不受限制
+	MaxInFlight int `mapstructure:"max_in_flight" yaml:"max_in_flight"`
+	// MaxQueueWait 请求排队等待空闲名额的最长时间，超时返回 503 和 Retry-After，0 表示不排队直接拒绝
+	MaxQueueWait time.Duration `mapstructure:"max_queue_wait" yaml:"max_queue_wait"`
+}
+
+// 响应压缩默认值
+const (
+	defaultCompressionMinSize = 1024
+)
+
+// defaultCompressionContentTypes 未配置 content_types 时允许压缩的媒体类型
+var defaultCompressionContentTypes = []string{"application/json", "text/csv", "text/plain"}
+
+// CompressionConfig 响应 gzip 压缩配置
+type CompressionConfig struct {
+	Enabled bool `mapstructure:"enabled" yaml:"enabled"`
+	// MinSize 响应体达到该字节数才压缩，默认 1024
+	MinSize int `mapstructure:"min_size" yaml:"min_size"`
+	// ContentTypes 允许压缩的媒体类型，默认 application/json、text/csv、text/plain
+	ContentTypes []string `mapstructure:"content_types" yaml:"content_types"`
+}
+
+// GetMinSize 返回压缩阈值，未配置时为 1024 字节
+func (c CompressionConfig) GetMinSize() int {
+	if c.MinSize <= 0 {
+		return defaultCompressionMinSize
+	}
+	return c.MinSize
+}
+
+// GetContentTypes 返回允许压缩的媒体类型，未配置时使用默认列表
+func (c CompressionConfig) GetContentTypes() []string {
+	if len(c.ContentTypes) == 0 {
+		return defaultCompressionContentTypes
+	}
+	return c.ContentTypes
 }
 
 type LoggingConfig struct {
@@ -77,23 +544,111 @@ type LoggingConfig struct {
 	Format string `mapstructure:"format" yaml:"format"` // json, text
 	Output string `mapstructure:"output" yaml:"output"` // stdout, file
 	File   string `mapstructure:"file" yaml:"file"`     // 日志文件路径
+	// LogBodies 以 debug 级别记录请求和响应体（敏感字段脱敏），仅用于排查问题，生产环境禁止开启
+	LogBodies bool `mapstructure:"log_bodies" yaml:"log_bodies"`
+	// BodyMaxBytes 每个请求或响应体最多记录的字节数，默认 4096
+	BodyMaxBytes int `mapstructure:"body_max_bytes" yaml:"body_max_bytes"`
+}
+
+// GetBodyMaxBytes 返回请求/响应体日志的截断长度，未配置时为 4096 字节
+func (l LoggingConfig) GetBodyMaxBytes() int {
+	if l.BodyMaxBytes <= 0 {
+		return 4096
+	}
+	return l.BodyMaxBytes
 }
 
 type RateLimitConfig struct {
 	Enabled  bool          `mapstructure:"enabled" yaml:"enabled"`
 	Requests int           `mapstructure:"requests" yaml:"requests"`
 	Window   time.Duration `mapstructure:"window" yaml:"window"`
+	// Strategy 全局限流算法：token_bucket（默认，按 Requests/Window 匀速补充，允许 Burst 的瞬时突发）
+	// 或 fixed_window（每个窗口最多 Requests 次，超出后直到窗口结束前全部拒绝）
+	Strategy string `mapstructure:"strategy" yaml:"strategy"`
+	// Burst 令牌桶容量，即允许的瞬时突发请求数，0 表示与 Requests 相同；fixed_window 模式忽略
+	Burst int `mapstructure:"burst" yaml:"burst"`
+	// RefreshRequests 刷新接口每个 IP 加令牌族在 RefreshWindow 内允许的请求数，默认 5（不受 Enabled 影响）
+	RefreshRequests int `mapstructure:"refresh_requests" yaml:"refresh_requests"`
+	// RefreshIPRequests 刷新接口每个 IP 在 RefreshWindow 内允许的请求数，默认 30
+	RefreshIPRequests int `mapstructure:"refresh_ip_requests" yaml:"refresh_ip_requests"`
+	// RefreshWindow 刷新接口限流窗口，默认 1 分钟
+	RefreshWindow time.Duration `mapstructure:"refresh_window" yaml:"refresh_window"`
+	// AdminRequests 管理员接口每个管理员在 AdminWindow 内允许的请求数，默认 30（随 Enabled 启停）
+	AdminRequests int `mapstructure:"admin_requests" yaml:"admin_requests"`
+	// AdminWindow 管理员接口限流窗口，默认 1 分钟
+	AdminWindow time.Duration `mapstructure:"admin_window" yaml:"admin_window"`
+	// SearchRequests 用户搜索接口每个用户在 SearchWindow 内允许的请求数，默认 10（不受 Enabled 影响）
+	SearchRequests int `mapstructure:"search_requests" yaml:"search_requests"`
+	// SearchWindow 用户搜索接口限流窗口，默认 1 分钟
+	SearchWindow time.Duration `mapstructure:"search_window" yaml:"search_window"`
+}
+
+// RefreshLimits 返回刷新接口的限流参数，未配置的字段使用默认值
+func (r RateLimitConfig) RefreshLimits() (perFamily, perIP int, window time.Duration) {
+	perFamily, perIP, window = r.RefreshRequests, r.RefreshIPRequests, r.RefreshWindow
+	if perFamily <= 0 {
+		perFamily = 5
+	}
+	if perIP <= 0 {
+		perIP = 30
+	}
+	if window <= 0 {
+		window = time.Minute
+	}
+	return perFamily, perIP, window
+}
+
+// AdminLimits 返回管理员接口的限流参数，未配置的字段使用默认值
+func (r RateLimitConfig) AdminLimits() (requests int, window time.Duration) {
+	requests, window = r.AdminRequests, r.AdminWindow
+	if requests <= 0 {
+		requests = 30
+	}
+	if window <= 0 {
+		window = time.Minute
+	}
+	return requests, window
+}
+
+// SearchLimits 返回用户搜索接口的限流参数，未配置的字段使用默认值
+func (r RateLimitConfig) SearchLimits() (requests int, window time.Duration) {
+	requests, window = r.SearchRequests, r.SearchWindow
+	if requests <= 0 {
+		requests = 10
+	}
+	if window <= 0 {
+		window = time.Minute
+	}
+	return requests, window
 }
 
 type MigrationsConfig struct {
 	Directory   string `mapstructure:"directory" yaml:"directory"`
 	Timeout     int    `mapstructure:"timeout" yaml:"timeout"`
 	LockTimeout int    `mapstructure:"locktimeout" yaml:"locktimeout"`
+	// DataBatchSize 数据迁移（data-migrate）每批处理的行数，每批在独立事务中提交
+	DataBatchSize int `mapstructure:"data_batch_size" yaml:"data_batch_size"`
+}
+
+// defaultDataBatchSize 数据迁移默认批大小
+const defaultDataBatchSize = 1000
+
+// GetDataBatchSize 返回数据迁移批大小，未配置时为 1000
+func (c MigrationsConfig) GetDataBatchSize() int {
+	if c.DataBatchSize <= 0 {
+		return defaultDataBatchSize
+	}
+	return c.DataBatchSize
 }
 
 type HealthConfig struct {
+	// Timeout 就绪探针中单个依赖检查的超时时间（秒），超时的检查判定为失败
 	Timeout              int  `mapstructure:"timeout" yaml:"timeout"`
 	DatabaseCheckEnabled bool `mapstructure:"database_check_enabled" yaml:"database_check_enabled"`
+	// MigrationCheckEnabled 就绪探针中报告当前迁移版本和 dirty 状态
+	MigrationCheckEnabled bool `mapstructure:"migration_check_enabled" yaml:"migration_check_enabled"`
+	// FailOnDirtySchema 迁移处于 dirty 状态时就绪探针返回 503；为 false 时只标记为 degraded
+	FailOnDirtySchema bool `mapstructure:"fail_on_dirty_schema" yaml:"fail_on_dirty_schema"`
 }
 
 // MongoDBConfig MongoDB 数据库配置
@@ -118,6 +673,20 @@ type RedisConfig struct {
 	WriteTimeout int    `mapstructure:"write_timeout" yaml:"write_timeout"`
 	PoolSize     int    `mapstructure:"pool_size" yaml:"pool_size"`
 	MinIdleConns int    `mapstructure:"min_idle_conns" yaml:"min_idle_conns"`
+	// Breaker 缓存连续失败后熔断，熔断期间缓存读写短路为未命中/空操作，请求回落到数据库
+	Breaker BreakerConfig `mapstructure:"breaker" yaml:"breaker"`
+}
+
+// BreakerConfig 外部依赖熔断配置
+type BreakerConfig struct {
+	// FailureThreshold 连续失败多少次后熔断，默认 5
+	FailureThreshold int `mapstructure:"failure_threshold" yaml:"failure_threshold"`
+	// Cooldown 熔断持续时间，结束后放行一次试探调用，默认 30s
+	Cooldown time.Duration `mapstructure:"cooldown" yaml:"cooldown"`
+	// FailureRate 最近 WindowSize 次调用的失败占比达到该值时熔断（0~1），0 表示只按连续失败次数熔断
+	FailureRate float64 `mapstructure:"failure_rate" yaml:"failure_rate"`
+	// WindowSize 失败率统计的调用次数，默认 20
+	WindowSize int `mapstructure:"window_size" yaml:"window_size"`
 }
 
 // RabbitMQConfig RabbitMQ 消息队列配置
@@ -131,6 +700,8 @@ type RabbitMQConfig struct {
 	Queue         string `mapstructure:"queue" yaml:"queue"`
 	RoutingKey    string `mapstructure:"routing_key" yaml:"routing_key"`
 	PrefetchCount int    `mapstructure:"prefetch_count" yaml:"prefetch_count"`
+	// Breaker 发布连续失败后熔断，熔断期间事件发布短路为空操作
+	Breaker BreakerConfig `mapstructure:"breaker" yaml:"breaker"`
 }
 
 // GRPCConfig gRPC 服务配置
@@ -149,6 +720,14 @@ type MetricsConfig struct {
 	Path    string `mapstructure:"path" yaml:"path"`
 }
 
+// GetPath 返回指标路径，未配置时为 /metrics
+func (m MetricsConfig) GetPath() string {
+	if m.Path == "" {
+		return "/metrics"
+	}
+	return m.Path
+}
+
 // SecurityConfig 安全配置
 type SecurityConfig struct {
 	// Bcrypt 成本因子（推荐 10-14）
@@ -169,11 +748,102 @@ type SecurityConfig struct {
 	LockoutDuration int `mapstructure:"lockout_duration" yaml:"lockout_duration"`
 	// 启用安全响应头
 	EnableSecurityHeaders bool `mapstructure:"enable_security_headers" yaml:"enable_security_headers"`
+	// 刷新令牌的客户端与会话历史明显不符（国家或 User-Agent 不同）时的处理：off、log（默认）、revoke
+	SessionAnomalyAction string `mapstructure:"session_anomaly_action" yaml:"session_anomaly_action"`
+	// AdminIPAllowlist 允许访问管理员接口的 CIDR 或 IP，留空不限制；development 环境不生效
+	AdminIPAllowlist []string `mapstructure:"admin_ip_allowlist" yaml:"admin_ip_allowlist"`
+	// LoginHistoryRetentionDays 登录历史保留天数，由清理任务删除更早的记录，默认 90
+	LoginHistoryRetentionDays int `mapstructure:"login_history_retention_days" yaml:"login_history_retention_days"`
+	// AllowedEmailDomains 允许注册的邮箱域名（含子域名，不区分大小写），留空时只按 BlockedEmailDomains 限制
+	AllowedEmailDomains []string `mapstructure:"allowed_email_domains" yaml:"allowed_email_domains"`
+	// BlockedEmailDomains 禁止注册的邮箱域名（含子域名，不区分大小写），如一次性邮箱服务
+	BlockedEmailDomains []string `mapstructure:"blocked_email_domains" yaml:"blocked_email_domains"`
+	// EmailChangeVerification 修改邮箱时先保存为待验证邮箱并发送验证令牌，确认前登录仍使用原邮箱；关闭时直接生效
+	EmailChangeVerification bool `mapstructure:"email_change_verification" yaml:"email_change_verification"`
+	// EmailChangeTokenTTL 邮箱变更验证令牌有效期，默认 24 小时
+	EmailChangeTokenTTL time.Duration `mapstructure:"email_change_token_ttl" yaml:"email_change_token_ttl"`
+	// PasswordHistoryDepth 修改或重置密码时不能与最近 N 个密码（含当前密码）相同，0 表示不限制
+	PasswordHistoryDepth int `mapstructure:"password_history_depth" yaml:"password_history_depth"`
+}
+
+// 会话异常处理方式
+const (
+	// SessionAnomalyOff 不检测
+	SessionAnomalyOff = "off"
+	// SessionAnomalyLog 只记录安全事件，刷新照常完成
+	SessionAnomalyLog = "log"
+	// SessionAnomalyRevoke 记录安全事件并撤销整个会话，用户需重新登录
+	SessionAnomalyRevoke = "revoke"
+)
+
+// GetLoginHistoryRetention 返回登录历史保留时长，未配置时为 90 天
+func (s SecurityConfig) GetLoginHistoryRetention() time.Duration {
+	days := s.LoginHistoryRetentionDays
+	if days <= 0 {
+		days = 90
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// AdminIPAllowlistFor 返回 environment 下生效的管理员 IP 白名单，development 环境始终返回 nil（不限制）
+func (s SecurityConfig) AdminIPAllowlistFor(environment string) []string {
+	if environment == "development" {
+		return nil
+	}
+	return s.AdminIPAllowlist
+}
+
+// GetSessionAnomalyAction 返回会话异常处理方式，未配置时为 SessionAnomalyLog
+func (s SecurityConfig) GetSessionAnomalyAction() string {
+	if s.SessionAnomalyAction == "" {
+		return SessionAnomalyLog
+	}
+	return s.SessionAnomalyAction
+}
+
+// GetEmailChangeTokenTTL 返回邮箱变更验证令牌有效期，未配置时为 24 小时
+func (s SecurityConfig) GetEmailChangeTokenTTL() time.Duration {
+	if s.EmailChangeTokenTTL <= 0 {
+		return 24 * time.Hour
+	}
+	return s.EmailChangeTokenTTL
+}
+
+// DefaultBcryptCost 未配置 security.bcrypt_cost 时使用的成本因子
+const DefaultBcryptCost = 12
+
+// GetBcryptCost 返回 bcrypt 成本因子，未配置时为 DefaultBcryptCost
+func (s SecurityConfig) GetBcryptCost() int {
+	if s.BcryptCost <= 0 {
+		return DefaultBcryptCost
+	}
+	return s.BcryptCost
 }
 
 // LoadConfig loads configuration using Viper. If configPath is non-empty it
 // will be used as the exact config file path, otherwise Viper searches common locations.
 func LoadConfig(configPath string) (*Config, error) {
+	cfg, _, err := readConfig(configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// configDecodeHook 将 "15m"、"168h" 等字符串解析为 time.Duration，逗号分隔的字符串解析为切片
+// YAML 和环境变量（如 JWT_ACCESS_TOKEN_TTL=15m）都以字符串形式提供，无法解析时加载失败而不是变成 0
+var configDecodeHook = mapstructure.ComposeDecodeHookFunc(
+	mapstructure.StringToTimeDurationHookFunc(),
+	mapstructure.StringToSliceHookFunc(","),
+)
+
+// readConfig 读取配置文件和环境变量，不做校验；返回的 viper 实例用于监听配置文件变化
+func readConfig(configPath string) (*Config, *viper.Viper, error) {
 	v := viper.New()
 
 	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
@@ -185,7 +855,7 @@ func LoadConfig(configPath string) (*Config, error) {
 		v.SetConfigFile(configPath)
 		if err := v.ReadInConfig(); err != nil {
 			if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
-				return nil, fmt.Errorf("failed to read config file: %w", err)
+				return nil, nil, fmt.Errorf("failed to read config file: %w", err)
 			}
 		}
 	} else {
@@ -202,21 +872,25 @@ func LoadConfig(configPath string) (*Config, error) {
 
 		if err := v.ReadInConfig(); err != nil {
 			if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
-				return nil, fmt.Errorf("failed to read base config file: %w", err)
+				return nil, nil, fmt.Errorf("failed to read base config file: %w", err)
 			}
 		}
 
 		v.SetConfigName(fmt.Sprintf("config.%s", env))
 		if err := v.MergeInConfig(); err != nil {
 			if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
-				return nil, fmt.Errorf("failed to merge environment config: %w", err)
+				return nil, nil, fmt.Errorf("failed to merge environment config: %w", err)
 			}
 		}
 	}
 
 	var cfg Config
-	if err := v.Unmarshal(&cfg); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
+	if err := v.Unmarshal(&cfg, viper.DecodeHook(configDecodeHook)); err != nil {
+		return nil, nil, fmt.Errorf("failed to unmarshal config: %w", err)
+	}
+
+	if err := resolveReferences(&cfg); err != nil {
+		return nil, nil, fmt.Errorf("failed to resolve config references: %w", err)
 	}
 
 	if cfg.App.Environment == "" {
@@ -227,11 +901,7 @@ func LoadConfig(configPath string) (*Config, error) {
 		}
 	}
 
-	if err := cfg.Validate(); err != nil {
-		return nil, err
-	}
-
-	return &cfg, nil
+	return &cfg, v, nil
 }
 
 func bindEnvVariables(v *viper.Viper) {
@@ -240,31 +910,76 @@ func bindEnvVariables(v *viper.Viper) {
 		"app.version":                   "APP_VERSION",
 		"app.environment":               "APP_ENVIRONMENT",
 		"app.debug":                     "APP_DEBUG",
+		"app.watch_config":              "APP_WATCH_CONFIG",
 		"database.host":                 "DATABASE_HOST",
 		"database.port":                 "DATABASE_PORT",
 		"database.user":                 "DATABASE_USER",
 		"database.password":             "DATABASE_PASSWORD",
 		"database.name":                 "DATABASE_NAME",
 		"database.sslmode":              "DATABASE_SSLMODE",
+		"database.connect_max_attempts":  "DATABASE_CONNECT_MAX_ATTEMPTS",
+		"database.connect_retry_timeout": "DATABASE_CONNECT_RETRY_TIMEOUT",
+		"database.replica_health_interval": "DATABASE_REPLICA_HEALTH_INTERVAL",
 		"jwt.secret":                    "JWT_SECRET",
 		"jwt.access_token_ttl":          "JWT_ACCESS_TOKEN_TTL",
 		"jwt.refresh_token_ttl":         "JWT_REFRESH_TOKEN_TTL",
+		"jwt.remember_me_refresh_token_ttl": "JWT_REMEMBER_ME_REFRESH_TOKEN_TTL",
 		"jwt.ttlhours":                  "JWT_TTLHOURS",
+		"jwt.enforce_token_version":     "JWT_ENFORCE_TOKEN_VERSION",
+		"jwt.token_version_cache_ttl":   "JWT_TOKEN_VERSION_CACHE_TTL",
+		"jwt.role_cache_ttl":            "JWT_ROLE_CACHE_TTL",
+		"jwt.role_cache_size":           "JWT_ROLE_CACHE_SIZE",
+		"jwt.auto_renew_enabled":        "JWT_AUTO_RENEW_ENABLED",
+		"jwt.auto_renew_window":         "JWT_AUTO_RENEW_WINDOW",
+		"jwt.impersonation_ttl":         "JWT_IMPERSONATION_TTL",
+		"jwt.clock_skew":                "JWT_CLOCK_SKEW",
+		"jwt.refresh_max_failures":      "JWT_REFRESH_MAX_FAILURES",
+		"jwt.refresh_store":             "JWT_REFRESH_STORE",
+		"jwt.max_client_access_token_ttl":  "JWT_MAX_CLIENT_ACCESS_TOKEN_TTL",
+		"jwt.max_client_refresh_token_ttl": "JWT_MAX_CLIENT_REFRESH_TOKEN_TTL",
+		"jwt.refresh_cookie.enabled":    "JWT_REFRESH_COOKIE_ENABLED",
+		"jwt.refresh_cookie.name":       "JWT_REFRESH_COOKIE_NAME",
+		"jwt.refresh_cookie.domain":     "JWT_REFRESH_COOKIE_DOMAIN",
+		"jwt.refresh_cookie.path":       "JWT_REFRESH_COOKIE_PATH",
+		"jwt.access_cookie.enabled":     "JWT_ACCESS_COOKIE_ENABLED",
+		"jwt.access_cookie.name":        "JWT_ACCESS_COOKIE_NAME",
+		"jwt.allow_admin_impersonation": "JWT_ALLOW_ADMIN_IMPERSONATION",
 		"server.port":                   "SERVER_PORT",
 		"server.readtimeout":            "SERVER_READTIMEOUT",
 		"server.writetimeout":           "SERVER_WRITETIMEOUT",
 		"server.idletimeout":            "SERVER_IDLETIMEOUT",
 		"server.shutdowntimeout":        "SERVER_SHUTDOWNTIMEOUT",
 		"server.maxheaderbytes":         "SERVER_MAXHEADERBYTES",
+		"server.trusted_proxies":        "SERVER_TRUSTED_PROXIES",
+		"server.compression.enabled":       "SERVER_COMPRESSION_ENABLED",
+		"server.compression.min_size":      "SERVER_COMPRESSION_MIN_SIZE",
+		"server.compression.content_types": "SERVER_COMPRESSION_CONTENT_TYPES",
+		"server.etag_enabled":              "SERVER_ETAG_ENABLED",
+		"server.max_in_flight":             "SERVER_MAX_IN_FLIGHT",
+		"server.max_queue_wait":            "SERVER_MAX_QUEUE_WAIT",
 		"logging.level":                 "LOGGING_LEVEL",
+		"logging.log_bodies":            "LOGGING_LOG_BODIES",
+		"logging.body_max_bytes":        "LOGGING_BODY_MAX_BYTES",
 		"ratelimit.enabled":             "RATELIMIT_ENABLED",
 		"ratelimit.requests":            "RATELIMIT_REQUESTS",
 		"ratelimit.window":              "RATELIMIT_WINDOW",
+		"ratelimit.strategy":            "RATELIMIT_STRATEGY",
+		"ratelimit.burst":               "RATELIMIT_BURST",
+		"ratelimit.refresh_requests":    "RATELIMIT_REFRESH_REQUESTS",
+		"ratelimit.refresh_ip_requests": "RATELIMIT_REFRESH_IP_REQUESTS",
+		"ratelimit.refresh_window":      "RATELIMIT_REFRESH_WINDOW",
+		"ratelimit.admin_requests":      "RATELIMIT_ADMIN_REQUESTS",
+		"ratelimit.admin_window":        "RATELIMIT_ADMIN_WINDOW",
+		"ratelimit.search_requests":     "RATELIMIT_SEARCH_REQUESTS",
+		"ratelimit.search_window":       "RATELIMIT_SEARCH_WINDOW",
 		"migrations.directory":          "MIGRATIONS_DIRECTORY",
 		"migrations.timeout":            "MIGRATIONS_TIMEOUT",
 		"migrations.locktimeout":        "MIGRATIONS_LOCKTIMEOUT",
-		"health.timeout":                "HEALTH_TIMEOUT",
-		"health.database_check_enabled": "HEALTH_DATABASE_CHECK_ENABLED",
+		"migrations.data_batch_size":    "MIGRATIONS_DATA_BATCH_SIZE",
+		"health.timeout":                 "HEALTH_TIMEOUT",
+		"health.database_check_enabled":  "HEALTH_DATABASE_CHECK_ENABLED",
+		"health.migration_check_enabled": "HEALTH_MIGRATION_CHECK_ENABLED",
+		"health.fail_on_dirty_schema":    "HEALTH_FAIL_ON_DIRTY_SCHEMA",
 
 		// MongoDB
 		"mongodb.uri":             "MONGODB_URI",
@@ -274,15 +989,90 @@ func bindEnvVariables(v *viper.Viper) {
 		"redis.host":           "REDIS_HOST",
 		"redis.port":           "REDIS_PORT",
 		"redis.password":       "REDIS_PASSWORD",
+		"redis.breaker.failure_threshold": "REDIS_BREAKER_FAILURE_THRESHOLD",
+		"redis.breaker.cooldown":          "REDIS_BREAKER_COOLDOWN",
+		"redis.breaker.failure_rate":      "REDIS_BREAKER_FAILURE_RATE",
+		"redis.breaker.window_size":       "REDIS_BREAKER_WINDOW_SIZE",
 	
 		// RabbitMQ
 		"rabbitmq.url":            "RABBITMQ_URL",
+		"rabbitmq.breaker.failure_threshold": "RABBITMQ_BREAKER_FAILURE_THRESHOLD",
+		"rabbitmq.breaker.cooldown":          "RABBITMQ_BREAKER_COOLDOWN",
+		"rabbitmq.breaker.failure_rate":      "RABBITMQ_BREAKER_FAILURE_RATE",
+		"rabbitmq.breaker.window_size":       "RABBITMQ_BREAKER_WINDOW_SIZE",
 
 		// gRPC
 		"grpc.port":               "GRPC_PORT",
 
+		// Security
+		"security.session_anomaly_action":       "SECURITY_SESSION_ANOMALY_ACTION",
+		"security.admin_ip_allowlist":           "SECURITY_ADMIN_IP_ALLOWLIST",
+		"security.login_history_retention_days": "SECURITY_LOGIN_HISTORY_RETENTION_DAYS",
+		"security.allowed_email_domains":        "SECURITY_ALLOWED_EMAIL_DOMAINS",
+		"security.blocked_email_domains":        "SECURITY_BLOCKED_EMAIL_DOMAINS",
+		"security.email_change_verification":    "SECURITY_EMAIL_CHANGE_VERIFICATION",
+		"security.email_change_token_ttl":       "SECURITY_EMAIL_CHANGE_TOKEN_TTL",
+		"security.password_history_depth":       "SECURITY_PASSWORD_HISTORY_DEPTH",
+
 		// Metrics
+		"metrics.enabled": "METRICS_ENABLED",
 		"metrics.port":    "METRICS_PORT",
+		"metrics.path":    "METRICS_PATH",
+
+		// Scheduler
+		"scheduler.health_port":                "SCHEDULER_HEALTH_PORT",
+		"scheduler.critical_failure_threshold": "SCHEDULER_CRITICAL_FAILURE_THRESHOLD",
+		"scheduler.drain_timeout":              "SCHEDULER_DRAIN_TIMEOUT",
+
+		// Swagger
+		"swagger.ui_enabled": "SWAGGER_UI_ENABLED",
+		"swagger.host":       "SWAGGER_HOST",
+
+		// API versioning
+		"api.base_path":        "API_BASE_PATH",
+		"api.v1_deprecated_at": "API_V1_DEPRECATED_AT",
+		"api.v1_sunset_at":     "API_V1_SUNSET_AT",
+
+		// Feature flags
+		"feature_flags.cache_ttl": "FEATURE_FLAGS_CACHE_TTL",
+
+		// Mail
+		"mail.enabled":  "MAIL_ENABLED",
+		"mail.host":     "MAIL_HOST",
+		"mail.port":     "MAIL_PORT",
+		"mail.username": "MAIL_USERNAME",
+		"mail.password": "MAIL_PASSWORD",
+		"mail.from":     "MAIL_FROM",
+		"mail.tls_mode": "MAIL_TLS_MODE",
+
+		"mail.breaker.failure_threshold": "MAIL_BREAKER_FAILURE_THRESHOLD",
+		"mail.breaker.cooldown":          "MAIL_BREAKER_COOLDOWN",
+		"mail.breaker.failure_rate":      "MAIL_BREAKER_FAILURE_RATE",
+		"mail.breaker.window_size":       "MAIL_BREAKER_WINDOW_SIZE",
+
+		// Pagination
+		"pagination.default_page_size": "PAGINATION_DEFAULT_PAGE_SIZE",
+		"pagination.max_page_size":     "PAGINATION_MAX_PAGE_SIZE",
+
+		// OAuth
+		"oauth.google.enabled":       "OAUTH_GOOGLE_ENABLED",
+		"oauth.google.client_id":     "OAUTH_GOOGLE_CLIENT_ID",
+		"oauth.google.client_secret": "OAUTH_GOOGLE_CLIENT_SECRET",
+		"oauth.google.redirect_url":  "OAUTH_GOOGLE_REDIRECT_URL",
+
+		// Outbound HTTP client
+		"http_client.timeout":                   "HTTP_CLIENT_TIMEOUT",
+		"http_client.dial_timeout":              "HTTP_CLIENT_DIAL_TIMEOUT",
+		"http_client.response_header_timeout":   "HTTP_CLIENT_RESPONSE_HEADER_TIMEOUT",
+		"http_client.max_idle_conns":            "HTTP_CLIENT_MAX_IDLE_CONNS",
+		"http_client.max_idle_conns_per_host":   "HTTP_CLIENT_MAX_IDLE_CONNS_PER_HOST",
+		"http_client.tls_min_version":           "HTTP_CLIENT_TLS_MIN_VERSION",
+		"http_client.max_retries":               "HTTP_CLIENT_MAX_RETRIES",
+		"http_client.retry_backoff":             "HTTP_CLIENT_RETRY_BACKOFF",
+		"http_client.breaker.failure_threshold": "HTTP_CLIENT_BREAKER_FAILURE_THRESHOLD",
+		"http_client.breaker.cooldown":          "HTTP_CLIENT_BREAKER_COOLDOWN",
+		"http_client.breaker.failure_rate":      "HTTP_CLIENT_BREAKER_FAILURE_RATE",
+		"http_client.breaker.window_size":       "HTTP_CLIENT_BREAKER_WINDOW_SIZE",
 
 	
 	}
@@ -335,14 +1125,3 @@ func GetConfigPath() string {
 
 	return "configs/config.yaml"
 }
-
-func (c *Config) LogSafeConfig(logger *slog.Logger) {
-	logger.Info("Loaded Configuration:")
-	logger.Info("App", "Name", c.App.Name, "Environment", c.App.Environment, "Debug", c.App.Debug)
-	logger.Info("Database", "Host", c.Database.Host, "Port", c.Database.Port, "User", c.Database.User, "Password", "<redacted>", "Name", c.Database.Name, "SSLMode", c.Database.SSLMode)
-	logger.Info("JWT", "Secret", "<redacted>", "AccessTokenTTL", c.JWT.AccessTokenTTL, "RefreshTokenTTL", c.JWT.RefreshTokenTTL)
-	logger.Info("Server", "Port", c.Server.Port, "ReadTimeout", c.Server.ReadTimeout, "WriteTimeout", c.Server.WriteTimeout, "IdleTimeout", c.Server.IdleTimeout, "ShutdownTimeout", c.Server.ShutdownTimeout, "MaxHeaderBytes", c.Server.MaxHeaderBytes)
-	logger.Info("Logging", "Level", c.Logging.Level)
-	logger.Info("RateLimit", "Enabled", c.Ratelimit.Enabled, "Requests", c.Ratelimit.Requests, "Window", c.Ratelimit.Window)
-	logger.Info("Migrations", "Directory", c.Migrations.Directory, "Timeout", c.Migrations.Timeout, "LockTimeout", c.Migrations.LockTimeout)
-}
@@ -0,0 +1,146 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// maxProductionRefreshTTL 生产环境刷新令牌（含"记住我"）有效期上限
+const maxProductionRefreshTTL = 30 * 24 * time.Hour
+
+// Rule 一条环境策略规则，配置不满足时返回描述问题的错误
+type Rule func(c *Config) error
+
+// Policy 按运行环境附加在各配置段校验之上的策略
+// Rules 不满足时校验失败，Advisories 不满足时只输出警告
+type Policy struct {
+	Name       string
+	Rules      []Rule
+	Advisories []Rule
+}
+
+// productionRules 生产环境必须满足的规则
+var productionRules = []Rule{
+	func(c *Config) error {
+		if c.App.Debug {
+			return fmt.Errorf("app.debug must be false in production")
+		}
+		return nil
+	},
+	func(c *Config) error {
+		if c.Logging.LogBodies {
+			return fmt.Errorf("logging.log_bodies must be false in production")
+		}
+		return nil
+	},
+	func(c *Config) error {
+		if c.JWT.RefreshStore == RefreshStoreMemory {
+			return fmt.Errorf("jwt.refresh_store cannot be 'memory' in production")
+		}
+		return nil
+	},
+	func(c *Config) error {
+		if c.JWT.RefreshTokenTTL > maxProductionRefreshTTL || c.JWT.RememberMeRefreshTokenTTL > maxProductionRefreshTTL {
+			return fmt.Errorf("jwt.refresh_token_ttl and jwt.remember_me_refresh_token_ttl must not exceed %s in production", maxProductionRefreshTTL)
+		}
+		for _, client := range c.JWT.Clients {
+			if client.RefreshTokenTTL > maxProductionRefreshTTL {
+				return fmt.Errorf("jwt.clients[%s].refresh_token_ttl must not exceed %s in production", client.ID, maxProductionRefreshTTL)
+			}
+		}
+		return nil
+	},
+	func(c *Config) error {
+		if c.Database.Password == "" {
+			return fmt.Errorf("database.password is required in production")
+		}
+		return nil
+	},
+	func(c *Config) error {
+		if c.Database.SSLMode == "disable" {
+			return fmt.Errorf("database SSL mode cannot be 'disable' in production")
+		}
+		return nil
+	},
+	func(c *Config) error {
+		if !c.Ratelimit.Enabled {
+			return fmt.Errorf("ratelimit.enabled must be true in production")
+		}
+		return nil
+	},
+	func(c *Config) error {
+		if cost := c.Security.GetBcryptCost(); cost < 12 {
+			return fmt.Errorf("security.bcrypt_cost must be at least 12 in production (current: %d)", cost)
+		}
+		return nil
+	},
+	func(c *Config) error {
+		if !c.Security.EnableSecurityHeaders {
+			return fmt.Errorf("security.enable_security_headers must be true in production")
+		}
+		return nil
+	},
+}
+
+// securityAdvisories 所有环境都会提示的安全建议
+var securityAdvisories = []Rule{
+	func(c *Config) error {
+		if c.Security.BcryptCost < 10 || c.Security.BcryptCost > 14 {
+			return fmt.Errorf("bcrypt cost factor (%d) should be between 10-14 for optimal security", c.Security.BcryptCost)
+		}
+		return nil
+	},
+	func(c *Config) error {
+		if c.Security.PasswordMinLength < 8 {
+			return fmt.Errorf("password minimum length (%d) should be at least 8 characters", c.Security.PasswordMinLength)
+		}
+		return nil
+	},
+	func(c *Config) error {
+		if c.Security.MaxLoginAttempts < 3 || c.Security.MaxLoginAttempts > 10 {
+			return fmt.Errorf("max login attempts (%d) should be between 3-10", c.Security.MaxLoginAttempts)
+		}
+		return nil
+	},
+}
+
+var (
+	// ProductionPolicy 生产环境：强制执行 productionRules
+	ProductionPolicy = Policy{Name: "production", Rules: productionRules, Advisories: securityAdvisories}
+	// StagingPolicy 预发环境：与生产环境相同的规则，但只输出警告，便于上线前发现问题
+	StagingPolicy = Policy{Name: "staging", Advisories: append(append([]Rule{}, productionRules...), securityAdvisories...)}
+	// DevelopmentPolicy 开发与测试环境：只输出安全建议警告
+	DevelopmentPolicy = Policy{Name: "development", Advisories: securityAdvisories}
+)
+
+// PolicyFor 返回 app.environment 对应的校验策略，未知环境按开发环境处理
+func PolicyFor(environment string) Policy {
+	switch environment {
+	case "production":
+		return ProductionPolicy
+	case "staging":
+		return StagingPolicy
+	default:
+		return DevelopmentPolicy
+	}
+}
+
+// Violations 返回 c 违反的全部 Rules
+func (p Policy) Violations(c *Config) []error {
+	return evaluate(p.Rules, c)
+}
+
+// Warnings 返回 c 违反的全部 Advisories
+func (p Policy) Warnings(c *Config) []error {
+	return evaluate(p.Advisories, c)
+}
+
+func evaluate(rules []Rule, c *Config) []error {
+	var errs []error
+	for _, rule := range rules {
+		if err := rule(c); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
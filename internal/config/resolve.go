@@ -0,0 +1,89 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// SecretFilePrefix 以此开头的配置值视为文件引用，加载时替换为文件内容（去除首尾空白）
+// 用于读取 Kubernetes 以文件挂载的 Secret，如 database.password: "file:///run/secrets/db-password"
+const SecretFilePrefix = "file://"
+
+// envReference 匹配 ${ENV_VAR}；不带花括号的 $ 保持原样，避免误伤密码中的 $ 字符
+var envReference = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// resolveReferences 展开所有字符串配置项中的 ${ENV_VAR}，并读取 file:// 引用的文件
+// 环境变量未设置或文件无法读取时返回包含配置键的错误，错误中不包含任何配置值
+func resolveReferences(cfg *Config) error {
+	return errors.Join(resolveValue("", reflect.ValueOf(cfg).Elem())...)
+}
+
+func resolveValue(key string, v reflect.Value) []error {
+	switch v.Kind() {
+	case reflect.Struct:
+		var errs []error
+		t := v.Type()
+		for i := 0; i < v.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			errs = append(errs, resolveValue(joinKey(key, fieldKey(field)), v.Field(i))...)
+		}
+		return errs
+	case reflect.Slice:
+		var errs []error
+		for i := 0; i < v.Len(); i++ {
+			errs = append(errs, resolveValue(fmt.Sprintf("%s[%d]", key, i), v.Index(i))...)
+		}
+		return errs
+	case reflect.String:
+		resolved, err := resolveString(v.String())
+		if err != nil {
+			return []error{fmt.Errorf("config %s: %w", key, err)}
+		}
+		v.SetString(resolved)
+	}
+	return nil
+}
+
+func resolveString(value string) (string, error) {
+	var missing []string
+	value = envReference.ReplaceAllStringFunc(value, func(ref string) string {
+		name := envReference.FindStringSubmatch(ref)[1]
+		env, ok := os.LookupEnv(name)
+		if !ok {
+			missing = append(missing, name)
+		}
+		return env
+	})
+	switch len(missing) {
+	case 0:
+	case 1:
+		return "", fmt.Errorf("environment variable %s is not set", missing[0])
+	default:
+		return "", fmt.Errorf("environment variables %s are not set", strings.Join(missing, ", "))
+	}
+
+	path, ok := strings.CutPrefix(value, SecretFilePrefix)
+	if !ok {
+		return value, nil
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		// os.PathError 只包含路径和原因，不会泄露文件内容
+		return "", fmt.Errorf("failed to read secret file: %w", err)
+	}
+	return strings.TrimSpace(string(content)), nil
+}
+
+func joinKey(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
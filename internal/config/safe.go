@@ -0,0 +1,108 @@
+package config
+
+import (
+	"log/slog"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// RedactedValue 敏感配置项在日志和接口输出中的替代值
+const RedactedValue = "<redacted>"
+
+// sensitiveKeys 完整匹配即视为敏感的配置键（连接串中通常内嵌账号密码）
+var sensitiveKeys = map[string]bool{
+	"uri": true,
+	"url": true,
+	"dsn": true,
+}
+
+// isSensitiveKey 判断配置键是否为敏感信息：包含 password/secret，或为连接串
+func isSensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+	return strings.Contains(key, "password") || strings.Contains(key, "secret") || sensitiveKeys[key]
+}
+
+// SafeMap 返回脱敏后的完整配置，键与配置文件一致（mapstructure 标签）
+// 敏感字段非空时替换为 RedactedValue，为空时保留空字符串以便排查是否已配置；时长输出为 "15m0s" 形式
+func (c *Config) SafeMap() map[string]any {
+	return safeStruct(reflect.ValueOf(*c))
+}
+
+func safeStruct(v reflect.Value) map[string]any {
+	out := make(map[string]any, v.NumField())
+	t := v.Type()
+	for i := 0; i < v.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		key := fieldKey(field)
+		if isSensitiveKey(key) && field.Type.Kind() == reflect.String {
+			if v.Field(i).String() == "" {
+				out[key] = ""
+			} else {
+				out[key] = RedactedValue
+			}
+			continue
+		}
+		out[key] = safeValue(v.Field(i))
+	}
+	return out
+}
+
+// fieldKey 返回字段在配置文件中的键名（mapstructure 标签，缺省为小写字段名）
+func fieldKey(field reflect.StructField) string {
+	key, _, _ := strings.Cut(field.Tag.Get("mapstructure"), ",")
+	if key == "" || key == "-" {
+		key = strings.ToLower(field.Name)
+	}
+	return key
+}
+
+func safeValue(v reflect.Value) any {
+	if d, ok := v.Interface().(time.Duration); ok {
+		return d.String()
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		return safeStruct(v)
+	case reflect.Slice:
+		items := make([]any, v.Len())
+		for i := range items {
+			items[i] = safeValue(v.Index(i))
+		}
+		return items
+	default:
+		return v.Interface()
+	}
+}
+
+// LogSafeConfig 按配置段输出脱敏后的配置
+func (c *Config) LogSafeConfig(logger *slog.Logger) {
+	logger.Info("Loaded Configuration:")
+	safe := c.SafeMap()
+	sections := make([]string, 0, len(safe))
+	for section := range safe {
+		sections = append(sections, section)
+	}
+	sort.Strings(sections)
+
+	for _, section := range sections {
+		fields, ok := safe[section].(map[string]any)
+		if !ok {
+			continue
+		}
+		keys := make([]string, 0, len(fields))
+		for key := range fields {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		args := make([]any, 0, len(keys)*2)
+		for _, key := range keys {
+			args = append(args, key, fields[key])
+		}
+		logger.Info(section, args...)
+	}
+}
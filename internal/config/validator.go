@@ -2,102 +2,422 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"mime"
+	"net"
 	"strings"
 )
 
+// Validate 校验各配置段，并应用当前环境的校验策略（见 PolicyFor）
+// 所有问题一次性返回（errors.Join），策略中的建议项只输出警告
 func (c *Config) Validate() error {
-	if c.JWT.Secret == "" {
-		return fmt.Errorf("JWT_SECRET environment variable is required - generate with: make generate-jwt-secret")
+	sections := []func() []error{
+		c.validateJWT,
+		c.validateDatabase,
+		c.validateServer,
+		c.validateRatelimit,
+		c.validateLogging,
+		c.validateRedis,
+		c.validateMongoDB,
+		c.validateRabbitMQ,
+		c.validateAPI,
+		c.validateFeatureFlags,
+		c.validateMail,
+		c.validateOAuth,
+		c.validatePagination,
+		c.validateSecurity,
+		c.validatePolicies,
+	}
+
+	var errs []error
+	for _, validate := range sections {
+		errs = append(errs, validate()...)
+	}
+
+	policy := PolicyFor(c.App.Environment)
+	errs = append(errs, policy.Violations(c)...)
+	for _, warning := range policy.Warnings(c) {
+		fmt.Printf("⚠️  Warning: %s\n", warning)
+	}
+
+	return errors.Join(errs...)
+}
+
+func (c *Config) validateLogging() []error {
+	var errs []error
+	if c.Logging.BodyMaxBytes < 0 {
+		errs = append(errs, fmt.Errorf("logging.body_max_bytes must be non-negative"))
 	}
+	return errs
+}
 
-	if len(c.JWT.Secret) < 32 {
-		return fmt.Errorf(
+func (c *Config) validateJWT() []error {
+	var errs []error
+	if c.JWT.Secret == "" {
+		errs = append(errs, fmt.Errorf("JWT_SECRET environment variable is required - generate with: make generate-jwt-secret"))
+	} else if len(c.JWT.Secret) < 32 {
+		errs = append(errs, fmt.Errorf(
 			"JWT_SECRET must be at least 32 characters (current: %d)\nGenerate secure secret: make generate-jwt-secret",
 			len(c.JWT.Secret),
-		)
+		))
 	}
 
-	if c.Database.Host == "" {
-		return fmt.Errorf("database.host is required")
+	switch c.JWT.RefreshStore {
+	case "", RefreshStoreDatabase, RefreshStoreMemory:
+	default:
+		errs = append(errs, fmt.Errorf("jwt.refresh_store must be one of: database, memory"))
 	}
 
-	if c.Server.ReadTimeout < 0 {
-		return fmt.Errorf("server.readtimeout must be non-negative")
+	// 角色缓存配置验证
+	if c.JWT.RoleCacheTTL < 0 {
+		errs = append(errs, fmt.Errorf("jwt.role_cache_ttl must be non-negative"))
 	}
-
-	if c.Server.WriteTimeout < 0 {
-		return fmt.Errorf("server.writetimeout must be non-negative")
+	if c.JWT.RoleCacheSize < 0 {
+		errs = append(errs, fmt.Errorf("jwt.role_cache_size must be non-negative"))
 	}
 
-	if c.Server.IdleTimeout < 0 {
-		return fmt.Errorf("server.idletimeout must be non-negative")
+	// 时钟偏差只用于吸收机器间的微小误差，过大会让过期令牌长时间有效
+	if c.JWT.ClockSkew < 0 || c.JWT.ClockSkew > maxJWTClockSkew {
+		errs = append(errs, fmt.Errorf("jwt.clock_skew must be between 0 and %s", maxJWTClockSkew))
 	}
 
-	if c.Server.ShutdownTimeout < 0 {
-		return fmt.Errorf("server.shutdowntimeout must be non-negative")
+	// 刷新令牌 Cookie 配置验证
+	if c.JWT.RefreshCookie.Enabled && c.JWT.RefreshCookie.Path != "" && !strings.HasPrefix(c.JWT.RefreshCookie.Path, "/") {
+		errs = append(errs, fmt.Errorf("jwt.refresh_cookie.path must start with /"))
+	}
+	// 访问令牌 Cookie 依赖刷新令牌 Cookie 的 CSRF 防护和轮换
+	if c.JWT.AccessCookie.Enabled && !c.JWT.RefreshCookie.Enabled {
+		errs = append(errs, fmt.Errorf("jwt.access_cookie requires jwt.refresh_cookie.enabled"))
 	}
+	return append(errs, c.validateClients()...)
+}
 
-	if c.Server.MaxHeaderBytes < 0 {
-		return fmt.Errorf("server.maxheaderbytes must be non-negative")
+// validateClients 客户端令牌策略验证：标识唯一、有效期不超过上限、签发方式合法
+func (c *Config) validateClients() []error {
+	var errs []error
+	if c.JWT.MaxClientAccessTokenTTL < 0 || c.JWT.MaxClientRefreshTokenTTL < 0 {
+		errs = append(errs, fmt.Errorf("jwt.max_client_access_token_ttl and jwt.max_client_refresh_token_ttl must be non-negative"))
 	}
+	maxAccess, maxRefresh := c.JWT.GetMaxClientAccessTokenTTL(), c.JWT.GetMaxClientRefreshTokenTTL()
 
-	if c.App.Environment == "production" {
-		if c.Database.Password == "" {
-			return fmt.Errorf("database.password is required in production")
+	seen := make(map[string]bool, len(c.JWT.Clients))
+	for i, client := range c.JWT.Clients {
+		if client.ID == "" || len(client.ID) > 64 {
+			errs = append(errs, fmt.Errorf("jwt.clients[%d].id must be 1-64 characters", i))
+			continue
+		}
+		if seen[client.ID] {
+			errs = append(errs, fmt.Errorf("jwt.clients contains duplicate id %q", client.ID))
 		}
+		seen[client.ID] = true
 
-		if c.Database.SSLMode == "disable" {
-			return fmt.Errorf("database SSL mode cannot be 'disable' in production")
+		if client.AccessTokenTTL < 0 || client.AccessTokenTTL > maxAccess {
+			errs = append(errs, fmt.Errorf("jwt.clients[%s].access_token_ttl must be between 0 and %s", client.ID, maxAccess))
+		}
+		if client.RefreshTokenTTL < 0 || client.RefreshTokenTTL > maxRefresh {
+			errs = append(errs, fmt.Errorf("jwt.clients[%s].refresh_token_ttl must be between 0 and %s", client.ID, maxRefresh))
+		}
+		for _, grant := range client.Grants {
+			switch grant {
+			case ClientGrantPassword, ClientGrantRegister, ClientGrantRefresh, ClientGrantOAuth:
+			default:
+				errs = append(errs, fmt.Errorf("jwt.clients[%s].grants contains unknown grant %q (expected password, register, refresh or oauth)", client.ID, grant))
+			}
 		}
 	}
+	return errs
+}
 
-	// Redis 配置验证（如果启用）
-	if c.Redis.Enabled {
-		if c.Redis.Host == "" {
-			return fmt.Errorf("redis.host is required when Redis is enabled")
+func (c *Config) validateDatabase() []error {
+	var errs []error
+	if c.Database.Host == "" {
+		errs = append(errs, fmt.Errorf("database.host is required"))
+	}
+	if c.Database.ConnectMaxAttempts < 0 {
+		errs = append(errs, fmt.Errorf("database.connect_max_attempts must be non-negative"))
+	}
+	if c.Database.ConnectRetryTimeout < 0 {
+		errs = append(errs, fmt.Errorf("database.connect_retry_timeout must be non-negative"))
+	}
+	if c.Database.ReplicaHealthInterval < 0 {
+		errs = append(errs, fmt.Errorf("database.replica_health_interval must be non-negative"))
+	}
+	names := make(map[string]bool, len(c.Database.Replicas))
+	for i, replica := range c.Database.Replicas {
+		if replica.Host == "" {
+			errs = append(errs, fmt.Errorf("database.replicas[%d].host is required", i))
 		}
-		if c.Redis.Port == 0 {
-			return fmt.Errorf("redis.port is required when Redis is enabled")
+		if replica.Port < 0 || replica.Port > 65535 {
+			errs = append(errs, fmt.Errorf("database.replicas[%d].port must be between 0 and 65535", i))
 		}
-		if c.Redis.DB < 0 || c.Redis.DB > 15 {
-			return fmt.Errorf("redis.db must be between 0-15")
+		name := c.Database.ReplicaName(i)
+		if names[name] {
+			errs = append(errs, fmt.Errorf("database.replicas[%d].name %q is duplicated", i, name))
 		}
+		names[name] = true
 	}
+	return errs
+}
 
-	// MongoDB 配置验证（如果启用）
-	if c.MongoDB.Enabled {
-		if c.MongoDB.URI == "" {
-			return fmt.Errorf("mongodb.uri is required when MongoDB is enabled")
+func (c *Config) validateServer() []error {
+	var errs []error
+	if c.Server.ReadTimeout < 0 {
+		errs = append(errs, fmt.Errorf("server.readtimeout must be non-negative"))
+	}
+	if c.Server.WriteTimeout < 0 {
+		errs = append(errs, fmt.Errorf("server.writetimeout must be non-negative"))
+	}
+	if c.Server.IdleTimeout < 0 {
+		errs = append(errs, fmt.Errorf("server.idletimeout must be non-negative"))
+	}
+	if c.Server.ShutdownTimeout < 0 {
+		errs = append(errs, fmt.Errorf("server.shutdowntimeout must be non-negative"))
+	}
+	if c.Server.MaxHeaderBytes < 0 {
+		errs = append(errs, fmt.Errorf("server.maxheaderbytes must be non-negative"))
+	}
+	for _, proxy := range c.Server.TrustedProxies {
+		if !isIPOrCIDR(strings.TrimSpace(proxy)) {
+			errs = append(errs, fmt.Errorf("server.trusted_proxies contains invalid IP or CIDR %q", proxy))
 		}
-		if c.MongoDB.Database == "" {
-			return fmt.Errorf("mongodb.database is required when MongoDB is enabled")
+	}
+	if c.Server.Compression.MinSize < 0 {
+		errs = append(errs, fmt.Errorf("server.compression.min_size must be non-negative"))
+	}
+	for _, contentType := range c.Server.Compression.ContentTypes {
+		if _, _, err := mime.ParseMediaType(contentType); err != nil {
+			errs = append(errs, fmt.Errorf("server.compression.content_types contains invalid media type %q", contentType))
 		}
 	}
+	if c.Server.MaxInFlight < 0 {
+		errs = append(errs, fmt.Errorf("server.max_in_flight must be non-negative"))
+	}
+	if c.Server.MaxQueueWait < 0 {
+		errs = append(errs, fmt.Errorf("server.max_queue_wait must be non-negative"))
+	}
+	return errs
+}
 
-	// RabbitMQ 配置验证（如果启用）
-	if c.RabbitMQ.Enabled {
-		if c.RabbitMQ.URL == "" {
-			return fmt.Errorf("rabbitmq.url is required when RabbitMQ is enabled")
-		}
+// validateRatelimit 校验全局限流算法和令牌桶容量
+func (c *Config) validateRatelimit() []error {
+	var errs []error
+	rl := c.Ratelimit
+	switch rl.Strategy {
+	case "", RateLimitStrategyTokenBucket, RateLimitStrategyFixedWindow:
+	default:
+		errs = append(errs, fmt.Errorf("ratelimit.strategy must be %q or %q, got %q",
+			RateLimitStrategyTokenBucket, RateLimitStrategyFixedWindow, rl.Strategy))
+	}
+	if rl.Burst < 0 {
+		errs = append(errs, fmt.Errorf("ratelimit.burst must be non-negative"))
+	}
+	return errs
+}
+
+// validateRedis Redis 配置验证（如果启用）
+func (c *Config) validateRedis() []error {
+	if !c.Redis.Enabled {
+		return nil
+	}
+	var errs []error
+	if c.Redis.Host == "" {
+		errs = append(errs, fmt.Errorf("redis.host is required when Redis is enabled"))
+	}
+	if c.Redis.Port == 0 {
+		errs = append(errs, fmt.Errorf("redis.port is required when Redis is enabled"))
+	}
+	if c.Redis.DB < 0 || c.Redis.DB > 15 {
+		errs = append(errs, fmt.Errorf("redis.db must be between 0-15"))
+	}
+	return append(errs, c.Redis.Breaker.validate("redis.breaker")...)
+}
+
+// validateMongoDB MongoDB 配置验证（如果启用）
+func (c *Config) validateMongoDB() []error {
+	if !c.MongoDB.Enabled {
+		return nil
+	}
+	var errs []error
+	if c.MongoDB.URI == "" {
+		errs = append(errs, fmt.Errorf("mongodb.uri is required when MongoDB is enabled"))
+	}
+	if c.MongoDB.Database == "" {
+		errs = append(errs, fmt.Errorf("mongodb.database is required when MongoDB is enabled"))
+	}
+	return errs
+}
+
+// validateRabbitMQ RabbitMQ 配置验证（如果启用）
+func (c *Config) validateRabbitMQ() []error {
+	if !c.RabbitMQ.Enabled {
+		return nil
 	}
+	var errs []error
+	if c.RabbitMQ.URL == "" {
+		errs = append(errs, fmt.Errorf("rabbitmq.url is required when RabbitMQ is enabled"))
+	}
+	return append(errs, c.RabbitMQ.Breaker.validate("rabbitmq.breaker")...)
+}
 
-	// 安全配置验证
-	if c.Security.BcryptCost < 10 || c.Security.BcryptCost > 14 {
-		fmt.Printf("⚠️  Warning: bcrypt cost factor (%d) should be between 10-14 for optimal security\n", c.Security.BcryptCost)
+// validate 熔断配置验证，key 为配置段前缀
+func (b BreakerConfig) validate(key string) []error {
+	var errs []error
+	if b.FailureThreshold < 0 {
+		errs = append(errs, fmt.Errorf("%s.failure_threshold must be non-negative", key))
+	}
+	if b.Cooldown < 0 {
+		errs = append(errs, fmt.Errorf("%s.cooldown must be non-negative", key))
+	}
+	if b.FailureRate < 0 || b.FailureRate > 1 {
+		errs = append(errs, fmt.Errorf("%s.failure_rate must be between 0 and 1", key))
 	}
+	if b.WindowSize < 0 {
+		errs = append(errs, fmt.Errorf("%s.window_size must be non-negative", key))
+	}
+	return errs
+}
 
-	if c.Security.PasswordMinLength < 8 {
-		fmt.Printf("⚠️  Warning: password minimum length (%d) should be at least 8 characters\n", c.Security.PasswordMinLength)
+// validateAPI API 版本配置验证
+func (c *Config) validateAPI() []error {
+	deprecatedAt, sunsetAt, err := c.API.V1Deprecation()
+	if err != nil {
+		return []error{err}
+	}
+	if !deprecatedAt.IsZero() && !sunsetAt.IsZero() && sunsetAt.Before(deprecatedAt) {
+		return []error{fmt.Errorf("api.v1_sunset_at must not be before api.v1_deprecated_at")}
+	}
+	return nil
+}
+
+// validateFeatureFlags 功能开关配置验证
+func (c *Config) validateFeatureFlags() []error {
+	var errs []error
+	seenFlags := make(map[string]bool, len(c.FeatureFlags.Flags))
+	for _, f := range c.FeatureFlags.Flags {
+		if f.Name == "" {
+			errs = append(errs, fmt.Errorf("feature_flags.flags[].name is required"))
+			continue
+		}
+		if seenFlags[f.Name] {
+			errs = append(errs, fmt.Errorf("feature_flags.flags: duplicate flag %q", f.Name))
+		}
+		seenFlags[f.Name] = true
+		if f.Percentage < 0 || f.Percentage > 100 {
+			errs = append(errs, fmt.Errorf("feature_flags.flags[%s].percentage must be between 0-100", f.Name))
+		}
+	}
+	return errs
+}
+
+// validateMail 邮件配置验证（如果启用）
+func (c *Config) validateMail() []error {
+	if !c.Mail.Enabled {
+		return nil
+	}
+	var errs []error
+	if c.Mail.Host == "" {
+		errs = append(errs, fmt.Errorf("mail.host is required when mail is enabled"))
+	}
+	if c.Mail.From == "" {
+		errs = append(errs, fmt.Errorf("mail.from is required when mail is enabled"))
+	}
+	switch c.Mail.TLSMode {
+	case "", MailTLSNone, MailTLSStartTLS, MailTLSImplicit:
+	default:
+		errs = append(errs, fmt.Errorf("mail.tls_mode must be one of: none, starttls, tls"))
 	}
+	return append(errs, c.Mail.Breaker.validate("mail.breaker")...)
+}
 
-	if c.Security.MaxLoginAttempts < 3 || c.Security.MaxLoginAttempts > 10 {
-		fmt.Printf("⚠️  Warning: max login attempts (%d) should be between 3-10\n", c.Security.MaxLoginAttempts)
+// validateOAuth 第三方登录配置验证（如果启用）
+func (c *Config) validateOAuth() []error {
+	if !c.OAuth.Google.Enabled {
+		return nil
+	}
+	var errs []error
+	if c.OAuth.Google.ClientID == "" || c.OAuth.Google.ClientSecret == "" {
+		errs = append(errs, fmt.Errorf("oauth.google.client_id and oauth.google.client_secret are required when google login is enabled"))
 	}
+	if c.OAuth.Google.RedirectURL == "" {
+		errs = append(errs, fmt.Errorf("oauth.google.redirect_url is required when google login is enabled"))
+	}
+	return errs
+}
 
+// validatePagination 分页配置验证
+func (c *Config) validatePagination() []error {
+	if c.Pagination.DefaultPageSize < 0 || c.Pagination.MaxPageSize < 0 {
+		return []error{fmt.Errorf("pagination page sizes must not be negative")}
+	}
+	if c.Pagination.GetDefaultPageSize() > c.Pagination.GetMaxPageSize() {
+		return []error{fmt.Errorf("pagination.default_page_size (%d) must not exceed pagination.max_page_size (%d)",
+			c.Pagination.GetDefaultPageSize(), c.Pagination.GetMaxPageSize())}
+	}
 	return nil
 }
 
+// validateEmailDomains 域名不能为空、不能含 @ 或空格，且至少包含一个点
+func validateEmailDomains(key string, domains []string) []error {
+	var errs []error
+	for _, domain := range domains {
+		domain = strings.TrimSpace(domain)
+		if domain == "" || strings.ContainsAny(domain, "@ ") || !strings.Contains(domain, ".") {
+			errs = append(errs, fmt.Errorf("%s contains invalid domain %q", key, domain))
+		}
+	}
+	return errs
+}
+
+// validateSecurity 安全配置验证
+func (c *Config) validateSecurity() []error {
+	var errs []error
+	switch c.Security.SessionAnomalyAction {
+	case "", SessionAnomalyOff, SessionAnomalyLog, SessionAnomalyRevoke:
+	default:
+		errs = append(errs, fmt.Errorf("security.session_anomaly_action must be one of: off, log, revoke"))
+	}
+	for _, entry := range c.Security.AdminIPAllowlist {
+		if !isIPOrCIDR(strings.TrimSpace(entry)) {
+			errs = append(errs, fmt.Errorf("security.admin_ip_allowlist contains invalid IP or CIDR %q", entry))
+		}
+	}
+	if c.Security.LoginHistoryRetentionDays < 0 {
+		errs = append(errs, fmt.Errorf("security.login_history_retention_days must be non-negative"))
+	}
+	if c.Security.PasswordHistoryDepth < 0 {
+		errs = append(errs, fmt.Errorf("security.password_history_depth must be non-negative"))
+	}
+	errs = append(errs, validateEmailDomains("security.allowed_email_domains", c.Security.AllowedEmailDomains)...)
+	errs = append(errs, validateEmailDomains("security.blocked_email_domains", c.Security.BlockedEmailDomains)...)
+	return errs
+}
+
+// validatePolicies 协议版本配置验证
+func (c *Config) validatePolicies() []error {
+	var errs []error
+	seen := make(map[string]bool, len(c.Policies.Documents))
+	for _, d := range c.Policies.Documents {
+		if d.Type == "" {
+			errs = append(errs, fmt.Errorf("policies.documents[].type is required"))
+			continue
+		}
+		if seen[d.Type] {
+			errs = append(errs, fmt.Errorf("policies.documents: duplicate document type %q", d.Type))
+		}
+		seen[d.Type] = true
+		if d.Version == "" {
+			errs = append(errs, fmt.Errorf("policies.documents[%s].version is required", d.Type))
+		}
+		switch d.Enforcement {
+		case "", PolicyEnforcementFlag, PolicyEnforcementBlock:
+		default:
+			errs = append(errs, fmt.Errorf("policies.documents[%s].enforcement must be one of: flag, block", d.Type))
+		}
+	}
+	return errs
+}
+
 // ValidateOrPanic 验证配置，如果失败则 panic
 // 用于应用启动时的配置验证
 func (c *Config) ValidateOrPanic() {
@@ -106,3 +426,12 @@ func (c *Config) ValidateOrPanic() {
 		panic("配置验证失败，应用无法启动")
 	}
 }
+
+// isIPOrCIDR 判断字符串是否为合法的 IP 地址或 CIDR
+func isIPOrCIDR(value string) bool {
+	if strings.Contains(value, "/") {
+		_, _, err := net.ParseCIDR(value)
+		return err == nil
+	}
+	return net.ParseIP(value) != nil
+}
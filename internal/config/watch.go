@@ -0,0 +1,121 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"reflect"
+	"sync"
+	"sync/atomic"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Store 发布当前生效的配置快照，配置文件变化时原子替换
+// 能够实时生效的组件（日志级别、限流参数、功能开关缓存时间）每次使用时从 Load() 读取，不要保存字段副本
+type Store struct {
+	current  atomic.Pointer[Config]
+	logLevel slog.LevelVar
+	// mu 串行化配置更新，避免连续的文件事件交错发布
+	mu sync.Mutex
+}
+
+// NewStore 以已校验的配置创建快照
+func NewStore(cfg *Config) *Store {
+	s := &Store{}
+	s.current.Store(cfg)
+	s.logLevel.Set(cfg.Logging.GetLogLevel())
+	return s
+}
+
+// Load 返回当前配置快照，调用方不得修改
+func (s *Store) Load() *Config {
+	return s.current.Load()
+}
+
+// LogLevel 返回随 logging.level 变化的日志级别，用作 slog.HandlerOptions.Level
+func (s *Store) LogLevel() *slog.LevelVar {
+	return &s.logLevel
+}
+
+// Update 校验并发布新配置；需要重启才能生效的字段保留当前值，并对每个被忽略的变更输出 warn 日志
+func (s *Store) Update(next *Config) error {
+	if err := next.Validate(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, field := range retainRestartOnlyFields(next, s.Load()) {
+		slog.Warn("Config change requires a restart and was ignored", "field", field)
+	}
+	s.current.Store(next)
+	s.logLevel.Set(next.Logging.GetLogLevel())
+	return nil
+}
+
+// Watch 监听 configPath（为空时为 LoadConfig 找到的基础配置文件）的变化，重新读取并发布配置
+// 新配置校验失败时继续使用当前配置并输出 error 日志。环境配置文件 config.{env}.yaml 不在监听范围内
+func (s *Store) Watch(configPath string) error {
+	_, v, err := readConfig(configPath)
+	if err != nil {
+		return err
+	}
+	file := v.ConfigFileUsed()
+	if file == "" {
+		return fmt.Errorf("no config file to watch")
+	}
+
+	v.OnConfigChange(func(e fsnotify.Event) {
+		next, _, err := readConfig(configPath)
+		if err == nil {
+			err = s.Update(next)
+		}
+		if err != nil {
+			slog.Error("Config reload failed, keeping current configuration", "file", e.Name, "error", err)
+			return
+		}
+		slog.Info("Configuration reloaded", "file", e.Name, "log_level", next.Logging.GetLogLevel().String())
+	})
+	v.WatchConfig()
+	return nil
+}
+
+// WatchConfig 与 LoadConfig 相同地加载配置，并在配置文件变化时热加载
+func WatchConfig(configPath string) (*Store, error) {
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		return nil, err
+	}
+	store := NewStore(cfg)
+	if err := store.Watch(configPath); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// retainRestartOnlyFields 将 next 中无法热加载的字段（数据库连接、监听端口、JWT 密钥）恢复为 current 的值，返回发生变化的字段
+func retainRestartOnlyFields(next, current *Config) []string {
+	var changed []string
+	if !reflect.DeepEqual(next.Database, current.Database) {
+		changed = append(changed, "database")
+		next.Database = current.Database
+	}
+	if next.Server.Port != current.Server.Port {
+		changed = append(changed, "server.port")
+		next.Server.Port = current.Server.Port
+	}
+	if next.GRPC.Port != current.GRPC.Port {
+		changed = append(changed, "grpc.port")
+		next.GRPC.Port = current.GRPC.Port
+	}
+	if next.Metrics.Port != current.Metrics.Port {
+		changed = append(changed, "metrics.port")
+		next.Metrics.Port = current.Metrics.Port
+	}
+	if next.JWT.Secret != current.JWT.Secret {
+		changed = append(changed, "jwt.secret")
+		next.JWT.Secret = current.JWT.Secret
+	}
+	return changed
+}
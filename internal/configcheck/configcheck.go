@@ -0,0 +1,336 @@
+// Package configcheck 提供启动前的配置自检：校验配置，探测数据库、Redis、MongoDB、RabbitMQ，
+// 并检查迁移目录与数据库迁移状态
+// 供 `server --check-config`（别名 `--preflight`）与 `migrate configcheck` 使用，适合作为 Kubernetes initContainer
+package configcheck
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/golang-migrate/migrate/v4/source"
+	"gorm.io/gorm"
+
+	"github.com/yeegeek/uyou-go-api-starter/internal/config"
+	"github.com/yeegeek/uyou-go-api-starter/internal/db"
+	"github.com/yeegeek/uyou-go-api-starter/internal/health"
+	"github.com/yeegeek/uyou-go-api-starter/internal/messaging"
+	"github.com/yeegeek/uyou-go-api-starter/internal/migrate"
+	"github.com/yeegeek/uyou-go-api-starter/internal/mongodb"
+	"github.com/yeegeek/uyou-go-api-starter/internal/redis"
+)
+
+// defaultCheckTimeout 未配置 health.timeout 时单项检查的超时时间
+const defaultCheckTimeout = 5 * time.Second
+
+type Status string
+
+const (
+	StatusPass Status = "pass"
+	StatusFail Status = "fail"
+	// StatusSkip 依赖未启用，未执行
+	StatusSkip Status = "skip"
+)
+
+// Result 单项检查结果
+type Result struct {
+	Name      string `json:"name"`
+	Status    Status `json:"status"`
+	Message   string `json:"message,omitempty"`
+	LatencyMs int64  `json:"latency_ms"`
+}
+
+// Report 自检报告，任一检查失败时 OK 为 false
+type Report struct {
+	OK        bool      `json:"ok"`
+	Timestamp time.Time `json:"timestamp"`
+	Checks    []Result  `json:"checks"`
+}
+
+// Check 一项自检，Run 返回的字符串作为通过时的说明
+type Check struct {
+	Name string
+	// Skip 非空时跳过该检查并记录原因
+	Skip string
+	Run  func(ctx context.Context) (string, error)
+}
+
+// Run 加载 configPath 指定的配置（为空时按默认规则查找）并执行全部自检
+func Run(ctx context.Context, configPath string) *Report {
+	cfg, err := config.LoadConfig(configPath)
+	if err != nil {
+		return Execute(ctx, defaultCheckTimeout, []Check{{
+			Name: "config",
+			Run:  func(context.Context) (string, error) { return "", fmt.Errorf("failed to load configuration: %w", err) },
+		}})
+	}
+	checks, cleanup := Checks(cfg)
+	defer cleanup()
+	return Execute(ctx, checkTimeout(cfg), checks)
+}
+
+// Checks 返回针对 cfg 的全部自检项及释放数据库连接的 cleanup；Redis、MongoDB、RabbitMQ 未启用时跳过
+// database 与 migration_status 共用一个连接，复用就绪探针的数据库和迁移检查器
+func Checks(cfg *config.Config) ([]Check, func()) {
+	conn := &dbConn{cfg: cfg.Database}
+	checks := []Check{
+		{Name: "config", Run: func(context.Context) (string, error) {
+			return "configuration is valid", cfg.Validate()
+		}},
+		{Name: "database", Run: func(ctx context.Context) (string, error) {
+			database, err := conn.open()
+			if err != nil {
+				return "", err
+			}
+			if err := checkerError(health.NewDatabaseChecker(database).Check(ctx)); err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("connected to %s:%d/%s", cfg.Database.Host, cfg.Database.Port, cfg.Database.Name), nil
+		}},
+		{Name: "redis", Run: func(ctx context.Context) (string, error) {
+			client, err := redis.NewClient(cfg)
+			if err != nil {
+				return "", err
+			}
+			defer client.Close()
+			return fmt.Sprintf("connected to %s:%d", cfg.Redis.Host, cfg.Redis.Port), client.HealthCheck(ctx)
+		}},
+		{Name: "mongodb", Run: func(ctx context.Context) (string, error) {
+			client, err := mongodb.NewClient(cfg)
+			if err != nil {
+				return "", err
+			}
+			defer client.Close(context.Background())
+			return fmt.Sprintf("connected to database %s", cfg.MongoDB.Database), client.HealthCheck(ctx)
+		}},
+		{Name: "rabbitmq", Run: func(context.Context) (string, error) {
+			mq, err := messaging.NewRabbitMQ(&cfg.RabbitMQ)
+			if err != nil {
+				return "", err
+			}
+			defer mq.Close()
+			return "connected", mq.HealthCheck()
+		}},
+		{Name: "migrations", Run: func(context.Context) (string, error) {
+			return CheckMigrationsDir(cfg.Migrations.Directory)
+		}},
+		{Name: "migration_status", Run: func(ctx context.Context) (string, error) {
+			database, err := conn.open()
+			if err != nil {
+				return "", err
+			}
+			return checkMigrationStatus(ctx, database, cfg.Migrations)
+		}},
+	}
+	for i := range checks {
+		switch {
+		case checks[i].Name == "redis" && !cfg.Redis.Enabled:
+			checks[i].Skip = "redis is disabled"
+		case checks[i].Name == "mongodb" && !cfg.MongoDB.Enabled:
+			checks[i].Skip = "mongodb is disabled"
+		case checks[i].Name == "rabbitmq" && !cfg.RabbitMQ.Enabled:
+			checks[i].Skip = "rabbitmq is disabled"
+		}
+	}
+	return checks, conn.close
+}
+
+// Execute 依次执行检查，每项最多运行 timeout
+func Execute(ctx context.Context, timeout time.Duration, checks []Check) *Report {
+	report := &Report{OK: true, Timestamp: time.Now(), Checks: make([]Result, 0, len(checks))}
+	for _, check := range checks {
+		result := runCheck(ctx, timeout, check)
+		if result.Status == StatusFail {
+			report.OK = false
+		}
+		report.Checks = append(report.Checks, result)
+	}
+	return report
+}
+
+func runCheck(ctx context.Context, timeout time.Duration, check Check) Result {
+	if check.Skip != "" {
+		return Result{Name: check.Name, Status: StatusSkip, Message: check.Skip}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan Result, 1)
+	go func() {
+		message, err := check.Run(ctx)
+		if err != nil {
+			done <- Result{Name: check.Name, Status: StatusFail, Message: err.Error()}
+			return
+		}
+		done <- Result{Name: check.Name, Status: StatusPass, Message: message}
+	}()
+
+	var result Result
+	select {
+	case result = <-done:
+	case <-ctx.Done():
+		result = Result{Name: check.Name, Status: StatusFail, Message: fmt.Sprintf("timed out after %s", timeout)}
+	}
+	result.LatencyMs = time.Since(start).Milliseconds()
+	return result
+}
+
+// Write 以缩进 JSON 输出报告
+func (r *Report) Write(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// ExitCode 报告全部通过时返回 0，否则返回 1
+func (r *Report) ExitCode() int {
+	if r.OK {
+		return 0
+	}
+	return 1
+}
+
+func checkTimeout(cfg *config.Config) time.Duration {
+	if cfg.Health.Timeout > 0 {
+		return time.Duration(cfg.Health.Timeout) * time.Second
+	}
+	return defaultCheckTimeout
+}
+
+// dbConn 在第一次需要时连接数据库，连接失败时后续检查直接返回同一错误
+type dbConn struct {
+	cfg      config.DatabaseConfig
+	database *gorm.DB
+	err      error
+	opened   bool
+}
+
+func (c *dbConn) open() (*gorm.DB, error) {
+	if !c.opened {
+		c.opened = true
+		c.database, c.err = db.NewPostgresDBFromDatabaseConfig(c.cfg)
+	}
+	return c.database, c.err
+}
+
+func (c *dbConn) close() {
+	if c.database == nil {
+		return
+	}
+	if sqlDB, err := c.database.DB(); err == nil {
+		_ = sqlDB.Close()
+	}
+}
+
+// checkerError 将就绪探针检查结果转换为错误，只有 pass 视为通过
+func checkerError(result health.CheckResult) error {
+	if result.Status == health.CheckPass {
+		return nil
+	}
+	if result.Error != "" {
+		return fmt.Errorf("%s: %s", result.Message, result.Error)
+	}
+	return fmt.Errorf("%s", result.Message)
+}
+
+// checkMigrationStatus 读取数据库当前迁移版本，dirty 或落后于迁移目录中的最新版本时失败
+func checkMigrationStatus(ctx context.Context, database *gorm.DB, cfg config.MigrationsConfig) (string, error) {
+	_, latest, err := scanMigrationsDir(cfg.Directory)
+	if err != nil {
+		return "", err
+	}
+	sqlDB, err := database.DB()
+	if err != nil {
+		return "", err
+	}
+	// migrator 关闭时会关闭共享的数据库连接，因此不单独关闭，由 cleanup 统一释放
+	migrator, err := migrate.New(sqlDB, migrate.Config{
+		MigrationsDir: cfg.Directory,
+		Timeout:       time.Duration(cfg.Timeout) * time.Second,
+		LockTimeout:   time.Duration(cfg.LockTimeout) * time.Second,
+	})
+	if err != nil {
+		return "", err
+	}
+	return MigrationStatus(ctx, migrator, latest)
+}
+
+// MigrationStatus 使用就绪探针的迁移检查器校验 source 的版本：dirty 或低于 latest（存在未执行的迁移）时返回错误
+func MigrationStatus(ctx context.Context, source health.VersionSource, latest uint) (string, error) {
+	result := health.NewMigrationChecker(source, true).Check(ctx)
+	if err := checkerError(result); err != nil {
+		return "", err
+	}
+	details, _ := result.Details.(health.MigrationDetails)
+	if details.Version < latest {
+		return "", fmt.Errorf("database schema at version %d, latest migration is %d; run migrate up", details.Version, latest)
+	}
+	return result.Message, nil
+}
+
+// CheckMigrationsDir 校验迁移目录存在，且其中的 .sql 文件名均可解析、版本与方向不重复、每个版本都有 up 迁移
+func CheckMigrationsDir(dir string) (string, error) {
+	count, latest, err := scanMigrationsDir(dir)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%d migrations, latest version %d", count, latest), nil
+}
+
+// scanMigrationsDir 校验迁移目录并返回 up 迁移数量和最新版本
+func scanMigrationsDir(dir string) (int, uint, error) {
+	if dir == "" {
+		return 0, 0, fmt.Errorf("migrations directory is not configured")
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to read migrations directory: %w", err)
+	}
+
+	files := map[source.Direction]map[uint]string{source.Up: {}, source.Down: {}}
+	var problems []string
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".sql" {
+			continue
+		}
+		m, err := source.Parse(entry.Name())
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: invalid migration file name", entry.Name()))
+			continue
+		}
+		if other, ok := files[m.Direction][m.Version]; ok {
+			problems = append(problems, fmt.Sprintf("%s: duplicate %s migration for version %d (also %s)", entry.Name(), m.Direction, m.Version, other))
+			continue
+		}
+		files[m.Direction][m.Version] = entry.Name()
+	}
+	for version, name := range files[source.Down] {
+		if _, ok := files[source.Up][version]; !ok {
+			problems = append(problems, fmt.Sprintf("%s: no up migration for version %d", name, version))
+		}
+	}
+
+	var latest uint
+	for version := range files[source.Up] {
+		if version > latest {
+			latest = version
+		}
+	}
+
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return 0, 0, fmt.Errorf("invalid migrations in %s: %s", dir, strings.Join(problems, "; "))
+	}
+	if len(files[source.Up]) == 0 {
+		return 0, 0, fmt.Errorf("no migrations found in %s", dir)
+	}
+	return len(files[source.Up]), latest, nil
+}
@@ -0,0 +1,238 @@
+package configcheck
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yeegeek/uyou-go-api-starter/internal/config"
+)
+
+func TestExecute(t *testing.T) {
+	report := Execute(context.Background(), time.Second, []Check{
+		{Name: "ok", Run: func(context.Context) (string, error) { return "fine", nil }},
+		{Name: "disabled", Skip: "not enabled", Run: func(context.Context) (string, error) {
+			t.Fatal("skipped checks must not run")
+			return "", nil
+		}},
+		{Name: "broken", Run: func(context.Context) (string, error) { return "", errors.New("boom") }},
+	})
+
+	assert.False(t, report.OK)
+	assert.Equal(t, 1, report.ExitCode())
+	require.Len(t, report.Checks, 3)
+	assert.Equal(t, Result{Name: "ok", Status: StatusPass, Message: "fine", LatencyMs: report.Checks[0].LatencyMs}, report.Checks[0])
+	assert.Equal(t, Result{Name: "disabled", Status: StatusSkip, Message: "not enabled"}, report.Checks[1])
+	assert.Equal(t, StatusFail, report.Checks[2].Status)
+	assert.Equal(t, "boom", report.Checks[2].Message)
+
+	var buf bytes.Buffer
+	require.NoError(t, report.Write(&buf))
+	var decoded map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	assert.Equal(t, false, decoded["ok"])
+}
+
+func TestExecute_Timeout(t *testing.T) {
+	report := Execute(context.Background(), 20*time.Millisecond, []Check{
+		{Name: "slow", Run: func(ctx context.Context) (string, error) {
+			time.Sleep(time.Second)
+			return "", nil
+		}},
+	})
+
+	assert.False(t, report.OK)
+	assert.Equal(t, StatusFail, report.Checks[0].Status)
+	assert.Contains(t, report.Checks[0].Message, "timed out")
+}
+
+func TestExecute_AllPassed(t *testing.T) {
+	report := Execute(context.Background(), time.Second, []Check{
+		{Name: "ok", Run: func(context.Context) (string, error) { return "", nil }},
+		{Name: "disabled", Skip: "not enabled"},
+	})
+
+	assert.True(t, report.OK)
+	assert.Equal(t, 0, report.ExitCode())
+}
+
+func TestChecks_SkipsDisabledDependencies(t *testing.T) {
+	cfg := config.NewTestConfig()
+	cfg.Redis.Enabled = false
+	cfg.MongoDB.Enabled = false
+	cfg.RabbitMQ.Enabled = false
+
+	checks, cleanup := Checks(cfg)
+	defer cleanup()
+	names := make(map[string]string, len(checks))
+	for _, check := range checks {
+		names[check.Name] = check.Skip
+	}
+
+	assert.Equal(t, map[string]string{
+		"config":           "",
+		"database":         "",
+		"redis":            "redis is disabled",
+		"mongodb":          "mongodb is disabled",
+		"rabbitmq":         "rabbitmq is disabled",
+		"migrations":       "",
+		"migration_status": "",
+	}, names)
+}
+
+// fakeVersionSource is a health.VersionSource returning a fixed migration state
+type fakeVersionSource struct {
+	version uint
+	dirty   bool
+	err     error
+}
+
+func (f fakeVersionSource) Version() (uint, bool, error) {
+	return f.version, f.dirty, f.err
+}
+
+func TestMigrationStatus(t *testing.T) {
+	tests := []struct {
+		name    string
+		source  fakeVersionSource
+		wantErr string
+	}{
+		{name: "up to date", source: fakeVersionSource{version: 5}},
+		{name: "pending migrations", source: fakeVersionSource{version: 3}, wantErr: "database schema at version 3, latest migration is 5"},
+		{name: "dirty", source: fakeVersionSource{version: 5, dirty: true}, wantErr: "dirty at version 5"},
+		{name: "version unreadable", source: fakeVersionSource{err: errors.New("relation does not exist")}, wantErr: "relation does not exist"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			message, err := MigrationStatus(context.Background(), tt.source, 5)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "Database schema at version 5", message)
+		})
+	}
+}
+
+func TestPreflight_GoodConfig(t *testing.T) {
+	cfg := config.NewTestConfig()
+	cfg.Migrations.Directory = "../../migrations"
+	_, latest, err := scanMigrationsDir(cfg.Migrations.Directory)
+	require.NoError(t, err)
+
+	checks, cleanup := Checks(cfg)
+	defer cleanup()
+	// No database in unit tests: the database-backed checks run against a schema at the latest version
+	for i := range checks {
+		switch checks[i].Name {
+		case "database":
+			checks[i].Run = func(context.Context) (string, error) { return "connected", nil }
+		case "migration_status":
+			checks[i].Run = func(ctx context.Context) (string, error) {
+				return MigrationStatus(ctx, fakeVersionSource{version: latest}, latest)
+			}
+		}
+	}
+
+	report := Execute(context.Background(), time.Second, checks)
+
+	assert.True(t, report.OK, "%+v", report.Checks)
+	assert.Equal(t, 0, report.ExitCode())
+}
+
+func TestPreflight_BrokenConfig(t *testing.T) {
+	t.Run("invalid configuration file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "config.yaml")
+		require.NoError(t, os.WriteFile(path, []byte("app:\n  environment: production\njwt:\n  secret: short\n"), 0o644))
+
+		report := Run(context.Background(), path)
+
+		assert.False(t, report.OK)
+		assert.Equal(t, 1, report.ExitCode())
+		require.Len(t, report.Checks, 1)
+		assert.Equal(t, "config", report.Checks[0].Name)
+		assert.Equal(t, StatusFail, report.Checks[0].Status)
+	})
+
+	t.Run("unreachable database", func(t *testing.T) {
+		cfg := config.NewTestConfig()
+		cfg.Database.Host = "127.0.0.1"
+		cfg.Database.Port = 1
+		cfg.Migrations.Directory = "../../migrations"
+
+		checks, cleanup := Checks(cfg)
+		defer cleanup()
+		report := Execute(context.Background(), 5*time.Second, checks)
+
+		assert.False(t, report.OK)
+		assert.Equal(t, 1, report.ExitCode())
+		statuses := make(map[string]Status, len(report.Checks))
+		for _, result := range report.Checks {
+			statuses[result.Name] = result.Status
+		}
+		assert.Equal(t, StatusPass, statuses["config"])
+		assert.Equal(t, StatusPass, statuses["migrations"])
+		assert.Equal(t, StatusFail, statuses["database"])
+		assert.Equal(t, StatusFail, statuses["migration_status"])
+	})
+}
+
+func TestCheckMigrationsDir(t *testing.T) {
+	write := func(t *testing.T, dir string, names ...string) {
+		t.Helper()
+		for _, name := range names {
+			require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte("SELECT 1;"), 0o644))
+		}
+	}
+
+	t.Run("repository migrations parse", func(t *testing.T) {
+		message, err := CheckMigrationsDir("../../migrations")
+		require.NoError(t, err)
+		assert.Contains(t, message, "latest version")
+	})
+
+	t.Run("valid directory", func(t *testing.T) {
+		dir := t.TempDir()
+		write(t, dir, "1_init.up.sql", "1_init.down.sql", "2_next.up.sql", "README.md")
+
+		message, err := CheckMigrationsDir(dir)
+		require.NoError(t, err)
+		assert.Equal(t, "2 migrations, latest version 2", message)
+	})
+
+	t.Run("missing directory", func(t *testing.T) {
+		_, err := CheckMigrationsDir(filepath.Join(t.TempDir(), "missing"))
+		assert.ErrorContains(t, err, "failed to read migrations directory")
+	})
+
+	t.Run("not configured", func(t *testing.T) {
+		_, err := CheckMigrationsDir("")
+		assert.ErrorContains(t, err, "not configured")
+	})
+
+	t.Run("empty directory", func(t *testing.T) {
+		_, err := CheckMigrationsDir(t.TempDir())
+		assert.ErrorContains(t, err, "no migrations found")
+	})
+
+	t.Run("invalid and duplicate files", func(t *testing.T) {
+		dir := t.TempDir()
+		write(t, dir, "1_init.up.sql", "1_again.up.sql", "init.sql", "3_orphan.down.sql")
+
+		_, err := CheckMigrationsDir(dir)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "duplicate up migration for version 1")
+		assert.Contains(t, err.Error(), "init.sql: invalid migration file name")
+		assert.Contains(t, err.Error(), "no up migration for version 3")
+	})
+}
@@ -0,0 +1,168 @@
+package contextutil
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// KeyTrustedProxies is the context key holding the trusted proxy networks
+const KeyTrustedProxies = "trusted_proxies"
+
+// TrustedProxies is the set of networks whose forwarding headers are believed
+type TrustedProxies struct {
+	prefixes []netip.Prefix
+}
+
+// ParseTrustedProxies parses CIDRs or bare IP addresses into a trusted proxy set
+func ParseTrustedProxies(entries []string) (*TrustedProxies, error) {
+	tp := &TrustedProxies{}
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if strings.Contains(entry, "/") {
+			prefix, err := netip.ParsePrefix(entry)
+			if err != nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q: %w", entry, err)
+			}
+			tp.prefixes = append(tp.prefixes, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", entry, err)
+		}
+		addr = addr.Unmap()
+		tp.prefixes = append(tp.prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return tp, nil
+}
+
+// Contains checks if ip belongs to a trusted proxy network
+func (tp *TrustedProxies) Contains(ip string) bool {
+	if tp == nil {
+		return false
+	}
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range tp.prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// SetTrustedProxies stores the trusted proxy set in context
+func SetTrustedProxies(c *gin.Context, tp *TrustedProxies) {
+	c.Set(KeyTrustedProxies, tp)
+}
+
+// ClientIP resolves the originating client address.
+// Forwarding headers (RFC 7239 Forwarded, then X-Forwarded-For, then X-Real-IP)
+// are only honoured when the immediate peer is a trusted proxy; the chain is
+// walked from the nearest hop and the first untrusted address is the client.
+// Without trusted proxies the socket address is returned, so spoofed headers
+// from direct clients are ignored.
+func ClientIP(c *gin.Context) string {
+	peer := remoteIP(c)
+	tp, _ := c.Value(KeyTrustedProxies).(*TrustedProxies)
+	if peer == "" || !tp.Contains(peer) {
+		return peer
+	}
+
+	if hops := forwardedFor(c.GetHeader("Forwarded")); len(hops) > 0 {
+		return walkHops(tp, hops, peer)
+	}
+	if hops := splitForwardedFor(c.GetHeader("X-Forwarded-For")); len(hops) > 0 {
+		return walkHops(tp, hops, peer)
+	}
+	if ip := normalizeIP(c.GetHeader("X-Real-IP")); ip != "" {
+		return ip
+	}
+	return peer
+}
+
+// walkHops returns the nearest untrusted address in a forwarding chain ordered client first.
+// A malformed hop stops the walk, since anything before it may have been forged.
+func walkHops(tp *TrustedProxies, hops []string, peer string) string {
+	client := peer
+	for i := len(hops) - 1; i >= 0; i-- {
+		ip := normalizeIP(hops[i])
+		if ip == "" {
+			return client
+		}
+		client = ip
+		if !tp.Contains(ip) {
+			return ip
+		}
+	}
+	return client
+}
+
+// remoteIP returns the socket peer address without port
+func remoteIP(c *gin.Context) string {
+	if c.Request == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(strings.TrimSpace(c.Request.RemoteAddr))
+	if err != nil {
+		return normalizeIP(c.Request.RemoteAddr)
+	}
+	return normalizeIP(host)
+}
+
+func splitForwardedFor(header string) []string {
+	if header == "" {
+		return nil
+	}
+	return strings.Split(header, ",")
+}
+
+// forwardedFor extracts the for= parameters of an RFC 7239 Forwarded header in order
+func forwardedFor(header string) []string {
+	if header == "" {
+		return nil
+	}
+	var hops []string
+	for _, element := range strings.Split(header, ",") {
+		for _, pair := range strings.Split(element, ";") {
+			key, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+			if ok && strings.EqualFold(key, "for") {
+				hops = append(hops, value)
+			}
+		}
+	}
+	return hops
+}
+
+// normalizeIP strips quotes, IPv6 brackets and ports, returning "" for anything that is not an IP
+// (including RFC 7239 obfuscated identifiers such as "unknown" or "_hidden")
+func normalizeIP(value string) string {
+	value = strings.Trim(strings.TrimSpace(value), `"`)
+	if value == "" {
+		return ""
+	}
+	if addr, err := netip.ParseAddr(value); err == nil {
+		return addr.Unmap().String()
+	}
+	if host, _, err := net.SplitHostPort(value); err == nil {
+		if addr, err := netip.ParseAddr(host); err == nil {
+			return addr.Unmap().String()
+		}
+	}
+	if strings.HasPrefix(value, "[") && strings.HasSuffix(value, "]") {
+		if addr, err := netip.ParseAddr(value[1 : len(value)-1]); err == nil {
+			return addr.Unmap().String()
+		}
+	}
+	return ""
+}
@@ -0,0 +1,131 @@
+package contextutil
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTrustedProxies(t *testing.T) {
+	tp, err := ParseTrustedProxies([]string{"10.0.0.0/8", " 192.168.1.10 ", "", "2001:db8::/32"})
+	require.NoError(t, err)
+
+	assert.True(t, tp.Contains("10.1.2.3"))
+	assert.True(t, tp.Contains("192.168.1.10"))
+	assert.False(t, tp.Contains("192.168.1.11"))
+	assert.True(t, tp.Contains("2001:db8::1"))
+	assert.True(t, tp.Contains("::ffff:10.0.0.1"))
+	assert.False(t, tp.Contains("not-an-ip"))
+
+	_, err = ParseTrustedProxies([]string{"10.0.0.0/33"})
+	assert.Error(t, err)
+	_, err = ParseTrustedProxies([]string{"proxy.internal"})
+	assert.Error(t, err)
+
+	var none *TrustedProxies
+	assert.False(t, none.Contains("10.0.0.1"))
+}
+
+func TestClientIP(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	trusted, err := ParseTrustedProxies([]string{"10.0.0.0/8", "172.16.0.1"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		trusted    *TrustedProxies
+		remoteAddr string
+		headers    map[string]string
+		expected   string
+	}{
+		{
+			name:       "no trusted proxies ignores headers",
+			remoteAddr: "10.0.0.5:4000",
+			headers:    map[string]string{"X-Forwarded-For": "198.51.100.1"},
+			expected:   "10.0.0.5",
+		},
+		{
+			name:       "spoofed X-Forwarded-For from untrusted peer",
+			trusted:    trusted,
+			remoteAddr: "203.0.113.9:4000",
+			headers: map[string]string{
+				"X-Forwarded-For": "1.2.3.4",
+				"X-Real-IP":       "1.2.3.4",
+				"Forwarded":       "for=1.2.3.4",
+			},
+			expected: "203.0.113.9",
+		},
+		{
+			name:       "two-hop proxy chain",
+			trusted:    trusted,
+			remoteAddr: "10.0.0.2:4000",
+			headers:    map[string]string{"X-Forwarded-For": "198.51.100.7, 172.16.0.1"},
+			expected:   "198.51.100.7",
+		},
+		{
+			name:       "client-supplied prefix is not trusted",
+			trusted:    trusted,
+			remoteAddr: "10.0.0.2:4000",
+			headers:    map[string]string{"X-Forwarded-For": "1.2.3.4, 198.51.100.7, 172.16.0.1"},
+			expected:   "198.51.100.7",
+		},
+		{
+			name:       "chain of only trusted hops returns the furthest",
+			trusted:    trusted,
+			remoteAddr: "10.0.0.2:4000",
+			headers:    map[string]string{"X-Forwarded-For": "10.0.0.9, 172.16.0.1"},
+			expected:   "10.0.0.9",
+		},
+		{
+			name:       "malformed hop stops the walk",
+			trusted:    trusted,
+			remoteAddr: "10.0.0.2:4000",
+			headers:    map[string]string{"X-Forwarded-For": "198.51.100.7, garbage"},
+			expected:   "10.0.0.2",
+		},
+		{
+			name:       "RFC 7239 Forwarded takes precedence",
+			trusted:    trusted,
+			remoteAddr: "10.0.0.2:4000",
+			headers: map[string]string{
+				"Forwarded":       `for="[2001:db8::7]:4711";proto=https, for=172.16.0.1`,
+				"X-Forwarded-For": "198.51.100.7",
+			},
+			expected: "2001:db8::7",
+		},
+		{
+			name:       "X-Real-IP from trusted peer",
+			trusted:    trusted,
+			remoteAddr: "10.0.0.2:4000",
+			headers:    map[string]string{"X-Real-IP": "198.51.100.8"},
+			expected:   "198.51.100.8",
+		},
+		{
+			name:       "trusted peer without forwarding headers",
+			trusted:    trusted,
+			remoteAddr: "10.0.0.2:4000",
+			expected:   "10.0.0.2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+			c.Request.RemoteAddr = tt.remoteAddr
+			for k, v := range tt.headers {
+				c.Request.Header.Set(k, v)
+			}
+			if tt.trusted != nil {
+				SetTrustedProxies(c, tt.trusted)
+			}
+
+			assert.Equal(t, tt.expected, ClientIP(c))
+		})
+	}
+}
@@ -2,7 +2,9 @@
 package contextutil
 
 import (
+	"context"
 	"fmt"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 
@@ -67,13 +69,60 @@ func IsAuthenticated(c *gin.Context) bool {
 	return GetUser(c) != nil
 }
 
-// CanAccessUser checks if authenticated user can access target user
-func CanAccessUser(c *gin.Context, targetUserID uint) bool {
-	if IsAdmin(c) {
+// RoleAdmin is the role that overrides ownership checks
+const RoleAdmin = "admin"
+
+// AccessOption adjusts the rules applied by CanAccessUser
+type AccessOption func(*accessRules)
+
+type accessRules struct {
+	selfOnly   bool
+	permission string
+}
+
+// SelfOnly disables the admin override, for destructive actions a user may only perform on themselves
+func SelfOnly() AccessOption {
+	return func(r *accessRules) {
+		r.selfOnly = true
+	}
+}
+
+// AllowPermission also lets holders of permission act on other users, e.g. a support role with users:read
+func AllowPermission(permission string) AccessOption {
+	return func(r *accessRules) {
+		r.permission = permission
+	}
+}
+
+// CanAccessUser checks if the authenticated user may act on targetUserID:
+// the user themselves, or an admin (or a holder of the AllowPermission permission) unless SelfOnly is given
+func CanAccessUser(c *gin.Context, targetUserID uint, opts ...AccessOption) bool {
+	var rules accessRules
+	for _, opt := range opts {
+		opt(&rules)
+	}
+
+	if rules.selfOnly {
+		return RequireOwnershipOrRole(c, targetUserID)
+	}
+	if RequireOwnershipOrRole(c, targetUserID, RoleAdmin) {
 		return true
 	}
-	authenticatedUserID := GetUserID(c)
-	return authenticatedUserID == targetUserID
+	return rules.permission != "" && HasPermission(c, rules.permission)
+}
+
+// RequireOwnershipOrRole checks if the authenticated user owns a resource
+// (ownerID is their ID) or holds any of roles. Unauthenticated requests and
+// unowned resources (ownerID 0) are never considered owned.
+func RequireOwnershipOrRole(c *gin.Context, ownerID uint, roles ...string) bool {
+	userID := GetUserID(c)
+	if userID == 0 {
+		return false
+	}
+	if ownerID != 0 && userID == ownerID {
+		return true
+	}
+	return HasAnyRole(c, roles...)
 }
 
 // GetUserName retrieves the authenticated user's name from context
@@ -99,6 +148,16 @@ func HasRole(c *gin.Context, role string) bool {
 	return false
 }
 
+// HasAnyRole checks if user has at least one of roles
+func HasAnyRole(c *gin.Context, roles ...string) bool {
+	for _, role := range roles {
+		if HasRole(c, role) {
+			return true
+		}
+	}
+	return false
+}
+
 // GetRoles retrieves user roles from context
 func GetRoles(c *gin.Context) []string {
 	claims := GetUser(c)
@@ -108,7 +167,120 @@ func GetRoles(c *gin.Context) []string {
 	return claims.Roles
 }
 
+// PermissionAll grants every permission
+const PermissionAll = "admin:*"
+
+// HasPermission checks if user has a specific permission.
+// A granted "resource:*" covers every action on resource, and PermissionAll covers everything.
+// Admins implicitly hold every permission.
+func HasPermission(c *gin.Context, permission string) bool {
+	claims := GetUser(c)
+	if claims == nil {
+		return false
+	}
+	// WHY: Tokens issued before permissions were added to the claims carry only role names
+	if IsAdmin(c) {
+		return true
+	}
+	for _, p := range claims.Permissions {
+		if permissionGrants(p, permission) {
+			return true
+		}
+	}
+	return false
+}
+
+// permissionGrants reports whether the granted permission covers permission
+func permissionGrants(granted, permission string) bool {
+	if granted == permission || granted == PermissionAll {
+		return true
+	}
+	resource, action, ok := strings.Cut(granted, ":")
+	return ok && action == "*" && strings.HasPrefix(permission, resource+":")
+}
+
+// GetPermissions retrieves user permissions from context
+func GetPermissions(c *gin.Context) []string {
+	claims := GetUser(c)
+	if claims == nil {
+		return []string{}
+	}
+	return claims.Permissions
+}
+
+// GetImpersonatorID retrieves the ID of the admin impersonating the user
+// Returns 0 if the request is not an impersonation session
+func GetImpersonatorID(c *gin.Context) uint {
+	claims := GetUser(c)
+	if claims == nil {
+		return 0
+	}
+	return claims.ImpersonatorID
+}
+
+// IsImpersonating checks if the request was made with an impersonation token
+func IsImpersonating(c *gin.Context) bool {
+	return GetImpersonatorID(c) != 0
+}
+
 // IsAdmin checks if user has admin role
 func IsAdmin(c *gin.Context) bool {
-	return HasRole(c, "admin")
+	return HasRole(c, RoleAdmin)
+}
+
+// API versions understood by the router
+const (
+	APIVersionV1 = "v1"
+	APIVersionV2 = "v2"
+)
+
+// KeyAPIVersion is the context key holding the resolved API version
+const KeyAPIVersion = "api_version"
+
+// SupportedAPIVersions lists every version the router serves, oldest first
+var SupportedAPIVersions = []string{APIVersionV1, APIVersionV2}
+
+// SetAPIVersion stores the resolved API version in context
+func SetAPIVersion(c *gin.Context, version string) {
+	c.Set(KeyAPIVersion, version)
+}
+
+// GetAPIVersion retrieves the resolved API version from context
+// Returns v1 if no version was resolved
+func GetAPIVersion(c *gin.Context) string {
+	if version := c.GetString(KeyAPIVersion); version != "" {
+		return version
+	}
+	return APIVersionV1
+}
+
+// IsAPIVersion checks if the request resolved to the given API version
+func IsAPIVersion(c *gin.Context, version string) bool {
+	return GetAPIVersion(c) == version
+}
+
+// IsSupportedAPIVersion checks if the version is served by the router
+func IsSupportedAPIVersion(version string) bool {
+	for _, v := range SupportedAPIVersions {
+		if v == version {
+			return true
+		}
+	}
+	return false
+}
+
+// requestIDKey is the context.Context key for the request ID
+type requestIDKey struct{}
+
+// WithRequestID returns a copy of ctx carrying the request ID
+// WHY: Services and outbound clients only receive context.Context, not the gin context
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestIDFromContext retrieves the request ID stored by WithRequestID
+// Returns an empty string if not found
+func RequestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
 }
@@ -1,6 +1,7 @@
 package contextutil
 
 import (
+	"context"
 	"net/http/httptest"
 	"testing"
 
@@ -291,6 +292,7 @@ func TestCanAccessUser(t *testing.T) {
 		name         string
 		setup        func(*gin.Context)
 		targetUserID uint
+		opts         []AccessOption
 		expected     bool
 	}{
 		{
@@ -325,6 +327,50 @@ func TestCanAccessUser(t *testing.T) {
 			targetUserID: 1,
 			expected:     false,
 		},
+		{
+			name: "admin can access other user",
+			setup: func(c *gin.Context) {
+				c.Set(auth.KeyUser, &auth.Claims{UserID: 1, Roles: []string{RoleAdmin}})
+			},
+			targetUserID: 2,
+			expected:     true,
+		},
+		{
+			name: "allowed permission can access other user",
+			setup: func(c *gin.Context) {
+				c.Set(auth.KeyUser, &auth.Claims{UserID: 1, Roles: []string{"support"}, Permissions: []string{"users:read"}})
+			},
+			targetUserID: 2,
+			opts:         []AccessOption{AllowPermission("users:read")},
+			expected:     true,
+		},
+		{
+			name: "other permission cannot access other user",
+			setup: func(c *gin.Context) {
+				c.Set(auth.KeyUser, &auth.Claims{UserID: 1, Roles: []string{"support"}, Permissions: []string{"users:read"}})
+			},
+			targetUserID: 2,
+			opts:         []AccessOption{AllowPermission("users:delete")},
+			expected:     false,
+		},
+		{
+			name: "self-only denies admin override",
+			setup: func(c *gin.Context) {
+				c.Set(auth.KeyUser, &auth.Claims{UserID: 1, Roles: []string{RoleAdmin}})
+			},
+			targetUserID: 2,
+			opts:         []AccessOption{SelfOnly()},
+			expected:     false,
+		},
+		{
+			name: "self-only allows own user",
+			setup: func(c *gin.Context) {
+				c.Set(auth.KeyUser, &auth.Claims{UserID: 1})
+			},
+			targetUserID: 1,
+			opts:         []AccessOption{SelfOnly()},
+			expected:     true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -334,12 +380,49 @@ func TestCanAccessUser(t *testing.T) {
 
 			tt.setup(c)
 
-			result := CanAccessUser(c, tt.targetUserID)
+			result := CanAccessUser(c, tt.targetUserID, tt.opts...)
 			assert.Equal(t, tt.expected, result)
 		})
 	}
 }
 
+func TestRequireOwnershipOrRole(t *testing.T) {
+	tests := []struct {
+		name     string
+		claims   *auth.Claims
+		ownerID  uint
+		roles    []string
+		expected bool
+	}{
+		{name: "owner", claims: &auth.Claims{UserID: 1}, ownerID: 1, expected: true},
+		{name: "not owner without roles", claims: &auth.Claims{UserID: 1}, ownerID: 2, expected: false},
+		{name: "not owner with matching role", claims: &auth.Claims{UserID: 1, Roles: []string{"moderator"}}, ownerID: 2, roles: []string{RoleAdmin, "moderator"}, expected: true},
+		{name: "not owner with other role", claims: &auth.Claims{UserID: 1, Roles: []string{"user"}}, ownerID: 2, roles: []string{RoleAdmin}, expected: false},
+		{name: "unowned resource", claims: &auth.Claims{UserID: 1}, ownerID: 0, expected: false},
+		{name: "unauthenticated", claims: nil, ownerID: 0, roles: []string{RoleAdmin}, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			if tt.claims != nil {
+				c.Set(auth.KeyUser, tt.claims)
+			}
+
+			assert.Equal(t, tt.expected, RequireOwnershipOrRole(c, tt.ownerID, tt.roles...))
+		})
+	}
+}
+
+func TestHasAnyRole(t *testing.T) {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Set(auth.KeyUser, &auth.Claims{UserID: 1, Roles: []string{"user", "editor"}})
+
+	assert.True(t, HasAnyRole(c, RoleAdmin, "editor"))
+	assert.False(t, HasAnyRole(c, RoleAdmin))
+	assert.False(t, HasAnyRole(c))
+}
+
 func TestGetUserName(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -571,3 +654,92 @@ func TestIsAdmin(t *testing.T) {
 		})
 	}
 }
+
+func TestGetAPIVersion(t *testing.T) {
+	tests := []struct {
+		name     string
+		setup    func(*gin.Context)
+		expected string
+	}{
+		{
+			name:     "defaults to v1",
+			setup:    func(c *gin.Context) {},
+			expected: APIVersionV1,
+		},
+		{
+			name: "resolved v2",
+			setup: func(c *gin.Context) {
+				SetAPIVersion(c, APIVersionV2)
+			},
+			expected: APIVersionV2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+
+			tt.setup(c)
+
+			assert.Equal(t, tt.expected, GetAPIVersion(c))
+			assert.True(t, IsAPIVersion(c, tt.expected))
+		})
+	}
+}
+
+func TestIsSupportedAPIVersion(t *testing.T) {
+	assert.True(t, IsSupportedAPIVersion(APIVersionV1))
+	assert.True(t, IsSupportedAPIVersion(APIVersionV2))
+	assert.False(t, IsSupportedAPIVersion("v3"))
+	assert.False(t, IsSupportedAPIVersion(""))
+}
+
+func TestRequestIDFromContext(t *testing.T) {
+	assert.Empty(t, RequestIDFromContext(context.Background()))
+
+	ctx := WithRequestID(context.Background(), "req-123")
+	assert.Equal(t, "req-123", RequestIDFromContext(ctx))
+}
+
+func TestGetImpersonatorID(t *testing.T) {
+	tests := []struct {
+		name          string
+		setup         func(*gin.Context)
+		expected      uint
+		impersonating bool
+	}{
+		{
+			name: "impersonation session",
+			setup: func(c *gin.Context) {
+				c.Set(auth.KeyUser, &auth.Claims{UserID: 1, ImpersonatorID: 2})
+			},
+			expected:      2,
+			impersonating: true,
+		},
+		{
+			name: "regular session",
+			setup: func(c *gin.Context) {
+				c.Set(auth.KeyUser, &auth.Claims{UserID: 1})
+			},
+			expected: 0,
+		},
+		{
+			name:     "unauthenticated",
+			setup:    func(c *gin.Context) {},
+			expected: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+
+			tt.setup(c)
+
+			assert.Equal(t, tt.expected, GetImpersonatorID(c))
+			assert.Equal(t, tt.impersonating, IsImpersonating(c))
+		})
+	}
+}
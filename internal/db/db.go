@@ -78,13 +78,42 @@ func NewPostgresDB(cfg Config) (*gorm.DB, error) {
 	return db, nil
 }
 
-// NewPostgresDBFromDatabaseConfig creates a new PostgreSQL DB connection from typed config
+// NewPostgresDBFromDatabaseConfig creates a new PostgreSQL DB connection from typed config,
+// retrying with backoff according to connect_max_attempts / connect_retry_timeout
 func NewPostgresDBFromDatabaseConfig(cfg config.DatabaseConfig) (*gorm.DB, error) {
+	return ConnectWithRetry(context.Background(), RetryConfigFromDatabaseConfig(cfg), func() (*gorm.DB, error) {
+		return openPostgres(cfg)
+	})
+}
+
+// openPostgres opens and pings a PostgreSQL connection pool once
+func openPostgres(cfg config.DatabaseConfig) (*gorm.DB, error) {
+	db, err := newPostgresPool(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sql.DB from gorm DB: %w", err)
+	}
+
+	// 预热连接池
+	if err := sqlDB.Ping(); err != nil {
+		_ = sqlDB.Close()
+		return nil, fmt.Errorf("failed to ping database: %w", err)
+	}
+	return db, nil
+}
+
+// newPostgresPool opens a configured PostgreSQL connection pool without connecting
+func newPostgresPool(cfg config.DatabaseConfig) (*gorm.DB, error) {
 	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%d sslmode=%s",
 		cfg.Host, cfg.User, cfg.Password, cfg.Name, cfg.Port, cfg.SSLMode)
 
 	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
-		Logger: customLogger{logger.Default.LogMode(logger.Info)},
+		Logger:               customLogger{logger.Default.LogMode(logger.Info)},
+		DisableAutomaticPing: true,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to postgres database: %w", err)
@@ -120,13 +149,8 @@ func NewPostgresDBFromDatabaseConfig(cfg config.DatabaseConfig) (*gorm.DB, error
 	}
 	sqlDB.SetConnMaxIdleTime(connMaxIdleTime)
 
-	// 预热连接池
-	if err := sqlDB.Ping(); err != nil {
-		return nil, fmt.Errorf("failed to ping database: %w", err)
-	}
-
-	log.Printf("Database connection pool configured: max_open=%d, max_idle=%d, max_lifetime=%v, max_idle_time=%v\n",
-		maxOpenConns, maxIdleConns, connMaxLifetime, connMaxIdleTime)
+	log.Printf("Database connection pool configured: host=%s, max_open=%d, max_idle=%d, max_lifetime=%v, max_idle_time=%v\n",
+		cfg.Host, maxOpenConns, maxIdleConns, connMaxLifetime, connMaxIdleTime)
 
 	return db, nil
 }
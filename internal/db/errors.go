@@ -0,0 +1,93 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"syscall"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+
+	apiErrors "github.com/yeegeek/uyou-go-api-starter/internal/errors"
+)
+
+// errDBClosed 对应 database/sql 未导出的 "sql: database is closed" 错误
+const errDBClosed = "sql: database is closed"
+
+// WrapError 将连接层错误包装为 ErrDatabaseUnavailable，保留原始错误供日志使用；其他错误原样返回
+func WrapError(err error) error {
+	if err == nil || errors.Is(err, apiErrors.ErrDatabaseUnavailable) || !IsConnectionError(err) {
+		return err
+	}
+	return fmt.Errorf("%w: %w", apiErrors.ErrDatabaseUnavailable, err)
+}
+
+// IsTransientError 判断错误是否可能在重试后成功：连接丢失、查询超时或被取消，以及 PostgreSQL 的
+// 序列化失败（40001）、死锁（40P01）、锁等待超时（55P03）和语句超时（57014）
+func IsTransientError(err error) bool {
+	if IsConnectionError(err) || errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case "40001", "40P01", "55P03", "57014":
+			return true
+		}
+	}
+	return false
+}
+
+// IsConnectionError 判断错误是否由数据库连接丢失或不可达引起，而不是查询本身的问题
+func IsConnectionError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, sql.ErrConnDone) ||
+		errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.EPIPE) {
+		return true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+
+	var connectErr *pgconn.ConnectError
+	if errors.As(err, &connectErr) {
+		return true
+	}
+
+	// 08 类为连接异常；57P01-57P03 为管理员关闭、崩溃关闭以及暂时无法连接
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return strings.HasPrefix(pgErr.Code, "08") ||
+			pgErr.Code == "57P01" || pgErr.Code == "57P02" || pgErr.Code == "57P03"
+	}
+
+	return strings.Contains(err.Error(), errDBClosed)
+}
+
+// IsUniqueViolation 判断错误是否为唯一约束冲突：PostgreSQL 的 23505、SQLite 的 UNIQUE constraint failed，
+// 以及开启 TranslateError 时 GORM 返回的 ErrDuplicatedKey
+func IsUniqueViolation(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return true
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code == "23505"
+	}
+	return strings.Contains(err.Error(), "UNIQUE constraint failed")
+}
@@ -0,0 +1,71 @@
+package db
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+
+	apiErrors "github.com/yeegeek/uyou-go-api-starter/internal/errors"
+)
+
+func TestIsConnectionError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "nil", err: nil, want: false},
+		{name: "bad connection", err: driver.ErrBadConn, want: true},
+		{name: "database closed", err: errors.New("sql: database is closed"), want: true},
+		{name: "network error", err: &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}, want: true},
+		{name: "postgres admin shutdown", err: &pgconn.PgError{Code: "57P01"}, want: true},
+		{name: "postgres connection failure", err: fmt.Errorf("query: %w", &pgconn.PgError{Code: "08006"}), want: true},
+		{name: "postgres unique violation", err: &pgconn.PgError{Code: "23505"}, want: false},
+		{name: "record not found", err: gorm.ErrRecordNotFound, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, IsConnectionError(tt.err))
+		})
+	}
+}
+
+func TestWrapError(t *testing.T) {
+	assert.NoError(t, WrapError(nil))
+	assert.Equal(t, gorm.ErrRecordNotFound, WrapError(gorm.ErrRecordNotFound))
+
+	wrapped := WrapError(driver.ErrBadConn)
+	assert.ErrorIs(t, wrapped, apiErrors.ErrDatabaseUnavailable)
+	assert.ErrorIs(t, wrapped, driver.ErrBadConn)
+
+	// 已包装的错误不会重复包装
+	assert.Equal(t, wrapped, WrapError(wrapped))
+}
+
+func TestIsUniqueViolation(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "nil", err: nil, want: false},
+		{name: "postgres unique violation", err: fmt.Errorf("create: %w", &pgconn.PgError{Code: "23505"}), want: true},
+		{name: "postgres foreign key violation", err: &pgconn.PgError{Code: "23503"}, want: false},
+		{name: "sqlite unique constraint", err: errors.New("UNIQUE constraint failed: users.email"), want: true},
+		{name: "gorm duplicated key", err: gorm.ErrDuplicatedKey, want: true},
+		{name: "other error", err: errors.New("boom"), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, IsUniqueViolation(tt.err))
+		})
+	}
+}
@@ -0,0 +1,385 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/yeegeek/uyou-go-api-starter/internal/config"
+	"github.com/yeegeek/uyou-go-api-starter/internal/health"
+	"github.com/yeegeek/uyou-go-api-starter/internal/metrics"
+)
+
+const (
+	// resolverName 读写分离插件在 gorm.Config.Plugins 中的名称
+	resolverName = "db:read_replicas"
+	// replicaPingTimeout 单次副本健康检查的超时时间
+	replicaPingTimeout = 3 * time.Second
+
+	// RoutingReplica 读请求路由到副本
+	RoutingReplica = "replica"
+	// RoutingPrimary 没有可用副本，读请求回退到主库
+	RoutingPrimary = "primary"
+)
+
+// replicaKey 在语句实例上记录本次查询使用的副本，查询结束后据此判断是否需要将副本标记为不可用
+const replicaKey = "db:read_replica"
+
+// writeCTEPattern 匹配 CTE 中的写操作
+var writeCTEPattern = regexp.MustCompile(`(?i)\b(insert|update|delete|merge)\b`)
+
+// lockingReadPattern 匹配原生 SQL 中的 FOR UPDATE / FOR SHARE 等加锁读，加锁读必须在主库执行
+var lockingReadPattern = regexp.MustCompile(`(?i)\bfor\s+(no\s+key\s+)?(update|share|key\s+share)\b`)
+
+type primaryContextKey struct{}
+
+// UsePrimary 标记 ctx 上的查询强制在主库执行，用于写入后需要立即读到最新数据的场景（副本存在复制延迟）
+func UsePrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryContextKey{}, true)
+}
+
+func usePrimary(ctx context.Context) bool {
+	if ctx == nil {
+		return false
+	}
+	v, _ := ctx.Value(primaryContextKey{}).(bool)
+	return v
+}
+
+// Replica 一个只读副本
+type Replica struct {
+	// Name 副本名称，用于日志、指标和健康检查
+	Name string
+	// DB 副本的连接，需与主库使用相同的方言
+	DB *gorm.DB
+}
+
+// ReplicaState 副本当前的健康状态
+type ReplicaState struct {
+	Name          string    `json:"name"`
+	Healthy       bool      `json:"healthy"`
+	LastError     string    `json:"last_error,omitempty"`
+	LastCheckedAt time.Time `json:"last_checked_at,omitempty"`
+}
+
+// RoutingState 读请求当前的路由状态，用于健康检查
+type RoutingState struct {
+	// Routing 为 replica 或 primary
+	Routing  string         `json:"routing"`
+	Replicas []ReplicaState `json:"replicas"`
+}
+
+type replica struct {
+	name string
+	pool gorm.ConnPool
+	// ping 检查副本是否可用，测试中可替换
+	ping func(ctx context.Context) error
+
+	healthy atomic.Bool
+
+	mu            sync.Mutex
+	lastErr       error
+	lastCheckedAt time.Time
+}
+
+// setHealth 更新副本状态，状态变化时记录日志并更新指标
+func (r *replica) setHealth(err error) {
+	r.mu.Lock()
+	r.lastErr = err
+	r.lastCheckedAt = time.Now()
+	r.mu.Unlock()
+
+	healthy := err == nil
+	if r.healthy.Swap(healthy) == healthy {
+		return
+	}
+	metrics.SetDBReplicaHealthy(r.name, healthy)
+	if healthy {
+		log.Printf("Database replica %s recovered, routing reads to it again\n", r.name)
+	} else {
+		log.Printf("Database replica %s is unavailable, routing its reads to the primary: %v\n", r.name, err)
+	}
+}
+
+func (r *replica) state() ReplicaState {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	state := ReplicaState{Name: r.name, Healthy: r.healthy.Load(), LastCheckedAt: r.lastCheckedAt}
+	if r.lastErr != nil {
+		state.LastError = r.lastErr.Error()
+	}
+	return state
+}
+
+// Resolver 读写分离插件：事务外的查询按轮询路由到健康的副本，写操作、事务、加锁读和 UsePrimary 标记的查询留在主库
+//
+// 副本采用惰性故障转移：查询因连接错误失败或定期 ping 失败时副本被标记为不可用，其读请求回退到主库，
+// 直到后续 ping 成功后恢复。副本全部不可用时读请求全部由主库承担。
+type Resolver struct {
+	replicas []*replica
+	interval time.Duration
+	next     atomic.Uint64
+
+	started   atomic.Bool
+	startOnce sync.Once
+	stopOnce  sync.Once
+	stopCh    chan struct{}
+	done      chan struct{}
+}
+
+// NewResolver 创建读写分离插件，interval 为定期健康检查的间隔；副本初始视为可用，需通过 gorm.DB.Use 注册
+func NewResolver(interval time.Duration, replicas ...Replica) *Resolver {
+	r := &Resolver{
+		interval: interval,
+		stopCh:   make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	for _, rep := range replicas {
+		sqlDB, err := rep.DB.DB()
+		if err != nil {
+			// 非 *sql.DB 连接（如已处于事务中）无法作为副本
+			panic(fmt.Sprintf("db: replica %s has no connection pool: %v", rep.Name, err))
+		}
+		item := &replica{name: rep.Name, pool: sqlDB, ping: sqlDB.PingContext}
+		item.healthy.Store(true)
+		metrics.SetDBReplicaHealthy(rep.Name, true)
+		r.replicas = append(r.replicas, item)
+	}
+	return r
+}
+
+// NewResolverFromDatabaseConfig 连接配置中的全部副本并创建读写分离插件
+// 连接时不 ping 副本，启动时副本不可用不影响服务启动，由 Start 后的健康检查决定是否路由
+func NewResolverFromDatabaseConfig(cfg config.DatabaseConfig) (*Resolver, error) {
+	replicas := make([]Replica, 0, len(cfg.Replicas))
+	for i := range cfg.Replicas {
+		name := cfg.ReplicaName(i)
+		db, err := newPostgresPool(cfg.ReplicaDatabaseConfig(i))
+		if err != nil {
+			for _, opened := range replicas {
+				if sqlDB, err := opened.DB.DB(); err == nil {
+					_ = sqlDB.Close()
+				}
+			}
+			return nil, fmt.Errorf("replica %s: %w", name, err)
+		}
+		replicas = append(replicas, Replica{Name: name, DB: db})
+	}
+	return NewResolver(cfg.GetReplicaHealthInterval(), replicas...), nil
+}
+
+// ResolverFrom 返回 db 上注册的读写分离插件，未注册时返回 nil
+func ResolverFrom(db *gorm.DB) *Resolver {
+	if db == nil || db.Config == nil {
+		return nil
+	}
+	resolver, _ := db.Config.Plugins[resolverName].(*Resolver)
+	return resolver
+}
+
+// Name 实现 gorm.Plugin
+func (r *Resolver) Name() string {
+	return resolverName
+}
+
+// Initialize 实现 gorm.Plugin，在查询回调前切换连接、查询结束后检查副本连接错误
+func (r *Resolver) Initialize(db *gorm.DB) error {
+	if err := db.Callback().Query().Before("gorm:query").Register("db:route_read_query", r.route); err != nil {
+		return err
+	}
+	if err := db.Callback().Query().After("gorm:query").Register("db:observe_read_query", r.observe); err != nil {
+		return err
+	}
+	if err := db.Callback().Row().Before("gorm:row").Register("db:route_read_row", r.route); err != nil {
+		return err
+	}
+	return db.Callback().Row().After("gorm:row").Register("db:observe_read_row", r.observe)
+}
+
+// route 将可以在副本执行的读请求切换到副本连接
+func (r *Resolver) route(db *gorm.DB) {
+	if db.Error != nil || !r.readable(db.Statement) {
+		return
+	}
+	rep := r.pick()
+	if rep == nil {
+		return
+	}
+	db.Statement.ConnPool = rep.pool
+	db.InstanceSet(replicaKey, rep)
+}
+
+// observe 副本查询因连接错误失败时立即标记副本不可用，后续读请求不再等待该副本
+func (r *Resolver) observe(db *gorm.DB) {
+	v, ok := db.InstanceGet(replicaKey)
+	if !ok {
+		return
+	}
+	if rep, ok := v.(*replica); ok && IsConnectionError(db.Error) {
+		rep.setHealth(db.Error)
+	}
+}
+
+// readable 判断语句是否可以在副本执行
+func (r *Resolver) readable(stmt *gorm.Statement) bool {
+	if _, inTx := stmt.ConnPool.(gorm.TxCommitter); inTx {
+		return false
+	}
+	if usePrimary(stmt.Context) {
+		return false
+	}
+	if _, locking := stmt.Clauses["FOR"]; locking {
+		return false
+	}
+	// 原生 SQL（Raw）在回调前已生成，只允许只读语句
+	if stmt.SQL.Len() > 0 {
+		sql := stmt.SQL.String()
+		if !isReadStatement(sql) || lockingReadPattern.MatchString(sql) {
+			return false
+		}
+	}
+	return true
+}
+
+// isReadStatement 判断原生 SQL 是否只读；包含写操作的 CTE（如 WITH x AS (DELETE ...)）留在主库
+func isReadStatement(sql string) bool {
+	fields := strings.Fields(sql)
+	if len(fields) == 0 {
+		return false
+	}
+	switch strings.ToUpper(fields[0]) {
+	case "SELECT":
+		return true
+	case "WITH":
+		return !writeCTEPattern.MatchString(sql)
+	}
+	return false
+}
+
+// pick 按轮询从健康的副本中选择一个，没有健康副本时返回 nil
+func (r *Resolver) pick() *replica {
+	n := len(r.replicas)
+	if n == 0 {
+		return nil
+	}
+	start := int(r.next.Add(1) % uint64(n))
+	for i := 0; i < n; i++ {
+		if rep := r.replicas[(start+i)%n]; rep.healthy.Load() {
+			return rep
+		}
+	}
+	return nil
+}
+
+// CheckNow 立即 ping 全部副本并更新健康状态
+func (r *Resolver) CheckNow(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, rep := range r.replicas {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			pingCtx, cancel := context.WithTimeout(ctx, replicaPingTimeout)
+			defer cancel()
+			rep.setHealth(rep.ping(pingCtx))
+		}()
+	}
+	wg.Wait()
+}
+
+// Start 立即检查一次副本，然后按间隔定期检查，重复调用无效
+func (r *Resolver) Start() {
+	r.startOnce.Do(func() {
+		r.started.Store(true)
+		r.CheckNow(context.Background())
+		go r.loop()
+	})
+}
+
+func (r *Resolver) loop() {
+	defer close(r.done)
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.stopCh:
+			return
+		case <-ticker.C:
+			r.CheckNow(context.Background())
+		}
+	}
+}
+
+// Stop 停止定期检查并关闭副本连接
+func (r *Resolver) Stop() {
+	r.stopOnce.Do(func() {
+		close(r.stopCh)
+		if r.started.Load() {
+			<-r.done
+		}
+		for _, rep := range r.replicas {
+			if closer, ok := rep.pool.(interface{ Close() error }); ok {
+				_ = closer.Close()
+			}
+		}
+	})
+}
+
+// States 返回全部副本的健康状态
+func (r *Resolver) States() []ReplicaState {
+	states := make([]ReplicaState, 0, len(r.replicas))
+	for _, rep := range r.replicas {
+		states = append(states, rep.state())
+	}
+	return states
+}
+
+// Routing 返回读请求当前的路由状态：至少一个副本可用时为 replica，否则为 primary
+func (r *Resolver) Routing() RoutingState {
+	state := RoutingState{Routing: RoutingPrimary, Replicas: r.States()}
+	for _, rep := range state.Replicas {
+		if rep.Healthy {
+			state.Routing = RoutingReplica
+			break
+		}
+	}
+	return state
+}
+
+type resolverChecker struct {
+	resolver *Resolver
+}
+
+func (c *resolverChecker) Name() string {
+	return "database_replicas"
+}
+
+// Check 副本不可用时读请求仍由主库承担，因此只报告 warn，不影响就绪状态
+func (c *resolverChecker) Check(_ context.Context) health.CheckResult {
+	routing := c.resolver.Routing()
+	var down []string
+	for _, rep := range routing.Replicas {
+		if !rep.Healthy {
+			down = append(down, rep.Name)
+		}
+	}
+	switch {
+	case len(down) == 0:
+		return health.CheckResult{Status: health.CheckPass, Message: "Reads are routed to replicas", Details: routing}
+	case routing.Routing == RoutingPrimary:
+		return health.CheckResult{Status: health.CheckWarn, Message: "All replicas are unavailable, reads are routed to the primary", Details: routing}
+	default:
+		return health.CheckResult{Status: health.CheckWarn, Message: "Replicas unavailable: " + strings.Join(down, ", "), Details: routing}
+	}
+}
+
+// HealthChecker 返回副本的健康检查，可与其他 health.Checker 一起使用
+func (r *Resolver) HealthChecker() health.Checker {
+	return &resolverChecker{resolver: r}
+}
@@ -0,0 +1,187 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/yeegeek/uyou-go-api-starter/internal/health"
+)
+
+// origin 主库和副本中各有一行不同的数据，查询结果即可说明语句在哪个库执行
+type origin struct {
+	ID   uint `gorm:"primaryKey"`
+	Name string
+}
+
+func newOriginDB(t *testing.T, name string) *gorm.DB {
+	t.Helper()
+	db, err := NewSQLiteDB(filepath.Join(t.TempDir(), name+".db"))
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&origin{}))
+	require.NoError(t, db.Create(&origin{ID: 1, Name: name}).Error)
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			_ = sqlDB.Close()
+		}
+	})
+	return db
+}
+
+// newResolverDB 返回注册了一个副本的主库连接和插件
+func newResolverDB(t *testing.T) (*gorm.DB, *Resolver) {
+	t.Helper()
+	primary := newOriginDB(t, "primary")
+	resolver := NewResolver(time.Hour, Replica{Name: "replica-1", DB: newOriginDB(t, "replica")})
+	require.NoError(t, primary.Use(resolver))
+	return primary, resolver
+}
+
+func servedBy(t *testing.T, db *gorm.DB) string {
+	t.Helper()
+	var row origin
+	require.NoError(t, db.First(&row, 1).Error)
+	return row.Name
+}
+
+func TestResolver_RoutesReads(t *testing.T) {
+	primary, resolver := newResolverDB(t)
+	ctx := context.Background()
+
+	assert.Equal(t, "replica", servedBy(t, primary), "读请求走副本")
+	assert.Same(t, resolver, ResolverFrom(primary))
+
+	var name string
+	require.NoError(t, primary.Raw("SELECT name FROM origins WHERE id = ?", 1).Scan(&name).Error)
+	assert.Equal(t, "replica", name, "原生只读 SQL 走副本")
+
+	var count int64
+	require.NoError(t, primary.Model(&origin{}).Where("name = ?", "replica").Count(&count).Error)
+	assert.Equal(t, int64(1), count)
+
+	assert.Equal(t, "primary", servedBy(t, primary.WithContext(UsePrimary(ctx))), "UsePrimary 强制走主库")
+
+	require.NoError(t, primary.Transaction(func(tx *gorm.DB) error {
+		assert.Equal(t, "primary", servedBy(t, tx), "事务内的读请求走主库")
+		return nil
+	}))
+
+	// SQLite 不支持 FOR UPDATE，只检查路由判断
+	locking := primary.Session(&gorm.Session{DryRun: true}).Clauses(clause.Locking{Strength: "UPDATE"}).Find(&[]origin{})
+	assert.False(t, resolver.readable(locking.Statement), "加锁读走主库")
+
+	require.NoError(t, primary.Create(&origin{ID: 2, Name: "written"}).Error)
+	var written origin
+	require.NoError(t, primary.WithContext(UsePrimary(ctx)).First(&written, 2).Error, "写请求走主库")
+	assert.ErrorIs(t, primary.First(&origin{}, 2).Error, gorm.ErrRecordNotFound, "副本中没有写入的数据")
+}
+
+func TestResolver_FailoverAndRecovery(t *testing.T) {
+	primary, resolver := newResolverDB(t)
+	ctx := context.Background()
+	rep := resolver.replicas[0]
+
+	rep.ping = func(context.Context) error { return errors.New("connection refused") }
+	resolver.CheckNow(ctx)
+
+	assert.Equal(t, "primary", servedBy(t, primary), "副本不可用时读请求回退到主库")
+	routing := resolver.Routing()
+	assert.Equal(t, RoutingPrimary, routing.Routing)
+	require.Len(t, routing.Replicas, 1)
+	assert.False(t, routing.Replicas[0].Healthy)
+	assert.Equal(t, "connection refused", routing.Replicas[0].LastError)
+	assert.Equal(t, health.CheckWarn, resolver.HealthChecker().Check(ctx).Status)
+
+	rep.ping = func(context.Context) error { return nil }
+	resolver.CheckNow(ctx)
+
+	assert.Equal(t, "replica", servedBy(t, primary), "副本恢复后读请求重新走副本")
+	assert.Equal(t, RoutingReplica, resolver.Routing().Routing)
+	assert.Equal(t, health.CheckPass, resolver.HealthChecker().Check(ctx).Status)
+}
+
+func TestResolver_PeriodicCheck(t *testing.T) {
+	resolver := NewResolver(10*time.Millisecond, Replica{Name: "replica-1", DB: newOriginDB(t, "replica")})
+	var down atomic.Bool
+	down.Store(true)
+	resolver.replicas[0].ping = func(context.Context) error {
+		if down.Load() {
+			return errors.New("connection refused")
+		}
+		return nil
+	}
+
+	resolver.Start()
+	defer resolver.Stop()
+	assert.Equal(t, RoutingPrimary, resolver.Routing().Routing, "Start 时立即检查一次")
+
+	down.Store(false)
+	assert.Eventually(t, func() bool {
+		return resolver.Routing().Routing == RoutingReplica
+	}, time.Second, 10*time.Millisecond, "定期检查发现副本恢复")
+}
+
+func TestResolver_QueryConnectionErrorMarksReplicaUnhealthy(t *testing.T) {
+	primary, resolver := newResolverDB(t)
+	rep := resolver.replicas[0]
+
+	// 关闭副本连接池模拟副本宕机：本次查询失败，之后的读请求不再等待该副本
+	require.NoError(t, rep.pool.(interface{ Close() error }).Close())
+	assert.Error(t, primary.First(&origin{}, 1).Error)
+	assert.False(t, rep.state().Healthy)
+
+	assert.Equal(t, "primary", servedBy(t, primary))
+}
+
+func TestResolver_RoundRobinSkipsUnhealthy(t *testing.T) {
+	resolver := NewResolver(time.Hour,
+		Replica{Name: "a", DB: newOriginDB(t, "a")},
+		Replica{Name: "b", DB: newOriginDB(t, "b")},
+	)
+
+	seen := map[string]int{}
+	for i := 0; i < 4; i++ {
+		seen[resolver.pick().name]++
+	}
+	assert.Equal(t, map[string]int{"a": 2, "b": 2}, seen)
+
+	resolver.replicas[0].setHealth(errors.New("down"))
+	for i := 0; i < 4; i++ {
+		assert.Equal(t, "b", resolver.pick().name)
+	}
+	assert.Equal(t, RoutingReplica, resolver.Routing().Routing, "仍有可用副本")
+	assert.Equal(t, health.CheckWarn, resolver.HealthChecker().Check(context.Background()).Status)
+
+	resolver.replicas[1].setHealth(errors.New("down"))
+	assert.Nil(t, resolver.pick())
+	assert.Equal(t, RoutingPrimary, resolver.Routing().Routing)
+}
+
+func TestIsReadStatement(t *testing.T) {
+	tests := []struct {
+		sql  string
+		want bool
+	}{
+		{"SELECT * FROM users", true},
+		{"  select\n1", true},
+		{"WITH recent AS (SELECT id FROM users) SELECT * FROM recent", true},
+		{"WITH gone AS (DELETE FROM users RETURNING id) SELECT * FROM gone", false},
+		{"UPDATE users SET name = 'x'", false},
+		{"INSERT INTO users (name) VALUES ('x')", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, isReadStatement(tt.sql), tt.sql)
+	}
+	assert.True(t, lockingReadPattern.MatchString("SELECT * FROM users FOR UPDATE"))
+	assert.True(t, lockingReadPattern.MatchString("select * from users for no key update"))
+	assert.False(t, lockingReadPattern.MatchString("SELECT * FROM users WHERE updated_at > now()"))
+}
@@ -0,0 +1,100 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/yeegeek/uyou-go-api-starter/internal/config"
+)
+
+const (
+	defaultInitialBackoff = 500 * time.Millisecond
+	defaultMaxBackoff     = 10 * time.Second
+)
+
+// RetryConfig 数据库启动连接的重试策略
+// MaxAttempts 和 Timeout 均为 0 时只尝试一次
+type RetryConfig struct {
+	// MaxAttempts 最大尝试次数，0 表示仅受 Timeout 限制
+	MaxAttempts int
+	// Timeout 重试总时长上限，0 表示仅受 MaxAttempts 限制
+	Timeout time.Duration
+	// InitialBackoff 首次重试前的等待时间，之后每次翻倍
+	InitialBackoff time.Duration
+	// MaxBackoff 单次等待时间上限
+	MaxBackoff time.Duration
+}
+
+// RetryConfigFromDatabaseConfig 从数据库配置构建重试策略
+func RetryConfigFromDatabaseConfig(cfg config.DatabaseConfig) RetryConfig {
+	return RetryConfig{
+		MaxAttempts: cfg.ConnectMaxAttempts,
+		Timeout:     time.Duration(cfg.ConnectRetryTimeout) * time.Second,
+	}
+}
+
+// ConnectWithRetry 使用指数退避重复调用 connect，直到成功、达到最大次数或超过总时长
+// 用于容器启动时数据库晚于应用就绪的场景
+func ConnectWithRetry(ctx context.Context, cfg RetryConfig, connect func() (*gorm.DB, error)) (*gorm.DB, error) {
+	if cfg.MaxAttempts <= 0 && cfg.Timeout <= 0 {
+		return connect()
+	}
+
+	backoff := cfg.InitialBackoff
+	if backoff <= 0 {
+		backoff = defaultInitialBackoff
+	}
+	maxBackoff := cfg.MaxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = defaultMaxBackoff
+	}
+
+	var deadline time.Time
+	if cfg.Timeout > 0 {
+		deadline = time.Now().Add(cfg.Timeout)
+	}
+
+	for attempt := 1; ; attempt++ {
+		db, err := connect()
+		if err == nil {
+			if attempt > 1 {
+				log.Printf("Database connection established after %d attempts\n", attempt)
+			}
+			return db, nil
+		}
+
+		if cfg.MaxAttempts > 0 && attempt >= cfg.MaxAttempts {
+			return nil, fmt.Errorf("database unavailable after %d attempts: %w", attempt, err)
+		}
+
+		wait := backoff
+		if !deadline.IsZero() {
+			remaining := time.Until(deadline)
+			if remaining <= 0 {
+				return nil, fmt.Errorf("database unavailable after %d attempts in %v: %w", attempt, cfg.Timeout, err)
+			}
+			if wait > remaining {
+				wait = remaining
+			}
+		}
+
+		log.Printf("Database connection attempt %d failed, retrying in %v: %v\n", attempt, wait, err)
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, fmt.Errorf("database connection retry cancelled: %w", ctx.Err())
+		case <-timer.C:
+		}
+
+		backoff *= 2
+		if backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+}
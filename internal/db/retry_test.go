@@ -0,0 +1,91 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/yeegeek/uyou-go-api-starter/internal/config"
+)
+
+// flakyConnector 前 failures 次连接失败，之后返回可用的 SQLite 连接
+type flakyConnector struct {
+	failures int
+	calls    int
+}
+
+func (f *flakyConnector) connect() (*gorm.DB, error) {
+	f.calls++
+	if f.calls <= f.failures {
+		return nil, errors.New("connection refused")
+	}
+	return NewSQLiteDB(":memory:")
+}
+
+func TestConnectWithRetry_EventuallySucceeds(t *testing.T) {
+	connector := &flakyConnector{failures: 3}
+	cfg := RetryConfig{MaxAttempts: 5, InitialBackoff: time.Millisecond, MaxBackoff: 5 * time.Millisecond}
+
+	db, err := ConnectWithRetry(context.Background(), cfg, connector.connect)
+	require.NoError(t, err)
+	require.NotNil(t, db)
+	assert.Equal(t, 4, connector.calls)
+
+	var one int
+	require.NoError(t, db.Raw("SELECT 1").Scan(&one).Error)
+	assert.Equal(t, 1, one)
+}
+
+func TestConnectWithRetry_GivesUpAfterMaxAttempts(t *testing.T) {
+	connector := &flakyConnector{failures: 10}
+	cfg := RetryConfig{MaxAttempts: 3, InitialBackoff: time.Millisecond}
+
+	db, err := ConnectWithRetry(context.Background(), cfg, connector.connect)
+	assert.Nil(t, db)
+	assert.ErrorContains(t, err, "after 3 attempts")
+	assert.ErrorContains(t, err, "connection refused")
+	assert.Equal(t, 3, connector.calls)
+}
+
+func TestConnectWithRetry_StopsAtTimeout(t *testing.T) {
+	connector := &flakyConnector{failures: 1 << 30}
+	cfg := RetryConfig{Timeout: 50 * time.Millisecond, InitialBackoff: 5 * time.Millisecond}
+
+	start := time.Now()
+	db, err := ConnectWithRetry(context.Background(), cfg, connector.connect)
+	assert.Nil(t, db)
+	assert.Error(t, err)
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Greater(t, connector.calls, 1)
+}
+
+func TestConnectWithRetry_ZeroConfigTriesOnce(t *testing.T) {
+	connector := &flakyConnector{failures: 1}
+
+	db, err := ConnectWithRetry(context.Background(), RetryConfig{}, connector.connect)
+	assert.Nil(t, db)
+	assert.EqualError(t, err, "connection refused")
+	assert.Equal(t, 1, connector.calls)
+}
+
+func TestConnectWithRetry_ContextCancelled(t *testing.T) {
+	connector := &flakyConnector{failures: 1 << 30}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	db, err := ConnectWithRetry(ctx, RetryConfig{MaxAttempts: 5, InitialBackoff: time.Hour}, connector.connect)
+	assert.Nil(t, db)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 1, connector.calls)
+}
+
+func TestRetryConfigFromDatabaseConfig(t *testing.T) {
+	cfg := RetryConfigFromDatabaseConfig(config.DatabaseConfig{ConnectMaxAttempts: 10, ConnectRetryTimeout: 60})
+	assert.Equal(t, 10, cfg.MaxAttempts)
+	assert.Equal(t, time.Minute, cfg.Timeout)
+}
@@ -0,0 +1,19 @@
+// Package errors 提供错误代码目录接口
+package errors
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CatalogHandler godoc
+// @Summary Error code catalog
+// @Description List every error code the API can emit with its default HTTP status and description
+// @Tags meta
+// @Produce json
+// @Success 200 {object} errors.Response{success=bool,data=[]errors.Definition} "Error definitions"
+// @Router /api/v1/errors [get]
+func CatalogHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, Success(Catalog()))
+}
@@ -0,0 +1,103 @@
+package errors
+
+import (
+	"encoding/json"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCatalog_ListsEveryCode(t *testing.T) {
+	codes := []string{
+		CodeInternal, CodeNotFound, CodeUnauthorized, CodeForbidden, CodeValidation, CodeConflict,
+		CodeTooManyRequests, CodeUnsupportedAPIVersion, CodeServiceUnavailable, CodeUnsupportedMediaType,
+		CodeAccountLocked, CodeAccountDisabled, CodePolicyNotAccepted, CodeRouteNotFound, CodeMethodNotAllowed,
+		CodeEmptyBody,
+	}
+
+	catalog := Catalog()
+	assert.Len(t, catalog, len(codes), "every code appears exactly once")
+	for _, code := range codes {
+		d, ok := Lookup(code)
+		if assert.True(t, ok, "code %s is not registered", code) {
+			assert.NotZero(t, d.Status)
+			assert.NotEmpty(t, d.Description)
+		}
+	}
+
+	_, ok := Lookup("NO_SUCH_CODE")
+	assert.False(t, ok)
+}
+
+func TestCatalog_ConstructorsUseRegisteredStatus(t *testing.T) {
+	errs := []*APIError{
+		NotFound("x"), BadRequest("x"), Conflict("x"), Forbidden("x"), Unauthorized("x"),
+		UnsupportedAPIVersion("v9", nil), RouteNotFound(http.MethodGet, "/x"), MethodNotAllowed(http.MethodPost, nil),
+		UnsupportedMediaType("text/plain", nil), ServiceUnavailable("x"), InternalServerError(assert.AnError),
+		AccountDisabled(), PolicyNotAccepted(nil), ValidationError(nil), EmptyBody(),
+		&TooManyRequests(1).APIError, &AccountLocked(1).APIError, &RetryableUnavailable("x", 1).APIError,
+	}
+
+	for _, err := range errs {
+		d, ok := Lookup(err.Code)
+		if assert.True(t, ok, "constructor emits unregistered code %s", err.Code) {
+			assert.Equal(t, d.Status, err.Status, err.Code)
+		}
+	}
+}
+
+func TestCatalogHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/errors", CatalogHandler)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/errors", nil))
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var response struct {
+		Success bool         `json:"success"`
+		Data    []Definition `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.True(t, response.Success)
+	assert.Equal(t, Catalog(), response.Data)
+}
+
+// TestSharedMessages_HandlersUseConstants 防止处理函数重新写死已登记的公共错误消息
+func TestSharedMessages_HandlersUseConstants(t *testing.T) {
+	shared := []string{MsgUserNotFound, MsgRoleNotFound, MsgInvalidUserID, MsgInvalidRoleID, MsgForbiddenUserID, MsgUserNotAuthenticated, MsgEmailExists, MsgOrganizationNotFound}
+	quoted := make([]string, len(shared))
+	for i, msg := range shared {
+		quoted[i] = regexp.QuoteMeta(msg)
+	}
+	literal := regexp.MustCompile(`(?i)rrors\.\w+\("(` + strings.Join(quoted, "|") + `)"`)
+
+	err := filepath.WalkDir("..", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") {
+			return err
+		}
+		src, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		for _, match := range literal.FindAllString(string(src), -1) {
+			// 哨兵错误（errors.New）不属于 API 响应消息
+			if strings.HasPrefix(match, "rrors.New(") {
+				continue
+			}
+			t.Errorf("%s: use the errors.Msg* constant instead of %s", path, match)
+		}
+		return nil
+	})
+	require.NoError(t, err)
+}
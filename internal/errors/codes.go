@@ -1,13 +1,92 @@
 // Package errors 定义错误代码常量
 package errors
 
+import "net/http"
+
 // Error code constants for machine-readable API error identification.
 const (
-	CodeInternal        = "INTERNAL_ERROR"
-	CodeNotFound        = "NOT_FOUND"
-	CodeUnauthorized    = "UNAUTHORIZED"
-	CodeForbidden       = "FORBIDDEN"
-	CodeValidation      = "VALIDATION_ERROR"
-	CodeConflict        = "CONFLICT"
-	CodeTooManyRequests = "TOO_MANY_REQUESTS"
+	CodeInternal              = "INTERNAL_ERROR"
+	CodeNotFound              = "NOT_FOUND"
+	CodeUnauthorized          = "UNAUTHORIZED"
+	CodeForbidden             = "FORBIDDEN"
+	CodeValidation            = "VALIDATION_ERROR"
+	CodeConflict              = "CONFLICT"
+	CodeTooManyRequests       = "TOO_MANY_REQUESTS"
+	CodeUnsupportedAPIVersion = "UNSUPPORTED_API_VERSION"
+	CodeServiceUnavailable    = "SERVICE_UNAVAILABLE"
+	CodeUnsupportedMediaType  = "UNSUPPORTED_MEDIA_TYPE"
+	CodeAccountLocked         = "ACCOUNT_LOCKED"
+	CodeAccountDisabled       = "ACCOUNT_DISABLED"
+	CodePolicyNotAccepted     = "POLICY_NOT_ACCEPTED"
+	CodeRouteNotFound         = "ROUTE_NOT_FOUND"
+	CodeMethodNotAllowed      = "METHOD_NOT_ALLOWED"
+	CodeEmptyBody             = "EMPTY_BODY"
 )
+
+// Shared error messages, so the same failure reads the same from every handler.
+const (
+	MsgUserNotFound         = "User not found"
+	MsgRoleNotFound         = "Role not found"
+	MsgInvalidUserID        = "Invalid user ID"
+	MsgInvalidRoleID        = "Invalid role ID"
+	MsgForbiddenUserID      = "Forbidden user ID"
+	MsgUserNotAuthenticated = "User not authenticated"
+	MsgEmailExists          = "Email already exists"
+	MsgOrganizationNotFound = "Organization not found"
+	MsgAdminGrantForbidden  = "Granting or changing admin privileges requires admin:*"
+)
+
+// Definition describes one error code the API can emit.
+type Definition struct {
+	Code        string `json:"code" example:"NOT_FOUND"`
+	Status      int    `json:"status" example:"404"`
+	Description string `json:"description" example:"The requested resource does not exist"`
+}
+
+// definitions is the registry of every error code; constructors take their HTTP status from it.
+var definitions = []Definition{
+	{Code: CodeValidation, Status: http.StatusBadRequest, Description: "The request is malformed or a field failed validation; fields lists each failing field"},
+	{Code: CodeEmptyBody, Status: http.StatusBadRequest, Description: "The endpoint expects a JSON request body but the body is empty"},
+	{Code: CodeUnauthorized, Status: http.StatusUnauthorized, Description: "Authentication is missing, invalid or expired"},
+	{Code: CodeForbidden, Status: http.StatusForbidden, Description: "The caller is authenticated but not allowed to perform the action"},
+	{Code: CodeAccountDisabled, Status: http.StatusForbidden, Description: "The account has been disabled by an administrator"},
+	{Code: CodePolicyNotAccepted, Status: http.StatusForbidden, Description: "The current version of a required policy document has not been accepted; details lists the documents"},
+	{Code: CodeNotFound, Status: http.StatusNotFound, Description: "The requested resource does not exist"},
+	{Code: CodeRouteNotFound, Status: http.StatusNotFound, Description: "No route matches the request path"},
+	{Code: CodeUnsupportedAPIVersion, Status: http.StatusNotFound, Description: "The API version prefix is not supported; details lists the supported versions"},
+	{Code: CodeMethodNotAllowed, Status: http.StatusMethodNotAllowed, Description: "The route exists but does not accept the request method; the Allow header lists accepted methods"},
+	{Code: CodeConflict, Status: http.StatusConflict, Description: "The request conflicts with an existing resource"},
+	{Code: CodeUnsupportedMediaType, Status: http.StatusUnsupportedMediaType, Description: "The request body Content-Type is not accepted; details lists supported content types"},
+	{Code: CodeTooManyRequests, Status: http.StatusTooManyRequests, Description: "The rate limit was exceeded; retry after retry_after seconds"},
+	{Code: CodeAccountLocked, Status: http.StatusTooManyRequests, Description: "The account is temporarily locked after repeated failed logins; retry after retry_after seconds"},
+	{Code: CodeInternal, Status: http.StatusInternalServerError, Description: "An unexpected server error; details are only included when app.debug is on, otherwise quote the request_id for support"},
+	{Code: CodeServiceUnavailable, Status: http.StatusServiceUnavailable, Description: "A dependency is temporarily unavailable; the request can be retried"},
+}
+
+// registry indexes definitions by code.
+var registry = func() map[string]Definition {
+	byCode := make(map[string]Definition, len(definitions))
+	for _, d := range definitions {
+		byCode[d.Code] = d
+	}
+	return byCode
+}()
+
+// Catalog returns every error code the API can emit, in registry order.
+func Catalog() []Definition {
+	return append([]Definition(nil), definitions...)
+}
+
+// Lookup returns the definition registered for code.
+func Lookup(code string) (Definition, bool) {
+	d, ok := registry[code]
+	return d, ok
+}
+
+// statusOf returns the HTTP status registered for code, or 500 for an unregistered code.
+func statusOf(code string) int {
+	if d, ok := registry[code]; ok {
+		return d.Status
+	}
+	return http.StatusInternalServerError
+}
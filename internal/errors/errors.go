@@ -2,19 +2,39 @@
 package errors
 
 import (
+	stderrors "errors"
 	"fmt"
-	"net/http"
+	"io"
+	"reflect"
 	"strconv"
+	"strings"
+	"sync/atomic"
 
+	"github.com/gin-gonic/gin/binding"
 	"github.com/go-playground/validator/v10"
 )
 
+func init() {
+	// WHY: Validation errors report json field names so clients can map them back to the request payload
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(jsonFieldName)
+	}
+}
+
+// ErrDatabaseUnavailable marks failures caused by losing the database connection.
+// Repositories wrap driver-level connection errors with it so handlers can answer 503 without leaking driver text.
+var ErrDatabaseUnavailable = stderrors.New("database unavailable")
+
 // APIError represents a structured API error with code, message, details and HTTP status.
 type APIError struct {
 	Code    string `json:"code"`
 	Message string `json:"message"`
 	Details any    `json:"details,omitempty"`
-	Status  int    `json:"-"`
+	// Fields maps each failing request field (json name) to a readable reason
+	Fields map[string]string `json:"fields,omitempty"`
+	Status int               `json:"-"`
+	// cause is the underlying error of a 5xx response; it is logged, never serialized
+	cause error
 }
 
 // RateLimitError extends APIError with retry-after information for rate limiting.
@@ -27,12 +47,17 @@ func (e *APIError) Error() string {
 	return e.Message
 }
 
+// Unwrap returns the underlying error, so errors.Is and errors.As see through an APIError
+func (e *APIError) Unwrap() error {
+	return e.cause
+}
+
 // NotFound creates a 404 Not Found error.
 func NotFound(message string) *APIError {
 	return &APIError{
 		Code:    CodeNotFound,
 		Message: message,
-		Status:  http.StatusNotFound,
+		Status:  statusOf(CodeNotFound),
 	}
 }
 
@@ -41,7 +66,7 @@ func BadRequest(message string) *APIError {
 	return &APIError{
 		Code:    CodeValidation,
 		Message: message,
-		Status:  http.StatusBadRequest,
+		Status:  statusOf(CodeValidation),
 	}
 }
 
@@ -50,7 +75,7 @@ func Conflict(message string) *APIError {
 	return &APIError{
 		Code:    CodeConflict,
 		Message: message,
-		Status:  http.StatusConflict,
+		Status:  statusOf(CodeConflict),
 	}
 }
 
@@ -59,7 +84,7 @@ func Forbidden(message string) *APIError {
 	return &APIError{
 		Code:    CodeForbidden,
 		Message: message,
-		Status:  http.StatusForbidden,
+		Status:  statusOf(CodeForbidden),
 	}
 }
 
@@ -68,18 +93,141 @@ func Unauthorized(message string) *APIError {
 	return &APIError{
 		Code:    CodeUnauthorized,
 		Message: message,
-		Status:  http.StatusUnauthorized,
+		Status:  statusOf(CodeUnauthorized),
 	}
 }
 
-// InternalServerError creates a 500 Internal Server Error with details from the original error.
-func InternalServerError(err error) *APIError {
+// UnsupportedAPIVersion creates a 404 error for requests to an unknown API version prefix.
+func UnsupportedAPIVersion(version string, supported []string) *APIError {
 	return &APIError{
+		Code:    CodeUnsupportedAPIVersion,
+		Message: "API version " + version + " is not supported",
+		Details: map[string]any{
+			"requested_version":  version,
+			"supported_versions": supported,
+		},
+		Status: statusOf(CodeUnsupportedAPIVersion),
+	}
+}
+
+// RouteNotFound creates a 404 error for requests that match no route.
+func RouteNotFound(method, path string) *APIError {
+	return &APIError{
+		Code:    CodeRouteNotFound,
+		Message: "No route matches " + method + " " + path,
+		Status:  statusOf(CodeRouteNotFound),
+	}
+}
+
+// MethodNotAllowed creates a 405 error for a route that exists but does not accept the request method.
+func MethodNotAllowed(method string, allowed []string) *APIError {
+	return &APIError{
+		Code:    CodeMethodNotAllowed,
+		Message: "Method " + method + " is not allowed on this route",
+		Details: map[string]any{
+			"allowed_methods": allowed,
+		},
+		Status: statusOf(CodeMethodNotAllowed),
+	}
+}
+
+// UnsupportedMediaType creates a 415 Unsupported Media Type error listing the accepted content types.
+func UnsupportedMediaType(contentType string, supported []string) *APIError {
+	message := "Content-Type " + contentType + " is not supported"
+	if contentType == "" {
+		message = "Content-Type header is required"
+	}
+	return &APIError{
+		Code:    CodeUnsupportedMediaType,
+		Message: message,
+		Details: map[string]any{
+			"supported_content_types": supported,
+		},
+		Status: statusOf(CodeUnsupportedMediaType),
+	}
+}
+
+// EmptyBody creates a 400 error for a request that must carry a JSON body but has none.
+func EmptyBody() *APIError {
+	return &APIError{
+		Code:    CodeEmptyBody,
+		Message: "Request body is required",
+		Status:  statusOf(CodeEmptyBody),
+	}
+}
+
+// ServiceUnavailable creates a 503 Service Unavailable error for temporarily unreachable dependencies.
+func ServiceUnavailable(message string) *APIError {
+	return &APIError{
+		Code:    CodeServiceUnavailable,
+		Message: message,
+		Status:  statusOf(CodeServiceUnavailable),
+	}
+}
+
+// RetryableUnavailable creates a 503 error for a transient failure the client should retry after ra seconds.
+func RetryableUnavailable(message string, ra int) *RateLimitError {
+	return &RateLimitError{
+		APIError: APIError{
+			Code:    CodeServiceUnavailable,
+			Message: message,
+			Details: fmt.Sprintf("The request was not applied. Please retry in %s seconds.", strconv.Itoa(ra)),
+			Status:  statusOf(CodeServiceUnavailable),
+		},
+		RetryAfter: ra,
+	}
+}
+
+// DatabaseUnavailable creates a generic 503 error for a lost database connection.
+// The cause is kept for ErrorHandler, which logs it with the request ID.
+func DatabaseUnavailable(err error) *APIError {
+	apiErr := ServiceUnavailable("Service temporarily unavailable, please retry later")
+	apiErr.cause = err
+	return apiErr
+}
+
+// transient is implemented by typed errors that know whether a retry may succeed, such as user.RepositoryError
+type transient interface {
+	Transient() bool
+}
+
+// IsTransient reports whether err, or an error it wraps, is a lost database connection or
+// declares itself transient; such failures are answered with 503 instead of 500.
+func IsTransient(err error) bool {
+	if stderrors.Is(err, ErrDatabaseUnavailable) {
+		return true
+	}
+	var t transient
+	return stderrors.As(err, &t) && t.Transient()
+}
+
+// verboseDetails 为 true（app.debug）时 500 响应的 details 返回原始错误信息
+var verboseDetails atomic.Bool
+
+// SetDebug configures whether internal error details are returned to clients.
+// Outside debug mode 500 responses carry no details; ErrorHandler logs the cause with the request ID.
+func SetDebug(debug bool) {
+	verboseDetails.Store(debug)
+}
+
+// InternalServerError creates a 500 Internal Server Error that wraps err.
+// Transient failures (see IsTransient) are reported as 503 without the driver message.
+// The error text is only included in details in debug mode; ErrorHandler always logs it with the request ID.
+func InternalServerError(err error) *APIError {
+	if IsTransient(err) {
+		return DatabaseUnavailable(err)
+	}
+
+	apiErr := &APIError{
 		Code:    CodeInternal,
 		Message: "Internal server error",
-		Details: err.Error(),
-		Status:  http.StatusInternalServerError,
+		Status:  statusOf(CodeInternal),
+		cause:   err,
+	}
+	if verboseDetails.Load() && err != nil {
+		apiErr.Details = err.Error()
 	}
+	return apiErr
 }
 
 // TooManyRequests creates a 429 Too Many Requests error with retry-after seconds.
@@ -89,55 +237,120 @@ func TooManyRequests(ra int) *RateLimitError {
 			Code:    CodeTooManyRequests,
 			Message: "Rate limit exceeded",
 			Details: fmt.Sprintf("Too many requests. Please try again in %s seconds.", strconv.Itoa(ra)),
-			Status:  http.StatusTooManyRequests,
+			Status:  statusOf(CodeTooManyRequests),
+		},
+		RetryAfter: ra,
+	}
+}
+
+// AccountLocked creates a 429 error for an account locked by repeated failed logins, with seconds until it unlocks.
+func AccountLocked(ra int) *RateLimitError {
+	return &RateLimitError{
+		APIError: APIError{
+			Code:    CodeAccountLocked,
+			Message: "Account is temporarily locked",
+			Details: fmt.Sprintf("Too many failed login attempts. Please try again in %s seconds.", strconv.Itoa(ra)),
+			Status:  statusOf(CodeAccountLocked),
 		},
 		RetryAfter: ra,
 	}
 }
 
+// AccountDisabled creates a 403 error for an account an administrator has disabled.
+func AccountDisabled() *APIError {
+	return &APIError{
+		Code:    CodeAccountDisabled,
+		Message: "Account is disabled",
+		Status:  statusOf(CodeAccountDisabled),
+	}
+}
+
+// PolicyNotAccepted creates a 403 error for a user who has not accepted the current version of
+// a policy document that blocks access until it is accepted.
+func PolicyNotAccepted(documents []string) *APIError {
+	return &APIError{
+		Code:    CodePolicyNotAccepted,
+		Message: "The current version of a required policy must be accepted",
+		Details: map[string]any{
+			"documents": documents,
+		},
+		Status: statusOf(CodePolicyNotAccepted),
+	}
+}
+
 // ValidationError creates a validation error with field-level details.
 func ValidationError(details interface{}) *APIError {
 	return &APIError{
 		Code:    CodeValidation,
 		Message: "Validation failed",
 		Details: details,
-		Status:  http.StatusBadRequest,
+		Status:  statusOf(CodeValidation),
 	}
 }
 
 // FromGinValidation converts Gin/validator errors to structured APIError with field-level details.
 func FromGinValidation(err error) *APIError {
+	// WHY: Binding an empty body fails with a bare EOF, which reads like a server fault rather than a missing payload
+	if stderrors.Is(err, io.EOF) {
+		return EmptyBody()
+	}
 	if validationErrs, ok := err.(validator.ValidationErrors); ok {
 		details := make(map[string]string)
+		fields := make(map[string]string)
 
 		for _, fieldErr := range validationErrs {
-			details[fieldErr.Field()] = formatValidationError(fieldErr)
+			details[fieldErr.StructField()] = formatValidationError(fieldErr)
+			fields[fieldErr.Field()] = validationReason(fieldErr)
 		}
 
-		return ValidationError(details)
+		apiErr := ValidationError(details)
+		apiErr.Fields = fields
+		return apiErr
 	}
 
 	return &APIError{
 		Code:    CodeValidation,
 		Message: "Invalid request data format",
 		Details: err.Error(),
-		Status:  http.StatusBadRequest,
+		Status:  statusOf(CodeValidation),
 	}
 }
 
 // formatValidationError converts validator field errors to human-readable messages.
-// Handles common validation tags: required, email, min, max.
 func formatValidationError(fe validator.FieldError) string {
+	return fe.StructField() + " " + validationReason(fe)
+}
+
+// validationReason describes why a field failed validation, without the field name.
+// Handles common validation tags: required, email, min, max, len, oneof.
+func validationReason(fe validator.FieldError) string {
 	switch fe.Tag() {
 	case "required":
-		return fe.Field() + " is required"
+		return "is required"
 	case "email":
-		return fe.Field() + " must be a valid email address"
+		return "must be a valid email address"
 	case "min":
-		return fe.Field() + " is too short (minimum " + fe.Param() + ")"
+		return "is too short (minimum " + fe.Param() + ")"
 	case "max":
-		return fe.Field() + " is too long (maximum " + fe.Param() + ")"
+		return "is too long (maximum " + fe.Param() + ")"
+	case "len":
+		return "must be exactly " + fe.Param() + " long"
+	case "oneof":
+		return "must be one of: " + strings.Join(strings.Fields(fe.Param()), ", ")
+	default:
+		return "failed validation on tag " + fe.Tag()
+	}
+}
+
+// jsonFieldName reports the json name of a struct field, falling back to the Go name.
+func jsonFieldName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	switch name {
+	case "-":
+		return ""
+	case "":
+		return field.Name
 	default:
-		return fe.Field() + " failed validation on tag " + fe.Tag()
+		return name
 	}
 }
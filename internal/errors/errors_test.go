@@ -2,13 +2,17 @@ package errors
 
 import (
 	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"reflect"
 	"testing"
 
+	"github.com/gin-gonic/gin/binding"
 	ut "github.com/go-playground/universal-translator"
 	"github.com/go-playground/validator/v10"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestAPIError_Error(t *testing.T) {
@@ -57,6 +61,38 @@ func TestForbidden(t *testing.T) {
 	assert.Nil(t, err.Details)
 }
 
+func TestAccountDisabled(t *testing.T) {
+	err := AccountDisabled()
+
+	assert.Equal(t, CodeAccountDisabled, err.Code)
+	assert.Equal(t, "Account is disabled", err.Message)
+	assert.Equal(t, http.StatusForbidden, err.Status)
+}
+
+func TestPolicyNotAccepted(t *testing.T) {
+	err := PolicyNotAccepted([]string{"terms"})
+
+	assert.Equal(t, CodePolicyNotAccepted, err.Code)
+	assert.Equal(t, http.StatusForbidden, err.Status)
+	assert.Equal(t, map[string]any{"documents": []string{"terms"}}, err.Details)
+}
+
+func TestRouteNotFound(t *testing.T) {
+	err := RouteNotFound(http.MethodGet, "/api/v1/nope")
+
+	assert.Equal(t, CodeRouteNotFound, err.Code)
+	assert.Equal(t, http.StatusNotFound, err.Status)
+	assert.Equal(t, "No route matches GET /api/v1/nope", err.Message)
+}
+
+func TestMethodNotAllowed(t *testing.T) {
+	err := MethodNotAllowed(http.MethodGet, []string{http.MethodPost})
+
+	assert.Equal(t, CodeMethodNotAllowed, err.Code)
+	assert.Equal(t, http.StatusMethodNotAllowed, err.Status)
+	assert.Equal(t, map[string]any{"allowed_methods": []string{http.MethodPost}}, err.Details)
+}
+
 func TestUnauthorized(t *testing.T) {
 	err := Unauthorized("Authentication required")
 
@@ -67,6 +103,9 @@ func TestUnauthorized(t *testing.T) {
 }
 
 func TestInternalServerError(t *testing.T) {
+	t.Cleanup(func() { SetDebug(false) })
+	SetDebug(true)
+
 	originalErr := errors.New("database connection failed")
 	err := InternalServerError(originalErr)
 
@@ -76,6 +115,80 @@ func TestInternalServerError(t *testing.T) {
 	assert.Equal(t, "database connection failed", err.Details)
 }
 
+func TestInternalServerError_Redaction(t *testing.T) {
+	t.Cleanup(func() { SetDebug(false) })
+	originalErr := errors.New(`pq: relation "users" does not exist`)
+
+	SetDebug(true)
+	err := InternalServerError(originalErr)
+	assert.Equal(t, originalErr.Error(), err.Details)
+
+	SetDebug(false)
+	err = InternalServerError(originalErr)
+	assert.Equal(t, CodeInternal, err.Code)
+	assert.Equal(t, http.StatusInternalServerError, err.Status)
+	assert.Nil(t, err.Details, "details are only returned in debug mode")
+	assert.Same(t, originalErr, err.Unwrap(), "the cause is kept for logging")
+}
+
+// repoError mimics a typed service error such as user.RepositoryError
+type repoError struct {
+	op        string
+	err       error
+	transient bool
+}
+
+var errRepo = errors.New("repository")
+
+func (e *repoError) Error() string   { return e.op + ": " + e.err.Error() }
+func (e *repoError) Unwrap() []error { return []error{errRepo, e.err} }
+func (e *repoError) Transient() bool { return e.transient }
+
+func TestInternalServerError_WrappingChain(t *testing.T) {
+	driverErr := errors.New(`pq: syntax error at or near "FORM"`)
+	cause := fmt.Errorf("failed to list users: %w", &repoError{op: "ListAllUsers", err: driverErr})
+	apiErr := InternalServerError(cause)
+
+	assert.Equal(t, http.StatusInternalServerError, apiErr.Status, "a non-transient repository error is a programming error")
+
+	// The API error unwraps to every link of the chain
+	assert.ErrorIs(t, apiErr, cause)
+	assert.ErrorIs(t, apiErr, errRepo)
+	assert.ErrorIs(t, apiErr, driverErr)
+	var typed *repoError
+	require.ErrorAs(t, apiErr, &typed)
+	assert.Equal(t, "ListAllUsers", typed.op)
+	assert.False(t, IsTransient(apiErr))
+
+	// Wrapping the API error again keeps the chain intact
+	outer := fmt.Errorf("handler: %w", apiErr)
+	assert.ErrorIs(t, outer, driverErr)
+	var unwrapped *APIError
+	require.ErrorAs(t, outer, &unwrapped)
+	assert.Same(t, apiErr, unwrapped)
+}
+
+func TestInternalServerError_TransientTypedError(t *testing.T) {
+	cause := fmt.Errorf("failed to get user: %w", &repoError{op: "FindByID", err: errors.New("canceling statement due to statement timeout"), transient: true})
+	apiErr := InternalServerError(cause)
+
+	assert.True(t, IsTransient(cause))
+	assert.Equal(t, CodeServiceUnavailable, apiErr.Code)
+	assert.Equal(t, http.StatusServiceUnavailable, apiErr.Status)
+	assert.Nil(t, apiErr.Details)
+	assert.ErrorIs(t, apiErr, errRepo, "the cause is kept for logging")
+}
+
+func TestInternalServerError_DatabaseUnavailable(t *testing.T) {
+	cause := fmt.Errorf("%w: %w", ErrDatabaseUnavailable, errors.New("dial tcp 10.0.0.5:5432: connect: connection refused"))
+	err := InternalServerError(fmt.Errorf("failed to get user: %w", cause))
+
+	assert.Equal(t, CodeServiceUnavailable, err.Code)
+	assert.Equal(t, http.StatusServiceUnavailable, err.Status)
+	assert.Nil(t, err.Details)
+	assert.NotContains(t, err.Message, "connection refused")
+}
+
 func TestTooManyRequests(t *testing.T) {
 	retryAfter := 60
 	err := TooManyRequests(retryAfter)
@@ -87,6 +200,16 @@ func TestTooManyRequests(t *testing.T) {
 	assert.Contains(t, err.Details, "60 seconds")
 }
 
+func TestRetryableUnavailable(t *testing.T) {
+	err := RetryableUnavailable("Token refresh is temporarily unavailable", 2)
+
+	assert.Equal(t, CodeServiceUnavailable, err.Code)
+	assert.Equal(t, "Token refresh is temporarily unavailable", err.Message)
+	assert.Equal(t, http.StatusServiceUnavailable, err.Status)
+	assert.Equal(t, 2, err.RetryAfter)
+	assert.Contains(t, err.Details, "2 seconds")
+}
+
 func TestValidationError(t *testing.T) {
 	details := map[string]string{
 		"email":    "Invalid email format",
@@ -209,6 +332,38 @@ func TestFromGinValidation_WithValidationErrors(t *testing.T) {
 	assert.Contains(t, details, "Name")
 }
 
+func TestFromGinValidation_FieldReasons(t *testing.T) {
+	type signupRequest struct {
+		Email    string `json:"email" binding:"required,email"`
+		Password string `json:"password" binding:"required,min=8"`
+		Name     string `json:"name,omitempty" binding:"max=5"`
+		Role     string `json:"role" binding:"omitempty,oneof=user admin"`
+	}
+
+	err := binding.Validator.ValidateStruct(&signupRequest{
+		Email: "not-an-email",
+		Name:  "far too long",
+		Role:  "root",
+	})
+	assert.Error(t, err)
+
+	apiErr := FromGinValidation(err)
+
+	assert.Equal(t, CodeValidation, apiErr.Code)
+	assert.Equal(t, "Validation failed", apiErr.Message)
+	assert.Equal(t, map[string]string{
+		"email":    "must be a valid email address",
+		"password": "is required",
+		"name":     "is too long (maximum 5)",
+		"role":     "must be one of: user, admin",
+	}, apiErr.Fields)
+
+	details, ok := apiErr.Details.(map[string]string)
+	assert.True(t, ok)
+	assert.Equal(t, "Email must be a valid email address", details["Email"])
+	assert.Equal(t, "Password is required", details["Password"])
+}
+
 func TestFromGinValidation_WithNonValidationError(t *testing.T) {
 	err := errors.New("some random error")
 	apiErr := FromGinValidation(err)
@@ -219,6 +374,14 @@ func TestFromGinValidation_WithNonValidationError(t *testing.T) {
 	assert.Equal(t, "some random error", apiErr.Details)
 }
 
+func TestFromGinValidation_EmptyBody(t *testing.T) {
+	apiErr := FromGinValidation(fmt.Errorf("decode: %w", io.EOF))
+
+	assert.Equal(t, CodeEmptyBody, apiErr.Code)
+	assert.Equal(t, http.StatusBadRequest, apiErr.Status)
+	assert.Nil(t, apiErr.Details)
+}
+
 func TestRateLimitError_Structure(t *testing.T) {
 	err := TooManyRequests(30)
 
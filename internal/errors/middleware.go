@@ -2,7 +2,7 @@
 package errors
 
 import (
-	"net/http"
+	"log/slog"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -37,12 +37,14 @@ func ErrorHandler() gin.HandlerFunc {
 			}
 
 			if apiErr, ok := err.Err.(*APIError); ok {
+				logCause(c, apiErr, reqID)
 				response := Response{
 					Success: false,
 					Error: &ErrorInfo{
 						Code:      apiErr.Code,
 						Message:   apiErr.Message,
 						Details:   apiErr.Details,
+						Fields:    apiErr.Fields,
 						Timestamp: time.Now(),
 						Path:      getRequestPath(c),
 						RequestID: reqID,
@@ -52,22 +54,38 @@ func ErrorHandler() gin.HandlerFunc {
 				return
 			}
 
+			// WHY: Unwrapped errors go through InternalServerError so detail redaction and transient-failure mapping apply
+			apiErr := InternalServerError(err.Err)
+			logCause(c, apiErr, reqID)
 			response := Response{
 				Success: false,
 				Error: &ErrorInfo{
-					Code:      CodeInternal,
-					Message:   "Internal server error",
-					Details:   err.Err.Error(),
+					Code:      apiErr.Code,
+					Message:   apiErr.Message,
+					Details:   apiErr.Details,
 					Timestamp: time.Now(),
 					Path:      getRequestPath(c),
 					RequestID: reqID,
 				},
 			}
-			c.JSON(http.StatusInternalServerError, response)
+			c.JSON(apiErr.Status, response)
 		}
 	}
 }
 
+// logCause logs the underlying error of a 5xx response with the request ID, so the redacted
+// response can be matched to the log entry
+func logCause(c *gin.Context, apiErr *APIError, requestID string) {
+	if apiErr.cause == nil {
+		return
+	}
+	msg := "Internal server error"
+	if IsTransient(apiErr.cause) {
+		msg = "Database unavailable"
+	}
+	slog.Error(msg, "request_id", requestID, "path", getRequestPath(c), "error", apiErr.cause)
+}
+
 func getRequestPath(c *gin.Context) string {
 	if c.Request == nil || c.Request.URL == nil {
 		return ""
@@ -1,8 +1,11 @@
 package errors
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -149,6 +152,63 @@ func TestErrorHandler_WithUnknownError(t *testing.T) {
 	assert.Contains(t, w.Body.String(), "Internal server error")
 }
 
+func TestErrorHandler_RedactsDetailsUnlessDebug(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Cleanup(func() { SetDebug(false) })
+
+	var logs bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&logs, nil)))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+
+	serve := func(err error) string {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest("GET", "/test", nil)
+		c.Set("request_id", "req-123")
+		_ = c.Error(err)
+		ErrorHandler()(c)
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		return w.Body.String()
+	}
+
+	leak := errors.New("dial tcp 10.0.0.5:5432: SELECT * FROM users")
+
+	SetDebug(true)
+	assert.Contains(t, serve(leak), "SELECT * FROM users")
+	assert.Contains(t, serve(InternalServerError(leak)), "SELECT * FROM users")
+
+	SetDebug(false)
+	for _, err := range []error{leak, InternalServerError(leak)} {
+		logs.Reset()
+		body := serve(err)
+		assert.NotContains(t, body, "SELECT * FROM users")
+		assert.NotContains(t, body, `"details"`)
+		assert.Contains(t, body, `"request_id":"req-123"`)
+
+		// The cause is logged under the same request ID
+		assert.Contains(t, logs.String(), `"request_id":"req-123"`)
+		assert.Contains(t, logs.String(), "SELECT * FROM users")
+	}
+}
+
+func TestErrorHandler_DatabaseUnavailable(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("GET", "/test", nil)
+
+	_ = c.Error(fmt.Errorf("%w: %w", ErrDatabaseUnavailable, errors.New("sql: database is closed")))
+
+	ErrorHandler()(c)
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Contains(t, w.Body.String(), CodeServiceUnavailable)
+	assert.NotContains(t, w.Body.String(), "database is closed")
+	assert.NotContains(t, w.Body.String(), `"details"`)
+}
+
 func TestErrorHandler_WithNoErrors(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
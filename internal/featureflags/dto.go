@@ -0,0 +1,48 @@
+package featureflags
+
+import "time"
+
+// UpdateFlagRequest represents a full replacement of a flag definition
+type UpdateFlagRequest struct {
+	Description string `json:"description" binding:"max=255" example:"Two-factor authentication"`
+	Enabled     bool   `json:"enabled" example:"false"`
+	Percentage  int    `json:"percentage" binding:"min=0,max=100" example:"10"`
+	AllowUsers  []uint `json:"allow_users" example:"1,2"`
+	DenyUsers   []uint `json:"deny_users"`
+}
+
+// CreateFlagRequest represents a request to create a feature flag
+type CreateFlagRequest struct {
+	Name string `json:"name" binding:"required" example:"two_factor_auth"`
+	UpdateFlagRequest
+}
+
+// FlagResponse represents a feature flag definition in API responses
+type FlagResponse struct {
+	Name        string     `json:"name" example:"two_factor_auth"`
+	Description string     `json:"description,omitempty"`
+	Enabled     bool       `json:"enabled"`
+	Percentage  int        `json:"percentage" example:"10"`
+	AllowUsers  []uint     `json:"allow_users"`
+	DenyUsers   []uint     `json:"deny_users"`
+	Source      string     `json:"source" example:"database"`
+	UpdatedAt   *time.Time `json:"updated_at,omitempty"`
+}
+
+// ToFlagResponse converts a Flag to FlagResponse
+func ToFlagResponse(f *Flag) FlagResponse {
+	resp := FlagResponse{
+		Name:        f.Name,
+		Description: f.Description,
+		Enabled:     f.Enabled,
+		Percentage:  f.Percentage,
+		AllowUsers:  nonNil(f.AllowUsers),
+		DenyUsers:   nonNil(f.DenyUsers),
+		Source:      f.Source(),
+	}
+	if !f.UpdatedAt.IsZero() {
+		updatedAt := f.UpdatedAt
+		resp.UpdatedAt = &updatedAt
+	}
+	return resp
+}
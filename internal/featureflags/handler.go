@@ -0,0 +1,162 @@
+package featureflags
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	apiErrors "github.com/yeegeek/uyou-go-api-starter/internal/errors"
+)
+
+// Handler handles feature flag HTTP requests
+type Handler struct {
+	service Service
+}
+
+// NewHandler creates a new feature flag handler
+func NewHandler(service Service) *Handler {
+	return &Handler{service: service}
+}
+
+// GetMyFlags godoc
+// @Summary Evaluated feature flags
+// @Description Get the state of every feature flag for the current user; anonymous callers get each flag's default
+// @Tags meta
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} errors.Response{success=bool,data=map[string]bool} "Flag states keyed by flag name"
+// @Router /api/v1/meta/flags [get]
+func (h *Handler) GetMyFlags(c *gin.Context) {
+	c.JSON(http.StatusOK, apiErrors.Success(h.service.Evaluate(c)))
+}
+
+// ListFlags godoc
+// @Summary List feature flags (Admin only)
+// @Description Get all feature flag definitions from configuration and database (requires admin role)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} errors.Response{success=bool,data=[]FlagResponse} "Success response with flag list"
+// @Failure 403 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Admin access required"
+// @Failure 500 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Failed to list flags"
+// @Router /api/v1/admin/flags [get]
+func (h *Handler) ListFlags(c *gin.Context) {
+	flags, err := h.service.ListFlags(c.Request.Context())
+	if err != nil {
+		_ = c.Error(apiErrors.InternalServerError(err))
+		return
+	}
+
+	responses := make([]FlagResponse, len(flags))
+	for i := range flags {
+		responses[i] = ToFlagResponse(&flags[i])
+	}
+
+	c.JSON(http.StatusOK, apiErrors.Success(responses))
+}
+
+// CreateFlag godoc
+// @Summary Create feature flag (Admin only)
+// @Description Create a feature flag; names must be 2-100 lowercase letters, digits, '_', '.' or '-' starting with a letter (requires admin role)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body CreateFlagRequest true "Flag definition"
+// @Success 201 {object} errors.Response{success=bool,data=FlagResponse} "Success response with created flag"
+// @Failure 400 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Validation error or invalid flag name"
+// @Failure 403 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Admin access required"
+// @Failure 409 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Flag already exists"
+// @Failure 500 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Failed to create flag"
+// @Router /api/v1/admin/flags [post]
+func (h *Handler) CreateFlag(c *gin.Context) {
+	var req CreateFlagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(apiErrors.FromGinValidation(err))
+		return
+	}
+
+	flag, err := h.service.CreateFlag(c.Request.Context(), req)
+	if err != nil {
+		if errors.Is(err, ErrInvalidFlagName) {
+			_ = c.Error(apiErrors.BadRequest("Invalid flag name"))
+			return
+		}
+		if errors.Is(err, ErrFlagExists) {
+			_ = c.Error(apiErrors.Conflict("Flag already exists"))
+			return
+		}
+		_ = c.Error(apiErrors.InternalServerError(err))
+		return
+	}
+
+	c.JSON(http.StatusCreated, apiErrors.Success(ToFlagResponse(flag)))
+}
+
+// UpdateFlag godoc
+// @Summary Update feature flag (Admin only)
+// @Description Replace a feature flag definition; updating a configured flag stores a database override (requires admin role)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param name path string true "Flag name"
+// @Param request body UpdateFlagRequest true "Flag definition"
+// @Success 200 {object} errors.Response{success=bool,data=FlagResponse} "Success response with updated flag"
+// @Failure 400 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Validation error"
+// @Failure 403 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Admin access required"
+// @Failure 404 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Flag not found"
+// @Failure 500 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Failed to update flag"
+// @Router /api/v1/admin/flags/{name} [put]
+func (h *Handler) UpdateFlag(c *gin.Context) {
+	var req UpdateFlagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(apiErrors.FromGinValidation(err))
+		return
+	}
+
+	flag, err := h.service.UpdateFlag(c.Request.Context(), c.Param("name"), req)
+	if err != nil {
+		if errors.Is(err, ErrFlagNotFound) {
+			_ = c.Error(apiErrors.NotFound("Flag not found"))
+			return
+		}
+		_ = c.Error(apiErrors.InternalServerError(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, apiErrors.Success(ToFlagResponse(flag)))
+}
+
+// DeleteFlag godoc
+// @Summary Delete feature flag (Admin only)
+// @Description Delete a stored feature flag; configured flags revert to their configured definition (requires admin role)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param name path string true "Flag name"
+// @Success 204
+// @Failure 403 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Admin access required"
+// @Failure 404 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Flag not found"
+// @Failure 409 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Flag is only defined in configuration"
+// @Failure 500 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Failed to delete flag"
+// @Router /api/v1/admin/flags/{name} [delete]
+func (h *Handler) DeleteFlag(c *gin.Context) {
+	if err := h.service.DeleteFlag(c.Request.Context(), c.Param("name")); err != nil {
+		if errors.Is(err, ErrFlagNotFound) {
+			_ = c.Error(apiErrors.NotFound("Flag not found"))
+			return
+		}
+		if errors.Is(err, ErrConfigFlag) {
+			_ = c.Error(apiErrors.Conflict("Flag is defined in configuration and cannot be deleted"))
+			return
+		}
+		_ = c.Error(apiErrors.InternalServerError(err))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
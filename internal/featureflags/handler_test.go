@@ -0,0 +1,106 @@
+package featureflags
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yeegeek/uyou-go-api-starter/internal/auth"
+	"github.com/yeegeek/uyou-go-api-starter/internal/config"
+	"github.com/yeegeek/uyou-go-api-starter/internal/errors"
+)
+
+func setupTestRouter(t *testing.T, userID uint) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	svc, _ := setupTestService(t, config.FeatureFlagsConfig{
+		Flags: []config.FeatureFlagConfig{
+			{Name: "beta", AllowUsers: []uint{7}},
+			{Name: "dark_mode", Enabled: true},
+		},
+	})
+	h := NewHandler(svc)
+
+	router := gin.New()
+	router.Use(errors.ErrorHandler())
+	router.Use(func(c *gin.Context) {
+		if userID != 0 {
+			c.Set(auth.KeyUser, &auth.Claims{UserID: userID})
+		}
+		c.Next()
+	})
+	router.GET("/meta/flags", h.GetMyFlags)
+	router.GET("/admin/flags", h.ListFlags)
+	router.POST("/admin/flags", h.CreateFlag)
+	router.PUT("/admin/flags/:name", h.UpdateFlag)
+	router.DELETE("/admin/flags/:name", h.DeleteFlag)
+	return router
+}
+
+func TestHandler_GetMyFlags(t *testing.T) {
+	tests := []struct {
+		name   string
+		userID uint
+		want   map[string]bool
+	}{
+		{name: "anonymous", userID: 0, want: map[string]bool{"beta": false, "dark_mode": true}},
+		{name: "allowed user", userID: 7, want: map[string]bool{"beta": true, "dark_mode": true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := setupTestRouter(t, tt.userID)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/meta/flags", nil))
+
+			require.Equal(t, http.StatusOK, w.Code)
+			var resp struct {
+				Data map[string]bool `json:"data"`
+			}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			assert.Equal(t, tt.want, resp.Data)
+		})
+	}
+}
+
+func TestHandler_AdminFlags(t *testing.T) {
+	router := setupTestRouter(t, 1)
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		expectedStatus int
+	}{
+		{name: "create flag", method: http.MethodPost, path: "/admin/flags", body: `{"name":"new_profile","percentage":25}`, expectedStatus: http.StatusCreated},
+		{name: "duplicate flag", method: http.MethodPost, path: "/admin/flags", body: `{"name":"new_profile"}`, expectedStatus: http.StatusConflict},
+		{name: "invalid name", method: http.MethodPost, path: "/admin/flags", body: `{"name":"Bad Name"}`, expectedStatus: http.StatusBadRequest},
+		{name: "percentage out of range", method: http.MethodPost, path: "/admin/flags", body: `{"name":"other","percentage":101}`, expectedStatus: http.StatusBadRequest},
+		{name: "override configured flag", method: http.MethodPut, path: "/admin/flags/beta", body: `{"enabled":true}`, expectedStatus: http.StatusOK},
+		{name: "update unknown flag", method: http.MethodPut, path: "/admin/flags/missing", body: `{}`, expectedStatus: http.StatusNotFound},
+		{name: "list flags", method: http.MethodGet, path: "/admin/flags", expectedStatus: http.StatusOK},
+		{name: "delete stored flag", method: http.MethodDelete, path: "/admin/flags/new_profile", expectedStatus: http.StatusNoContent},
+		{name: "delete configured-only flag", method: http.MethodDelete, path: "/admin/flags/dark_mode", expectedStatus: http.StatusConflict},
+		{name: "delete unknown flag", method: http.MethodDelete, path: "/admin/flags/missing", expectedStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code, w.Body.String())
+		})
+	}
+}
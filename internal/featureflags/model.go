@@ -0,0 +1,51 @@
+// Package featureflags 提供功能开关：配置或数据库定义、按用户灰度和管理接口
+package featureflags
+
+import (
+	"regexp"
+	"time"
+)
+
+// Flag is a feature flag definition. Flags defined in configuration have ID 0;
+// a database row with the same name overrides the configured definition.
+type Flag struct {
+	ID          uint   `gorm:"primaryKey"`
+	Name        string `gorm:"type:varchar(100);uniqueIndex;not null"`
+	Description string `gorm:"type:varchar(255)"`
+	// Enabled is the default state for anonymous users and users outside the rollout
+	Enabled bool `gorm:"not null;default:false"`
+	// Percentage of authenticated users (0-100) bucketed into the flag by hashing user ID and flag name
+	Percentage int    `gorm:"not null;default:0"`
+	AllowUsers []uint `gorm:"type:jsonb;serializer:json;not null;default:'[]'"`
+	// DenyUsers always evaluate to off and take precedence over AllowUsers
+	DenyUsers []uint    `gorm:"type:jsonb;serializer:json;not null;default:'[]'"`
+	CreatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP"`
+	UpdatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP"`
+}
+
+// TableName specifies the table name for Flag
+func (Flag) TableName() string {
+	return "feature_flags"
+}
+
+// Flag sources reported by the admin API
+const (
+	SourceConfig   = "config"
+	SourceDatabase = "database"
+)
+
+// Source reports where the flag definition comes from
+func (f *Flag) Source() string {
+	if f.ID == 0 {
+		return SourceConfig
+	}
+	return SourceDatabase
+}
+
+var flagNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_.-]{1,99}$`)
+
+// IsValidFlagName reports whether name is a well-formed flag name:
+// 2-100 characters, starting with a lowercase letter, followed by lowercase letters, digits, '_', '.' or '-'
+func IsValidFlagName(name string) bool {
+	return flagNamePattern.MatchString(name)
+}
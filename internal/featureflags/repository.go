@@ -0,0 +1,58 @@
+package featureflags
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// Repository defines persistence for feature flags managed through the admin API
+type Repository interface {
+	List(ctx context.Context) ([]Flag, error)
+	FindByName(ctx context.Context, name string) (*Flag, error)
+	Create(ctx context.Context, flag *Flag) error
+	Update(ctx context.Context, flag *Flag) error
+	Delete(ctx context.Context, id uint) error
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+// NewRepository creates a new feature flag repository
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+// List returns all stored flags ordered by name
+func (r *repository) List(ctx context.Context) ([]Flag, error) {
+	var flags []Flag
+	err := r.db.WithContext(ctx).Order("name").Find(&flags).Error
+	return flags, err
+}
+
+// FindByName returns the stored flag with the given name, or nil if none exists
+func (r *repository) FindByName(ctx context.Context, name string) (*Flag, error) {
+	var flag Flag
+	err := r.db.WithContext(ctx).Where("name = ?", name).First(&flag).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &flag, nil
+}
+
+func (r *repository) Create(ctx context.Context, flag *Flag) error {
+	return r.db.WithContext(ctx).Create(flag).Error
+}
+
+func (r *repository) Update(ctx context.Context, flag *Flag) error {
+	return r.db.WithContext(ctx).Save(flag).Error
+}
+
+func (r *repository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&Flag{}, id).Error
+}
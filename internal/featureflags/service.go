@@ -0,0 +1,321 @@
+package featureflags
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"log/slog"
+	"slices"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/yeegeek/uyou-go-api-starter/internal/auth"
+	"github.com/yeegeek/uyou-go-api-starter/internal/config"
+)
+
+var (
+	// ErrFlagNotFound is returned when no flag with the given name exists
+	ErrFlagNotFound = errors.New("feature flag not found")
+	// ErrFlagExists is returned when creating a flag whose name is already defined
+	ErrFlagExists = errors.New("feature flag already exists")
+	// ErrInvalidFlagName is returned when a flag name format is invalid
+	ErrInvalidFlagName = errors.New("invalid feature flag name")
+	// ErrConfigFlag is returned when deleting a flag that is only defined in configuration
+	ErrConfigFlag = errors.New("feature flag is defined in configuration")
+)
+
+// defaultCacheTTL is used when no cache TTL is configured
+const defaultCacheTTL = 30 * time.Second
+
+// Service evaluates feature flags and manages their stored definitions
+type Service interface {
+	// Enabled reports whether the flag is on for the user in ctx; unknown flags are off
+	Enabled(ctx context.Context, name string) bool
+	// Evaluate returns the state of every flag for the user in ctx
+	Evaluate(ctx context.Context) map[string]bool
+	ListFlags(ctx context.Context) ([]Flag, error)
+	CreateFlag(ctx context.Context, req CreateFlagRequest) (*Flag, error)
+	UpdateFlag(ctx context.Context, name string, req UpdateFlagRequest) (*Flag, error)
+	DeleteFlag(ctx context.Context, name string) error
+}
+
+type service struct {
+	repo        Repository
+	configFlags map[string]Flag
+	cacheTTL    func() time.Duration
+	now         func() time.Time
+
+	mu       sync.RWMutex
+	flags    map[string]Flag
+	loadedAt time.Time
+}
+
+// Option customizes the feature flag service
+type Option func(*service)
+
+// WithCacheTTLFunc reads the cache TTL on every lookup instead of using cfg.CacheTTL,
+// so a reloaded configuration applies without recreating the service
+func WithCacheTTLFunc(ttl func() time.Duration) Option {
+	return func(s *service) {
+		s.cacheTTL = ttl
+	}
+}
+
+// NewService creates a feature flag service. Stored flags are cached for
+// cfg.CacheTTL, so changes made through another instance take effect within it.
+func NewService(repo Repository, cfg config.FeatureFlagsConfig, opts ...Option) Service {
+
+	configFlags := make(map[string]Flag, len(cfg.Flags))
+	for _, f := range cfg.Flags {
+		configFlags[f.Name] = Flag{
+			Name:        f.Name,
+			Description: f.Description,
+			Enabled:     f.Enabled,
+			Percentage:  f.Percentage,
+			AllowUsers:  f.AllowUsers,
+			DenyUsers:   f.DenyUsers,
+		}
+	}
+
+	svc := &service{
+		repo:        repo,
+		configFlags: configFlags,
+		cacheTTL:    func() time.Duration { return cfg.CacheTTL },
+		now:         time.Now,
+	}
+	for _, opt := range opts {
+		opt(svc)
+	}
+	return svc
+}
+
+// currentCacheTTL returns the configured cache TTL, or the default when it is not positive
+func (s *service) currentCacheTTL() time.Duration {
+	if ttl := s.cacheTTL(); ttl > 0 {
+		return ttl
+	}
+	return defaultCacheTTL
+}
+
+type userIDKey struct{}
+
+// WithUserID returns a context carrying userID for flag evaluation outside HTTP handlers
+func WithUserID(ctx context.Context, userID uint) context.Context {
+	return context.WithValue(ctx, userIDKey{}, userID)
+}
+
+// userIDFromContext returns the user to evaluate flags for, or 0 for anonymous callers.
+// Gin contexts expose the authenticated claims set by the auth middleware.
+func userIDFromContext(ctx context.Context) uint {
+	if id, ok := ctx.Value(userIDKey{}).(uint); ok {
+		return id
+	}
+	if claims, ok := ctx.Value(auth.KeyUser).(*auth.Claims); ok && claims != nil {
+		return claims.UserID
+	}
+	return 0
+}
+
+func (s *service) Enabled(ctx context.Context, name string) bool {
+	flag, ok := s.definitions(ctx)[name]
+	if !ok {
+		return false
+	}
+	return evaluate(&flag, userIDFromContext(ctx))
+}
+
+func (s *service) Evaluate(ctx context.Context) map[string]bool {
+	userID := userIDFromContext(ctx)
+	flags := s.definitions(ctx)
+
+	result := make(map[string]bool, len(flags))
+	for name, flag := range flags {
+		result[name] = evaluate(&flag, userID)
+	}
+	return result
+}
+
+// evaluate applies deny list, allow list, percentage rollout and default in that order.
+// Anonymous users (userID 0) always get the default.
+func evaluate(f *Flag, userID uint) bool {
+	if userID == 0 {
+		return f.Enabled
+	}
+	if slices.Contains(f.DenyUsers, userID) {
+		return false
+	}
+	if slices.Contains(f.AllowUsers, userID) {
+		return true
+	}
+	if f.Percentage > 0 && bucket(f.Name, userID) < f.Percentage {
+		return true
+	}
+	return f.Enabled
+}
+
+// bucket deterministically maps a user to 0-99 for a flag, so a user stays in
+// the rollout as the percentage grows and different flags roll out to different users
+func bucket(name string, userID uint) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(name + ":" + strconv.FormatUint(uint64(userID), 10)))
+	return int(h.Sum32() % 100)
+}
+
+// definitions returns configured flags overlaid with stored flags, reloading
+// stored flags once the cache expires. On storage errors the last known
+// definitions are kept so evaluation never fails.
+func (s *service) definitions(ctx context.Context) map[string]Flag {
+	s.mu.RLock()
+	flags, loadedAt := s.flags, s.loadedAt
+	s.mu.RUnlock()
+
+	if flags != nil && s.now().Sub(loadedAt) < s.currentCacheTTL() {
+		return flags
+	}
+
+	stored, err := s.repo.List(ctx)
+	if err != nil {
+		slog.Warn("Failed to load feature flags, using cached definitions", "error", err)
+		if flags == nil {
+			return s.configFlags
+		}
+		return flags
+	}
+
+	merged := make(map[string]Flag, len(s.configFlags)+len(stored))
+	for name, f := range s.configFlags {
+		merged[name] = f
+	}
+	for _, f := range stored {
+		merged[f.Name] = f
+	}
+
+	s.mu.Lock()
+	s.flags, s.loadedAt = merged, s.now()
+	s.mu.Unlock()
+
+	return merged
+}
+
+// invalidate drops the cached definitions so changes are visible immediately on this instance
+func (s *service) invalidate() {
+	s.mu.Lock()
+	s.flags = nil
+	s.mu.Unlock()
+}
+
+// ListFlags returns all flag definitions ordered by name, bypassing the cache
+func (s *service) ListFlags(ctx context.Context) ([]Flag, error) {
+	s.invalidate()
+
+	stored, err := s.repo.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list feature flags: %w", err)
+	}
+
+	flags := make([]Flag, 0, len(s.configFlags)+len(stored))
+	for _, f := range stored {
+		flags = append(flags, f)
+	}
+	for name, f := range s.configFlags {
+		if !slices.ContainsFunc(stored, func(sf Flag) bool { return sf.Name == name }) {
+			flags = append(flags, f)
+		}
+	}
+	sort.Slice(flags, func(i, j int) bool { return flags[i].Name < flags[j].Name })
+
+	return flags, nil
+}
+
+// CreateFlag stores a new flag; names already defined in configuration must be overridden with UpdateFlag
+func (s *service) CreateFlag(ctx context.Context, req CreateFlagRequest) (*Flag, error) {
+	if !IsValidFlagName(req.Name) {
+		return nil, ErrInvalidFlagName
+	}
+	if _, ok := s.configFlags[req.Name]; ok {
+		return nil, ErrFlagExists
+	}
+
+	existing, err := s.repo.FindByName(ctx, req.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check existing feature flag: %w", err)
+	}
+	if existing != nil {
+		return nil, ErrFlagExists
+	}
+
+	flag := &Flag{Name: req.Name}
+	applyUpdate(flag, req.UpdateFlagRequest)
+	if err := s.repo.Create(ctx, flag); err != nil {
+		return nil, fmt.Errorf("failed to create feature flag: %w", err)
+	}
+
+	s.invalidate()
+	return flag, nil
+}
+
+// UpdateFlag replaces a flag definition. Updating a configured flag stores an override.
+func (s *service) UpdateFlag(ctx context.Context, name string, req UpdateFlagRequest) (*Flag, error) {
+	flag, err := s.repo.FindByName(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find feature flag: %w", err)
+	}
+
+	if flag == nil {
+		if _, ok := s.configFlags[name]; !ok {
+			return nil, ErrFlagNotFound
+		}
+		flag = &Flag{Name: name}
+		applyUpdate(flag, req)
+		if err := s.repo.Create(ctx, flag); err != nil {
+			return nil, fmt.Errorf("failed to create feature flag override: %w", err)
+		}
+	} else {
+		applyUpdate(flag, req)
+		if err := s.repo.Update(ctx, flag); err != nil {
+			return nil, fmt.Errorf("failed to update feature flag: %w", err)
+		}
+	}
+
+	s.invalidate()
+	return flag, nil
+}
+
+// DeleteFlag removes a stored flag; configured flags revert to their configured definition
+func (s *service) DeleteFlag(ctx context.Context, name string) error {
+	flag, err := s.repo.FindByName(ctx, name)
+	if err != nil {
+		return fmt.Errorf("failed to find feature flag: %w", err)
+	}
+	if flag == nil {
+		if _, ok := s.configFlags[name]; ok {
+			return ErrConfigFlag
+		}
+		return ErrFlagNotFound
+	}
+
+	if err := s.repo.Delete(ctx, flag.ID); err != nil {
+		return fmt.Errorf("failed to delete feature flag: %w", err)
+	}
+
+	s.invalidate()
+	return nil
+}
+
+func applyUpdate(flag *Flag, req UpdateFlagRequest) {
+	flag.Description = req.Description
+	flag.Enabled = req.Enabled
+	flag.Percentage = req.Percentage
+	flag.AllowUsers = nonNil(req.AllowUsers)
+	flag.DenyUsers = nonNil(req.DenyUsers)
+}
+
+func nonNil(ids []uint) []uint {
+	if ids == nil {
+		return []uint{}
+	}
+	return ids
+}
@@ -0,0 +1,190 @@
+package featureflags
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"github.com/yeegeek/uyou-go-api-starter/internal/auth"
+	"github.com/yeegeek/uyou-go-api-starter/internal/config"
+)
+
+func setupTestService(t *testing.T, cfg config.FeatureFlagsConfig) (*service, Repository) {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&Flag{}))
+
+	repo := NewRepository(db)
+	return NewService(repo, cfg).(*service), repo
+}
+
+func TestEvaluate(t *testing.T) {
+	flag := &Flag{
+		Name:       "two_factor_auth",
+		Percentage: 0,
+		AllowUsers: []uint{1},
+		DenyUsers:  []uint{2},
+	}
+
+	tests := []struct {
+		name    string
+		enabled bool
+		userID  uint
+		want    bool
+	}{
+		{name: "anonymous gets default off", enabled: false, userID: 0, want: false},
+		{name: "anonymous gets default on", enabled: true, userID: 0, want: true},
+		{name: "allowed user", enabled: false, userID: 1, want: true},
+		{name: "denied user overrides default", enabled: true, userID: 2, want: false},
+		{name: "other user gets default", enabled: true, userID: 3, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := *flag
+			f.Enabled = tt.enabled
+			assert.Equal(t, tt.want, evaluate(&f, tt.userID))
+		})
+	}
+}
+
+func TestEvaluate_PercentageRollout(t *testing.T) {
+	flag := &Flag{Name: "new_profile", Percentage: 30}
+
+	enabled := 0
+	for userID := uint(1); userID <= 1000; userID++ {
+		first := evaluate(flag, userID)
+		assert.Equal(t, first, evaluate(flag, userID), "evaluation must be deterministic")
+		if first {
+			enabled++
+		}
+	}
+	assert.InDelta(t, 300, enabled, 60)
+
+	// Users in a smaller rollout stay enabled when the percentage grows
+	wider := &Flag{Name: "new_profile", Percentage: 60}
+	for userID := uint(1); userID <= 1000; userID++ {
+		if evaluate(flag, userID) {
+			assert.True(t, evaluate(wider, userID))
+		}
+	}
+
+	assert.False(t, evaluate(flag, 0), "anonymous users are not bucketed")
+}
+
+func TestService_Enabled_Context(t *testing.T) {
+	svc, _ := setupTestService(t, config.FeatureFlagsConfig{
+		Flags: []config.FeatureFlagConfig{{Name: "beta", AllowUsers: []uint{7}}},
+	})
+
+	assert.False(t, svc.Enabled(context.Background(), "beta"))
+	assert.True(t, svc.Enabled(WithUserID(context.Background(), 7), "beta"))
+	assert.False(t, svc.Enabled(WithUserID(context.Background(), 7), "unknown"))
+
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(nil)
+	assert.False(t, svc.Enabled(c, "beta"))
+	c.Set(auth.KeyUser, &auth.Claims{UserID: 7})
+	assert.True(t, svc.Enabled(c, "beta"))
+}
+
+func TestService_DatabaseOverridesConfig(t *testing.T) {
+	ctx := context.Background()
+	svc, _ := setupTestService(t, config.FeatureFlagsConfig{
+		Flags: []config.FeatureFlagConfig{{Name: "beta", Enabled: false}},
+	})
+
+	assert.False(t, svc.Enabled(ctx, "beta"))
+
+	flag, err := svc.UpdateFlag(ctx, "beta", UpdateFlagRequest{Enabled: true})
+	require.NoError(t, err)
+	assert.Equal(t, SourceDatabase, flag.Source())
+	assert.True(t, svc.Enabled(ctx, "beta"))
+
+	flags, err := svc.ListFlags(ctx)
+	require.NoError(t, err)
+	require.Len(t, flags, 1)
+	assert.True(t, flags[0].Enabled)
+
+	require.NoError(t, svc.DeleteFlag(ctx, "beta"))
+	assert.False(t, svc.Enabled(ctx, "beta"), "configured definition applies again")
+	assert.ErrorIs(t, svc.DeleteFlag(ctx, "beta"), ErrConfigFlag)
+}
+
+func TestService_CacheTTL(t *testing.T) {
+	ctx := context.Background()
+	svc, repo := setupTestService(t, config.FeatureFlagsConfig{CacheTTL: time.Minute})
+
+	now := time.Now()
+	svc.now = func() time.Time { return now }
+
+	assert.False(t, svc.Enabled(ctx, "beta"))
+
+	// Simulate a change made through another instance
+	require.NoError(t, repo.Create(ctx, &Flag{Name: "beta", Enabled: true}))
+	assert.False(t, svc.Enabled(ctx, "beta"), "cached definitions are used until the TTL expires")
+
+	now = now.Add(time.Minute)
+	assert.True(t, svc.Enabled(ctx, "beta"))
+}
+
+func TestService_CacheTTLFunc(t *testing.T) {
+	ctx := context.Background()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&Flag{}))
+	repo := NewRepository(db)
+
+	ttl := time.Hour
+	svc := NewService(repo, config.FeatureFlagsConfig{CacheTTL: time.Hour},
+		WithCacheTTLFunc(func() time.Duration { return ttl }),
+	).(*service)
+	now := time.Now()
+	svc.now = func() time.Time { return now }
+
+	assert.False(t, svc.Enabled(ctx, "beta"))
+	require.NoError(t, repo.Create(ctx, &Flag{Name: "beta", Enabled: true}))
+
+	now = now.Add(time.Minute)
+	assert.False(t, svc.Enabled(ctx, "beta"), "still within the original TTL")
+
+	// A reloaded configuration shortens the TTL without recreating the service
+	ttl = 30 * time.Second
+	assert.True(t, svc.Enabled(ctx, "beta"))
+}
+
+func TestService_CreateFlag(t *testing.T) {
+	ctx := context.Background()
+	svc, _ := setupTestService(t, config.FeatureFlagsConfig{
+		Flags: []config.FeatureFlagConfig{{Name: "configured"}},
+	})
+
+	flag, err := svc.CreateFlag(ctx, CreateFlagRequest{
+		Name:              "beta",
+		UpdateFlagRequest: UpdateFlagRequest{Percentage: 100},
+	})
+	require.NoError(t, err)
+	assert.NotZero(t, flag.ID)
+	assert.Equal(t, []uint{}, flag.AllowUsers)
+	assert.True(t, svc.Enabled(WithUserID(ctx, 42), "beta"))
+
+	_, err = svc.CreateFlag(ctx, CreateFlagRequest{Name: "beta"})
+	assert.ErrorIs(t, err, ErrFlagExists)
+
+	_, err = svc.CreateFlag(ctx, CreateFlagRequest{Name: "configured"})
+	assert.ErrorIs(t, err, ErrFlagExists)
+
+	_, err = svc.CreateFlag(ctx, CreateFlagRequest{Name: "Bad Name"})
+	assert.ErrorIs(t, err, ErrInvalidFlagName)
+
+	_, err = svc.UpdateFlag(ctx, "missing", UpdateFlagRequest{})
+	assert.ErrorIs(t, err, ErrFlagNotFound)
+}
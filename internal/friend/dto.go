@@ -76,7 +76,7 @@ func ToFriendInfo(u *user.User) *FriendInfo {
 	return &FriendInfo{
 		ID:           u.ID,
 		Name:         u.Name,
-		Username:     u.Username,
+		Username:     u.GetUsername(),
 		Email:        u.Email,
 		AvatarURL:    u.AvatarURL,
 		Gender:       u.Gender,
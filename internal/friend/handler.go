@@ -34,7 +34,7 @@ func NewHandler(service Service) *Handler {
 func (h *Handler) SendFriendRequest(c *gin.Context) {
 	userID := contextutil.GetUserID(c)
 	if userID == 0 {
-		c.JSON(http.StatusUnauthorized, errors.Unauthorized("user not authenticated"))
+		c.JSON(http.StatusUnauthorized, errors.Unauthorized(errors.MsgUserNotAuthenticated))
 		return
 	}
 
@@ -49,7 +49,7 @@ func (h *Handler) SendFriendRequest(c *gin.Context) {
 		if apiErr, ok := err.(*errors.APIError); ok {
 			c.JSON(apiErr.Status, apiErr)
 		} else {
-			c.JSON(http.StatusInternalServerError, errors.InternalServerError(err))
+			_ = c.Error(errors.InternalServerError(err))
 		}
 		return
 	}
@@ -70,7 +70,7 @@ func (h *Handler) SendFriendRequest(c *gin.Context) {
 func (h *Handler) GetFriendsList(c *gin.Context) {
 	userID := contextutil.GetUserID(c)
 	if userID == 0 {
-		c.JSON(http.StatusUnauthorized, errors.Unauthorized("user not authenticated"))
+		c.JSON(http.StatusUnauthorized, errors.Unauthorized(errors.MsgUserNotAuthenticated))
 		return
 	}
 
@@ -79,7 +79,7 @@ func (h *Handler) GetFriendsList(c *gin.Context) {
 		if apiErr, ok := err.(*errors.APIError); ok {
 			c.JSON(apiErr.Status, apiErr)
 		} else {
-			c.JSON(http.StatusInternalServerError, errors.InternalServerError(err))
+			_ = c.Error(errors.InternalServerError(err))
 		}
 		return
 	}
@@ -100,7 +100,7 @@ func (h *Handler) GetFriendsList(c *gin.Context) {
 func (h *Handler) GetFriendRequests(c *gin.Context) {
 	userID := contextutil.GetUserID(c)
 	if userID == 0 {
-		c.JSON(http.StatusUnauthorized, errors.Unauthorized("user not authenticated"))
+		c.JSON(http.StatusUnauthorized, errors.Unauthorized(errors.MsgUserNotAuthenticated))
 		return
 	}
 
@@ -109,7 +109,7 @@ func (h *Handler) GetFriendRequests(c *gin.Context) {
 		if apiErr, ok := err.(*errors.APIError); ok {
 			c.JSON(apiErr.Status, apiErr)
 		} else {
-			c.JSON(http.StatusInternalServerError, errors.InternalServerError(err))
+			_ = c.Error(errors.InternalServerError(err))
 		}
 		return
 	}
@@ -132,7 +132,7 @@ func (h *Handler) GetFriendRequests(c *gin.Context) {
 func (h *Handler) AcceptFriendRequest(c *gin.Context) {
 	userID := contextutil.GetUserID(c)
 	if userID == 0 {
-		c.JSON(http.StatusUnauthorized, errors.Unauthorized("user not authenticated"))
+		c.JSON(http.StatusUnauthorized, errors.Unauthorized(errors.MsgUserNotAuthenticated))
 		return
 	}
 
@@ -147,7 +147,7 @@ func (h *Handler) AcceptFriendRequest(c *gin.Context) {
 		if apiErr, ok := err.(*errors.APIError); ok {
 			c.JSON(apiErr.Status, apiErr)
 		} else {
-			c.JSON(http.StatusInternalServerError, errors.InternalServerError(err))
+			_ = c.Error(errors.InternalServerError(err))
 		}
 		return
 	}
@@ -170,7 +170,7 @@ func (h *Handler) AcceptFriendRequest(c *gin.Context) {
 func (h *Handler) RejectFriendRequest(c *gin.Context) {
 	userID := contextutil.GetUserID(c)
 	if userID == 0 {
-		c.JSON(http.StatusUnauthorized, errors.Unauthorized("user not authenticated"))
+		c.JSON(http.StatusUnauthorized, errors.Unauthorized(errors.MsgUserNotAuthenticated))
 		return
 	}
 
@@ -184,7 +184,7 @@ func (h *Handler) RejectFriendRequest(c *gin.Context) {
 		if apiErr, ok := err.(*errors.APIError); ok {
 			c.JSON(apiErr.Status, apiErr)
 		} else {
-			c.JSON(http.StatusInternalServerError, errors.InternalServerError(err))
+			_ = c.Error(errors.InternalServerError(err))
 		}
 		return
 	}
@@ -207,7 +207,7 @@ func (h *Handler) RejectFriendRequest(c *gin.Context) {
 func (h *Handler) DeleteFriend(c *gin.Context) {
 	userID := contextutil.GetUserID(c)
 	if userID == 0 {
-		c.JSON(http.StatusUnauthorized, errors.Unauthorized("user not authenticated"))
+		c.JSON(http.StatusUnauthorized, errors.Unauthorized(errors.MsgUserNotAuthenticated))
 		return
 	}
 
@@ -221,7 +221,7 @@ func (h *Handler) DeleteFriend(c *gin.Context) {
 		if apiErr, ok := err.(*errors.APIError); ok {
 			c.JSON(apiErr.Status, apiErr)
 		} else {
-			c.JSON(http.StatusInternalServerError, errors.InternalServerError(err))
+			_ = c.Error(errors.InternalServerError(err))
 		}
 		return
 	}
@@ -245,7 +245,7 @@ func (h *Handler) DeleteFriend(c *gin.Context) {
 func (h *Handler) UpdateFriendRemark(c *gin.Context) {
 	userID := contextutil.GetUserID(c)
 	if userID == 0 {
-		c.JSON(http.StatusUnauthorized, errors.Unauthorized("user not authenticated"))
+		c.JSON(http.StatusUnauthorized, errors.Unauthorized(errors.MsgUserNotAuthenticated))
 		return
 	}
 
@@ -265,7 +265,7 @@ func (h *Handler) UpdateFriendRemark(c *gin.Context) {
 		if apiErr, ok := err.(*errors.APIError); ok {
 			c.JSON(apiErr.Status, apiErr)
 		} else {
-			c.JSON(http.StatusInternalServerError, errors.InternalServerError(err))
+			_ = c.Error(errors.InternalServerError(err))
 		}
 		return
 	}
@@ -289,7 +289,7 @@ func (h *Handler) UpdateFriendRemark(c *gin.Context) {
 func (h *Handler) UpdateFriendGroup(c *gin.Context) {
 	userID := contextutil.GetUserID(c)
 	if userID == 0 {
-		c.JSON(http.StatusUnauthorized, errors.Unauthorized("user not authenticated"))
+		c.JSON(http.StatusUnauthorized, errors.Unauthorized(errors.MsgUserNotAuthenticated))
 		return
 	}
 
@@ -309,7 +309,7 @@ func (h *Handler) UpdateFriendGroup(c *gin.Context) {
 		if apiErr, ok := err.(*errors.APIError); ok {
 			c.JSON(apiErr.Status, apiErr)
 		} else {
-			c.JSON(http.StatusInternalServerError, errors.InternalServerError(err))
+			_ = c.Error(errors.InternalServerError(err))
 		}
 		return
 	}
@@ -333,13 +333,13 @@ func (h *Handler) UpdateFriendGroup(c *gin.Context) {
 func (h *Handler) BlockUser(c *gin.Context) {
 	userID := contextutil.GetUserID(c)
 	if userID == 0 {
-		c.JSON(http.StatusUnauthorized, errors.Unauthorized("user not authenticated"))
+		c.JSON(http.StatusUnauthorized, errors.Unauthorized(errors.MsgUserNotAuthenticated))
 		return
 	}
 
 	blockedUserID, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, errors.BadRequest("invalid user ID"))
+		c.JSON(http.StatusBadRequest, errors.BadRequest(errors.MsgInvalidUserID))
 		return
 	}
 
@@ -353,7 +353,7 @@ func (h *Handler) BlockUser(c *gin.Context) {
 		if apiErr, ok := err.(*errors.APIError); ok {
 			c.JSON(apiErr.Status, apiErr)
 		} else {
-			c.JSON(http.StatusInternalServerError, errors.InternalServerError(err))
+			_ = c.Error(errors.InternalServerError(err))
 		}
 		return
 	}
@@ -376,13 +376,13 @@ func (h *Handler) BlockUser(c *gin.Context) {
 func (h *Handler) UnblockUser(c *gin.Context) {
 	userID := contextutil.GetUserID(c)
 	if userID == 0 {
-		c.JSON(http.StatusUnauthorized, errors.Unauthorized("user not authenticated"))
+		c.JSON(http.StatusUnauthorized, errors.Unauthorized(errors.MsgUserNotAuthenticated))
 		return
 	}
 
 	blockedUserID, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, errors.BadRequest("invalid user ID"))
+		c.JSON(http.StatusBadRequest, errors.BadRequest(errors.MsgInvalidUserID))
 		return
 	}
 
@@ -390,7 +390,7 @@ func (h *Handler) UnblockUser(c *gin.Context) {
 		if apiErr, ok := err.(*errors.APIError); ok {
 			c.JSON(apiErr.Status, apiErr)
 		} else {
-			c.JSON(http.StatusInternalServerError, errors.InternalServerError(err))
+			_ = c.Error(errors.InternalServerError(err))
 		}
 		return
 	}
@@ -411,7 +411,7 @@ func (h *Handler) UnblockUser(c *gin.Context) {
 func (h *Handler) GetBlockedUsers(c *gin.Context) {
 	userID := contextutil.GetUserID(c)
 	if userID == 0 {
-		c.JSON(http.StatusUnauthorized, errors.Unauthorized("user not authenticated"))
+		c.JSON(http.StatusUnauthorized, errors.Unauthorized(errors.MsgUserNotAuthenticated))
 		return
 	}
 
@@ -420,7 +420,7 @@ func (h *Handler) GetBlockedUsers(c *gin.Context) {
 		if apiErr, ok := err.(*errors.APIError); ok {
 			c.JSON(apiErr.Status, apiErr)
 		} else {
-			c.JSON(http.StatusInternalServerError, errors.InternalServerError(err))
+			_ = c.Error(errors.InternalServerError(err))
 		}
 		return
 	}
@@ -0,0 +1,84 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+	"reflect"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// redactedValue 替换敏感字段的占位值
+const redactedValue = "[REDACTED]"
+
+// sensitiveFields 日志中需要脱敏的字段名（小写，按子串匹配）
+var sensitiveFields = []string{"password", "token", "secret"}
+
+// LoggingInterceptor 记录每次一元调用的方法、耗时、状态码和脱敏后的请求字段
+func LoggingInterceptor(logger *slog.Logger) grpc.UnaryServerInterceptor {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+
+		attrs := []any{
+			"method", info.FullMethod,
+			"code", status.Code(err).String(),
+			"duration", time.Since(start),
+			"request", redactFields(req),
+		}
+		if err != nil {
+			logger.WarnContext(ctx, "gRPC request failed", append(attrs, "err", err)...)
+		} else {
+			logger.InfoContext(ctx, "gRPC request completed", attrs...)
+		}
+
+		return resp, err
+	}
+}
+
+// redactFields 将请求消息转换为字段映射，并把敏感字段替换为占位值
+func redactFields(msg any) map[string]any {
+	v := reflect.ValueOf(msg)
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+
+	t := v.Type()
+	fields := make(map[string]any, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		if isSensitiveField(field.Name) {
+			fields[field.Name] = redactedValue
+			continue
+		}
+		fields[field.Name] = v.Field(i).Interface()
+	}
+	return fields
+}
+
+// isSensitiveField 判断字段名是否属于敏感字段
+func isSensitiveField(name string) bool {
+	lower := strings.ToLower(name)
+	for _, s := range sensitiveFields {
+		if strings.Contains(lower, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,74 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	pb "github.com/yeegeek/uyou-go-api-starter/api/proto/user"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestRedactFields(t *testing.T) {
+	fields := redactFields(&pb.AuthenticateRequest{Email: "test@example.com", Password: "Password123!"})
+
+	assert.Equal(t, "test@example.com", fields["Email"])
+	assert.Equal(t, redactedValue, fields["Password"])
+	assert.Nil(t, redactFields(nil))
+	assert.Nil(t, redactFields((*pb.AuthenticateRequest)(nil)))
+}
+
+func TestLoggingInterceptor(t *testing.T) {
+	tests := []struct {
+		name      string
+		handler   grpc.UnaryHandler
+		wantLevel string
+		wantCode  string
+	}{
+		{
+			name: "successful call",
+			handler: func(ctx context.Context, req any) (any, error) {
+				return &pb.AuthenticateResponse{}, nil
+			},
+			wantLevel: "level=INFO",
+			wantCode:  "code=OK",
+		},
+		{
+			name: "failed call",
+			handler: func(ctx context.Context, req any) (any, error) {
+				return nil, status.Error(codes.Unauthenticated, "invalid credentials")
+			},
+			wantLevel: "level=WARN",
+			wantCode:  "code=Unauthenticated",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := slog.New(slog.NewTextHandler(&buf, nil))
+			interceptor := LoggingInterceptor(logger)
+
+			req := &pb.CreateUserRequest{Name: "New User", Email: "new@example.com", Password: "Sup3rSecret!"}
+			info := &grpc.UnaryServerInfo{FullMethod: "/user.UserService/CreateUser"}
+			_, err := interceptor(context.Background(), req, info, tt.handler)
+
+			if tt.wantCode == "code=OK" {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+			out := buf.String()
+			assert.Contains(t, out, tt.wantLevel)
+			assert.Contains(t, out, tt.wantCode)
+			assert.Contains(t, out, "/user.UserService/CreateUser")
+			assert.Contains(t, out, "new@example.com")
+			assert.Contains(t, out, redactedValue)
+			assert.NotContains(t, out, "Sup3rSecret!")
+		})
+	}
+}
@@ -25,6 +25,7 @@ func NewServer(cfg *config.Config, userService user.Service) *Server {
 	opts := []grpc.ServerOption{
 		grpc.MaxRecvMsgSize(cfg.GRPC.MaxRecvMsgSize),
 		grpc.MaxSendMsgSize(cfg.GRPC.MaxSendMsgSize),
+		grpc.ChainUnaryInterceptor(LoggingInterceptor(slog.Default())),
 	}
 
 	// 创建 gRPC 服务器
@@ -3,12 +3,14 @@ package server
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	pb "github.com/yeegeek/uyou-go-api-starter/api/proto/user"
 	"github.com/yeegeek/uyou-go-api-starter/internal/user"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
 )
 
 // UserServiceServer 用户服务 gRPC 服务器实现
@@ -16,14 +18,37 @@ type UserServiceServer struct {
 	pb.UnimplementedUserServiceServer
 	userService user.Service
 	userRepo    user.Repository
+	// readMask 查询类接口返回的用户字段，nil 表示全部字段
+	readMask *fieldmaskpb.FieldMask
 }
 
-// NewUserServiceServer 创建用户服务 gRPC 服务器
-func NewUserServiceServer(userService user.Service, userRepo user.Repository) *UserServiceServer {
-	return &UserServiceServer{
+// UserServerOption 配置 UserServiceServer 的可选行为
+type UserServerOption func(*UserServiceServer)
+
+// WithReadMask 限制 GetUser、GetUserByEmail 和 ListUsers 返回的用户字段，
+// 调用方不是可信的内部服务时传入 PublicUserFields()，不返回邮箱
+// 创建、更新和认证接口返回调用方操作的用户本身，始终返回全部字段
+func WithReadMask(mask *fieldmaskpb.FieldMask) UserServerOption {
+	return func(s *UserServiceServer) {
+		s.readMask = mask
+	}
+}
+
+// PublicUserFields 返回公开用户字段的掩码，与 HTTP 接口的 PublicUserResponse 一致
+func PublicUserFields() *fieldmaskpb.FieldMask {
+	return &fieldmaskpb.FieldMask{Paths: []string{"id", "name"}}
+}
+
+// NewUserServiceServer 创建用户服务 gRPC 服务器，默认返回全部用户字段
+func NewUserServiceServer(userService user.Service, userRepo user.Repository, opts ...UserServerOption) *UserServiceServer {
+	s := &UserServiceServer{
 		userService: userService,
 		userRepo:    userRepo,
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
 // GetUser 获取用户信息
@@ -36,7 +61,7 @@ func (s *UserServiceServer) GetUser(ctx context.Context, req *pb.GetUserRequest)
 
 	// 转换为 protobuf 消息
 	return &pb.GetUserResponse{
-		User: convertUserToProto(usr),
+		User: convertUserToProto(usr, s.readMask),
 	}, nil
 }
 
@@ -50,7 +75,7 @@ func (s *UserServiceServer) GetUserByEmail(ctx context.Context, req *pb.GetUserB
 
 	// 转换为 protobuf 消息
 	return &pb.GetUserResponse{
-		User: convertUserToProto(usr),
+		User: convertUserToProto(usr, s.readMask),
 	}, nil
 }
 
@@ -76,7 +101,7 @@ func (s *UserServiceServer) ListUsers(ctx context.Context, req *pb.ListUsersRequ
 	// 转换为 protobuf 消息
 	pbUsers := make([]*pb.User, len(users))
 	for i, usr := range users {
-		pbUsers[i] = convertUserToProto(&usr)
+		pbUsers[i] = convertUserToProto(&usr, s.readMask)
 	}
 
 	return &pb.ListUsersResponse{
@@ -103,7 +128,7 @@ func (s *UserServiceServer) UpdateUser(ctx context.Context, req *pb.UpdateUserRe
 
 	// 转换为 protobuf 消息
 	return &pb.UpdateUserResponse{
-		User: convertUserToProto(usr),
+		User: convertUserToProto(usr, nil),
 	}, nil
 }
 
@@ -121,14 +146,110 @@ func (s *UserServiceServer) DeleteUser(ctx context.Context, req *pb.DeleteUserRe
 	}, nil
 }
 
-// convertUserToProto 将用户模型转换为 protobuf 消息
-func convertUserToProto(usr *user.User) *pb.User {
-	return &pb.User{
-		Id:        uint32(usr.ID),
-		Name:      usr.Name,
-		Email:     usr.Email,
-		Roles:     usr.GetRoleNames(),
-		CreatedAt: usr.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-		UpdatedAt: usr.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+// CreateUser 创建用户
+func (s *UserServiceServer) CreateUser(ctx context.Context, req *pb.CreateUserRequest) (*pb.CreateUserResponse, error) {
+	// 构建注册请求
+	registerReq := user.RegisterRequest{
+		Name:     req.Name,
+		Email:    req.Email,
+		Password: req.Password,
+	}
+
+	// 调用用户服务
+	usr, err := s.userService.RegisterUser(ctx, registerReq)
+	if err != nil {
+		if errors.Is(err, user.ErrEmailExists) {
+			return nil, status.Error(codes.AlreadyExists, "email already exists")
+		}
+		if isPasswordPolicyError(err) {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid password: %v", err)
+		}
+		if errors.Is(err, user.ErrTermsNotAccepted) {
+			return nil, status.Error(codes.FailedPrecondition, "terms must be accepted")
+		}
+		if errors.Is(err, user.ErrEmailDomainNotAllowed) {
+			return nil, status.Error(codes.InvalidArgument, "email domain not allowed")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to create user: %v", err)
+	}
+
+	// 转换为 protobuf 消息
+	return &pb.CreateUserResponse{
+		User: convertUserToProto(usr, nil),
+	}, nil
+}
+
+// Authenticate 校验用户凭证
+func (s *UserServiceServer) Authenticate(ctx context.Context, req *pb.AuthenticateRequest) (*pb.AuthenticateResponse, error) {
+	// 构建登录请求
+	loginReq := user.LoginRequest{
+		Email:    req.Email,
+		Password: req.Password,
+	}
+
+	// 调用用户服务
+	usr, err := s.userService.AuthenticateUser(ctx, loginReq)
+	if err != nil {
+		if errors.Is(err, user.ErrInvalidCredentials) {
+			return nil, status.Error(codes.Unauthenticated, "invalid credentials")
+		}
+		if errors.Is(err, user.ErrAccountDisabled) {
+			return nil, status.Error(codes.PermissionDenied, "account disabled")
+		}
+		if errors.Is(err, user.ErrAccountLocked) {
+			return nil, status.Error(codes.ResourceExhausted, "account temporarily locked")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to authenticate user: %v", err)
+	}
+
+	// 转换为 protobuf 消息
+	return &pb.AuthenticateResponse{
+		User: convertUserToProto(usr, nil),
+	}, nil
+}
+
+// isPasswordPolicyError 判断错误是否为密码策略校验失败
+func isPasswordPolicyError(err error) bool {
+	return errors.Is(err, user.ErrPasswordTooShort) ||
+		errors.Is(err, user.ErrPasswordMissingUppercase) ||
+		errors.Is(err, user.ErrPasswordMissingLowercase) ||
+		errors.Is(err, user.ErrPasswordMissingNumber) ||
+		errors.Is(err, user.ErrPasswordMissingSpecial)
+}
+
+// convertUserToProto 将用户模型转换为 protobuf 消息；mask 为 nil 时填充全部字段，
+// 否则只填充 mask 中列出的字段（按 proto 字段名，未知字段忽略），其余字段保持零值
+func convertUserToProto(usr *user.User, mask *fieldmaskpb.FieldMask) *pb.User {
+	if mask == nil {
+		return &pb.User{
+			Id:        uint32(usr.ID),
+			Name:      usr.Name,
+			Email:     usr.Email,
+			Roles:     usr.GetRoleNames(),
+			CreatedAt: usr.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+			UpdatedAt: usr.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+			Status:    usr.AccountStatus(),
+		}
+	}
+
+	pbUser := &pb.User{}
+	for _, path := range mask.GetPaths() {
+		switch path {
+		case "id":
+			pbUser.Id = uint32(usr.ID)
+		case "name":
+			pbUser.Name = usr.Name
+		case "email":
+			pbUser.Email = usr.Email
+		case "roles":
+			pbUser.Roles = usr.GetRoleNames()
+		case "created_at":
+			pbUser.CreatedAt = usr.CreatedAt.Format("2006-01-02T15:04:05Z07:00")
+		case "updated_at":
+			pbUser.UpdatedAt = usr.UpdatedAt.Format("2006-01-02T15:04:05Z07:00")
+		case "status":
+			pbUser.Status = usr.AccountStatus()
+		}
 	}
+	return pbUser
 }
@@ -0,0 +1,64 @@
+//go:build integration
+
+package server
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	pb "github.com/yeegeek/uyou-go-api-starter/api/proto/user"
+	"github.com/yeegeek/uyou-go-api-starter/internal/config"
+	"github.com/yeegeek/uyou-go-api-starter/internal/testutil/pgtest"
+	"github.com/yeegeek/uyou-go-api-starter/internal/user"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestMain(m *testing.M) {
+	os.Exit(pgtest.Main(m))
+}
+
+func TestUserServiceServer_Postgres(t *testing.T) {
+	db := pgtest.NewDB(t)
+	repo := user.NewRepository(db)
+	server := NewUserServiceServer(user.NewService(repo, &config.SecurityConfig{}), repo)
+	ctx := context.Background()
+
+	created, err := server.CreateUser(ctx, &pb.CreateUserRequest{Name: "Jane Doe", Email: "jane@example.com", Password: "SecurePass123!"})
+	require.NoError(t, err)
+	id := created.User.Id
+	assert.NotZero(t, id)
+
+	_, err = server.CreateUser(ctx, &pb.CreateUserRequest{Name: "Jane Again", Email: "jane@example.com", Password: "SecurePass123!"})
+	assert.Equal(t, codes.AlreadyExists, status.Code(err))
+
+	_, err = server.CreateUser(ctx, &pb.CreateUserRequest{Name: "John Doe", Email: "john@example.com", Password: "SecurePass123!"})
+	require.NoError(t, err, "a second user without a username is accepted")
+
+	got, err := server.GetUserByEmail(ctx, &pb.GetUserByEmailRequest{Email: "jane@example.com"})
+	require.NoError(t, err)
+	assert.Equal(t, id, got.User.Id)
+
+	authenticated, err := server.Authenticate(ctx, &pb.AuthenticateRequest{Email: "jane@example.com", Password: "SecurePass123!"})
+	require.NoError(t, err)
+	assert.Equal(t, id, authenticated.User.Id)
+	_, err = server.Authenticate(ctx, &pb.AuthenticateRequest{Email: "jane@example.com", Password: "wrong"})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	updated, err := server.UpdateUser(ctx, &pb.UpdateUserRequest{Id: id, Name: "Jane Smith"})
+	require.NoError(t, err)
+	assert.Equal(t, "Jane Smith", updated.User.Name)
+
+	list, err := server.ListUsers(ctx, &pb.ListUsersRequest{Page: 1, PageSize: 10})
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), list.Total)
+	assert.Len(t, list.Users, 2)
+
+	_, err = server.DeleteUser(ctx, &pb.DeleteUserRequest{Id: id})
+	require.NoError(t, err)
+	_, err = server.GetUser(ctx, &pb.GetUserRequest{Id: id})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
@@ -3,158 +3,23 @@ package server
 import (
 	"context"
 	"errors"
+	"fmt"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	pb "github.com/yeegeek/uyou-go-api-starter/api/proto/user"
+	"github.com/yeegeek/uyou-go-api-starter/internal/testutil/usertest"
 	"github.com/yeegeek/uyou-go-api-starter/internal/user"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
 )
 
-// MockUserService Mock 用户服务
-type MockUserService struct {
-	mock.Mock
-}
-
-func (m *MockUserService) RegisterUser(ctx context.Context, req user.RegisterRequest) (*user.User, error) {
-	args := m.Called(ctx, req)
-	if args.Get(0) == nil {
-		return nil, args.Error(1)
-	}
-	return args.Get(0).(*user.User), args.Error(1)
-}
-
-func (m *MockUserService) AuthenticateUser(ctx context.Context, req user.LoginRequest) (*user.User, error) {
-	args := m.Called(ctx, req)
-	if args.Get(0) == nil {
-		return nil, args.Error(1)
-	}
-	return args.Get(0).(*user.User), args.Error(1)
-}
-
-func (m *MockUserService) GetUserByID(ctx context.Context, id uint) (*user.User, error) {
-	args := m.Called(ctx, id)
-	if args.Get(0) == nil {
-		return nil, args.Error(1)
-	}
-	return args.Get(0).(*user.User), args.Error(1)
-}
-
-func (m *MockUserService) GetUserByEmail(ctx context.Context, email string) (*user.User, error) {
-	args := m.Called(ctx, email)
-	if args.Get(0) == nil {
-		return nil, args.Error(1)
-	}
-	return args.Get(0).(*user.User), args.Error(1)
-}
-
-func (m *MockUserService) UpdateUser(ctx context.Context, id uint, req user.UpdateUserRequest) (*user.User, error) {
-	args := m.Called(ctx, id, req)
-	if args.Get(0) == nil {
-		return nil, args.Error(1)
-	}
-	return args.Get(0).(*user.User), args.Error(1)
-}
-
-func (m *MockUserService) DeleteUser(ctx context.Context, id uint) error {
-	args := m.Called(ctx, id)
-	return args.Error(0)
-}
-
-func (m *MockUserService) ListUsers(ctx context.Context, filters user.UserFilterParams, page, perPage int) ([]user.User, int64, error) {
-	args := m.Called(ctx, filters, page, perPage)
-	if args.Get(0) == nil {
-		return nil, args.Get(1).(int64), args.Error(2)
-	}
-	return args.Get(0).([]user.User), args.Get(1).(int64), args.Error(2)
-}
-
-func (m *MockUserService) PromoteToAdmin(ctx context.Context, userID uint) error {
-	args := m.Called(ctx, userID)
-	return args.Error(0)
-}
-
-// MockUserRepository Mock 用户仓库
-type MockUserRepository struct {
-	mock.Mock
-}
-
-func (m *MockUserRepository) Create(ctx context.Context, usr *user.User) error {
-	args := m.Called(ctx, usr)
-	return args.Error(0)
-}
-
-func (m *MockUserRepository) FindByEmail(ctx context.Context, email string) (*user.User, error) {
-	args := m.Called(ctx, email)
-	if args.Get(0) == nil {
-		return nil, args.Error(1)
-	}
-	return args.Get(0).(*user.User), args.Error(1)
-}
-
-func (m *MockUserRepository) FindByID(ctx context.Context, id uint) (*user.User, error) {
-	args := m.Called(ctx, id)
-	if args.Get(0) == nil {
-		return nil, args.Error(1)
-	}
-	return args.Get(0).(*user.User), args.Error(1)
-}
-
-func (m *MockUserRepository) Update(ctx context.Context, usr *user.User) error {
-	args := m.Called(ctx, usr)
-	return args.Error(0)
-}
-
-func (m *MockUserRepository) Delete(ctx context.Context, id uint) error {
-	args := m.Called(ctx, id)
-	return args.Error(0)
-}
-
-func (m *MockUserRepository) ListAllUsers(ctx context.Context, filters user.UserFilterParams, page, perPage int) ([]user.User, int64, error) {
-	args := m.Called(ctx, filters, page, perPage)
-	if args.Get(0) == nil {
-		return nil, args.Get(1).(int64), args.Error(2)
-	}
-	return args.Get(0).([]user.User), args.Get(1).(int64), args.Error(2)
-}
-
-func (m *MockUserRepository) AssignRole(ctx context.Context, userID uint, roleName string) error {
-	args := m.Called(ctx, userID, roleName)
-	return args.Error(0)
-}
-
-func (m *MockUserRepository) RemoveRole(ctx context.Context, userID uint, roleName string) error {
-	args := m.Called(ctx, userID, roleName)
-	return args.Error(0)
-}
-
-func (m *MockUserRepository) FindRoleByName(ctx context.Context, name string) (*user.Role, error) {
-	args := m.Called(ctx, name)
-	if args.Get(0) == nil {
-		return nil, args.Error(1)
-	}
-	return args.Get(0).(*user.Role), args.Error(1)
-}
-
-func (m *MockUserRepository) GetUserRoles(ctx context.Context, userID uint) ([]user.Role, error) {
-	args := m.Called(ctx, userID)
-	if args.Get(0) == nil {
-		return nil, args.Error(1)
-	}
-	return args.Get(0).([]user.Role), args.Error(1)
-}
-
-func (m *MockUserRepository) Transaction(ctx context.Context, fn func(context.Context) error) error {
-	args := m.Called(ctx, fn)
-	return args.Error(0)
-}
-
 func TestNewUserServiceServer(t *testing.T) {
-	mockService := new(MockUserService)
-	mockRepo := new(MockUserRepository)
+	mockService := new(usertest.MockService)
+	mockRepo := new(usertest.MockRepository)
 
 	server := NewUserServiceServer(mockService, mockRepo)
 	assert.NotNil(t, server)
@@ -166,7 +31,7 @@ func TestUserServiceServer_GetUser(t *testing.T) {
 	tests := []struct {
 		name        string
 		req         *pb.GetUserRequest
-		setupMock   func(*MockUserService)
+		setupMock   func(*usertest.MockService)
 		wantErr     bool
 		wantCode    codes.Code
 		wantMessage string
@@ -174,7 +39,7 @@ func TestUserServiceServer_GetUser(t *testing.T) {
 		{
 			name: "successful get user",
 			req:  &pb.GetUserRequest{Id: 1},
-			setupMock: func(m *MockUserService) {
+			setupMock: func(m *usertest.MockService) {
 				m.On("GetUserByID", mock.Anything, uint(1)).Return(&user.User{
 					ID:    1,
 					Name:  "Test User",
@@ -187,7 +52,7 @@ func TestUserServiceServer_GetUser(t *testing.T) {
 		{
 			name: "user not found",
 			req:  &pb.GetUserRequest{Id: 999},
-			setupMock: func(m *MockUserService) {
+			setupMock: func(m *usertest.MockService) {
 				m.On("GetUserByID", mock.Anything, uint(999)).Return(nil, errors.New("user not found"))
 			},
 			wantErr:     true,
@@ -198,8 +63,8 @@ func TestUserServiceServer_GetUser(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			mockService := new(MockUserService)
-			mockRepo := new(MockUserRepository)
+			mockService := new(usertest.MockService)
+			mockRepo := new(usertest.MockRepository)
 			tt.setupMock(mockService)
 
 			server := NewUserServiceServer(mockService, mockRepo)
@@ -230,7 +95,7 @@ func TestUserServiceServer_GetUserByEmail(t *testing.T) {
 	tests := []struct {
 		name        string
 		req         *pb.GetUserByEmailRequest
-		setupMock   func(*MockUserRepository)
+		setupMock   func(*usertest.MockRepository)
 		wantErr     bool
 		wantCode    codes.Code
 		wantMessage string
@@ -238,7 +103,7 @@ func TestUserServiceServer_GetUserByEmail(t *testing.T) {
 		{
 			name: "successful get user by email",
 			req:  &pb.GetUserByEmailRequest{Email: "test@example.com"},
-			setupMock: func(m *MockUserRepository) {
+			setupMock: func(m *usertest.MockRepository) {
 				m.On("FindByEmail", mock.Anything, "test@example.com").Return(&user.User{
 					ID:    1,
 					Name:  "Test User",
@@ -251,7 +116,7 @@ func TestUserServiceServer_GetUserByEmail(t *testing.T) {
 		{
 			name: "user not found",
 			req:  &pb.GetUserByEmailRequest{Email: "notfound@example.com"},
-			setupMock: func(m *MockUserRepository) {
+			setupMock: func(m *usertest.MockRepository) {
 				m.On("FindByEmail", mock.Anything, "notfound@example.com").Return(nil, errors.New("user not found"))
 			},
 			wantErr:     true,
@@ -262,8 +127,8 @@ func TestUserServiceServer_GetUserByEmail(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			mockService := new(MockUserService)
-			mockRepo := new(MockUserRepository)
+			mockService := new(usertest.MockService)
+			mockRepo := new(usertest.MockRepository)
 			tt.setupMock(mockRepo)
 
 			server := NewUserServiceServer(mockService, mockRepo)
@@ -292,7 +157,7 @@ func TestUserServiceServer_ListUsers(t *testing.T) {
 	tests := []struct {
 		name      string
 		req       *pb.ListUsersRequest
-		setupMock func(*MockUserService)
+		setupMock func(*usertest.MockService)
 		wantErr   bool
 		wantTotal int32
 	}{
@@ -302,7 +167,7 @@ func TestUserServiceServer_ListUsers(t *testing.T) {
 				Page:     1,
 				PageSize: 10,
 			},
-			setupMock: func(m *MockUserService) {
+			setupMock: func(m *usertest.MockService) {
 				users := []user.User{
 					{ID: 1, Name: "User 1", Email: "user1@example.com"},
 					{ID: 2, Name: "User 2", Email: "user2@example.com"},
@@ -315,7 +180,7 @@ func TestUserServiceServer_ListUsers(t *testing.T) {
 		{
 			name: "default pagination",
 			req:  &pb.ListUsersRequest{},
-			setupMock: func(m *MockUserService) {
+			setupMock: func(m *usertest.MockService) {
 				users := []user.User{}
 				m.On("ListUsers", mock.Anything, user.UserFilterParams{}, 1, 10).Return(users, int64(0), nil)
 			},
@@ -328,7 +193,7 @@ func TestUserServiceServer_ListUsers(t *testing.T) {
 				Page:     1,
 				PageSize: 10,
 			},
-			setupMock: func(m *MockUserService) {
+			setupMock: func(m *usertest.MockService) {
 				m.On("ListUsers", mock.Anything, user.UserFilterParams{}, 1, 10).Return(nil, int64(0), errors.New("database error"))
 			},
 			wantErr: true,
@@ -337,8 +202,8 @@ func TestUserServiceServer_ListUsers(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			mockService := new(MockUserService)
-			mockRepo := new(MockUserRepository)
+			mockService := new(usertest.MockService)
+			mockRepo := new(usertest.MockRepository)
 			tt.setupMock(mockService)
 
 			server := NewUserServiceServer(mockService, mockRepo)
@@ -362,7 +227,7 @@ func TestUserServiceServer_UpdateUser(t *testing.T) {
 	tests := []struct {
 		name        string
 		req         *pb.UpdateUserRequest
-		setupMock   func(*MockUserService)
+		setupMock   func(*usertest.MockService)
 		wantErr     bool
 		wantCode    codes.Code
 		wantMessage string
@@ -374,7 +239,7 @@ func TestUserServiceServer_UpdateUser(t *testing.T) {
 				Name:  "Updated Name",
 				Email: "updated@example.com",
 			},
-			setupMock: func(m *MockUserService) {
+			setupMock: func(m *usertest.MockService) {
 				m.On("UpdateUser", mock.Anything, uint(1), user.UpdateUserRequest{
 					Name:  "Updated Name",
 					Email: "updated@example.com",
@@ -393,7 +258,7 @@ func TestUserServiceServer_UpdateUser(t *testing.T) {
 				Name:  "Updated Name",
 				Email: "updated@example.com",
 			},
-			setupMock: func(m *MockUserService) {
+			setupMock: func(m *usertest.MockService) {
 				m.On("UpdateUser", mock.Anything, uint(999), mock.Anything).Return(nil, errors.New("update failed"))
 			},
 			wantErr:     true,
@@ -404,8 +269,8 @@ func TestUserServiceServer_UpdateUser(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			mockService := new(MockUserService)
-			mockRepo := new(MockUserRepository)
+			mockService := new(usertest.MockService)
+			mockRepo := new(usertest.MockRepository)
 			tt.setupMock(mockService)
 
 			server := NewUserServiceServer(mockService, mockRepo)
@@ -435,7 +300,7 @@ func TestUserServiceServer_DeleteUser(t *testing.T) {
 	tests := []struct {
 		name        string
 		req         *pb.DeleteUserRequest
-		setupMock   func(*MockUserService)
+		setupMock   func(*usertest.MockService)
 		wantErr     bool
 		wantCode    codes.Code
 		wantMessage string
@@ -443,7 +308,7 @@ func TestUserServiceServer_DeleteUser(t *testing.T) {
 		{
 			name: "successful delete user",
 			req:  &pb.DeleteUserRequest{Id: 1},
-			setupMock: func(m *MockUserService) {
+			setupMock: func(m *usertest.MockService) {
 				m.On("DeleteUser", mock.Anything, uint(1)).Return(nil)
 			},
 			wantErr: false,
@@ -451,7 +316,7 @@ func TestUserServiceServer_DeleteUser(t *testing.T) {
 		{
 			name: "delete fails",
 			req:  &pb.DeleteUserRequest{Id: 999},
-			setupMock: func(m *MockUserService) {
+			setupMock: func(m *usertest.MockService) {
 				m.On("DeleteUser", mock.Anything, uint(999)).Return(errors.New("delete failed"))
 			},
 			wantErr:     true,
@@ -462,8 +327,8 @@ func TestUserServiceServer_DeleteUser(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			mockService := new(MockUserService)
-			mockRepo := new(MockUserRepository)
+			mockService := new(usertest.MockService)
+			mockRepo := new(usertest.MockRepository)
 			tt.setupMock(mockService)
 
 			server := NewUserServiceServer(mockService, mockRepo)
@@ -487,6 +352,183 @@ func TestUserServiceServer_DeleteUser(t *testing.T) {
 	}
 }
 
+func TestUserServiceServer_CreateUser(t *testing.T) {
+	tests := []struct {
+		name        string
+		req         *pb.CreateUserRequest
+		setupMock   func(*usertest.MockService)
+		wantErr     bool
+		wantCode    codes.Code
+		wantMessage string
+	}{
+		{
+			name: "successful create user",
+			req: &pb.CreateUserRequest{
+				Name:     "New User",
+				Email:    "new@example.com",
+				Password: "Password123!",
+			},
+			setupMock: func(m *usertest.MockService) {
+				m.On("RegisterUser", mock.Anything, user.RegisterRequest{
+					Name:     "New User",
+					Email:    "new@example.com",
+					Password: "Password123!",
+				}).Return(&user.User{
+					ID:    1,
+					Name:  "New User",
+					Email: "new@example.com",
+					Roles: []user.Role{{Name: "user"}},
+				}, nil)
+			},
+			wantErr: false,
+		},
+		{
+			name: "email already exists",
+			req: &pb.CreateUserRequest{
+				Name:     "New User",
+				Email:    "taken@example.com",
+				Password: "Password123!",
+			},
+			setupMock: func(m *usertest.MockService) {
+				m.On("RegisterUser", mock.Anything, mock.Anything).Return(nil, user.ErrEmailExists)
+			},
+			wantErr:     true,
+			wantCode:    codes.AlreadyExists,
+			wantMessage: "email already exists",
+		},
+		{
+			name: "weak password",
+			req: &pb.CreateUserRequest{
+				Name:     "New User",
+				Email:    "new@example.com",
+				Password: "password",
+			},
+			setupMock: func(m *usertest.MockService) {
+				m.On("RegisterUser", mock.Anything, mock.Anything).Return(nil, fmt.Errorf("password validation failed: %w", user.ErrPasswordMissingUppercase))
+			},
+			wantErr:     true,
+			wantCode:    codes.InvalidArgument,
+			wantMessage: "invalid password",
+		},
+		{
+			name: "create fails",
+			req: &pb.CreateUserRequest{
+				Name:     "New User",
+				Email:    "new@example.com",
+				Password: "Password123!",
+			},
+			setupMock: func(m *usertest.MockService) {
+				m.On("RegisterUser", mock.Anything, mock.Anything).Return(nil, errors.New("database error"))
+			},
+			wantErr:     true,
+			wantCode:    codes.Internal,
+			wantMessage: "failed to create user",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(usertest.MockService)
+			mockRepo := new(usertest.MockRepository)
+			tt.setupMock(mockService)
+
+			server := NewUserServiceServer(mockService, mockRepo)
+			resp, err := server.CreateUser(context.Background(), tt.req)
+
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Nil(t, resp)
+				st, ok := status.FromError(err)
+				assert.True(t, ok)
+				assert.Equal(t, tt.wantCode, st.Code())
+				assert.Contains(t, st.Message(), tt.wantMessage)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, resp)
+				assert.NotNil(t, resp.User)
+				assert.Equal(t, uint32(1), resp.User.Id)
+				assert.Equal(t, "new@example.com", resp.User.Email)
+			}
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUserServiceServer_Authenticate(t *testing.T) {
+	tests := []struct {
+		name        string
+		req         *pb.AuthenticateRequest
+		setupMock   func(*usertest.MockService)
+		wantErr     bool
+		wantCode    codes.Code
+		wantMessage string
+	}{
+		{
+			name: "successful authenticate",
+			req:  &pb.AuthenticateRequest{Email: "test@example.com", Password: "Password123!"},
+			setupMock: func(m *usertest.MockService) {
+				m.On("AuthenticateUser", mock.Anything, user.LoginRequest{
+					Email:    "test@example.com",
+					Password: "Password123!",
+				}).Return(&user.User{
+					ID:    1,
+					Name:  "Test User",
+					Email: "test@example.com",
+				}, nil)
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid credentials",
+			req:  &pb.AuthenticateRequest{Email: "test@example.com", Password: "wrong"},
+			setupMock: func(m *usertest.MockService) {
+				m.On("AuthenticateUser", mock.Anything, mock.Anything).Return(nil, user.ErrInvalidCredentials)
+			},
+			wantErr:     true,
+			wantCode:    codes.Unauthenticated,
+			wantMessage: "invalid credentials",
+		},
+		{
+			name: "authenticate fails",
+			req:  &pb.AuthenticateRequest{Email: "test@example.com", Password: "Password123!"},
+			setupMock: func(m *usertest.MockService) {
+				m.On("AuthenticateUser", mock.Anything, mock.Anything).Return(nil, errors.New("database error"))
+			},
+			wantErr:     true,
+			wantCode:    codes.Internal,
+			wantMessage: "failed to authenticate user",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(usertest.MockService)
+			mockRepo := new(usertest.MockRepository)
+			tt.setupMock(mockService)
+
+			server := NewUserServiceServer(mockService, mockRepo)
+			resp, err := server.Authenticate(context.Background(), tt.req)
+
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Nil(t, resp)
+				st, ok := status.FromError(err)
+				assert.True(t, ok)
+				assert.Equal(t, tt.wantCode, st.Code())
+				assert.Contains(t, st.Message(), tt.wantMessage)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, resp)
+				assert.NotNil(t, resp.User)
+				assert.Equal(t, "test@example.com", resp.User.Email)
+			}
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
 func TestConvertUserToProto(t *testing.T) {
 	now := time.Now()
 	usr := &user.User{
@@ -494,11 +536,12 @@ func TestConvertUserToProto(t *testing.T) {
 		Name:      "Test User",
 		Email:     "test@example.com",
 		Roles:     []user.Role{{ID: 1, Name: "user"}, {ID: 2, Name: "admin"}},
+		Active:    true,
 		CreatedAt: now,
 		UpdatedAt: now,
 	}
 
-	pbUser := convertUserToProto(usr)
+	pbUser := convertUserToProto(usr, nil)
 
 	assert.Equal(t, uint32(1), pbUser.Id)
 	assert.Equal(t, "Test User", pbUser.Name)
@@ -508,4 +551,58 @@ func TestConvertUserToProto(t *testing.T) {
 	assert.Contains(t, pbUser.Roles, "admin")
 	assert.NotEmpty(t, pbUser.CreatedAt)
 	assert.NotEmpty(t, pbUser.UpdatedAt)
+	assert.Equal(t, user.UserStatusActive, pbUser.Status)
+
+	usr.Active = false
+	assert.Equal(t, user.UserStatusDisabled, convertUserToProto(usr, nil).Status)
+}
+
+func TestConvertUserToProto_FieldMask(t *testing.T) {
+	usr := &user.User{
+		ID:        1,
+		Name:      "Test User",
+		Email:     "test@example.com",
+		Roles:     []user.Role{{ID: 1, Name: "admin"}},
+		Active:    true,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+
+	// Public fields are id and name only; email and the rest stay zero
+	assert.Equal(t, &pb.User{Id: 1, Name: "Test User"}, convertUserToProto(usr, PublicUserFields()))
+
+	pbUser := convertUserToProto(usr, &fieldmaskpb.FieldMask{Paths: []string{"email", "roles", "unknown"}})
+	assert.Equal(t, &pb.User{Email: "test@example.com", Roles: []string{"admin"}}, pbUser)
+}
+
+func TestUserServiceServer_WithReadMask(t *testing.T) {
+	usr := &user.User{ID: 1, Name: "Test User", Email: "test@example.com"}
+	mockService := new(usertest.MockService)
+	mockRepo := new(usertest.MockRepository)
+	mockService.On("GetUserByID", mock.Anything, uint(1)).Return(usr, nil)
+	mockService.On("ListUsers", mock.Anything, mock.Anything, 1, 10).Return([]user.User{*usr}, int64(1), nil)
+	mockService.On("UpdateUser", mock.Anything, uint(1), mock.Anything).Return(usr, nil)
+	mockRepo.On("FindByEmail", mock.Anything, "test@example.com").Return(usr, nil)
+
+	server := NewUserServiceServer(mockService, mockRepo, WithReadMask(PublicUserFields()))
+	ctx := context.Background()
+
+	got, err := server.GetUser(ctx, &pb.GetUserRequest{Id: 1})
+	assert.NoError(t, err)
+	assert.Empty(t, got.User.Email)
+	assert.Equal(t, "Test User", got.User.Name)
+
+	got, err = server.GetUserByEmail(ctx, &pb.GetUserByEmailRequest{Email: "test@example.com"})
+	assert.NoError(t, err)
+	assert.Empty(t, got.User.Email)
+
+	list, err := server.ListUsers(ctx, &pb.ListUsersRequest{})
+	assert.NoError(t, err)
+	assert.Len(t, list.Users, 1)
+	assert.Empty(t, list.Users[0].Email)
+
+	// Update returns the user being acted on, so the read mask does not apply
+	updated, err := server.UpdateUser(ctx, &pb.UpdateUserRequest{Id: 1, Name: "Test User"})
+	assert.NoError(t, err)
+	assert.Equal(t, "test@example.com", updated.User.Email)
 }
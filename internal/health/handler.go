@@ -47,7 +47,7 @@ func (h *Handler) Live(c *gin.Context) {
 
 // Ready godoc
 // @Summary      Readiness probe
-// @Description  Check if the application and its dependencies are ready to serve traffic
+// @Description  Check if the application and its dependencies are ready to serve traffic. Each dependency check is bounded by health.timeout and reports its name, status, latency_ms and error; a check that exceeds the timeout fails with error "timeout".
 // @Tags         Health
 // @Accept       json
 // @Produce      json
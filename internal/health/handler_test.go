@@ -2,6 +2,7 @@ package health
 
 import (
 	"context"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -162,3 +163,36 @@ func TestHandler_Ready(t *testing.T) {
 		})
 	}
 }
+
+func TestHandler_Ready_SlowCheckTimesOut(t *testing.T) {
+	svc := NewServiceWithTimeout([]Checker{
+		&slowChecker{name: "database", delay: 2 * time.Second, ignoreContext: true},
+	}, "1.0.0", "test", 50*time.Millisecond)
+	handler := NewHandler(svc)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/health/ready", handler.Ready)
+
+	req := httptest.NewRequest(http.MethodGet, "/health/ready", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	var body struct {
+		Status HealthStatus `json:"status"`
+		Checks map[string]struct {
+			Name      string `json:"name"`
+			Status    string `json:"status"`
+			LatencyMs int64  `json:"latency_ms"`
+			Error     string `json:"error"`
+		} `json:"checks"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, StatusUnhealthy, body.Status)
+	assert.Equal(t, "database", body.Checks["database"].Name)
+	assert.Equal(t, "fail", body.Checks["database"].Status)
+	assert.Equal(t, "timeout", body.Checks["database"].Error)
+	assert.GreaterOrEqual(t, body.Checks["database"].LatencyMs, int64(50))
+}
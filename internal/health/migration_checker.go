@@ -0,0 +1,63 @@
+// Package health 提供数据库迁移版本检查实现
+package health
+
+import (
+	"context"
+	"fmt"
+)
+
+// VersionSource 返回当前数据库迁移版本及是否处于 dirty 状态，migrate.Migrator 满足该接口
+type VersionSource interface {
+	Version() (uint, bool, error)
+}
+
+// MigrationDetails 就绪探针中迁移检查的详细信息
+type MigrationDetails struct {
+	Version uint `json:"version"`
+	Dirty   bool `json:"dirty"`
+}
+
+// MigrationChecker 在就绪探针中报告当前迁移版本和 dirty 状态
+type MigrationChecker struct {
+	source      VersionSource
+	failOnDirty bool
+}
+
+// NewMigrationChecker 创建迁移检查器；failOnDirty 为 true 时 dirty 状态判定为失败，否则只判定为降级
+func NewMigrationChecker(source VersionSource, failOnDirty bool) *MigrationChecker {
+	return &MigrationChecker{source: source, failOnDirty: failOnDirty}
+}
+
+func (m *MigrationChecker) Name() string {
+	return "migrations"
+}
+
+func (m *MigrationChecker) Check(ctx context.Context) CheckResult {
+	version, dirty, err := m.source.Version()
+	if err != nil {
+		return CheckResult{
+			Status:  CheckFail,
+			Message: "Failed to read migration version",
+			Error:   err.Error(),
+		}
+	}
+
+	details := MigrationDetails{Version: version, Dirty: dirty}
+	if !dirty {
+		return CheckResult{
+			Status:  CheckPass,
+			Message: fmt.Sprintf("Database schema at version %d", version),
+			Details: details,
+		}
+	}
+
+	status := CheckWarn
+	if m.failOnDirty {
+		status = CheckFail
+	}
+	return CheckResult{
+		Status:  status,
+		Message: fmt.Sprintf("Database schema is dirty at version %d", version),
+		Details: details,
+	}
+}
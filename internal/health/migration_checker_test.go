@@ -0,0 +1,114 @@
+package health
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeVersionSource struct {
+	version uint
+	dirty   bool
+	err     error
+}
+
+func (f fakeVersionSource) Version() (uint, bool, error) {
+	return f.version, f.dirty, f.err
+}
+
+func TestMigrationChecker_Ready(t *testing.T) {
+	tests := []struct {
+		name           string
+		source         fakeVersionSource
+		failOnDirty    bool
+		expectedStatus int
+		expectedHealth HealthStatus
+		expectedCheck  CheckStatus
+		expectedError  string
+		expectDetails  bool
+	}{
+		{
+			name:           "clean schema",
+			source:         fakeVersionSource{version: 20260222090000},
+			failOnDirty:    true,
+			expectedStatus: http.StatusOK,
+			expectedHealth: StatusHealthy,
+			expectedCheck:  CheckPass,
+			expectDetails:  true,
+		},
+		{
+			name:           "dirty schema fails readiness",
+			source:         fakeVersionSource{version: 20260222090000, dirty: true},
+			failOnDirty:    true,
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedHealth: StatusUnhealthy,
+			expectedCheck:  CheckFail,
+			expectedError:  "Database schema is dirty at version 20260222090000",
+			expectDetails:  true,
+		},
+		{
+			name:           "dirty schema only degrades readiness",
+			source:         fakeVersionSource{version: 20260222090000, dirty: true},
+			expectedStatus: http.StatusOK,
+			expectedHealth: StatusDegraded,
+			expectedCheck:  CheckWarn,
+			expectDetails:  true,
+		},
+		{
+			name:           "version error",
+			source:         fakeVersionSource{err: errors.New("failed to get migration version: connection refused")},
+			failOnDirty:    true,
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedHealth: StatusUnhealthy,
+			expectedCheck:  CheckFail,
+			expectedError:  "failed to get migration version: connection refused",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checker := NewMigrationChecker(tt.source, tt.failOnDirty)
+			handler := NewHandler(NewServiceWithTimeout([]Checker{checker}, "1.0.0", "test", time.Second))
+
+			gin.SetMode(gin.TestMode)
+			router := gin.New()
+			router.GET("/health/ready", handler.Ready)
+
+			req := httptest.NewRequest(http.MethodGet, "/health/ready", nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			var response struct {
+				Status HealthStatus `json:"status"`
+				Checks map[string]struct {
+					Status  CheckStatus       `json:"status"`
+					Error   string            `json:"error"`
+					Details *MigrationDetails `json:"details"`
+				} `json:"checks"`
+			}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.Equal(t, tt.expectedHealth, response.Status)
+
+			check, ok := response.Checks["migrations"]
+			require.True(t, ok)
+			assert.Equal(t, tt.expectedCheck, check.Status)
+			assert.Equal(t, tt.expectedError, check.Error)
+			if tt.expectDetails {
+				require.NotNil(t, check.Details)
+				assert.Equal(t, tt.source.version, check.Details.Version)
+				assert.Equal(t, tt.source.dirty, check.Details.Dirty)
+			} else {
+				assert.Nil(t, check.Details)
+			}
+		})
+	}
+}
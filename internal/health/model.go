@@ -1,6 +1,10 @@
 package health
 
-import "time"
+import (
+	"time"
+
+	"github.com/yeegeek/uyou-go-api-starter/internal/buildinfo"
+)
 
 type HealthStatus string
 
@@ -25,11 +29,18 @@ type HealthResponse struct {
 	Uptime      string                 `json:"uptime"`
 	Checks      map[string]CheckResult `json:"checks"`
 	Environment string                 `json:"environment"`
+	// Build 当前二进制的构建信息，用于确认运行的是哪个构建
+	Build buildinfo.Info `json:"build"`
 }
 
 type CheckResult struct {
+	Name         string      `json:"name,omitempty"`
 	Status       CheckStatus `json:"status"`
 	Message      string      `json:"message,omitempty"`
 	ResponseTime string      `json:"response_time,omitempty"`
-	Details      interface{} `json:"details,omitempty"`
+	// LatencyMs 就绪探针中由服务统一测量的检查耗时
+	LatencyMs int64 `json:"latency_ms"`
+	// Error 检查失败原因，超时为 "timeout"
+	Error   string      `json:"error,omitempty"`
+	Details interface{} `json:"details,omitempty"`
 }
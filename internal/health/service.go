@@ -3,10 +3,20 @@ package health
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sync"
 	"time"
+
+	"github.com/yeegeek/uyou-go-api-starter/internal/buildinfo"
 )
 
+// defaultCheckTimeout 未配置时单个依赖检查的超时时间
+const defaultCheckTimeout = 5 * time.Second
+
+// CheckErrorTimeout 检查超过超时时间时写入 CheckResult.Error 的值
+const CheckErrorTimeout = "timeout"
+
 type Service interface {
 	GetHealth(ctx context.Context) HealthResponse
 	GetLiveness(ctx context.Context) HealthResponse
@@ -14,18 +24,28 @@ type Service interface {
 }
 
 type service struct {
-	checkers    []Checker
-	startTime   time.Time
-	version     string
-	environment string
+	checkers     []Checker
+	startTime    time.Time
+	version      string
+	environment  string
+	checkTimeout time.Duration
 }
 
 func NewService(checkers []Checker, version, environment string) Service {
+	return NewServiceWithTimeout(checkers, version, environment, defaultCheckTimeout)
+}
+
+// NewServiceWithTimeout 创建健康检查服务，就绪探针中每个依赖检查最多运行 timeout
+func NewServiceWithTimeout(checkers []Checker, version, environment string, timeout time.Duration) Service {
+	if timeout <= 0 {
+		timeout = defaultCheckTimeout
+	}
 	return &service{
-		checkers:    checkers,
-		startTime:   time.Now(),
-		version:     version,
-		environment: environment,
+		checkers:     checkers,
+		startTime:    time.Now(),
+		version:      version,
+		environment:  environment,
+		checkTimeout: timeout,
 	}
 }
 
@@ -36,6 +56,7 @@ func (s *service) GetHealth(ctx context.Context) HealthResponse {
 		Timestamp:   time.Now(),
 		Uptime:      s.formatUptime(),
 		Environment: s.environment,
+		Build:       buildinfo.Get(),
 		Checks:      make(map[string]CheckResult),
 	}
 }
@@ -47,17 +68,29 @@ func (s *service) GetLiveness(ctx context.Context) HealthResponse {
 		Timestamp:   time.Now(),
 		Uptime:      s.formatUptime(),
 		Environment: s.environment,
+		Build:       buildinfo.Get(),
 		Checks:      make(map[string]CheckResult),
 	}
 }
 
 func (s *service) GetReadiness(ctx context.Context) HealthResponse {
+	// 并发执行检查，探针总耗时不超过单个检查的超时时间
+	results := make([]CheckResult, len(s.checkers))
+	var wg sync.WaitGroup
+	for i, checker := range s.checkers {
+		wg.Add(1)
+		go func(i int, checker Checker) {
+			defer wg.Done()
+			results[i] = s.runCheck(ctx, checker)
+		}(i, checker)
+	}
+	wg.Wait()
+
 	checks := make(map[string]CheckResult)
 	overallStatus := StatusHealthy
 
-	for _, checker := range s.checkers {
-		result := checker.Check(ctx)
-		checks[checker.Name()] = result
+	for _, result := range results {
+		checks[result.Name] = result
 
 		if result.Status == CheckFail {
 			overallStatus = StatusUnhealthy
@@ -72,10 +105,45 @@ func (s *service) GetReadiness(ctx context.Context) HealthResponse {
 		Timestamp:   time.Now(),
 		Uptime:      s.formatUptime(),
 		Environment: s.environment,
+		Build:       buildinfo.Get(),
 		Checks:      checks,
 	}
 }
 
+// runCheck 在超时上下文中执行单个检查并记录耗时
+// 检查未响应 context 取消时不再等待它，直接判定为超时失败，避免探针挂起
+func (s *service) runCheck(ctx context.Context, checker Checker) CheckResult {
+	checkCtx, cancel := context.WithTimeout(ctx, s.checkTimeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan CheckResult, 1)
+	go func() {
+		done <- checker.Check(checkCtx)
+	}()
+
+	var result CheckResult
+	select {
+	case result = <-done:
+	case <-checkCtx.Done():
+		result = CheckResult{Status: CheckFail, Message: "Check did not complete"}
+	}
+
+	result.Name = checker.Name()
+	result.LatencyMs = time.Since(start).Milliseconds()
+	if result.Status == CheckFail && result.Error == "" {
+		switch {
+		case errors.Is(checkCtx.Err(), context.DeadlineExceeded):
+			result.Error = CheckErrorTimeout
+		case checkCtx.Err() != nil:
+			result.Error = checkCtx.Err().Error()
+		default:
+			result.Error = result.Message
+		}
+	}
+	return result
+}
+
 func (s *service) formatUptime() string {
 	uptime := time.Since(s.startTime)
 	days := int(uptime.Hours() / 24)
@@ -21,6 +21,30 @@ func (m *mockChecker) Check(ctx context.Context) CheckResult {
 	return m.result
 }
 
+// slowChecker blocks for delay; when ignoreContext is set it keeps blocking after cancellation
+type slowChecker struct {
+	name          string
+	delay         time.Duration
+	ignoreContext bool
+}
+
+func (s *slowChecker) Name() string {
+	return s.name
+}
+
+func (s *slowChecker) Check(ctx context.Context) CheckResult {
+	if s.ignoreContext {
+		time.Sleep(s.delay)
+		return CheckResult{Status: CheckPass}
+	}
+	select {
+	case <-time.After(s.delay):
+		return CheckResult{Status: CheckPass}
+	case <-ctx.Done():
+		return CheckResult{Status: CheckFail, Message: "Check cancelled"}
+	}
+}
+
 func TestService_GetHealth(t *testing.T) {
 	svc := NewService([]Checker{}, "1.0.0", "test")
 
@@ -90,6 +114,51 @@ func TestService_GetReadiness(t *testing.T) {
 	}
 }
 
+func TestService_GetReadiness_ReportsPerCheckDetails(t *testing.T) {
+	svc := NewService([]Checker{
+		&mockChecker{name: "db", result: CheckResult{Status: CheckPass, Message: "OK"}},
+		&mockChecker{name: "cache", result: CheckResult{Status: CheckFail, Message: "Connection refused"}},
+	}, "1.0.0", "test")
+
+	response := svc.GetReadiness(context.Background())
+
+	assert.Equal(t, "db", response.Checks["db"].Name)
+	assert.Empty(t, response.Checks["db"].Error)
+	assert.GreaterOrEqual(t, response.Checks["db"].LatencyMs, int64(0))
+	assert.Equal(t, "cache", response.Checks["cache"].Name)
+	assert.Equal(t, "Connection refused", response.Checks["cache"].Error)
+}
+
+func TestService_GetReadiness_Timeout(t *testing.T) {
+	tests := []struct {
+		name    string
+		checker *slowChecker
+	}{
+		{name: "checker honours context", checker: &slowChecker{name: "slow", delay: 2 * time.Second}},
+		{name: "checker ignores context", checker: &slowChecker{name: "slow", delay: 2 * time.Second, ignoreContext: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := NewServiceWithTimeout([]Checker{
+				tt.checker,
+				&mockChecker{name: "db", result: CheckResult{Status: CheckPass}},
+			}, "1.0.0", "test", 50*time.Millisecond)
+
+			start := time.Now()
+			response := svc.GetReadiness(context.Background())
+
+			assert.Less(t, time.Since(start), time.Second, "probe must not wait for the slow check")
+			assert.Equal(t, StatusUnhealthy, response.Status)
+			slow := response.Checks["slow"]
+			assert.Equal(t, CheckFail, slow.Status)
+			assert.Equal(t, CheckErrorTimeout, slow.Error)
+			assert.GreaterOrEqual(t, slow.LatencyMs, int64(50))
+			assert.Equal(t, CheckPass, response.Checks["db"].Status)
+		})
+	}
+}
+
 func TestService_FormatUptime(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -0,0 +1,209 @@
+// Package httpclient 提供对外 HTTP 调用的客户端：统一的超时、代理和 TLS 配置，
+// 以及按目标主机的重试、熔断、指标和请求 ID 透传
+package httpclient
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/yeegeek/uyou-go-api-starter/internal/config"
+	"github.com/yeegeek/uyou-go-api-starter/internal/contextutil"
+	"github.com/yeegeek/uyou-go-api-starter/internal/metrics"
+	"github.com/yeegeek/uyou-go-api-starter/internal/resilience"
+)
+
+// RequestIDHeader 透传给下游服务的请求 ID 请求头
+const RequestIDHeader = "X-Request-ID"
+
+const (
+	defaultTimeout               = 10 * time.Second
+	defaultDialTimeout           = 5 * time.Second
+	defaultResponseHeaderTimeout = 10 * time.Second
+	defaultMaxIdleConns          = 100
+	defaultMaxIdleConnsPerHost   = 10
+	defaultMaxRetries            = 2
+	defaultRetryBackoff          = 200 * time.Millisecond
+	// maxRetryBackoff 单次重试等待时间上限
+	maxRetryBackoff = 5 * time.Second
+	// maxDrainBytes 重试前读取并丢弃的失败响应体上限，读完才能复用连接
+	maxDrainBytes = 64 << 10
+)
+
+// 指标中非状态码的结果
+const (
+	statusError       = "error"
+	statusCircuitOpen = "circuit_open"
+)
+
+// ErrCircuitOpen 目标主机熔断期间请求直接返回的错误
+var ErrCircuitOpen = errors.New("httpclient: circuit breaker is open")
+
+// errServerStatus 5xx 响应计入熔断时使用的错误
+var errServerStatus = errors.New("httpclient: server error")
+
+// NewHTTPClient 根据配置创建 *http.Client：连接、响应头和总超时，空闲连接池，
+// 代理读取 HTTP_PROXY/HTTPS_PROXY/NO_PROXY 环境变量，TLS 最低版本默认 1.2
+func NewHTTPClient(cfg config.HTTPClientConfig) *http.Client {
+	transport := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   orDefault(cfg.DialTimeout, defaultDialTimeout),
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		ForceAttemptHTTP2:     true,
+		TLSHandshakeTimeout:   orDefault(cfg.DialTimeout, defaultDialTimeout),
+		ResponseHeaderTimeout: orDefault(cfg.ResponseHeaderTimeout, defaultResponseHeaderTimeout),
+		MaxIdleConns:          orDefault(cfg.MaxIdleConns, defaultMaxIdleConns),
+		MaxIdleConnsPerHost:   orDefault(cfg.MaxIdleConnsPerHost, defaultMaxIdleConnsPerHost),
+		IdleConnTimeout:       90 * time.Second,
+		ExpectContinueTimeout: time.Second,
+		TLSClientConfig:       &tls.Config{MinVersion: tlsMinVersion(cfg.TLSMinVersion)},
+	}
+	return &http.Client{Transport: transport, Timeout: orDefault(cfg.Timeout, defaultTimeout)}
+}
+
+// Client 对外 HTTP 调用客户端，可并发使用
+// 连接错误和 5xx 响应按指数退避加随机抖动重试；每个目标主机一个熔断器，熔断期间返回 ErrCircuitOpen；
+// 每次尝试按主机记录 outbound_http_* 指标；请求上下文带有请求 ID 时自动设置 X-Request-ID
+type Client struct {
+	httpClient   *http.Client
+	maxRetries   int
+	retryBackoff time.Duration
+	breakerCfg   config.BreakerConfig
+
+	mu       sync.Mutex
+	breakers map[string]*resilience.Breaker
+}
+
+// New 根据配置创建客户端
+func New(cfg config.HTTPClientConfig) *Client {
+	return NewWithHTTPClient(cfg, NewHTTPClient(cfg))
+}
+
+// NewWithHTTPClient 使用已有的 *http.Client 发送请求，重试和熔断仍按 cfg 配置，用于测试或自定义 Transport
+func NewWithHTTPClient(cfg config.HTTPClientConfig, httpClient *http.Client) *Client {
+	maxRetries := cfg.MaxRetries
+	if maxRetries == 0 {
+		maxRetries = defaultMaxRetries
+	} else if maxRetries < 0 {
+		maxRetries = 0
+	}
+	return &Client{
+		httpClient:   httpClient,
+		maxRetries:   maxRetries,
+		retryBackoff: orDefault(cfg.RetryBackoff, defaultRetryBackoff),
+		breakerCfg:   cfg.Breaker,
+		breakers:     make(map[string]*resilience.Breaker),
+	}
+}
+
+// Do 发送请求，用法与 http.Client.Do 相同：err 为 nil 时调用方必须关闭响应体
+// 重试次数用尽时返回最后一次的 5xx 响应或错误；请求体无法重放（未设置 GetBody）时不重试
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	req = req.Clone(ctx)
+	if req.Header.Get(RequestIDHeader) == "" {
+		if requestID := contextutil.RequestIDFromContext(ctx); requestID != "" {
+			req.Header.Set(RequestIDHeader, requestID)
+		}
+	}
+
+	host := req.URL.Host
+	breaker := c.breaker(host)
+	replayable := req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+
+	for attempt := 0; ; attempt++ {
+		if !breaker.Allow() {
+			metrics.RecordOutboundHTTPRequest(host, req.Method, statusCircuitOpen, 0)
+			return nil, fmt.Errorf("%w: %s", ErrCircuitOpen, host)
+		}
+
+		start := time.Now()
+		resp, err := c.httpClient.Do(req)
+		status := statusError
+		if err == nil {
+			status = strconv.Itoa(resp.StatusCode)
+		}
+		metrics.RecordOutboundHTTPRequest(host, req.Method, status, time.Since(start).Seconds())
+
+		failure := err
+		if err == nil && resp.StatusCode >= http.StatusInternalServerError {
+			failure = fmt.Errorf("%w: status %d", errServerStatus, resp.StatusCode)
+		}
+		// 调用方取消或超时的请求不能说明目标主机不可用，不计入熔断
+		if failure != nil && ctx.Err() != nil {
+			failure = nil
+		}
+		breaker.Record(failure)
+
+		if failure == nil || attempt >= c.maxRetries || !replayable {
+			return resp, err
+		}
+		if resp != nil {
+			_, _ = io.CopyN(io.Discard, resp.Body, maxDrainBytes)
+			resp.Body.Close()
+		}
+
+		timer := time.NewTimer(c.backoff(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = body
+		}
+	}
+}
+
+// breaker 返回目标主机的熔断器，首次访问时创建
+func (c *Client) breaker(host string) *resilience.Breaker {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	b, ok := c.breakers[host]
+	if !ok {
+		b = resilience.NewBreaker("http:"+host, c.breakerCfg.FailureThreshold, c.breakerCfg.Cooldown,
+			resilience.WithFailureRate(c.breakerCfg.FailureRate, c.breakerCfg.WindowSize))
+		c.breakers[host] = b
+	}
+	return b
+}
+
+// backoff 返回第 attempt 次失败后的等待时间：retryBackoff 每次翻倍（不超过 maxRetryBackoff），
+// 取其一半加上一半以内的随机值，避免多个实例同时重试
+func (c *Client) backoff(attempt int) time.Duration {
+	delay := c.retryBackoff << attempt
+	if delay <= 0 || delay > maxRetryBackoff {
+		delay = maxRetryBackoff
+	}
+	half := delay / 2
+	return half + rand.N(half+1)
+}
+
+func tlsMinVersion(version string) uint16 {
+	if version == "1.3" {
+		return tls.VersionTLS13
+	}
+	return tls.VersionTLS12
+}
+
+func orDefault[T int | time.Duration](value, fallback T) T {
+	if value <= 0 {
+		return fallback
+	}
+	return value
+}
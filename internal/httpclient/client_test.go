@@ -0,0 +1,150 @@
+package httpclient
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yeegeek/uyou-go-api-starter/internal/config"
+	"github.com/yeegeek/uyou-go-api-starter/internal/contextutil"
+)
+
+func testConfig() config.HTTPClientConfig {
+	return config.HTTPClientConfig{
+		Timeout:      2 * time.Second,
+		MaxRetries:   2,
+		RetryBackoff: time.Millisecond,
+		Breaker:      config.BreakerConfig{FailureThreshold: 100, Cooldown: time.Minute},
+	}
+}
+
+// statusServer responds with the given status codes in order, repeating the last one
+func statusServer(t *testing.T, statuses ...int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		n := int(calls.Add(1))
+		status := statuses[min(n, len(statuses))-1]
+		w.WriteHeader(status)
+		_, _ = w.Write(body)
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+func TestClient_Do_Retries(t *testing.T) {
+	tests := []struct {
+		name       string
+		statuses   []int
+		wantStatus int
+		wantCalls  int32
+	}{
+		{name: "success after transient failures", statuses: []int{502, 503, 200}, wantStatus: 200, wantCalls: 3},
+		{name: "retries exhausted returns the last response", statuses: []int{500}, wantStatus: 500, wantCalls: 3},
+		{name: "client errors are not retried", statuses: []int{404}, wantStatus: 404, wantCalls: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, calls := statusServer(t, tt.statuses...)
+			client := New(testConfig())
+
+			req, err := http.NewRequest(http.MethodPost, server.URL, strings.NewReader("payload"))
+			require.NoError(t, err)
+			resp, err := client.Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			assert.Equal(t, tt.wantStatus, resp.StatusCode)
+			assert.Equal(t, tt.wantCalls, calls.Load())
+			body, _ := io.ReadAll(resp.Body)
+			assert.Equal(t, "payload", string(body), "the request body is replayed on every attempt")
+		})
+	}
+}
+
+func TestClient_Do_RetryExhaustedOnConnectionErrors(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	url := server.URL
+	server.Close()
+
+	client := New(testConfig())
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(t, err)
+	_, err = client.Do(req)
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrCircuitOpen)
+}
+
+func TestClient_Do_BreakerOpens(t *testing.T) {
+	server, calls := statusServer(t, 503)
+	cfg := testConfig()
+	cfg.MaxRetries = -1
+	cfg.Breaker = config.BreakerConfig{FailureThreshold: 3, Cooldown: time.Minute}
+	client := New(cfg)
+
+	for range 3 {
+		req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+		require.NoError(t, err)
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+	}
+	require.Equal(t, int32(3), calls.Load())
+
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+	_, err = client.Do(req)
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	assert.Equal(t, int32(3), calls.Load(), "an open breaker short-circuits without calling the host")
+
+	other, otherCalls := statusServer(t, 200)
+	req, err = http.NewRequest(http.MethodGet, other.URL, nil)
+	require.NoError(t, err)
+	resp, err := client.Do(req)
+	require.NoError(t, err, "breakers are per host")
+	resp.Body.Close()
+	assert.Equal(t, int32(1), otherCalls.Load())
+}
+
+func TestClient_Do_PropagatesRequestID(t *testing.T) {
+	var got atomic.Value
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got.Store(r.Header.Get(RequestIDHeader))
+	}))
+	defer server.Close()
+	client := New(testConfig())
+
+	ctx := contextutil.WithRequestID(context.Background(), "req-123")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, "req-123", got.Load())
+	assert.Empty(t, req.Header.Get(RequestIDHeader), "the caller's request is not modified")
+}
+
+func TestNewHTTPClient_Defaults(t *testing.T) {
+	client := NewHTTPClient(config.HTTPClientConfig{TLSMinVersion: "1.3"})
+	assert.Equal(t, defaultTimeout, client.Timeout)
+
+	transport, ok := client.Transport.(*http.Transport)
+	require.True(t, ok)
+	assert.Equal(t, defaultMaxIdleConns, transport.MaxIdleConns)
+	assert.Equal(t, defaultMaxIdleConnsPerHost, transport.MaxIdleConnsPerHost)
+	assert.Equal(t, defaultResponseHeaderTimeout, transport.ResponseHeaderTimeout)
+	assert.NotNil(t, transport.Proxy)
+	assert.Equal(t, uint16(tls.VersionTLS13), transport.TLSClientConfig.MinVersion)
+}
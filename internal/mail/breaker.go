@@ -0,0 +1,37 @@
+package mail
+
+import (
+	"context"
+	"errors"
+
+	"github.com/yeegeek/uyou-go-api-starter/internal/resilience"
+)
+
+// ErrMailerUnavailable 熔断期间发送直接返回的错误，属于临时性失败，队列会按退避重新入队
+var ErrMailerUnavailable = errors.New("mail: SMTP server unavailable")
+
+// breakerMailer 在 SMTP 临时性失败后熔断，熔断期间 Send 立即返回 ErrMailerUnavailable，不再等待连接超时；
+// 永久性失败（如 5xx 拒收）说明服务器可用，不计入熔断
+type breakerMailer struct {
+	mailer  Mailer
+	breaker *resilience.Breaker
+}
+
+// NewBreakerMailer 为 mailer 的发送操作加上熔断
+func NewBreakerMailer(mailer Mailer, breaker *resilience.Breaker) Mailer {
+	return &breakerMailer{mailer: mailer, breaker: breaker}
+}
+
+// Send 熔断期间返回 ErrMailerUnavailable；未熔断时返回底层发送的错误
+func (m *breakerMailer) Send(ctx context.Context, msg Message) error {
+	if !m.breaker.Allow() {
+		return ErrMailerUnavailable
+	}
+	err := m.mailer.Send(ctx, msg)
+	if err != nil && IsTransient(err) {
+		m.breaker.Record(err)
+	} else {
+		m.breaker.Record(nil)
+	}
+	return err
+}
@@ -0,0 +1,57 @@
+// Package mail 提供邮件发送抽象、SMTP 实现、内嵌模板和异步发送队列
+package mail
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/yeegeek/uyou-go-api-starter/internal/config"
+	"github.com/yeegeek/uyou-go-api-starter/internal/resilience"
+)
+
+// ErrNoRecipients 邮件没有收件人
+var ErrNoRecipients = errors.New("mail: message has no recipients")
+
+// Message 待发送的邮件，Text 和 HTML 至少提供一个
+type Message struct {
+	To      []string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// Mailer 邮件发送接口
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// New 根据配置创建邮件发送器，未启用时返回只记录日志的实现，启用时 SMTP 发送按 cfg.Breaker 熔断
+func New(cfg config.MailConfig) Mailer {
+	if !cfg.Enabled {
+		return NewLogMailer(slog.Default())
+	}
+	breaker := resilience.NewBreaker("smtp", cfg.Breaker.FailureThreshold, cfg.Breaker.Cooldown,
+		resilience.WithFailureRate(cfg.Breaker.FailureRate, cfg.Breaker.WindowSize))
+	return NewBreakerMailer(NewSMTPMailer(cfg), breaker)
+}
+
+// LogMailer 只记录日志不发送邮件，用于开发环境和未配置 SMTP 的部署
+type LogMailer struct {
+	logger *slog.Logger
+}
+
+// NewLogMailer 创建日志邮件发送器
+func NewLogMailer(logger *slog.Logger) *LogMailer {
+	return &LogMailer{logger: logger}
+}
+
+// Send 记录邮件内容（正文只在 debug 级别输出，其中可能包含验证码等敏感信息）
+func (m *LogMailer) Send(ctx context.Context, msg Message) error {
+	if len(msg.To) == 0 {
+		return ErrNoRecipients
+	}
+	m.logger.InfoContext(ctx, "Mail disabled, message not sent", "to", msg.To, "subject", msg.Subject)
+	m.logger.DebugContext(ctx, "Mail body", "text", msg.Text)
+	return nil
+}
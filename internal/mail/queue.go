@@ -0,0 +1,169 @@
+package mail
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/yeegeek/uyou-go-api-starter/internal/config"
+	"github.com/yeegeek/uyou-go-api-starter/internal/metrics"
+)
+
+var (
+	// ErrQueueFull 待发送队列已满
+	ErrQueueFull = errors.New("mail: send queue is full")
+	// ErrQueueClosed 发送队列已关闭
+	ErrQueueClosed = errors.New("mail: send queue is closed")
+)
+
+// 队列默认值
+const (
+	defaultWorkers      = 2
+	defaultQueueSize    = 100
+	defaultRetryBackoff = 5 * time.Second
+)
+
+// 邮件发送指标状态
+const (
+	statusSent    = "sent"
+	statusFailed  = "failed"
+	statusRetried = "retried"
+	statusDropped = "dropped"
+)
+
+type job struct {
+	msg     Message
+	attempt int
+}
+
+// Queue 异步邮件发送器，实现 Mailer 接口
+// Send 只负责入队，固定数量的协程从有界队列中取出邮件发送，调用方不会阻塞在 SMTP 上；
+// 临时性失败按指数退避重新入队，重试次数用尽或永久性失败时记录日志和指标
+type Queue struct {
+	mailer     Mailer
+	jobs       chan job
+	maxRetries int
+	backoff    time.Duration
+	logger     *slog.Logger
+
+	mu     sync.RWMutex
+	closed bool
+	wg     sync.WaitGroup
+}
+
+// NewQueue 创建并启动发送队列
+func NewQueue(mailer Mailer, cfg config.MailConfig) *Queue {
+	workers := cfg.Workers
+	if workers <= 0 {
+		workers = defaultWorkers
+	}
+	queueSize := cfg.QueueSize
+	if queueSize <= 0 {
+		queueSize = defaultQueueSize
+	}
+	backoff := cfg.RetryBackoff
+	if backoff <= 0 {
+		backoff = defaultRetryBackoff
+	}
+
+	q := &Queue{
+		mailer:     mailer,
+		jobs:       make(chan job, queueSize),
+		maxRetries: cfg.MaxRetries,
+		backoff:    backoff,
+		logger:     slog.Default(),
+	}
+
+	q.wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go q.worker()
+	}
+	return q
+}
+
+// Send 将邮件加入发送队列，队列已满时立即返回 ErrQueueFull
+func (q *Queue) Send(_ context.Context, msg Message) error {
+	if len(msg.To) == 0 {
+		return ErrNoRecipients
+	}
+	if err := q.enqueue(job{msg: msg}); err != nil {
+		metrics.RecordMail(statusDropped)
+		return err
+	}
+	return nil
+}
+
+func (q *Queue) enqueue(j job) error {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	if q.closed {
+		return ErrQueueClosed
+	}
+	select {
+	case q.jobs <- j:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+func (q *Queue) worker() {
+	defer q.wg.Done()
+	for j := range q.jobs {
+		q.deliver(j)
+	}
+}
+
+// deliver 发送一封邮件，请求上下文此时可能已结束，因此使用独立的上下文
+func (q *Queue) deliver(j job) {
+	err := q.mailer.Send(context.Background(), j.msg)
+	if err == nil {
+		metrics.RecordMail(statusSent)
+		return
+	}
+
+	if IsTransient(err) && j.attempt < q.maxRetries {
+		delay := q.backoff << j.attempt
+		metrics.RecordMail(statusRetried)
+		q.logger.Warn("Mail delivery failed, retrying", "to", j.msg.To, "subject", j.msg.Subject, "attempt", j.attempt+1, "retry_in", delay, "error", err)
+
+		retry := job{msg: j.msg, attempt: j.attempt + 1}
+		time.AfterFunc(delay, func() {
+			if err := q.enqueue(retry); err != nil {
+				metrics.RecordMail(statusFailed)
+				q.logger.Error("Mail retry dropped", "to", retry.msg.To, "subject", retry.msg.Subject, "error", err)
+			}
+		})
+		return
+	}
+
+	metrics.RecordMail(statusFailed)
+	q.logger.Error("Mail delivery failed", "to", j.msg.To, "subject", j.msg.Subject, "attempts", j.attempt+1, "error", err)
+}
+
+// Close 停止接收新邮件并等待已入队的邮件发送完成，ctx 结束时直接返回
+// 关闭后到期的重试会被丢弃并计为失败
+func (q *Queue) Close(ctx context.Context) error {
+	q.mu.Lock()
+	if !q.closed {
+		q.closed = true
+		close(q.jobs)
+	}
+	q.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		q.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,158 @@
+package mail
+
+import (
+	"context"
+	"errors"
+	"net/textproto"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yeegeek/uyou-go-api-starter/internal/config"
+	"github.com/yeegeek/uyou-go-api-starter/internal/resilience"
+)
+
+// fakeMailer returns the queued errors in order, then succeeds
+type fakeMailer struct {
+	mu     sync.Mutex
+	errs   []error
+	calls  int
+	sent   []Message
+	block  chan struct{}
+	called chan struct{}
+}
+
+func (m *fakeMailer) Send(_ context.Context, msg Message) error {
+	if m.called != nil {
+		m.called <- struct{}{}
+	}
+	if m.block != nil {
+		<-m.block
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls++
+	if len(m.errs) > 0 {
+		err := m.errs[0]
+		m.errs = m.errs[1:]
+		return err
+	}
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+func (m *fakeMailer) snapshot() (int, int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.calls, len(m.sent)
+}
+
+var testMessage = Message{To: []string{"alice@example.com"}, Subject: "Hi", Text: "Hello"}
+
+func TestQueue_RetriesTransientFailures(t *testing.T) {
+	mailer := &fakeMailer{errs: []error{&textproto.Error{Code: 421, Msg: "try again later"}}}
+	q := NewQueue(mailer, config.MailConfig{Workers: 1, MaxRetries: 2, RetryBackoff: time.Millisecond})
+
+	require.NoError(t, q.Send(context.Background(), testMessage))
+
+	assert.Eventually(t, func() bool {
+		calls, sent := mailer.snapshot()
+		return calls == 2 && sent == 1
+	}, time.Second, 5*time.Millisecond)
+	require.NoError(t, q.Close(context.Background()))
+}
+
+func TestQueue_DoesNotRetryPermanentFailures(t *testing.T) {
+	mailer := &fakeMailer{errs: []error{&textproto.Error{Code: 550, Msg: "no such user"}}}
+	q := NewQueue(mailer, config.MailConfig{Workers: 1, MaxRetries: 2, RetryBackoff: time.Millisecond})
+
+	require.NoError(t, q.Send(context.Background(), testMessage))
+	require.NoError(t, q.Close(context.Background()))
+
+	calls, sent := mailer.snapshot()
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, 0, sent)
+}
+
+func TestQueue_SendDoesNotBlockWhenFull(t *testing.T) {
+	mailer := &fakeMailer{block: make(chan struct{}), called: make(chan struct{}, 1)}
+	q := NewQueue(mailer, config.MailConfig{Workers: 1, QueueSize: 1})
+
+	require.NoError(t, q.Send(context.Background(), testMessage))
+	<-mailer.called // the worker is now busy with the first message
+	require.NoError(t, q.Send(context.Background(), testMessage))
+
+	done := make(chan error, 1)
+	go func() { done <- q.Send(context.Background(), testMessage) }()
+	select {
+	case err := <-done:
+		assert.ErrorIs(t, err, ErrQueueFull)
+	case <-time.After(time.Second):
+		t.Fatal("Send blocked on a full queue")
+	}
+
+	mailer.called = nil
+	close(mailer.block)
+	require.NoError(t, q.Close(context.Background()))
+	_, sent := mailer.snapshot()
+	assert.Equal(t, 2, sent)
+
+	assert.ErrorIs(t, q.Send(context.Background(), testMessage), ErrQueueClosed)
+}
+
+func TestQueue_RejectsMessagesWithoutRecipients(t *testing.T) {
+	q := NewQueue(&fakeMailer{}, config.MailConfig{})
+	defer func() { _ = q.Close(context.Background()) }()
+
+	assert.ErrorIs(t, q.Send(context.Background(), Message{Subject: "Hi"}), ErrNoRecipients)
+}
+
+func TestBreakerMailer_FailsFast(t *testing.T) {
+	unavailable := &textproto.Error{Code: 421, Msg: "service not available"}
+	mailer := &fakeMailer{errs: []error{unavailable, unavailable}}
+	m := NewBreakerMailer(mailer, resilience.NewBreaker("smtp-test", 2, time.Hour))
+
+	assert.ErrorIs(t, m.Send(context.Background(), testMessage), unavailable)
+	assert.ErrorIs(t, m.Send(context.Background(), testMessage), unavailable)
+	assert.ErrorIs(t, m.Send(context.Background(), testMessage), ErrMailerUnavailable)
+
+	calls, _ := mailer.snapshot()
+	assert.Equal(t, 2, calls, "an open circuit does not reach the SMTP server")
+}
+
+func TestBreakerMailer_PermanentFailuresDoNotOpen(t *testing.T) {
+	rejected := &textproto.Error{Code: 550, Msg: "no such user"}
+	mailer := &fakeMailer{errs: []error{rejected, rejected}}
+	m := NewBreakerMailer(mailer, resilience.NewBreaker("smtp-test", 1, time.Hour))
+
+	assert.ErrorIs(t, m.Send(context.Background(), testMessage), rejected)
+	assert.ErrorIs(t, m.Send(context.Background(), testMessage), rejected)
+	assert.NoError(t, m.Send(context.Background(), testMessage))
+}
+
+func TestQueue_RetriesWhileCircuitOpen(t *testing.T) {
+	mailer := &fakeMailer{errs: []error{&textproto.Error{Code: 421, Msg: "try again later"}}}
+	m := NewBreakerMailer(mailer, resilience.NewBreaker("smtp-test", 1, 30*time.Millisecond))
+	q := NewQueue(m, config.MailConfig{Workers: 1, MaxRetries: 5, RetryBackoff: 10 * time.Millisecond})
+
+	require.NoError(t, q.Send(context.Background(), testMessage))
+
+	// The first retry hits the open circuit and is queued again; the probe after the cooldown delivers
+	assert.Eventually(t, func() bool {
+		calls, sent := mailer.snapshot()
+		return calls == 2 && sent == 1
+	}, time.Second, 5*time.Millisecond)
+	require.NoError(t, q.Close(context.Background()))
+}
+
+func TestIsTransient(t *testing.T) {
+	assert.True(t, IsTransient(ErrMailerUnavailable))
+	assert.True(t, IsTransient(&textproto.Error{Code: 451}))
+	assert.False(t, IsTransient(&textproto.Error{Code: 550}))
+	assert.True(t, IsTransient(context.DeadlineExceeded))
+	assert.False(t, IsTransient(errors.New("boom")))
+}
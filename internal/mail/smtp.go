@@ -0,0 +1,224 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yeegeek/uyou-go-api-starter/internal/config"
+)
+
+// defaultSMTPTimeout 未配置超时时单次发送的超时时间
+const defaultSMTPTimeout = 10 * time.Second
+
+// SMTPMailer 通过 SMTP 发送邮件，每封邮件使用独立连接
+type SMTPMailer struct {
+	cfg config.MailConfig
+	now func() time.Time
+}
+
+// NewSMTPMailer 创建 SMTP 邮件发送器
+func NewSMTPMailer(cfg config.MailConfig) *SMTPMailer {
+	if cfg.TLSMode == "" {
+		cfg.TLSMode = config.MailTLSStartTLS
+	}
+	if cfg.Port == 0 {
+		switch cfg.TLSMode {
+		case config.MailTLSImplicit:
+			cfg.Port = 465
+		case config.MailTLSNone:
+			cfg.Port = 25
+		default:
+			cfg.Port = 587
+		}
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultSMTPTimeout
+	}
+	return &SMTPMailer{cfg: cfg, now: time.Now}
+}
+
+// Send 连接 SMTP 服务器并发送邮件
+func (m *SMTPMailer) Send(ctx context.Context, msg Message) error {
+	if len(msg.To) == 0 {
+		return ErrNoRecipients
+	}
+
+	from, err := mail.ParseAddress(m.cfg.From)
+	if err != nil {
+		return fmt.Errorf("mail: invalid from address: %w", err)
+	}
+	body, err := buildMessage(from.String(), msg, m.now(), newBoundary())
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, m.cfg.Timeout)
+	defer cancel()
+
+	client, err := m.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if m.cfg.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", m.cfg.Username, m.cfg.Password, m.cfg.Host)); err != nil {
+			return fmt.Errorf("mail: authentication failed: %w", err)
+		}
+	}
+	if err := client.Mail(from.Address); err != nil {
+		return fmt.Errorf("mail: MAIL FROM rejected: %w", err)
+	}
+	for _, to := range msg.To {
+		if err := client.Rcpt(to); err != nil {
+			return fmt.Errorf("mail: RCPT TO %s rejected: %w", to, err)
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("mail: DATA rejected: %w", err)
+	}
+	if _, err := w.Write(body); err != nil {
+		return fmt.Errorf("mail: failed to write message: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("mail: message rejected: %w", err)
+	}
+
+	return client.Quit()
+}
+
+// dial 建立连接并按 TLS 模式完成加密握手
+func (m *SMTPMailer) dial(ctx context.Context) (*smtp.Client, error) {
+	addr := net.JoinHostPort(m.cfg.Host, strconv.Itoa(m.cfg.Port))
+	tlsConfig := &tls.Config{ServerName: m.cfg.Host, MinVersion: tls.VersionTLS12}
+
+	var (
+		conn net.Conn
+		err  error
+	)
+	if m.cfg.TLSMode == config.MailTLSImplicit {
+		conn, err = (&tls.Dialer{Config: tlsConfig}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = (&net.Dialer{}).DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("mail: failed to connect to %s: %w", addr, err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, m.cfg.Host)
+	if err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("mail: SMTP handshake failed: %w", err)
+	}
+
+	if m.cfg.TLSMode == config.MailTLSStartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			_ = client.Close()
+			return nil, errors.New("mail: server does not support STARTTLS")
+		}
+		if err := client.StartTLS(tlsConfig); err != nil {
+			_ = client.Close()
+			return nil, fmt.Errorf("mail: STARTTLS failed: %w", err)
+		}
+	}
+
+	return client, nil
+}
+
+// IsTransient 判断发送失败是否值得重试：SMTP 4xx 响应、网络错误或熔断中
+func IsTransient(err error) bool {
+	if errors.Is(err, ErrMailerUnavailable) {
+		return true
+	}
+	var protoErr *textproto.Error
+	if errors.As(err, &protoErr) {
+		return protoErr.Code >= 400 && protoErr.Code < 500
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	return errors.Is(err, context.DeadlineExceeded)
+}
+
+// buildMessage 生成 RFC 5322 邮件内容，同时提供文本和 HTML 时使用 multipart/alternative
+func buildMessage(from string, msg Message, date time.Time, boundary string) ([]byte, error) {
+	if msg.Text == "" && msg.HTML == "" {
+		return nil, errors.New("mail: message has no body")
+	}
+
+	var buf bytes.Buffer
+	writeHeader := func(key, value string) {
+		buf.WriteString(key + ": " + value + "\r\n")
+	}
+
+	writeHeader("From", from)
+	writeHeader("To", strings.Join(msg.To, ", "))
+	writeHeader("Subject", mime.QEncoding.Encode("utf-8", msg.Subject))
+	writeHeader("Date", date.Format(time.RFC1123Z))
+	writeHeader("MIME-Version", "1.0")
+
+	if msg.Text != "" && msg.HTML != "" {
+		writeHeader("Content-Type", `multipart/alternative; boundary="`+boundary+`"`)
+		buf.WriteString("\r\n")
+		for _, part := range []struct{ contentType, body string }{
+			{"text/plain", msg.Text},
+			{"text/html", msg.HTML},
+		} {
+			buf.WriteString("--" + boundary + "\r\n")
+			if err := writePart(&buf, part.contentType, part.body); err != nil {
+				return nil, err
+			}
+			buf.WriteString("\r\n")
+		}
+		buf.WriteString("--" + boundary + "--\r\n")
+		return buf.Bytes(), nil
+	}
+
+	contentType, body := "text/plain", msg.Text
+	if msg.HTML != "" {
+		contentType, body = "text/html", msg.HTML
+	}
+	if err := writePart(&buf, contentType, body); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writePart 写入一段 quoted-printable 编码的正文及其头部
+func writePart(buf *bytes.Buffer, contentType, body string) error {
+	buf.WriteString("Content-Type: " + contentType + "; charset=UTF-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+
+	qp := quotedprintable.NewWriter(buf)
+	body = strings.ReplaceAll(strings.ReplaceAll(body, "\r\n", "\n"), "\n", "\r\n")
+	if _, err := qp.Write([]byte(body)); err != nil {
+		return fmt.Errorf("mail: failed to encode body: %w", err)
+	}
+	return qp.Close()
+}
+
+func newBoundary() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
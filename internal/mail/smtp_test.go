@@ -0,0 +1,51 @@
+package mail
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildMessage(t *testing.T) {
+	date := time.Date(2026, 2, 14, 9, 0, 0, 0, time.UTC)
+
+	t.Run("text and html use multipart/alternative", func(t *testing.T) {
+		body, err := buildMessage("UYou <no-reply@example.com>", Message{
+			To:      []string{"alice@example.com", "bob@example.com"},
+			Subject: "验证码",
+			Text:    "code: 123456\n",
+			HTML:    "<p>code: <b>123456</b></p>",
+		}, date, "BOUNDARY")
+		require.NoError(t, err)
+
+		s := string(body)
+		assert.Contains(t, s, "From: UYou <no-reply@example.com>\r\n")
+		assert.Contains(t, s, "To: alice@example.com, bob@example.com\r\n")
+		assert.Contains(t, s, "Subject: =?utf-8?q?")
+		assert.Contains(t, s, "Date: Sat, 14 Feb 2026 09:00:00 +0000\r\n")
+		assert.Contains(t, s, `Content-Type: multipart/alternative; boundary="BOUNDARY"`)
+		assert.Contains(t, s, "Content-Type: text/plain; charset=UTF-8\r\n")
+		assert.Contains(t, s, "Content-Type: text/html; charset=UTF-8\r\n")
+		assert.Contains(t, s, "code: 123456\r\n")
+		assert.True(t, strings.HasSuffix(s, "--BOUNDARY--\r\n"))
+	})
+
+	t.Run("single part", func(t *testing.T) {
+		body, err := buildMessage("no-reply@example.com", Message{
+			To:   []string{"alice@example.com"},
+			HTML: "<p>hi</p>",
+		}, date, "BOUNDARY")
+		require.NoError(t, err)
+
+		assert.NotContains(t, string(body), "multipart")
+		assert.Contains(t, string(body), "Content-Type: text/html; charset=UTF-8\r\n")
+	})
+
+	t.Run("empty body", func(t *testing.T) {
+		_, err := buildMessage("no-reply@example.com", Message{To: []string{"alice@example.com"}}, date, "BOUNDARY")
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,101 @@
+package mail
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"maps"
+	"strings"
+	texttemplate "text/template"
+)
+
+//go:embed templates/*.tmpl
+var templateFS embed.FS
+
+// 内置邮件模板名称
+const (
+	TemplateVerification  = "verification"
+	TemplatePasswordReset = "password_reset"
+	TemplateInvite        = "invite"
+)
+
+// Renderer 渲染内嵌邮件模板
+//
+// 每个模板由两个文件组成：
+//   - <name>.txt.tmpl 定义 "subject"（主题）和 "content"（纯文本正文）
+//   - <name>.html.tmpl 定义 "content"（HTML 正文），套用 layout.html.tmpl
+//
+// 模板数据为默认值（如 AppName）与调用方变量合并后的 map，变量缺失时渲染失败，
+// HTML 布局中可以通过 {{.Subject}} 使用渲染后的主题
+type Renderer struct {
+	text     map[string]*texttemplate.Template
+	html     map[string]*htmltemplate.Template
+	defaults map[string]any
+}
+
+// NewRenderer 解析所有内嵌模板，defaults 会合并到每次渲染的数据中
+func NewRenderer(defaults map[string]any) (*Renderer, error) {
+	textFiles, err := fs.Glob(templateFS, "templates/*.txt.tmpl")
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Renderer{
+		text:     make(map[string]*texttemplate.Template, len(textFiles)),
+		html:     make(map[string]*htmltemplate.Template, len(textFiles)),
+		defaults: defaults,
+	}
+
+	for _, file := range textFiles {
+		name := strings.TrimSuffix(strings.TrimPrefix(file, "templates/"), ".txt.tmpl")
+
+		textTmpl, err := texttemplate.New(name).Option("missingkey=error").ParseFS(templateFS, file)
+		if err != nil {
+			return nil, fmt.Errorf("mail: failed to parse template %s: %w", file, err)
+		}
+		r.text[name] = textTmpl
+
+		htmlFile := "templates/" + name + ".html.tmpl"
+		htmlTmpl, err := htmltemplate.New(name).Option("missingkey=error").ParseFS(templateFS, "templates/layout.html.tmpl", htmlFile)
+		if err != nil {
+			return nil, fmt.Errorf("mail: failed to parse template %s: %w", htmlFile, err)
+		}
+		r.html[name] = htmlTmpl
+	}
+
+	return r, nil
+}
+
+// Render 使用 vars 渲染模板，返回填好主题和正文的邮件
+func (r *Renderer) Render(name string, to []string, vars map[string]any) (Message, error) {
+	textTmpl, ok := r.text[name]
+	if !ok {
+		return Message{}, fmt.Errorf("mail: unknown template %q", name)
+	}
+
+	data := make(map[string]any, len(r.defaults)+len(vars)+1)
+	maps.Copy(data, r.defaults)
+	maps.Copy(data, vars)
+
+	var subject, text, html bytes.Buffer
+	if err := textTmpl.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return Message{}, fmt.Errorf("mail: failed to render %s subject: %w", name, err)
+	}
+	if err := textTmpl.ExecuteTemplate(&text, "content", data); err != nil {
+		return Message{}, fmt.Errorf("mail: failed to render %s text: %w", name, err)
+	}
+
+	data["Subject"] = subject.String()
+	if err := r.html[name].ExecuteTemplate(&html, "layout", data); err != nil {
+		return Message{}, fmt.Errorf("mail: failed to render %s html: %w", name, err)
+	}
+
+	return Message{
+		To:      to,
+		Subject: subject.String(),
+		Text:    text.String(),
+		HTML:    html.String(),
+	}, nil
+}
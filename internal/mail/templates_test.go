@@ -0,0 +1,72 @@
+package mail
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+func assertGolden(t *testing.T, name, got string) {
+	t.Helper()
+
+	path := filepath.Join("testdata", name+".golden")
+	if *update {
+		require.NoError(t, os.WriteFile(path, []byte(got), 0o644))
+	}
+
+	want, err := os.ReadFile(path)
+	require.NoError(t, err, "run go test ./internal/mail -update to create golden files")
+	assert.Equal(t, string(want), got)
+}
+
+func TestRenderer_Golden(t *testing.T) {
+	r, err := NewRenderer(map[string]any{"AppName": "UYou"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		template string
+		vars     map[string]any
+	}{
+		{
+			template: TemplateVerification,
+			vars:     map[string]any{"Name": "Alice", "Code": "482913", "ExpiresInMinutes": 10},
+		},
+		{
+			template: TemplatePasswordReset,
+			vars:     map[string]any{"Name": "Alice", "ResetURL": "https://app.example.com/reset?token=abc&id=1", "ExpiresInMinutes": 30},
+		},
+		{
+			template: TemplateInvite,
+			vars:     map[string]any{"InviterName": "<Bob>", "AcceptURL": "https://app.example.com/invite/xyz"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.template, func(t *testing.T) {
+			msg, err := r.Render(tt.template, []string{"alice@example.com"}, tt.vars)
+			require.NoError(t, err)
+
+			assert.Equal(t, []string{"alice@example.com"}, msg.To)
+			assertGolden(t, tt.template+".subject", msg.Subject)
+			assertGolden(t, tt.template+".txt", msg.Text)
+			assertGolden(t, tt.template+".html", msg.HTML)
+		})
+	}
+}
+
+func TestRenderer_Errors(t *testing.T) {
+	r, err := NewRenderer(map[string]any{"AppName": "UYou"})
+	require.NoError(t, err)
+
+	_, err = r.Render("unknown", []string{"alice@example.com"}, nil)
+	assert.Error(t, err)
+
+	_, err = r.Render(TemplateVerification, []string{"alice@example.com"}, map[string]any{"Name": "Alice"})
+	assert.Error(t, err, "missing variables must fail instead of rendering <no value>")
+}
@@ -0,0 +1,29 @@
+package messaging
+
+import (
+	"context"
+
+	"github.com/yeegeek/uyou-go-api-starter/internal/resilience"
+)
+
+// breakerQueue 在发布连续失败后熔断：熔断期间 Publish 直接丢弃消息并返回 nil，
+// 避免 RabbitMQ 故障时每个发布事件的请求都报错；Subscribe、Close、HealthCheck 直接透传
+type breakerQueue struct {
+	MessageQueue
+	breaker *resilience.Breaker
+}
+
+// NewBreakerQueue 为 mq 的发布操作加上熔断
+func NewBreakerQueue(mq MessageQueue, breaker *resilience.Breaker) MessageQueue {
+	return &breakerQueue{MessageQueue: mq, breaker: breaker}
+}
+
+// Publish 熔断期间为空操作；未熔断时返回底层发布的错误
+func (q *breakerQueue) Publish(ctx context.Context, topic string, message []byte) error {
+	if !q.breaker.Allow() {
+		return nil
+	}
+	err := q.MessageQueue.Publish(ctx, topic, message)
+	q.breaker.Record(err)
+	return err
+}
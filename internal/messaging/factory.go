@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/yeegeek/uyou-go-api-starter/internal/config"
+	"github.com/yeegeek/uyou-go-api-starter/internal/resilience"
 )
 
 // MessageQueueProvider 消息队列提供商类型
@@ -20,12 +21,22 @@ const (
 )
 
 // NewMessageQueue 根据配置创建消息队列实例
-// 根据配置中的 provider 字段选择实现
+// 根据配置中的 provider 字段选择实现，发布操作按 cfg.Breaker 熔断
 func NewMessageQueue(cfg *config.RabbitMQConfig, provider MessageQueueProvider) (MessageQueue, error) {
 	if !cfg.Enabled {
 		return nil, fmt.Errorf("message queue is not enabled")
 	}
 
+	mq, err := newProviderQueue(cfg, provider)
+	if err != nil {
+		return nil, err
+	}
+	breaker := resilience.NewBreaker("rabbitmq", cfg.Breaker.FailureThreshold, cfg.Breaker.Cooldown,
+		resilience.WithFailureRate(cfg.Breaker.FailureRate, cfg.Breaker.WindowSize))
+	return NewBreakerQueue(mq, breaker), nil
+}
+
+func newProviderQueue(cfg *config.RabbitMQConfig, provider MessageQueueProvider) (MessageQueue, error) {
 	switch provider {
 	case ProviderRabbitMQ:
 		return NewRabbitMQ(cfg)
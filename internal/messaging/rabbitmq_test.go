@@ -2,11 +2,13 @@ package messaging
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/yeegeek/uyou-go-api-starter/internal/config"
+	"github.com/yeegeek/uyou-go-api-starter/internal/resilience"
 )
 
 func TestNewRabbitMQ(t *testing.T) {
@@ -299,3 +301,55 @@ func TestEventHandlerFunc(t *testing.T) {
 	assert.NoError(t, err)
 	assert.True(t, called)
 }
+
+// failingQueue 模拟不可用的消息队列，记录发布调用次数
+type failingQueue struct {
+	publishes int
+	err       error
+}
+
+func (q *failingQueue) Publish(ctx context.Context, topic string, message []byte) error {
+	q.publishes++
+	return q.err
+}
+
+func (q *failingQueue) Subscribe(ctx context.Context, topic string, handler MessageHandler) error {
+	return q.err
+}
+
+func (q *failingQueue) Close() error { return nil }
+
+func (q *failingQueue) HealthCheck() error { return q.err }
+
+func TestBreakerQueue_ShortCircuitsPublishWhenOpen(t *testing.T) {
+	backend := &failingQueue{err: errors.New("connection refused")}
+	cooldown := 50 * time.Millisecond
+	mq := NewBreakerQueue(backend, resilience.NewBreaker("rabbitmq", 3, cooldown))
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		assert.Error(t, mq.Publish(ctx, "user.created", []byte("{}")))
+	}
+	assert.Equal(t, 3, backend.publishes)
+
+	// 熔断期间发布为空操作，不再访问后端
+	for i := 0; i < 10; i++ {
+		assert.NoError(t, mq.Publish(ctx, "user.created", []byte("{}")))
+	}
+	assert.Equal(t, 3, backend.publishes)
+	assert.Error(t, mq.HealthCheck(), "health check bypasses the breaker")
+
+	// 冷却结束后半开，放行一次试探调用
+	time.Sleep(cooldown + 10*time.Millisecond)
+	assert.Error(t, mq.Publish(ctx, "user.created", []byte("{}")))
+	assert.Equal(t, 4, backend.publishes)
+	assert.NoError(t, mq.Publish(ctx, "user.created", []byte("{}")))
+	assert.Equal(t, 4, backend.publishes, "failed probe reopens the breaker")
+
+	// 后端恢复后试探成功，熔断关闭
+	backend.err = nil
+	time.Sleep(cooldown + 10*time.Millisecond)
+	assert.NoError(t, mq.Publish(ctx, "user.created", []byte("{}")))
+	assert.NoError(t, mq.Publish(ctx, "user.created", []byte("{}")))
+	assert.Equal(t, 6, backend.publishes)
+}
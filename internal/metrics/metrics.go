@@ -6,6 +6,21 @@ import (
 	"github.com/prometheus/client_golang/prometheus/promauto"
 )
 
+// 登录失败原因，用作 auth_login_failures_total 的 reason 标签
+const (
+	LoginFailureBadPassword = "bad-password"
+	LoginFailureUnknownUser = "unknown-user"
+	LoginFailureLocked      = "locked"
+	LoginFailureDisabled    = "disabled"
+)
+
+// 刷新结果，用作 auth_token_refresh_total 的 result 标签
+const (
+	TokenRefreshSuccess = "success"
+	TokenRefreshReuse   = "reuse"
+	TokenRefreshAnomaly = "anomaly"
+)
+
 var (
 	// HTTPRequestsTotal HTTP 请求总数
 	HTTPRequestsTotal = promauto.NewCounterVec(
@@ -46,6 +61,25 @@ var (
 		[]string{"method", "path"},
 	)
 
+	// OutboundHTTPRequestsTotal 对外 HTTP 请求总数（status: 状态码/error/circuit_open），每次重试单独计数
+	OutboundHTTPRequestsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "outbound_http_requests_total",
+			Help: "对外 HTTP 请求总数",
+		},
+		[]string{"host", "method", "status"},
+	)
+
+	// OutboundHTTPRequestDuration 对外 HTTP 请求延迟（秒）
+	OutboundHTTPRequestDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "outbound_http_request_duration_seconds",
+			Help:    "对外 HTTP 请求延迟（秒）",
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{"host", "method"},
+	)
+
 	// DatabaseQueriesTotal 数据库查询总数
 	DatabaseQueriesTotal = promauto.NewCounterVec(
 		prometheus.CounterOpts{
@@ -120,6 +154,41 @@ var (
 		[]string{"event_type", "status"},
 	)
 
+	// MailMessagesTotal 邮件发送总数（status: sent/failed/retried/dropped）
+	MailMessagesTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "mail_messages_total",
+			Help: "邮件发送总数",
+		},
+		[]string{"status"},
+	)
+
+	// AuthLoginSuccessTotal 密码登录成功总数
+	AuthLoginSuccessTotal = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "auth_login_success_total",
+			Help: "密码登录成功总数",
+		},
+	)
+
+	// AuthLoginFailuresTotal 密码登录失败总数（reason: bad-password/unknown-user/locked/disabled）
+	AuthLoginFailuresTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "auth_login_failures_total",
+			Help: "密码登录失败总数",
+		},
+		[]string{"reason"},
+	)
+
+	// AuthTokenRefreshTotal 刷新令牌使用总数（result: success/reuse/anomaly）
+	AuthTokenRefreshTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "auth_token_refresh_total",
+			Help: "刷新令牌使用总数",
+		},
+		[]string{"result"},
+	)
+
 	// ActiveConnections 活跃连接数
 	ActiveConnections = promauto.NewGaugeVec(
 		prometheus.GaugeOpts{
@@ -137,6 +206,51 @@ var (
 		},
 		[]string{"type", "code"},
 	)
+
+	// LoadShedTotal 因并发已满被拒绝的请求总数（reason: queue_timeout/client_gone）
+	LoadShedTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "load_shed_total",
+			Help: "因并发已满被拒绝的请求总数",
+		},
+		[]string{"group", "reason"},
+	)
+
+	// CircuitBreakerState 外部依赖熔断器当前状态（0 closed，1 open，2 half-open）
+	CircuitBreakerState = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "circuit_breaker_state",
+			Help: "熔断器当前状态（0 closed，1 open，2 half-open）",
+		},
+		[]string{"name"},
+	)
+
+	// CircuitBreakerTransitionsTotal 熔断器状态转换总数
+	CircuitBreakerTransitionsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "circuit_breaker_transitions_total",
+			Help: "熔断器状态转换总数",
+		},
+		[]string{"name", "from", "to"},
+	)
+
+	// DBReplicaHealthy 数据库只读副本是否可用（1 可用，0 不可用，读请求回退到主库）
+	DBReplicaHealthy = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "db_replica_healthy",
+			Help: "数据库只读副本是否可用（1 可用，0 不可用）",
+		},
+		[]string{"replica"},
+	)
+
+	// BuildInfo 构建信息，值恒为 1，版本等信息在标签中
+	BuildInfo = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "build_info",
+			Help: "构建信息（值恒为 1）",
+		},
+		[]string{"version", "commit", "build_date", "go_version"},
+	)
 )
 
 // RecordHTTPRequest 记录 HTTP 请求指标
@@ -147,6 +261,14 @@ func RecordHTTPRequest(method, path, status string, duration, requestSize, respo
 	HTTPResponseSize.WithLabelValues(method, path).Observe(responseSize)
 }
 
+// RecordOutboundHTTPRequest 记录一次对外 HTTP 请求，短路的请求 duration 为 0 且不计入延迟
+func RecordOutboundHTTPRequest(host, method, status string, duration float64) {
+	OutboundHTTPRequestsTotal.WithLabelValues(host, method, status).Inc()
+	if duration > 0 {
+		OutboundHTTPRequestDuration.WithLabelValues(host, method).Observe(duration)
+	}
+}
+
 // RecordDatabaseQuery 记录数据库查询指标
 func RecordDatabaseQuery(operation, table string, duration float64) {
 	DatabaseQueriesTotal.WithLabelValues(operation, table).Inc()
@@ -179,6 +301,26 @@ func RecordMessageConsumed(eventType, status string) {
 	MessageQueueConsumedTotal.WithLabelValues(eventType, status).Inc()
 }
 
+// RecordMail 记录邮件发送结果
+func RecordMail(status string) {
+	MailMessagesTotal.WithLabelValues(status).Inc()
+}
+
+// RecordLoginSuccess 记录密码登录成功
+func RecordLoginSuccess() {
+	AuthLoginSuccessTotal.Inc()
+}
+
+// RecordLoginFailure 记录密码登录失败及原因
+func RecordLoginFailure(reason string) {
+	AuthLoginFailuresTotal.WithLabelValues(reason).Inc()
+}
+
+// RecordTokenRefresh 记录刷新令牌的使用结果
+func RecordTokenRefresh(result string) {
+	AuthTokenRefreshTotal.WithLabelValues(result).Inc()
+}
+
 // SetActiveConnections 设置活跃连接数
 func SetActiveConnections(connType string, count float64) {
 	ActiveConnections.WithLabelValues(connType).Set(count)
@@ -188,3 +330,32 @@ func SetActiveConnections(connType string, count float64) {
 func RecordError(errorType, code string) {
 	ErrorsTotal.WithLabelValues(errorType, code).Inc()
 }
+
+// RecordLoadShed 记录一次因并发已满被拒绝的请求
+func RecordLoadShed(group, reason string) {
+	LoadShedTotal.WithLabelValues(group, reason).Inc()
+}
+
+// SetCircuitBreakerState 记录熔断器当前状态
+func SetCircuitBreakerState(name string, state float64) {
+	CircuitBreakerState.WithLabelValues(name).Set(state)
+}
+
+// RecordCircuitBreakerTransition 记录一次熔断器状态转换
+func RecordCircuitBreakerTransition(name, from, to string) {
+	CircuitBreakerTransitionsTotal.WithLabelValues(name, from, to).Inc()
+}
+
+// SetDBReplicaHealthy 记录只读副本当前是否可用
+func SetDBReplicaHealthy(replica string, healthy bool) {
+	value := 0.0
+	if healthy {
+		value = 1
+	}
+	DBReplicaHealthy.WithLabelValues(replica).Set(value)
+}
+
+// SetBuildInfo 记录当前二进制的构建信息
+func SetBuildInfo(version, commit, buildDate, goVersion string) {
+	BuildInfo.WithLabelValues(version, commit, buildDate, goVersion).Set(1)
+}
@@ -0,0 +1,163 @@
+// Package middleware 提供管理员接口专用的 IP 白名单和审计日志中间件
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/netip"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/yeegeek/uyou-go-api-starter/internal/contextutil"
+	"github.com/yeegeek/uyou-go-api-starter/internal/errors"
+)
+
+// IPAllowlist 只放行客户端 IP 属于 entries（CIDR 或单个 IP）的请求，其余返回 403
+// 客户端 IP 通过 contextutil.ClientIP 解析，只采信可信代理转发的地址；entries 为空时不做限制
+func IPAllowlist(entries []string) (gin.HandlerFunc, error) {
+	prefixes, err := parsePrefixes(entries)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(c *gin.Context) {
+		if len(prefixes) == 0 {
+			c.Next()
+			return
+		}
+		ip := contextutil.ClientIP(c)
+		if !prefixesContain(prefixes, ip) {
+			slog.WarnContext(c.Request.Context(), "Request rejected by IP allowlist",
+				"ip", ip,
+				"method", c.Request.Method,
+				"path", c.Request.URL.Path,
+			)
+			c.JSON(http.StatusForbidden, errors.Forbidden("access denied from this IP address"))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}, nil
+}
+
+// AuditRecord 一次管理员请求的审计信息，交给 AuditRecorder 持久化
+type AuditRecord struct {
+	ActorID uint
+	// Action 为请求方法加 /admin 之后的路由模板，例如 "POST /admin/users/:id/deactivate"
+	Action   string
+	TargetID string
+	Method   string
+	Path     string
+	Status   int
+	IP       string
+	At       time.Time
+}
+
+// AuditRecorder 持久化管理员审计记录
+type AuditRecorder interface {
+	RecordAudit(ctx context.Context, record AuditRecord) error
+}
+
+// auditTargetParams 按顺序取第一个非空的路由参数作为操作对象
+var auditTargetParams = []string{"id", "name", "family"}
+
+// AdminAudit 在管理员请求完成后记录一条审计日志：操作者、方法、路由、状态码和客户端 IP
+// 传入 recorders 时同时持久化，写入失败只记录警告，不影响已发出的响应
+func AdminAudit(recorders ...AuditRecorder) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = c.Request.URL.Path
+		}
+		slog.InfoContext(c.Request.Context(), "Admin request",
+			"event", "admin_request",
+			"audit", true,
+			"admin_id", contextutil.GetUserID(c),
+			"method", c.Request.Method,
+			"route", route,
+			"path", c.Request.URL.Path,
+			"status", c.Writer.Status(),
+			"ip", contextutil.ClientIP(c),
+			"duration", time.Since(start),
+		)
+
+		if len(recorders) == 0 {
+			return
+		}
+		record := AuditRecord{
+			ActorID: contextutil.GetUserID(c),
+			Action:  auditAction(c.Request.Method, route),
+			Method:  c.Request.Method,
+			Path:    c.Request.URL.Path,
+			Status:  c.Writer.Status(),
+			IP:      contextutil.ClientIP(c),
+			At:      start,
+		}
+		for _, param := range auditTargetParams {
+			if record.TargetID = c.Param(param); record.TargetID != "" {
+				break
+			}
+		}
+		// 客户端断开后仍要写入审计记录
+		ctx := context.WithoutCancel(c.Request.Context())
+		for _, recorder := range recorders {
+			if err := recorder.RecordAudit(ctx, record); err != nil {
+				slog.WarnContext(ctx, "Failed to persist admin audit record", "action", record.Action, "error", err)
+			}
+		}
+	}
+}
+
+// auditAction 去掉 API 前缀和版本号，同一接口在不同版本下的操作类型相同
+func auditAction(method, route string) string {
+	if i := strings.Index(route, "/admin"); i >= 0 {
+		route = route[i:]
+	}
+	return method + " " + route
+}
+
+func parsePrefixes(entries []string) ([]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if strings.Contains(entry, "/") {
+			prefix, err := netip.ParsePrefix(entry)
+			if err != nil {
+				return nil, fmt.Errorf("invalid IP allowlist entry %q: %w", entry, err)
+			}
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid IP allowlist entry %q: %w", entry, err)
+		}
+		addr = addr.Unmap()
+		prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return prefixes, nil
+}
+
+func prefixesContain(prefixes []netip.Prefix, ip string) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
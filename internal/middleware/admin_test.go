@@ -0,0 +1,101 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yeegeek/uyou-go-api-starter/internal/contextutil"
+)
+
+func TestIPAllowlist(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name         string
+		allowlist    []string
+		remoteAddr   string
+		forwardedFor string
+		wantStatus   int
+	}{
+		{"empty allowlist allows everyone", nil, "203.0.113.9:4000", "", http.StatusOK},
+		{"ip inside cidr", []string{"10.1.0.0/16"}, "10.1.2.3:4000", "", http.StatusOK},
+		{"exact ip", []string{"192.0.2.7"}, "192.0.2.7:4000", "", http.StatusOK},
+		{"ip outside list", []string{"10.1.0.0/16", "192.0.2.7"}, "203.0.113.9:4000", "", http.StatusForbidden},
+		{"spoofed forwarded-for from untrusted peer", []string{"10.1.0.0/16"}, "203.0.113.9:4000", "10.1.2.3", http.StatusForbidden},
+		{"forwarded by trusted proxy", []string{"198.51.100.0/24"}, "10.0.0.2:4000", "198.51.100.20", http.StatusOK},
+		{"trusted proxy itself is not allowlisted", []string{"198.51.100.0/24"}, "10.0.0.2:4000", "203.0.113.9", http.StatusForbidden},
+	}
+
+	trusted, err := contextutil.ParseTrustedProxies([]string{"10.0.0.0/24"})
+	require.NoError(t, err)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			allowlist, err := IPAllowlist(tt.allowlist)
+			require.NoError(t, err)
+
+			router := gin.New()
+			router.Use(TrustedProxies(trusted))
+			router.GET("/admin", allowlist, func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/admin", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.forwardedFor != "" {
+				req.Header.Set("X-Forwarded-For", tt.forwardedFor)
+			}
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+		})
+	}
+}
+
+func TestIPAllowlist_InvalidEntry(t *testing.T) {
+	_, err := IPAllowlist([]string{"10.0.0.0/33"})
+	assert.ErrorContains(t, err, "invalid IP allowlist entry")
+}
+
+type recordingAuditRecorder struct {
+	records []AuditRecord
+}
+
+func (r *recordingAuditRecorder) RecordAudit(_ context.Context, record AuditRecord) error {
+	r.records = append(r.records, record)
+	return nil
+}
+
+func TestAdminAudit_Recorder(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	recorder := &recordingAuditRecorder{}
+	router := gin.New()
+	admin := router.Group("/api/v1/admin", AdminAudit(recorder))
+	admin.POST("/users/:id/deactivate", func(c *gin.Context) { c.Status(http.StatusOK) })
+	admin.DELETE("/flags/:name", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+	admin.GET("/stats", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodPost, "/api/v1/admin/users/7/deactivate", nil),
+		httptest.NewRequest(http.MethodDelete, "/api/v1/admin/flags/beta", nil),
+		httptest.NewRequest(http.MethodGet, "/api/v1/admin/stats", nil),
+	} {
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	require.Len(t, recorder.records, 3)
+	assert.Equal(t, "POST /admin/users/:id/deactivate", recorder.records[0].Action)
+	assert.Equal(t, "7", recorder.records[0].TargetID)
+	assert.Equal(t, "/api/v1/admin/users/7/deactivate", recorder.records[0].Path)
+	assert.Equal(t, "DELETE /admin/flags/:name", recorder.records[1].Action)
+	assert.Equal(t, "beta", recorder.records[1].TargetID)
+	assert.Equal(t, http.StatusNoContent, recorder.records[1].Status)
+	assert.Equal(t, "GET /admin/stats", recorder.records[2].Action)
+	assert.Empty(t, recorder.records[2].TargetID)
+}
@@ -0,0 +1,196 @@
+// Package middleware 提供调试用的请求/响应体日志中间件
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BodyRedactedValue 请求/响应体中敏感字段的替代值
+const BodyRedactedValue = "<redacted>"
+
+// defaultBodyLogMaxBytes 未配置时每个请求/响应体最多记录的字节数
+const defaultBodyLogMaxBytes = 4096
+
+// sensitiveBodyKeys 字段名（忽略大小写）包含其中任一片段即脱敏，覆盖 password、new_password、refresh_token、access_token、client_secret 等
+var sensitiveBodyKeys = []string{"password", "token", "secret"}
+
+// sensitiveBodyPattern 用于被截断、无法解析为 JSON 的请求体，按 "key": "value" 形式脱敏
+var sensitiveBodyPattern = regexp.MustCompile(`(?i)("[^"]*(?:password|token|secret)[^"]*"\s*:\s*)("(?:[^"\\]|\\.)*"?|[^,}\]\s]+)`)
+
+// BodyLoggerConfig 请求/响应体日志配置
+type BodyLoggerConfig struct {
+	// Logger 为 nil 时使用 slog.Default()；日志以 debug 级别输出，只有日志级别为 debug 时才会出现
+	Logger *slog.Logger
+	// MaxBytes 每个请求或响应体最多记录的字节数，<= 0 时使用 4096
+	MaxBytes int
+	// SkipPaths 不记录请求/响应体的路径
+	SkipPaths []string
+}
+
+// BodyLogger 以 debug 级别记录脱敏后的 JSON 请求体和响应体，用于排查接口问题
+// 请求体只预读前 MaxBytes 字节，随后与未读部分拼接还给处理函数，不会消耗请求体；非 JSON 内容只记录类型不记录内容
+func BodyLogger(cfg BodyLoggerConfig) gin.HandlerFunc {
+	logger := cfg.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	maxBytes := cfg.MaxBytes
+	if maxBytes <= 0 {
+		maxBytes = defaultBodyLogMaxBytes
+	}
+	skipPaths := make(map[string]bool, len(cfg.SkipPaths))
+	for _, path := range cfg.SkipPaths {
+		skipPaths[path] = true
+	}
+
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		if skipPaths[c.Request.URL.Path] || !logger.Enabled(ctx, slog.LevelDebug) {
+			c.Next()
+			return
+		}
+
+		requestBody, requestTruncated := peekRequestBody(c, maxBytes)
+		capture := &bodyCaptureWriter{ResponseWriter: c.Writer, limit: maxBytes}
+		c.Writer = capture
+
+		c.Next()
+
+		logger.DebugContext(ctx, "HTTP body",
+			slog.String("request_id", c.GetString("request_id")),
+			slog.String("method", c.Request.Method),
+			slog.String("path", c.Request.URL.Path),
+			slog.Int("status", capture.Status()),
+			slog.String("request_body", describeBody(c.Request.Header.Get("Content-Type"), requestBody, requestTruncated)),
+			slog.String("response_body", describeBody(capture.Header().Get("Content-Type"), capture.body.Bytes(), capture.truncated)),
+		)
+	}
+}
+
+// peekRequestBody 预读请求体的前 maxBytes 字节，并把已读部分放回请求体开头；多读一个字节用于判断是否截断
+func peekRequestBody(c *gin.Context, maxBytes int) ([]byte, bool) {
+	if c.Request.Body == nil || c.Request.Body == http.NoBody {
+		return nil, false
+	}
+	head, err := io.ReadAll(io.LimitReader(c.Request.Body, int64(maxBytes)+1))
+	c.Request.Body = &replayBody{Reader: io.MultiReader(bytes.NewReader(head), c.Request.Body), Closer: c.Request.Body}
+	if err != nil {
+		return nil, false
+	}
+	if len(head) > maxBytes {
+		return head[:maxBytes], true
+	}
+	return head, false
+}
+
+// replayBody 先返回预读的部分再继续读取原始请求体，Close 关闭原始请求体
+type replayBody struct {
+	io.Reader
+	io.Closer
+}
+
+// bodyCaptureWriter 在写出响应的同时保留前 limit 字节
+type bodyCaptureWriter struct {
+	gin.ResponseWriter
+	body      bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (w *bodyCaptureWriter) Write(b []byte) (int, error) {
+	w.capture(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *bodyCaptureWriter) WriteString(s string) (int, error) {
+	w.capture([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *bodyCaptureWriter) capture(b []byte) {
+	if remaining := w.limit - w.body.Len(); remaining < len(b) {
+		w.truncated = true
+		b = b[:max(remaining, 0)]
+	}
+	w.body.Write(b)
+}
+
+// describeBody 返回可写入日志的请求/响应体：JSON 脱敏后输出，其他类型只记录类型
+func describeBody(contentType string, body []byte, truncated bool) string {
+	if len(body) == 0 {
+		return ""
+	}
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if mediaType != "application/json" && !strings.HasSuffix(mediaType, "+json") {
+		return "<" + mediaTypeOrUnknown(mediaType) + " body omitted>"
+	}
+
+	redacted := redactJSONBody(body)
+	if truncated {
+		redacted += "...<truncated>"
+	}
+	return redacted
+}
+
+func mediaTypeOrUnknown(mediaType string) string {
+	if mediaType == "" {
+		return "unknown"
+	}
+	return mediaType
+}
+
+// redactJSONBody 将敏感字段的值替换为 BodyRedactedValue；无法解析时（例如被截断）按正则脱敏
+func redactJSONBody(body []byte) string {
+	var payload any
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return sensitiveBodyPattern.ReplaceAllString(string(body), `${1}"`+BodyRedactedValue+`"`)
+	}
+	// 不转义 HTML 字符，占位值按原样输出
+	var out bytes.Buffer
+	encoder := json.NewEncoder(&out)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(redactValue(payload)); err != nil {
+		return BodyRedactedValue
+	}
+	return strings.TrimSuffix(out.String(), "\n")
+}
+
+func redactValue(v any) any {
+	switch value := v.(type) {
+	case map[string]any:
+		for key, nested := range value {
+			if isSensitiveBodyKey(key) {
+				value[key] = BodyRedactedValue
+				continue
+			}
+			value[key] = redactValue(nested)
+		}
+		return value
+	case []any:
+		for i := range value {
+			value[i] = redactValue(value[i])
+		}
+		return value
+	default:
+		return v
+	}
+}
+
+func isSensitiveBodyKey(key string) bool {
+	lower := strings.ToLower(key)
+	for _, s := range sensitiveBodyKeys {
+		if strings.Contains(lower, s) {
+			return true
+		}
+	}
+	return false
+}
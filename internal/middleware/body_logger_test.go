@@ -0,0 +1,133 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newBodyLoggerRouter(buf *bytes.Buffer, level slog.Level, maxBytes int) *gin.Engine {
+	logger := slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: level}))
+	router := gin.New()
+	router.Use(BodyLogger(BodyLoggerConfig{Logger: logger, MaxBytes: maxBytes}))
+	router.POST("/login", func(c *gin.Context) {
+		var req struct {
+			Email    string `json:"email"`
+			Password string `json:"password"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"email":         req.Email,
+			"password_seen": req.Password == "S3cret!pass",
+			"access_token":  "eyJhbGciOiJIUzI1NiJ9.access",
+			"refresh_token": "eyJhbGciOiJIUzI1NiJ9.refresh",
+		})
+	})
+	return router
+}
+
+func decodeBodyLog(t *testing.T, buf *bytes.Buffer) map[string]any {
+	t.Helper()
+	var entry map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, "HTTP body", entry["msg"])
+	return entry
+}
+
+func TestBodyLogger_RedactsSecretsAndKeepsBodyReadable(t *testing.T) {
+	var buf bytes.Buffer
+	router := newBodyLoggerRouter(&buf, slog.LevelDebug, 0)
+
+	req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(`{"email":"jane@example.com","password":"S3cret!pass"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"password_seen":true`, "the handler still reads the full body")
+	assert.Contains(t, w.Body.String(), "eyJhbGciOiJIUzI1NiJ9.access", "the client still receives the tokens")
+
+	assert.NotContains(t, buf.String(), "S3cret!pass")
+	assert.NotContains(t, buf.String(), "eyJhbGciOiJIUzI1NiJ9")
+
+	entry := decodeBodyLog(t, &buf)
+	assert.Equal(t, `{"email":"jane@example.com","password":"<redacted>"}`, entry["request_body"])
+	response := entry["response_body"].(string)
+	assert.Contains(t, response, `"access_token":"<redacted>"`)
+	assert.Contains(t, response, `"refresh_token":"<redacted>"`)
+	assert.Contains(t, response, `"email":"jane@example.com"`)
+	assert.EqualValues(t, http.StatusOK, entry["status"])
+}
+
+func TestBodyLogger_TruncatesLargeBodies(t *testing.T) {
+	var buf bytes.Buffer
+	router := newBodyLoggerRouter(&buf, slog.LevelDebug, 40)
+
+	body := `{"password":"S3cret!pass","email":"` + strings.Repeat("a", 100) + `@example.com"}`
+	req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), strings.Repeat("a", 100), "the handler sees the body beyond the logging cap")
+	assert.NotContains(t, buf.String(), "S3cret!pass")
+
+	entry := decodeBodyLog(t, &buf)
+	request := entry["request_body"].(string)
+	assert.True(t, strings.HasPrefix(request, `{"password":"<redacted>"`), request)
+	assert.True(t, strings.HasSuffix(request, "...<truncated>"), request)
+	assert.True(t, strings.HasSuffix(entry["response_body"].(string), "...<truncated>"))
+}
+
+func TestBodyLogger_OmitsNonJSONBodies(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	router := gin.New()
+	router.Use(BodyLogger(BodyLoggerConfig{Logger: logger}))
+	router.POST("/upload", func(c *gin.Context) {
+		data, err := io.ReadAll(c.Request.Body)
+		require.NoError(t, err)
+		c.String(http.StatusOK, "%d", len(data))
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/upload", strings.NewReader("password=S3cret!pass"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, "20", w.Body.String())
+	assert.NotContains(t, buf.String(), "S3cret!pass")
+	entry := decodeBodyLog(t, &buf)
+	assert.Equal(t, "<application/x-www-form-urlencoded body omitted>", entry["request_body"])
+}
+
+func TestBodyLogger_SilentAboveDebug(t *testing.T) {
+	var buf bytes.Buffer
+	router := newBodyLoggerRouter(&buf, slog.LevelInfo, 0)
+
+	req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(`{"email":"jane@example.com","password":"S3cret!pass"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, buf.String())
+}
+
+func TestRedactJSONBody_Nested(t *testing.T) {
+	got := redactJSONBody([]byte(`{"data":{"user":{"id":1},"items":[{"Access_Token":"a"}]},"client_secret":"s","new_password":"p"}`))
+	assert.Equal(t, `{"client_secret":"<redacted>","data":{"items":[{"Access_Token":"<redacted>"}],"user":{"id":1}},"new_password":"<redacted>"}`, got)
+}
@@ -0,0 +1,179 @@
+// Package middleware 提供响应压缩中间件
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CompressionConfig 响应压缩参数
+type CompressionConfig struct {
+	// MinSize 响应体达到该字节数才压缩，更小的响应压缩后收益不大
+	MinSize int
+	// ContentTypes 允许压缩的媒体类型（不含参数），如 application/json
+	ContentTypes []string
+}
+
+// gzipWriterPool 复用 gzip.Writer，避免每个响应分配压缩窗口
+var gzipWriterPool = sync.Pool{
+	New: func() any {
+		w, _ := gzip.NewWriterLevel(nil, gzip.DefaultCompression)
+		return w
+	},
+}
+
+// Compression 对接受 gzip 的客户端压缩响应体
+// 响应体先缓冲到 MinSize 字节再决定是否压缩：不足 MinSize、媒体类型不在允许列表、处理函数已设置 Content-Encoding
+// （已压缩或自行编码的流式接口）或状态码不带响应体时原样输出；处理函数主动 Flush 时按流式响应立即决定
+func Compression(cfg CompressionConfig) gin.HandlerFunc {
+	contentTypes := make([]string, len(cfg.ContentTypes))
+	for i, t := range cfg.ContentTypes {
+		contentTypes[i] = strings.ToLower(strings.TrimSpace(t))
+	}
+
+	return func(c *gin.Context) {
+		// 同一 URL 的响应随 Accept-Encoding 不同，缓存必须区分
+		c.Writer.Header().Add("Vary", "Accept-Encoding")
+		if c.Request.Method == http.MethodHead || !acceptsGzip(c.Request.Header.Get("Accept-Encoding")) {
+			c.Next()
+			return
+		}
+
+		original := c.Writer
+		w := &compressWriter{ResponseWriter: original, minSize: cfg.MinSize, contentTypes: contentTypes}
+		c.Writer = w
+		defer func() {
+			w.finish()
+			c.Writer = original
+		}()
+		c.Next()
+	}
+}
+
+// acceptsGzip 解析 Accept-Encoding，gzip 或 * 且 q 不为 0 时返回 true
+func acceptsGzip(header string) bool {
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name != "gzip" && name != "*" {
+			continue
+		}
+		if q, ok := strings.CutPrefix(strings.ReplaceAll(params, " ", ""), "q="); ok {
+			if v, err := strconv.ParseFloat(q, 64); err == nil && v == 0 {
+				continue
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// compressWriter 缓冲响应体直到能决定是否压缩，之后直接写入 gzip 或原始输出
+type compressWriter struct {
+	gin.ResponseWriter
+	minSize      int
+	contentTypes []string
+	buf          bytes.Buffer
+	decided      bool
+	gz           *gzip.Writer
+}
+
+func (w *compressWriter) Write(b []byte) (int, error) {
+	if w.decided {
+		return w.write(b)
+	}
+	w.buf.Write(b)
+	if w.buf.Len() >= w.minSize {
+		if err := w.decide(true); err != nil {
+			return 0, err
+		}
+	}
+	return len(b), nil
+}
+
+func (w *compressWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// WriteHeaderNow 在决定前不提交响应头，否则 Content-Encoding 无法再设置
+func (w *compressWriter) WriteHeaderNow() {
+	if w.decided {
+		w.ResponseWriter.WriteHeaderNow()
+	}
+}
+
+// Written 决定前缓冲中已有数据也视为已写出，与 gin 对 Written 的用法一致
+func (w *compressWriter) Written() bool {
+	return w.ResponseWriter.Written() || w.buf.Len() > 0
+}
+
+// Flush 流式响应要求数据立即送达，按当前信息决定是否压缩后刷新
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		_ = w.decide(true)
+	}
+	if w.gz != nil {
+		_ = w.gz.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+func (w *compressWriter) write(b []byte) (int, error) {
+	if w.gz != nil {
+		return w.gz.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// decide 根据响应头决定是否压缩并写出已缓冲的数据；large 表示响应体足够大或为流式响应
+func (w *compressWriter) decide(large bool) error {
+	w.decided = true
+	if large && w.compressible() {
+		header := w.Header()
+		header.Set("Content-Encoding", "gzip")
+		header.Del("Content-Length")
+		w.gz = gzipWriterPool.Get().(*gzip.Writer)
+		w.gz.Reset(w.ResponseWriter)
+	}
+	if w.buf.Len() == 0 {
+		return nil
+	}
+	_, err := w.write(w.buf.Bytes())
+	w.buf.Reset()
+	return err
+}
+
+func (w *compressWriter) compressible() bool {
+	header := w.Header()
+	if header.Get("Content-Encoding") != "" {
+		return false
+	}
+	switch status := w.Status(); {
+	case status < http.StatusOK, status == http.StatusNoContent, status == http.StatusNotModified:
+		return false
+	}
+	mediaType, _, err := mime.ParseMediaType(header.Get("Content-Type"))
+	return err == nil && slices.Contains(w.contentTypes, strings.ToLower(mediaType))
+}
+
+// finish 处理函数返回后输出剩余缓冲并结束 gzip 流
+func (w *compressWriter) finish() {
+	if !w.decided {
+		_ = w.decide(false)
+	}
+	if w.gz != nil {
+		_ = w.gz.Close()
+		w.gz.Reset(nil)
+		gzipWriterPool.Put(w.gz)
+		w.gz = nil
+	}
+	w.ResponseWriter.WriteHeaderNow()
+}
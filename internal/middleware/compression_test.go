@@ -0,0 +1,103 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupCompressionRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Compression(CompressionConfig{MinSize: 64, ContentTypes: []string{"application/json", "text/csv"}}))
+
+	large := strings.Repeat("a", 256)
+	router.GET("/large", func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{"data": large}) })
+	router.GET("/small", func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{"data": "a"}) })
+	router.GET("/html", func(c *gin.Context) { c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(large)) })
+	router.GET("/encoded", func(c *gin.Context) {
+		c.Header("Content-Encoding", "br")
+		c.Data(http.StatusOK, "application/json", []byte(large))
+	})
+	router.GET("/stream", func(c *gin.Context) {
+		c.Header("Content-Type", "text/csv")
+		c.Status(http.StatusOK)
+		_, _ = c.Writer.WriteString("id,name\n")
+		c.Writer.Flush()
+		_, _ = c.Writer.WriteString("1,Jane\n")
+	})
+	return router
+}
+
+func doCompressionRequest(router *gin.Engine, path, acceptEncoding string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if acceptEncoding != "" {
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+	}
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func gunzip(t *testing.T, body io.Reader) string {
+	t.Helper()
+	reader, err := gzip.NewReader(body)
+	require.NoError(t, err)
+	defer reader.Close()
+	decoded, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	return string(decoded)
+}
+
+func TestCompression_CompressesLargeResponses(t *testing.T) {
+	router := setupCompressionRouter()
+
+	w := doCompressionRequest(router, "/large", "br;q=1.0, gzip;q=0.8")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+	assert.Equal(t, "Accept-Encoding", w.Header().Get("Vary"))
+	assert.Empty(t, w.Header().Get("Content-Length"))
+	assert.Equal(t, `{"data":"`+strings.Repeat("a", 256)+`"}`, gunzip(t, w.Body))
+}
+
+func TestCompression_PassesThrough(t *testing.T) {
+	router := setupCompressionRouter()
+
+	tests := []struct {
+		name           string
+		path           string
+		acceptEncoding string
+	}{
+		{name: "client does not accept gzip", path: "/large"},
+		{name: "gzip refused with q=0", path: "/large", acceptEncoding: "gzip;q=0"},
+		{name: "below min size", path: "/small", acceptEncoding: "gzip"},
+		{name: "content type not allowed", path: "/html", acceptEncoding: "gzip"},
+		{name: "handler set content encoding", path: "/encoded", acceptEncoding: "gzip"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := doCompressionRequest(router, tt.path, tt.acceptEncoding)
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.NotEqual(t, "gzip", w.Header().Get("Content-Encoding"))
+			assert.Equal(t, "Accept-Encoding", w.Header().Get("Vary"))
+			assert.NotEmpty(t, w.Body.String())
+			assert.NotContains(t, w.Body.String(), "\x1f\x8b", "body must not be gzip encoded")
+		})
+	}
+}
+
+func TestCompression_FlushStreamsCompressedBody(t *testing.T) {
+	router := setupCompressionRouter()
+
+	w := doCompressionRequest(router, "/stream", "gzip")
+	assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"), "flushing decides immediately for allowed types")
+	assert.Equal(t, "id,name\n1,Jane\n", gunzip(t, w.Body))
+}
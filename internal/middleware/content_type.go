@@ -0,0 +1,81 @@
+// Package middleware 提供请求 Content-Type 校验中间件
+package middleware
+
+import (
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	apiErrors "github.com/yeegeek/uyou-go-api-starter/internal/errors"
+)
+
+// MediaTypeJSON 接口默认接受的请求体类型，application/*+json 同样接受
+const MediaTypeJSON = "application/json"
+
+// ContentTypeOverride 为单个路由指定允许的请求体类型，例如文件上传接口使用 multipart/form-data
+// Path 为 gin 路由模板（如 /api/v1/users/:id/avatar），与 c.FullPath() 比较
+type ContentTypeOverride struct {
+	Method     string
+	Path       string
+	MediaTypes []string
+}
+
+// JSONContentType 要求携带请求体的 POST/PUT/PATCH/DELETE 请求使用 JSON，否则返回 415；缺少 Content-Type 同样返回 415
+// 没有请求体的请求（如登出、无参数的管理操作）和未匹配路由的请求不做校验，后者交给 404 处理
+func JSONContentType(overrides ...ContentTypeOverride) gin.HandlerFunc {
+	routes := make(map[string][]string, len(overrides))
+	for _, o := range overrides {
+		routes[strings.ToUpper(o.Method)+" "+o.Path] = o.MediaTypes
+	}
+
+	return func(c *gin.Context) {
+		if !hasRequestBody(c.Request) || c.FullPath() == "" {
+			c.Next()
+			return
+		}
+
+		allowed, overridden := routes[c.Request.Method+" "+c.FullPath()]
+		if !overridden {
+			allowed = []string{MediaTypeJSON}
+		}
+
+		contentType := c.GetHeader("Content-Type")
+		if !mediaTypeAllowed(contentType, allowed, overridden) {
+			_ = c.Error(apiErrors.UnsupportedMediaType(contentType, allowed))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// hasRequestBody 只校验可能携带请求体的方法；ContentLength 为 -1 表示分块传输，长度未知
+func hasRequestBody(r *http.Request) bool {
+	switch r.Method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return r.ContentLength != 0
+	default:
+		return false
+	}
+}
+
+// mediaTypeAllowed 忽略 boundary 等参数比较媒体类型；默认规则下同时接受 application/*+json，
+// 且 JSON 请求体只接受 utf-8 字符集（未声明 charset 视为 utf-8）
+func mediaTypeAllowed(contentType string, allowed []string, exact bool) bool {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	isJSON := mediaType == MediaTypeJSON || strings.HasPrefix(mediaType, "application/") && strings.HasSuffix(mediaType, "+json")
+	if charset, ok := params["charset"]; ok && isJSON && !strings.EqualFold(charset, "utf-8") {
+		return false
+	}
+	for _, a := range allowed {
+		if mediaType == a {
+			return true
+		}
+	}
+	return !exact && isJSON
+}
@@ -0,0 +1,136 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	apiErrors "github.com/yeegeek/uyou-go-api-starter/internal/errors"
+)
+
+func setupContentTypeRouter(overrides ...ContentTypeOverride) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(apiErrors.ErrorHandler())
+	router.Use(JSONContentType(overrides...))
+
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	router.GET("/items", ok)
+	router.POST("/items", ok)
+	router.PATCH("/items/:id", ok)
+	router.DELETE("/items/:id", ok)
+	router.POST("/items/:id/attachment", ok)
+	router.PUT("/items/:id", func(c *gin.Context) {
+		var req map[string]any
+		if err := c.ShouldBindJSON(&req); err != nil {
+			_ = c.Error(apiErrors.FromGinValidation(err))
+			return
+		}
+		c.Status(http.StatusOK)
+	})
+	return router
+}
+
+func TestJSONContentType(t *testing.T) {
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		contentType string
+		body        string
+		wantStatus  int
+	}{
+		{name: "json body", method: http.MethodPost, path: "/items", contentType: "application/json", body: `{"a":1}`, wantStatus: http.StatusOK},
+		{name: "json with charset", method: http.MethodPatch, path: "/items/1", contentType: "application/json; charset=utf-8", body: `{"a":1}`, wantStatus: http.StatusOK},
+		{name: "json with uppercase charset", method: http.MethodPost, path: "/items", contentType: "application/json; charset=UTF-8", body: `{"a":1}`, wantStatus: http.StatusOK},
+		{name: "json with other charset", method: http.MethodPost, path: "/items", contentType: "application/json; charset=iso-8859-1", body: `{"a":1}`, wantStatus: http.StatusUnsupportedMediaType},
+		{name: "structured json suffix", method: http.MethodPatch, path: "/items/1", contentType: "application/merge-patch+json", body: `{"a":1}`, wantStatus: http.StatusOK},
+		{name: "text/plain body", method: http.MethodPost, path: "/items", contentType: "text/plain", body: `{"a":1}`, wantStatus: http.StatusUnsupportedMediaType},
+		{name: "form body", method: http.MethodPost, path: "/items", contentType: "application/x-www-form-urlencoded", body: "a=1", wantStatus: http.StatusUnsupportedMediaType},
+		{name: "missing header with body", method: http.MethodDelete, path: "/items/1", body: `{"password":"x"}`, wantStatus: http.StatusUnsupportedMediaType},
+		{name: "malformed header", method: http.MethodPost, path: "/items", contentType: "application/json;;;=", body: `{}`, wantStatus: http.StatusUnsupportedMediaType},
+		{name: "bodyless post", method: http.MethodPost, path: "/items", wantStatus: http.StatusOK},
+		{name: "bodyless delete", method: http.MethodDelete, path: "/items/1", wantStatus: http.StatusOK},
+		{name: "get unaffected", method: http.MethodGet, path: "/items", contentType: "text/plain", body: "ignored", wantStatus: http.StatusOK},
+		{name: "unknown route left to 404", method: http.MethodPost, path: "/missing", contentType: "text/plain", body: "x", wantStatus: http.StatusNotFound},
+	}
+
+	router := setupContentTypeRouter()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+		})
+	}
+}
+
+func TestJSONContentType_ErrorEnvelope(t *testing.T) {
+	router := setupContentTypeRouter()
+
+	req := httptest.NewRequest(http.MethodPost, "/items", strings.NewReader("hello"))
+	req.Header.Set("Content-Type", "text/plain")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+	var resp apiErrors.Response
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.False(t, resp.Success)
+	require.NotNil(t, resp.Error)
+	assert.Equal(t, apiErrors.CodeUnsupportedMediaType, resp.Error.Code)
+	assert.Contains(t, resp.Error.Message, "text/plain")
+	assert.Equal(t, "/items", resp.Error.Path)
+}
+
+func TestJSONContentType_Override(t *testing.T) {
+	router := setupContentTypeRouter(ContentTypeOverride{
+		Method:     http.MethodPost,
+		Path:       "/items/:id/attachment",
+		MediaTypes: []string{"multipart/form-data"},
+	})
+
+	send := func(path, contentType string) int {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader("--boundary--"))
+		req.Header.Set("Content-Type", contentType)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, send("/items/1/attachment", "multipart/form-data; boundary=boundary"))
+	assert.Equal(t, http.StatusUnsupportedMediaType, send("/items/1/attachment", "application/json"), "override replaces the default")
+	assert.Equal(t, http.StatusUnsupportedMediaType, send("/items", "multipart/form-data; boundary=boundary"), "other routes still require JSON")
+}
+
+func TestJSONContentType_EmptyBody(t *testing.T) {
+	router := setupContentTypeRouter()
+
+	req := httptest.NewRequest(http.MethodPut, "/items/1", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusBadRequest, w.Code, "an empty body passes the content type check but fails binding")
+	var resp apiErrors.Response
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.NotNil(t, resp.Error)
+	assert.Equal(t, apiErrors.CodeEmptyBody, resp.Error.Code)
+	assert.Nil(t, resp.Error.Details, "the binding EOF is not echoed back")
+
+	req = httptest.NewRequest(http.MethodPut, "/items/1", strings.NewReader(`{"a":1}`))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
@@ -0,0 +1,83 @@
+// Package middleware 提供条件请求（ETag / If-None-Match）中间件
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ETag 为 GET 请求的 200 响应计算弱 ETag（响应体 SHA-256 的前 16 字节），If-None-Match 命中时返回 304 且不带响应体
+// 使用弱 ETag 是因为压缩中间件可能改变传输编码，但表示的内容不变；只适合响应体较小的单资源接口
+func ETag() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method != http.MethodGet {
+			c.Next()
+			return
+		}
+
+		original := c.Writer
+		w := &etagWriter{ResponseWriter: original}
+		c.Writer = w
+		c.Next()
+		c.Writer = original
+
+		// 出错的请求由 ErrorHandler 在之后写出错误响应，不能带 ETag
+		if w.Status() != http.StatusOK || len(c.Errors) > 0 {
+			if w.body.Len() > 0 {
+				_, _ = original.Write(w.body.Bytes())
+			}
+			return
+		}
+
+		sum := sha256.Sum256(w.body.Bytes())
+		etag := `W/"` + hex.EncodeToString(sum[:16]) + `"`
+		original.Header().Set("ETag", etag)
+		if etagMatches(c.Request.Header.Get("If-None-Match"), etag) {
+			original.Header().Del("Content-Length")
+			original.WriteHeader(http.StatusNotModified)
+			original.WriteHeaderNow()
+			return
+		}
+		_, _ = original.Write(w.body.Bytes())
+	}
+}
+
+// etagMatches 按弱比较判断 If-None-Match 是否包含 etag，* 匹配任意值
+func etagMatches(header, etag string) bool {
+	if header == "" {
+		return false
+	}
+	opaque := strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == opaque {
+			return true
+		}
+	}
+	return false
+}
+
+// etagWriter 缓冲完整响应体，响应头在计算出 ETag 后才提交
+type etagWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *etagWriter) Write(b []byte) (int, error) {
+	return w.body.Write(b)
+}
+
+func (w *etagWriter) WriteString(s string) (int, error) {
+	return w.body.WriteString(s)
+}
+
+func (w *etagWriter) WriteHeaderNow() {}
+
+func (w *etagWriter) Written() bool {
+	return w.ResponseWriter.Written() || w.body.Len() > 0
+}
@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	apiErrors "github.com/yeegeek/uyou-go-api-starter/internal/errors"
+)
+
+func setupETagRouter(name *string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(apiErrors.ErrorHandler())
+	router.GET("/users/:id", ETag(), func(c *gin.Context) {
+		if c.Param("id") != "1" {
+			_ = c.Error(apiErrors.NotFound("user not found"))
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"id": 1, "name": *name})
+	})
+	return router
+}
+
+func doETagRequest(router *gin.Engine, path, ifNoneMatch string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if ifNoneMatch != "" {
+		req.Header.Set("If-None-Match", ifNoneMatch)
+	}
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestETag_ConditionalRequests(t *testing.T) {
+	name := "Jane"
+	router := setupETagRouter(&name)
+
+	w := doETagRequest(router, "/users/1", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	etag := w.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+	assert.Regexp(t, `^W/"[0-9a-f]+"$`, etag)
+	assert.Contains(t, w.Body.String(), "Jane")
+
+	w = doETagRequest(router, "/users/1", etag)
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Empty(t, w.Body.String())
+	assert.Equal(t, etag, w.Header().Get("ETag"))
+
+	w = doETagRequest(router, "/users/1", `"other", `+etag)
+	assert.Equal(t, http.StatusNotModified, w.Code, "any tag in the list may match")
+
+	w = doETagRequest(router, "/users/1", "*")
+	assert.Equal(t, http.StatusNotModified, w.Code)
+
+	name = "Janet"
+	w = doETagRequest(router, "/users/1", etag)
+	assert.Equal(t, http.StatusOK, w.Code, "changed representation gets a new tag")
+	assert.NotEqual(t, etag, w.Header().Get("ETag"))
+	assert.Contains(t, w.Body.String(), "Janet")
+}
+
+func TestETag_SkipsErrorResponses(t *testing.T) {
+	name := "Jane"
+	router := setupETagRouter(&name)
+
+	w := doETagRequest(router, "/users/2", "*")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Empty(t, w.Header().Get("ETag"))
+	assert.Contains(t, w.Body.String(), apiErrors.CodeNotFound)
+}
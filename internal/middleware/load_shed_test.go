@@ -0,0 +1,207 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	apiErrors "github.com/yeegeek/uyou-go-api-starter/internal/errors"
+)
+
+func setupLoadShedRouter(limiter *ConcurrencyLimiter, handler gin.HandlerFunc) *gin.Engine {
+	router := gin.New()
+	router.Use(apiErrors.ErrorHandler())
+	router.Use(LoadShed(limiter))
+	router.GET("/work/:id", handler)
+	return router
+}
+
+func serveAsync(router http.Handler, req *http.Request) <-chan *httptest.ResponseRecorder {
+	done := make(chan *httptest.ResponseRecorder, 1)
+	go func() {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		done <- w
+	}()
+	return done
+}
+
+func TestLoadShed_StressEnforcesCap(t *testing.T) {
+	const maxInFlight, requests = 3, 24
+	limiter := NewConcurrencyLimiter("test", maxInFlight, 5*time.Second)
+
+	var active, peak atomic.Int32
+	router := setupLoadShedRouter(limiter, func(c *gin.Context) {
+		n := active.Add(1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		active.Add(-1)
+		c.Status(http.StatusOK)
+	})
+
+	var wg sync.WaitGroup
+	codes := make([]int, requests)
+	for i := range requests {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/work/1", nil))
+			codes[i] = w.Code
+		}()
+	}
+	wg.Wait()
+
+	for i, code := range codes {
+		assert.Equal(t, http.StatusOK, code, "request %d should complete once a slot frees up", i)
+	}
+	assert.Equal(t, int32(maxInFlight), peak.Load(), "in-flight requests must never exceed the cap")
+	assert.Zero(t, limiter.InFlight())
+	assert.Zero(t, limiter.Queued())
+}
+
+func TestLoadShed_RejectsAfterQueueWait(t *testing.T) {
+	tests := []struct {
+		name       string
+		queueWait  time.Duration
+		retryAfter string
+	}{
+		{name: "queue wait elapses", queueWait: 50 * time.Millisecond, retryAfter: "1"},
+		{name: "no queueing", queueWait: 0, retryAfter: "1"},
+		{name: "retry after rounds up", queueWait: 1500 * time.Millisecond, retryAfter: "2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter := NewConcurrencyLimiter("test", 1, tt.queueWait)
+			release := make(chan struct{})
+			router := setupLoadShedRouter(limiter, func(c *gin.Context) {
+				if c.Param("id") == "slow" {
+					<-release
+				}
+				c.Status(http.StatusOK)
+			})
+
+			slow := serveAsync(router, httptest.NewRequest(http.MethodGet, "/work/slow", nil))
+			require.Eventually(t, func() bool { return limiter.InFlight() == 1 }, time.Second, time.Millisecond)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/work/fast", nil))
+
+			assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+			assert.Equal(t, tt.retryAfter, w.Header().Get("Retry-After"))
+			var response apiErrors.Response
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			require.NotNil(t, response.Error)
+			assert.Equal(t, apiErrors.CodeServiceUnavailable, response.Error.Code)
+
+			close(release)
+			assert.Equal(t, http.StatusOK, (<-slow).Code)
+			assert.Zero(t, limiter.InFlight())
+		})
+	}
+}
+
+func TestLoadShed_QueueIsFIFO(t *testing.T) {
+	limiter := NewConcurrencyLimiter("test", 1, 5*time.Second)
+	release := make(chan struct{})
+	var mu sync.Mutex
+	var order []string
+	router := setupLoadShedRouter(limiter, func(c *gin.Context) {
+		if c.Param("id") == "slow" {
+			<-release
+		}
+		mu.Lock()
+		order = append(order, c.Param("id"))
+		mu.Unlock()
+		c.Status(http.StatusOK)
+	})
+
+	slow := serveAsync(router, httptest.NewRequest(http.MethodGet, "/work/slow", nil))
+	require.Eventually(t, func() bool { return limiter.InFlight() == 1 }, time.Second, time.Millisecond)
+
+	var queued []<-chan *httptest.ResponseRecorder
+	for i, id := range []string{"a", "b", "c"} {
+		queued = append(queued, serveAsync(router, httptest.NewRequest(http.MethodGet, "/work/"+id, nil)))
+		require.Eventually(t, func() bool { return limiter.Queued() == i+1 }, time.Second, time.Millisecond)
+	}
+
+	close(release)
+	assert.Equal(t, http.StatusOK, (<-slow).Code)
+	for _, done := range queued {
+		assert.Equal(t, http.StatusOK, (<-done).Code)
+	}
+	assert.Equal(t, []string{"slow", "a", "b", "c"}, order)
+}
+
+func TestLoadShed_ClientDisconnectLeavesQueue(t *testing.T) {
+	limiter := NewConcurrencyLimiter("test", 1, 5*time.Second)
+	release := make(chan struct{})
+	var handled atomic.Int32
+	router := setupLoadShedRouter(limiter, func(c *gin.Context) {
+		if c.Param("id") == "slow" {
+			<-release
+		}
+		handled.Add(1)
+		c.Status(http.StatusOK)
+	})
+
+	slow := serveAsync(router, httptest.NewRequest(http.MethodGet, "/work/slow", nil))
+	require.Eventually(t, func() bool { return limiter.InFlight() == 1 }, time.Second, time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	gone := serveAsync(router, httptest.NewRequest(http.MethodGet, "/work/gone", nil).WithContext(ctx))
+	require.Eventually(t, func() bool { return limiter.Queued() == 1 }, time.Second, time.Millisecond)
+
+	cancel()
+	assert.Equal(t, http.StatusServiceUnavailable, (<-gone).Code)
+	assert.Zero(t, limiter.Queued())
+
+	// The slot freed by the slow request goes to the next arrival, not to the departed client
+	close(release)
+	assert.Equal(t, http.StatusOK, (<-slow).Code)
+	assert.Zero(t, limiter.InFlight())
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/work/next", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, int32(2), handled.Load())
+}
+
+func TestLoadShed_ReleasesSlotOnPanic(t *testing.T) {
+	limiter := NewConcurrencyLimiter("test", 1, 0)
+	router := gin.New()
+	router.Use(gin.CustomRecovery(func(c *gin.Context, _ any) { c.AbortWithStatus(http.StatusInternalServerError) }))
+	router.Use(LoadShed(limiter))
+	router.GET("/panic", func(c *gin.Context) { panic("boom") })
+
+	for range 2 {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/panic", nil))
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+	}
+	assert.Zero(t, limiter.InFlight())
+}
+
+func TestLoadShed_Disabled(t *testing.T) {
+	assert.Nil(t, NewConcurrencyLimiter("test", 0, time.Second))
+
+	router := setupLoadShedRouter(nil, func(c *gin.Context) { c.Status(http.StatusOK) })
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/work/1", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
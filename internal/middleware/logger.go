@@ -8,6 +8,8 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+
+	"github.com/yeegeek/uyou-go-api-starter/internal/contextutil"
 )
 
 // LoggerConfig defines the configuration for the logger middleware
@@ -31,8 +33,9 @@ func DefaultLoggerConfig() *LoggerConfig {
 	}
 }
 
-// NewLoggerConfig creates a logger configuration from logging config
-func NewLoggerConfig(logLevel slog.Level, skipPaths []string) *LoggerConfig {
+// NewLoggerConfig creates a logger configuration from logging config.
+// Pass a *slog.LevelVar to change the level at runtime.
+func NewLoggerConfig(logLevel slog.Leveler, skipPaths []string) *LoggerConfig {
 	// Create a JSON logger that writes to stdout
 	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
 		Level: logLevel,
@@ -74,6 +77,7 @@ func Logger(config *LoggerConfig) gin.HandlerFunc {
 		}
 		c.Set("request_id", requestID)
 		c.Writer.Header().Set("X-Request-ID", requestID)
+		c.Request = c.Request.WithContext(contextutil.WithRequestID(c.Request.Context(), requestID))
 
 		// Process request
 		c.Next()
@@ -102,18 +106,28 @@ func Logger(config *LoggerConfig) gin.HandlerFunc {
 			level = slog.LevelWarn
 		}
 
-		// Log structured data
-		logger.Log(c.Request.Context(), level, "HTTP Request",
+		attrs := []any{
 			slog.String("request_id", requestID),
 			slog.String("method", c.Request.Method),
 			slog.String("path", path),
 			slog.Int("status", statusCode),
 			slog.Duration("duration", duration),
 			slog.String("duration_ms", formatDuration(duration)),
-			slog.String("client_ip", c.ClientIP()),
+			slog.String("client_ip", contextutil.ClientIP(c)),
 			slog.String("user_agent", c.Request.UserAgent()),
 			slog.Int("response_size", c.Writer.Size()),
-		)
+		}
+
+		// Record both identities so impersonated requests are attributable to the admin
+		if userID := contextutil.GetUserID(c); userID != 0 {
+			attrs = append(attrs, slog.Uint64("user_id", uint64(userID)))
+		}
+		if impersonatorID := contextutil.GetImpersonatorID(c); impersonatorID != 0 {
+			attrs = append(attrs, slog.Uint64("impersonator_id", uint64(impersonatorID)))
+		}
+
+		// Log structured data
+		logger.Log(c.Request.Context(), level, "HTTP Request", attrs...)
 
 		// Log error if present
 		if len(c.Errors) > 0 {
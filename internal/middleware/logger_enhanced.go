@@ -7,6 +7,8 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+
+	"github.com/yeegeek/uyou-go-api-starter/internal/contextutil"
 )
 
 // EnhancedLoggerMiddleware 增强的结构化日志中间件
@@ -19,6 +21,7 @@ func EnhancedLoggerMiddleware() gin.HandlerFunc {
 		}
 		c.Set("request_id", requestID)
 		c.Header("X-Request-ID", requestID)
+		c.Request = c.Request.WithContext(contextutil.WithRequestID(c.Request.Context(), requestID))
 
 		// 生成追踪 ID
 		traceID := c.GetHeader("X-Trace-ID")
@@ -38,7 +41,7 @@ func EnhancedLoggerMiddleware() gin.HandlerFunc {
 			"method", c.Request.Method,
 			"path", c.Request.URL.Path,
 			"query", c.Request.URL.RawQuery,
-			"client_ip", c.ClientIP(),
+			"client_ip", contextutil.ClientIP(c),
 			"user_agent", c.Request.UserAgent(),
 		)
 
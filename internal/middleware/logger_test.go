@@ -10,6 +10,8 @@ import (
 	"testing"
 
 	"github.com/gin-gonic/gin"
+
+	"github.com/yeegeek/uyou-go-api-starter/internal/contextutil"
 )
 
 func init() {
@@ -151,9 +153,11 @@ func TestLoggerWithProvidedRequestID(t *testing.T) {
 		Logger:    logger,
 	}
 
+	var contextID string
 	router := gin.New()
 	router.Use(Logger(config))
 	router.GET("/test", func(c *gin.Context) {
+		contextID = contextutil.RequestIDFromContext(c.Request.Context())
 		c.JSON(http.StatusOK, gin.H{"status": "ok"})
 	})
 
@@ -169,6 +173,11 @@ func TestLoggerWithProvidedRequestID(t *testing.T) {
 	if !strings.Contains(logOutput, providedID) {
 		t.Errorf("Expected log to contain provided request ID: %s", providedID)
 	}
+
+	// Verify request ID reaches the request context for outbound calls
+	if contextID != providedID {
+		t.Errorf("Expected request context to carry request ID %s, got %q", providedID, contextID)
+	}
 }
 
 // TestLoggerStatusCodes tests logging of different status codes
@@ -2,6 +2,7 @@
 package middleware
 
 import (
+	"math"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
@@ -13,25 +14,64 @@ const (
 	MaxPerPage     = 100
 )
 
+// paginationLimitsKey 请求上下文中分页配置的键
+const paginationLimitsKey = "pagination_limits"
+
 // PaginationParams represents pagination parameters
 type PaginationParams struct {
 	Page    int
 	PerPage int
 }
 
+// paginationLimits 每页数量的默认值和上限
+type paginationLimits struct {
+	defaultPerPage int
+	maxPerPage     int
+}
+
+// Pagination 将配置的每页默认数量和上限写入请求上下文，供 ParsePaginationParams 使用
+// 未注册该中间件时使用 DefaultPerPage 和 MaxPerPage
+func Pagination(defaultPerPage, maxPerPage int) gin.HandlerFunc {
+	if maxPerPage < 1 {
+		maxPerPage = MaxPerPage
+	}
+	if defaultPerPage < 1 || defaultPerPage > maxPerPage {
+		defaultPerPage = min(DefaultPerPage, maxPerPage)
+	}
+	limits := paginationLimits{defaultPerPage: defaultPerPage, maxPerPage: maxPerPage}
+
+	return func(c *gin.Context) {
+		c.Set(paginationLimitsKey, limits)
+		c.Next()
+	}
+}
+
 // ParsePaginationParams parses and validates pagination parameters from request
 func ParsePaginationParams(c *gin.Context) PaginationParams {
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	if page < 1 {
+	limits := paginationLimits{defaultPerPage: DefaultPerPage, maxPerPage: MaxPerPage}
+	if v, ok := c.Get(paginationLimitsKey); ok {
+		if l, ok := v.(paginationLimits); ok {
+			limits = l
+		}
+	}
+
+	// 超出 int 范围时 Atoi 返回边界值和错误，按无效值处理
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
 		page = DefaultPage
 	}
 
-	perPage, _ := strconv.Atoi(c.DefaultQuery("per_page", "20"))
-	if perPage < 1 {
-		perPage = DefaultPerPage
+	perPage, err := strconv.Atoi(c.Query("per_page"))
+	if err != nil || perPage < 1 {
+		perPage = limits.defaultPerPage
+	}
+	if perPage > limits.maxPerPage {
+		perPage = limits.maxPerPage
 	}
-	if perPage > MaxPerPage {
-		perPage = MaxPerPage
+
+	// 限制页码使偏移量 (page-1)*per_page 不溢出；超出数据范围的页码照常返回空列表
+	if maxPage := math.MaxInt / perPage; page > maxPage {
+		page = maxPage
 	}
 
 	return PaginationParams{
@@ -3,6 +3,7 @@ package middleware
 import (
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"testing"
 
 	"github.com/gin-gonic/gin"
@@ -166,3 +167,84 @@ func TestPaginationConstants(t *testing.T) {
 	assert.Equal(t, 20, DefaultPerPage)
 	assert.Equal(t, 100, MaxPerPage)
 }
+
+func TestPagination_ConfiguredLimits(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name            string
+		defaultPerPage  int
+		maxPerPage      int
+		query           string
+		expectedPerPage int
+	}{
+		{name: "configured default applies when omitted", defaultPerPage: 10, maxPerPage: 50, query: "", expectedPerPage: 10},
+		{name: "configured default applies when invalid", defaultPerPage: 10, maxPerPage: 50, query: "per_page=abc", expectedPerPage: 10},
+		{name: "configured max caps larger per_page", defaultPerPage: 10, maxPerPage: 50, query: "per_page=80", expectedPerPage: 50},
+		{name: "per_page within configured max", defaultPerPage: 10, maxPerPage: 50, query: "per_page=30", expectedPerPage: 30},
+		{name: "zero values fall back to built-in defaults", query: "per_page=500", expectedPerPage: MaxPerPage},
+		{name: "default above max is clamped", defaultPerPage: 30, maxPerPage: 5, query: "", expectedPerPage: 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			_, router := gin.CreateTestContext(w)
+
+			var result PaginationParams
+			router.Use(Pagination(tt.defaultPerPage, tt.maxPerPage))
+			router.GET("/", func(c *gin.Context) {
+				result = ParsePaginationParams(c)
+			})
+
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/?"+tt.query, nil))
+
+			assert.Equal(t, tt.expectedPerPage, result.PerPage)
+		})
+	}
+}
+
+func FuzzPaginationParams(f *testing.F) {
+	gin.SetMode(gin.TestMode)
+	for _, seed := range []string{
+		"",
+		"page=2&per_page=50",
+		"page=-5&per_page=-10",
+		"per_page=200",
+		"page=abc&per_page=xyz",
+		"page=2.5&per_page=25.7",
+		"page=999999",
+		"page=9223372036854775807&per_page=100",
+		"page=99999999999999999999",
+		"page=%2B3&per_page=+7",
+		"page=1&page=0",
+	} {
+		f.Add(seed, 0, 0)
+		f.Add(seed, 50, 500)
+	}
+
+	f.Fuzz(func(t *testing.T, rawQuery string, defaultPerPage, maxPerPage int) {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = &http.Request{Method: http.MethodGet, URL: &url.URL{Path: "/", RawQuery: rawQuery}}
+
+		// 0 表示不注册 Pagination 中间件，使用默认上限
+		limit := MaxPerPage
+		if maxPerPage != 0 {
+			Pagination(defaultPerPage, maxPerPage)(c)
+			if maxPerPage > 0 {
+				limit = maxPerPage
+			}
+		}
+
+		params := ParsePaginationParams(c)
+		if params.Page < 1 {
+			t.Fatalf("page %d < 1", params.Page)
+		}
+		if params.PerPage < 1 || params.PerPage > limit {
+			t.Fatalf("per_page %d outside 1..%d", params.PerPage, limit)
+		}
+		if offset := (params.Page - 1) * params.PerPage; offset < 0 || offset/params.PerPage != params.Page-1 {
+			t.Fatalf("offset for page %d per_page %d overflows", params.Page, params.PerPage)
+		}
+	})
+}
@@ -4,6 +4,7 @@ package middleware
 import (
 	"math"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -28,56 +29,195 @@ var (
 // Default in-memory store (LRU with TTL).
 var defaultStore = expirable.NewLRU[string, *rate.Limiter](DefaultCacheSize, nil, DefaultTTL)
 
+// NewMemoryStore creates a separate in-memory limiter store, for limiters whose
+// keys should not compete with the global limiter for LRU capacity.
+func NewMemoryStore(size int, ttl time.Duration) Storage {
+	return expirable.NewLRU[string, *rate.Limiter](size, nil, ttl)
+}
+
 // NewRateLimitMiddleware installs a token-bucket rate limiter per key.
 // R = requests / window (req/s). Burst = requests (allows short spikes up to N).
+// Use NewDynamicRateLimitMiddleware for a separate burst or the fixed-window strategy.
 func NewRateLimitMiddleware(
 	window time.Duration,
 	requests int,
 	keyFunc func(*gin.Context) string,
 	store Storage,
 ) gin.HandlerFunc {
+	return NewDynamicRateLimitMiddleware(func() RateLimitParams {
+		return RateLimitParams{Enabled: true, Window: window, Requests: requests}
+	}, keyFunc, store)
+}
+
+// Rate limiting strategies selectable through RateLimitParams.Strategy.
+const (
+	// StrategyTokenBucket refills Requests tokens per Window and admits spikes up to Burst
+	StrategyTokenBucket = "token_bucket"
+	// StrategyFixedWindow admits at most Requests per key in a Window that starts with the key's first request
+	StrategyFixedWindow = "fixed_window"
+)
+
+// RateLimitParams 限流参数，未启用时请求直接放行
+type RateLimitParams struct {
+	Enabled  bool
+	Window   time.Duration
+	Requests int
+	// Strategy 限流算法，StrategyTokenBucket（默认）或 StrategyFixedWindow
+	Strategy string
+	// Burst 令牌桶容量，即允许的瞬时突发请求数，0 表示与 Requests 相同；固定窗口模式忽略
+	Burst int
+}
+
+// rateLimitDecision is the outcome of taking one request from a key's limiter.
+type rateLimitDecision struct {
+	allowed    bool
+	limit      int
+	remaining  int
+	resetAt    time.Time
+	retryAfter time.Duration
+}
+
+// NewDynamicRateLimitMiddleware 与 NewRateLimitMiddleware 相同，但每个请求都从 params 读取限流参数，
+// 配置热加载后已有的限流器在下一次请求时按新参数调整，无需重建中间件
+func NewDynamicRateLimitMiddleware(
+	params func() RateLimitParams,
+	keyFunc func(*gin.Context) string,
+	store Storage,
+) gin.HandlerFunc {
+	return newRateLimitHandler(params, keyFunc, store, time.Now)
+}
+
+func newRateLimitHandler(
+	params func() RateLimitParams,
+	keyFunc func(*gin.Context) string,
+	store Storage,
+	now func() time.Time,
+) gin.HandlerFunc {
 
 	if store == nil {
 		store = defaultStore
 	}
-
-	r := rate.Limit(float64(requests) / window.Seconds())
-	burst := requests
+	windows := newFixedWindowStore()
 
 	return func(c *gin.Context) {
-		key := keyFunc(c)
-
-		lim, ok := store.Get(key)
-		if !ok {
-			lim = rate.NewLimiter(r, burst)
-			store.Add(key, lim)
+		p := params()
+		if !p.Enabled {
+			c.Next()
+			return
 		}
 
-		res := lim.Reserve()
-		delay := res.Delay()
+		key := keyFunc(c)
+		var d rateLimitDecision
+		if p.Strategy == StrategyFixedWindow {
+			d = windows.take(key, p, now())
+		} else {
+			d = takeToken(store, key, p, now())
+		}
 
-		if delay > 0 {
-			res.Cancel()
-			ra := int(math.Ceil(delay.Seconds()))
-			resetAt := time.Now().Add(time.Duration(ra) * time.Second).Unix()
+		if !d.allowed {
+			ra := int(math.Ceil(d.retryAfter.Seconds()))
 
 			c.Header("Retry-After", strconv.Itoa(ra))
-			c.Header("X-RateLimit-Limit", strconv.Itoa(requests))
-			c.Header("X-RateLimit-Remaining", "0")
-			c.Header("X-RateLimit-Reset", strconv.FormatInt(resetAt, 10))
+			setRateLimitHeaders(c, d.limit, 0, d.resetAt)
 
 			_ = c.Error(apiErrors.TooManyRequests(ra))
 			c.Abort()
 			return
 		}
 
-		remaining := lim.Tokens()
-		resetAt := time.Now().Add(window).Unix()
-
-		c.Header("X-RateLimit-Limit", strconv.Itoa(requests))
-		c.Header("X-RateLimit-Remaining", strconv.Itoa(int(remaining)))
-		c.Header("X-RateLimit-Reset", strconv.FormatInt(resetAt, 10))
+		setRateLimitHeaders(c, d.limit, d.remaining, d.resetAt)
 
 		c.Next()
 	}
 }
+
+// takeToken takes one token from the key's bucket, which refills at Requests per Window
+// and holds up to Burst tokens.
+func takeToken(store Storage, key string, p RateLimitParams, now time.Time) rateLimitDecision {
+	r := rate.Limit(float64(p.Requests) / p.Window.Seconds())
+	burst := p.Burst
+	if burst <= 0 {
+		burst = p.Requests
+	}
+
+	lim, ok := store.Get(key)
+	if !ok {
+		lim = rate.NewLimiter(r, burst)
+		store.Add(key, lim)
+	} else if lim.Limit() != r || lim.Burst() != burst {
+		lim.SetLimitAt(now, r)
+		lim.SetBurstAt(now, burst)
+	}
+
+	res := lim.ReserveN(now, 1)
+	delay := res.DelayFrom(now)
+
+	if delay > 0 {
+		res.CancelAt(now)
+		_, resetAt := limiterStatus(lim, now)
+		return rateLimitDecision{limit: burst, resetAt: resetAt, retryAfter: delay}
+	}
+
+	remaining, resetAt := limiterStatus(lim, now)
+	return rateLimitDecision{allowed: true, limit: burst, remaining: remaining, resetAt: resetAt}
+}
+
+// fixedWindow counts the requests of one key since start.
+type fixedWindow struct {
+	start time.Time
+	count int
+}
+
+// fixedWindowStore holds the per-key windows of the fixed-window strategy.
+type fixedWindowStore struct {
+	mu      sync.Mutex
+	windows *expirable.LRU[string, *fixedWindow]
+}
+
+func newFixedWindowStore() *fixedWindowStore {
+	return &fixedWindowStore{windows: expirable.NewLRU[string, *fixedWindow](DefaultCacheSize, nil, DefaultTTL)}
+}
+
+// take counts one request against the key's current window, opening a new window when the
+// previous one has ended. Requests over the count are rejected until the window ends.
+func (s *fixedWindowStore) take(key string, p RateLimitParams, now time.Time) rateLimitDecision {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w, ok := s.windows.Get(key)
+	if !ok || !now.Before(w.start.Add(p.Window)) {
+		w = &fixedWindow{start: now}
+		s.windows.Add(key, w)
+	}
+	resetAt := w.start.Add(p.Window)
+
+	if w.count >= p.Requests {
+		return rateLimitDecision{limit: p.Requests, resetAt: resetAt, retryAfter: resetAt.Sub(now)}
+	}
+	w.count++
+	return rateLimitDecision{allowed: true, limit: p.Requests, remaining: p.Requests - w.count, resetAt: resetAt}
+}
+
+// limiterStatus reports how many whole requests the limiter still admits at now
+// and when its bucket will be full again.
+func limiterStatus(lim *rate.Limiter, now time.Time) (int, time.Time) {
+	tokens := lim.TokensAt(now)
+	remaining := int(math.Floor(tokens))
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	missing := float64(lim.Burst()) - tokens
+	if missing <= 0 || lim.Limit() <= 0 {
+		return remaining, now
+	}
+	refill := time.Duration(missing / float64(lim.Limit()) * float64(time.Second))
+	return remaining, now.Add(refill)
+}
+
+// setRateLimitHeaders writes the X-RateLimit-* headers; reset is in epoch seconds.
+func setRateLimitHeaders(c *gin.Context, limit, remaining int, resetAt time.Time) {
+	c.Header("X-RateLimit-Limit", strconv.Itoa(limit))
+	c.Header("X-RateLimit-Remaining", strconv.Itoa(remaining))
+	c.Header("X-RateLimit-Reset", strconv.FormatInt(int64(math.Ceil(float64(resetAt.UnixNano())/float64(time.Second))), 10))
+}
@@ -258,3 +258,171 @@ func TestRateLimitMiddleware_Headers(t *testing.T) {
 		}
 	}
 }
+
+// TestRateLimitMiddleware_HeadersDecrement tests that remaining decrements and recovers after the window
+func TestRateLimitMiddleware_HeadersDecrement(t *testing.T) {
+	window := time.Second
+	middleware := NewRateLimitMiddleware(window, 3, func(c *gin.Context) string {
+		return "decrement"
+	}, NewMockStorage())
+
+	router := gin.New()
+	router.Use(apiErrors.ErrorHandler())
+	router.Use(middleware)
+	router.GET("/test", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"message": "success"})
+	})
+
+	do := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/test", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	for _, want := range []string{"2", "1", "0"} {
+		w := do()
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "3", w.Header().Get("X-RateLimit-Limit"))
+		assert.Equal(t, want, w.Header().Get("X-RateLimit-Remaining"))
+
+		reset, err := strconv.ParseInt(w.Header().Get("X-RateLimit-Reset"), 10, 64)
+		assert.NoError(t, err, "X-RateLimit-Reset should be a valid unix timestamp")
+		assert.GreaterOrEqual(t, reset, time.Now().Unix(), "Reset time should not be in the past")
+	}
+
+	blocked := do()
+	assert.Equal(t, http.StatusTooManyRequests, blocked.Code)
+	assert.Equal(t, "0", blocked.Header().Get("X-RateLimit-Remaining"))
+
+	// After a full window the bucket is refilled.
+	time.Sleep(window + 50*time.Millisecond)
+
+	w := do()
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "2", w.Header().Get("X-RateLimit-Remaining"))
+}
+
+func TestNewDynamicRateLimitMiddleware_FollowsParams(t *testing.T) {
+	params := RateLimitParams{Enabled: true, Window: time.Minute, Requests: 1}
+	router := gin.New()
+	router.Use(apiErrors.ErrorHandler())
+	router.Use(NewDynamicRateLimitMiddleware(
+		func() RateLimitParams { return params },
+		func(c *gin.Context) string { return "client" },
+		NewMockStorage(),
+	))
+	router.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	request := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		return w
+	}
+
+	assert.Equal(t, http.StatusOK, request().Code)
+	assert.Equal(t, http.StatusTooManyRequests, request().Code)
+
+	// Raising the limit updates the existing limiter on the next request; tokens refill at the new rate from then on
+	params.Window = time.Second
+	params.Requests = 1000
+	assert.Equal(t, "1000", request().Header().Get("X-RateLimit-Limit"))
+	time.Sleep(10 * time.Millisecond)
+	w := request()
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "1000", w.Header().Get("X-RateLimit-Limit"))
+
+	params.Enabled = false
+	for i := 0; i < 5; i++ {
+		w = request()
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Get("X-RateLimit-Limit"), "disabled limiter sets no headers")
+	}
+}
+
+func TestRateLimitStrategies_BurstVersusFixedWindow(t *testing.T) {
+	// 60 requests per minute is a refill of one token per second
+	base := RateLimitParams{Enabled: true, Window: time.Minute, Requests: 60}
+
+	setup := func(params RateLimitParams) (func() *httptest.ResponseRecorder, func(time.Duration)) {
+		now := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)
+		router := gin.New()
+		router.Use(apiErrors.ErrorHandler())
+		router.Use(newRateLimitHandler(
+			func() RateLimitParams { return params },
+			func(c *gin.Context) string { return "client" },
+			NewMockStorage(),
+			func() time.Time { return now },
+		))
+		router.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+		request := func() *httptest.ResponseRecorder {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+			return w
+		}
+		return request, func(d time.Duration) { now = now.Add(d) }
+	}
+
+	admitted := func(request func() *httptest.ResponseRecorder, n int) int {
+		ok := 0
+		for i := 0; i < n; i++ {
+			if request().Code == http.StatusOK {
+				ok++
+			}
+		}
+		return ok
+	}
+
+	t.Run("token bucket allows the burst then throttles to the refill rate", func(t *testing.T) {
+		params := base
+		params.Strategy = StrategyTokenBucket
+		params.Burst = 10
+		request, advance := setup(params)
+
+		assert.Equal(t, 10, admitted(request, 20), "an initial burst of Burst requests is admitted")
+		w := request()
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.Equal(t, "1", w.Header().Get("Retry-After"))
+		assert.Equal(t, "10", w.Header().Get("X-RateLimit-Limit"))
+
+		// Sustained traffic is admitted at one request per second, not in bursts
+		for i := 0; i < 5; i++ {
+			advance(time.Second)
+			assert.Equal(t, 1, admitted(request, 5), "second %d", i+1)
+		}
+
+		// An idle client refills up to Burst, never beyond it
+		advance(time.Hour)
+		assert.Equal(t, 10, admitted(request, 20))
+	})
+
+	t.Run("token bucket defaults the burst to the request count", func(t *testing.T) {
+		params := base
+		request, _ := setup(params)
+
+		assert.Equal(t, 60, admitted(request, 70))
+	})
+
+	t.Run("fixed window blocks strictly at the count until the window ends", func(t *testing.T) {
+		params := base
+		params.Strategy = StrategyFixedWindow
+		params.Burst = 10
+		request, advance := setup(params)
+
+		assert.Equal(t, 60, admitted(request, 70), "burst is ignored; the whole count is available at once")
+
+		// Unlike the bucket, nothing refills inside the window
+		advance(30 * time.Second)
+		w := request()
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.Equal(t, "30", w.Header().Get("Retry-After"))
+		assert.Equal(t, "60", w.Header().Get("X-RateLimit-Limit"))
+		assert.Equal(t, "0", w.Header().Get("X-RateLimit-Remaining"))
+
+		advance(30 * time.Second)
+		w = request()
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "59", w.Header().Get("X-RateLimit-Remaining"))
+	})
+}
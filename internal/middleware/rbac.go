@@ -10,10 +10,26 @@ import (
 	"github.com/yeegeek/uyou-go-api-starter/internal/errors"
 )
 
-// RequireRole returns a middleware that checks if the user has the specified role
-func RequireRole(role string) gin.HandlerFunc {
+// RequireRole returns a middleware that checks if the user has at least one of the specified roles.
+// Roles are read from the claims set by auth.AuthMiddleware, so the check never queries the database;
+// role changes take effect when the next access token is issued.
+// Use it on route groups instead of repeating role checks in handlers.
+func RequireRole(roles ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		if !contextutil.HasRole(c, role) {
+		if !contextutil.HasAnyRole(c, roles...) {
+			c.JSON(http.StatusForbidden, errors.Forbidden("insufficient permissions"))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequirePermission returns a middleware that checks if the user has the specified permission.
+// Admins pass regardless of their explicit permissions.
+func RequirePermission(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !contextutil.HasPermission(c, permission) {
 			c.JSON(http.StatusForbidden, errors.Forbidden("insufficient permissions"))
 			c.Abort()
 			return
@@ -24,5 +40,5 @@ func RequireRole(role string) gin.HandlerFunc {
 
 // RequireAdmin returns a middleware that checks if the user is an admin
 func RequireAdmin() gin.HandlerFunc {
-	return RequireRole("admin")
+	return RequireRole(contextutil.RoleAdmin)
 }
@@ -4,12 +4,18 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"sync/atomic"
 	"testing"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
 
 	"github.com/yeegeek/uyou-go-api-starter/internal/auth"
+	"github.com/yeegeek/uyou-go-api-starter/internal/config"
+	"github.com/yeegeek/uyou-go-api-starter/internal/testutil"
 )
 
 func TestRequireRole(t *testing.T) {
@@ -155,3 +161,179 @@ func TestRequireAdmin(t *testing.T) {
 		})
 	}
 }
+
+func TestRequirePermission(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name            string
+		permission      string
+		userRoles       []string
+		userPermissions []string
+		authenticated   bool
+		expectedStatus  int
+	}{
+		{
+			name:            "non-admin user with granted permission",
+			permission:      "users:delete",
+			userRoles:       []string{"user", "moderator"},
+			userPermissions: []string{"users:read", "users:delete"},
+			authenticated:   true,
+			expectedStatus:  http.StatusOK,
+		},
+		{
+			name:            "user without permission",
+			permission:      "users:delete",
+			userRoles:       []string{"user", "moderator"},
+			userPermissions: []string{"users:read"},
+			authenticated:   true,
+			expectedStatus:  http.StatusForbidden,
+		},
+		{
+			name:           "user with no permissions",
+			permission:     "users:read",
+			userRoles:      []string{"user"},
+			authenticated:  true,
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:            "resource wildcard covers every action",
+			permission:      "users:delete",
+			userRoles:       []string{"support"},
+			userPermissions: []string{"users:*"},
+			authenticated:   true,
+			expectedStatus:  http.StatusOK,
+		},
+		{
+			name:            "resource wildcard does not cover other resources",
+			permission:      "roles:manage",
+			userRoles:       []string{"support"},
+			userPermissions: []string{"users:*"},
+			authenticated:   true,
+			expectedStatus:  http.StatusForbidden,
+		},
+		{
+			name:            "admin:* grants every permission",
+			permission:      "roles:manage",
+			userRoles:       []string{"superuser"},
+			userPermissions: []string{"admin:*"},
+			authenticated:   true,
+			expectedStatus:  http.StatusOK,
+		},
+		{
+			name:           "admin implicitly holds all permissions",
+			permission:     "users:delete",
+			userRoles:      []string{"admin"},
+			authenticated:  true,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "no authenticated user",
+			permission:     "users:read",
+			authenticated:  false,
+			expectedStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, router := gin.CreateTestContext(w)
+
+			router.Use(func(c *gin.Context) {
+				if tt.authenticated {
+					c.Set(auth.KeyUser, &auth.Claims{
+						UserID:      1,
+						Email:       "test@example.com",
+						Roles:       tt.userRoles,
+						Permissions: tt.userPermissions,
+					})
+				}
+				c.Next()
+			})
+
+			router.Use(RequirePermission(tt.permission))
+			router.GET("/test", func(c *gin.Context) {
+				c.JSON(http.StatusOK, gin.H{"message": "success"})
+			})
+
+			c.Request = httptest.NewRequest(http.MethodGet, "/test", nil)
+			router.ServeHTTP(w, c.Request)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestRequireRole_AnyOf(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		userRoles      []string
+		expectedStatus int
+	}{
+		{name: "first role matches", userRoles: []string{"admin"}, expectedStatus: http.StatusOK},
+		{name: "second role matches", userRoles: []string{"user", "moderator"}, expectedStatus: http.StatusOK},
+		{name: "no role matches", userRoles: []string{"user"}, expectedStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			_, router := gin.CreateTestContext(w)
+
+			router.Use(func(c *gin.Context) {
+				c.Set(auth.KeyUser, &auth.Claims{UserID: 1, Roles: tt.userRoles})
+				c.Next()
+			})
+			router.Use(RequireRole("admin", "moderator"))
+			router.GET("/test", func(c *gin.Context) {
+				c.JSON(http.StatusOK, gin.H{"message": "success"})
+			})
+
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/test", nil))
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestRequireRole_UsesClaimsWithoutDatabase(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	var queries atomic.Int32
+	require.NoError(t, db.Callback().Query().Before("gorm:query").Register("count_queries", func(*gorm.DB) { queries.Add(1) }))
+	require.NoError(t, db.Callback().Raw().Before("gorm:raw").Register("count_raw", func(*gorm.DB) { queries.Add(1) }))
+
+	authService := auth.NewServiceWithRepo(&config.JWTConfig{Secret: "test-secret-key-for-rbac-claims"}, db)
+	router := gin.New()
+	router.GET("/admin", auth.AuthMiddleware(authService), RequireRole("admin"), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"message": "admin access granted"})
+	})
+
+	tests := []struct {
+		name           string
+		roles          []string
+		expectedStatus int
+	}{
+		{name: "admin claim", roles: []string{"user", "admin"}, expectedStatus: http.StatusOK},
+		{name: "no admin claim", roles: []string{"user"}, expectedStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// user 42 does not exist in the database; the role decision comes from the token alone
+			token := testutil.SignAccessToken(t, "test-secret-key-for-rbac-claims", &auth.Claims{UserID: 42, Email: "admin@example.com", Roles: tt.roles})
+
+			req := httptest.NewRequest(http.MethodGet, "/admin", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+	assert.Zero(t, queries.Load(), "role checks must not query the database")
+}
@@ -0,0 +1,15 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/yeegeek/uyou-go-api-starter/internal/contextutil"
+)
+
+// TrustedProxies 将可信代理集合写入上下文，供 contextutil.ClientIP 解析真实客户端 IP
+func TrustedProxies(tp *contextutil.TrustedProxies) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		contextutil.SetTrustedProxies(c, tp)
+		c.Next()
+	}
+}
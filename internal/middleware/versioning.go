@@ -0,0 +1,35 @@
+// Package middleware 提供 API 版本中间件
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/yeegeek/uyou-go-api-starter/internal/contextutil"
+)
+
+// APIVersion 将路由组对应的 API 版本写入上下文
+// 处理器通过 contextutil.GetAPIVersion 分支，而不是解析请求路径
+func APIVersion(version string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		contextutil.SetAPIVersion(c, version)
+		c.Next()
+	}
+}
+
+// Deprecation 为已弃用的 API 版本添加 Deprecation 和 Sunset 响应头
+// Deprecation 按 RFC 9745 输出为 "@<unix 秒>"，Sunset 按 RFC 8594 输出为 HTTP-date，零值时不输出
+func Deprecation(deprecatedAt, sunsetAt time.Time) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !deprecatedAt.IsZero() {
+			c.Header("Deprecation", "@"+strconv.FormatInt(deprecatedAt.Unix(), 10))
+		}
+		if !sunsetAt.IsZero() {
+			c.Header("Sunset", sunsetAt.UTC().Format(http.TimeFormat))
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/yeegeek/uyou-go-api-starter/internal/contextutil"
+)
+
+func TestAPIVersion(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var resolved string
+	router := gin.New()
+	router.GET("/test", APIVersion(contextutil.APIVersionV2), func(c *gin.Context) {
+		resolved = contextutil.GetAPIVersion(c)
+		c.Status(http.StatusOK)
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/test", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, contextutil.APIVersionV2, resolved)
+}
+
+func TestDeprecation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	deprecatedAt := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	sunsetAt := time.Date(2026, 12, 31, 23, 59, 59, 0, time.UTC)
+
+	tests := []struct {
+		name            string
+		deprecatedAt    time.Time
+		sunsetAt        time.Time
+		wantDeprecation string
+		wantSunset      string
+	}{
+		{
+			name:            "both headers",
+			deprecatedAt:    deprecatedAt,
+			sunsetAt:        sunsetAt,
+			wantDeprecation: "@1780272000",
+			wantSunset:      "Thu, 31 Dec 2026 23:59:59 GMT",
+		},
+		{
+			name:            "deprecation only",
+			deprecatedAt:    deprecatedAt,
+			wantDeprecation: "@1780272000",
+		},
+		{
+			name: "not configured",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.GET("/test", Deprecation(tt.deprecatedAt, tt.sunsetAt), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/test", nil))
+
+			assert.Equal(t, tt.wantDeprecation, w.Header().Get("Deprecation"))
+			assert.Equal(t, tt.wantSunset, w.Header().Get("Sunset"))
+		})
+	}
+}
@@ -0,0 +1,228 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DefaultDataBatchSize is used when DataMigrationOptions.BatchSize is not set
+const DefaultDataBatchSize = 1000
+
+// ErrDataMigrationNotFound is returned for a data migration name that is not registered
+var ErrDataMigrationNotFound = errors.New("data migration not found")
+
+// DataMigration is a row-level backfill registered in Go, separate from the SQL schema migrations.
+// Rows are visited in ascending ID order; the runner stores the last visited ID after each batch so
+// an interrupted run resumes where it stopped.
+type DataMigration interface {
+	// Name identifies the migration on the command line and in the checkpoint table
+	Name() string
+	// Description explains what the migration changes
+	Description() string
+	// Pending counts the rows with an ID above after that the migration would change
+	Pending(ctx context.Context, db *gorm.DB, after uint64) (int64, error)
+	// MigrateBatch changes up to limit rows with an ID above after, using tx
+	MigrateBatch(ctx context.Context, tx *gorm.DB, after uint64, limit int) (BatchResult, error)
+}
+
+// BatchResult reports one batch of a data migration
+type BatchResult struct {
+	// LastID is the highest row ID the batch visited; the next batch starts after it
+	LastID uint64
+	// Scanned is the number of rows visited; fewer than the batch size means the migration is finished
+	Scanned int
+	// Affected is the number of rows changed
+	Affected int64
+	// Skipped is the number of visited rows left unchanged, e.g. because of a conflict
+	Skipped int64
+}
+
+// DataMigrationOptions controls a data migration run
+type DataMigrationOptions struct {
+	// BatchSize is the number of rows per transaction; DefaultDataBatchSize when zero
+	BatchSize int
+	// DryRun only reports the number of rows that would change
+	DryRun bool
+	// Pause is slept between batches to leave room for application writes
+	Pause time.Duration
+}
+
+// DataMigrationResult summarizes a data migration run
+type DataMigrationResult struct {
+	Name     string
+	DryRun   bool
+	Resumed  bool
+	Finished bool
+	// Pending is the number of rows left to change when the run started
+	Pending  int64
+	Affected int64
+	Skipped  int64
+	Batches  int
+	LastID   uint64
+}
+
+// dataMigrationCheckpoint is the progress of a data migration, committed together with each batch
+type dataMigrationCheckpoint struct {
+	Name         string `gorm:"primaryKey;size:100"`
+	LastID       uint64 `gorm:"not null;default:0"`
+	RowsAffected int64  `gorm:"not null;default:0"`
+	CompletedAt  *time.Time
+	UpdatedAt    time.Time
+}
+
+func (dataMigrationCheckpoint) TableName() string {
+	return "data_migration_checkpoints"
+}
+
+var (
+	dataMigrationsMu sync.RWMutex
+	dataMigrations   = make(map[string]DataMigration)
+)
+
+// RegisterDataMigration makes a data migration available to the data-migrate command.
+// It panics on a duplicate name, like other init-time registries.
+func RegisterDataMigration(m DataMigration) {
+	dataMigrationsMu.Lock()
+	defer dataMigrationsMu.Unlock()
+
+	if _, exists := dataMigrations[m.Name()]; exists {
+		panic(fmt.Sprintf("migrate: data migration %q registered twice", m.Name()))
+	}
+	dataMigrations[m.Name()] = m
+}
+
+// LookupDataMigration returns the registered data migration with the given name
+func LookupDataMigration(name string) (DataMigration, error) {
+	dataMigrationsMu.RLock()
+	defer dataMigrationsMu.RUnlock()
+
+	m, ok := dataMigrations[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrDataMigrationNotFound, name)
+	}
+	return m, nil
+}
+
+// DataMigrations returns the registered data migrations sorted by name
+func DataMigrations() []DataMigration {
+	dataMigrationsMu.RLock()
+	defer dataMigrationsMu.RUnlock()
+
+	all := make([]DataMigration, 0, len(dataMigrations))
+	for _, m := range dataMigrations {
+		all = append(all, m)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Name() < all[j].Name() })
+	return all
+}
+
+// RunDataMigration runs m in batches from its last checkpoint. Each batch and its checkpoint are
+// committed in one transaction, so a failure loses at most the batch in progress. A finished
+// migration is not run again. The data_migration_checkpoints table must exist (migrate up).
+func RunDataMigration(ctx context.Context, db *gorm.DB, m DataMigration, opts DataMigrationOptions) (*DataMigrationResult, error) {
+	batchSize := opts.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultDataBatchSize
+	}
+
+	checkpoint, err := loadCheckpoint(ctx, db, m.Name())
+	if err != nil {
+		return nil, err
+	}
+
+	result := &DataMigrationResult{
+		Name:    m.Name(),
+		DryRun:  opts.DryRun,
+		Resumed: checkpoint.LastID > 0 && checkpoint.CompletedAt == nil,
+		LastID:  checkpoint.LastID,
+	}
+	if checkpoint.CompletedAt != nil {
+		slog.Info("Data migration already completed", "name", m.Name(), "completed_at", checkpoint.CompletedAt)
+		result.Finished = true
+		return result, nil
+	}
+
+	result.Pending, err = m.Pending(ctx, db.WithContext(ctx), checkpoint.LastID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count pending rows: %w", err)
+	}
+	if opts.DryRun {
+		slog.Info("Data migration dry run", "name", m.Name(), "pending", result.Pending, "after_id", checkpoint.LastID)
+		return result, nil
+	}
+
+	slog.Info("Running data migration...", "name", m.Name(), "pending", result.Pending,
+		"batch_size", batchSize, "resume_after_id", checkpoint.LastID)
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return result, fmt.Errorf("data migration %s interrupted after id %d: %w", m.Name(), result.LastID, err)
+		}
+
+		var batch BatchResult
+		err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			var err error
+			batch, err = m.MigrateBatch(ctx, tx, checkpoint.LastID, batchSize)
+			if err != nil {
+				return err
+			}
+
+			// WHY: The checkpoint commits with the batch, so a resumed run never repeats or skips rows
+			if batch.Scanned > 0 {
+				checkpoint.LastID = batch.LastID
+			}
+			checkpoint.RowsAffected += batch.Affected
+			if batch.Scanned < batchSize {
+				now := time.Now()
+				checkpoint.CompletedAt = &now
+			}
+			return tx.Clauses(clause.OnConflict{UpdateAll: true}).Create(&checkpoint).Error
+		})
+		if err != nil {
+			return result, fmt.Errorf("data migration %s failed after id %d: %w", m.Name(), result.LastID, err)
+		}
+
+		result.Batches++
+		result.Affected += batch.Affected
+		result.Skipped += batch.Skipped
+		result.LastID = checkpoint.LastID
+		slog.Info("Data migration batch committed", "name", m.Name(), "batch", result.Batches,
+			"last_id", result.LastID, "affected", result.Affected, "skipped", result.Skipped, "pending", result.Pending)
+
+		if checkpoint.CompletedAt != nil {
+			break
+		}
+		if opts.Pause > 0 {
+			select {
+			case <-ctx.Done():
+			case <-time.After(opts.Pause):
+			}
+		}
+	}
+
+	result.Finished = true
+	slog.Info("Data migration completed", "name", m.Name(), "affected", result.Affected,
+		"skipped", result.Skipped, "batches", result.Batches, "status", "✅")
+	return result, nil
+}
+
+// loadCheckpoint returns the stored checkpoint, or a fresh one when the migration has not run
+func loadCheckpoint(ctx context.Context, db *gorm.DB, name string) (dataMigrationCheckpoint, error) {
+	var checkpoint dataMigrationCheckpoint
+	err := db.WithContext(ctx).Where("name = ?", name).Take(&checkpoint).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return dataMigrationCheckpoint{Name: name}, nil
+	}
+	if err != nil {
+		return checkpoint, fmt.Errorf("failed to load data migration checkpoint: %w", err)
+	}
+	return checkpoint, nil
+}
@@ -0,0 +1,74 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+func init() {
+	RegisterDataMigration(lowercaseEmails{})
+}
+
+// lowercaseEmails rewrites users.email to lower case, including soft-deleted users because the
+// unique constraint covers them. A row whose lower-case email already belongs to another user is
+// left unchanged and logged for manual cleanup.
+type lowercaseEmails struct{}
+
+func (lowercaseEmails) Name() string {
+	return "lowercase_user_emails"
+}
+
+func (lowercaseEmails) Description() string {
+	return "Lowercase users.email; rows that would collide with an existing email are skipped"
+}
+
+func (lowercaseEmails) Pending(ctx context.Context, db *gorm.DB, after uint64) (int64, error) {
+	var count int64
+	err := db.WithContext(ctx).Table("users").
+		Where("id > ? AND email <> LOWER(email)", after).
+		Count(&count).Error
+	return count, err
+}
+
+func (lowercaseEmails) MigrateBatch(ctx context.Context, tx *gorm.DB, after uint64, limit int) (BatchResult, error) {
+	var rows []struct {
+		ID    uint64
+		Email string
+	}
+	// WHY: Walking only the mixed-case rows keeps each batch to limit updates however sparse they are
+	err := tx.WithContext(ctx).Table("users").
+		Select("id, email").
+		Where("id > ? AND email <> LOWER(email)", after).
+		Order("id").
+		Limit(limit).
+		Find(&rows).Error
+	if err != nil {
+		return BatchResult{}, fmt.Errorf("failed to select users: %w", err)
+	}
+
+	result := BatchResult{LastID: after, Scanned: len(rows)}
+	for _, row := range rows {
+		result.LastID = row.ID
+		lower := strings.ToLower(row.Email)
+
+		// WHY: Updating row by row lets a collision inside the same batch be skipped instead of failing the batch
+		update := tx.WithContext(ctx).Exec(
+			"UPDATE users SET email = ? WHERE id = ? AND NOT EXISTS (SELECT 1 FROM users other WHERE other.email = ? AND other.id <> ?)",
+			lower, row.ID, lower, row.ID,
+		)
+		if update.Error != nil {
+			return BatchResult{}, fmt.Errorf("failed to update user %d: %w", row.ID, update.Error)
+		}
+		if update.RowsAffected == 0 {
+			slog.Warn("Skipped user whose lower-case email is already taken", "user_id", row.ID)
+			result.Skipped++
+			continue
+		}
+		result.Affected++
+	}
+	return result, nil
+}
@@ -0,0 +1,184 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/yeegeek/uyou-go-api-starter/internal/config"
+	"github.com/yeegeek/uyou-go-api-starter/internal/testutil"
+	"github.com/yeegeek/uyou-go-api-starter/internal/user"
+)
+
+func setupDataMigrationDB(t *testing.T, emails ...string) *gorm.DB {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	// WHY: Every connection to :memory: is a separate database
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { _ = sqlDB.Close() })
+
+	require.NoError(t, db.Exec("CREATE TABLE users (id INTEGER PRIMARY KEY, email TEXT NOT NULL UNIQUE)").Error)
+	require.NoError(t, db.AutoMigrate(&dataMigrationCheckpoint{}))
+	for i, email := range emails {
+		require.NoError(t, db.Exec("INSERT INTO users (id, email) VALUES (?, ?)", i+1, email).Error)
+	}
+	return db
+}
+
+func userEmails(t *testing.T, db *gorm.DB) []string {
+	t.Helper()
+	var emails []string
+	require.NoError(t, db.Table("users").Order("id").Pluck("email", &emails).Error)
+	return emails
+}
+
+// failingBatch wraps a data migration and fails its failOn-th batch, simulating an interrupted run
+type failingBatch struct {
+	DataMigration
+	calls  int
+	failOn int
+}
+
+func (f *failingBatch) MigrateBatch(ctx context.Context, tx *gorm.DB, after uint64, limit int) (BatchResult, error) {
+	f.calls++
+	result, err := f.DataMigration.MigrateBatch(ctx, tx, after, limit)
+	if f.calls == f.failOn {
+		return BatchResult{}, errors.New("connection reset")
+	}
+	return result, err
+}
+
+func TestLowercaseEmails_Run(t *testing.T) {
+	db := setupDataMigrationDB(t, "Alice@Example.com", "bob@example.com", "CAROL@EXAMPLE.COM", "Dave@example.com")
+
+	result, err := RunDataMigration(context.Background(), db, lowercaseEmails{}, DataMigrationOptions{BatchSize: 2})
+	require.NoError(t, err)
+
+	assert.True(t, result.Finished)
+	assert.False(t, result.Resumed)
+	assert.Equal(t, int64(3), result.Pending)
+	assert.Equal(t, int64(3), result.Affected)
+	assert.Equal(t, 2, result.Batches)
+	assert.Equal(t, []string{"alice@example.com", "bob@example.com", "carol@example.com", "dave@example.com"}, userEmails(t, db))
+
+	var checkpoint dataMigrationCheckpoint
+	require.NoError(t, db.Take(&checkpoint, "name = ?", "lowercase_user_emails").Error)
+	assert.Equal(t, uint64(4), checkpoint.LastID)
+	assert.Equal(t, int64(3), checkpoint.RowsAffected)
+	assert.NotNil(t, checkpoint.CompletedAt)
+
+	t.Run("completed migration does not run again", func(t *testing.T) {
+		require.NoError(t, db.Exec("UPDATE users SET email = 'Eve@example.com' WHERE id = 2").Error)
+
+		again, err := RunDataMigration(context.Background(), db, lowercaseEmails{}, DataMigrationOptions{BatchSize: 2})
+		require.NoError(t, err)
+		assert.True(t, again.Finished)
+		assert.Zero(t, again.Batches)
+		assert.Equal(t, "Eve@example.com", userEmails(t, db)[1])
+	})
+}
+
+func TestLowercaseEmails_DryRun(t *testing.T) {
+	db := setupDataMigrationDB(t, "Alice@Example.com", "bob@example.com", "CAROL@EXAMPLE.COM")
+
+	result, err := RunDataMigration(context.Background(), db, lowercaseEmails{}, DataMigrationOptions{DryRun: true})
+	require.NoError(t, err)
+
+	assert.True(t, result.DryRun)
+	assert.False(t, result.Finished)
+	assert.Equal(t, int64(2), result.Pending)
+	assert.Zero(t, result.Affected)
+	assert.Equal(t, []string{"Alice@Example.com", "bob@example.com", "CAROL@EXAMPLE.COM"}, userEmails(t, db))
+
+	var checkpoints int64
+	require.NoError(t, db.Model(&dataMigrationCheckpoint{}).Count(&checkpoints).Error)
+	assert.Zero(t, checkpoints)
+}
+
+func TestLowercaseEmails_ResumeAfterInterruption(t *testing.T) {
+	db := setupDataMigrationDB(t, "A@example.com", "B@example.com", "C@example.com", "D@example.com", "E@example.com")
+	interrupted := &failingBatch{DataMigration: lowercaseEmails{}, failOn: 2}
+
+	_, err := RunDataMigration(context.Background(), db, interrupted, DataMigrationOptions{BatchSize: 2})
+	require.Error(t, err)
+	assert.ErrorContains(t, err, "connection reset")
+
+	// The first batch is committed with its checkpoint; the failed batch is rolled back
+	assert.Equal(t, []string{"a@example.com", "b@example.com", "C@example.com", "D@example.com", "E@example.com"}, userEmails(t, db))
+	var checkpoint dataMigrationCheckpoint
+	require.NoError(t, db.Take(&checkpoint, "name = ?", "lowercase_user_emails").Error)
+	assert.Equal(t, uint64(2), checkpoint.LastID)
+	assert.Nil(t, checkpoint.CompletedAt)
+
+	// A row before the checkpoint changed meanwhile is not revisited
+	require.NoError(t, db.Exec("UPDATE users SET email = 'Z@example.com' WHERE id = 1").Error)
+
+	result, err := RunDataMigration(context.Background(), db, lowercaseEmails{}, DataMigrationOptions{BatchSize: 2})
+	require.NoError(t, err)
+
+	assert.True(t, result.Resumed)
+	assert.True(t, result.Finished)
+	assert.Equal(t, int64(3), result.Pending)
+	assert.Equal(t, int64(3), result.Affected)
+	assert.Equal(t, []string{"Z@example.com", "b@example.com", "c@example.com", "d@example.com", "e@example.com"}, userEmails(t, db))
+
+	require.NoError(t, db.Take(&checkpoint, "name = ?", "lowercase_user_emails").Error)
+	assert.Equal(t, int64(5), checkpoint.RowsAffected)
+	assert.NotNil(t, checkpoint.CompletedAt)
+}
+
+func TestLowercaseEmails_SkipsCollisions(t *testing.T) {
+	db := setupDataMigrationDB(t, "alice@example.com", "Alice@example.com", "BOB@example.com", "Bob@example.com")
+
+	result, err := RunDataMigration(context.Background(), db, lowercaseEmails{}, DataMigrationOptions{BatchSize: 10})
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(1), result.Affected)
+	assert.Equal(t, int64(2), result.Skipped)
+	assert.Equal(t, []string{"alice@example.com", "Alice@example.com", "bob@example.com", "Bob@example.com"}, userEmails(t, db))
+}
+
+func TestLowercaseEmails_LoginAfterBackfill(t *testing.T) {
+	db := testutil.NewSQLiteDB(t)
+	require.NoError(t, db.AutoMigrate(&dataMigrationCheckpoint{}))
+	hash, err := bcrypt.GenerateFromPassword([]byte("Password123!"), bcrypt.MinCost)
+	require.NoError(t, err)
+	require.NoError(t, db.Exec("INSERT INTO users (name, email, password_hash) VALUES ('Alice', 'Alice@Example.com', ?)", string(hash)).Error)
+
+	_, err = RunDataMigration(context.Background(), db, lowercaseEmails{}, DataMigrationOptions{BatchSize: 10})
+	require.NoError(t, err)
+
+	svc := user.NewService(user.NewRepository(db), &config.SecurityConfig{BcryptCost: bcrypt.MinCost})
+	for _, email := range []string{"Alice@Example.com", "alice@example.com", " ALICE@EXAMPLE.COM "} {
+		_, err := svc.AuthenticateUser(context.Background(), user.LoginRequest{Email: email, Password: "Password123!"})
+		assert.NoError(t, err, email)
+	}
+
+	_, err = svc.RegisterUser(context.Background(), user.RegisterRequest{Name: "Alice", Email: "ALICE@example.com", Password: "Password123!"})
+	assert.ErrorIs(t, err, user.ErrEmailExists, "registration must not create a second account differing only in case")
+}
+
+func TestLookupDataMigration(t *testing.T) {
+	m, err := LookupDataMigration("lowercase_user_emails")
+	require.NoError(t, err)
+	assert.Equal(t, "lowercase_user_emails", m.Name())
+	assert.Contains(t, DataMigrations(), m)
+
+	_, err = LookupDataMigration("missing")
+	assert.ErrorIs(t, err, ErrDataMigrationNotFound)
+}
+
+func TestRegisterDataMigration_Duplicate(t *testing.T) {
+	assert.Panics(t, func() { RegisterDataMigration(lowercaseEmails{}) })
+}
@@ -0,0 +1,146 @@
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/yeegeek/uyou-go-api-starter/internal/config"
+	"github.com/yeegeek/uyou-go-api-starter/internal/httpclient"
+)
+
+// ProviderGoogle Google 提供方名称
+const ProviderGoogle = "google"
+
+const (
+	googleAuthURL     = "https://accounts.google.com/o/oauth2/v2/auth"
+	googleTokenURL    = "https://oauth2.googleapis.com/token"
+	googleUserInfoURL = "https://openidconnect.googleapis.com/v1/userinfo"
+	googleScopes      = "openid email profile"
+
+	// maxResponseBytes 提供方响应体读取上限
+	maxResponseBytes = 1 << 20
+)
+
+// GoogleProvider 使用 Google OpenID Connect 授权码流程登录
+type GoogleProvider struct {
+	clientID     string
+	clientSecret string
+	redirectURL  string
+	authURL      string
+	tokenURL     string
+	userInfoURL  string
+	httpClient   *httpclient.Client
+}
+
+// NewGoogleProvider 根据配置创建 Google 提供方，请求通过 client 发送
+func NewGoogleProvider(cfg config.OAuthProviderConfig, client *httpclient.Client) *GoogleProvider {
+	return &GoogleProvider{
+		clientID:     cfg.ClientID,
+		clientSecret: cfg.ClientSecret,
+		redirectURL:  cfg.RedirectURL,
+		authURL:      googleAuthURL,
+		tokenURL:     googleTokenURL,
+		userInfoURL:  googleUserInfoURL,
+		httpClient:   client,
+	}
+}
+
+// Name 返回 google
+func (g *GoogleProvider) Name() string {
+	return ProviderGoogle
+}
+
+// AuthCodeURL 返回 Google 授权页地址
+func (g *GoogleProvider) AuthCodeURL(state string) string {
+	q := url.Values{}
+	q.Set("client_id", g.clientID)
+	q.Set("redirect_uri", g.redirectURL)
+	q.Set("response_type", "code")
+	q.Set("scope", googleScopes)
+	q.Set("state", state)
+	q.Set("prompt", "select_account")
+	return g.authURL + "?" + q.Encode()
+}
+
+// Exchange 用授权码换取访问令牌，再从 userinfo 接口获取用户资料
+func (g *GoogleProvider) Exchange(ctx context.Context, code string) (*Profile, error) {
+	form := url.Values{}
+	form.Set("code", code)
+	form.Set("client_id", g.clientID)
+	form.Set("client_secret", g.clientSecret)
+	form.Set("redirect_uri", g.redirectURL)
+	form.Set("grant_type", "authorization_code")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, g.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrExchangeFailed, err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+		TokenType   string `json:"token_type"`
+	}
+	if err := g.do(req, &token); err != nil {
+		return nil, err
+	}
+	if token.AccessToken == "" {
+		return nil, fmt.Errorf("%w: token response without access_token", ErrExchangeFailed)
+	}
+
+	req, err = http.NewRequestWithContext(ctx, http.MethodGet, g.userInfoURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrExchangeFailed, err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token.AccessToken)
+	req.Header.Set("Accept", "application/json")
+
+	var info struct {
+		Sub           string `json:"sub"`
+		Email         string `json:"email"`
+		EmailVerified bool   `json:"email_verified"`
+		Name          string `json:"name"`
+		Picture       string `json:"picture"`
+	}
+	if err := g.do(req, &info); err != nil {
+		return nil, err
+	}
+	if info.Sub == "" {
+		return nil, fmt.Errorf("%w: userinfo response without sub", ErrExchangeFailed)
+	}
+
+	return &Profile{
+		Subject:       info.Sub,
+		Email:         strings.ToLower(strings.TrimSpace(info.Email)),
+		EmailVerified: info.EmailVerified,
+		Name:          info.Name,
+		AvatarURL:     info.Picture,
+	}, nil
+}
+
+// do 发送请求并解析 JSON 响应，非 2xx 状态视为失败
+func (g *GoogleProvider) do(req *http.Request, out any) error {
+	resp, err := g.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrExchangeFailed, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrExchangeFailed, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%w: %s returned status %d", ErrExchangeFailed, req.URL.Path, resp.StatusCode)
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("%w: %w", ErrExchangeFailed, err)
+	}
+	return nil
+}
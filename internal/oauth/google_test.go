@@ -0,0 +1,90 @@
+package oauth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yeegeek/uyou-go-api-starter/internal/config"
+	"github.com/yeegeek/uyou-go-api-starter/internal/httpclient"
+)
+
+func newTestGoogleProvider(serverURL string) *GoogleProvider {
+	g := NewGoogleProvider(config.OAuthProviderConfig{
+		Enabled:      true,
+		ClientID:     "client-id",
+		ClientSecret: "client-secret",
+		RedirectURL:  "https://api.example.com/api/v1/auth/oauth/google/callback",
+	}, httpclient.New(config.HTTPClientConfig{}))
+	g.tokenURL = serverURL + "/token"
+	g.userInfoURL = serverURL + "/userinfo"
+	return g
+}
+
+func TestGoogleProvider_AuthCodeURL(t *testing.T) {
+	g := newTestGoogleProvider("")
+
+	u, err := url.Parse(g.AuthCodeURL("state-123"))
+	require.NoError(t, err)
+
+	q := u.Query()
+	assert.Equal(t, "accounts.google.com", u.Host)
+	assert.Equal(t, "client-id", q.Get("client_id"))
+	assert.Equal(t, "state-123", q.Get("state"))
+	assert.Equal(t, "code", q.Get("response_type"))
+	assert.Equal(t, "https://api.example.com/api/v1/auth/oauth/google/callback", q.Get("redirect_uri"))
+	assert.Contains(t, q.Get("scope"), "email")
+}
+
+func TestGoogleProvider_Exchange(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		if r.PostForm.Get("code") != "good-code" || r.PostForm.Get("client_secret") != "client-secret" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":"invalid_grant"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"access_token":"google-access","token_type":"Bearer"}`))
+	})
+	mux.HandleFunc("/userinfo", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer google-access" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{"sub":"1234","email":"Jane@Example.com","email_verified":true,"name":"Jane","picture":"https://example.com/a.png"}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	g := newTestGoogleProvider(server.URL)
+
+	profile, err := g.Exchange(context.Background(), "good-code")
+	require.NoError(t, err)
+	assert.Equal(t, &Profile{
+		Subject:       "1234",
+		Email:         "jane@example.com",
+		EmailVerified: true,
+		Name:          "Jane",
+		AvatarURL:     "https://example.com/a.png",
+	}, profile)
+
+	_, err = g.Exchange(context.Background(), "bad-code")
+	assert.ErrorIs(t, err, ErrExchangeFailed)
+}
+
+func TestNewRegistry(t *testing.T) {
+	registry := NewRegistry(config.OAuthConfig{}, httpclient.New(config.HTTPClientConfig{}))
+	_, ok := registry.Get(ProviderGoogle)
+	assert.False(t, ok)
+
+	registry = NewRegistry(config.OAuthConfig{Google: config.OAuthProviderConfig{Enabled: true}}, httpclient.New(config.HTTPClientConfig{}))
+	p, ok := registry.Get(ProviderGoogle)
+	require.True(t, ok)
+	assert.Equal(t, ProviderGoogle, p.Name())
+}
@@ -0,0 +1,56 @@
+// Package oauth 提供第三方 OAuth2/OIDC 登录提供方
+package oauth
+
+import (
+	"context"
+	"errors"
+
+	"github.com/yeegeek/uyou-go-api-starter/internal/config"
+	"github.com/yeegeek/uyou-go-api-starter/internal/httpclient"
+)
+
+// ErrExchangeFailed 授权码换取令牌或获取用户信息失败
+var ErrExchangeFailed = errors.New("oauth exchange failed")
+
+// Profile 提供方返回的用户资料
+type Profile struct {
+	// Subject 提供方内的用户唯一标识，邮箱可能变化，账号关联以它为准
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+	AvatarURL     string
+}
+
+// Provider 第三方登录提供方，测试中可替换为假实现
+type Provider interface {
+	// Name 提供方名称，用于路由参数和账号关联记录
+	Name() string
+	// AuthCodeURL 返回携带 state 的授权页地址
+	AuthCodeURL(state string) string
+	// Exchange 用授权码换取令牌并获取用户资料
+	Exchange(ctx context.Context, code string) (*Profile, error)
+}
+
+// Registry 按名称查找已启用的提供方
+type Registry map[string]Provider
+
+// NewRegistry 根据配置创建已启用的提供方，提供方共用 client 发送请求
+func NewRegistry(cfg config.OAuthConfig, client *httpclient.Client) Registry {
+	registry := Registry{}
+	if cfg.Google.Enabled {
+		registry.Register(NewGoogleProvider(cfg.Google, client))
+	}
+	return registry
+}
+
+// Register 注册提供方，同名提供方会被覆盖
+func (r Registry) Register(p Provider) {
+	r[p.Name()] = p
+}
+
+// Get 返回指定名称的提供方
+func (r Registry) Get(name string) (Provider, bool) {
+	p, ok := r[name]
+	return p, ok
+}
@@ -0,0 +1,272 @@
+// Package openapitest 在测试中按 swag 生成的 OpenAPI（Swagger 2.0）文档校验处理器响应，
+// 用于发现处理器与注释之间的偏差：未文档化的状态码、类型不符、缺少必填字段以及文档中不存在的字段
+//
+// 文档通过 api/docs 注册的 swag 文档读取，修改注释后需重新执行 make swag
+package openapitest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/go-openapi/spec"
+	"github.com/swaggo/swag"
+
+	_ "github.com/yeegeek/uyou-go-api-starter/api/docs"
+)
+
+var (
+	loadOnce sync.Once
+	loaded   *spec.Swagger
+	loadErr  error
+)
+
+// Spec 返回已注册的 OpenAPI 文档，只解析一次
+func Spec() (*spec.Swagger, error) {
+	loadOnce.Do(func() {
+		raw, err := swag.ReadDoc()
+		if err != nil {
+			loadErr = fmt.Errorf("failed to read swagger doc: %w", err)
+			return
+		}
+		var doc spec.Swagger
+		if err := json.Unmarshal([]byte(raw), &doc); err != nil {
+			loadErr = fmt.Errorf("failed to parse swagger doc: %w", err)
+			return
+		}
+		loaded = &doc
+	})
+	return loaded, loadErr
+}
+
+// AssertResponse 校验 w 记录的响应符合文档中 method path（如 "/api/v1/users/{id}"）对应状态码的响应定义
+func AssertResponse(t testing.TB, method, path string, w *httptest.ResponseRecorder) {
+	t.Helper()
+	if err := ValidateResponse(method, path, w.Code, w.Body.Bytes()); err != nil {
+		t.Errorf("response of %s %s (%d) does not match the OpenAPI spec:\n%v\nbody: %s", method, path, w.Code, err, w.Body.String())
+	}
+}
+
+// ValidateResponse 校验响应体符合文档中 method path 在 status 下的 schema，返回全部不符合项
+// Swagger 2.0 没有 nullable，可选字段为 null 时视为未返回
+func ValidateResponse(method, path string, status int, body []byte) error {
+	doc, err := Spec()
+	if err != nil {
+		return err
+	}
+
+	item, ok := doc.Paths.Paths[path]
+	if !ok {
+		return fmt.Errorf("path %s is not documented", path)
+	}
+	op := operation(item, method)
+	if op == nil {
+		return fmt.Errorf("%s %s is not documented", method, path)
+	}
+	if op.Responses == nil {
+		return fmt.Errorf("%s %s documents no responses", method, path)
+	}
+	response, ok := op.Responses.StatusCodeResponses[status]
+	if !ok {
+		if op.Responses.Default == nil {
+			return fmt.Errorf("status %d is not documented for %s %s", status, method, path)
+		}
+		response = *op.Responses.Default
+	}
+	if response.Schema == nil {
+		return nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var value any
+	if err := dec.Decode(&value); err != nil {
+		return fmt.Errorf("response body is not valid JSON: %w", err)
+	}
+
+	v := &validator{doc: doc}
+	return errors.Join(v.validate("$", response.Schema, value, true)...)
+}
+
+func operation(item spec.PathItem, method string) *spec.Operation {
+	switch strings.ToUpper(method) {
+	case "GET":
+		return item.Get
+	case "POST":
+		return item.Post
+	case "PUT":
+		return item.Put
+	case "PATCH":
+		return item.Patch
+	case "DELETE":
+		return item.Delete
+	default:
+		return nil
+	}
+}
+
+type validator struct {
+	doc *spec.Swagger
+}
+
+// resolve 跟随 #/definitions/ 引用，返回实际的 schema
+func (v *validator) resolve(schema *spec.Schema) (*spec.Schema, error) {
+	for seen := 0; schema.Ref.String() != ""; seen++ {
+		if seen > 32 {
+			return nil, fmt.Errorf("reference cycle at %s", schema.Ref.String())
+		}
+		name, ok := strings.CutPrefix(schema.Ref.String(), "#/definitions/")
+		if !ok {
+			return nil, fmt.Errorf("unsupported reference %s", schema.Ref.String())
+		}
+		def, ok := v.doc.Definitions[name]
+		if !ok {
+			return nil, fmt.Errorf("undefined reference %s", schema.Ref.String())
+		}
+		schema = &def
+	}
+	return schema, nil
+}
+
+// validate 校验 value；strict 为 true 时对象中出现文档（含 allOf 分支）未声明的字段视为错误
+func (v *validator) validate(at string, schema *spec.Schema, value any, strict bool) []error {
+	schema, err := v.resolve(schema)
+	if err != nil {
+		return []error{fmt.Errorf("%s: %w", at, err)}
+	}
+	if value == nil {
+		return nil
+	}
+
+	var errs []error
+	for i := range schema.AllOf {
+		errs = append(errs, v.validate(at, &schema.AllOf[i], value, false)...)
+	}
+
+	if len(schema.Enum) > 0 && !inEnum(schema.Enum, value) {
+		errs = append(errs, fmt.Errorf("%s: %v is not one of %v", at, value, schema.Enum))
+	}
+
+	switch {
+	case schema.Type.Contains("object") || (len(schema.Type) == 0 && len(v.declaredProperties(schema)) > 0):
+		object, ok := value.(map[string]any)
+		if !ok {
+			return append(errs, fmt.Errorf("%s: expected object, got %s", at, kind(value)))
+		}
+		errs = append(errs, v.validateObject(at, schema, object, strict)...)
+	case schema.Type.Contains("array"):
+		items, ok := value.([]any)
+		if !ok {
+			return append(errs, fmt.Errorf("%s: expected array, got %s", at, kind(value)))
+		}
+		if schema.Items != nil && schema.Items.Schema != nil {
+			for i, item := range items {
+				errs = append(errs, v.validate(fmt.Sprintf("%s[%d]", at, i), schema.Items.Schema, item, true)...)
+			}
+		}
+	case schema.Type.Contains("string"):
+		if _, ok := value.(string); !ok {
+			errs = append(errs, fmt.Errorf("%s: expected string, got %s", at, kind(value)))
+		}
+	case schema.Type.Contains("integer"):
+		n, ok := value.(json.Number)
+		if _, err := n.Int64(); !ok || err != nil {
+			errs = append(errs, fmt.Errorf("%s: expected integer, got %s", at, kind(value)))
+		}
+	case schema.Type.Contains("number"):
+		if _, ok := value.(json.Number); !ok {
+			errs = append(errs, fmt.Errorf("%s: expected number, got %s", at, kind(value)))
+		}
+	case schema.Type.Contains("boolean"):
+		if _, ok := value.(bool); !ok {
+			errs = append(errs, fmt.Errorf("%s: expected boolean, got %s", at, kind(value)))
+		}
+	}
+	return errs
+}
+
+func (v *validator) validateObject(at string, schema *spec.Schema, object map[string]any, strict bool) []error {
+	var errs []error
+	for _, name := range schema.Required {
+		if _, ok := object[name]; !ok {
+			errs = append(errs, fmt.Errorf("%s: missing required field %q", at, name))
+		}
+	}
+
+	names := make([]string, 0, len(object))
+	for name := range object {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	declared := v.declaredProperties(schema)
+	for _, name := range names {
+		field := at + "." + name
+		if property, ok := schema.Properties[name]; ok {
+			errs = append(errs, v.validate(field, &property, object[name], true)...)
+			continue
+		}
+		if declared[name] {
+			// 由 allOf 分支校验
+			continue
+		}
+		switch {
+		case schema.AdditionalProperties != nil && schema.AdditionalProperties.Schema != nil:
+			errs = append(errs, v.validate(field, schema.AdditionalProperties.Schema, object[name], true)...)
+		case schema.AdditionalProperties != nil && schema.AdditionalProperties.Allows:
+		case strict && len(declared) > 0:
+			errs = append(errs, fmt.Errorf("%s: field is not documented", field))
+		}
+	}
+	return errs
+}
+
+// declaredProperties 返回 schema 及其 allOf 分支声明的全部字段
+func (v *validator) declaredProperties(schema *spec.Schema) map[string]bool {
+	declared := make(map[string]bool, len(schema.Properties))
+	for name := range schema.Properties {
+		declared[name] = true
+	}
+	for i := range schema.AllOf {
+		sub, err := v.resolve(&schema.AllOf[i])
+		if err != nil {
+			continue
+		}
+		for name := range v.declaredProperties(sub) {
+			declared[name] = true
+		}
+	}
+	return declared
+}
+
+func inEnum(enum []any, value any) bool {
+	for _, allowed := range enum {
+		if fmt.Sprint(allowed) == fmt.Sprint(value) {
+			return true
+		}
+	}
+	return false
+}
+
+func kind(value any) string {
+	switch value.(type) {
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	case string:
+		return "string"
+	case json.Number:
+		return "number"
+	case bool:
+		return "boolean"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
@@ -0,0 +1,101 @@
+package openapitest
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const registerPath = "/api/v1/auth/register"
+
+func TestSpec_Loads(t *testing.T) {
+	doc, err := Spec()
+	require.NoError(t, err)
+	assert.Contains(t, doc.Paths.Paths, registerPath)
+}
+
+func TestValidateResponse(t *testing.T) {
+	tests := []struct {
+		name    string
+		method  string
+		path    string
+		status  int
+		body    string
+		wantErr []string
+	}{
+		{
+			name:   "documented success response",
+			method: http.MethodPost, path: registerPath, status: http.StatusOK,
+			body: `{"success":true,"data":{"access_token":"a","refresh_token":"r","token_type":"Bearer","expires_in":900,
+				"user":{"id":1,"name":"John","email":"john@example.com","roles":["user"],"active":true,"created_at":"2026-01-01T00:00:00Z"}}}`,
+		},
+		{
+			name:   "documented error response",
+			method: http.MethodPost, path: registerPath, status: http.StatusBadRequest,
+			body: `{"success":false,"error":{"code":"VALIDATION_ERROR","message":"Validation failed","fields":{"email":"invalid"}}}`,
+		},
+		{
+			name:   "null optional field",
+			method: http.MethodPost, path: registerPath, status: http.StatusOK,
+			body: `{"success":true,"data":{"user":null}}`,
+		},
+		{
+			name:   "wrong types",
+			method: http.MethodPost, path: registerPath, status: http.StatusOK,
+			body:    `{"success":"yes","data":{"expires_in":1.5,"user":{"id":"1","roles":"user"}}}`,
+			wantErr: []string{"$.success: expected boolean", "$.data.expires_in: expected integer", "$.data.user.id: expected integer", "$.data.user.roles: expected array"},
+		},
+		{
+			name:   "undocumented fields",
+			method: http.MethodPost, path: registerPath, status: http.StatusOK,
+			body:    `{"success":true,"token":"x","data":{"user":{"id":1,"password_hash":"secret"}}}`,
+			wantErr: []string{"$.token: field is not documented", "$.data.user.password_hash: field is not documented"},
+		},
+		{
+			name:   "additional properties schema",
+			method: http.MethodPost, path: registerPath, status: http.StatusBadRequest,
+			body:    `{"success":false,"error":{"fields":{"email":1}}}`,
+			wantErr: []string{"$.error.fields.email: expected string"},
+		},
+		{
+			name:   "undocumented status",
+			method: http.MethodPost, path: registerPath, status: http.StatusTeapot,
+			body:    `{}`,
+			wantErr: []string{"status 418 is not documented"},
+		},
+		{
+			name:   "undocumented method",
+			method: http.MethodDelete, path: registerPath, status: http.StatusOK,
+			body:    `{}`,
+			wantErr: []string{"DELETE /api/v1/auth/register is not documented"},
+		},
+		{
+			name:   "undocumented path",
+			method: http.MethodGet, path: "/api/v1/nope", status: http.StatusOK,
+			body:    `{}`,
+			wantErr: []string{"path /api/v1/nope is not documented"},
+		},
+		{
+			name:   "invalid JSON",
+			method: http.MethodPost, path: registerPath, status: http.StatusOK,
+			body:    `not json`,
+			wantErr: []string{"response body is not valid JSON"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateResponse(tt.method, tt.path, tt.status, []byte(tt.body))
+			if len(tt.wantErr) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			for _, want := range tt.wantErr {
+				assert.ErrorContains(t, err, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package organization
+
+import "time"
+
+// CreateOrganizationRequest represents a request to create an organization
+type CreateOrganizationRequest struct {
+	Name string `json:"name" binding:"required,min=2,max=100" example:"Acme Inc"`
+}
+
+// UpdateOrganizationRequest represents a request to rename an organization
+type UpdateOrganizationRequest struct {
+	Name string `json:"name" binding:"required,min=2,max=100" example:"Acme Inc"`
+}
+
+// AddMemberRequest represents a request to add a user to an organization
+type AddMemberRequest struct {
+	UserID uint   `json:"user_id" binding:"required" example:"42"`
+	Role   string `json:"role" binding:"required,oneof=owner admin member" example:"member"`
+}
+
+// UpdateMemberRequest represents a request to change a member's organization role
+type UpdateMemberRequest struct {
+	Role string `json:"role" binding:"required,oneof=owner admin member" example:"admin"`
+}
+
+// OrganizationResponse represents an organization in API responses; Role is the caller's role in it
+type OrganizationResponse struct {
+	ID        uint      `json:"id" example:"1"`
+	Name      string    `json:"name" example:"Acme Inc"`
+	Role      string    `json:"role,omitempty" example:"owner"`
+	CreatedBy uint      `json:"created_by" example:"7"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// MemberResponse represents an organization member in API responses
+type MemberResponse struct {
+	UserID   uint      `json:"user_id" example:"42"`
+	Name     string    `json:"name,omitempty" example:"Jane Doe"`
+	Email    string    `json:"email,omitempty" example:"jane@example.com"`
+	Role     string    `json:"role" example:"member"`
+	JoinedAt time.Time `json:"joined_at"`
+}
+
+// MemberListResponse represents one page of organization members
+type MemberListResponse struct {
+	Members    []MemberResponse `json:"members"`
+	Total      int64            `json:"total"`
+	Page       int              `json:"page"`
+	PerPage    int              `json:"per_page"`
+	TotalPages int              `json:"total_pages"`
+	HasNext    bool             `json:"has_next"`
+}
+
+// ToOrganizationResponse converts an Organization and the caller's role to OrganizationResponse
+func ToOrganizationResponse(org *Organization, role string) OrganizationResponse {
+	return OrganizationResponse{
+		ID:        org.ID,
+		Name:      org.Name,
+		Role:      role,
+		CreatedBy: org.CreatedBy,
+		CreatedAt: org.CreatedAt,
+		UpdatedAt: org.UpdatedAt,
+	}
+}
+
+// ToMemberResponse converts a Member to MemberResponse
+func ToMemberResponse(m *Member) MemberResponse {
+	return MemberResponse{
+		UserID:   m.UserID,
+		Role:     m.Role,
+		JoinedAt: m.CreatedAt,
+	}
+}
+
+// ToMemberDetailResponse converts a MemberDetail to MemberResponse
+func ToMemberDetailResponse(m *MemberDetail) MemberResponse {
+	return MemberResponse{
+		UserID:   m.UserID,
+		Name:     m.Name,
+		Email:    m.Email,
+		Role:     m.Role,
+		JoinedAt: m.JoinedAt,
+	}
+}
@@ -0,0 +1,386 @@
+package organization
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/yeegeek/uyou-go-api-starter/internal/contextutil"
+	apiErrors "github.com/yeegeek/uyou-go-api-starter/internal/errors"
+	"github.com/yeegeek/uyou-go-api-starter/internal/middleware"
+)
+
+// HeaderOrganizationID selects the organization for requests outside the /orgs/:org_id prefix
+const HeaderOrganizationID = "X-Organization-ID"
+
+// KeyMembership is the gin context key holding the caller's *Member set by RequireMembership
+const KeyMembership = "organization_membership"
+
+// Handler handles organization HTTP requests
+type Handler struct {
+	service Service
+}
+
+// NewHandler creates a new organization handler
+func NewHandler(service Service) *Handler {
+	return &Handler{service: service}
+}
+
+// RequireMembership resolves the organization from the :org_id path parameter, or the
+// X-Organization-ID header when the route has none, and requires the caller to be a member
+// with at least minRole. The membership is stored under KeyMembership.
+// Non-members get 404 so organization IDs cannot be probed.
+func (h *Handler) RequireMembership(minRole string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		raw := c.Param("org_id")
+		if raw == "" {
+			raw = c.GetHeader(HeaderOrganizationID)
+		}
+		if raw == "" {
+			_ = c.Error(apiErrors.BadRequest("Organization ID is required"))
+			c.Abort()
+			return
+		}
+		orgID, err := strconv.ParseUint(raw, 10, 32)
+		if err != nil || orgID == 0 {
+			_ = c.Error(apiErrors.BadRequest("Invalid organization ID"))
+			c.Abort()
+			return
+		}
+
+		// WHY: Memberships are read from the database rather than the token's orgs claim,
+		// so removing a member takes effect immediately instead of when their token expires
+		member, err := h.service.GetMembership(c.Request.Context(), uint(orgID), contextutil.GetUserID(c))
+		if err != nil {
+			if errors.Is(err, ErrNotMember) {
+				_ = c.Error(apiErrors.NotFound(apiErrors.MsgOrganizationNotFound))
+			} else {
+				_ = c.Error(apiErrors.InternalServerError(err))
+			}
+			c.Abort()
+			return
+		}
+		if !member.HasRole(minRole) {
+			_ = c.Error(apiErrors.Forbidden("Organization role " + minRole + " or higher is required"))
+			c.Abort()
+			return
+		}
+
+		c.Set(KeyMembership, member)
+		c.Next()
+	}
+}
+
+// MembershipFromContext returns the membership resolved by RequireMembership, or nil
+func MembershipFromContext(c *gin.Context) *Member {
+	value, exists := c.Get(KeyMembership)
+	if !exists {
+		return nil
+	}
+	member, _ := value.(*Member)
+	return member
+}
+
+// CreateOrganization godoc
+// @Summary Create organization
+// @Description Create an organization; the caller becomes its first owner
+// @Tags organizations
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body CreateOrganizationRequest true "Organization name"
+// @Success 201 {object} errors.Response{success=bool,data=OrganizationResponse} "Created organization"
+// @Failure 400 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Validation error"
+// @Failure 401 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Unauthorized"
+// @Failure 500 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Internal server error"
+// @Router /api/v1/orgs [post]
+func (h *Handler) CreateOrganization(c *gin.Context) {
+	userID := contextutil.GetUserID(c)
+	if userID == 0 {
+		_ = c.Error(apiErrors.Unauthorized(apiErrors.MsgUserNotAuthenticated))
+		return
+	}
+
+	var req CreateOrganizationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(apiErrors.FromGinValidation(err))
+		return
+	}
+
+	org, err := h.service.CreateOrganization(c.Request.Context(), userID, req)
+	if err != nil {
+		_ = c.Error(apiErrors.InternalServerError(err))
+		return
+	}
+
+	c.JSON(http.StatusCreated, apiErrors.Success(ToOrganizationResponse(org, RoleOwner)))
+}
+
+// ListOrganizations godoc
+// @Summary List my organizations
+// @Description List the organizations the caller belongs to with their role in each
+// @Tags organizations
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} errors.Response{success=bool,data=[]OrganizationResponse} "Organizations"
+// @Failure 401 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Unauthorized"
+// @Failure 500 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Internal server error"
+// @Router /api/v1/orgs [get]
+func (h *Handler) ListOrganizations(c *gin.Context) {
+	userID := contextutil.GetUserID(c)
+	if userID == 0 {
+		_ = c.Error(apiErrors.Unauthorized(apiErrors.MsgUserNotAuthenticated))
+		return
+	}
+
+	memberships, err := h.service.ListOrganizations(c.Request.Context(), userID)
+	if err != nil {
+		_ = c.Error(apiErrors.InternalServerError(err))
+		return
+	}
+
+	responses := make([]OrganizationResponse, len(memberships))
+	for i := range memberships {
+		responses[i] = ToOrganizationResponse(&memberships[i].Organization, memberships[i].Role)
+	}
+	c.JSON(http.StatusOK, apiErrors.Success(responses))
+}
+
+// GetOrganization godoc
+// @Summary Get organization
+// @Description Get an organization the caller belongs to
+// @Tags organizations
+// @Produce json
+// @Security BearerAuth
+// @Param org_id path int true "Organization ID"
+// @Success 200 {object} errors.Response{success=bool,data=OrganizationResponse} "Organization"
+// @Failure 400 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Invalid organization ID"
+// @Failure 404 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Organization not found or caller is not a member"
+// @Failure 500 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Internal server error"
+// @Router /api/v1/orgs/{org_id} [get]
+func (h *Handler) GetOrganization(c *gin.Context) {
+	member := MembershipFromContext(c)
+	org, err := h.service.GetOrganization(c.Request.Context(), member.OrganizationID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, apiErrors.Success(ToOrganizationResponse(org, member.Role)))
+}
+
+// UpdateOrganization godoc
+// @Summary Rename organization
+// @Description Rename an organization (requires organization admin or owner)
+// @Tags organizations
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param org_id path int true "Organization ID"
+// @Param request body UpdateOrganizationRequest true "New name"
+// @Success 200 {object} errors.Response{success=bool,data=OrganizationResponse} "Updated organization"
+// @Failure 400 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Validation error"
+// @Failure 403 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Organization role too low"
+// @Failure 404 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Organization not found or caller is not a member"
+// @Failure 500 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Internal server error"
+// @Router /api/v1/orgs/{org_id} [patch]
+func (h *Handler) UpdateOrganization(c *gin.Context) {
+	member := MembershipFromContext(c)
+
+	var req UpdateOrganizationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(apiErrors.FromGinValidation(err))
+		return
+	}
+
+	org, err := h.service.UpdateOrganization(c.Request.Context(), member.OrganizationID, req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, apiErrors.Success(ToOrganizationResponse(org, member.Role)))
+}
+
+// DeleteOrganization godoc
+// @Summary Delete organization
+// @Description Delete an organization and all of its memberships (requires organization owner)
+// @Tags organizations
+// @Security BearerAuth
+// @Param org_id path int true "Organization ID"
+// @Success 204
+// @Failure 403 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Organization role too low"
+// @Failure 404 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Organization not found or caller is not a member"
+// @Failure 500 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Internal server error"
+// @Router /api/v1/orgs/{org_id} [delete]
+func (h *Handler) DeleteOrganization(c *gin.Context) {
+	member := MembershipFromContext(c)
+	if err := h.service.DeleteOrganization(c.Request.Context(), member.OrganizationID); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ListMembers godoc
+// @Summary List organization members
+// @Description List the members of an organization the caller belongs to, oldest first
+// @Tags organizations
+// @Produce json
+// @Security BearerAuth
+// @Param org_id path int true "Organization ID"
+// @Param page query int false "Page number (default: 1)"
+// @Param per_page query int false "Items per page (default: 20, max: 100)"
+// @Success 200 {object} errors.Response{success=bool,data=MemberListResponse} "Members"
+// @Failure 404 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Organization not found or caller is not a member"
+// @Failure 500 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Internal server error"
+// @Router /api/v1/orgs/{org_id}/members [get]
+func (h *Handler) ListMembers(c *gin.Context) {
+	member := MembershipFromContext(c)
+	pagination := middleware.ParsePaginationParams(c)
+
+	members, total, err := h.service.ListMembers(c.Request.Context(), member.OrganizationID, pagination.Page, pagination.PerPage)
+	if err != nil {
+		_ = c.Error(apiErrors.InternalServerError(err))
+		return
+	}
+
+	totalPages := int((total + int64(pagination.PerPage) - 1) / int64(pagination.PerPage))
+	response := MemberListResponse{
+		Members:    make([]MemberResponse, len(members)),
+		Total:      total,
+		Page:       pagination.Page,
+		PerPage:    pagination.PerPage,
+		TotalPages: totalPages,
+		HasNext:    pagination.Page < totalPages,
+	}
+	for i := range members {
+		response.Members[i] = ToMemberDetailResponse(&members[i])
+	}
+	c.JSON(http.StatusOK, apiErrors.Success(response))
+}
+
+// AddMember godoc
+// @Summary Add organization member
+// @Description Add a user to the organization. Admins may add admins and members; only owners may add owners.
+// @Tags organizations
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param org_id path int true "Organization ID"
+// @Param request body AddMemberRequest true "User and organization role"
+// @Success 201 {object} errors.Response{success=bool,data=MemberResponse} "Added member"
+// @Failure 400 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Validation error"
+// @Failure 403 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Organization role too low"
+// @Failure 404 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Organization or user not found"
+// @Failure 409 {object} errors.Response{success=bool,error=errors.ErrorInfo} "User is already a member"
+// @Failure 500 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Internal server error"
+// @Router /api/v1/orgs/{org_id}/members [post]
+func (h *Handler) AddMember(c *gin.Context) {
+	var req AddMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(apiErrors.FromGinValidation(err))
+		return
+	}
+
+	member, err := h.service.AddMember(c.Request.Context(), MembershipFromContext(c), req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, apiErrors.Success(ToMemberResponse(member)))
+}
+
+// UpdateMember godoc
+// @Summary Change organization member role
+// @Description Change a member's organization role. Admins may not change owners or grant ownership; the last owner cannot be demoted.
+// @Tags organizations
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param org_id path int true "Organization ID"
+// @Param user_id path int true "User ID"
+// @Param request body UpdateMemberRequest true "New organization role"
+// @Success 200 {object} errors.Response{success=bool,data=MemberResponse} "Updated member"
+// @Failure 400 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Validation error"
+// @Failure 403 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Organization role too low"
+// @Failure 404 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Organization or member not found"
+// @Failure 409 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Organization must keep an owner"
+// @Failure 500 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Internal server error"
+// @Router /api/v1/orgs/{org_id}/members/{user_id} [patch]
+func (h *Handler) UpdateMember(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("user_id"), 10, 32)
+	if err != nil {
+		_ = c.Error(apiErrors.BadRequest(apiErrors.MsgInvalidUserID))
+		return
+	}
+
+	var req UpdateMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(apiErrors.FromGinValidation(err))
+		return
+	}
+
+	member, err := h.service.UpdateMemberRole(c.Request.Context(), MembershipFromContext(c), uint(userID), req.Role)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, apiErrors.Success(ToMemberResponse(member)))
+}
+
+// RemoveMember godoc
+// @Summary Remove organization member
+// @Description Remove a member from the organization. Any member may remove themself to leave; removing others requires organization admin, and only owners may remove owners. The last owner cannot leave.
+// @Tags organizations
+// @Security BearerAuth
+// @Param org_id path int true "Organization ID"
+// @Param user_id path int true "User ID"
+// @Success 204
+// @Failure 400 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Invalid user ID"
+// @Failure 403 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Organization role too low"
+// @Failure 404 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Organization or member not found"
+// @Failure 409 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Organization must keep an owner"
+// @Failure 500 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Internal server error"
+// @Router /api/v1/orgs/{org_id}/members/{user_id} [delete]
+func (h *Handler) RemoveMember(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("user_id"), 10, 32)
+	if err != nil {
+		_ = c.Error(apiErrors.BadRequest(apiErrors.MsgInvalidUserID))
+		return
+	}
+
+	if err := h.service.RemoveMember(c.Request.Context(), MembershipFromContext(c), uint(userID)); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// handleError maps organization service errors to API errors
+func (h *Handler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrOrganizationNotFound):
+		_ = c.Error(apiErrors.NotFound(apiErrors.MsgOrganizationNotFound))
+	case errors.Is(err, ErrMemberNotFound):
+		_ = c.Error(apiErrors.NotFound("Member not found"))
+	case errors.Is(err, ErrUserNotFound):
+		_ = c.Error(apiErrors.NotFound(apiErrors.MsgUserNotFound))
+	case errors.Is(err, ErrMemberExists):
+		_ = c.Error(apiErrors.Conflict("User is already a member"))
+	case errors.Is(err, ErrLastOwner):
+		_ = c.Error(apiErrors.Conflict("Organization must keep at least one owner"))
+	case errors.Is(err, ErrInsufficientRole):
+		_ = c.Error(apiErrors.Forbidden("Organization role does not allow this change"))
+	case errors.Is(err, ErrInvalidRole):
+		_ = c.Error(apiErrors.BadRequest("Invalid organization role"))
+	default:
+		_ = c.Error(apiErrors.InternalServerError(err))
+	}
+}
@@ -0,0 +1,151 @@
+package organization
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yeegeek/uyou-go-api-starter/internal/auth"
+	apiErrors "github.com/yeegeek/uyou-go-api-starter/internal/errors"
+)
+
+// testUserHeader carries the caller's user ID in handler tests
+const testUserHeader = "X-Test-User"
+
+func setupTestRouter(t *testing.T) (*gin.Engine, *Organization) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	svc := NewService(NewRepository(setupTestDB(t)))
+	org := setupTestOrganization(t, svc)
+	h := NewHandler(svc)
+
+	router := gin.New()
+	router.Use(apiErrors.ErrorHandler())
+	router.Use(func(c *gin.Context) {
+		id, _ := strconv.ParseUint(c.GetHeader(testUserHeader), 10, 32)
+		c.Set(auth.KeyUser, &auth.Claims{UserID: uint(id)})
+	})
+	router.POST("/orgs", h.CreateOrganization)
+	router.GET("/orgs", h.ListOrganizations)
+	router.GET("/orgs/:org_id", h.RequireMembership(RoleMember), h.GetOrganization)
+	router.PATCH("/orgs/:org_id", h.RequireMembership(RoleAdmin), h.UpdateOrganization)
+	router.DELETE("/orgs/:org_id", h.RequireMembership(RoleOwner), h.DeleteOrganization)
+	router.GET("/orgs/:org_id/members", h.RequireMembership(RoleMember), h.ListMembers)
+	router.POST("/orgs/:org_id/members", h.RequireMembership(RoleAdmin), h.AddMember)
+	router.PATCH("/orgs/:org_id/members/:user_id", h.RequireMembership(RoleAdmin), h.UpdateMember)
+	router.DELETE("/orgs/:org_id/members/:user_id", h.RequireMembership(RoleMember), h.RemoveMember)
+	router.GET("/projects", h.RequireMembership(RoleMember), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"org_id": MembershipFromContext(c).OrganizationID})
+	})
+	return router, org
+}
+
+func doOrgRequest(router *gin.Engine, userID uint, method, path, body string, headers ...string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(testUserHeader, strconv.FormatUint(uint64(userID), 10))
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestHandler_CreateAndListOrganizations(t *testing.T) {
+	router, _ := setupTestRouter(t)
+
+	w := doOrgRequest(router, 4, http.MethodPost, "/orgs", `{"name":"Beta"}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var created struct {
+		Data OrganizationResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	assert.Equal(t, "Beta", created.Data.Name)
+	assert.Equal(t, RoleOwner, created.Data.Role)
+
+	w = doOrgRequest(router, 4, http.MethodPost, "/orgs", `{"name":""}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = doOrgRequest(router, 4, http.MethodGet, "/orgs", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var listed struct {
+		Data []OrganizationResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &listed))
+	require.Len(t, listed.Data, 1)
+	assert.Equal(t, created.Data.ID, listed.Data[0].ID)
+}
+
+func TestHandler_RequireMembership(t *testing.T) {
+	router, org := setupTestRouter(t)
+	orgPath := "/orgs/" + strconv.FormatUint(uint64(org.ID), 10)
+	orgHeader := strconv.FormatUint(uint64(org.ID), 10)
+
+	tests := []struct {
+		name       string
+		userID     uint
+		method     string
+		path       string
+		body       string
+		headers    []string
+		wantStatus int
+	}{
+		{name: "member reads organization", userID: 3, method: http.MethodGet, path: orgPath, wantStatus: http.StatusOK},
+		{name: "outsider gets not found", userID: 4, method: http.MethodGet, path: orgPath, wantStatus: http.StatusNotFound},
+		{name: "unknown organization", userID: 1, method: http.MethodGet, path: "/orgs/999", wantStatus: http.StatusNotFound},
+		{name: "invalid organization ID", userID: 1, method: http.MethodGet, path: "/orgs/abc", wantStatus: http.StatusBadRequest},
+		{name: "member cannot rename", userID: 3, method: http.MethodPatch, path: orgPath, body: `{"name":"Renamed"}`, wantStatus: http.StatusForbidden},
+		{name: "admin renames", userID: 2, method: http.MethodPatch, path: orgPath, body: `{"name":"Renamed"}`, wantStatus: http.StatusOK},
+		{name: "admin cannot delete", userID: 2, method: http.MethodDelete, path: orgPath, wantStatus: http.StatusForbidden},
+		{name: "header selects organization", userID: 3, method: http.MethodGet, path: "/projects", headers: []string{HeaderOrganizationID, orgHeader}, wantStatus: http.StatusOK},
+		{name: "header for foreign organization", userID: 4, method: http.MethodGet, path: "/projects", headers: []string{HeaderOrganizationID, orgHeader}, wantStatus: http.StatusNotFound},
+		{name: "missing organization", userID: 3, method: http.MethodGet, path: "/projects", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := doOrgRequest(router, tt.userID, tt.method, tt.path, tt.body, tt.headers...)
+			assert.Equal(t, tt.wantStatus, w.Code, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_Members(t *testing.T) {
+	router, org := setupTestRouter(t)
+	membersPath := "/orgs/" + strconv.FormatUint(uint64(org.ID), 10) + "/members"
+
+	w := doOrgRequest(router, 3, http.MethodGet, membersPath+"?per_page=2", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var listed struct {
+		Data MemberListResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &listed))
+	assert.Equal(t, int64(3), listed.Data.Total)
+	assert.True(t, listed.Data.HasNext)
+	require.Len(t, listed.Data.Members, 2)
+	assert.Equal(t, "Owner", listed.Data.Members[0].Name)
+
+	assert.Equal(t, http.StatusForbidden, doOrgRequest(router, 3, http.MethodPost, membersPath, `{"user_id":4,"role":"member"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, doOrgRequest(router, 2, http.MethodPost, membersPath, `{"user_id":4,"role":"guest"}`).Code)
+	assert.Equal(t, http.StatusNotFound, doOrgRequest(router, 2, http.MethodPost, membersPath, `{"user_id":99,"role":"member"}`).Code)
+	assert.Equal(t, http.StatusCreated, doOrgRequest(router, 2, http.MethodPost, membersPath, `{"user_id":4,"role":"member"}`).Code)
+	assert.Equal(t, http.StatusConflict, doOrgRequest(router, 2, http.MethodPost, membersPath, `{"user_id":4,"role":"member"}`).Code)
+
+	assert.Equal(t, http.StatusForbidden, doOrgRequest(router, 2, http.MethodPatch, membersPath+"/4", `{"role":"owner"}`).Code)
+	assert.Equal(t, http.StatusOK, doOrgRequest(router, 2, http.MethodPatch, membersPath+"/4", `{"role":"admin"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, doOrgRequest(router, 2, http.MethodPatch, membersPath+"/abc", `{"role":"admin"}`).Code)
+
+	assert.Equal(t, http.StatusConflict, doOrgRequest(router, 1, http.MethodDelete, membersPath+"/1", "").Code, "the last owner cannot leave")
+	assert.Equal(t, http.StatusForbidden, doOrgRequest(router, 3, http.MethodDelete, membersPath+"/4", "").Code)
+	assert.Equal(t, http.StatusNoContent, doOrgRequest(router, 3, http.MethodDelete, membersPath+"/3", "").Code)
+	assert.Equal(t, http.StatusNotFound, doOrgRequest(router, 3, http.MethodGet, membersPath, "").Code, "removed members lose access immediately")
+}
@@ -0,0 +1,73 @@
+// Package organization 提供组织（多租户）与组织成员管理
+package organization
+
+import "time"
+
+// Organization roles, from most to least privileged
+const (
+	RoleOwner  = "owner"
+	RoleAdmin  = "admin"
+	RoleMember = "member"
+)
+
+// roleRanks orders organization roles; a higher rank includes every permission of a lower one
+var roleRanks = map[string]int{
+	RoleMember: 1,
+	RoleAdmin:  2,
+	RoleOwner:  3,
+}
+
+// IsValidRole reports whether role is one of owner, admin or member
+func IsValidRole(role string) bool {
+	_, ok := roleRanks[role]
+	return ok
+}
+
+// Organization is a tenant that users join through memberships
+type Organization struct {
+	ID   uint   `gorm:"primaryKey"`
+	Name string `gorm:"type:varchar(100);not null"`
+	// CreatedBy is the user who created the organization and became its first owner
+	CreatedBy uint      `gorm:"not null"`
+	CreatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP"`
+	UpdatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP"`
+}
+
+// TableName specifies the table name for Organization
+func (Organization) TableName() string {
+	return "organizations"
+}
+
+// Member links a user to an organization with an organization role
+type Member struct {
+	OrganizationID uint      `gorm:"primaryKey"`
+	UserID         uint      `gorm:"primaryKey;index"`
+	Role           string    `gorm:"type:varchar(20);not null"`
+	CreatedAt      time.Time `gorm:"default:CURRENT_TIMESTAMP"`
+	UpdatedAt      time.Time `gorm:"default:CURRENT_TIMESTAMP"`
+}
+
+// TableName specifies the table name for Member
+func (Member) TableName() string {
+	return "organization_members"
+}
+
+// HasRole reports whether the member's role is at least min
+func (m *Member) HasRole(min string) bool {
+	return roleRanks[m.Role] >= roleRanks[min]
+}
+
+// Membership is an organization together with the caller's role in it
+type Membership struct {
+	Organization
+	Role string
+}
+
+// MemberDetail is a member with the user's name and email, for member listings
+type MemberDetail struct {
+	UserID   uint
+	Name     string
+	Email    string
+	Role     string
+	JoinedAt time.Time
+}
@@ -0,0 +1,153 @@
+package organization
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// Repository defines persistence for organizations and their members
+type Repository interface {
+	// Create stores the organization and its first owner in one transaction; owner.OrganizationID is set from org
+	Create(ctx context.Context, org *Organization, owner *Member) error
+	FindByID(ctx context.Context, id uint) (*Organization, error)
+	Update(ctx context.Context, org *Organization) error
+	// Delete removes the organization and all of its memberships
+	Delete(ctx context.Context, id uint) error
+	// ListForUser returns the organizations the user belongs to, ordered by name
+	ListForUser(ctx context.Context, userID uint) ([]Membership, error)
+	FindMember(ctx context.Context, orgID, userID uint) (*Member, error)
+	// ListMembers returns one page of members ordered by join time, with the total count
+	ListMembers(ctx context.Context, orgID uint, page, perPage int) ([]MemberDetail, int64, error)
+	AddMember(ctx context.Context, member *Member) error
+	UpdateMemberRole(ctx context.Context, orgID, userID uint, role string) error
+	RemoveMember(ctx context.Context, orgID, userID uint) error
+	CountOwners(ctx context.Context, orgID uint) (int64, error)
+	UserExists(ctx context.Context, userID uint) (bool, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+// NewRepository creates a new organization repository
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) Create(ctx context.Context, org *Organization, owner *Member) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(org).Error; err != nil {
+			return err
+		}
+		owner.OrganizationID = org.ID
+		return tx.Create(owner).Error
+	})
+}
+
+// FindByID returns the organization, or nil if none exists
+func (r *repository) FindByID(ctx context.Context, id uint) (*Organization, error) {
+	var org Organization
+	err := r.db.WithContext(ctx).First(&org, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &org, nil
+}
+
+func (r *repository) Update(ctx context.Context, org *Organization) error {
+	return r.db.WithContext(ctx).Save(org).Error
+}
+
+func (r *repository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("organization_id = ?", id).Delete(&Member{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&Organization{}, id).Error
+	})
+}
+
+func (r *repository) ListForUser(ctx context.Context, userID uint) ([]Membership, error) {
+	var memberships []Membership
+	err := r.db.WithContext(ctx).Table("organizations").
+		Select("organizations.*, organization_members.role").
+		Joins("JOIN organization_members ON organization_members.organization_id = organizations.id").
+		Where("organization_members.user_id = ?", userID).
+		Order("organizations.name, organizations.id").
+		Scan(&memberships).Error
+	return memberships, err
+}
+
+// FindMember returns the user's membership, or nil if they do not belong to the organization
+func (r *repository) FindMember(ctx context.Context, orgID, userID uint) (*Member, error) {
+	var member Member
+	err := r.db.WithContext(ctx).
+		Where("organization_id = ? AND user_id = ?", orgID, userID).
+		First(&member).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &member, nil
+}
+
+func (r *repository) ListMembers(ctx context.Context, orgID uint, page, perPage int) ([]MemberDetail, int64, error) {
+	var total int64
+	if err := r.db.WithContext(ctx).Model(&Member{}).Where("organization_id = ?", orgID).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var members []MemberDetail
+	err := r.db.WithContext(ctx).Table("organization_members").
+		Select("organization_members.user_id, users.name, users.email, organization_members.role, organization_members.created_at AS joined_at").
+		Joins("JOIN users ON users.id = organization_members.user_id AND users.deleted_at IS NULL").
+		Where("organization_members.organization_id = ?", orgID).
+		Order("organization_members.created_at, organization_members.user_id").
+		Offset((page - 1) * perPage).
+		Limit(perPage).
+		Scan(&members).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return members, total, nil
+}
+
+func (r *repository) AddMember(ctx context.Context, member *Member) error {
+	return r.db.WithContext(ctx).Create(member).Error
+}
+
+func (r *repository) UpdateMemberRole(ctx context.Context, orgID, userID uint, role string) error {
+	return r.db.WithContext(ctx).Model(&Member{}).
+		Where("organization_id = ? AND user_id = ?", orgID, userID).
+		Update("role", role).Error
+}
+
+func (r *repository) RemoveMember(ctx context.Context, orgID, userID uint) error {
+	return r.db.WithContext(ctx).
+		Where("organization_id = ? AND user_id = ?", orgID, userID).
+		Delete(&Member{}).Error
+}
+
+func (r *repository) CountOwners(ctx context.Context, orgID uint) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&Member{}).
+		Where("organization_id = ? AND role = ?", orgID, RoleOwner).
+		Count(&count).Error
+	return count, err
+}
+
+// UserExists reports whether a (non-deleted) user with the ID exists
+func (r *repository) UserExists(ctx context.Context, userID uint) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Table("users").
+		Where("id = ? AND deleted_at IS NULL", userID).
+		Count(&count).Error
+	return count > 0, err
+}
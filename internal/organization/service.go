@@ -0,0 +1,280 @@
+package organization
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrOrganizationNotFound is returned when the organization does not exist
+	ErrOrganizationNotFound = errors.New("organization not found")
+	// ErrNotMember is returned when the user does not belong to the organization
+	ErrNotMember = errors.New("not a member of the organization")
+	// ErrMemberNotFound is returned when changing or removing a user who is not a member
+	ErrMemberNotFound = errors.New("organization member not found")
+	// ErrMemberExists is returned when adding a user who already belongs to the organization
+	ErrMemberExists = errors.New("user is already a member of the organization")
+	// ErrUserNotFound is returned when adding a user that does not exist
+	ErrUserNotFound = errors.New("user not found")
+	// ErrInvalidRole is returned for a role other than owner, admin or member
+	ErrInvalidRole = errors.New("invalid organization role")
+	// ErrInsufficientRole is returned when the acting member's role does not allow the change
+	ErrInsufficientRole = errors.New("insufficient organization role")
+	// ErrLastOwner is returned when a change would leave the organization without an owner
+	ErrLastOwner = errors.New("organization must keep at least one owner")
+)
+
+// MembershipCacheInvalidator is notified after a user's memberships change, so tokens issued
+// afterwards carry the new organization roles. auth.Service satisfies this interface.
+type MembershipCacheInvalidator interface {
+	InvalidateUserRoles(userID uint)
+	InvalidateAllRoles()
+}
+
+type noopMembershipCacheInvalidator struct{}
+
+func (noopMembershipCacheInvalidator) InvalidateUserRoles(uint) {}
+func (noopMembershipCacheInvalidator) InvalidateAllRoles()      {}
+
+// Service manages organizations and their memberships.
+// Member changes take the acting member, resolved by RequireMembership, and enforce the organization role rules.
+type Service interface {
+	CreateOrganization(ctx context.Context, userID uint, req CreateOrganizationRequest) (*Organization, error)
+	ListOrganizations(ctx context.Context, userID uint) ([]Membership, error)
+	GetOrganization(ctx context.Context, orgID uint) (*Organization, error)
+	UpdateOrganization(ctx context.Context, orgID uint, req UpdateOrganizationRequest) (*Organization, error)
+	DeleteOrganization(ctx context.Context, orgID uint) error
+	GetMembership(ctx context.Context, orgID, userID uint) (*Member, error)
+	ListMembers(ctx context.Context, orgID uint, page, perPage int) ([]MemberDetail, int64, error)
+	AddMember(ctx context.Context, actor *Member, req AddMemberRequest) (*Member, error)
+	UpdateMemberRole(ctx context.Context, actor *Member, userID uint, role string) (*Member, error)
+	RemoveMember(ctx context.Context, actor *Member, userID uint) error
+}
+
+type service struct {
+	repo  Repository
+	cache MembershipCacheInvalidator
+}
+
+// ServiceOption configures optional organization service behaviour
+type ServiceOption func(*service)
+
+// WithMembershipCacheInvalidator invalidates the cached authorization of users whose memberships change
+func WithMembershipCacheInvalidator(invalidator MembershipCacheInvalidator) ServiceOption {
+	return func(s *service) {
+		s.cache = invalidator
+	}
+}
+
+// NewService creates a new organization service
+func NewService(repo Repository, opts ...ServiceOption) Service {
+	s := &service{repo: repo, cache: noopMembershipCacheInvalidator{}}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// CreateOrganization creates an organization with the user as its first owner
+func (s *service) CreateOrganization(ctx context.Context, userID uint, req CreateOrganizationRequest) (*Organization, error) {
+	org := &Organization{Name: strings.TrimSpace(req.Name), CreatedBy: userID}
+	owner := &Member{UserID: userID, Role: RoleOwner}
+	if err := s.repo.Create(ctx, org, owner); err != nil {
+		return nil, fmt.Errorf("failed to create organization: %w", err)
+	}
+	s.cache.InvalidateUserRoles(userID)
+	return org, nil
+}
+
+// ListOrganizations returns the organizations the user belongs to with their role in each
+func (s *service) ListOrganizations(ctx context.Context, userID uint) ([]Membership, error) {
+	memberships, err := s.repo.ListForUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list organizations: %w", err)
+	}
+	return memberships, nil
+}
+
+func (s *service) GetOrganization(ctx context.Context, orgID uint) (*Organization, error) {
+	org, err := s.repo.FindByID(ctx, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find organization: %w", err)
+	}
+	if org == nil {
+		return nil, ErrOrganizationNotFound
+	}
+	return org, nil
+}
+
+func (s *service) UpdateOrganization(ctx context.Context, orgID uint, req UpdateOrganizationRequest) (*Organization, error) {
+	org, err := s.GetOrganization(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	org.Name = strings.TrimSpace(req.Name)
+	if err := s.repo.Update(ctx, org); err != nil {
+		return nil, fmt.Errorf("failed to update organization: %w", err)
+	}
+	return org, nil
+}
+
+// DeleteOrganization removes the organization and every membership
+func (s *service) DeleteOrganization(ctx context.Context, orgID uint) error {
+	if _, err := s.GetOrganization(ctx, orgID); err != nil {
+		return err
+	}
+	if err := s.repo.Delete(ctx, orgID); err != nil {
+		return fmt.Errorf("failed to delete organization: %w", err)
+	}
+	// WHY: Every former member's cached organization roles are now stale
+	s.cache.InvalidateAllRoles()
+	return nil
+}
+
+// GetMembership returns the user's membership, or ErrNotMember
+func (s *service) GetMembership(ctx context.Context, orgID, userID uint) (*Member, error) {
+	member, err := s.repo.FindMember(ctx, orgID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find membership: %w", err)
+	}
+	if member == nil {
+		return nil, ErrNotMember
+	}
+	return member, nil
+}
+
+func (s *service) ListMembers(ctx context.Context, orgID uint, page, perPage int) ([]MemberDetail, int64, error) {
+	members, total, err := s.repo.ListMembers(ctx, orgID, page, perPage)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list members: %w", err)
+	}
+	return members, total, nil
+}
+
+// AddMember adds a user to the actor's organization. Admins may add admins and members;
+// only owners may add owners.
+func (s *service) AddMember(ctx context.Context, actor *Member, req AddMemberRequest) (*Member, error) {
+	if !IsValidRole(req.Role) {
+		return nil, ErrInvalidRole
+	}
+	if !canGrant(actor, req.Role) {
+		return nil, ErrInsufficientRole
+	}
+
+	exists, err := s.repo.UserExists(ctx, req.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find user: %w", err)
+	}
+	if !exists {
+		return nil, ErrUserNotFound
+	}
+	existing, err := s.repo.FindMember(ctx, actor.OrganizationID, req.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find membership: %w", err)
+	}
+	if existing != nil {
+		return nil, ErrMemberExists
+	}
+
+	member := &Member{OrganizationID: actor.OrganizationID, UserID: req.UserID, Role: req.Role}
+	if err := s.repo.AddMember(ctx, member); err != nil {
+		return nil, fmt.Errorf("failed to add member: %w", err)
+	}
+	s.cache.InvalidateUserRoles(req.UserID)
+	return member, nil
+}
+
+// UpdateMemberRole changes a member's role. Admins may not change owners or grant ownership,
+// and the last owner cannot be demoted.
+func (s *service) UpdateMemberRole(ctx context.Context, actor *Member, userID uint, role string) (*Member, error) {
+	if !IsValidRole(role) {
+		return nil, ErrInvalidRole
+	}
+	member, err := s.findTarget(ctx, actor, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !canGrant(actor, role) || !canManage(actor, member) {
+		return nil, ErrInsufficientRole
+	}
+	if member.Role == role {
+		return member, nil
+	}
+	if member.Role == RoleOwner {
+		if err := s.ensureAnotherOwner(ctx, actor.OrganizationID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.repo.UpdateMemberRole(ctx, actor.OrganizationID, userID, role); err != nil {
+		return nil, fmt.Errorf("failed to update member role: %w", err)
+	}
+	s.cache.InvalidateUserRoles(userID)
+	member.Role = role
+	return member, nil
+}
+
+// RemoveMember removes a member. Any member may leave; removing someone else requires admin,
+// and only owners may remove owners. The last owner cannot leave or be removed.
+func (s *service) RemoveMember(ctx context.Context, actor *Member, userID uint) error {
+	member, err := s.findTarget(ctx, actor, userID)
+	if err != nil {
+		return err
+	}
+	if actor.UserID != userID && !canManage(actor, member) {
+		return ErrInsufficientRole
+	}
+	if member.Role == RoleOwner {
+		if err := s.ensureAnotherOwner(ctx, actor.OrganizationID); err != nil {
+			return err
+		}
+	}
+
+	if err := s.repo.RemoveMember(ctx, actor.OrganizationID, userID); err != nil {
+		return fmt.Errorf("failed to remove member: %w", err)
+	}
+	s.cache.InvalidateUserRoles(userID)
+	return nil
+}
+
+// findTarget loads the member being changed in the actor's organization
+func (s *service) findTarget(ctx context.Context, actor *Member, userID uint) (*Member, error) {
+	member, err := s.repo.FindMember(ctx, actor.OrganizationID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find membership: %w", err)
+	}
+	if member == nil {
+		return nil, ErrMemberNotFound
+	}
+	return member, nil
+}
+
+// ensureAnotherOwner fails with ErrLastOwner unless the organization has more than one owner
+func (s *service) ensureAnotherOwner(ctx context.Context, orgID uint) error {
+	owners, err := s.repo.CountOwners(ctx, orgID)
+	if err != nil {
+		return fmt.Errorf("failed to count owners: %w", err)
+	}
+	if owners <= 1 {
+		return ErrLastOwner
+	}
+	return nil
+}
+
+// canGrant reports whether the actor may give a member the role: admins up to admin, owners any role
+func canGrant(actor *Member, role string) bool {
+	if role == RoleOwner {
+		return actor.HasRole(RoleOwner)
+	}
+	return actor.HasRole(RoleAdmin)
+}
+
+// canManage reports whether the actor may change or remove the member: admins manage non-owners, owners everyone
+func canManage(actor *Member, member *Member) bool {
+	if member.Role == RoleOwner {
+		return actor.HasRole(RoleOwner)
+	}
+	return actor.HasRole(RoleAdmin)
+}
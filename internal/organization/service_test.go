@@ -0,0 +1,184 @@
+package organization
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&Organization{}, &Member{}))
+	require.NoError(t, db.Exec(`CREATE TABLE users (
+		id INTEGER PRIMARY KEY,
+		name TEXT NOT NULL,
+		email TEXT NOT NULL,
+		deleted_at DATETIME
+	)`).Error)
+	require.NoError(t, db.Exec(`INSERT INTO users (id, name, email) VALUES
+		(1, 'Owner', 'owner@example.com'),
+		(2, 'Admin', 'admin@example.com'),
+		(3, 'Member', 'member@example.com'),
+		(4, 'Outsider', 'outsider@example.com')`).Error)
+	require.NoError(t, db.Exec(`INSERT INTO users (id, name, email, deleted_at) VALUES (5, 'Deleted', 'deleted@example.com', CURRENT_TIMESTAMP)`).Error)
+	return db
+}
+
+// recordingInvalidator records which users' cached authorization was invalidated
+type recordingInvalidator struct {
+	users []uint
+	all   int
+}
+
+func (r *recordingInvalidator) InvalidateUserRoles(userID uint) { r.users = append(r.users, userID) }
+func (r *recordingInvalidator) InvalidateAllRoles()             { r.all++ }
+
+// setupTestOrganization creates an organization owned by user 1 with user 2 as admin and user 3 as member
+func setupTestOrganization(t *testing.T, svc Service) *Organization {
+	t.Helper()
+	ctx := context.Background()
+
+	org, err := svc.CreateOrganization(ctx, 1, CreateOrganizationRequest{Name: " Acme "})
+	require.NoError(t, err)
+	owner, err := svc.GetMembership(ctx, org.ID, 1)
+	require.NoError(t, err)
+	_, err = svc.AddMember(ctx, owner, AddMemberRequest{UserID: 2, Role: RoleAdmin})
+	require.NoError(t, err)
+	_, err = svc.AddMember(ctx, owner, AddMemberRequest{UserID: 3, Role: RoleMember})
+	require.NoError(t, err)
+	return org
+}
+
+func TestService_CreateAndList(t *testing.T) {
+	invalidator := &recordingInvalidator{}
+	svc := NewService(NewRepository(setupTestDB(t)), WithMembershipCacheInvalidator(invalidator))
+	ctx := context.Background()
+
+	org := setupTestOrganization(t, svc)
+	assert.Equal(t, "Acme", org.Name)
+	assert.Equal(t, uint(1), org.CreatedBy)
+	assert.Equal(t, []uint{1, 2, 3}, invalidator.users, "every membership change refreshes the member's token claims")
+
+	_, err := svc.CreateOrganization(ctx, 3, CreateOrganizationRequest{Name: "Beta"})
+	require.NoError(t, err)
+
+	memberships, err := svc.ListOrganizations(ctx, 3)
+	require.NoError(t, err)
+	require.Len(t, memberships, 2)
+	assert.Equal(t, "Acme", memberships[0].Name)
+	assert.Equal(t, RoleMember, memberships[0].Role)
+	assert.Equal(t, "Beta", memberships[1].Name)
+	assert.Equal(t, RoleOwner, memberships[1].Role)
+
+	memberships, err = svc.ListOrganizations(ctx, 4)
+	require.NoError(t, err)
+	assert.Empty(t, memberships, "users outside any organization keep working single-tenant")
+
+	members, total, err := svc.ListMembers(ctx, org.ID, 1, 2)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), total)
+	require.Len(t, members, 2)
+	assert.Equal(t, "owner@example.com", members[0].Email)
+	assert.Equal(t, RoleAdmin, members[1].Role)
+}
+
+func TestService_AddMember(t *testing.T) {
+	svc := NewService(NewRepository(setupTestDB(t)))
+	ctx := context.Background()
+	org := setupTestOrganization(t, svc)
+	admin, err := svc.GetMembership(ctx, org.ID, 2)
+	require.NoError(t, err)
+	member, err := svc.GetMembership(ctx, org.ID, 3)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		actor   *Member
+		req     AddMemberRequest
+		wantErr error
+	}{
+		{name: "member cannot add", actor: member, req: AddMemberRequest{UserID: 4, Role: RoleMember}, wantErr: ErrInsufficientRole},
+		{name: "admin cannot add owner", actor: admin, req: AddMemberRequest{UserID: 4, Role: RoleOwner}, wantErr: ErrInsufficientRole},
+		{name: "invalid role", actor: admin, req: AddMemberRequest{UserID: 4, Role: "guest"}, wantErr: ErrInvalidRole},
+		{name: "unknown user", actor: admin, req: AddMemberRequest{UserID: 99, Role: RoleMember}, wantErr: ErrUserNotFound},
+		{name: "deleted user", actor: admin, req: AddMemberRequest{UserID: 5, Role: RoleMember}, wantErr: ErrUserNotFound},
+		{name: "already a member", actor: admin, req: AddMemberRequest{UserID: 3, Role: RoleMember}, wantErr: ErrMemberExists},
+		{name: "admin adds admin", actor: admin, req: AddMemberRequest{UserID: 4, Role: RoleAdmin}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			added, err := svc.AddMember(ctx, tt.actor, tt.req)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, org.ID, added.OrganizationID)
+			assert.Equal(t, tt.req.Role, added.Role)
+		})
+	}
+}
+
+func TestService_OwnerRules(t *testing.T) {
+	svc := NewService(NewRepository(setupTestDB(t)))
+	ctx := context.Background()
+	org := setupTestOrganization(t, svc)
+	owner, err := svc.GetMembership(ctx, org.ID, 1)
+	require.NoError(t, err)
+	admin, err := svc.GetMembership(ctx, org.ID, 2)
+	require.NoError(t, err)
+	member, err := svc.GetMembership(ctx, org.ID, 3)
+	require.NoError(t, err)
+
+	_, err = svc.UpdateMemberRole(ctx, admin, 1, RoleMember)
+	assert.ErrorIs(t, err, ErrInsufficientRole, "admins cannot demote owners")
+	_, err = svc.UpdateMemberRole(ctx, admin, 3, RoleOwner)
+	assert.ErrorIs(t, err, ErrInsufficientRole, "admins cannot grant ownership")
+	_, err = svc.UpdateMemberRole(ctx, owner, 1, RoleAdmin)
+	assert.ErrorIs(t, err, ErrLastOwner)
+	assert.ErrorIs(t, svc.RemoveMember(ctx, owner, 1), ErrLastOwner, "the last owner cannot leave")
+	assert.ErrorIs(t, svc.RemoveMember(ctx, member, 2), ErrInsufficientRole)
+	assert.ErrorIs(t, svc.RemoveMember(ctx, admin, 1), ErrInsufficientRole)
+	assert.ErrorIs(t, svc.RemoveMember(ctx, admin, 4), ErrMemberNotFound)
+
+	updated, err := svc.UpdateMemberRole(ctx, owner, 2, RoleOwner)
+	require.NoError(t, err)
+	assert.Equal(t, RoleOwner, updated.Role)
+
+	require.NoError(t, svc.RemoveMember(ctx, owner, 1), "another owner remains")
+	require.NoError(t, svc.RemoveMember(ctx, member, 3), "members can leave")
+
+	_, err = svc.GetMembership(ctx, org.ID, 1)
+	assert.ErrorIs(t, err, ErrNotMember)
+	members, total, err := svc.ListMembers(ctx, org.ID, 1, 20)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, uint(2), members[0].UserID)
+}
+
+func TestService_UpdateAndDeleteOrganization(t *testing.T) {
+	invalidator := &recordingInvalidator{}
+	svc := NewService(NewRepository(setupTestDB(t)), WithMembershipCacheInvalidator(invalidator))
+	ctx := context.Background()
+	org := setupTestOrganization(t, svc)
+
+	updated, err := svc.UpdateOrganization(ctx, org.ID, UpdateOrganizationRequest{Name: "Acme Corp"})
+	require.NoError(t, err)
+	assert.Equal(t, "Acme Corp", updated.Name)
+
+	require.NoError(t, svc.DeleteOrganization(ctx, org.ID))
+	assert.Equal(t, 1, invalidator.all)
+	_, err = svc.GetOrganization(ctx, org.ID)
+	assert.ErrorIs(t, err, ErrOrganizationNotFound)
+	_, err = svc.GetMembership(ctx, org.ID, 2)
+	assert.ErrorIs(t, err, ErrNotMember, "memberships are removed with the organization")
+	assert.ErrorIs(t, svc.DeleteOrganization(ctx, org.ID), ErrOrganizationNotFound)
+}
@@ -4,13 +4,22 @@ package redis
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/yeegeek/uyou-go-api-starter/internal/resilience"
 )
 
+// ErrCacheUnavailable 缓存熔断期间读操作返回的错误，调用方按未命中处理
+var ErrCacheUnavailable = errors.New("cache unavailable")
+
 // Cache 提供高级缓存操作
 type Cache struct {
-	client *Client
+	client  *Client
+	breaker *resilience.Breaker
 }
 
 // NewCache 创建缓存实例
@@ -18,18 +27,55 @@ func NewCache(client *Client) *Cache {
 	return &Cache{client: client}
 }
 
+// NewCacheWithBreaker 创建带熔断的缓存实例：Redis 连续失败后，熔断期间读操作返回 ErrCacheUnavailable，
+// 写和删除操作为空操作，不再等待连接超时
+func NewCacheWithBreaker(client *Client, breaker *resilience.Breaker) *Cache {
+	return &Cache{client: client, breaker: breaker}
+}
+
+// call 执行一次 Redis 操作并向熔断器报告结果；键不存在（redis.Nil）不计为失败
+func (c *Cache) call(fn func() error) error {
+	if c.breaker == nil {
+		return fn()
+	}
+	if !c.breaker.Allow() {
+		return ErrCacheUnavailable
+	}
+	err := fn()
+	if errors.Is(err, redis.Nil) {
+		c.breaker.Record(nil)
+	} else {
+		c.breaker.Record(err)
+	}
+	return err
+}
+
+// write 执行写操作，熔断期间为空操作
+func (c *Cache) write(fn func() error) error {
+	if err := c.call(fn); !errors.Is(err, ErrCacheUnavailable) {
+		return err
+	}
+	return nil
+}
+
 // Set 设置缓存（自动序列化为 JSON）
 func (c *Cache) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
 	data, err := json.Marshal(value)
 	if err != nil {
 		return fmt.Errorf("failed to marshal value: %w", err)
 	}
-	return c.client.SetWithExpiration(ctx, key, data, expiration)
+	return c.write(func() error {
+		return c.client.SetWithExpiration(ctx, key, data, expiration)
+	})
 }
 
 // Get 获取缓存（自动反序列化 JSON）
 func (c *Cache) Get(ctx context.Context, key string, dest interface{}) error {
-	data, err := c.client.GetString(ctx, key)
+	var data string
+	err := c.call(func() (err error) {
+		data, err = c.client.GetString(ctx, key)
+		return err
+	})
 	if err != nil {
 		return err
 	}
@@ -66,27 +112,47 @@ func (c *Cache) GetOrSet(ctx context.Context, key string, dest interface{}, expi
 
 // Delete 删除缓存
 func (c *Cache) Delete(ctx context.Context, keys ...string) error {
-	return c.client.Delete(ctx, keys...)
+	return c.write(func() error {
+		return c.client.Delete(ctx, keys...)
+	})
 }
 
 // Increment 增加指定键的数值（用于计数）
 func (c *Cache) Increment(ctx context.Context, key string, value int64) (int64, error) {
 	// 使用底层客户端的原子递增能力
-	return c.client.IncrementBy(ctx, key, value)
+	var result int64
+	err := c.call(func() (err error) {
+		result, err = c.client.IncrementBy(ctx, key, value)
+		return err
+	})
+	return result, err
 }
 
 // Expire 设置键的过期时间
 func (c *Cache) Expire(ctx context.Context, key string, expiration time.Duration) error {
-	return c.client.Expire(ctx, key, expiration)
+	return c.write(func() error {
+		return c.client.Expire(ctx, key, expiration)
+	})
 }
 
 // TTL 获取键的剩余过期时间
 func (c *Cache) TTL(ctx context.Context, key string) (time.Duration, error) {
-	return c.client.TTL(ctx, key).Result()
+	var ttl time.Duration
+	err := c.call(func() (err error) {
+		ttl, err = c.client.TTL(ctx, key).Result()
+		return err
+	})
+	return ttl, err
 }
 
 // DeletePattern 删除匹配模式的所有键
 func (c *Cache) DeletePattern(ctx context.Context, pattern string) error {
+	return c.write(func() error {
+		return c.deletePattern(ctx, pattern)
+	})
+}
+
+func (c *Cache) deletePattern(ctx context.Context, pattern string) error {
 	var cursor uint64
 	for {
 		keys, nextCursor, err := c.client.Scan(ctx, cursor, pattern, 100).Result()
@@ -5,9 +5,11 @@ import (
 	"testing"
 	"time"
 
+	goredis "github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/yeegeek/uyou-go-api-starter/internal/config"
+	"github.com/yeegeek/uyou-go-api-starter/internal/resilience"
 )
 
 func TestNewCache(t *testing.T) {
@@ -397,3 +399,39 @@ func TestCache_TTL(t *testing.T) {
 	// 清理
 	_ = cache.Delete(ctx, key)
 }
+
+func TestCacheWithBreaker_DegradesWhenRedisUnavailable(t *testing.T) {
+	// 指向未监听的端口，模拟 Redis 故障
+	client := &Client{Client: goredis.NewClient(&goredis.Options{
+		Addr:        "127.0.0.1:1",
+		DialTimeout: 100 * time.Millisecond,
+		MaxRetries:  -1,
+	})}
+	defer client.Close()
+
+	cooldown := 50 * time.Millisecond
+	cache := NewCacheWithBreaker(client, resilience.NewBreaker("redis", 3, cooldown))
+	ctx := context.Background()
+	var dest string
+
+	for i := 0; i < 3; i++ {
+		err := cache.Get(ctx, "user:1", &dest)
+		require.Error(t, err)
+		assert.NotErrorIs(t, err, ErrCacheUnavailable)
+	}
+
+	// 熔断后读操作立即返回未命中，写操作为空操作
+	assert.ErrorIs(t, cache.Get(ctx, "user:1", &dest), ErrCacheUnavailable)
+	assert.NoError(t, cache.Set(ctx, "user:1", "value", time.Minute))
+	assert.NoError(t, cache.Delete(ctx, "user:1"))
+	assert.NoError(t, cache.DeletePattern(ctx, "user:*"))
+	_, err := cache.Increment(ctx, "counter", 1)
+	assert.ErrorIs(t, err, ErrCacheUnavailable)
+
+	// 冷却结束后放行一次试探调用，失败则重新熔断
+	time.Sleep(cooldown + 10*time.Millisecond)
+	err = cache.Get(ctx, "user:1", &dest)
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, ErrCacheUnavailable)
+	assert.ErrorIs(t, cache.Get(ctx, "user:1", &dest), ErrCacheUnavailable)
+}
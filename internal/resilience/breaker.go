@@ -0,0 +1,298 @@
+// Package resilience 提供外部依赖（Redis、RabbitMQ 等）故障时的降级工具
+package resilience
+
+import (
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/yeegeek/uyou-go-api-starter/internal/metrics"
+)
+
+const (
+	// DefaultFailureThreshold 未配置时连续失败多少次后熔断
+	DefaultFailureThreshold = 5
+	// DefaultCooldown 未配置时熔断后多久进入半开状态尝试恢复
+	DefaultCooldown = 30 * time.Second
+	// DefaultWindowSize 启用失败率熔断但未配置窗口时统计最近多少次调用
+	DefaultWindowSize = 20
+)
+
+// State 熔断器状态
+type State int
+
+const (
+	// StateClosed 正常放行
+	StateClosed State = iota
+	// StateOpen 熔断中，调用直接短路
+	StateOpen
+	// StateHalfOpen 冷却结束，放行一次试探调用
+	StateHalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// Breaker 按连续失败次数或失败率熔断：连续失败达到阈值，或最近 windowSize 次调用的失败率达到 failureRate 后进入 open，
+// 冷却期内 Allow 返回 false；冷却结束后进入 half-open 放行一次试探调用，成功则恢复 closed，失败则重新 open
+// 每次状态转换更新 circuit_breaker_state 和 circuit_breaker_transitions_total 指标并输出日志；
+// 熔断和恢复使用 warn/info 级别，故障期间每个冷却周期一次的试探只在 debug 级别输出，避免刷日志
+type Breaker struct {
+	name      string
+	threshold int
+	cooldown  time.Duration
+	// failureRate 为 0 时只按连续失败次数熔断
+	failureRate float64
+	windowSize  int
+	now         func() time.Time
+
+	mu       sync.Mutex
+	state    State
+	failures int
+	openedAt time.Time
+	// probing half-open 状态下已放行试探调用，结果返回前不再放行
+	probing bool
+	// window closed 状态下最近 windowSize 次调用的结果（true 为失败），环形写入
+	window   []bool
+	next     int
+	recorded int
+	failed   int
+}
+
+// Option 熔断器可选参数
+type Option func(*Breaker)
+
+// WithFailureRate 在连续失败次数之外按失败率熔断：最近 windowSize 次调用中失败占比达到 rate 时熔断，
+// 调用次数不足 windowSize 时不按失败率判断；rate 不大于 0 时不启用，windowSize 不大于 0 时使用 DefaultWindowSize
+func WithFailureRate(rate float64, windowSize int) Option {
+	return func(b *Breaker) {
+		if rate <= 0 {
+			return
+		}
+		if windowSize <= 0 {
+			windowSize = DefaultWindowSize
+		}
+		b.failureRate = rate
+		b.windowSize = windowSize
+		b.window = make([]bool, windowSize)
+	}
+}
+
+// NewBreaker 创建熔断器并登记到 Breakers()，同名熔断器以最后创建的为准；failureThreshold、cooldown 不大于 0 时使用默认值
+func NewBreaker(name string, failureThreshold int, cooldown time.Duration, opts ...Option) *Breaker {
+	if failureThreshold <= 0 {
+		failureThreshold = DefaultFailureThreshold
+	}
+	if cooldown <= 0 {
+		cooldown = DefaultCooldown
+	}
+	b := &Breaker{name: name, threshold: failureThreshold, cooldown: cooldown, now: time.Now}
+	for _, opt := range opts {
+		opt(b)
+	}
+	metrics.SetCircuitBreakerState(name, float64(StateClosed))
+	register(b)
+	return b
+}
+
+// Name 返回熔断器名称（依赖名）
+func (b *Breaker) Name() string {
+	return b.name
+}
+
+// State 返回当前状态；冷却已结束的 open 状态报告为 half-open
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == StateOpen && b.cooldownElapsed() {
+		return StateHalfOpen
+	}
+	return b.state
+}
+
+// Allow 判断本次调用是否放行；返回 true 时调用方必须用 Record 报告结果
+func (b *Breaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case StateOpen:
+		if !b.cooldownElapsed() {
+			return false
+		}
+		b.transition(StateHalfOpen)
+		slog.Debug("Circuit half-open, probing dependency", "dependency", b.name)
+		b.probing = true
+		return true
+	case StateHalfOpen:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+		return true
+	default:
+		return true
+	}
+}
+
+// Record 报告一次放行调用的结果，err 为 nil 表示成功
+func (b *Breaker) Record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err == nil {
+		if b.state != StateClosed {
+			b.transition(StateClosed)
+			slog.Info("Dependency recovered, circuit closed", "dependency", b.name)
+			b.resetWindow()
+		} else {
+			b.observe(false)
+		}
+		b.failures = 0
+		b.probing = false
+		return
+	}
+
+	switch b.state {
+	case StateHalfOpen:
+		b.open()
+		slog.Debug("Dependency probe failed, circuit reopened", "dependency", b.name, "error", err)
+	case StateClosed:
+		b.failures++
+		b.observe(true)
+		rate, full := b.windowRate()
+		if b.failures >= b.threshold || (full && rate >= b.failureRate) {
+			slog.Warn("Dependency unavailable, circuit opened",
+				"dependency", b.name, "failures", b.failures, "failure_rate", rate, "cooldown", b.cooldown.String(), "error", err)
+			b.open()
+		}
+	}
+}
+
+func (b *Breaker) open() {
+	b.transition(StateOpen)
+	b.openedAt = b.now()
+	b.failures = 0
+	b.probing = false
+	b.resetWindow()
+}
+
+// transition 切换状态并更新指标，调用方持有锁
+func (b *Breaker) transition(to State) {
+	from := b.state
+	b.state = to
+	metrics.SetCircuitBreakerState(b.name, float64(to))
+	metrics.RecordCircuitBreakerTransition(b.name, from.String(), to.String())
+}
+
+// observe 把一次 closed 状态下的调用结果写入失败率窗口
+func (b *Breaker) observe(failed bool) {
+	if b.window == nil {
+		return
+	}
+	if b.recorded == len(b.window) {
+		if b.window[b.next] {
+			b.failed--
+		}
+	} else {
+		b.recorded++
+	}
+	b.window[b.next] = failed
+	if failed {
+		b.failed++
+	}
+	b.next = (b.next + 1) % len(b.window)
+}
+
+// windowRate 返回窗口内的失败率，窗口未满时 full 为 false
+func (b *Breaker) windowRate() (rate float64, full bool) {
+	if b.window == nil || b.recorded == 0 {
+		return 0, false
+	}
+	return float64(b.failed) / float64(b.recorded), b.recorded == len(b.window)
+}
+
+func (b *Breaker) resetWindow() {
+	if b.window == nil {
+		return
+	}
+	clear(b.window)
+	b.next, b.recorded, b.failed = 0, 0, 0
+}
+
+func (b *Breaker) cooldownElapsed() bool {
+	return b.now().Sub(b.openedAt) >= b.cooldown
+}
+
+// Status 熔断器当前状态快照
+type Status struct {
+	Name  string `json:"name" example:"redis"`
+	State string `json:"state" example:"open" enums:"closed,open,half-open"`
+	// ConsecutiveFailures closed 状态下的连续失败次数
+	ConsecutiveFailures int `json:"consecutive_failures" example:"0"`
+	// FailureRate 失败率窗口内的失败占比，未启用失败率熔断时为 0
+	FailureRate float64 `json:"failure_rate" example:"0.5"`
+	// OpenedAt 最近一次熔断的时间，从未熔断时省略
+	OpenedAt *time.Time `json:"opened_at,omitempty"`
+	// ProbeAt open 状态下允许试探调用的时间
+	ProbeAt *time.Time `json:"probe_at,omitempty"`
+}
+
+// Status 返回当前状态快照
+func (b *Breaker) Status() Status {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	status := Status{Name: b.name, State: b.state.String(), ConsecutiveFailures: b.failures}
+	status.FailureRate, _ = b.windowRate()
+	if !b.openedAt.IsZero() {
+		openedAt := b.openedAt
+		status.OpenedAt = &openedAt
+	}
+	if b.state == StateOpen {
+		if b.cooldownElapsed() {
+			status.State = StateHalfOpen.String()
+		} else {
+			probeAt := b.openedAt.Add(b.cooldown)
+			status.ProbeAt = &probeAt
+		}
+	}
+	return status
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]*Breaker)
+)
+
+func register(b *Breaker) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[b.name] = b
+}
+
+// Breakers 返回所有已创建熔断器的状态，按名称排序
+func Breakers() []Status {
+	registryMu.RLock()
+	all := make([]*Breaker, 0, len(registry))
+	for _, b := range registry {
+		all = append(all, b)
+	}
+	registryMu.RUnlock()
+
+	statuses := make([]Status, 0, len(all))
+	for _, b := range all {
+		statuses = append(statuses, b.Status())
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	return statuses
+}
@@ -0,0 +1,227 @@
+package resilience
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errBackend = errors.New("connection refused")
+
+// newTestBreaker returns a breaker driven by a manual clock
+func newTestBreaker(threshold int, cooldown time.Duration) (*Breaker, *time.Time) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	b := NewBreaker("test", threshold, cooldown)
+	b.now = func() time.Time { return now }
+	return b, &now
+}
+
+func TestBreaker_OpensAfterThreshold(t *testing.T) {
+	b, _ := newTestBreaker(3, time.Minute)
+
+	for i := 0; i < 2; i++ {
+		assert.True(t, b.Allow())
+		b.Record(errBackend)
+		assert.Equal(t, StateClosed, b.State())
+	}
+
+	assert.True(t, b.Allow())
+	b.Record(errBackend)
+	assert.Equal(t, StateOpen, b.State())
+	assert.False(t, b.Allow())
+}
+
+func TestBreaker_SuccessResetsFailureCount(t *testing.T) {
+	b, _ := newTestBreaker(2, time.Minute)
+
+	b.Allow()
+	b.Record(errBackend)
+	b.Allow()
+	b.Record(nil)
+	b.Allow()
+	b.Record(errBackend)
+	assert.Equal(t, StateClosed, b.State(), "failures must be consecutive")
+}
+
+func TestBreaker_HalfOpensAfterCooldown(t *testing.T) {
+	b, now := newTestBreaker(1, time.Minute)
+
+	b.Allow()
+	b.Record(errBackend)
+	assert.False(t, b.Allow())
+
+	*now = now.Add(59 * time.Second)
+	assert.Equal(t, StateOpen, b.State())
+	assert.False(t, b.Allow())
+
+	*now = now.Add(time.Second)
+	assert.Equal(t, StateHalfOpen, b.State())
+	assert.True(t, b.Allow(), "first call after cooldown is a probe")
+	assert.False(t, b.Allow(), "only one probe at a time")
+
+	b.Record(nil)
+	assert.Equal(t, StateClosed, b.State())
+	assert.True(t, b.Allow())
+}
+
+func TestBreaker_FailedProbeReopens(t *testing.T) {
+	b, now := newTestBreaker(1, time.Minute)
+
+	b.Allow()
+	b.Record(errBackend)
+	*now = now.Add(time.Minute)
+
+	assert.True(t, b.Allow())
+	b.Record(errBackend)
+	assert.Equal(t, StateOpen, b.State())
+	assert.False(t, b.Allow())
+
+	// A new cooldown starts from the failed probe
+	*now = now.Add(time.Minute)
+	assert.True(t, b.Allow())
+}
+
+func TestNewBreaker_Defaults(t *testing.T) {
+	b := NewBreaker("redis", 0, 0)
+	assert.Equal(t, "redis", b.Name())
+	assert.Equal(t, DefaultFailureThreshold, b.threshold)
+	assert.Equal(t, DefaultCooldown, b.cooldown)
+	assert.Equal(t, "closed", b.State().String())
+}
+
+func TestBreaker_OpensOnFailureRate(t *testing.T) {
+	b, _ := newTestBreaker(100, time.Minute)
+	WithFailureRate(0.5, 4)(b)
+
+	// Alternating results never reach the consecutive threshold
+	for _, err := range []error{errBackend, nil, errBackend} {
+		assert.True(t, b.Allow())
+		b.Record(err)
+	}
+	assert.Equal(t, StateClosed, b.State(), "window of 4 is not full yet")
+
+	assert.True(t, b.Allow())
+	b.Record(nil)
+	assert.Equal(t, StateClosed, b.State(), "2 of 4 failed, but the rate is only checked on a failure")
+
+	assert.True(t, b.Allow())
+	b.Record(errBackend)
+	assert.Equal(t, StateOpen, b.State(), "last 4 calls: nil, errBackend, nil, errBackend")
+	assert.InDelta(t, 0, b.Status().FailureRate, 0.001, "the window restarts when the circuit opens")
+}
+
+func TestBreaker_FailureRateWindowSlides(t *testing.T) {
+	b, _ := newTestBreaker(100, time.Minute)
+	WithFailureRate(0.75, 4)(b)
+
+	for _, err := range []error{errBackend, errBackend, nil, nil, nil} {
+		b.Allow()
+		b.Record(err)
+	}
+	assert.InDelta(t, 0.25, b.Status().FailureRate, 0.001, "the oldest failure has left the window")
+
+	b.Allow()
+	b.Record(errBackend)
+	assert.Equal(t, StateClosed, b.State(), "2 of the last 4 calls failed")
+}
+
+func TestWithFailureRate_Disabled(t *testing.T) {
+	b := NewBreaker("rate-disabled", 3, time.Minute, WithFailureRate(0, 10))
+	assert.Nil(t, b.window)
+
+	b = NewBreaker("rate-default-window", 3, time.Minute, WithFailureRate(0.5, 0))
+	assert.Len(t, b.window, DefaultWindowSize)
+}
+
+func TestBreaker_TransitionTiming(t *testing.T) {
+	b, now := newTestBreaker(2, 30*time.Second)
+	start := *now
+
+	b.Allow()
+	b.Record(errBackend)
+	b.Allow()
+	b.Record(errBackend)
+
+	status := b.Status()
+	assert.Equal(t, "open", status.State)
+	require.NotNil(t, status.OpenedAt)
+	require.NotNil(t, status.ProbeAt)
+	assert.Equal(t, start, *status.OpenedAt)
+	assert.Equal(t, start.Add(30*time.Second), *status.ProbeAt)
+
+	*now = start.Add(30*time.Second - time.Nanosecond)
+	assert.False(t, b.Allow(), "still open just before the cooldown ends")
+
+	*now = start.Add(30 * time.Second)
+	assert.Equal(t, "half-open", b.Status().State)
+	assert.Nil(t, b.Status().ProbeAt)
+	assert.True(t, b.Allow())
+
+	// The failed probe at t+45s opens the circuit for a full cooldown from then
+	*now = start.Add(45 * time.Second)
+	b.Record(errBackend)
+	assert.Equal(t, start.Add(75*time.Second), *b.Status().ProbeAt)
+	*now = start.Add(74 * time.Second)
+	assert.False(t, b.Allow())
+	*now = start.Add(75 * time.Second)
+	assert.True(t, b.Allow())
+	b.Record(nil)
+	assert.Equal(t, "closed", b.Status().State)
+	assert.Nil(t, b.Status().ProbeAt)
+}
+
+func TestBreaker_TransitionMetrics(t *testing.T) {
+	b := NewBreaker("metrics-test", 1, time.Minute)
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	b.now = func() time.Time { return now }
+
+	scrape := func() string {
+		w := httptest.NewRecorder()
+		promhttp.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		return w.Body.String()
+	}
+	assert.Contains(t, scrape(), `circuit_breaker_state{name="metrics-test"} 0`)
+
+	b.Allow()
+	b.Record(errBackend)
+	body := scrape()
+	assert.Contains(t, body, `circuit_breaker_state{name="metrics-test"} 1`)
+	assert.Contains(t, body, `circuit_breaker_transitions_total{from="closed",name="metrics-test",to="open"} 1`)
+
+	now = now.Add(time.Minute)
+	b.Allow()
+	body = scrape()
+	assert.Contains(t, body, `circuit_breaker_state{name="metrics-test"} 2`)
+	assert.Contains(t, body, `circuit_breaker_transitions_total{from="open",name="metrics-test",to="half-open"} 1`)
+
+	b.Record(nil)
+	body = scrape()
+	assert.Contains(t, body, `circuit_breaker_state{name="metrics-test"} 0`)
+	assert.Contains(t, body, `circuit_breaker_transitions_total{from="half-open",name="metrics-test",to="closed"} 1`)
+}
+
+func TestBreakers_Registry(t *testing.T) {
+	NewBreaker("registry-b", 1, time.Minute)
+	first := NewBreaker("registry-a", 1, time.Minute)
+	first.Allow()
+	first.Record(errBackend)
+
+	// A breaker created again under the same name replaces the earlier one
+	NewBreaker("registry-a", 1, time.Minute)
+
+	var names []string
+	states := make(map[string]string)
+	for _, s := range Breakers() {
+		names = append(names, s.Name)
+		states[s.Name] = s.State
+	}
+	assert.IsIncreasing(t, names)
+	assert.Equal(t, "closed", states["registry-a"])
+	assert.Equal(t, "closed", states["registry-b"])
+}
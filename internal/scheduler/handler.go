@@ -0,0 +1,69 @@
+package scheduler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	apiErrors "github.com/yeegeek/uyou-go-api-starter/internal/errors"
+)
+
+// AdminBasePath 调度器管理接口的路径前缀，与 API 服务的管理员接口保持一致
+const AdminBasePath = "/api/v1/admin/scheduler"
+
+// Handler 调度器管理接口，挂载在调度器进程的 HTTP 服务上（见 Manager.EnableAdminAPI）
+// 任务只在调度器进程中注册，因此这些接口不在 API 服务的 OpenAPI 文档中
+type Handler struct {
+	manager *Manager
+}
+
+// NewHandler 创建调度器管理接口
+func NewHandler(manager *Manager) *Handler {
+	return &Handler{manager: manager}
+}
+
+// RegisterRoutes 在 rg 下注册管理接口，handlers 为认证、权限等中间件
+//
+//	POST /tasks/:name/run  立即执行任务并返回执行结果
+//	GET  /tasks/:name/runs 任务最近的执行记录，最新的在前
+func (h *Handler) RegisterRoutes(rg gin.IRouter, handlers ...gin.HandlerFunc) {
+	group := rg.Group("", handlers...)
+	group.POST("/tasks/:name/run", h.RunTask)
+	group.GET("/tasks/:name/runs", h.ListRuns)
+}
+
+// RunTask 立即执行任务，等待执行结束后返回结果；任务本身失败时仍返回 200，结果的 error 字段为失败原因
+// 任务不存在返回 404，任务正在执行（包括按计划触发的执行）返回 409
+func (h *Handler) RunTask(c *gin.Context) {
+	run, err := h.manager.RunNow(c.Param("name"))
+	if err != nil {
+		_ = c.Error(taskError(err))
+		return
+	}
+	c.JSON(http.StatusOK, apiErrors.Success(run))
+}
+
+// ListRuns 返回任务最近的执行记录，包括调度执行和手动执行
+func (h *Handler) ListRuns(c *gin.Context) {
+	runs, err := h.manager.TaskHistory(c.Param("name"))
+	if err != nil {
+		_ = c.Error(taskError(err))
+		return
+	}
+	c.JSON(http.StatusOK, apiErrors.Success(runs))
+}
+
+// taskError 将调度器错误转换为 API 错误
+func taskError(err error) error {
+	switch {
+	case errors.Is(err, ErrTaskNotFound):
+		return apiErrors.NotFound("Task not found")
+	case errors.Is(err, ErrTaskRunning):
+		return apiErrors.Conflict("Task is already running")
+	case errors.Is(err, ErrSchedulerNotRunning):
+		return apiErrors.ServiceUnavailable("Scheduler is not running")
+	default:
+		return apiErrors.InternalServerError(err)
+	}
+}
@@ -0,0 +1,210 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yeegeek/uyou-go-api-starter/internal/config"
+	"github.com/yeegeek/uyou-go-api-starter/internal/testutil"
+)
+
+// yearly 测试期间不会按计划触发的 cron 表达式，任务只通过 RunNow 执行
+const yearly = "0 0 0 1 1 *"
+
+// blockingTask 执行时通知 started，直到 release 关闭才返回
+type blockingTask struct {
+	name    string
+	started chan struct{}
+	release chan struct{}
+}
+
+func (t *blockingTask) Name() string { return t.name }
+
+func (t *blockingTask) Run(ctx context.Context) error {
+	close(t.started)
+	select {
+	case <-t.release:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func newStartedManager(t *testing.T, tasks ...Task) *Manager {
+	t.Helper()
+	manager := NewManager(&config.Config{}, slog.Default())
+	configs := make([]TaskConfig, 0, len(tasks))
+	for _, task := range tasks {
+		configs = append(configs, TaskConfig{Spec: yearly, Task: task})
+	}
+	require.NoError(t, manager.RegisterTasks(configs))
+	manager.Start()
+	t.Cleanup(manager.Stop)
+	return manager
+}
+
+func doAdminRequest(t *testing.T, router http.Handler, method, path string, out any) int {
+	t.Helper()
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+	if out != nil && w.Code == http.StatusOK {
+		var body struct {
+			Data json.RawMessage `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		require.NoError(t, json.Unmarshal(body.Data, out))
+	}
+	return w.Code
+}
+
+func TestHandler_RunTask(t *testing.T) {
+	ok := &MockTask{name: "ok"}
+	failing := &MockTask{name: "failing", runFunc: func(context.Context) error { return errors.New("boom") }}
+	manager := newStartedManager(t, ok, failing)
+
+	router := testutil.NewTestRouter(t)
+	NewHandler(manager).RegisterRoutes(router)
+
+	t.Run("known task runs immediately", func(t *testing.T) {
+		var run TaskRun
+		code := doAdminRequest(t, router, http.MethodPost, "/tasks/ok/run", &run)
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, 1, ok.runCount)
+		assert.Equal(t, "ok", run.Task)
+		assert.Equal(t, TriggerManual, run.Trigger)
+		assert.True(t, run.Succeeded())
+		assert.False(t, run.StartedAt.IsZero())
+	})
+
+	t.Run("task failure is reported in the result", func(t *testing.T) {
+		var run TaskRun
+		code := doAdminRequest(t, router, http.MethodPost, "/tasks/failing/run", &run)
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "boom", run.Error)
+	})
+
+	t.Run("unknown task", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, doAdminRequest(t, router, http.MethodPost, "/tasks/missing/run", nil))
+		assert.Equal(t, http.StatusNotFound, doAdminRequest(t, router, http.MethodGet, "/tasks/missing/runs", nil))
+	})
+
+	t.Run("manual run is recorded in history", func(t *testing.T) {
+		var runs []TaskRun
+		code := doAdminRequest(t, router, http.MethodGet, "/tasks/ok/runs", &runs)
+		require.Equal(t, http.StatusOK, code)
+		require.Len(t, runs, 1)
+		assert.Equal(t, TriggerManual, runs[0].Trigger)
+
+		statuses := manager.GetScheduler().TaskStatuses()
+		require.Len(t, statuses, 2)
+		assert.Equal(t, "boom", statuses[0].LastError, "手动执行同样更新任务状态")
+		assert.NotNil(t, statuses[1].LastRunAt)
+	})
+}
+
+func TestHandler_RunTask_Conflict(t *testing.T) {
+	task := &blockingTask{name: "slow", started: make(chan struct{}), release: make(chan struct{})}
+	manager := newStartedManager(t, task)
+	router := testutil.NewTestRouter(t)
+	NewHandler(manager).RegisterRoutes(router)
+
+	done := make(chan TaskRun)
+	go func() {
+		run, err := manager.RunNow("slow")
+		assert.NoError(t, err)
+		done <- run
+	}()
+	<-task.started
+
+	// 正在执行时，手动执行和按计划触发的执行都不会重叠
+	assert.Equal(t, http.StatusConflict, doAdminRequest(t, router, http.MethodPost, "/tasks/slow/run", nil))
+	_, err := manager.GetScheduler().execute(task, TriggerSchedule)
+	assert.ErrorIs(t, err, ErrTaskRunning)
+
+	close(task.release)
+	run := <-done
+	assert.True(t, run.Succeeded())
+
+	history, err := manager.TaskHistory("slow")
+	require.NoError(t, err)
+	assert.Len(t, history, 1, "被跳过的执行不记录")
+}
+
+func TestScheduler_RunNow(t *testing.T) {
+	t.Run("scheduler not running", func(t *testing.T) {
+		manager := NewManager(&config.Config{}, slog.Default())
+		require.NoError(t, manager.RegisterTasks([]TaskConfig{{Spec: yearly, Task: &MockTask{name: "task"}}}))
+
+		_, err := manager.RunNow("task")
+		assert.ErrorIs(t, err, ErrSchedulerNotRunning)
+
+		manager.Start()
+		_, err = manager.RunNow("task")
+		assert.NoError(t, err)
+
+		manager.Stop()
+		_, err = manager.RunNow("task")
+		assert.ErrorIs(t, err, ErrSchedulerNotRunning)
+	})
+
+	t.Run("history keeps the latest runs newest first", func(t *testing.T) {
+		runs := 0
+		task := &MockTask{name: "counter", runFunc: func(context.Context) error {
+			runs++
+			if runs == historyLimit+5 {
+				return errors.New("last")
+			}
+			return nil
+		}}
+		manager := newStartedManager(t, task)
+		for i := 0; i < historyLimit+5; i++ {
+			_, err := manager.RunNow("counter")
+			require.NoError(t, err)
+		}
+
+		history, err := manager.TaskHistory("counter")
+		require.NoError(t, err)
+		assert.Len(t, history, historyLimit)
+		assert.Equal(t, "last", history[0].Error)
+	})
+}
+
+func TestManager_EnableAdminAPI(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	manager := NewManager(&config.Config{}, slog.Default())
+	task := &MockTask{name: "task"}
+	require.NoError(t, manager.RegisterTasks([]TaskConfig{{Spec: yearly, Task: task}}))
+	manager.EnableAdminAPI(func(c *gin.Context) {
+		if c.GetHeader("Authorization") != "Bearer admin" {
+			c.AbortWithStatus(http.StatusUnauthorized)
+		}
+	})
+	require.NoError(t, manager.StartHealthServer("127.0.0.1:0"))
+	manager.Start()
+	defer manager.Stop()
+
+	url := "http://" + manager.HealthAddr() + AdminBasePath + "/tasks/task/run"
+	resp, err := http.Post(url, "application/json", nil)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode, "管理接口经过传入的中间件")
+	assert.Equal(t, 0, task.runCount)
+
+	req, err := http.NewRequest(http.MethodPost, url, nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer admin")
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, 1, task.runCount)
+}
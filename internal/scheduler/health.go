@@ -0,0 +1,182 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	apiErrors "github.com/yeegeek/uyou-go-api-starter/internal/errors"
+	"github.com/yeegeek/uyou-go-api-starter/internal/health"
+)
+
+// healthShutdownTimeout 停止调度器时等待健康检查请求处理完成的最长时间
+const healthShutdownTimeout = 5 * time.Second
+
+// TaskStatus 任务最近一次执行的情况，用于就绪探针
+type TaskStatus struct {
+	Name     string `json:"name"`
+	Critical bool   `json:"critical"`
+	// LastRunAt 最近一次开始执行的时间，尚未执行过时为空
+	LastRunAt *time.Time `json:"last_run_at,omitempty"`
+	// LastError 最近一次执行失败的原因，成功时为空
+	LastError string `json:"last_error,omitempty"`
+	// LastTimedOut 最近一次执行是否因超过 TaskConfig.Timeout 而失败
+	LastTimedOut bool `json:"last_timed_out,omitempty"`
+	// ConsecutiveFailures 连续失败次数，成功一次即清零
+	ConsecutiveFailures int `json:"consecutive_failures"`
+}
+
+// recordRun 记录一次任务执行结果，更新任务状态并追加执行记录
+func (s *Scheduler) recordRun(name, trigger string, startedAt time.Time, err error) TaskRun {
+	run := TaskRun{
+		Task:       name,
+		Trigger:    trigger,
+		StartedAt:  startedAt,
+		DurationMS: time.Since(startedAt).Milliseconds(),
+		TimedOut:   errors.Is(err, ErrTaskTimeout),
+	}
+	if err != nil {
+		run.Error = err.Error()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	status, ok := s.statuses[name]
+	if !ok {
+		return run
+	}
+	s.appendHistory(run)
+	status.LastRunAt = &startedAt
+	status.LastTimedOut = run.TimedOut
+	if err != nil {
+		status.LastError = run.Error
+		status.ConsecutiveFailures++
+		return run
+	}
+	status.LastError = ""
+	status.ConsecutiveFailures = 0
+	return run
+}
+
+// setCritical 将任务标记为关键任务，其连续失败会使就绪探针失败
+func (s *Scheduler) setCritical(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if status, ok := s.statuses[name]; ok {
+		status.Critical = true
+	}
+}
+
+// Started 返回调度器是否已启动且尚未停止
+func (s *Scheduler) Started() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.started
+}
+
+// TaskStatuses 返回所有任务的执行情况，按任务名排序
+func (s *Scheduler) TaskStatuses() []TaskStatus {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	statuses := make([]TaskStatus, 0, len(s.statuses))
+	for _, status := range s.statuses {
+		statuses = append(statuses, *status)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	return statuses
+}
+
+// schedulerChecker 就绪检查：调度器已启动，且没有关键任务连续失败达到阈值
+type schedulerChecker struct {
+	scheduler *Scheduler
+	threshold int
+}
+
+func (c *schedulerChecker) Name() string {
+	return "scheduler"
+}
+
+func (c *schedulerChecker) Check(_ context.Context) health.CheckResult {
+	statuses := c.scheduler.TaskStatuses()
+	if !c.scheduler.Started() {
+		return health.CheckResult{Status: health.CheckFail, Message: "Scheduler is not running", Details: statuses}
+	}
+
+	var failing []string
+	for _, status := range statuses {
+		if status.Critical && status.ConsecutiveFailures >= c.threshold {
+			failing = append(failing, status.Name)
+		}
+	}
+	if len(failing) > 0 {
+		return health.CheckResult{
+			Status:  health.CheckFail,
+			Message: "Critical tasks are failing: " + strings.Join(failing, ", "),
+			Details: statuses,
+		}
+	}
+	return health.CheckResult{Status: health.CheckPass, Message: "Scheduler is running", Details: statuses}
+}
+
+// HealthChecker 返回调度器的就绪检查，可与其他 health.Checker 一起使用
+func (m *Manager) HealthChecker() health.Checker {
+	return &schedulerChecker{scheduler: m.scheduler, threshold: m.config.Scheduler.GetCriticalFailureThreshold()}
+}
+
+// StartHealthServer 在 addr 上启动健康检查服务，提供 /health/live 和 /health/ready，调用过 EnableAdminAPI 时同时提供管理接口；Stop 时一并关闭
+func (m *Manager) StartHealthServer(addr string) error {
+	service := health.NewService([]health.Checker{m.HealthChecker()}, m.config.App.Version, m.config.App.Environment)
+	handler := health.NewHandler(service)
+
+	router := gin.New()
+	router.Use(gin.Recovery())
+	router.GET("/health/live", handler.Live)
+	router.GET("/health/ready", handler.Ready)
+	if m.adminMiddleware != nil {
+		admin := router.Group(AdminBasePath, apiErrors.ErrorHandler())
+		NewHandler(m).RegisterRoutes(admin, m.adminMiddleware...)
+	}
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	m.healthListener = listener
+	m.healthServer = &http.Server{Handler: router, ReadHeaderTimeout: 5 * time.Second}
+	go func() {
+		if err := m.healthServer.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			m.logger.Error("调度器健康检查服务异常退出", "error", err)
+		}
+	}()
+
+	m.logger.Info("调度器健康检查服务已启动", "addr", listener.Addr().String())
+	return nil
+}
+
+// HealthAddr 返回健康检查服务实际监听的地址，未启动时为空
+func (m *Manager) HealthAddr() string {
+	if m.healthListener == nil {
+		return ""
+	}
+	return m.healthListener.Addr().String()
+}
+
+// stopHealthServer 关闭健康检查服务
+func (m *Manager) stopHealthServer() {
+	if m.healthServer == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), healthShutdownTimeout)
+	defer cancel()
+	if err := m.healthServer.Shutdown(ctx); err != nil {
+		m.logger.Error("关闭调度器健康检查服务失败", "error", err)
+	}
+	m.healthServer = nil
+	m.healthListener = nil
+}
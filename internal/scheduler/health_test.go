@@ -0,0 +1,91 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yeegeek/uyou-go-api-starter/internal/config"
+	"github.com/yeegeek/uyou-go-api-starter/internal/health"
+)
+
+// switchTask 每秒执行一次，fail 为 true 时返回错误
+type switchTask struct {
+	name string
+	fail atomic.Bool
+}
+
+func (t *switchTask) Name() string {
+	return t.name
+}
+
+func (t *switchTask) Run(ctx context.Context) error {
+	if t.fail.Load() {
+		return errors.New("boom")
+	}
+	return nil
+}
+
+func getHealth(t *testing.T, url string) (int, health.HealthResponse) {
+	t.Helper()
+	resp, err := http.Get(url)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	var body health.HealthResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	return resp.StatusCode, body
+}
+
+func TestManager_HealthServer(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := &config.Config{Scheduler: config.SchedulerConfig{CriticalFailureThreshold: 2}}
+	manager := NewManager(cfg, slog.Default())
+
+	critical := &switchTask{name: "critical"}
+	optional := &switchTask{name: "optional"}
+	optional.fail.Store(true)
+	require.NoError(t, manager.RegisterTasks([]TaskConfig{
+		{Spec: "*/1 * * * * *", Task: critical, Critical: true},
+		{Spec: "*/1 * * * * *", Task: optional},
+	}))
+
+	require.NoError(t, manager.StartHealthServer("127.0.0.1:0"))
+	base := "http://" + manager.HealthAddr()
+
+	code, _ := getHealth(t, base+"/health/live")
+	assert.Equal(t, http.StatusOK, code)
+	code, body := getHealth(t, base+"/health/ready")
+	assert.Equal(t, http.StatusServiceUnavailable, code, "not ready before the scheduler starts")
+	assert.Equal(t, health.CheckFail, body.Checks["scheduler"].Status)
+
+	manager.Start()
+	code, _ = getHealth(t, base+"/health/ready")
+	assert.Equal(t, http.StatusOK, code, "failing optional tasks do not affect readiness")
+
+	critical.fail.Store(true)
+	require.Eventually(t, func() bool {
+		code, _ := getHealth(t, base+"/health/ready")
+		return code == http.StatusServiceUnavailable
+	}, 5*time.Second, 100*time.Millisecond, "consecutive critical failures fail readiness")
+	_, body = getHealth(t, base+"/health/ready")
+	assert.Contains(t, body.Checks["scheduler"].Message, "critical")
+
+	critical.fail.Store(false)
+	require.Eventually(t, func() bool {
+		code, _ := getHealth(t, base+"/health/ready")
+		return code == http.StatusOK
+	}, 3*time.Second, 100*time.Millisecond, "a successful run restores readiness")
+
+	manager.Stop()
+	_, err := http.Get(base + "/health/live")
+	assert.Error(t, err, "the health server stops with the manager")
+}
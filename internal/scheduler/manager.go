@@ -3,6 +3,11 @@ package scheduler
 
 import (
 	"log/slog"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
 
 	"github.com/yeegeek/uyou-go-api-starter/internal/config"
 )
@@ -12,6 +17,12 @@ type Manager struct {
 	scheduler *Scheduler
 	config    *config.Config
 	logger    *slog.Logger
+
+	// healthServer 由 StartHealthServer 启动的健康检查服务
+	healthServer   *http.Server
+	healthListener net.Listener
+	// adminMiddleware 非 nil 时健康检查服务同时提供管理接口，见 EnableAdminAPI
+	adminMiddleware gin.HandlersChain
 }
 
 // NewManager 创建任务管理器
@@ -36,6 +47,10 @@ func (m *Manager) RegisterTasks(tasks []TaskConfig) error {
 			)
 			return err
 		}
+		if taskConfig.Critical {
+			m.scheduler.setCritical(taskConfig.Task.Name())
+		}
+		m.scheduler.setTimeout(taskConfig.Task.Name(), taskConfig.Timeout)
 	}
 	return nil
 }
@@ -45,9 +60,26 @@ func (m *Manager) Start() {
 	m.scheduler.Start()
 }
 
-// Stop 停止任务管理器
+// Stop 停止任务管理器，等待正在执行的任务结束后关闭健康检查服务
 func (m *Manager) Stop() {
 	m.scheduler.Stop()
+	m.stopHealthServer()
+}
+
+// RunNow 立即执行已注册的任务并返回执行结果，见 Scheduler.RunNow
+func (m *Manager) RunNow(name string) (TaskRun, error) {
+	return m.scheduler.RunNow(name)
+}
+
+// TaskHistory 返回任务最近的执行记录，最新的在前
+func (m *Manager) TaskHistory(name string) ([]TaskRun, error) {
+	return m.scheduler.TaskHistory(name)
+}
+
+// EnableAdminAPI 在 StartHealthServer 启动的服务上挂载管理接口（AdminBasePath 下），需在其之前调用
+// handlers 必须包含认证和管理员权限校验，未调用时不提供管理接口
+func (m *Manager) EnableAdminAPI(handlers ...gin.HandlerFunc) {
+	m.adminMiddleware = append(gin.HandlersChain{}, handlers...)
 }
 
 // GetScheduler 获取调度器实例
@@ -59,4 +91,8 @@ func (m *Manager) GetScheduler() *Scheduler {
 type TaskConfig struct {
 	Spec string // cron 表达式
 	Task Task   // 任务实例
+	// Critical 关键任务连续失败达到 scheduler.critical_failure_threshold 次后就绪探针返回 503
+	Critical bool
+	// Timeout 单次执行的最长时间，超过后取消任务的 context 并将本次执行记为超时失败；0 表示不限制
+	Timeout time.Duration
 }
@@ -1,11 +1,15 @@
 package scheduler
 
 import (
+	"context"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/yeegeek/uyou-go-api-starter/internal/config"
+	"github.com/yeegeek/uyou-go-api-starter/internal/scheduler/tasks"
 	"log/slog"
 )
 
@@ -85,3 +89,86 @@ func TestManager_GetScheduler(t *testing.T) {
 	assert.NotNil(t, scheduler)
 	assert.Equal(t, manager.scheduler, scheduler)
 }
+
+func TestManager_Stop_CancelsContextAwareTask(t *testing.T) {
+	cfg := &config.Config{Scheduler: config.SchedulerConfig{DrainTimeout: 100 * time.Millisecond}}
+	manager := NewManager(cfg, slog.Default())
+
+	var runs, skipped atomic.Int32
+	started := make(chan struct{})
+	task := tasks.NewCleanupTask(slog.Default(),
+		tasks.WithPruner("blocking", func(ctx context.Context) (int64, error) {
+			// 只让第一次执行阻塞
+			if runs.Add(1) == 1 {
+				close(started)
+				<-ctx.Done()
+				return 0, ctx.Err()
+			}
+			return 0, nil
+		}),
+		tasks.WithPruner("after", func(ctx context.Context) (int64, error) {
+			if ctx.Err() != nil {
+				skipped.Add(1)
+			}
+			return 0, nil
+		}),
+	)
+	require.NoError(t, manager.RegisterTasks([]TaskConfig{{Spec: "*/1 * * * * *", Task: task}}))
+	manager.Start()
+
+	select {
+	case <-started:
+	case <-time.After(3 * time.Second):
+		t.Fatal("task did not start")
+	}
+	stopping := time.Now()
+	manager.Stop()
+	assert.Less(t, time.Since(stopping), time.Second, "Stop returns shortly after the drain timeout")
+
+	status := manager.GetScheduler().TaskStatuses()[0]
+	assert.Contains(t, status.LastError, context.Canceled.Error())
+	assert.Zero(t, skipped.Load(), "pruners after the cancellation are not run")
+}
+
+// sleepTask 睡眠 d 后返回，ctx 取消时提前返回 ctx.Err()
+type sleepTask struct {
+	name     string
+	d        time.Duration
+	canceled chan struct{}
+}
+
+func (t *sleepTask) Name() string { return t.name }
+
+func (t *sleepTask) Run(ctx context.Context) error {
+	select {
+	case <-time.After(t.d):
+		return nil
+	case <-ctx.Done():
+		close(t.canceled)
+		return ctx.Err()
+	}
+}
+
+func TestManager_TaskTimeout(t *testing.T) {
+	manager := NewManager(&config.Config{}, slog.Default())
+	task := &sleepTask{name: "slow", d: time.Minute, canceled: make(chan struct{})}
+	require.NoError(t, manager.RegisterTasks([]TaskConfig{
+		{Spec: "*/1 * * * * *", Task: task, Timeout: 50 * time.Millisecond},
+	}))
+	manager.Start()
+	defer manager.Stop()
+
+	select {
+	case <-task.canceled:
+	case <-time.After(3 * time.Second):
+		t.Fatal("task was not cancelled after its timeout")
+	}
+
+	require.Eventually(t, func() bool {
+		return manager.GetScheduler().TaskStatuses()[0].LastRunAt != nil
+	}, time.Second, 10*time.Millisecond)
+	status := manager.GetScheduler().TaskStatuses()[0]
+	assert.True(t, status.LastTimedOut)
+	assert.Contains(t, status.LastError, ErrTaskTimeout.Error())
+	assert.Equal(t, 1, status.ConsecutiveFailures)
+}
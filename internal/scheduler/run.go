@@ -0,0 +1,145 @@
+package scheduler
+
+import (
+	"errors"
+	"time"
+)
+
+// historyLimit 每个任务保留的最近执行记录条数
+const historyLimit = 20
+
+// 任务的触发方式
+const (
+	// TriggerSchedule 按 cron 表达式调度执行
+	TriggerSchedule = "schedule"
+	// TriggerManual 通过 RunNow 手动执行
+	TriggerManual = "manual"
+)
+
+var (
+	// ErrTaskNotFound 任务未注册
+	ErrTaskNotFound = errors.New("task not found")
+	// ErrTaskRunning 任务正在执行，同一任务不会并发执行
+	ErrTaskRunning = errors.New("task is already running")
+	// ErrSchedulerNotRunning 调度器未启动或已停止
+	ErrSchedulerNotRunning = errors.New("scheduler is not running")
+)
+
+// TaskRun 一次任务执行的结果
+type TaskRun struct {
+	Task string `json:"task"`
+	// Trigger 为 schedule 或 manual
+	Trigger    string    `json:"trigger"`
+	StartedAt  time.Time `json:"started_at"`
+	DurationMS int64     `json:"duration_ms"`
+	// Error 执行失败的原因，成功时为空
+	Error string `json:"error,omitempty"`
+	// TimedOut 是否因超过 TaskConfig.Timeout 而失败
+	TimedOut bool `json:"timed_out,omitempty"`
+}
+
+// Succeeded 返回本次执行是否成功
+func (r TaskRun) Succeeded() bool {
+	return r.Error == ""
+}
+
+// execute 执行一次任务并记录结果；同一任务正在执行时不执行，返回 ErrTaskRunning
+// 调度执行和手动执行共用该并发保护，手动执行不会与调度执行重叠
+func (s *Scheduler) execute(task Task, trigger string) (TaskRun, error) {
+	name := task.Name()
+	s.mu.Lock()
+	if s.running[name] {
+		s.mu.Unlock()
+		return TaskRun{}, ErrTaskRunning
+	}
+	s.running[name] = true
+	ctx := s.runCtx
+	timeout := s.timeouts[name]
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.running, name)
+		s.mu.Unlock()
+	}()
+
+	startTime := time.Now()
+	s.logger.Info("定时任务开始执行",
+		"task", name,
+		"trigger", trigger,
+		"time", startTime.Format(time.RFC3339),
+	)
+
+	// 执行任务
+	err := s.runWithTimeout(ctx, task, timeout)
+	run := s.recordRun(name, trigger, startTime, err)
+	if errors.Is(err, ErrTaskTimeout) {
+		s.logger.Error("定时任务执行超时",
+			"task", name,
+			"trigger", trigger,
+			"timeout", timeout,
+			"error", err,
+			"duration", time.Since(startTime),
+		)
+	} else if err != nil {
+		s.logger.Error("定时任务执行失败",
+			"task", name,
+			"trigger", trigger,
+			"error", err,
+			"duration", time.Since(startTime),
+		)
+	} else {
+		s.logger.Info("定时任务执行成功",
+			"task", name,
+			"trigger", trigger,
+			"duration", time.Since(startTime),
+		)
+	}
+	return run, nil
+}
+
+// RunNow 立即执行已注册的任务并等待其完成，返回执行结果；任务本身失败时结果的 Error 非空，返回的 error 为 nil
+// 任务不存在返回 ErrTaskNotFound，任务正在执行（无论是调度执行还是手动执行）返回 ErrTaskRunning，
+// 调度器未启动返回 ErrSchedulerNotRunning
+func (s *Scheduler) RunNow(name string) (TaskRun, error) {
+	task, ok := s.tasks[name]
+	if !ok {
+		return TaskRun{}, ErrTaskNotFound
+	}
+
+	// started 与 manualRuns.Add 在同一把锁内检查，Stop 置 started 为 false 后不会再有新的手动执行
+	s.mu.Lock()
+	if !s.started {
+		s.mu.Unlock()
+		return TaskRun{}, ErrSchedulerNotRunning
+	}
+	// Stop 等待手动执行结束，与调度执行一样受 drain_timeout 约束
+	s.manualRuns.Add(1)
+	s.mu.Unlock()
+	defer s.manualRuns.Done()
+
+	return s.execute(task, TriggerManual)
+}
+
+// TaskHistory 返回任务最近的执行记录，最新的在前
+func (s *Scheduler) TaskHistory(name string) ([]TaskRun, error) {
+	if _, ok := s.tasks[name]; !ok {
+		return nil, ErrTaskNotFound
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	runs := s.history[name]
+	history := make([]TaskRun, len(runs))
+	for i, run := range runs {
+		history[len(runs)-1-i] = run
+	}
+	return history, nil
+}
+
+// appendHistory 追加执行记录，超过 historyLimit 时丢弃最早的记录；调用方持有 s.mu
+func (s *Scheduler) appendHistory(run TaskRun) {
+	runs := append(s.history[run.Task], run)
+	if len(runs) > historyLimit {
+		runs = runs[len(runs)-historyLimit:]
+	}
+	s.history[run.Task] = runs
+}
@@ -5,8 +5,10 @@ package scheduler
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"sync"
 	"time"
 
 	"github.com/robfig/cron/v3"
@@ -21,12 +23,34 @@ type Task interface {
 	Run(ctx context.Context) error
 }
 
+// ErrTaskTimeout 任务执行超过 TaskConfig.Timeout 时记录的错误
+var ErrTaskTimeout = errors.New("task timed out")
+
 // Scheduler 定时任务调度器
 type Scheduler struct {
 	cron   *cron.Cron
 	config *config.Config
 	logger *slog.Logger
 	tasks  map[string]Task
+
+	// drainTimeout 停止时等待正在执行的任务完成的最长时间
+	drainTimeout time.Duration
+
+	// mu 保护以下字段，任务在 cron 的 goroutine 中执行，健康检查并发读取
+	mu       sync.RWMutex
+	started  bool
+	statuses map[string]*TaskStatus
+	// timeouts 每个任务单次执行的超时时间，未设置表示不限制
+	timeouts map[string]time.Duration
+	// running 正在执行的任务，同一任务的调度执行和手动执行不会重叠
+	running map[string]bool
+	// history 每个任务最近 historyLimit 次执行记录，按时间先后排列
+	history map[string][]TaskRun
+	// manualRuns 正在进行的手动执行，Stop 时与调度执行一起等待
+	manualRuns sync.WaitGroup
+	// runCtx 传给每次任务执行，停止时等待超过 drainTimeout 后取消
+	runCtx     context.Context
+	cancelRuns context.CancelFunc
 }
 
 // slogLoggerAdapter 适配 slog.Logger 到 cron.Logger 接口
@@ -48,11 +72,20 @@ func NewScheduler(cfg *config.Config, logger *slog.Logger) *Scheduler {
 		cron.WithLogger(cron.VerbosePrintfLogger(adapter)), // 使用自定义日志适配器
 	)
 
+	runCtx, cancelRuns := context.WithCancel(context.Background())
 	return &Scheduler{
 		cron:   c,
 		config: cfg,
 		logger: logger,
 		tasks:  make(map[string]Task),
+
+		runCtx:       runCtx,
+		cancelRuns:   cancelRuns,
+		drainTimeout: cfg.Scheduler.GetDrainTimeout(),
+		statuses:     make(map[string]*TaskStatus),
+		timeouts:     make(map[string]time.Duration),
+		running:      make(map[string]bool),
+		history:      make(map[string][]TaskRun),
 	}
 }
 
@@ -69,29 +102,14 @@ func NewScheduler(cfg *config.Config, logger *slog.Logger) *Scheduler {
 func (s *Scheduler) AddTask(spec string, task Task) error {
 	// 记录任务
 	s.tasks[task.Name()] = task
+	s.mu.Lock()
+	s.statuses[task.Name()] = &TaskStatus{Name: task.Name()}
+	s.mu.Unlock()
 
-	// 包装任务执行逻辑
+	// 包装任务执行逻辑；上一次执行（包括手动执行）尚未结束时跳过本次调度
 	_, err := s.cron.AddFunc(spec, func() {
-		ctx := context.Background()
-		startTime := time.Now()
-
-		s.logger.Info("定时任务开始执行",
-			"task", task.Name(),
-			"time", startTime.Format(time.RFC3339),
-		)
-
-		// 执行任务
-		if err := task.Run(ctx); err != nil {
-			s.logger.Error("定时任务执行失败",
-				"task", task.Name(),
-				"error", err,
-				"duration", time.Since(startTime),
-			)
-		} else {
-			s.logger.Info("定时任务执行成功",
-				"task", task.Name(),
-				"duration", time.Since(startTime),
-			)
+		if _, err := s.execute(task, TriggerSchedule); errors.Is(err, ErrTaskRunning) {
+			s.logger.Warn("上一次执行尚未结束，跳过本次调度", "task", task.Name())
 		}
 	})
 
@@ -112,19 +130,84 @@ func (s *Scheduler) AddTask(spec string, task Task) error {
 	return nil
 }
 
+// runWithTimeout 执行任务，timeout 大于 0 时为本次执行的 context 设置截止时间
+// 超时后任务的 context 被取消；无论任务返回什么，本次执行都记为 ErrTaskTimeout 失败
+func (s *Scheduler) runWithTimeout(ctx context.Context, task Task, timeout time.Duration) error {
+	if timeout <= 0 {
+		return task.Run(ctx)
+	}
+	runCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	err := task.Run(runCtx)
+	// 调度器停止时取消的是父 context，不属于超时
+	if errors.Is(runCtx.Err(), context.DeadlineExceeded) && ctx.Err() == nil {
+		if err == nil || errors.Is(err, context.DeadlineExceeded) {
+			return fmt.Errorf("%w after %s", ErrTaskTimeout, timeout)
+		}
+		return fmt.Errorf("%w after %s: %w", ErrTaskTimeout, timeout, err)
+	}
+	return err
+}
+
+// setTimeout 设置任务单次执行的超时时间，0 表示不限制
+func (s *Scheduler) setTimeout(name string, timeout time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if timeout > 0 {
+		s.timeouts[name] = timeout
+	} else {
+		delete(s.timeouts, name)
+	}
+}
+
 // Start 启动调度器
 func (s *Scheduler) Start() {
 	s.logger.Info("定时任务调度器启动",
 		"tasks_count", len(s.tasks),
 	)
+	s.mu.Lock()
+	// WHY: A previous Stop cancels the run context, so a restarted scheduler needs a fresh one
+	if s.runCtx.Err() != nil {
+		s.runCtx, s.cancelRuns = context.WithCancel(context.Background())
+	}
+	s.started = true
+	s.mu.Unlock()
 	s.cron.Start()
 }
 
 // Stop 停止调度器（优雅关闭）
+//
+// 不再触发新的执行，并等待正在执行的任务完成；超过 scheduler.drain_timeout 后取消任务的 context，
+// 再等待任务响应取消后返回
 func (s *Scheduler) Stop() {
 	s.logger.Info("定时任务调度器停止中...")
-	ctx := s.cron.Stop()
-	<-ctx.Done()
+	s.mu.Lock()
+	s.started = false
+	s.mu.Unlock()
+	cronDone := s.cron.Stop()
+	s.mu.RLock()
+	cancelRuns := s.cancelRuns
+	s.mu.RUnlock()
+
+	// 调度执行和手动执行都结束后关闭
+	done := make(chan struct{})
+	go func() {
+		<-cronDone.Done()
+		s.manualRuns.Wait()
+		close(done)
+	}()
+
+	timer := time.NewTimer(s.drainTimeout)
+	defer timer.Stop()
+	select {
+	case <-done:
+	case <-timer.C:
+		s.logger.Warn("等待任务完成超时，取消正在执行的任务", "drain_timeout", s.drainTimeout)
+		cancelRuns()
+		<-done
+	}
+	cancelRuns()
 	s.logger.Info("定时任务调度器已停止")
 }
 
@@ -3,6 +3,7 @@ package scheduler
 import (
 	"context"
 	"errors"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -236,3 +237,94 @@ func TestScheduler_Stop_GracefulShutdown(t *testing.T) {
 	// 停止应该很快完成（优雅关闭）
 	assert.Less(t, stopDuration, 2*time.Second)
 }
+
+func TestScheduler_Stop_DrainsRunningTasks(t *testing.T) {
+	tests := []struct {
+		name         string
+		drainTimeout time.Duration
+		taskDuration time.Duration
+		wantErr      error
+	}{
+		{name: "completes within the drain window", drainTimeout: 2 * time.Second, taskDuration: 300 * time.Millisecond},
+		{name: "cancelled after the drain window", drainTimeout: 100 * time.Millisecond, taskDuration: time.Minute, wantErr: context.Canceled},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{Scheduler: config.SchedulerConfig{DrainTimeout: tt.drainTimeout}}
+			scheduler := NewScheduler(cfg, slog.Default())
+
+			var runs atomic.Int32
+			started := make(chan struct{})
+			finished := make(chan error, 1)
+			task := &MockTask{
+				name: "slow-task",
+				runFunc: func(ctx context.Context) error {
+					// 只观察第一次执行
+					if runs.Add(1) > 1 {
+						return nil
+					}
+					close(started)
+					var err error
+					select {
+					case <-time.After(tt.taskDuration):
+					case <-ctx.Done():
+						err = ctx.Err()
+					}
+					finished <- err
+					return err
+				},
+			}
+
+			_ = scheduler.AddTask("*/1 * * * * *", task)
+			scheduler.Start()
+			select {
+			case <-started:
+			case <-time.After(3 * time.Second):
+				t.Fatal("task did not start")
+			}
+
+			stopStart := time.Now()
+			scheduler.Stop()
+			stopDuration := time.Since(stopStart)
+
+			// Stop 返回时任务已经结束
+			select {
+			case err := <-finished:
+				assert.ErrorIs(t, err, tt.wantErr)
+			default:
+				t.Fatal("Stop returned while the task was still running")
+			}
+			assert.Less(t, stopDuration, tt.drainTimeout+time.Second)
+		})
+	}
+}
+
+func TestScheduler_RunWithTimeout(t *testing.T) {
+	s := NewScheduler(&config.Config{}, slog.Default())
+
+	tests := []struct {
+		name        string
+		task        Task
+		timeout     time.Duration
+		wantTimeout bool
+		wantErr     bool
+	}{
+		{name: "zero timeout means no limit", task: &sleepTask{name: "t", d: 20 * time.Millisecond}, timeout: 0},
+		{name: "finishes within timeout", task: &sleepTask{name: "t", d: time.Millisecond}, timeout: time.Second},
+		{name: "exceeds timeout", task: &sleepTask{name: "t", d: time.Minute, canceled: make(chan struct{})},
+			timeout: 10 * time.Millisecond, wantTimeout: true, wantErr: true},
+		{name: "ignores cancellation but still timed out", task: &MockTask{name: "t", runFunc: func(ctx context.Context) error {
+			time.Sleep(30 * time.Millisecond)
+			return nil
+		}}, timeout: 10 * time.Millisecond, wantTimeout: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := s.runWithTimeout(context.Background(), tt.task, tt.timeout)
+			assert.Equal(t, tt.wantErr, err != nil, "error: %v", err)
+			assert.Equal(t, tt.wantTimeout, errors.Is(err, ErrTaskTimeout))
+		})
+	}
+}
@@ -3,21 +3,48 @@ package tasks
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log/slog"
 )
 
+// PruneFunc 删除一类过期数据并返回删除的行数
+type PruneFunc func(ctx context.Context) (int64, error)
+
+type pruner struct {
+	name string
+	fn   PruneFunc
+}
+
+// CleanupOption 配置清理任务
+type CleanupOption func(*CleanupTask)
+
+// WithPruner 注册一类过期数据的清理函数，name 用于日志
+func WithPruner(name string, fn PruneFunc) CleanupOption {
+	return func(t *CleanupTask) {
+		if fn != nil {
+			t.pruners = append(t.pruners, pruner{name: name, fn: fn})
+		}
+	}
+}
+
 // CleanupTask 清理任务：定期清理过期数据
 //
 // 示例：每小时执行一次，清理过期的刷新令牌、验证码等
 type CleanupTask struct {
-	logger *slog.Logger
+	logger  *slog.Logger
+	pruners []pruner
 }
 
 // NewCleanupTask 创建清理任务
-func NewCleanupTask(logger *slog.Logger) *CleanupTask {
-	return &CleanupTask{
+func NewCleanupTask(logger *slog.Logger, opts ...CleanupOption) *CleanupTask {
+	t := &CleanupTask{
 		logger: logger,
 	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
 }
 
 // Name 返回任务名称
@@ -25,16 +52,33 @@ func (t *CleanupTask) Name() string {
 	return "cleanup_expired_data"
 }
 
-// Run 执行清理任务
+// Run 执行清理任务，某一类数据清理失败不影响其余清理函数执行；ctx 取消后不再执行剩余的清理函数
 func (t *CleanupTask) Run(ctx context.Context) error {
 	t.logger.Info("开始清理过期数据")
 
-	// TODO: 实现具体的清理逻辑
+	var errs []error
+	for _, p := range t.pruners {
+		if err := ctx.Err(); err != nil {
+			t.logger.Warn("清理任务已取消", "remaining_from", p.name, "error", err)
+			return errors.Join(append(errs, err)...)
+		}
+		deleted, err := p.fn(ctx)
+		if err != nil {
+			t.logger.Error("清理过期数据失败", "target", p.name, "error", err)
+			errs = append(errs, fmt.Errorf("%s: %w", p.name, err))
+			continue
+		}
+		t.logger.Info("已清理过期数据", "target", p.name, "deleted", deleted)
+	}
+
+	// TODO: 实现其余的清理逻辑
 	// 1. 清理过期的刷新令牌
 	// 2. 清理过期的验证码
-	// 3. 清理过期的会话数据
-	// 4. 清理临时文件
+	// 3. 清理临时文件
 
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
 	t.logger.Info("过期数据清理完成")
 	return nil
 }
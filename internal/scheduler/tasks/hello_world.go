@@ -23,8 +23,11 @@ func (t *HelloWorldTask) Name() string {
 	return "hello_world"
 }
 
-// Run 执行任务
+// Run 执行任务，ctx 已取消时直接返回
 func (t *HelloWorldTask) Run(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 	t.logger.Info("Hello World")
 	return nil
 }
@@ -25,11 +25,14 @@ func (t *StatisticsTask) Name() string {
 	return "daily_statistics"
 }
 
-// Run 执行统计任务
+// Run 执行统计任务，ctx 已取消时直接返回
 func (t *StatisticsTask) Run(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 	t.logger.Info("开始生成每日统计数据")
 
-	// TODO: 实现具体的统计逻辑
+	// TODO: 实现具体的统计逻辑，每一步的查询都应传入 ctx，以便停止调度器时能被取消
 	// 1. 统计新增用户数
 	// 2. 统计活跃用户数
 	// 3. 统计消息发送量
@@ -0,0 +1,76 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/swaggo/swag"
+
+	"github.com/yeegeek/uyou-go-api-starter/internal/config"
+	"github.com/yeegeek/uyou-go-api-starter/internal/errors"
+)
+
+// openAPIHandler 返回指定文档实例的 OpenAPI 规范
+// host/schemes 在运行时由配置填充，未配置时使用当前请求的 Host 和协议
+// 注释中的路由以默认前缀 /api 生成，basePath 不同时改写 paths 以保持与实际路由一致
+func openAPIHandler(instanceName string, cfg config.SwaggerConfig, basePath string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		raw, err := swag.ReadDoc(instanceName)
+		if err != nil {
+			_ = c.Error(errors.NotFound("API specification not available"))
+			return
+		}
+
+		var doc map[string]any
+		if err := json.Unmarshal([]byte(raw), &doc); err != nil {
+			_ = c.Error(errors.InternalServerError(fmt.Errorf("failed to parse API specification: %w", err)))
+			return
+		}
+
+		doc["host"] = cfg.Host
+		if cfg.Host == "" {
+			doc["host"] = c.Request.Host
+		}
+
+		schemes := cfg.Schemes
+		if len(schemes) == 0 {
+			schemes = []string{requestScheme(c)}
+		}
+		doc["schemes"] = schemes
+
+		if paths, ok := doc["paths"].(map[string]any); ok && basePath != defaultBasePath {
+			doc["paths"] = rebasePaths(paths, basePath)
+		}
+
+		c.JSON(http.StatusOK, doc)
+	}
+}
+
+// defaultBasePath swag 注释中 @Router 使用的路由前缀
+const defaultBasePath = "/api"
+
+// rebasePaths 将以默认前缀开头的路径替换为配置的前缀
+func rebasePaths(paths map[string]any, basePath string) map[string]any {
+	rebased := make(map[string]any, len(paths))
+	for p, item := range paths {
+		if strings.HasPrefix(p, defaultBasePath+"/") {
+			p = basePath + strings.TrimPrefix(p, defaultBasePath)
+		}
+		rebased[p] = item
+	}
+	return rebased
+}
+
+// requestScheme 推断请求使用的协议，优先使用反向代理设置的 X-Forwarded-Proto
+func requestScheme(c *gin.Context) string {
+	if proto := c.GetHeader("X-Forwarded-Proto"); proto != "" {
+		return strings.ToLower(strings.TrimSpace(strings.Split(proto, ",")[0]))
+	}
+	if c.Request.TLS != nil {
+		return "https"
+	}
+	return "http"
+}
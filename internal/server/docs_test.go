@@ -0,0 +1,150 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-openapi/spec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/swaggo/swag"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"github.com/yeegeek/uyou-go-api-starter/internal/auth"
+	"github.com/yeegeek/uyou-go-api-starter/internal/config"
+	"github.com/yeegeek/uyou-go-api-starter/internal/errors"
+	"github.com/yeegeek/uyou-go-api-starter/internal/featureflags"
+	"github.com/yeegeek/uyou-go-api-starter/internal/friend"
+	"github.com/yeegeek/uyou-go-api-starter/internal/user"
+)
+
+const testSpec = `{
+	"swagger": "2.0",
+	"info": {"title": "Test API", "version": "1.0"},
+	"basePath": "/",
+	"paths": {
+		"/api/v1/auth/refresh": {
+			"post": {"responses": {"200": {"description": "OK"}}}
+		}
+	}
+}`
+
+type fakeDoc struct{}
+
+func (fakeDoc) ReadDoc() string { return testSpec }
+
+func init() {
+	swag.Register(swag.Name, fakeDoc{})
+}
+
+func fetchSpec(t *testing.T, router http.Handler, header map[string]string) (*httptest.ResponseRecorder, *spec.Swagger) {
+	t.Helper()
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/openapi.json", nil)
+	req.Host = "api.example.com"
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		return w, nil
+	}
+
+	var doc spec.Swagger
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &doc))
+	return w, &doc
+}
+
+func TestOpenAPIHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("uses request host and scheme when not configured", func(t *testing.T) {
+		router := gin.New()
+		router.GET("/api/v1/openapi.json", openAPIHandler(swag.Name, config.SwaggerConfig{}, "/api"))
+
+		w, doc := fetchSpec(t, router, map[string]string{"X-Forwarded-Proto": "https"})
+
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "2.0", doc.Swagger)
+		assert.Equal(t, "api.example.com", doc.Host)
+		assert.Equal(t, []string{"https"}, doc.Schemes)
+		assert.Contains(t, doc.Paths.Paths, "/api/v1/auth/refresh")
+	})
+
+	t.Run("uses configured host and schemes", func(t *testing.T) {
+		router := gin.New()
+		router.GET("/api/v1/openapi.json", openAPIHandler(swag.Name, config.SwaggerConfig{
+			Host:    "docs.internal:8443",
+			Schemes: []string{"https"},
+		}, "/api"))
+
+		w, doc := fetchSpec(t, router, nil)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "docs.internal:8443", doc.Host)
+		assert.Equal(t, []string{"https"}, doc.Schemes)
+	})
+
+	t.Run("rewrites paths for configured base path", func(t *testing.T) {
+		router := gin.New()
+		router.GET("/api/v1/openapi.json", openAPIHandler(swag.Name, config.SwaggerConfig{}, "/svc"))
+
+		w, doc := fetchSpec(t, router, nil)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, doc.Paths.Paths, "/svc/v1/auth/refresh")
+		assert.NotContains(t, doc.Paths.Paths, "/api/v1/auth/refresh")
+	})
+
+	t.Run("unknown instance returns 404", func(t *testing.T) {
+		router := gin.New()
+		router.Use(errors.ErrorHandler())
+		router.GET("/api/v1/openapi.json", openAPIHandler("v9", config.SwaggerConfig{}, "/api"))
+
+		w, _ := fetchSpec(t, router, nil)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestSetupRouter_SwaggerUIToggle(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+
+	authService := auth.NewService(&config.JWTConfig{Secret: "test-secret", TTLHours: 24})
+
+	tests := []struct {
+		name       string
+		uiEnabled  bool
+		wantUICode int
+	}{
+		{name: "ui enabled", uiEnabled: true, wantUICode: http.StatusOK},
+		{name: "ui disabled", uiEnabled: false, wantUICode: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{
+				App:     config.AppConfig{Version: "1.0.0", Environment: "test"},
+				Swagger: config.SwaggerConfig{UIEnabled: tt.uiEnabled},
+			}
+			router := SetupRouter(&user.Handler{}, &user.RoleHandler{}, &friend.Handler{}, &featureflags.Handler{}, authService, cfg, db)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/swagger/index.html", nil))
+			assert.Equal(t, tt.wantUICode, w.Code)
+
+			w, doc := fetchSpec(t, router, nil)
+			require.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, "2.0", doc.Swagger)
+			assert.Equal(t, "api.example.com", doc.Host)
+			assert.Equal(t, []string{"http"}, doc.Schemes)
+		})
+	}
+}
@@ -0,0 +1,164 @@
+//go:build integration
+
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yeegeek/uyou-go-api-starter/internal/auth"
+	"github.com/yeegeek/uyou-go-api-starter/internal/config"
+	"github.com/yeegeek/uyou-go-api-starter/internal/featureflags"
+	"github.com/yeegeek/uyou-go-api-starter/internal/friend"
+	"github.com/yeegeek/uyou-go-api-starter/internal/testutil/pgtest"
+	"github.com/yeegeek/uyou-go-api-starter/internal/user"
+)
+
+func TestMain(m *testing.M) {
+	os.Exit(pgtest.Main(m))
+}
+
+// e2eServer wires the real handlers and services against a migrated PostgreSQL database, like cmd/server
+type e2eServer struct {
+	router      *gin.Engine
+	userService user.Service
+}
+
+func newE2EServer(t *testing.T) *e2eServer {
+	t.Helper()
+	db := pgtest.NewDB(t)
+	cfg := config.NewTestConfig()
+
+	authService := auth.NewServiceWithRepo(&cfg.JWT, db)
+	userRepo := user.NewRepository(db)
+	userService := user.NewService(userRepo, &cfg.Security, user.WithRoleCacheInvalidator(authService))
+	userHandler := user.NewHandler(userService, authService)
+	roleHandler := user.NewRoleHandler(user.NewRoleService(userRepo, user.WithRoleServiceCacheInvalidator(authService)))
+	friendHandler := friend.NewHandler(friend.NewService(friend.NewRepository(db)))
+	flagsHandler := featureflags.NewHandler(featureflags.NewService(featureflags.NewRepository(db), cfg.FeatureFlags))
+
+	router := SetupRouter(userHandler, roleHandler, friendHandler, flagsHandler, authService, cfg, db)
+	return &e2eServer{router: router, userService: userService}
+}
+
+// do sends a JSON request and decodes the data field of a successful response into out
+func (s *e2eServer) do(t *testing.T, method, path, bearer string, body any, out any) int {
+	t.Helper()
+	var payload []byte
+	if body != nil {
+		var err error
+		payload, err = json.Marshal(body)
+		require.NoError(t, err)
+	}
+
+	req := httptest.NewRequest(method, path, bytes.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	if bearer != "" {
+		req.Header.Set("Authorization", "Bearer "+bearer)
+	}
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+
+	if out != nil && w.Code < http.StatusMultipleChoices {
+		envelope := struct {
+			Data json.RawMessage `json:"data"`
+		}{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &envelope), w.Body.String())
+		require.NoError(t, json.Unmarshal(envelope.Data, out), w.Body.String())
+	}
+	return w.Code
+}
+
+func (s *e2eServer) register(t *testing.T, name, email string) user.AuthResponse {
+	t.Helper()
+	var resp user.AuthResponse
+	code := s.do(t, http.MethodPost, "/api/v1/auth/register", "",
+		user.RegisterRequest{Name: name, Email: email, Password: "SecurePass123!"}, &resp)
+	require.Equal(t, http.StatusOK, code)
+	return resp
+}
+
+func (s *e2eServer) login(t *testing.T, email string) (user.AuthResponse, int) {
+	t.Helper()
+	var resp user.AuthResponse
+	code := s.do(t, http.MethodPost, "/api/v1/auth/login", "",
+		user.LoginRequest{Email: email, Password: "SecurePass123!"}, &resp)
+	return resp, code
+}
+
+func (s *e2eServer) refresh(t *testing.T, token string) (auth.TokenPairResponse, int) {
+	t.Helper()
+	var resp auth.TokenPairResponse
+	code := s.do(t, http.MethodPost, "/api/v1/auth/refresh", "", auth.RefreshTokenRequest{RefreshToken: token}, &resp)
+	return resp, code
+}
+
+func TestE2E_Postgres_UserLifecycle(t *testing.T) {
+	s := newE2EServer(t)
+
+	registered := s.register(t, "Alice", "alice@example.com")
+	assert.NotEmpty(t, registered.AccessToken)
+	assert.NotEmpty(t, registered.RefreshToken)
+	aliceID := registered.User.ID
+
+	// A second user without a username must not collide with the first on the username index
+	s.register(t, "Bob", "bob@example.com")
+	code := s.do(t, http.MethodPost, "/api/v1/auth/register", "",
+		user.RegisterRequest{Name: "Alice Again", Email: "alice@example.com", Password: "SecurePass123!"}, nil)
+	assert.Equal(t, http.StatusConflict, code, "duplicate email maps the unique violation to 409")
+
+	login, code := s.login(t, "alice@example.com")
+	require.Equal(t, http.StatusOK, code)
+
+	t.Run("refresh rotates the token", func(t *testing.T) {
+		rotated, code := s.refresh(t, login.RefreshToken)
+		require.Equal(t, http.StatusOK, code)
+		assert.NotEqual(t, login.RefreshToken, rotated.RefreshToken)
+
+		_, code = s.refresh(t, login.RefreshToken)
+		assert.Equal(t, http.StatusForbidden, code, "reusing a rotated token is detected")
+
+		_, code = s.refresh(t, rotated.RefreshToken)
+		assert.Equal(t, http.StatusUnauthorized, code, "reuse revokes the whole family")
+	})
+
+	admin := s.register(t, "Admin", "admin@example.com")
+	require.NoError(t, s.userService.PromoteToAdmin(context.Background(), admin.User.ID))
+	adminLogin, code := s.login(t, "admin@example.com")
+	require.Equal(t, http.StatusOK, code)
+
+	t.Run("admin lists users", func(t *testing.T) {
+		var list user.UserListResponse
+		code := s.do(t, http.MethodGet, "/api/v1/admin/users?sort=email&order=asc&search=example.com", adminLogin.AccessToken, nil, &list)
+		require.Equal(t, http.StatusOK, code)
+		require.NotNil(t, list.Total)
+		assert.Equal(t, int64(3), *list.Total)
+		emails := make([]string, 0, len(list.Users))
+		for _, u := range list.Users {
+			emails = append(emails, u.Email)
+		}
+		assert.Equal(t, []string{"admin@example.com", "alice@example.com", "bob@example.com"}, emails)
+
+		code = s.do(t, http.MethodGet, "/api/v1/admin/users", login.AccessToken, nil, nil)
+		assert.Equal(t, http.StatusForbidden, code, "regular users cannot list users")
+	})
+
+	t.Run("admin deletes a user", func(t *testing.T) {
+		path := fmt.Sprintf("/api/v1/admin/users/%d", aliceID)
+		assert.Equal(t, http.StatusNoContent, s.do(t, http.MethodDelete, path, adminLogin.AccessToken, nil, nil))
+		assert.Equal(t, http.StatusNotFound, s.do(t, http.MethodGet, path, adminLogin.AccessToken, nil, nil))
+
+		_, code := s.login(t, "alice@example.com")
+		assert.Equal(t, http.StatusUnauthorized, code, "a deleted user cannot log in")
+	})
+}
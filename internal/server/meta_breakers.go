@@ -0,0 +1,25 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/yeegeek/uyou-go-api-starter/internal/errors"
+	"github.com/yeegeek/uyou-go-api-starter/internal/resilience"
+)
+
+// breakersHandler 返回外部依赖熔断器（Redis 缓存、RabbitMQ 发布、SMTP 发送）的当前状态，未启用的依赖不出现在列表中
+//
+// @Summary List circuit breaker states (Admin only)
+// @Description Returns the state of each external dependency circuit breaker: closed, open (calls fail fast into the fallback) or half-open (a probe call is allowed) (requires admin role)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} errors.Response{success=bool,data=[]resilience.Status} "Circuit breaker states sorted by name"
+// @Failure 401 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Unauthorized"
+// @Failure 403 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Admin access required"
+// @Router /api/v1/admin/meta/breakers [get]
+func breakersHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, errors.Success(resilience.Breakers()))
+}
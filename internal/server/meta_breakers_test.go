@@ -0,0 +1,71 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"github.com/yeegeek/uyou-go-api-starter/internal/auth"
+	"github.com/yeegeek/uyou-go-api-starter/internal/config"
+	"github.com/yeegeek/uyou-go-api-starter/internal/featureflags"
+	"github.com/yeegeek/uyou-go-api-starter/internal/friend"
+	"github.com/yeegeek/uyou-go-api-starter/internal/resilience"
+	"github.com/yeegeek/uyou-go-api-starter/internal/user"
+)
+
+func TestBreakersHandler_ReportsStates(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	healthy := resilience.NewBreaker("meta-test-healthy", 1, time.Minute)
+	broken := resilience.NewBreaker("meta-test-broken", 1, time.Minute)
+	require.True(t, broken.Allow())
+	broken.Record(errors.New("dial tcp: connection refused"))
+	healthy.Allow()
+	healthy.Record(nil)
+
+	router := gin.New()
+	router.GET("/breakers", breakersHandler)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/breakers", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp struct {
+		Data []resilience.Status `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	states := make(map[string]resilience.Status)
+	for _, s := range resp.Data {
+		states[s.Name] = s
+	}
+
+	assert.Equal(t, "closed", states["meta-test-healthy"].State)
+	assert.Nil(t, states["meta-test-healthy"].OpenedAt)
+	assert.Equal(t, "open", states["meta-test-broken"].State)
+	require.NotNil(t, states["meta-test-broken"].OpenedAt)
+	require.NotNil(t, states["meta-test-broken"].ProbeAt)
+	assert.Equal(t, time.Minute, states["meta-test-broken"].ProbeAt.Sub(*states["meta-test-broken"].OpenedAt))
+}
+
+func TestSetupRouter_BreakersEndpointRequiresAuth(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	authService := auth.NewService(&config.JWTConfig{Secret: "test-secret", TTLHours: 24})
+	testConfig := &config.Config{App: config.AppConfig{Environment: "test"}}
+	router := SetupRouter(&user.Handler{}, &user.RoleHandler{}, &friend.Handler{}, &featureflags.Handler{}, authService, testConfig, db)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/admin/meta/breakers", nil))
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
@@ -0,0 +1,27 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/yeegeek/uyou-go-api-starter/internal/config"
+	"github.com/yeegeek/uyou-go-api-starter/internal/errors"
+)
+
+// configHandler 返回脱敏后的当前配置（含热加载后的值），脱敏规则与启动日志一致（见 config.SafeMap）
+//
+// @Summary Get effective configuration (Admin only)
+// @Description Returns the loaded configuration with secrets (passwords, JWT secret, OAuth client secret, connection URLs) replaced by "<redacted>" (requires admin role)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} errors.Response{success=bool,data=object} "Redacted configuration grouped by section"
+// @Failure 401 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Unauthorized"
+// @Failure 403 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Admin access required"
+// @Router /api/v1/admin/meta/config [get]
+func configHandler(store *config.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, errors.Success(store.Load().SafeMap()))
+	}
+}
@@ -0,0 +1,71 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"github.com/yeegeek/uyou-go-api-starter/internal/auth"
+	"github.com/yeegeek/uyou-go-api-starter/internal/config"
+	"github.com/yeegeek/uyou-go-api-starter/internal/featureflags"
+	"github.com/yeegeek/uyou-go-api-starter/internal/friend"
+	"github.com/yeegeek/uyou-go-api-starter/internal/user"
+)
+
+func TestConfigHandler_RedactsSecrets(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cfg := config.NewTestConfig()
+	secrets := map[string]*string{
+		"database.password":   &cfg.Database.Password,
+		"jwt.secret":          &cfg.JWT.Secret,
+		"redis.password":      &cfg.Redis.Password,
+		"mail.password":       &cfg.Mail.Password,
+		"oauth.client_secret": &cfg.OAuth.Google.ClientSecret,
+		"mongodb.uri":         &cfg.MongoDB.URI,
+		"rabbitmq.url":        &cfg.RabbitMQ.URL,
+	}
+	for name, field := range secrets {
+		*field = "leaked-" + name
+	}
+	cfg.Database.Host = "db.internal"
+
+	router := gin.New()
+	router.GET("/config", configHandler(config.NewStore(cfg)))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/config", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	body := w.Body.String()
+	assert.NotContains(t, body, "leaked-")
+	assert.Contains(t, body, `"host":"db.internal"`)
+
+	var resp struct {
+		Data map[string]map[string]any `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, config.RedactedValue, resp.Data["jwt"]["secret"])
+	assert.Equal(t, config.RedactedValue, resp.Data["database"]["password"])
+}
+
+func TestSetupRouter_ConfigEndpointRequiresAuth(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	authService := auth.NewService(&config.JWTConfig{Secret: "test-secret", TTLHours: 24})
+	testConfig := &config.Config{App: config.AppConfig{Environment: "test"}}
+	router := SetupRouter(&user.Handler{}, &user.RoleHandler{}, &friend.Handler{}, &featureflags.Handler{}, authService, testConfig, db)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/admin/meta/config", nil))
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
@@ -0,0 +1,22 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/yeegeek/uyou-go-api-starter/internal/buildinfo"
+	"github.com/yeegeek/uyou-go-api-starter/internal/errors"
+)
+
+// versionHandler 返回当前运行的构建信息，未通过 -ldflags 注入的字段显示为 "dev"
+//
+// @Summary Get build information
+// @Description Returns the version, git commit, build date and Go version of the running binary; fields not injected at build time read "dev"
+// @Tags meta
+// @Produce json
+// @Success 200 {object} errors.Response{success=bool,data=buildinfo.Info} "Build information"
+// @Router /api/v1/meta/version [get]
+func versionHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, errors.Success(buildinfo.Get()))
+}
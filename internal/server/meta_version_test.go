@@ -0,0 +1,61 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"github.com/yeegeek/uyou-go-api-starter/internal/auth"
+	"github.com/yeegeek/uyou-go-api-starter/internal/buildinfo"
+	"github.com/yeegeek/uyou-go-api-starter/internal/config"
+	"github.com/yeegeek/uyou-go-api-starter/internal/featureflags"
+	"github.com/yeegeek/uyou-go-api-starter/internal/friend"
+	"github.com/yeegeek/uyou-go-api-starter/internal/user"
+)
+
+func TestSetupRouter_VersionEndpoint(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	authService := auth.NewService(&config.JWTConfig{Secret: "test-secret", TTLHours: 24})
+	testConfig := &config.Config{App: config.AppConfig{Environment: "test"}, Metrics: config.MetricsConfig{Enabled: true}}
+	router := SetupRouter(&user.Handler{}, &user.RoleHandler{}, &friend.Handler{}, &featureflags.Handler{}, authService, testConfig, db)
+
+	// Anonymous callers may read the build information
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/meta/version", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp struct {
+		Success bool              `json:"success"`
+		Data    map[string]string `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.True(t, resp.Success)
+	want := buildinfo.Get()
+	assert.Equal(t, map[string]string{
+		"version":    want.Version,
+		"commit":     want.Commit,
+		"build_date": want.BuildDate,
+		"go_version": want.GoVersion,
+	}, resp.Data)
+
+	t.Run("health includes build", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health", nil))
+		assert.Contains(t, w.Body.String(), `"build":{"version":"`+want.Version+`"`)
+	})
+
+	t.Run("metrics expose build_info", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		assert.Contains(t, w.Body.String(), `build_info{build_date="`+want.BuildDate+`"`)
+	})
+}
@@ -0,0 +1,48 @@
+package server
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/yeegeek/uyou-go-api-starter/internal/config"
+	"github.com/yeegeek/uyou-go-api-starter/internal/migrate"
+)
+
+// migrationVersionSource 在第一次就绪检查时才创建 migrator，创建失败下次检查时重试，避免数据库暂不可用时影响路由初始化
+type migrationVersionSource struct {
+	db  *gorm.DB
+	cfg config.MigrationsConfig
+
+	mu       sync.Mutex
+	migrator *migrate.Migrator
+}
+
+func newMigrationVersionSource(db *gorm.DB, cfg config.MigrationsConfig) *migrationVersionSource {
+	return &migrationVersionSource{db: db, cfg: cfg}
+}
+
+// Version 返回当前迁移版本和 dirty 状态，实现 health.VersionSource
+func (s *migrationVersionSource) Version() (uint, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.migrator == nil {
+		sqlDB, err := s.db.DB()
+		if err != nil {
+			return 0, false, fmt.Errorf("failed to get sql.DB: %w", err)
+		}
+		migrator, err := migrate.New(sqlDB, migrate.Config{
+			MigrationsDir: s.cfg.Directory,
+			Timeout:       time.Duration(s.cfg.Timeout) * time.Second,
+			LockTimeout:   time.Duration(s.cfg.LockTimeout) * time.Second,
+		})
+		if err != nil {
+			return 0, false, fmt.Errorf("failed to create migrator: %w", err)
+		}
+		s.migrator = migrator
+	}
+	return s.migrator.Version()
+}
@@ -2,131 +2,256 @@
 package server
 
 import (
+	"strconv"
+	"time"
+
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 	"gorm.io/gorm"
 
+	"github.com/yeegeek/uyou-go-api-starter/internal/audit"
 	"github.com/yeegeek/uyou-go-api-starter/internal/auth"
+	"github.com/yeegeek/uyou-go-api-starter/internal/buildinfo"
 	"github.com/yeegeek/uyou-go-api-starter/internal/config"
+	"github.com/yeegeek/uyou-go-api-starter/internal/contextutil"
+	database "github.com/yeegeek/uyou-go-api-starter/internal/db"
 	"github.com/yeegeek/uyou-go-api-starter/internal/errors"
+	"github.com/yeegeek/uyou-go-api-starter/internal/featureflags"
+	"github.com/yeegeek/uyou-go-api-starter/internal/friend"
 	"github.com/yeegeek/uyou-go-api-starter/internal/health"
+	"github.com/yeegeek/uyou-go-api-starter/internal/metrics"
 	"github.com/yeegeek/uyou-go-api-starter/internal/middleware"
-	"github.com/yeegeek/uyou-go-api-starter/internal/friend"
+	"github.com/yeegeek/uyou-go-api-starter/internal/organization"
 	"github.com/yeegeek/uyou-go-api-starter/internal/user"
 )
 
 // SetupRouter creates and configures the Gin router
-func SetupRouter(userHandler *user.Handler, friendHandler *friend.Handler, authService auth.Service, cfg *config.Config, db *gorm.DB) *gin.Engine {
+func SetupRouter(userHandler *user.Handler, roleHandler *user.RoleHandler, friendHandler *friend.Handler, flagsHandler *featureflags.Handler, authService auth.Service, cfg *config.Config, db *gorm.DB) *gin.Engine {
+	return SetupRouterWithStore(userHandler, roleHandler, friendHandler, flagsHandler, authService, config.NewStore(cfg), db)
+}
+
+// SetupRouterWithStore 与 SetupRouter 相同，日志级别和限流参数从配置快照实时读取，配置热加载后无需重建路由
+func SetupRouterWithStore(userHandler *user.Handler, roleHandler *user.RoleHandler, friendHandler *friend.Handler, flagsHandler *featureflags.Handler, authService auth.Service, store *config.Store, db *gorm.DB) *gin.Engine {
+	cfg := store.Load()
 	router := gin.New()
+	// 路径存在但方法不对时返回 405 而不是 404
+	router.HandleMethodNotAllowed = true
 
 	if cfg.App.Environment == "production" {
 		gin.SetMode(gin.ReleaseMode)
 	} else {
 		gin.SetMode(gin.DebugMode)
 	}
+	// 只有 app.debug 开启时 500 响应才返回内部错误详情；原始错误连同请求 ID 写入日志
+	errors.SetDebug(cfg.App.Debug)
+
+	// 只采信可信代理转发的客户端 IP；gin 默认信任所有代理，未配置时会直接使用客户端伪造的 X-Forwarded-For
+	// 配置已在加载时校验，这里不会出错
+	trustedProxies, _ := contextutil.ParseTrustedProxies(cfg.Server.TrustedProxies)
+	_ = router.SetTrustedProxies(cfg.Server.TrustedProxies)
+	router.Use(middleware.TrustedProxies(trustedProxies))
 
 	skipPaths := config.GetSkipPaths(cfg.App.Environment)
 	loggerConfig := middleware.NewLoggerConfig(
-		cfg.Logging.GetLogLevel(),
+		store.LogLevel(),
 		skipPaths,
 	)
 	router.Use(middleware.Logger(loggerConfig))
+	// 压缩放在请求体日志之前，日志记录的是压缩前的响应体
+	if cfg.Server.Compression.Enabled {
+		router.Use(middleware.Compression(middleware.CompressionConfig{
+			MinSize:      cfg.Server.Compression.GetMinSize(),
+			ContentTypes: cfg.Server.Compression.GetContentTypes(),
+		}))
+	}
+	if cfg.Logging.LogBodies {
+		// 只在日志级别为 debug 时输出，敏感字段已脱敏
+		router.Use(middleware.BodyLogger(middleware.BodyLoggerConfig{
+			Logger:    loggerConfig.Logger,
+			MaxBytes:  cfg.Logging.GetBodyMaxBytes(),
+			SkipPaths: skipPaths,
+		}))
+	}
 	router.Use(errors.ErrorHandler())
 	router.Use(gin.Recovery())
 
 	corsConfig := cors.DefaultConfig()
 	corsConfig.AllowAllOrigins = true
-	corsConfig.AllowHeaders = append(corsConfig.AllowHeaders, "Authorization")
+	corsConfig.AllowHeaders = append(corsConfig.AllowHeaders, "Authorization", auth.CSRFTokenHeader, auth.RefreshTokenTransportHeader, auth.ClientIDHeader)
+	corsConfig.ExposeHeaders = append(corsConfig.ExposeHeaders, "X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset", "Retry-After", "Deprecation", "Sunset", auth.NewAccessTokenHeader, auth.ImpersonatingHeader)
 	router.Use(cors.New(corsConfig))
+	router.Use(middleware.Pagination(cfg.Pagination.GetDefaultPageSize(), cfg.Pagination.GetMaxPageSize()))
+	// 带请求体的写操作必须使用 JSON；文件上传等接口通过 ContentTypeOverride 放行 multipart/form-data
+	router.Use(middleware.JSONContentType())
 
 	var checkers []health.Checker
 	if cfg.Health.DatabaseCheckEnabled {
 		dbChecker := health.NewDatabaseChecker(db)
 		checkers = append(checkers, dbChecker)
+		// 配置了只读副本时报告读请求的路由状态；副本不可用只标记为 degraded，读请求由主库承担
+		if resolver := database.ResolverFrom(db); resolver != nil {
+			checkers = append(checkers, resolver.HealthChecker())
+		}
+	}
+	// 就绪探针报告迁移版本；schema 处于 dirty 状态时按配置返回 503 或只标记为 degraded
+	if cfg.Health.MigrationCheckEnabled {
+		checkers = append(checkers, health.NewMigrationChecker(newMigrationVersionSource(db, cfg.Migrations), cfg.Health.FailOnDirtySchema))
 	}
-	healthService := health.NewService(checkers, cfg.App.Version, cfg.App.Environment)
+	healthService := health.NewServiceWithTimeout(checkers, cfg.App.Version, cfg.App.Environment, time.Duration(cfg.Health.Timeout)*time.Second)
 	healthHandler := health.NewHandler(healthService)
 
 	router.GET("/health", healthHandler.Health)
 	router.GET("/health/live", healthHandler.Live)
 	router.GET("/health/ready", healthHandler.Ready)
 
-	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
-
-	rlCfg := cfg.Ratelimit
-	if rlCfg.Enabled {
-		router.Use(
-			middleware.NewRateLimitMiddleware(
-				rlCfg.Window,
-				rlCfg.Requests,
-				func(c *gin.Context) string {
-					ip := c.ClientIP()
-					if ip == "" {
-						ip = c.GetHeader("X-Forwarded-For")
-						if ip == "" {
-							ip = c.GetHeader("X-Real-IP")
-						}
-						if ip == "" {
-							ip = "unknown"
-						}
-					}
+	// Prometheus 指标，与健康检查一样挂在根路径且不受限流影响；生产环境应只对内网开放
+	if cfg.Metrics.Enabled {
+		build := buildinfo.Get()
+		metrics.SetBuildInfo(build.Version, build.Commit, build.BuildDate, build.GoVersion)
+		router.GET(cfg.Metrics.GetPath(), gin.WrapH(promhttp.Handler()))
+	}
+
+	basePath := cfg.API.GetBasePath()
+
+	// Swagger UI 可通过配置关闭，JSON 规范始终在 {base_path}/v1/openapi.json 提供
+	if cfg.Swagger.UIEnabled {
+		router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler, ginSwagger.URL(basePath+"/v1/openapi.json")))
+	}
+
+	// 始终安装限流中间件，启用状态和参数随配置热加载变化
+	router.Use(
+		middleware.NewDynamicRateLimitMiddleware(
+			func() middleware.RateLimitParams {
+				rl := store.Load().Ratelimit
+				return middleware.RateLimitParams{Enabled: rl.Enabled, Window: rl.Window, Requests: rl.Requests, Strategy: rl.Strategy, Burst: rl.Burst}
+			},
+			func(c *gin.Context) string {
+				if ip := contextutil.ClientIP(c); ip != "" {
 					return ip
-				},
-				nil,
-			),
-		)
-	}
-
-	v1 := router.Group("/api/v1")
-	{
-		authGroup := v1.Group("/auth")
-		{
-			authGroup.POST("/register", userHandler.Register)
-			authGroup.POST("/login", userHandler.Login)
-			authGroup.POST("/refresh", userHandler.RefreshToken)
-			authGroup.POST("/logout", auth.AuthMiddleware(authService), userHandler.Logout)
-			authGroup.GET("/me", auth.AuthMiddleware(authService), userHandler.GetMe)
-		}
+				}
+				return "unknown"
+			},
+			nil,
+		),
+	)
 
-		// User endpoints - authenticated users can access their own resources
-		usersGroup := v1.Group("/users")
-		usersGroup.Use(auth.AuthMiddleware(authService))
-		{
-			usersGroup.GET("/:id", userHandler.GetUser)
-			usersGroup.PUT("/:id", userHandler.UpdateUser)
-			usersGroup.DELETE("/:id", userHandler.DeleteUser)
-		}
+	// 访问令牌校验；启用访问令牌 Cookie 后，缺少 Authorization 头时从 Cookie 读取令牌
+	// 启用自动续期后，临近过期的令牌会通过 X-New-Access-Token 响应头返回新令牌
+	accessCookie := auth.WithAccessCookie(auth.NewAccessCookie(&cfg.JWT))
+	requireAuth := gin.HandlersChain{auth.AuthMiddleware(authService, accessCookie)}
+	if cfg.JWT.AutoRenewEnabled {
+		requireAuth = append(requireAuth, auth.TokenRenewalMiddleware(authService, cfg.JWT.AutoRenewWindow))
+	}
 
-		// Admin endpoints - admin role required, following REST best practices
-		adminGroup := v1.Group("/admin")
-		adminGroup.Use(auth.AuthMiddleware(authService), middleware.RequireAdmin())
-		{
-			// User management endpoints
-			adminGroup.GET("/users", userHandler.ListUsers)
-			adminGroup.GET("/users/:id", userHandler.GetUser)
-			adminGroup.PUT("/users/:id", userHandler.UpdateUser)
-			adminGroup.DELETE("/users/:id", userHandler.DeleteUser)
-		}
+	// 刷新接口独立限流，防止在全局限额内暴力猜测刷新令牌；先按 IP，再按 IP 加令牌族前缀
+	// 限流器缓存的过期时间取启动时的窗口
+	_, _, refreshWindow := cfg.Ratelimit.RefreshLimits()
+	refreshIPLimits := func() middleware.RateLimitParams {
+		_, perIP, window := store.Load().Ratelimit.RefreshLimits()
+		return middleware.RateLimitParams{Enabled: true, Window: window, Requests: perIP}
+	}
+	refreshFamilyLimits := func() middleware.RateLimitParams {
+		perFamily, _, window := store.Load().Ratelimit.RefreshLimits()
+		return middleware.RateLimitParams{Enabled: true, Window: window, Requests: perFamily}
+	}
+	refreshThrottle := gin.HandlersChain{
+		middleware.NewDynamicRateLimitMiddleware(refreshIPLimits, auth.RefreshIPThrottleKey(contextutil.ClientIP),
+			middleware.NewMemoryStore(middleware.DefaultCacheSize, refreshWindow)),
+		middleware.NewDynamicRateLimitMiddleware(refreshFamilyLimits, auth.RefreshFamilyThrottleKey(auth.NewRefreshCookie(&cfg.JWT), contextutil.ClientIP),
+			middleware.NewMemoryStore(middleware.DefaultCacheSize, refreshWindow)),
+	}
 
-		// Friend endpoints
-		friendsGroup := v1.Group("/friends")
-		friendsGroup.Use(auth.AuthMiddleware(authService))
-		{
-			friendsGroup.GET("", friendHandler.GetFriendsList)
-			friendsGroup.POST("/request", friendHandler.SendFriendRequest)
-			friendsGroup.GET("/requests", friendHandler.GetFriendRequests)
-			friendsGroup.POST("/requests/:id/accept", friendHandler.AcceptFriendRequest)
-			friendsGroup.POST("/requests/:id/reject", friendHandler.RejectFriendRequest)
-			friendsGroup.DELETE("/:id", friendHandler.DeleteFriend)
-			friendsGroup.PUT("/:id/remark", friendHandler.UpdateFriendRemark)
-			friendsGroup.PUT("/:id/group", friendHandler.UpdateFriendGroup)
-			friendsGroup.POST("/:id/block", friendHandler.BlockUser)
-			friendsGroup.DELETE("/:id/unblock", friendHandler.UnblockUser)
-			friendsGroup.GET("/blocked", friendHandler.GetBlockedUsers)
-		}
+	// 用户搜索接口按用户独立限流，限制逐个枚举用户名；与刷新接口一样不受全局 enabled 开关影响
+	_, searchWindow := cfg.Ratelimit.SearchLimits()
+	searchLimits := func() middleware.RateLimitParams {
+		requests, window := store.Load().Ratelimit.SearchLimits()
+		return middleware.RateLimitParams{Enabled: true, Window: window, Requests: requests}
+	}
+	searchThrottle := gin.HandlersChain{
+		middleware.NewDynamicRateLimitMiddleware(searchLimits, searchThrottleKey,
+			middleware.NewMemoryStore(middleware.DefaultCacheSize, searchWindow)),
+	}
+
+	// 管理员接口独立的中间件栈：IP 白名单（development 环境不生效，配置已在加载时校验）、登录、
+	// 按管理员限流和审计日志，权限由各路由的 RequirePermission 校验；审计记录同时写入 audit_logs 表，供 /admin/audit 查询和导出
+	adminAllowlist, _ := middleware.IPAllowlist(cfg.Security.AdminIPAllowlistFor(cfg.App.Environment))
+	_, adminWindow := cfg.Ratelimit.AdminLimits()
+	adminLimits := func() middleware.RateLimitParams {
+		rl := store.Load().Ratelimit
+		requests, window := rl.AdminLimits()
+		return middleware.RateLimitParams{Enabled: rl.Enabled, Window: window, Requests: requests}
+	}
+	auditRepo := audit.NewRepository(db)
+	adminStack := gin.HandlersChain{adminAllowlist}
+	adminStack = append(adminStack, requireAuth...)
+	adminStack = append(adminStack,
+		middleware.NewDynamicRateLimitMiddleware(adminLimits, adminThrottleKey,
+			middleware.NewMemoryStore(middleware.DefaultCacheSize, adminWindow)),
+		middleware.AdminAudit(audit.NewRecorder(auditRepo)),
+	)
+
+	// 组织成员变更后清除成员的角色缓存，之后签发的令牌携带新的组织角色
+	orgService := organization.NewService(organization.NewRepository(db), organization.WithMembershipCacheInvalidator(authService))
+
+	routes := &routeSet{
+		userHandler:     userHandler,
+		roleHandler:     roleHandler,
+		friendHandler:   friendHandler,
+		flagsHandler:    flagsHandler,
+		auditHandler:    audit.NewHandler(auditRepo),
+		orgHandler:      organization.NewHandler(orgService),
+		requireAuth:     requireAuth,
+		adminStack:      adminStack,
+		optionalAuth:    gin.HandlersChain{auth.OptionalAuthMiddleware(authService, accessCookie)},
+		refreshThrottle: refreshThrottle,
+		searchThrottle:  searchThrottle,
+		swagger:         cfg.Swagger,
+		basePath:        basePath,
+		etagEnabled:     cfg.Server.ETagEnabled,
+		config:          store,
+	}
+	// 负载保护只作用于业务 API，健康检查和指标挂在根路径上不受影响
+	if limiter := middleware.NewConcurrencyLimiter("api", cfg.Server.MaxInFlight, cfg.Server.MaxQueueWait); limiter != nil {
+		routes.loadShed = middleware.LoadShed(limiter)
 	}
 
+	// 配置已在加载时校验，这里不会出错
+	v1DeprecatedAt, v1SunsetAt, _ := cfg.API.V1Deprecation()
+
+	mountAPIVersions(router, basePath,
+		apiVersion{
+			name: contextutil.APIVersionV1,
+			middleware: []gin.HandlerFunc{
+				middleware.Deprecation(v1DeprecatedAt, v1SunsetAt),
+			},
+			routes: []routeRegistrar{
+				routes.shed(routes.openAPI), routes.shed(routes.errorCatalog), routes.shed(routes.auth), routes.shed(routes.users),
+				routes.admin,
+				routes.shed(routes.orgs), routes.shed(routes.friends), routes.shed(routes.meta),
+			},
+		},
+		// v2 复用 v1 的处理器，处理器根据上下文中的版本输出新的响应结构；尚未迁移的接口只在 v1 提供
+		apiVersion{
+			name:   contextutil.APIVersionV2,
+			routes: []routeRegistrar{routes.shed(routes.openAPI), routes.shed(routes.errorCatalog), routes.shed(routes.me), routes.shed(routes.users)},
+		},
+	)
+
+	router.NoRoute(routeNotFound(basePath))
+	router.NoMethod(methodNotAllowed(router))
+
 	return router
 }
+
+// adminThrottleKey 管理员接口按管理员用户 ID 限流
+func adminThrottleKey(c *gin.Context) string {
+	return "admin:" + strconv.FormatUint(uint64(contextutil.GetUserID(c)), 10)
+}
+
+// searchThrottleKey 用户搜索接口按用户 ID 限流
+func searchThrottleKey(c *gin.Context) string {
+	return "search:" + strconv.FormatUint(uint64(contextutil.GetUserID(c)), 10)
+}
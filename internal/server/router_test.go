@@ -1,17 +1,27 @@
 package server
 
 import (
+	"encoding/base64"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 
 	"github.com/yeegeek/uyou-go-api-starter/internal/auth"
 	"github.com/yeegeek/uyou-go-api-starter/internal/config"
+	"github.com/yeegeek/uyou-go-api-starter/internal/errors"
+	"github.com/yeegeek/uyou-go-api-starter/internal/featureflags"
+	"github.com/yeegeek/uyou-go-api-starter/internal/friend"
+	"github.com/yeegeek/uyou-go-api-starter/internal/testutil"
 	"github.com/yeegeek/uyou-go-api-starter/internal/user"
 )
 
@@ -47,7 +57,7 @@ func TestSetupRouter_HealthEndpoint(t *testing.T) {
 		},
 	}
 
-	router := SetupRouter(mockUserHandler, mockAuthService, testConfig, db)
+	router := SetupRouter(mockUserHandler, &user.RoleHandler{}, &friend.Handler{}, &featureflags.Handler{}, mockAuthService, testConfig, db)
 
 	assert.NotNil(t, router)
 
@@ -59,3 +69,468 @@ func TestSetupRouter_HealthEndpoint(t *testing.T) {
 	assert.Contains(t, w.Body.String(), "status")
 	assert.Contains(t, w.Body.String(), "healthy")
 }
+
+func TestSetupRouter_MetricsEndpoint(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	authService := auth.NewService(&config.JWTConfig{Secret: "test-secret", TTLHours: 24})
+
+	for _, enabled := range []bool{true, false} {
+		testConfig := &config.Config{
+			App:     config.AppConfig{Environment: "test"},
+			Metrics: config.MetricsConfig{Enabled: enabled},
+		}
+		router := SetupRouter(&user.Handler{}, &user.RoleHandler{}, &friend.Handler{}, &featureflags.Handler{}, authService, testConfig, db)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/metrics", nil)
+		router.ServeHTTP(w, req)
+
+		if enabled {
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Contains(t, w.Body.String(), "auth_login_success_total")
+		} else {
+			assert.Equal(t, http.StatusNotFound, w.Code)
+		}
+	}
+}
+
+func TestSetupRouter_APIVersioning(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	authService := auth.NewService(&config.JWTConfig{Secret: "test-secret", TTLHours: 24})
+
+	testConfig := &config.Config{
+		App: config.AppConfig{Version: "1.0.0", Environment: "test"},
+		API: config.APIConfig{
+			V1DeprecatedAt: "2026-06-01T00:00:00Z",
+			V1SunsetAt:     "2026-12-31T23:59:59Z",
+		},
+	}
+	router := SetupRouter(&user.Handler{}, &user.RoleHandler{}, &friend.Handler{}, &featureflags.Handler{}, authService, testConfig, db)
+
+	t.Run("v1 routes carry deprecation headers", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/users/1", nil))
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Equal(t, "@1780272000", w.Header().Get("Deprecation"))
+		assert.Equal(t, "Thu, 31 Dec 2026 23:59:59 GMT", w.Header().Get("Sunset"))
+	})
+
+	t.Run("v2 routes are not deprecated", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v2/users/1", nil))
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Empty(t, w.Header().Get("Deprecation"))
+		assert.Empty(t, w.Header().Get("Sunset"))
+	})
+
+	t.Run("unknown version returns 404 with error code", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v3/users/1", nil))
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		var response errors.Response
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		if assert.NotNil(t, response.Error) {
+			assert.Equal(t, errors.CodeUnsupportedAPIVersion, response.Error.Code)
+		}
+	})
+
+	t.Run("unknown route in supported version returns ROUTE_NOT_FOUND", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/does-not-exist", nil))
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		var response errors.Response
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		if assert.NotNil(t, response.Error) {
+			assert.Equal(t, errors.CodeRouteNotFound, response.Error.Code)
+		}
+	})
+}
+
+func TestSetupRouter_NotFoundAndMethodNotAllowed(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	authService := auth.NewService(&config.JWTConfig{Secret: "test-secret", TTLHours: 24})
+	testConfig := &config.Config{App: config.AppConfig{Environment: "test"}}
+	router := SetupRouter(&user.Handler{}, &user.RoleHandler{}, &friend.Handler{}, &featureflags.Handler{}, authService, testConfig, db)
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		wantStatus int
+		wantCode   string
+		wantAllow  string
+	}{
+		{name: "unknown path", method: http.MethodGet, path: "/no/such/path", wantStatus: http.StatusNotFound, wantCode: errors.CodeRouteNotFound},
+		{name: "GET on POST-only route", method: http.MethodGet, path: "/api/v1/auth/login", wantStatus: http.StatusMethodNotAllowed, wantCode: errors.CodeMethodNotAllowed, wantAllow: "POST"},
+		{
+			name: "route with path parameters", method: http.MethodPost, path: "/api/v1/users/42",
+			wantStatus: http.StatusMethodNotAllowed, wantCode: errors.CodeMethodNotAllowed, wantAllow: "DELETE, GET, PATCH, PUT",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			assert.Equal(t, tt.wantAllow, w.Header().Get("Allow"))
+			var response errors.Response
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.False(t, response.Success)
+			if assert.NotNil(t, response.Error) {
+				assert.Equal(t, tt.wantCode, response.Error.Code)
+			}
+		})
+	}
+
+	t.Run("swagger UI assets keep the default response", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/swagger/index.html", nil))
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.NotContains(t, w.Body.String(), errors.CodeRouteNotFound)
+	})
+}
+
+func TestSetupRouter_ErrorCatalog(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	authService := auth.NewService(&config.JWTConfig{Secret: "test-secret", TTLHours: 24})
+	testConfig := &config.Config{App: config.AppConfig{Environment: "test"}}
+	router := SetupRouter(&user.Handler{}, &user.RoleHandler{}, &friend.Handler{}, &featureflags.Handler{}, authService, testConfig, db)
+
+	for _, version := range []string{"v1", "v2"} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/"+version+"/errors", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code, version)
+		assert.Contains(t, w.Body.String(), `"code":"`+errors.CodeRouteNotFound+`"`, version)
+	}
+}
+
+func TestSetupRouter_CompressionToggle(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	authService := auth.NewService(&config.JWTConfig{Secret: "test-secret", TTLHours: 24})
+
+	for _, enabled := range []bool{false, true} {
+		testConfig := &config.Config{
+			App:    config.AppConfig{Environment: "test"},
+			Server: config.ServerConfig{Compression: config.CompressionConfig{Enabled: enabled, MinSize: 1}},
+		}
+		router := SetupRouter(&user.Handler{}, &user.RoleHandler{}, &friend.Handler{}, &featureflags.Handler{}, authService, testConfig, db)
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/no/such/path", nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		if enabled {
+			assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+			assert.Equal(t, "Accept-Encoding", w.Header().Get("Vary"))
+		} else {
+			assert.Empty(t, w.Header().Get("Content-Encoding"))
+			assert.Empty(t, w.Header().Get("Vary"))
+		}
+	}
+}
+
+func TestSetupRouter_RefreshThrottle(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	authService := auth.NewService(&config.JWTConfig{Secret: "test-secret", TTLHours: 24})
+
+	testConfig := &config.Config{
+		App: config.AppConfig{Version: "1.0.0", Environment: "test"},
+		Ratelimit: config.RateLimitConfig{
+			RefreshRequests:   2,
+			RefreshIPRequests: 3,
+			RefreshWindow:     time.Hour,
+		},
+	}
+	router := SetupRouter(user.NewHandler(nil, authService), &user.RoleHandler{}, &friend.Handler{}, &featureflags.Handler{}, authService, testConfig, db)
+
+	refresh := func(token string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/refresh", strings.NewReader(`{"refresh_token":"`+token+`"}`))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		return w
+	}
+	familyToken := func() string {
+		id := uuid.New()
+		return base64.RawURLEncoding.EncodeToString(id[:]) + "." + strings.Repeat("A", 43)
+	}
+
+	tokenA := familyToken()
+	assert.NotEqual(t, http.StatusTooManyRequests, refresh(tokenA).Code)
+	assert.NotEqual(t, http.StatusTooManyRequests, refresh(tokenA).Code)
+
+	w := refresh(tokenA)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code, "per-family limit should apply")
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+
+	// A new family from the same IP has its own family bucket but still counts against the IP limit
+	w = refresh(familyToken())
+	assert.Equal(t, http.StatusTooManyRequests, w.Code, "per-IP limit should apply across families")
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+}
+
+func TestSetupRouter_SearchThrottle(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	authService := auth.NewService(&config.JWTConfig{Secret: "test-secret", TTLHours: 24})
+
+	testConfig := &config.Config{
+		App:       config.AppConfig{Version: "1.0.0", Environment: "test"},
+		Ratelimit: config.RateLimitConfig{SearchRequests: 2, SearchWindow: time.Hour},
+	}
+	router := SetupRouter(user.NewHandler(nil, authService), &user.RoleHandler{}, &friend.Handler{}, &featureflags.Handler{}, authService, testConfig, db)
+
+	token := func(userID uint) string {
+		return testutil.SignAccessToken(t, "test-secret", &auth.Claims{UserID: userID, Email: "user@example.com", Roles: []string{"user"}})
+	}
+	// An empty query is rejected by the handler without touching the user service
+	search := func(bearer string) int {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/users/search", nil)
+		if bearer != "" {
+			req.Header.Set("Authorization", "Bearer "+bearer)
+		}
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, search(""))
+
+	alice, bob := token(1), token(2)
+	assert.Equal(t, http.StatusBadRequest, search(alice))
+	assert.Equal(t, http.StatusBadRequest, search(alice))
+	assert.Equal(t, http.StatusTooManyRequests, search(alice), "search limit applies even with the global limit disabled")
+	assert.Equal(t, http.StatusBadRequest, search(bob), "each user has their own bucket")
+}
+
+func TestSetupRouter_RateLimitIgnoresSpoofedForwardedFor(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+
+	testConfig := &config.Config{
+		App:       config.AppConfig{Version: "1.0.0", Environment: "test"},
+		Server:    config.ServerConfig{TrustedProxies: []string{"10.0.0.0/8"}},
+		Ratelimit: config.RateLimitConfig{Enabled: true, Requests: 1, Window: time.Hour},
+	}
+	router := SetupRouter(&user.Handler{}, &user.RoleHandler{}, &friend.Handler{}, &featureflags.Handler{}, nil, testConfig, db)
+
+	get := func(remoteAddr, forwardedFor string) int {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/openapi.json", nil)
+		req.RemoteAddr = remoteAddr
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// Direct clients cannot escape their bucket by rotating X-Forwarded-For
+	assert.NotEqual(t, http.StatusTooManyRequests, get("203.0.113.9:4000", "1.1.1.1"))
+	assert.Equal(t, http.StatusTooManyRequests, get("203.0.113.9:4000", "2.2.2.2"))
+
+	// Behind the trusted proxy each forwarded client gets its own bucket
+	assert.NotEqual(t, http.StatusTooManyRequests, get("10.0.0.2:4000", "198.51.100.1"))
+	assert.NotEqual(t, http.StatusTooManyRequests, get("10.0.0.2:4000", "198.51.100.2"))
+	assert.Equal(t, http.StatusTooManyRequests, get("10.0.0.2:4000", "198.51.100.1"))
+}
+
+func TestSetupRouterWithStore_RateLimitFollowsReload(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	authService := auth.NewService(&config.JWTConfig{Secret: "test-secret", TTLHours: 24})
+
+	cfg := config.NewTestConfig()
+	cfg.JWT.Secret = "hKLmNpQrStUvWxYzABCDEFGHIJKLMNOP"
+	cfg.Ratelimit.Enabled = false
+	store := config.NewStore(cfg)
+	router := SetupRouterWithStore(&user.Handler{}, &user.RoleHandler{}, &friend.Handler{}, &featureflags.Handler{}, authService, store, db)
+
+	request := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/v1/openapi.json", nil)
+		req.RemoteAddr = "192.0.2.77:1234"
+		router.ServeHTTP(w, req)
+		return w
+	}
+	assert.Empty(t, request().Header().Get("X-RateLimit-Limit"))
+
+	next := *cfg
+	next.Ratelimit = config.RateLimitConfig{Enabled: true, Requests: 1, Window: time.Minute}
+	if err := store.Update(&next); err != nil {
+		t.Fatalf("Failed to update config: %v", err)
+	}
+
+	assert.Equal(t, "1", request().Header().Get("X-RateLimit-Limit"))
+	assert.Equal(t, http.StatusTooManyRequests, request().Code)
+}
+
+func TestSetupRouter_AdminGroup(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	authService := auth.NewService(&config.JWTConfig{Secret: "test-secret", TTLHours: 24})
+	token := func(roles ...string) string {
+		return testutil.SignAccessToken(t, "test-secret", &auth.Claims{UserID: 1, Email: "admin@example.com", Roles: roles})
+	}
+	adminToken, userToken := token("admin"), token("user")
+
+	newRouter := func(environment string) *gin.Engine {
+		testConfig := &config.Config{
+			App:       config.AppConfig{Version: "1.0.0", Environment: environment},
+			Server:    config.ServerConfig{TrustedProxies: []string{"10.0.0.0/24"}},
+			Ratelimit: config.RateLimitConfig{Enabled: true, Requests: 100, Window: time.Minute, AdminRequests: 3, AdminWindow: time.Hour},
+			Security:  config.SecurityConfig{AdminIPAllowlist: []string{"192.0.2.0/24"}},
+		}
+		return SetupRouter(&user.Handler{}, &user.RoleHandler{}, &friend.Handler{}, &featureflags.Handler{}, authService, testConfig, db)
+	}
+	get := func(router *gin.Engine, remoteAddr, forwardedFor, bearer string) int {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/meta/config", nil)
+		req.RemoteAddr = remoteAddr
+		if forwardedFor != "" {
+			req.Header.Set("X-Forwarded-For", forwardedFor)
+		}
+		if bearer != "" {
+			req.Header.Set("Authorization", "Bearer "+bearer)
+		}
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	t.Run("allowlist", func(t *testing.T) {
+		router := newRouter("test")
+
+		assert.Equal(t, http.StatusOK, get(router, "192.0.2.10:4000", "", adminToken), "allowed IP")
+		assert.Equal(t, http.StatusOK, get(router, "10.0.0.2:4000", "192.0.2.11", adminToken), "allowed IP behind trusted proxy")
+		assert.Equal(t, http.StatusForbidden, get(router, "203.0.113.60:4000", "", adminToken), "disallowed IP")
+		assert.Equal(t, http.StatusForbidden, get(router, "203.0.113.60:4000", "192.0.2.10", adminToken), "spoofed X-Forwarded-For")
+		assert.Equal(t, http.StatusForbidden, get(router, "203.0.113.60:4000", "", ""), "allowlist runs before authentication")
+	})
+
+	t.Run("auth and role", func(t *testing.T) {
+		router := newRouter("test")
+
+		assert.Equal(t, http.StatusUnauthorized, get(router, "192.0.2.10:4000", "", ""))
+		assert.Equal(t, http.StatusForbidden, get(router, "192.0.2.10:4000", "", userToken))
+	})
+
+	t.Run("per-route permissions", func(t *testing.T) {
+		router := newRouter("test")
+		// Each token gets its own user so the admin rate limit of 3 requests is not shared
+		withPermissions := func(userID uint, permissions ...string) string {
+			return testutil.SignAccessToken(t, "test-secret", &auth.Claims{UserID: userID, Email: "staff@example.com", Roles: []string{"support"}, Permissions: permissions})
+		}
+		send := func(method, path, bearer string) int {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(method, path, nil)
+			req.RemoteAddr = "192.0.2.10:4000"
+			req.Header.Set("Authorization", "Bearer "+bearer)
+			router.ServeHTTP(w, req)
+			return w.Code
+		}
+
+		support := withPermissions(2, user.PermissionUsersRead)
+		assert.Equal(t, http.StatusForbidden, send(http.MethodDelete, "/api/v1/admin/users/1", support), "support cannot delete users")
+		assert.Equal(t, http.StatusForbidden, send(http.MethodPatch, "/api/v1/admin/users/1", support), "support cannot edit users")
+		assert.Equal(t, http.StatusForbidden, send(http.MethodGet, "/api/v1/admin/meta/config", support), "admin-only routes need admin:*")
+		assert.Equal(t, http.StatusOK, send(http.MethodGet, "/api/v1/admin/meta/config", withPermissions(3, user.PermissionAdminAll)), "admin:* grants everything")
+		assert.Equal(t, http.StatusOK, send(http.MethodGet, "/api/v1/admin/meta/config", adminToken), "tokens without permissions fall back to the admin role")
+	})
+
+	t.Run("stricter rate limit", func(t *testing.T) {
+		router := newRouter("test")
+
+		for i := 0; i < 3; i++ {
+			assert.Equal(t, http.StatusOK, get(router, "192.0.2.10:4000", "", adminToken))
+		}
+		assert.Equal(t, http.StatusTooManyRequests, get(router, "192.0.2.10:4000", "", adminToken))
+	})
+
+	t.Run("allowlist skipped in development", func(t *testing.T) {
+		router := newRouter("development")
+
+		assert.Equal(t, http.StatusOK, get(router, "203.0.113.60:4000", "", adminToken))
+	})
+}
+
+func TestSetupRouter_AdminPrivilegeGrants(t *testing.T) {
+	db := testutil.NewSQLiteDB(t)
+	require.NoError(t, db.Exec(`INSERT INTO permissions (id, name, description) VALUES (4, 'roles:manage', ''), (5, 'admin:*', '')`).Error)
+	require.NoError(t, db.Exec(`INSERT INTO roles (id, name, description) VALUES (3, 'moderator', '')`).Error)
+	require.NoError(t, db.Exec(`INSERT INTO users (id, name, email, password_hash) VALUES (10, 'Manager', 'manager@example.com', 'hash')`).Error)
+
+	authService := auth.NewService(&config.JWTConfig{Secret: "test-secret", TTLHours: 24})
+	roleHandler := user.NewRoleHandler(user.NewRoleService(user.NewRepository(db)))
+	router := SetupRouter(&user.Handler{}, roleHandler, &friend.Handler{}, &featureflags.Handler{}, authService, &config.Config{}, db)
+
+	tokenWith := func(permissions ...string) string {
+		return testutil.SignAccessToken(t, "test-secret", &auth.Claims{UserID: 10, Email: "manager@example.com", Roles: []string{"moderator"}, Permissions: permissions})
+	}
+	send := func(method, path, bearer, body string) int {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+bearer)
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+	grantAdminAll := `{"permissions":["roles:manage","admin:*"]}`
+	promoteSelf := `{"user_ids":[10],"role":"admin","action":"assign"}`
+
+	t.Run("roles:manage alone cannot grant admin privileges", func(t *testing.T) {
+		manager := tokenWith(user.PermissionRolesManage)
+
+		assert.Equal(t, http.StatusForbidden, send(http.MethodPut, "/api/v1/admin/roles/3/permissions", manager, grantAdminAll))
+		assert.Equal(t, http.StatusForbidden, send(http.MethodPut, "/api/v1/admin/roles/2/permissions", manager, `{"permissions":["users:read"]}`))
+		assert.Equal(t, http.StatusForbidden, send(http.MethodPost, "/api/v1/admin/users/bulk/roles", manager, promoteSelf))
+		assert.Equal(t, http.StatusOK, send(http.MethodPut, "/api/v1/admin/roles/3/permissions", manager, `{"permissions":["users:read"]}`),
+			"other permission changes are still allowed")
+
+		var admins int64
+		require.NoError(t, db.Table("user_roles").Where("user_id = 10 AND role_id = 2").Count(&admins).Error)
+		assert.Zero(t, admins)
+	})
+
+	t.Run("admin:* can grant admin privileges", func(t *testing.T) {
+		admin := tokenWith(user.PermissionAdminAll)
+
+		assert.Equal(t, http.StatusOK, send(http.MethodPut, "/api/v1/admin/roles/3/permissions", admin, grantAdminAll))
+		assert.Equal(t, http.StatusOK, send(http.MethodPost, "/api/v1/admin/users/bulk/roles", admin, promoteSelf))
+	})
+}
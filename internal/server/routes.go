@@ -0,0 +1,326 @@
+package server
+
+import (
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/swaggo/swag"
+
+	"github.com/yeegeek/uyou-go-api-starter/internal/audit"
+	"github.com/yeegeek/uyou-go-api-starter/internal/config"
+	"github.com/yeegeek/uyou-go-api-starter/internal/contextutil"
+	"github.com/yeegeek/uyou-go-api-starter/internal/errors"
+	"github.com/yeegeek/uyou-go-api-starter/internal/featureflags"
+	"github.com/yeegeek/uyou-go-api-starter/internal/friend"
+	"github.com/yeegeek/uyou-go-api-starter/internal/middleware"
+	"github.com/yeegeek/uyou-go-api-starter/internal/organization"
+	"github.com/yeegeek/uyou-go-api-starter/internal/user"
+)
+
+// routeRegistrar 向某个 API 版本的路由组注册一组路由，同一个注册函数可以挂载到多个版本
+type routeRegistrar func(rg *gin.RouterGroup)
+
+// apiVersion 描述挂载在 {base_path}/{name} 下的一个 API 版本
+type apiVersion struct {
+	name       string
+	middleware []gin.HandlerFunc
+	routes     []routeRegistrar
+}
+
+// mountAPIVersions 为每个版本创建路由组，写入版本上下文后依次执行注册函数
+func mountAPIVersions(router gin.IRouter, basePath string, versions ...apiVersion) {
+	for _, v := range versions {
+		handlers := append([]gin.HandlerFunc{middleware.APIVersion(v.name)}, v.middleware...)
+		group := router.Group(basePath+"/"+v.name, handlers...)
+		for _, register := range v.routes {
+			register(group)
+		}
+	}
+}
+
+// shed 为注册函数挂载的路由加上负载保护，多个版本共享同一组并发名额
+func (r *routeSet) shed(register routeRegistrar) routeRegistrar {
+	if r.loadShed == nil {
+		return register
+	}
+	return func(rg *gin.RouterGroup) {
+		register(rg.Group("", r.loadShed))
+	}
+}
+
+// routeSet 持有各版本共享的处理器，方法即路由注册函数
+type routeSet struct {
+	userHandler   *user.Handler
+	roleHandler   *user.RoleHandler
+	friendHandler *friend.Handler
+	flagsHandler  *featureflags.Handler
+	auditHandler  *audit.Handler
+	orgHandler    *organization.Handler
+	requireAuth   gin.HandlersChain
+	optionalAuth  gin.HandlersChain
+	// adminStack 管理员接口的完整中间件栈，所有 /admin 路由都必须挂在它下面
+	adminStack gin.HandlersChain
+	// refreshThrottle 刷新接口专用限流
+	refreshThrottle gin.HandlersChain
+	// searchThrottle 用户搜索接口专用限流
+	searchThrottle gin.HandlersChain
+	swagger        config.SwaggerConfig
+	basePath       string
+	// etagEnabled 单资源 GET 接口是否支持 If-None-Match 条件请求
+	etagEnabled bool
+	// config 供管理员查看脱敏后的当前配置
+	config *config.Store
+	// loadShed 负载保护中间件，未配置并发上限时为 nil；管理员接口不挂载，过载时仍可操作
+	loadShed gin.HandlerFunc
+}
+
+// openAPI 注册当前版本的 OpenAPI 规范，v1 使用 swag 默认文档实例，其余版本使用同名实例
+func (r *routeSet) openAPI(rg *gin.RouterGroup) {
+	instance := path.Base(rg.BasePath())
+	if instance == contextutil.APIVersionV1 {
+		instance = swag.Name
+	}
+	rg.GET("/openapi.json", openAPIHandler(instance, r.swagger, r.basePath))
+}
+
+// errorCatalog 注册错误代码目录，客户端据此枚举所有可能的错误代码
+func (r *routeSet) errorCatalog(rg *gin.RouterGroup) {
+	rg.GET("/errors", errors.CatalogHandler)
+}
+
+// auth 注册注册、登录、刷新令牌、邮箱变更确认以及需要登录的会话接口
+func (r *routeSet) auth(rg *gin.RouterGroup) {
+	authGroup := rg.Group("/auth")
+	{
+		authGroup.POST("/register", r.userHandler.Register)
+		authGroup.POST("/login", r.userHandler.Login)
+		authGroup.POST("/refresh", append(r.refreshThrottle, r.userHandler.RefreshToken)...)
+		authGroup.POST("/verify-email-change", r.userHandler.VerifyEmailChange)
+		authGroup.GET("/oauth/:provider/login", r.userHandler.OAuthLogin)
+		authGroup.GET("/oauth/:provider/callback", r.userHandler.OAuthCallback)
+
+		sessionGroup := authGroup.Group("", r.requireAuth...)
+		sessionGroup.POST("/logout", r.userHandler.Logout)
+		sessionGroup.POST("/logout-all", r.userHandler.LogoutAll)
+		sessionGroup.GET("/me", r.cacheable(r.userHandler.GetMe)...)
+		sessionGroup.GET("/me/permissions", r.userHandler.GetMyPermissions)
+		sessionGroup.GET("/login-history", r.userHandler.GetLoginHistory)
+		sessionGroup.PATCH("/me", r.userHandler.UpdateMe)
+		sessionGroup.DELETE("/me", r.userHandler.DeleteMe)
+		sessionGroup.PUT("/me/password", r.userHandler.ChangePassword)
+	}
+}
+
+// me 只注册当前用户接口，供尚未迁移登录流程的版本使用
+func (r *routeSet) me(rg *gin.RouterGroup) {
+	sessionGroup := rg.Group("/auth", r.requireAuth...)
+	{
+		sessionGroup.GET("/me", r.cacheable(r.userHandler.GetMe)...)
+		sessionGroup.PATCH("/me", r.userHandler.UpdateMe)
+	}
+}
+
+// users 注册用户接口，已登录用户可以访问自己的资源，并可按名称搜索其他用户（只返回公开字段）
+// 存在 block 类协议未接受时，除接受协议接口外都返回 POLICY_NOT_ACCEPTED
+func (r *routeSet) users(rg *gin.RouterGroup) {
+	usersGroup := rg.Group("/users", r.requireAuth...)
+	{
+		usersGroup.POST("/:id/accept-policy", r.userHandler.AcceptPolicy)
+
+		acceptedGroup := usersGroup.Group("", r.userHandler.RequirePolicyAcceptance())
+		acceptedGroup.GET("/search", append(r.searchThrottle, r.userHandler.SearchUsers)...)
+		acceptedGroup.GET("/:id", r.cacheable(r.userHandler.GetUser)...)
+		acceptedGroup.PUT("/:id", r.userHandler.UpdateUser)
+		acceptedGroup.PATCH("/:id", r.userHandler.PatchUser)
+		acceptedGroup.DELETE("/:id", r.userHandler.DeleteUser)
+		acceptedGroup.GET("/:id/preferences", r.userHandler.GetPreferences)
+		acceptedGroup.PATCH("/:id/preferences", r.userHandler.UpdatePreferences)
+	}
+}
+
+// admin 注册管理员接口，经过 IP 白名单、登录、管理员限流和审计日志；每个路由按所需权限单独校验，
+// 例如只有 users:read 的 support 角色可以查看用户但不能修改或删除，admin:* 覆盖全部权限
+func (r *routeSet) admin(rg *gin.RouterGroup) {
+	can := middleware.RequirePermission
+	adminGroup := rg.Group("/admin", r.adminStack...)
+	{
+		// User management endpoints
+		adminGroup.GET("/users", can(user.PermissionUsersRead), r.userHandler.ListUsers)
+		adminGroup.POST("/users/bulk/roles", can(user.PermissionRolesManage), r.roleHandler.BulkUpdateUserRoles)
+		adminGroup.GET("/users/:id", can(user.PermissionUsersRead), r.userHandler.GetAdminUser)
+		adminGroup.PUT("/users/:id", can(user.PermissionUsersWrite), r.userHandler.UpdateUser)
+		adminGroup.PATCH("/users/:id", can(user.PermissionUsersWrite), r.userHandler.PatchUser)
+		adminGroup.DELETE("/users/:id", can(user.PermissionUsersDelete), r.userHandler.DeleteUser)
+		adminGroup.POST("/users/:id/impersonate", can(user.PermissionAdminAll), r.userHandler.Impersonate)
+		adminGroup.POST("/users/:id/force-logout", can(user.PermissionUsersWrite), r.userHandler.ForceLogout)
+		adminGroup.POST("/users/:id/deactivate", can(user.PermissionUsersWrite), r.userHandler.DeactivateUser)
+		adminGroup.POST("/users/:id/reactivate", can(user.PermissionUsersWrite), r.userHandler.ReactivateUser)
+		adminGroup.POST("/users/:id/disable", can(user.PermissionUsersWrite), r.userHandler.DeactivateUser)
+		adminGroup.POST("/users/:id/enable", can(user.PermissionUsersWrite), r.userHandler.ReactivateUser)
+		adminGroup.GET("/users/:id/lockout", can(user.PermissionUsersRead), r.userHandler.GetLockout)
+		adminGroup.DELETE("/users/:id/lockout", can(user.PermissionUsersWrite), r.userHandler.ClearLockout)
+		adminGroup.GET("/impersonations", can(user.PermissionAdminAll), r.userHandler.ListImpersonations)
+		adminGroup.GET("/policy-acceptances", can(user.PermissionUsersRead), r.userHandler.ListPolicyAcceptances)
+		adminGroup.GET("/sessions", can(user.PermissionUsersRead), r.userHandler.ListSessions)
+		adminGroup.DELETE("/sessions/:family", can(user.PermissionUsersWrite), r.userHandler.RevokeSession)
+		adminGroup.GET("/stats", can(user.PermissionStatsRead), r.userHandler.GetStats)
+
+		adminGroup.GET("/roles", can(user.PermissionRolesManage), r.roleHandler.ListRoles)
+		adminGroup.POST("/roles", can(user.PermissionRolesManage), r.roleHandler.CreateRole)
+		adminGroup.PUT("/roles/:id", can(user.PermissionRolesManage), r.roleHandler.UpdateRole)
+		adminGroup.DELETE("/roles/:id", can(user.PermissionRolesManage), r.roleHandler.DeleteRole)
+		adminGroup.PUT("/roles/:id/permissions", can(user.PermissionRolesManage), r.roleHandler.SetRolePermissions)
+		adminGroup.GET("/permissions", can(user.PermissionRolesManage), r.roleHandler.ListPermissions)
+
+		adminGroup.GET("/flags", can(user.PermissionAdminAll), r.flagsHandler.ListFlags)
+		adminGroup.POST("/flags", can(user.PermissionAdminAll), r.flagsHandler.CreateFlag)
+		adminGroup.PUT("/flags/:name", can(user.PermissionAdminAll), r.flagsHandler.UpdateFlag)
+		adminGroup.DELETE("/flags/:name", can(user.PermissionAdminAll), r.flagsHandler.DeleteFlag)
+
+		adminGroup.GET("/audit", can(user.PermissionAdminAll), r.auditHandler.ListEntries)
+		adminGroup.GET("/audit/export", can(user.PermissionAdminAll), r.auditHandler.ExportEntries)
+
+		adminGroup.GET("/meta/config", can(user.PermissionAdminAll), configHandler(r.config))
+		adminGroup.GET("/meta/breakers", can(user.PermissionAdminAll), breakersHandler)
+	}
+}
+
+// meta 注册客户端元信息接口，匿名用户也可以访问
+func (r *routeSet) meta(rg *gin.RouterGroup) {
+	metaGroup := rg.Group("/meta", r.optionalAuth...)
+	{
+		metaGroup.GET("/flags", r.flagsHandler.GetMyFlags)
+		metaGroup.GET("/version", versionHandler)
+	}
+}
+
+// orgs 注册组织与成员接口；/orgs/:org_id 下的接口要求调用者是该组织成员，写操作按组织角色限制
+func (r *routeSet) orgs(rg *gin.RouterGroup) {
+	orgsGroup := rg.Group("/orgs", r.requireAuth...)
+	{
+		orgsGroup.POST("", r.orgHandler.CreateOrganization)
+		orgsGroup.GET("", r.orgHandler.ListOrganizations)
+	}
+
+	member := r.orgHandler.RequireMembership(organization.RoleMember)
+	admin := r.orgHandler.RequireMembership(organization.RoleAdmin)
+	owner := r.orgHandler.RequireMembership(organization.RoleOwner)
+	orgGroup := orgsGroup.Group("/:org_id")
+	{
+		orgGroup.GET("", member, r.orgHandler.GetOrganization)
+		orgGroup.PATCH("", admin, r.orgHandler.UpdateOrganization)
+		orgGroup.DELETE("", owner, r.orgHandler.DeleteOrganization)
+		orgGroup.GET("/members", member, r.orgHandler.ListMembers)
+		orgGroup.POST("/members", admin, r.orgHandler.AddMember)
+		orgGroup.PATCH("/members/:user_id", admin, r.orgHandler.UpdateMember)
+		// 成员可以移除自己（退出组织），移除他人的权限由服务层按组织角色判断
+		orgGroup.DELETE("/members/:user_id", member, r.orgHandler.RemoveMember)
+	}
+}
+
+// friends 注册好友接口
+func (r *routeSet) friends(rg *gin.RouterGroup) {
+	friendsGroup := rg.Group("/friends", r.requireAuth...)
+	friendsGroup.Use(r.userHandler.RequirePolicyAcceptance())
+	{
+		friendsGroup.GET("", r.friendHandler.GetFriendsList)
+		friendsGroup.POST("/request", r.friendHandler.SendFriendRequest)
+		friendsGroup.GET("/requests", r.friendHandler.GetFriendRequests)
+		friendsGroup.POST("/requests/:id/accept", r.friendHandler.AcceptFriendRequest)
+		friendsGroup.POST("/requests/:id/reject", r.friendHandler.RejectFriendRequest)
+		friendsGroup.DELETE("/:id", r.friendHandler.DeleteFriend)
+		friendsGroup.PUT("/:id/remark", r.friendHandler.UpdateFriendRemark)
+		friendsGroup.PUT("/:id/group", r.friendHandler.UpdateFriendGroup)
+		friendsGroup.POST("/:id/block", r.friendHandler.BlockUser)
+		friendsGroup.DELETE("/:id/unblock", r.friendHandler.UnblockUser)
+		friendsGroup.GET("/blocked", r.friendHandler.GetBlockedUsers)
+	}
+}
+
+// cacheable 启用 ETag 时在单资源 GET 接口前加上条件请求处理
+func (r *routeSet) cacheable(handler gin.HandlerFunc) gin.HandlersChain {
+	if !r.etagEnabled {
+		return gin.HandlersChain{handler}
+	}
+	return gin.HandlersChain{middleware.ETag(), handler}
+}
+
+// swaggerUIPrefix Swagger UI 静态资源不是 JSON 接口，未匹配时保持 gin 默认响应
+const swaggerUIPrefix = "/swagger/"
+
+// routeNotFound 对未知版本前缀的请求返回 UNSUPPORTED_API_VERSION，其余未匹配路由返回 ROUTE_NOT_FOUND
+func routeNotFound(basePath string) gin.HandlerFunc {
+	pattern := regexp.MustCompile(`^` + regexp.QuoteMeta(basePath) + `/(v[0-9]+)(/|$)`)
+
+	return func(c *gin.Context) {
+		path := c.Request.URL.Path
+		if strings.HasPrefix(path, swaggerUIPrefix) {
+			return
+		}
+		if m := pattern.FindStringSubmatch(path); m != nil && !contextutil.IsSupportedAPIVersion(m[1]) {
+			_ = c.Error(errors.UnsupportedAPIVersion(m[1], contextutil.SupportedAPIVersions))
+			return
+		}
+		_ = c.Error(errors.RouteNotFound(c.Request.Method, path))
+	}
+}
+
+// methodNotAllowed 路由存在但不接受该方法时返回 405，Allow 头列出该路径注册的全部方法
+// 路由表在第一次请求时从 engine 读取，此时所有路由都已注册
+func methodNotAllowed(engine *gin.Engine) gin.HandlerFunc {
+	var (
+		once   sync.Once
+		routes []allowedRoute
+	)
+
+	return func(c *gin.Context) {
+		path := c.Request.URL.Path
+		if strings.HasPrefix(path, swaggerUIPrefix) {
+			return
+		}
+		once.Do(func() { routes = compileRoutes(engine.Routes()) })
+
+		var allowed []string
+		for _, route := range routes {
+			if route.pattern.MatchString(path) && !slices.Contains(allowed, route.method) {
+				allowed = append(allowed, route.method)
+			}
+		}
+		slices.Sort(allowed)
+		c.Header("Allow", strings.Join(allowed, ", "))
+		_ = c.Error(errors.MethodNotAllowed(c.Request.Method, allowed))
+	}
+}
+
+// allowedRoute 一条已注册路由的方法和路径匹配规则
+type allowedRoute struct {
+	method  string
+	pattern *regexp.Regexp
+}
+
+// compileRoutes 将 gin 路由模板转换为正则：:param 匹配一个路径段，*param 匹配剩余部分
+func compileRoutes(infos gin.RoutesInfo) []allowedRoute {
+	routes := make([]allowedRoute, 0, len(infos))
+	for _, info := range infos {
+		segments := strings.Split(info.Path, "/")
+		for i, segment := range segments {
+			switch {
+			case strings.HasPrefix(segment, ":"):
+				segments[i] = `[^/]+`
+			case strings.HasPrefix(segment, "*"):
+				segments[i] = `.*`
+			default:
+				segments[i] = regexp.QuoteMeta(segment)
+			}
+		}
+		routes = append(routes, allowedRoute{
+			method:  info.Method,
+			pattern: regexp.MustCompile(`^` + strings.Join(segments, "/") + `$`),
+		})
+	}
+	return routes
+}
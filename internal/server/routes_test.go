@@ -0,0 +1,101 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yeegeek/uyou-go-api-starter/internal/contextutil"
+	"github.com/yeegeek/uyou-go-api-starter/internal/errors"
+	"github.com/yeegeek/uyou-go-api-starter/internal/middleware"
+)
+
+func TestMountAPIVersions_SharedRegistrar(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	ping := func(rg *gin.RouterGroup) {
+		rg.GET("/ping", func(c *gin.Context) {
+			c.String(http.StatusOK, contextutil.GetAPIVersion(c))
+		})
+	}
+
+	router := gin.New()
+	router.Use(errors.ErrorHandler())
+	mountAPIVersions(router, "/svc",
+		apiVersion{name: contextutil.APIVersionV1, routes: []routeRegistrar{ping}},
+		apiVersion{name: contextutil.APIVersionV2, routes: []routeRegistrar{ping}},
+	)
+	router.NoRoute(routeNotFound("/svc"))
+
+	tests := []struct {
+		path     string
+		wantCode int
+		wantBody string
+	}{
+		{path: "/svc/v1/ping", wantCode: http.StatusOK, wantBody: contextutil.APIVersionV1},
+		{path: "/svc/v2/ping", wantCode: http.StatusOK, wantBody: contextutil.APIVersionV2},
+		{path: "/api/v1/ping", wantCode: http.StatusNotFound},
+		{path: "/svc/v9/ping", wantCode: http.StatusNotFound, wantBody: errors.CodeUnsupportedAPIVersion},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			assert.Equal(t, tt.wantCode, w.Code)
+			assert.Contains(t, w.Body.String(), tt.wantBody)
+		})
+	}
+}
+
+func TestRouteSet_ShedBypassesUnwrappedRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	limiter := middleware.NewConcurrencyLimiter("test", 1, 0)
+	routes := &routeSet{loadShed: middleware.LoadShed(limiter)}
+	release := make(chan struct{})
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	api := func(rg *gin.RouterGroup) {
+		rg.GET("/slow", func(c *gin.Context) { <-release })
+		rg.GET("/ping", ok)
+	}
+	admin := func(rg *gin.RouterGroup) { rg.GET("/admin/ping", ok) }
+
+	router := gin.New()
+	router.Use(errors.ErrorHandler())
+	router.GET("/health", ok)
+	mountAPIVersions(router, "/svc",
+		apiVersion{name: contextutil.APIVersionV1, routes: []routeRegistrar{routes.shed(api), admin}},
+	)
+
+	slow := make(chan struct{})
+	go func() {
+		defer close(slow)
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/svc/v1/slow", nil))
+	}()
+	require.Eventually(t, func() bool { return limiter.InFlight() == 1 }, time.Second, time.Millisecond)
+
+	tests := []struct {
+		path     string
+		wantCode int
+	}{
+		{path: "/svc/v1/ping", wantCode: http.StatusServiceUnavailable},
+		{path: "/svc/v1/admin/ping", wantCode: http.StatusOK},
+		{path: "/health", wantCode: http.StatusOK},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		assert.Equal(t, tt.wantCode, w.Code, tt.path)
+	}
+
+	close(release)
+	<-slow
+	assert.Zero(t, limiter.InFlight())
+}
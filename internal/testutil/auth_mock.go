@@ -0,0 +1,113 @@
+package testutil
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/yeegeek/uyou-go-api-starter/internal/auth"
+)
+
+// MockAuthService 是 auth.Service 的 testify 模拟实现；GenerateToken、GenerateTokenPair 的 opts 不参与匹配
+type MockAuthService struct {
+	mock.Mock
+}
+
+func (m *MockAuthService) ValidateToken(tokenString string) (*auth.Claims, error) {
+	args := m.Called(tokenString)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*auth.Claims), args.Error(1)
+}
+
+func (m *MockAuthService) RenewAccessToken(ctx context.Context, claims *auth.Claims) (string, error) {
+	args := m.Called(ctx, claims)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockAuthService) GenerateImpersonationToken(ctx context.Context, impersonatorID, targetUserID uint, email, name, reason string) (*auth.ImpersonationToken, error) {
+	args := m.Called(ctx, impersonatorID, targetUserID, email, name, reason)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*auth.ImpersonationToken), args.Error(1)
+}
+
+func (m *MockAuthService) ListActiveImpersonations(ctx context.Context) ([]auth.ImpersonationGrant, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]auth.ImpersonationGrant), args.Error(1)
+}
+
+func (m *MockAuthService) ListSessions(ctx context.Context, filter auth.SessionFilter) ([]auth.SessionFamily, error) {
+	args := m.Called(ctx, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]auth.SessionFamily), args.Error(1)
+}
+
+func (m *MockAuthService) RevokeSession(ctx context.Context, tokenFamily uuid.UUID) error {
+	args := m.Called(ctx, tokenFamily)
+	return args.Error(0)
+}
+
+func (m *MockAuthService) InvalidateUserRoles(userID uint) {
+	m.Called(userID)
+}
+
+func (m *MockAuthService) InvalidateAllRoles() {
+	m.Called()
+}
+
+func (m *MockAuthService) CheckClientGrant(clientID, grant string) error {
+	args := m.Called(clientID, grant)
+	return args.Error(0)
+}
+
+func (m *MockAuthService) GenerateToken(userID uint, email string, name string, opts ...auth.TokenPairOption) (string, error) {
+	args := m.Called(userID, email, name)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockAuthService) GenerateTokenPair(ctx context.Context, userID uint, email string, name string, opts ...auth.TokenPairOption) (*auth.TokenPair, error) {
+	args := m.Called(ctx, userID, email, name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*auth.TokenPair), args.Error(1)
+}
+
+func (m *MockAuthService) RefreshAccessToken(ctx context.Context, refreshToken string) (*auth.TokenPair, error) {
+	args := m.Called(ctx, refreshToken)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*auth.TokenPair), args.Error(1)
+}
+
+func (m *MockAuthService) RevokeRefreshToken(ctx context.Context, refreshToken string) error {
+	args := m.Called(ctx, refreshToken)
+	return args.Error(0)
+}
+
+func (m *MockAuthService) RevokeUserRefreshToken(ctx context.Context, userID uint, refreshToken string) error {
+	args := m.Called(ctx, userID, refreshToken)
+	return args.Error(0)
+}
+
+func (m *MockAuthService) RevokeAllUserTokens(ctx context.Context, userID uint) (int64, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockAuthService) CountActiveSessions(ctx context.Context) (int64, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+var _ auth.Service = (*MockAuthService)(nil)
@@ -0,0 +1,178 @@
+// Package testutil 提供测试共用的 SQLite 数据库、Gin 路由和 auth.Service 模拟实现
+//
+// 本包只依赖 auth，user 包内部的测试也可以使用；依赖 user 的模拟实现和数据构造器在 usertest 子包中。
+// user、auth 包内部（非 _test 包）的测试无法导入依赖自身的包，因此这两个包各自保留了一份包内模拟实现，
+// 修改接口时需要同步更新
+package testutil
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/yeegeek/uyou-go-api-starter/internal/auth"
+)
+
+// userSchema 与 migrations 中用户相关的表结构保持一致（SQLite 语法），并写入默认角色和权限
+const userSchema = `
+	CREATE TABLE users (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL,
+		username TEXT,
+		email TEXT UNIQUE NOT NULL,
+		pending_email TEXT,
+		email_change_token_hash TEXT,
+		email_change_expires_at DATETIME,
+		phone TEXT,
+		password_hash TEXT NOT NULL,
+		avatar_url TEXT,
+		gender TEXT,
+		birthday DATETIME,
+		country TEXT,
+		city TEXT,
+		bio TEXT,
+		language TEXT DEFAULT 'en',
+		is_vip BOOLEAN DEFAULT FALSE,
+		vip_expires_at DATETIME,
+		is_online BOOLEAN DEFAULT FALSE,
+		last_active_at DATETIME,
+		status TEXT DEFAULT 'active',
+		coins INTEGER DEFAULT 0,
+		fingerprint TEXT,
+		token_version INTEGER NOT NULL DEFAULT 0,
+		active BOOLEAN NOT NULL DEFAULT TRUE,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		deleted_at DATETIME
+	);
+	CREATE INDEX idx_users_email ON users(email);
+	CREATE INDEX idx_users_deleted_at ON users(deleted_at);
+
+	CREATE TABLE roles (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT UNIQUE NOT NULL,
+		description TEXT,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE INDEX idx_roles_name ON roles(name);
+
+	CREATE TABLE user_roles (
+		user_id INTEGER NOT NULL,
+		role_id INTEGER NOT NULL,
+		assigned_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (user_id, role_id),
+		FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+		FOREIGN KEY (role_id) REFERENCES roles(id) ON DELETE CASCADE
+	);
+	CREATE INDEX idx_user_roles_user_id ON user_roles(user_id);
+	CREATE INDEX idx_user_roles_role_id ON user_roles(role_id);
+
+	CREATE TABLE permissions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT UNIQUE NOT NULL,
+		description TEXT,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE role_permissions (
+		role_id INTEGER NOT NULL,
+		permission_id INTEGER NOT NULL,
+		granted_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (role_id, permission_id),
+		FOREIGN KEY (role_id) REFERENCES roles(id) ON DELETE CASCADE,
+		FOREIGN KEY (permission_id) REFERENCES permissions(id) ON DELETE CASCADE
+	);
+
+	CREATE TABLE user_identities (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL,
+		provider TEXT NOT NULL,
+		subject TEXT NOT NULL,
+		email TEXT,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE (provider, subject),
+		FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+	);
+
+	CREATE TABLE user_preferences (
+		user_id INTEGER NOT NULL,
+		key TEXT NOT NULL,
+		-- JSONB in PostgreSQL; SQLite would give a JSONB column numeric affinity and store 50 as an integer
+		value TEXT NOT NULL,
+
+		updated_at DATETIME NOT NULL,
+		PRIMARY KEY (user_id, key),
+		FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+	);
+
+	CREATE TABLE login_failures (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL,
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+	);
+	CREATE INDEX idx_login_failures_user_id_created_at ON login_failures(user_id, created_at);
+
+	CREATE TABLE password_history (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL,
+		password_hash TEXT NOT NULL,
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+	);
+	CREATE INDEX idx_password_history_user_id_created_at ON password_history(user_id, created_at);
+
+	CREATE TABLE login_attempts (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER,
+		email_hash TEXT NOT NULL DEFAULT '',
+		ip TEXT NOT NULL DEFAULT '',
+		user_agent TEXT NOT NULL DEFAULT '',
+		outcome TEXT NOT NULL,
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+	);
+	CREATE INDEX idx_login_attempts_user_id_created_at ON login_attempts(user_id, created_at);
+
+	CREATE TABLE policy_acceptances (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL,
+		document_type TEXT NOT NULL,
+		version TEXT NOT NULL,
+		ip_address TEXT NOT NULL DEFAULT '',
+		accepted_at DATETIME NOT NULL,
+		FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+	);
+
+	INSERT INTO roles (id, name, description) VALUES
+		(1, 'user', 'Standard user with basic permissions'),
+		(2, 'admin', 'Administrator with full system access');
+
+	INSERT INTO permissions (id, name, description) VALUES
+		(1, 'users:read', 'View any user profile and the user list'),
+		(2, 'users:write', 'Update any user profile'),
+		(3, 'users:delete', 'Delete any user');
+`
+
+// NewSQLiteDB 创建内存 SQLite 数据库，包含用户、角色、权限及相关表和 refresh_tokens 表，测试结束时关闭
+// 内存数据库的每个连接都是独立的库，因此连接池限制为一个连接
+func NewSQLiteDB(t testing.TB) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { _ = sqlDB.Close() })
+
+	require.NoError(t, db.Exec(userSchema).Error)
+	require.NoError(t, db.AutoMigrate(&auth.RefreshToken{}))
+	return db
+}
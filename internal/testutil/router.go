@@ -0,0 +1,42 @@
+package testutil
+
+import (
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/yeegeek/uyou-go-api-starter/internal/auth"
+	apiErrors "github.com/yeegeek/uyou-go-api-starter/internal/errors"
+)
+
+// RouterOption 配置 NewTestRouter 创建的路由
+type RouterOption func(*gin.Engine)
+
+// WithClaims 为每个请求注入已登录用户，相当于通过了认证中间件
+func WithClaims(claims *auth.Claims) RouterOption {
+	return func(r *gin.Engine) {
+		r.Use(func(c *gin.Context) {
+			c.Set(auth.KeyUser, claims)
+			c.Next()
+		})
+	}
+}
+
+// WithMiddleware 按顺序追加中间件
+func WithMiddleware(handlers ...gin.HandlerFunc) RouterOption {
+	return func(r *gin.Engine) {
+		r.Use(handlers...)
+	}
+}
+
+// NewTestRouter 创建测试模式的 Gin 路由，已挂载统一错误处理中间件，选项按顺序应用
+func NewTestRouter(t testing.TB, opts ...RouterOption) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(apiErrors.ErrorHandler())
+	for _, opt := range opts {
+		opt(router)
+	}
+	return router
+}
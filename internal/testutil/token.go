@@ -0,0 +1,33 @@
+package testutil
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yeegeek/uyou-go-api-starter/internal/auth"
+)
+
+// SignAccessToken 使用 secret 直接签发携带 claims 中用户、角色和权限的访问令牌，有效期一小时。
+// 不经过 auth.Service，测试可以构造数据库中不存在的用户或任意角色组合
+func SignAccessToken(t *testing.T, secret string, claims *auth.Claims) string {
+	t.Helper()
+
+	now := time.Now()
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub":   strconv.FormatUint(uint64(claims.UserID), 10),
+		"email": claims.Email,
+		"name":  claims.Name,
+		"roles": claims.Roles,
+		"perms": claims.Permissions,
+		"exp":   now.Add(time.Hour).Unix(),
+		"iat":   now.Unix(),
+		"nbf":   now.Unix(),
+	})
+	signed, err := token.SignedString([]byte(secret))
+	require.NoError(t, err)
+	return signed
+}
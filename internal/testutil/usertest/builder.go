@@ -0,0 +1,92 @@
+package usertest
+
+import (
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/yeegeek/uyou-go-api-starter/internal/user"
+)
+
+// roleIDs 与 testutil.NewSQLiteDB 中预置角色的 ID 一致
+var roleIDs = map[string]uint{
+	user.RoleUser:  1,
+	user.RoleAdmin: 2,
+}
+
+// UserBuilder 构造测试用户，默认是已启用、拥有 user 角色的普通用户
+//
+//	admin := usertest.NewUser().WithID(7).WithRole(user.RoleAdmin).Build()
+type UserBuilder struct {
+	u user.User
+}
+
+// NewUser 创建用户构造器
+func NewUser() *UserBuilder {
+	return &UserBuilder{u: user.User{
+		ID:           1,
+		Name:         "Test User",
+		Email:        "test@example.com",
+		PasswordHash: "hash",
+		Status:       user.UserStatusActive,
+		Active:       true,
+		Roles:        []user.Role{{ID: roleIDs[user.RoleUser], Name: user.RoleUser}},
+	}}
+}
+
+// WithID 设置用户 ID，传 0 表示由数据库分配
+func (b *UserBuilder) WithID(id uint) *UserBuilder {
+	b.u.ID = id
+	return b
+}
+
+// WithName 设置用户名称
+func (b *UserBuilder) WithName(name string) *UserBuilder {
+	b.u.Name = name
+	return b
+}
+
+// WithEmail 设置邮箱
+func (b *UserBuilder) WithEmail(email string) *UserBuilder {
+	b.u.Email = email
+	return b
+}
+
+// WithPassword 以最低 bcrypt cost 保存密码哈希，使 AuthenticateUser 能用 password 登录
+func (b *UserBuilder) WithPassword(password string) *UserBuilder {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	if err != nil {
+		panic(err)
+	}
+	b.u.PasswordHash = string(hash)
+	return b
+}
+
+// WithRole 追加角色，已有同名角色时不重复添加；预置角色（user、admin）使用与测试库一致的 ID
+func (b *UserBuilder) WithRole(name string) *UserBuilder {
+	for _, r := range b.u.Roles {
+		if r.Name == name {
+			return b
+		}
+	}
+	b.u.Roles = append(b.u.Roles, user.Role{ID: roleIDs[name], Name: name})
+	return b
+}
+
+// WithoutRoles 清空角色
+func (b *UserBuilder) WithoutRoles() *UserBuilder {
+	b.u.Roles = nil
+	return b
+}
+
+// Disabled 将用户设为已停用
+func (b *UserBuilder) Disabled() *UserBuilder {
+	b.u.Active = false
+	b.u.Status = user.UserStatusDisabled
+	return b
+}
+
+// Build 返回构造好的用户，每次调用返回独立的副本
+func (b *UserBuilder) Build() *user.User {
+	u := b.u
+	u.Roles = append([]user.Role(nil), b.u.Roles...)
+	return &u
+}
@@ -0,0 +1,28 @@
+package usertest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/yeegeek/uyou-go-api-starter/internal/user"
+)
+
+func TestUserBuilder(t *testing.T) {
+	b := NewUser().WithID(7).WithRole(user.RoleAdmin).WithRole(user.RoleAdmin).WithPassword("secret")
+	first := b.Build()
+	second := b.Build()
+
+	assert.Equal(t, uint(7), first.ID)
+	assert.True(t, first.IsAdmin())
+	assert.Len(t, first.Roles, 2)
+	assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(first.PasswordHash), []byte("secret")))
+
+	first.Roles[0].Name = "changed"
+	assert.Equal(t, user.RoleUser, second.Roles[0].Name, "Build returns independent copies")
+
+	disabled := NewUser().WithoutRoles().Disabled().Build()
+	assert.Empty(t, disabled.Roles)
+	assert.False(t, disabled.Active)
+}
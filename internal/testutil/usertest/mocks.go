@@ -0,0 +1,461 @@
+// Package usertest 提供 user 包接口的模拟实现和测试数据构造器，供 user 包以外的测试使用
+//
+// user 包内部的测试无法导入本包（会形成导入循环），使用 internal/user/mocks_test.go 中的同名实现；修改接口时两处需同步更新
+package usertest
+
+import (
+	"context"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+
+	"github.com/yeegeek/uyou-go-api-starter/internal/oauth"
+	"github.com/yeegeek/uyou-go-api-starter/internal/user"
+)
+
+// MockService 是 user.Service 的 testify 模拟实现
+type MockService struct {
+	mock.Mock
+}
+
+func (m *MockService) RegisterUser(ctx context.Context, req user.RegisterRequest) (*user.User, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*user.User), args.Error(1)
+}
+
+func (m *MockService) AuthenticateUser(ctx context.Context, req user.LoginRequest) (*user.User, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*user.User), args.Error(1)
+}
+
+func (m *MockService) GetUserByID(ctx context.Context, id uint) (*user.User, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*user.User), args.Error(1)
+}
+
+func (m *MockService) UpdateUser(ctx context.Context, id uint, req user.UpdateUserRequest) (*user.User, error) {
+	args := m.Called(ctx, id, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*user.User), args.Error(1)
+}
+
+func (m *MockService) PatchUser(ctx context.Context, id uint, req user.PatchUserRequest) (*user.User, error) {
+	args := m.Called(ctx, id, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*user.User), args.Error(1)
+}
+
+func (m *MockService) VerifyPassword(ctx context.Context, id uint, password string) error {
+	args := m.Called(ctx, id, password)
+	return args.Error(0)
+}
+
+func (m *MockService) LoginWithOAuth(ctx context.Context, provider string, profile *oauth.Profile) (*user.User, error) {
+	args := m.Called(ctx, provider, profile)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*user.User), args.Error(1)
+}
+
+func (m *MockService) GetEffectivePermissions(ctx context.Context, id uint) (*user.EffectivePermissions, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*user.EffectivePermissions), args.Error(1)
+}
+
+func (m *MockService) DeleteUser(ctx context.Context, id uint) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockService) ListUsers(ctx context.Context, filters user.UserFilterParams, page, perPage int) ([]user.User, int64, error) {
+	args := m.Called(ctx, filters, page, perPage)
+	if args.Get(0) == nil {
+		return nil, args.Get(1).(int64), args.Error(2)
+	}
+	return args.Get(0).([]user.User), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockService) PromoteToAdmin(ctx context.Context, userID uint) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockService) SetUserActive(ctx context.Context, id uint, active bool) (*user.User, error) {
+	args := m.Called(ctx, id, active)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*user.User), args.Error(1)
+}
+
+func (m *MockService) ResetPassword(ctx context.Context, id uint) (string, error) {
+	args := m.Called(ctx, id)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockService) ChangePassword(ctx context.Context, id uint, currentPassword, newPassword string) error {
+	args := m.Called(ctx, id, currentPassword, newPassword)
+	return args.Error(0)
+}
+
+func (m *MockService) GetLockoutStatus(ctx context.Context, id uint) (*user.LockoutStatus, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*user.LockoutStatus), args.Error(1)
+}
+
+func (m *MockService) ClearLockout(ctx context.Context, id uint) (*user.LockoutStatus, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*user.LockoutStatus), args.Error(1)
+}
+
+func (m *MockService) GetUserStatistics(ctx context.Context) (*user.UserStatistics, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*user.UserStatistics), args.Error(1)
+}
+
+func (m *MockService) ListLoginHistory(ctx context.Context, userID uint, limit, offset int) ([]user.LoginAttempt, error) {
+	args := m.Called(ctx, userID, limit, offset)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]user.LoginAttempt), args.Error(1)
+}
+
+func (m *MockService) GetLastLoginAt(ctx context.Context, userID uint) (*time.Time, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*time.Time), args.Error(1)
+}
+
+func (m *MockService) ConfirmEmailChange(ctx context.Context, token string) (*user.User, error) {
+	args := m.Called(ctx, token)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*user.User), args.Error(1)
+}
+
+// MockRepository 是 user.Repository 的 testify 模拟实现；Transaction 直接在当前 ctx 中执行 fn
+type MockRepository struct {
+	mock.Mock
+}
+
+func (m *MockRepository) Create(ctx context.Context, u *user.User) error {
+	args := m.Called(ctx, u)
+	return args.Error(0)
+}
+
+func (m *MockRepository) FindByEmail(ctx context.Context, email string) (*user.User, error) {
+	args := m.Called(ctx, email)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*user.User), args.Error(1)
+}
+
+func (m *MockRepository) FindByID(ctx context.Context, id uint) (*user.User, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*user.User), args.Error(1)
+}
+
+func (m *MockRepository) FindPreferences(ctx context.Context, userID uint) ([]user.UserPreference, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]user.UserPreference), args.Error(1)
+}
+
+func (m *MockRepository) UpsertPreferences(ctx context.Context, prefs []user.UserPreference) error {
+	args := m.Called(ctx, prefs)
+	return args.Error(0)
+}
+
+func (m *MockRepository) PruneOrphanedRoleAssignments(ctx context.Context) (int, error) {
+	args := m.Called(ctx)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockRepository) FindByEmailChangeToken(ctx context.Context, tokenHash string) (*user.User, error) {
+	args := m.Called(ctx, tokenHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*user.User), args.Error(1)
+}
+
+func (m *MockRepository) Update(ctx context.Context, u *user.User) error {
+	args := m.Called(ctx, u)
+	return args.Error(0)
+}
+
+func (m *MockRepository) Delete(ctx context.Context, id uint) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockRepository) ListAllUsers(ctx context.Context, filters user.UserFilterParams, page, perPage int) ([]user.User, int64, error) {
+	args := m.Called(ctx, filters, page, perPage)
+	if args.Get(0) == nil {
+		return nil, args.Get(1).(int64), args.Error(2)
+	}
+	return args.Get(0).([]user.User), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockRepository) AssignRole(ctx context.Context, userID uint, roleName string) error {
+	args := m.Called(ctx, userID, roleName)
+	return args.Error(0)
+}
+
+func (m *MockRepository) RemoveRole(ctx context.Context, userID uint, roleName string) error {
+	args := m.Called(ctx, userID, roleName)
+	return args.Error(0)
+}
+
+func (m *MockRepository) AssignRoleBulk(ctx context.Context, roleID uint, userIDs []uint) ([]uint, error) {
+	args := m.Called(ctx, roleID, userIDs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]uint), args.Error(1)
+}
+
+func (m *MockRepository) RemoveRoleBulk(ctx context.Context, roleID uint, userIDs []uint) ([]uint, error) {
+	args := m.Called(ctx, roleID, userIDs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]uint), args.Error(1)
+}
+
+func (m *MockRepository) FindExistingUserIDs(ctx context.Context, ids []uint) ([]uint, error) {
+	args := m.Called(ctx, ids)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]uint), args.Error(1)
+}
+
+func (m *MockRepository) SetActive(ctx context.Context, id uint, active bool) error {
+	args := m.Called(ctx, id, active)
+	return args.Error(0)
+}
+
+func (m *MockRepository) UpdatePassword(ctx context.Context, id uint, passwordHash string) error {
+	args := m.Called(ctx, id, passwordHash)
+	return args.Error(0)
+}
+
+func (m *MockRepository) ListPasswordHistory(ctx context.Context, userID uint, limit int) ([]string, error) {
+	args := m.Called(ctx, userID, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockRepository) RecordPasswordHistory(ctx context.Context, userID uint, passwordHash string, keep int) error {
+	args := m.Called(ctx, userID, passwordHash, keep)
+	return args.Error(0)
+}
+
+func (m *MockRepository) RecordLoginFailure(ctx context.Context, userID uint, at, pruneBefore time.Time) error {
+	args := m.Called(ctx, userID, at, pruneBefore)
+	return args.Error(0)
+}
+
+func (m *MockRepository) ListLoginFailures(ctx context.Context, userID uint, since time.Time) ([]time.Time, error) {
+	args := m.Called(ctx, userID, since)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]time.Time), args.Error(1)
+}
+
+func (m *MockRepository) ClearLoginFailures(ctx context.Context, userID uint) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockRepository) CreateLoginAttempts(ctx context.Context, attempts []user.LoginAttempt) error {
+	args := m.Called(ctx, attempts)
+	return args.Error(0)
+}
+
+func (m *MockRepository) ListLoginAttempts(ctx context.Context, userID uint, limit, offset int) ([]user.LoginAttempt, error) {
+	args := m.Called(ctx, userID, limit, offset)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]user.LoginAttempt), args.Error(1)
+}
+
+func (m *MockRepository) CreatePolicyAcceptances(ctx context.Context, acceptances []user.PolicyAcceptance) error {
+	args := m.Called(ctx, acceptances)
+	return args.Error(0)
+}
+
+func (m *MockRepository) FindPolicyAcceptances(ctx context.Context, userID uint) ([]user.PolicyAcceptance, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]user.PolicyAcceptance), args.Error(1)
+}
+
+func (m *MockRepository) ListPolicyAcceptances(ctx context.Context, filter user.PolicyAcceptanceFilter, page, perPage int) ([]user.PolicyAcceptance, int64, error) {
+	args := m.Called(ctx, filter, page, perPage)
+	if args.Get(0) == nil {
+		return nil, 0, args.Error(2)
+	}
+	return args.Get(0).([]user.PolicyAcceptance), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockRepository) FindLastSuccessfulLogin(ctx context.Context, userID uint) (*time.Time, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*time.Time), args.Error(1)
+}
+
+func (m *MockRepository) DeleteLoginAttemptsBefore(ctx context.Context, before time.Time) (int64, error) {
+	args := m.Called(ctx, before)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockRepository) FindRoleByName(ctx context.Context, roleName string) (*user.Role, error) {
+	args := m.Called(ctx, roleName)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*user.Role), args.Error(1)
+}
+
+func (m *MockRepository) GetUserRoles(ctx context.Context, userID uint) ([]user.Role, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]user.Role), args.Error(1)
+}
+
+func (m *MockRepository) FindRoleByID(ctx context.Context, id uint) (*user.Role, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*user.Role), args.Error(1)
+}
+
+func (m *MockRepository) CreateRole(ctx context.Context, role *user.Role) error {
+	args := m.Called(ctx, role)
+	return args.Error(0)
+}
+
+func (m *MockRepository) ListRoles(ctx context.Context) ([]user.Role, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]user.Role), args.Error(1)
+}
+
+func (m *MockRepository) UpdateRole(ctx context.Context, role *user.Role) error {
+	args := m.Called(ctx, role)
+	return args.Error(0)
+}
+
+func (m *MockRepository) DeleteRole(ctx context.Context, id uint) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockRepository) ListPermissions(ctx context.Context) ([]user.Permission, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]user.Permission), args.Error(1)
+}
+
+func (m *MockRepository) FindPermissionsByNames(ctx context.Context, names []string) ([]user.Permission, error) {
+	args := m.Called(ctx, names)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]user.Permission), args.Error(1)
+}
+
+func (m *MockRepository) SetRolePermissions(ctx context.Context, roleID uint, permissionIDs []uint) error {
+	args := m.Called(ctx, roleID, permissionIDs)
+	return args.Error(0)
+}
+
+func (m *MockRepository) CountUsers(ctx context.Context) (int64, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockRepository) CountUsersByRole(ctx context.Context, roleName string) (int64, error) {
+	args := m.Called(ctx, roleName)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockRepository) CountUsersSince(ctx context.Context, since time.Time) (int64, error) {
+	args := m.Called(ctx, since)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockRepository) FindIdentity(ctx context.Context, provider, subject string) (*user.UserIdentity, error) {
+	args := m.Called(ctx, provider, subject)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*user.UserIdentity), args.Error(1)
+}
+
+func (m *MockRepository) CreateIdentity(ctx context.Context, identity *user.UserIdentity) error {
+	args := m.Called(ctx, identity)
+	return args.Error(0)
+}
+
+func (m *MockRepository) Transaction(ctx context.Context, fn func(context.Context) error) error {
+	return fn(ctx)
+}
+
+var (
+	_ user.Service    = (*MockService)(nil)
+	_ user.Repository = (*MockRepository)(nil)
+)
@@ -7,144 +7,146 @@ import (
 	"time"
 
 	"github.com/yeegeek/uyou-go-api-starter/internal/config"
-	"github.com/yeegeek/uyou-go-api-starter/internal/redis"
 )
 
-// AccountLockoutService 账户锁定服务
-type AccountLockoutService struct {
-	cache              *redis.Cache
-	maxAttempts        int
-	lockoutDuration    time.Duration
-	enabled            bool
-}
+const (
+	// 未配置时的默认锁定策略，与 configs/config.yaml 保持一致
+	defaultMaxLoginAttempts = 5
+	defaultLockoutDuration  = 15 * time.Minute
+)
 
-// NewAccountLockoutService 创建账户锁定服务
-func NewAccountLockoutService(cache *redis.Cache, cfg *config.SecurityConfig) *AccountLockoutService {
-	lockoutDuration := time.Duration(cfg.LockoutDuration) * time.Minute
-	if lockoutDuration == 0 {
-		lockoutDuration = 15 * time.Minute
-	}
+// LoginFailure 一次密码错误的登录尝试
+// 锁定状态完全由锁定窗口内的失败记录推导，登录成功或管理员解锁时删除
+type LoginFailure struct {
+	ID        uint      `gorm:"primaryKey"`
+	UserID    uint      `gorm:"not null;index"`
+	CreatedAt time.Time `gorm:"not null"`
+}
 
-	maxAttempts := cfg.MaxLoginAttempts
-	if maxAttempts == 0 {
-		maxAttempts = 5
-	}
+// TableName 指定登录失败记录对应的数据库表名
+func (LoginFailure) TableName() string {
+	return "login_failures"
+}
 
-	return &AccountLockoutService{
-		cache:           cache,
-		maxAttempts:     maxAttempts,
-		lockoutDuration: lockoutDuration,
-		enabled:         cache != nil,
-	}
+// LockoutStatus 账户锁定状态
+type LockoutStatus struct {
+	// 锁定窗口内的失败次数
+	FailedAttempts int
+	MaxAttempts    int
+	Locked         bool
+	// 锁定到期时间，未锁定时为 nil
+	LockedUntil *time.Time
+	// 锁定窗口内的失败时间，最近的在前
+	RecentFailures []time.Time
 }
 
-// RecordFailedAttempt 记录登录失败尝试
-func (s *AccountLockoutService) RecordFailedAttempt(ctx context.Context, email string) error {
-	if !s.enabled {
-		return nil
+// RetryAfter 距离解锁的剩余时间，未锁定或已到期时为 0
+func (st *LockoutStatus) RetryAfter(now time.Time) time.Duration {
+	if !st.Locked || st.LockedUntil == nil || !st.LockedUntil.After(now) {
+		return 0
 	}
+	return st.LockedUntil.Sub(now)
+}
 
-	key := s.getAttemptsKey(email)
-	
-	// 获取当前失败次数
-	attempts, err := s.cache.Increment(ctx, key, 1)
-	if err != nil {
-		return fmt.Errorf("failed to increment login attempts: %w", err)
-	}
+// AccountLockedError 登录失败次数过多导致账户被锁定，errors.Is(err, ErrAccountLocked) 成立
+type AccountLockedError struct {
+	Until time.Time
+}
 
-	// 设置过期时间（如果是第一次失败）
-	if attempts == 1 {
-		if err := s.cache.Expire(ctx, key, s.lockoutDuration); err != nil {
-			return fmt.Errorf("failed to set expiration: %w", err)
-		}
-	}
+func (e *AccountLockedError) Error() string {
+	return fmt.Sprintf("account locked until %s", e.Until.UTC().Format(time.RFC3339))
+}
 
-	// 如果达到最大尝试次数，锁定账户
-	if attempts >= int64(s.maxAttempts) {
-		lockKey := s.getLockKey(email)
-		if err := s.cache.Set(ctx, lockKey, "locked", s.lockoutDuration); err != nil {
-			return fmt.Errorf("failed to lock account: %w", err)
-		}
-	}
+// Is 使 errors.Is 能以 ErrAccountLocked 匹配
+func (e *AccountLockedError) Is(target error) bool {
+	return target == ErrAccountLocked
+}
 
-	return nil
+// lockoutPolicy 锁定策略：窗口内失败次数达到上限即锁定，锁定持续到最后一次失败后一个窗口
+// 锁定期间的登录请求在校验密码前即被拒绝，不再记录失败，因此窗口内的记录数不超过上限
+type lockoutPolicy struct {
+	maxAttempts int
+	window      time.Duration
 }
 
-// IsAccountLocked 检查账户是否被锁定
-func (s *AccountLockoutService) IsAccountLocked(ctx context.Context, email string) (bool, time.Duration, error) {
-	if !s.enabled {
-		return false, 0, nil
+func newLockoutPolicy(cfg *config.SecurityConfig) lockoutPolicy {
+	p := lockoutPolicy{maxAttempts: cfg.MaxLoginAttempts, window: time.Duration(cfg.LockoutDuration) * time.Minute}
+	if p.maxAttempts <= 0 {
+		p.maxAttempts = defaultMaxLoginAttempts
 	}
-
-	lockKey := s.getLockKey(email)
-	
-	var locked string
-	err := s.cache.Get(ctx, lockKey, &locked)
-	if err != nil {
-		// 键不存在，账户未锁定
-		return false, 0, nil
+	if p.window <= 0 {
+		p.window = defaultLockoutDuration
 	}
-
-	// 获取剩余锁定时间
-	ttl, err := s.cache.TTL(ctx, lockKey)
-	if err != nil {
-		return true, 0, err
-	}
-
-	return true, ttl, nil
+	return p
 }
 
-// ResetFailedAttempts 重置登录失败次数（登录成功后调用）
-func (s *AccountLockoutService) ResetFailedAttempts(ctx context.Context, email string) error {
-	if !s.enabled {
-		return nil
+// status 由窗口内的失败时间（最近的在前）计算锁定状态
+func (p lockoutPolicy) status(failures []time.Time) *LockoutStatus {
+	st := &LockoutStatus{
+		FailedAttempts: len(failures),
+		MaxAttempts:    p.maxAttempts,
+		RecentFailures: failures,
 	}
-
-	attemptsKey := s.getAttemptsKey(email)
-	lockKey := s.getLockKey(email)
-
-	// 删除失败次数记录
-	if err := s.cache.Delete(ctx, attemptsKey); err != nil {
-		return fmt.Errorf("failed to delete attempts: %w", err)
+	if len(failures) >= p.maxAttempts {
+		until := failures[0].Add(p.window)
+		st.Locked = true
+		st.LockedUntil = &until
 	}
+	return st
+}
 
-	// 删除锁定记录
-	if err := s.cache.Delete(ctx, lockKey); err != nil {
-		return fmt.Errorf("failed to delete lock: %w", err)
+// GetLockoutStatus returns the user's failed login count, lock expiry and recent failure times
+func (s *service) GetLockoutStatus(ctx context.Context, id uint) (*LockoutStatus, error) {
+	if err := s.ensureUserExists(ctx, id); err != nil {
+		return nil, err
 	}
-
-	return nil
+	return s.lockoutStatus(ctx, id)
 }
 
-// GetRemainingAttempts 获取剩余尝试次数
-func (s *AccountLockoutService) GetRemainingAttempts(ctx context.Context, email string) (int, error) {
-	if !s.enabled {
-		return s.maxAttempts, nil
+// ClearLockout resets the user's failed login attempts and lifts any lock.
+// Clearing an account that is not locked is a no-op; the status before clearing is returned for auditing.
+func (s *service) ClearLockout(ctx context.Context, id uint) (*LockoutStatus, error) {
+	if err := s.ensureUserExists(ctx, id); err != nil {
+		return nil, err
 	}
-
-	key := s.getAttemptsKey(email)
-	
-	var attempts int64
-	err := s.cache.Get(ctx, key, &attempts)
+	previous, err := s.lockoutStatus(ctx, id)
 	if err != nil {
-		// 键不存在，返回最大尝试次数
-		return s.maxAttempts, nil
+		return nil, err
 	}
-
-	remaining := s.maxAttempts - int(attempts)
-	if remaining < 0 {
-		remaining = 0
+	if previous.FailedAttempts == 0 {
+		return previous, nil
+	}
+	if err := s.repo.ClearLoginFailures(ctx, id); err != nil {
+		return nil, fmt.Errorf("failed to clear login failures: %w", err)
 	}
+	return previous, nil
+}
 
-	return remaining, nil
+// lockoutStatus computes the lock state from the failures inside the lockout window
+func (s *service) lockoutStatus(ctx context.Context, userID uint) (*LockoutStatus, error) {
+	failures, err := s.repo.ListLoginFailures(ctx, userID, time.Now().Add(-s.lockout.window))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load login failures: %w", err)
+	}
+	return s.lockout.status(failures), nil
 }
 
-// getAttemptsKey 获取失败尝试次数的缓存键
-func (s *AccountLockoutService) getAttemptsKey(email string) string {
-	return fmt.Sprintf("login:attempts:%s", email)
+// recordLoginFailure stores a failed password attempt and prunes failures that left the lockout window
+func (s *service) recordLoginFailure(ctx context.Context, userID uint) error {
+	now := time.Now()
+	if err := s.repo.RecordLoginFailure(ctx, userID, now, now.Add(-s.lockout.window)); err != nil {
+		return fmt.Errorf("failed to record login failure: %w", err)
+	}
+	return nil
 }
 
-// getLockKey 获取账户锁定的缓存键
-func (s *AccountLockoutService) getLockKey(email string) string {
-	return fmt.Sprintf("login:locked:%s", email)
+func (s *service) ensureUserExists(ctx context.Context, id uint) error {
+	user, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to find user: %w", err)
+	}
+	if user == nil {
+		return ErrUserNotFound
+	}
+	return nil
 }
@@ -0,0 +1,145 @@
+package user
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yeegeek/uyou-go-api-starter/internal/config"
+	"github.com/yeegeek/uyou-go-api-starter/internal/testutil"
+)
+
+func TestLockoutPolicy_Status(t *testing.T) {
+	policy := newLockoutPolicy(&config.SecurityConfig{MaxLoginAttempts: 3, LockoutDuration: 10})
+	now := time.Now()
+
+	status := policy.status([]time.Time{now, now.Add(-time.Minute)})
+	assert.False(t, status.Locked)
+	assert.Nil(t, status.LockedUntil)
+	assert.Equal(t, 2, status.FailedAttempts)
+	assert.Zero(t, status.RetryAfter(now))
+
+	status = policy.status([]time.Time{now, now.Add(-time.Minute), now.Add(-2 * time.Minute)})
+	require.True(t, status.Locked)
+	assert.Equal(t, now.Add(10*time.Minute), *status.LockedUntil, "the lock lasts one window from the latest failure")
+	assert.Equal(t, 10*time.Minute, status.RetryAfter(now))
+}
+
+func TestLockoutPolicy_Defaults(t *testing.T) {
+	policy := newLockoutPolicy(&config.SecurityConfig{})
+	assert.Equal(t, defaultMaxLoginAttempts, policy.maxAttempts)
+	assert.Equal(t, defaultLockoutDuration, policy.window)
+}
+
+func TestService_Lockout_LocksAfterRepeatedFailuresUntilCleared(t *testing.T) {
+	db := testutil.NewSQLiteDB(t)
+	service := NewService(NewRepository(db), newTestSecurityConfig())
+	ctx := context.Background()
+
+	credentials := LoginRequest{Email: "jane@example.com", Password: "Password123!"}
+	registered, err := service.RegisterUser(ctx, RegisterRequest{Name: "Jane Doe", Email: credentials.Email, Password: credentials.Password})
+	require.NoError(t, err)
+
+	wrong := LoginRequest{Email: credentials.Email, Password: "WrongPassword1!"}
+	for i := 0; i < 5; i++ {
+		_, err = service.AuthenticateUser(ctx, wrong)
+		require.ErrorIs(t, err, ErrInvalidCredentials)
+	}
+
+	_, err = service.AuthenticateUser(ctx, credentials)
+	require.ErrorIs(t, err, ErrAccountLocked, "the correct password is rejected while locked")
+	var locked *AccountLockedError
+	require.ErrorAs(t, err, &locked)
+	assert.WithinDuration(t, time.Now().Add(15*time.Minute), locked.Until, 5*time.Second)
+
+	status, err := service.GetLockoutStatus(ctx, registered.ID)
+	require.NoError(t, err)
+	assert.True(t, status.Locked)
+	assert.Equal(t, 5, status.FailedAttempts, "attempts while locked are not recorded")
+	assert.Len(t, status.RecentFailures, 5)
+
+	previous, err := service.ClearLockout(ctx, registered.ID)
+	require.NoError(t, err)
+	assert.True(t, previous.Locked)
+	assert.Equal(t, 5, previous.FailedAttempts)
+
+	user, err := service.AuthenticateUser(ctx, credentials)
+	require.NoError(t, err)
+	assert.Equal(t, registered.ID, user.ID)
+}
+
+func TestService_Lockout_SuccessfulLoginResetsFailures(t *testing.T) {
+	db := testutil.NewSQLiteDB(t)
+	service := NewService(NewRepository(db), newTestSecurityConfig())
+	ctx := context.Background()
+
+	credentials := LoginRequest{Email: "jane@example.com", Password: "Password123!"}
+	registered, err := service.RegisterUser(ctx, RegisterRequest{Name: "Jane Doe", Email: credentials.Email, Password: credentials.Password})
+	require.NoError(t, err)
+
+	for i := 0; i < 4; i++ {
+		_, err = service.AuthenticateUser(ctx, LoginRequest{Email: credentials.Email, Password: "WrongPassword1!"})
+		require.ErrorIs(t, err, ErrInvalidCredentials)
+	}
+	_, err = service.AuthenticateUser(ctx, credentials)
+	require.NoError(t, err)
+
+	status, err := service.GetLockoutStatus(ctx, registered.ID)
+	require.NoError(t, err)
+	assert.Zero(t, status.FailedAttempts)
+	assert.Empty(t, status.RecentFailures)
+}
+
+func TestService_Lockout_ExpiresAfterWindow(t *testing.T) {
+	db := testutil.NewSQLiteDB(t)
+	repo := NewRepository(db)
+	service := NewService(repo, newTestSecurityConfig())
+	ctx := context.Background()
+
+	credentials := LoginRequest{Email: "jane@example.com", Password: "Password123!"}
+	registered, err := service.RegisterUser(ctx, RegisterRequest{Name: "Jane Doe", Email: credentials.Email, Password: credentials.Password})
+	require.NoError(t, err)
+
+	lockedAt := time.Now().Add(-16 * time.Minute)
+	for i := 0; i < 5; i++ {
+		require.NoError(t, repo.RecordLoginFailure(ctx, registered.ID, lockedAt, lockedAt.Add(-time.Hour)))
+	}
+
+	status, err := service.GetLockoutStatus(ctx, registered.ID)
+	require.NoError(t, err)
+	assert.False(t, status.Locked)
+
+	_, err = service.AuthenticateUser(ctx, credentials)
+	require.NoError(t, err)
+}
+
+func TestService_ClearLockout(t *testing.T) {
+	t.Run("user not found", func(t *testing.T) {
+		mockRepo := &MockRepository{}
+		mockRepo.On("FindByID", mock.Anything, uint(999)).Return(nil, nil)
+
+		service := NewService(mockRepo, newTestSecurityConfig())
+		status, err := service.ClearLockout(context.Background(), 999)
+
+		assert.ErrorIs(t, err, ErrUserNotFound)
+		assert.Nil(t, status)
+	})
+
+	t.Run("account not locked is a no-op", func(t *testing.T) {
+		mockRepo := &MockRepository{}
+		mockRepo.On("FindByID", mock.Anything, uint(1)).Return(&User{ID: 1}, nil)
+		mockRepo.On("ListLoginFailures", mock.Anything, uint(1), mock.Anything).Return([]time.Time{}, nil)
+
+		service := NewService(mockRepo, newTestSecurityConfig())
+		status, err := service.ClearLockout(context.Background(), 1)
+
+		require.NoError(t, err)
+		assert.False(t, status.Locked)
+		assert.Zero(t, status.FailedAttempts)
+		mockRepo.AssertNotCalled(t, "ClearLoginFailures", mock.Anything, mock.Anything)
+	})
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/yeegeek/uyou-go-api-starter/internal/oauth"
 	"github.com/yeegeek/uyou-go-api-starter/internal/redis"
 )
 
@@ -73,6 +74,20 @@ func (s *CachedService) UpdateUser(ctx context.Context, id uint, req UpdateUserR
 	return user, nil
 }
 
+// PatchUser 部分更新用户信息（清除缓存）
+func (s *CachedService) PatchUser(ctx context.Context, id uint, req PatchUserRequest) (*User, error) {
+	user, err := s.service.PatchUser(ctx, id, req)
+	if err != nil {
+		return nil, err
+	}
+
+	// 清除缓存
+	cacheKey := fmt.Sprintf("user:%d", id)
+	_ = s.cache.Delete(ctx, cacheKey)
+
+	return user, nil
+}
+
 // DeleteUser 删除用户（清除缓存）
 func (s *CachedService) DeleteUser(ctx context.Context, id uint) error {
 	err := s.service.DeleteUser(ctx, id)
@@ -97,6 +112,21 @@ func (s *CachedService) AuthenticateUser(ctx context.Context, req LoginRequest)
 	return s.service.AuthenticateUser(ctx, req)
 }
 
+// VerifyPassword 校验当前密码（不缓存）
+func (s *CachedService) VerifyPassword(ctx context.Context, id uint, password string) error {
+	return s.service.VerifyPassword(ctx, id, password)
+}
+
+// LoginWithOAuth 第三方登录（不缓存）
+func (s *CachedService) LoginWithOAuth(ctx context.Context, provider string, profile *oauth.Profile) (*User, error) {
+	return s.service.LoginWithOAuth(ctx, provider, profile)
+}
+
+// GetEffectivePermissions 查询当前角色和权限（不缓存，角色变更需立即生效）
+func (s *CachedService) GetEffectivePermissions(ctx context.Context, id uint) (*EffectivePermissions, error) {
+	return s.service.GetEffectivePermissions(ctx, id)
+}
+
 // ListUsers 列出用户（不缓存）
 func (s *CachedService) ListUsers(ctx context.Context, filters UserFilterParams, page, perPage int) ([]User, int64, error) {
 	return s.service.ListUsers(ctx, filters, page, perPage)
@@ -116,6 +146,86 @@ func (s *CachedService) PromoteToAdmin(ctx context.Context, userID uint) error {
 	return nil
 }
 
+// SetUserActive 停用或启用用户（清除缓存）
+func (s *CachedService) SetUserActive(ctx context.Context, id uint, active bool) (*User, error) {
+	user, err := s.service.SetUserActive(ctx, id, active)
+	if err != nil {
+		return nil, err
+	}
+
+	// 清除缓存
+	cacheKey := fmt.Sprintf("user:%d", id)
+	_ = s.cache.Delete(ctx, cacheKey)
+
+	return user, nil
+}
+
+// ResetPassword 重置为临时密码（清除缓存）
+func (s *CachedService) ResetPassword(ctx context.Context, id uint) (string, error) {
+	password, err := s.service.ResetPassword(ctx, id)
+	if err != nil {
+		return "", err
+	}
+
+	// 清除缓存
+	cacheKey := fmt.Sprintf("user:%d", id)
+	_ = s.cache.Delete(ctx, cacheKey)
+
+	return password, nil
+}
+
+// ChangePassword 修改密码（清除缓存）
+func (s *CachedService) ChangePassword(ctx context.Context, id uint, currentPassword, newPassword string) error {
+	if err := s.service.ChangePassword(ctx, id, currentPassword, newPassword); err != nil {
+		return err
+	}
+
+	// 清除缓存
+	cacheKey := fmt.Sprintf("user:%d", id)
+	_ = s.cache.Delete(ctx, cacheKey)
+
+	return nil
+}
+
+// GetLockoutStatus 获取账户锁定状态（不缓存）
+func (s *CachedService) GetLockoutStatus(ctx context.Context, id uint) (*LockoutStatus, error) {
+	return s.service.GetLockoutStatus(ctx, id)
+}
+
+// ClearLockout 清除账户锁定状态
+func (s *CachedService) ClearLockout(ctx context.Context, id uint) (*LockoutStatus, error) {
+	return s.service.ClearLockout(ctx, id)
+}
+
+// GetUserStatistics 获取用户统计（不缓存）
+func (s *CachedService) GetUserStatistics(ctx context.Context) (*UserStatistics, error) {
+	return s.service.GetUserStatistics(ctx)
+}
+
+// ListLoginHistory 获取登录历史（不缓存）
+func (s *CachedService) ListLoginHistory(ctx context.Context, userID uint, limit, offset int) ([]LoginAttempt, error) {
+	return s.service.ListLoginHistory(ctx, userID, limit, offset)
+}
+
+// GetLastLoginAt 获取最近一次登录成功时间（不缓存）
+func (s *CachedService) GetLastLoginAt(ctx context.Context, userID uint) (*time.Time, error) {
+	return s.service.GetLastLoginAt(ctx, userID)
+}
+
+// ConfirmEmailChange 确认邮箱变更（清除缓存）
+func (s *CachedService) ConfirmEmailChange(ctx context.Context, token string) (*User, error) {
+	user, err := s.service.ConfirmEmailChange(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	// 清除缓存
+	cacheKey := fmt.Sprintf("user:%d", user.ID)
+	_ = s.cache.Delete(ctx, cacheKey)
+
+	return user, nil
+}
+
 // InvalidateUserCache 使用户缓存失效
 func (s *CachedService) InvalidateUserCache(ctx context.Context, userID uint) error {
 	cacheKey := fmt.Sprintf("user:%d", userID)
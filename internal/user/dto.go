@@ -1,17 +1,31 @@
 // Package user 定义用户相关的数据传输对象（DTO）
 package user
 
+import (
+	"time"
+
+	"github.com/yeegeek/uyou-go-api-starter/internal/auth"
+)
+
 // RegisterRequest represents registration request payload
 type RegisterRequest struct {
-	Name     string `json:"name" binding:"required,min=2,max=100"`
-	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required,min=6"`
+	Name     string `json:"name" binding:"required,min=2,max=100" example:"Jane Doe"`
+	Email    string `json:"email" binding:"required,email" example:"jane@example.com"`
+	Password string `json:"password" binding:"required,min=6" example:"SecurePass123!"`
+	// ClientID selects a registered client's token lifetimes; the X-Client-Id header is used when empty
+	ClientID string `json:"client_id,omitempty" binding:"omitempty,max=64" example:"mobile"`
+	// AcceptTerms must be true when policies are configured; the current version of every policy is recorded as accepted
+	AcceptTerms bool `json:"accept_terms,omitempty" example:"true"`
 }
 
 // LoginRequest represents login request payload
 type LoginRequest struct {
-	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required"`
+	Email    string `json:"email" binding:"required,email" example:"jane@example.com"`
+	Password string `json:"password" binding:"required" example:"SecurePass123!"`
+	// RememberMe issues a longer-lived refresh token for trusted devices
+	RememberMe bool `json:"remember_me" example:"false"`
+	// ClientID selects a registered client's token lifetimes; the X-Client-Id header is used when empty
+	ClientID string `json:"client_id,omitempty" binding:"omitempty,max=64" example:"mobile"`
 }
 
 // UpdateUserRequest represents user update request payload
@@ -20,25 +34,127 @@ type UpdateUserRequest struct {
 	Email string `json:"email" binding:"omitempty,email"`
 }
 
-// UserResponse represents user response (without sensitive fields)
+// PatchUserRequest represents a partial user update payload.
+// Nil fields are left unchanged; provided fields are validated and applied.
+type PatchUserRequest struct {
+	Name  *string `json:"name" binding:"omitempty,min=2,max=100"`
+	Email *string `json:"email" binding:"omitempty,email"`
+}
+
+// VerifyEmailChangeRequest confirms a pending email change with the token sent to the new address
+type VerifyEmailChangeRequest struct {
+	Token string `json:"token" binding:"required" example:"q3Z8n1x0vYb5Jk2mWc7dRf4TgHs9LpAe6uNiOo3KwEy"`
+}
+
+// DeleteMeRequest confirms account deletion with the current password
+type DeleteMeRequest struct {
+	Password string `json:"password" binding:"required" example:"SecurePass123!"`
+}
+
+// ChangePasswordRequest replaces the current user's password; the current password is required
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required" example:"SecurePass123!"`
+	NewPassword     string `json:"new_password" binding:"required" example:"EvenMoreSecure456!"`
+}
+
+// UserResponse represents user response (without sensitive fields).
+// pending_email is the new address awaiting verification; email stays in use until it is confirmed
 type UserResponse struct {
-	ID        uint     `json:"id"`
-	Name      string   `json:"name"`
-	Email     string   `json:"email"`
-	Roles     []string `json:"roles"`
-	CreatedAt string   `json:"created_at"`
-	UpdatedAt string   `json:"updated_at"`
+	ID           uint     `json:"id"`
+	Name         string   `json:"name"`
+	Email        string   `json:"email"`
+	PendingEmail string   `json:"pending_email,omitempty" example:"new@example.com"`
+	Roles        []string `json:"roles"`
+	Active       bool     `json:"active"`
+	CreatedAt    string   `json:"created_at"`
+	UpdatedAt    string   `json:"updated_at"`
+}
+
+// UserResponseV2 represents the v2 user response: the identifier is exposed as
+// user_id and timestamps are RFC3339 in UTC
+type UserResponseV2 struct {
+	UserID       uint     `json:"user_id"`
+	Name         string   `json:"name"`
+	Email        string   `json:"email"`
+	PendingEmail string   `json:"pending_email,omitempty" example:"new@example.com"`
+	Roles        []string `json:"roles"`
+	Active       bool     `json:"active"`
+	CreatedAt    string   `json:"created_at"`
+	UpdatedAt    string   `json:"updated_at"`
+}
+
+// PublicUserResponse represents a user as shown to callers other than the user themselves and
+// holders of users:read. It lists the fields that may be shown, so email, pending_email, roles
+// and account state are never part of it, nor are profile fields added to UserResponse later
+type PublicUserResponse struct {
+	ID   uint   `json:"id" example:"7"`
+	Name string `json:"name" example:"Jane Doe"`
+}
+
+// PublicUserResponseV2 is the v2 shape of PublicUserResponse
+type PublicUserResponseV2 struct {
+	UserID uint   `json:"user_id" example:"7"`
+	Name   string `json:"name" example:"Jane Doe"`
+}
+
+// MeResponse represents the current user with the time of their last successful
+// password login; last_login_at is null when no login is on record
+type MeResponse struct {
+	UserResponse
+	LastLoginAt *string `json:"last_login_at"`
+	// Policies lists configured policy documents; the client asks for re-acceptance of those not accepted
+	Policies []PolicyStatusResponse `json:"policies,omitempty"`
+}
+
+// MeResponseV2 is the v2 shape of MeResponse
+type MeResponseV2 struct {
+	UserResponseV2
+	LastLoginAt *string                `json:"last_login_at"`
+	Policies    []PolicyStatusResponse `json:"policies,omitempty"`
+}
+
+// LockoutStatusResponse represents a user's failed login lockout state.
+// Timestamps are RFC3339 in UTC; locked_until is null while the account is not locked
+type LockoutStatusResponse struct {
+	Locked         bool     `json:"locked"`
+	FailedAttempts int      `json:"failed_attempts"`
+	MaxAttempts    int      `json:"max_attempts"`
+	LockedUntil    *string  `json:"locked_until"`
+	RecentFailures []string `json:"recent_failures"`
+}
+
+// AdminUserResponse represents the admin user detail: the user plus their lockout state
+type AdminUserResponse struct {
+	UserResponse
+	Lockout LockoutStatusResponse `json:"lockout"`
+}
+
+// AdminUserResponseV2 is the v2 shape of AdminUserResponse
+type AdminUserResponseV2 struct {
+	UserResponseV2
+	Lockout LockoutStatusResponse `json:"lockout"`
 }
 
 // AuthResponse represents authentication response
 type AuthResponse struct {
-	AccessToken  string       `json:"access_token"`
-	RefreshToken string       `json:"refresh_token"`
-	TokenType    string       `json:"token_type"`
-	ExpiresIn    int64        `json:"expires_in"`
+	AccessToken  string       `json:"access_token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+	RefreshToken string       `json:"refresh_token,omitempty" example:"3q2-7wQ1RnKbq0vzWm4XbA.Yh2k9..."`
+	TokenType    string       `json:"token_type" example:"Bearer"`
+	ExpiresIn    int64        `json:"expires_in" example:"900"`
 	User         UserResponse `json:"user"`
 }
 
+// MessageResponse represents a response carrying only a human-readable message
+type MessageResponse struct {
+	Message string `json:"message" example:"Successfully logged out"`
+}
+
+// EffectivePermissions represents the roles and flattened permissions a user currently holds
+type EffectivePermissions struct {
+	Roles       []string `json:"roles"`
+	Permissions []string `json:"permissions"`
+}
+
 // LegacyAuthResponse represents legacy authentication response (deprecated)
 type LegacyAuthResponse struct {
 	Token string       `json:"token"`
@@ -46,22 +162,375 @@ type LegacyAuthResponse struct {
 }
 
 // UserListResponse represents paginated user list response
+// Total and TotalPages are omitted when the client requested count=none,
+// and approximate for count=estimated.
 type UserListResponse struct {
-	Users      []UserResponse `json:"users"`
-	Total      int64          `json:"total"`
-	Page       int            `json:"page"`
-	PerPage    int            `json:"per_page"`
-	TotalPages int            `json:"total_pages"`
+	Users []UserResponse `json:"users"`
+	UserPageInfo
+}
+
+// PublicUserListResponse is UserListResponse with users in their public shape,
+// returned to callers without the users:read permission
+type PublicUserListResponse struct {
+	Users []PublicUserResponse `json:"users"`
+	UserPageInfo
+}
+
+// UserPageInfo holds the pagination fields shared by user list responses
+type UserPageInfo struct {
+	Total      *int64 `json:"total,omitempty"`
+	Page       int    `json:"page"`
+	PerPage    int    `json:"per_page"`
+	TotalPages *int   `json:"total_pages,omitempty"`
+	HasNext    bool   `json:"has_next"`
+}
+
+// UserSearchResponse represents user search results in their public shape
+type UserSearchResponse struct {
+	Users []PublicUserResponse `json:"users"`
+}
+
+// UserSearchResponseV2 is the v2 shape of UserSearchResponse
+type UserSearchResponseV2 struct {
+	Users []PublicUserResponseV2 `json:"users"`
+}
+
+// CreateRoleRequest represents role creation payload
+type CreateRoleRequest struct {
+	Name        string `json:"name" binding:"required,min=2,max=50"`
+	Description string `json:"description" binding:"max=255"`
+}
+
+// UpdateRoleRequest represents role update payload; role names are immutable
+type UpdateRoleRequest struct {
+	Description string `json:"description" binding:"max=255"`
+}
+
+// SetRolePermissionsRequest represents the full set of permissions to grant to a role
+type SetRolePermissionsRequest struct {
+	Permissions []string `json:"permissions" binding:"required,dive,min=3,max=100"`
+}
+
+// MaxBulkRoleUsers caps how many users a single bulk role request may change
+const MaxBulkRoleUsers = 200
+
+// Bulk role actions
+const (
+	BulkRoleActionAssign = "assign"
+	BulkRoleActionRemove = "remove"
+)
+
+// Per-user outcomes of a bulk role request
+const (
+	BulkRoleStatusSucceeded      = "succeeded"
+	BulkRoleStatusAlreadyHadRole = "already_had_role"
+	BulkRoleStatusDidNotHaveRole = "did_not_have_role"
+	BulkRoleStatusNotFound       = "not_found"
+)
+
+// BulkRoleRequest assigns or removes one role for many users at once
+type BulkRoleRequest struct {
+	UserIDs []uint `json:"user_ids" binding:"required,min=1,dive,min=1" example:"12,15,18"`
+	Role    string `json:"role" binding:"required" example:"admin"`
+	Action  string `json:"action" binding:"required,oneof=assign remove" example:"assign"`
+}
+
+// BulkRoleResult reports what happened to one user of a bulk role request
+type BulkRoleResult struct {
+	UserID uint   `json:"user_id"`
+	Status string `json:"status" enums:"succeeded,already_had_role,did_not_have_role,not_found"`
+}
+
+// BulkRoleResponse lists the per-user outcomes of a bulk role request
+type BulkRoleResponse struct {
+	Role      string           `json:"role"`
+	Action    string           `json:"action"`
+	Succeeded int              `json:"succeeded"`
+	Results   []BulkRoleResult `json:"results"`
+}
+
+// ImpersonateRequest represents an admin's request to act as another user
+type ImpersonateRequest struct {
+	Reason string `json:"reason" binding:"required,min=3,max=255"`
+}
+
+// RevokeSessionsResponse reports how many sessions were signed out
+type RevokeSessionsResponse struct {
+	RevokedSessions int64 `json:"revoked_sessions"`
+}
+
+// ImpersonationResponse is returned when an impersonation session starts.
+// It never contains a refresh token: the session ends when the access token expires.
+type ImpersonationResponse struct {
+	AccessToken    string       `json:"access_token"`
+	TokenType      string       `json:"token_type"`
+	ExpiresIn      int64        `json:"expires_in"`
+	ExpiresAt      string       `json:"expires_at"`
+	GrantID        string       `json:"grant_id"`
+	ImpersonatorID uint         `json:"impersonator_id"`
+	User           UserResponse `json:"user"`
+}
+
+// ImpersonationGrantResponse represents a currently valid impersonation grant
+type ImpersonationGrantResponse struct {
+	ID             string `json:"id"`
+	ImpersonatorID uint   `json:"impersonator_id"`
+	TargetUserID   uint   `json:"target_user_id"`
+	Reason         string `json:"reason"`
+	CreatedAt      string `json:"created_at"`
+	ExpiresAt      string `json:"expires_at"`
+}
+
+// SessionResponse represents one login session (refresh token family) in the admin session list
+type SessionResponse struct {
+	TokenFamily string  `json:"token_family"`
+	UserID      uint    `json:"user_id"`
+	ClientID    string  `json:"client_id,omitempty"`
+	CreatedAt   string  `json:"created_at"`
+	LastUsedAt  *string `json:"last_used_at,omitempty"`
+	ExpiresAt   string  `json:"expires_at"`
+	Revoked     bool    `json:"revoked"`
+	RevokedAt   *string `json:"revoked_at,omitempty"`
+	Active      bool    `json:"active"`
+}
+
+// SessionListResponse represents a page of login sessions
+type SessionListResponse struct {
+	Sessions []SessionResponse `json:"sessions"`
+	Page     int               `json:"page"`
+	PerPage  int               `json:"per_page"`
+	HasNext  bool              `json:"has_next"`
+}
+
+// LoginAttemptResponse represents one password login attempt in the caller's login history
+type LoginAttemptResponse struct {
+	Outcome   string `json:"outcome"`
+	IP        string `json:"ip"`
+	UserAgent string `json:"user_agent"`
+	CreatedAt string `json:"created_at"`
+}
+
+// LoginHistoryResponse represents a page of login attempts, most recent first
+type LoginHistoryResponse struct {
+	Attempts []LoginAttemptResponse `json:"attempts"`
+	Page     int                    `json:"page"`
+	PerPage  int                    `json:"per_page"`
+	HasNext  bool                   `json:"has_next"`
+}
+
+// AcceptPolicyRequest represents a user accepting the current version of policy documents
+type AcceptPolicyRequest struct {
+	// Documents lists the policy types to accept; all configured policies when empty
+	Documents []string `json:"documents" binding:"omitempty,max=20,dive,required,max=50" example:"terms"`
+}
+
+// PolicyStatusResponse represents a user's acceptance of one configured policy document.
+// accepted_version and accepted_at are null when the user never accepted the document
+type PolicyStatusResponse struct {
+	Document        string  `json:"document" example:"terms"`
+	RequiredVersion string  `json:"required_version" example:"2026-01-01"`
+	Enforcement     string  `json:"enforcement" enums:"flag,block"`
+	Accepted        bool    `json:"accepted"`
+	AcceptedVersion *string `json:"accepted_version"`
+	AcceptedAt      *string `json:"accepted_at"`
+}
+
+// PolicyAcceptanceResponse represents one recorded policy acceptance
+type PolicyAcceptanceResponse struct {
+	ID         uint   `json:"id"`
+	UserID     uint   `json:"user_id"`
+	Document   string `json:"document"`
+	Version    string `json:"version"`
+	IP         string `json:"ip"`
+	AcceptedAt string `json:"accepted_at"`
+}
+
+// PolicyAcceptanceListResponse represents a page of policy acceptances, most recent first
+type PolicyAcceptanceListResponse struct {
+	Acceptances []PolicyAcceptanceResponse `json:"acceptances"`
+	Total       int64                      `json:"total"`
+	Page        int                        `json:"page"`
+	PerPage     int                        `json:"per_page"`
+	TotalPages  int                        `json:"total_pages"`
+}
+
+// RoleResponse represents role response
+type RoleResponse struct {
+	ID          uint     `json:"id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	BuiltIn     bool     `json:"built_in"`
+	Permissions []string `json:"permissions"`
+	CreatedAt   string   `json:"created_at"`
+	UpdatedAt   string   `json:"updated_at"`
+}
+
+// UserStatistics holds aggregated user counts
+type UserStatistics struct {
+	TotalUsers      int64
+	AdminUsers      int64
+	NewUsersLast24h int64
+	NewUsersLast7d  int64
+	NewUsersLast30d int64
+}
+
+// UserStatsResponse represents the admin user-statistics response
+type UserStatsResponse struct {
+	TotalUsers      int64 `json:"total_users"`
+	AdminUsers      int64 `json:"admin_users"`
+	NewUsersLast24h int64 `json:"new_users_last_24h"`
+	NewUsersLast7d  int64 `json:"new_users_last_7d"`
+	NewUsersLast30d int64 `json:"new_users_last_30d"`
+	ActiveSessions  int64 `json:"active_sessions"`
 }
 
 // ToUserResponse converts User model to UserResponse DTO
 func ToUserResponse(user *User) UserResponse {
 	return UserResponse{
-		ID:        user.ID,
-		Name:      user.Name,
-		Email:     user.Email,
-		Roles:     user.GetRoleNames(),
-		CreatedAt: user.CreatedAt.Format("2006-01-02T15:04:05Z"),
-		UpdatedAt: user.UpdatedAt.Format("2006-01-02T15:04:05Z"),
+		ID:           user.ID,
+		Name:         user.Name,
+		Email:        user.Email,
+		PendingEmail: user.PendingEmail,
+		Roles:        user.GetRoleNames(),
+		Active:       user.Active,
+		CreatedAt:    user.CreatedAt.Format("2006-01-02T15:04:05Z"),
+		UpdatedAt:    user.UpdatedAt.Format("2006-01-02T15:04:05Z"),
+	}
+}
+
+// ToUserResponseV2 converts User model to UserResponseV2 DTO
+func ToUserResponseV2(user *User) UserResponseV2 {
+	return UserResponseV2{
+		UserID:       user.ID,
+		Name:         user.Name,
+		Email:        user.Email,
+		PendingEmail: user.PendingEmail,
+		Roles:        user.GetRoleNames(),
+		Active:       user.Active,
+		CreatedAt:    user.CreatedAt.UTC().Format(time.RFC3339),
+		UpdatedAt:    user.UpdatedAt.UTC().Format(time.RFC3339),
+	}
+}
+
+// ToPublicUserResponse converts User model to PublicUserResponse DTO
+func ToPublicUserResponse(user *User) PublicUserResponse {
+	return PublicUserResponse{
+		ID:   user.ID,
+		Name: user.Name,
+	}
+}
+
+// ToPublicUserResponseV2 converts User model to PublicUserResponseV2 DTO
+func ToPublicUserResponseV2(user *User) PublicUserResponseV2 {
+	return PublicUserResponseV2{
+		UserID: user.ID,
+		Name:   user.Name,
+	}
+}
+
+// ToLockoutStatusResponse converts a lockout status to its DTO
+func ToLockoutStatusResponse(status *LockoutStatus) LockoutStatusResponse {
+	resp := LockoutStatusResponse{
+		Locked:         status.Locked,
+		FailedAttempts: status.FailedAttempts,
+		MaxAttempts:    status.MaxAttempts,
+		RecentFailures: make([]string, len(status.RecentFailures)),
+	}
+	if status.LockedUntil != nil {
+		until := status.LockedUntil.UTC().Format(time.RFC3339)
+		resp.LockedUntil = &until
+	}
+	for i, at := range status.RecentFailures {
+		resp.RecentFailures[i] = at.UTC().Format(time.RFC3339)
+	}
+	return resp
+}
+
+// ToImpersonationGrantResponse converts an impersonation grant to its DTO
+func ToImpersonationGrantResponse(grant *auth.ImpersonationGrant) ImpersonationGrantResponse {
+	return ImpersonationGrantResponse{
+		ID:             grant.ID.String(),
+		ImpersonatorID: grant.ImpersonatorID,
+		TargetUserID:   grant.TargetUserID,
+		Reason:         grant.Reason,
+		CreatedAt:      grant.CreatedAt.UTC().Format(time.RFC3339),
+		ExpiresAt:      grant.ExpiresAt.UTC().Format(time.RFC3339),
+	}
+}
+
+// ToSessionResponse converts a token family summary to its DTO
+func ToSessionResponse(session *auth.SessionFamily) SessionResponse {
+	resp := SessionResponse{
+		TokenFamily: session.TokenFamily.String(),
+		UserID:      session.UserID,
+		ClientID:    session.ClientID,
+		CreatedAt:   session.CreatedAt.UTC().Format(time.RFC3339),
+		ExpiresAt:   session.ExpiresAt.UTC().Format(time.RFC3339),
+		Revoked:     session.RevokedAt != nil,
+		Active:      session.Active,
+	}
+	if session.LastUsedAt != nil {
+		lastUsed := session.LastUsedAt.UTC().Format(time.RFC3339)
+		resp.LastUsedAt = &lastUsed
+	}
+	if session.RevokedAt != nil {
+		revoked := session.RevokedAt.UTC().Format(time.RFC3339)
+		resp.RevokedAt = &revoked
+	}
+	return resp
+}
+
+// ToLoginAttemptResponse converts a LoginAttempt model to its DTO
+func ToLoginAttemptResponse(attempt *LoginAttempt) LoginAttemptResponse {
+	return LoginAttemptResponse{
+		Outcome:   attempt.Outcome,
+		IP:        attempt.IP,
+		UserAgent: attempt.UserAgent,
+		CreatedAt: attempt.CreatedAt.UTC().Format(time.RFC3339),
+	}
+}
+
+// ToPolicyStatusResponses converts policy statuses to their DTOs
+func ToPolicyStatusResponses(statuses []PolicyStatus) []PolicyStatusResponse {
+	responses := make([]PolicyStatusResponse, len(statuses))
+	for i, status := range statuses {
+		responses[i] = PolicyStatusResponse{
+			Document:        status.DocumentType,
+			RequiredVersion: status.RequiredVersion,
+			Enforcement:     status.Enforcement,
+			Accepted:        status.Accepted,
+		}
+		if status.AcceptedAt != nil {
+			version := status.AcceptedVersion
+			acceptedAt := status.AcceptedAt.UTC().Format(time.RFC3339)
+			responses[i].AcceptedVersion = &version
+			responses[i].AcceptedAt = &acceptedAt
+		}
+	}
+	return responses
+}
+
+// ToPolicyAcceptanceResponse converts a policy acceptance record to its DTO
+func ToPolicyAcceptanceResponse(acceptance *PolicyAcceptance) PolicyAcceptanceResponse {
+	return PolicyAcceptanceResponse{
+		ID:         acceptance.ID,
+		UserID:     acceptance.UserID,
+		Document:   acceptance.DocumentType,
+		Version:    acceptance.Version,
+		IP:         acceptance.IP,
+		AcceptedAt: acceptance.AcceptedAt.UTC().Format(time.RFC3339),
+	}
+}
+
+// ToRoleResponse converts Role model to RoleResponse DTO
+func ToRoleResponse(role *Role) RoleResponse {
+	return RoleResponse{
+		ID:          role.ID,
+		Name:        role.Name,
+		Description: role.Description,
+		BuiltIn:     role.IsBuiltIn(),
+		Permissions: role.GetPermissionNames(),
+		CreatedAt:   role.CreatedAt.Format("2006-01-02T15:04:05Z"),
+		UpdatedAt:   role.UpdatedAt.Format("2006-01-02T15:04:05Z"),
 	}
 }
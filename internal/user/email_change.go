@@ -0,0 +1,149 @@
+package user
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/yeegeek/uyou-go-api-starter/internal/auth"
+	"github.com/yeegeek/uyou-go-api-starter/internal/config"
+	"github.com/yeegeek/uyou-go-api-starter/internal/mail"
+)
+
+// ErrInvalidEmailChangeToken is returned when an email change token is unknown, already used or expired
+var ErrInvalidEmailChangeToken = errors.New("invalid or expired email change token")
+
+// EmailChangeNotifier 将邮箱变更验证令牌发送到待验证的新邮箱
+type EmailChangeNotifier interface {
+	SendEmailChangeToken(ctx context.Context, user *User, token string, expiresAt time.Time) error
+}
+
+// logEmailChangeNotifier 未配置通知方式时只记录日志，不输出令牌
+type logEmailChangeNotifier struct{}
+
+func (logEmailChangeNotifier) SendEmailChangeToken(ctx context.Context, user *User, _ string, expiresAt time.Time) error {
+	slog.WarnContext(ctx, "Email change notifier not configured, verification token not delivered",
+		"user_id", user.ID, "expires_at", expiresAt)
+	return nil
+}
+
+// emailChangePolicy 邮箱变更验证设置
+type emailChangePolicy struct {
+	verify   bool
+	ttl      time.Duration
+	notifier EmailChangeNotifier
+	now      func() time.Time
+}
+
+func newEmailChangePolicy(cfg *config.SecurityConfig) emailChangePolicy {
+	return emailChangePolicy{
+		verify:   cfg.EmailChangeVerification,
+		ttl:      cfg.GetEmailChangeTokenTTL(),
+		notifier: logEmailChangeNotifier{},
+		now:      time.Now,
+	}
+}
+
+// WithEmailChangeNotifier delivers email change verification tokens through notifier
+func WithEmailChangeNotifier(notifier EmailChangeNotifier) ServiceOption {
+	return func(s *service) {
+		if notifier != nil {
+			s.emailChange.notifier = notifier
+		}
+	}
+}
+
+// requestEmailChange stores email as the user's pending email with a fresh verification token.
+// The current email stays in use, so login keeps working with it until the change is confirmed.
+// A repeated request replaces the earlier token.
+func (s *service) requestEmailChange(ctx context.Context, user *User, email string) error {
+	token, err := generateEmailChangeToken()
+	if err != nil {
+		return fmt.Errorf("failed to generate email change token: %w", err)
+	}
+	expiresAt := s.emailChange.now().Add(s.emailChange.ttl)
+
+	user.PendingEmail = email
+	user.EmailChangeTokenHash = auth.HashToken(token)
+	user.EmailChangeExpiresAt = &expiresAt
+	if err := s.repo.Update(ctx, user); err != nil {
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+
+	if err := s.emailChange.notifier.SendEmailChangeToken(ctx, user, token, expiresAt); err != nil {
+		return fmt.Errorf("failed to send email change token: %w", err)
+	}
+	return nil
+}
+
+// ConfirmEmailChange applies the pending email of the user the token was issued to.
+// The token is single use; it is rejected once expired or after a newer change request replaced it.
+func (s *service) ConfirmEmailChange(ctx context.Context, token string) (*User, error) {
+	user, err := s.repo.FindByEmailChangeToken(ctx, auth.HashToken(token))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find email change: %w", err)
+	}
+	if user == nil || user.PendingEmail == "" || user.EmailChangeExpiresAt == nil ||
+		!s.emailChange.now().Before(*user.EmailChangeExpiresAt) {
+		return nil, ErrInvalidEmailChangeToken
+	}
+
+	// WHY: The address may have been registered by someone else while the change was pending
+	existingUser, err := s.repo.FindByEmail(ctx, user.PendingEmail)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check existing email: %w", err)
+	}
+	if existingUser != nil && existingUser.ID != user.ID {
+		return nil, ErrEmailExists
+	}
+
+	user.Email = user.PendingEmail
+	clearPendingEmail(user)
+	if err := s.repo.Update(ctx, user); err != nil {
+		return nil, fmt.Errorf("failed to update user: %w", err)
+	}
+	return user, nil
+}
+
+func clearPendingEmail(user *User) {
+	user.PendingEmail = ""
+	user.EmailChangeTokenHash = ""
+	user.EmailChangeExpiresAt = nil
+}
+
+// generateEmailChangeToken returns 32 random bytes as unpadded base64url
+func generateEmailChangeToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// MailEmailChangeNotifier 通过邮件发送邮箱变更验证令牌，使用内置的 verification 模板
+type MailEmailChangeNotifier struct {
+	mailer   mail.Mailer
+	renderer *mail.Renderer
+}
+
+// NewMailEmailChangeNotifier 创建邮件通知器，邮件发往待验证的新邮箱
+func NewMailEmailChangeNotifier(mailer mail.Mailer, renderer *mail.Renderer) *MailEmailChangeNotifier {
+	return &MailEmailChangeNotifier{mailer: mailer, renderer: renderer}
+}
+
+// SendEmailChangeToken 渲染并发送验证邮件
+func (n *MailEmailChangeNotifier) SendEmailChangeToken(ctx context.Context, user *User, token string, expiresAt time.Time) error {
+	msg, err := n.renderer.Render(mail.TemplateVerification, []string{user.PendingEmail}, map[string]any{
+		"Name":             user.Name,
+		"Code":             token,
+		"ExpiresInMinutes": int(time.Until(expiresAt).Round(time.Minute).Minutes()),
+	})
+	if err != nil {
+		return err
+	}
+	return n.mailer.Send(ctx, msg)
+}
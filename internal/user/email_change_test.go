@@ -0,0 +1,196 @@
+package user
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yeegeek/uyou-go-api-starter/internal/auth"
+	"github.com/yeegeek/uyou-go-api-starter/internal/openapitest"
+	"github.com/yeegeek/uyou-go-api-starter/internal/testutil"
+)
+
+// captureNotifier records the last email change token instead of sending it
+type captureNotifier struct {
+	to        string
+	token     string
+	expiresAt time.Time
+}
+
+func (n *captureNotifier) SendEmailChangeToken(_ context.Context, user *User, token string, expiresAt time.Time) error {
+	n.to, n.token, n.expiresAt = user.PendingEmail, token, expiresAt
+	return nil
+}
+
+func setupEmailChangeService(t *testing.T) (*service, *captureNotifier, *User) {
+	t.Helper()
+	db := testutil.NewSQLiteDB(t)
+	cfg := newTestSecurityConfig()
+	cfg.EmailChangeVerification = true
+	cfg.EmailChangeTokenTTL = time.Hour
+
+	notifier := &captureNotifier{}
+	svc := NewService(NewRepository(db), cfg, WithEmailChangeNotifier(notifier)).(*service)
+	user, err := svc.RegisterUser(context.Background(), RegisterRequest{Name: "Jane Doe", Email: "jane@example.com", Password: "Password123!"})
+	require.NoError(t, err)
+	return svc, notifier, user
+}
+
+func TestService_EmailChange_RequiresConfirmation(t *testing.T) {
+	svc, notifier, user := setupEmailChangeService(t)
+	ctx := context.Background()
+	newEmail := "jane.doe@example.com"
+
+	updated, err := svc.PatchUser(ctx, user.ID, PatchUserRequest{Email: &newEmail})
+	require.NoError(t, err)
+	assert.Equal(t, "jane@example.com", updated.Email, "the current email stays active until confirmed")
+	assert.Equal(t, newEmail, updated.PendingEmail)
+	assert.Equal(t, newEmail, notifier.to, "the token is sent to the new address")
+	require.NotEmpty(t, notifier.token)
+	assert.NotEqual(t, notifier.token, updated.EmailChangeTokenHash, "only the token hash is stored")
+
+	stored, err := svc.GetUserByID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "jane@example.com", stored.Email)
+	assert.Equal(t, newEmail, stored.PendingEmail)
+
+	// Login keeps using the old email until the change is confirmed
+	_, err = svc.AuthenticateUser(ctx, LoginRequest{Email: "jane@example.com", Password: "Password123!"})
+	require.NoError(t, err)
+	_, err = svc.AuthenticateUser(ctx, LoginRequest{Email: newEmail, Password: "Password123!"})
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+
+	confirmed, err := svc.ConfirmEmailChange(ctx, notifier.token)
+	require.NoError(t, err)
+	assert.Equal(t, newEmail, confirmed.Email)
+	assert.Empty(t, confirmed.PendingEmail)
+
+	_, err = svc.AuthenticateUser(ctx, LoginRequest{Email: newEmail, Password: "Password123!"})
+	require.NoError(t, err)
+	_, err = svc.AuthenticateUser(ctx, LoginRequest{Email: "jane@example.com", Password: "Password123!"})
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+
+	_, err = svc.ConfirmEmailChange(ctx, notifier.token)
+	assert.ErrorIs(t, err, ErrInvalidEmailChangeToken, "the token is single use")
+}
+
+func TestService_EmailChange_RejectedTokens(t *testing.T) {
+	newEmail := "jane.doe@example.com"
+
+	t.Run("expired", func(t *testing.T) {
+		svc, notifier, user := setupEmailChangeService(t)
+		ctx := context.Background()
+		_, err := svc.PatchUser(ctx, user.ID, PatchUserRequest{Email: &newEmail})
+		require.NoError(t, err)
+
+		svc.emailChange.now = func() time.Time { return notifier.expiresAt }
+		_, err = svc.ConfirmEmailChange(ctx, notifier.token)
+		assert.ErrorIs(t, err, ErrInvalidEmailChangeToken)
+	})
+
+	t.Run("replaced by a newer request", func(t *testing.T) {
+		svc, notifier, user := setupEmailChangeService(t)
+		ctx := context.Background()
+		_, err := svc.PatchUser(ctx, user.ID, PatchUserRequest{Email: &newEmail})
+		require.NoError(t, err)
+		first := notifier.token
+
+		other := "jd@example.com"
+		_, err = svc.PatchUser(ctx, user.ID, PatchUserRequest{Email: &other})
+		require.NoError(t, err)
+
+		_, err = svc.ConfirmEmailChange(ctx, first)
+		assert.ErrorIs(t, err, ErrInvalidEmailChangeToken)
+		confirmed, err := svc.ConfirmEmailChange(ctx, notifier.token)
+		require.NoError(t, err)
+		assert.Equal(t, other, confirmed.Email)
+	})
+
+	t.Run("withdrawn by setting the current email", func(t *testing.T) {
+		svc, notifier, user := setupEmailChangeService(t)
+		ctx := context.Background()
+		_, err := svc.PatchUser(ctx, user.ID, PatchUserRequest{Email: &newEmail})
+		require.NoError(t, err)
+
+		current := "jane@example.com"
+		updated, err := svc.PatchUser(ctx, user.ID, PatchUserRequest{Email: &current})
+		require.NoError(t, err)
+		assert.Empty(t, updated.PendingEmail)
+
+		_, err = svc.ConfirmEmailChange(ctx, notifier.token)
+		assert.ErrorIs(t, err, ErrInvalidEmailChangeToken)
+	})
+
+	t.Run("address taken while pending", func(t *testing.T) {
+		svc, notifier, user := setupEmailChangeService(t)
+		ctx := context.Background()
+		_, err := svc.PatchUser(ctx, user.ID, PatchUserRequest{Email: &newEmail})
+		require.NoError(t, err)
+		_, err = svc.RegisterUser(ctx, RegisterRequest{Name: "John Doe", Email: newEmail, Password: "Password123!"})
+		require.NoError(t, err)
+
+		_, err = svc.ConfirmEmailChange(ctx, notifier.token)
+		assert.ErrorIs(t, err, ErrEmailExists)
+	})
+}
+
+func TestService_EmailChange_DisabledAppliesImmediately(t *testing.T) {
+	db := testutil.NewSQLiteDB(t)
+	notifier := &captureNotifier{}
+	svc := NewService(NewRepository(db), newTestSecurityConfig(), WithEmailChangeNotifier(notifier))
+	ctx := context.Background()
+	user, err := svc.RegisterUser(ctx, RegisterRequest{Name: "Jane Doe", Email: "jane@example.com", Password: "Password123!"})
+	require.NoError(t, err)
+
+	newEmail := "jane.doe@example.com"
+	updated, err := svc.PatchUser(ctx, user.ID, PatchUserRequest{Email: &newEmail})
+	require.NoError(t, err)
+	assert.Equal(t, newEmail, updated.Email)
+	assert.Empty(t, updated.PendingEmail)
+	assert.Empty(t, notifier.token)
+}
+
+func TestHandler_EmailChange(t *testing.T) {
+	svc, notifier, user := setupEmailChangeService(t)
+	handler := NewHandler(svc, new(testutil.MockAuthService))
+
+	router := testutil.NewTestRouter(t)
+	router.PATCH("/api/v1/auth/me", func(c *gin.Context) {
+		c.Set(auth.KeyUser, &auth.Claims{UserID: user.ID, Email: user.Email})
+		c.Next()
+	}, handler.UpdateMe)
+	router.POST("/api/v1/auth/verify-email-change", handler.VerifyEmailChange)
+
+	serve := func(method, path string, body any) *httptest.ResponseRecorder {
+		payload, _ := json.Marshal(body)
+		req := httptest.NewRequest(method, path, bytes.NewReader(payload))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := serve(http.MethodPatch, "/api/v1/auth/me", map[string]string{"email": "jane.doe@example.com"})
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"email":"jane@example.com"`)
+	assert.Contains(t, w.Body.String(), `"pending_email":"jane.doe@example.com"`)
+	openapitest.AssertResponse(t, http.MethodPatch, "/api/v1/auth/me", w)
+
+	w = serve(http.MethodPost, "/api/v1/auth/verify-email-change", map[string]string{"token": "not-a-token"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	openapitest.AssertResponse(t, http.MethodPost, "/api/v1/auth/verify-email-change", w)
+
+	w = serve(http.MethodPost, "/api/v1/auth/verify-email-change", map[string]string{"token": notifier.token})
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"email":"jane.doe@example.com"`)
+	assert.NotContains(t, w.Body.String(), "pending_email")
+	openapitest.AssertResponse(t, http.MethodPost, "/api/v1/auth/verify-email-change", w)
+}
@@ -0,0 +1,79 @@
+// Package user 提供注册邮箱域名限制功能
+package user
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/yeegeek/uyou-go-api-starter/internal/config"
+)
+
+// ErrEmailDomainNotAllowed 注册邮箱的域名不在允许列表中或在禁止列表中
+var ErrEmailDomainNotAllowed = errors.New("email domain not allowed")
+
+// EmailDomainNotAllowedError 携带被拒绝的域名，errors.Is(err, ErrEmailDomainNotAllowed) 成立
+type EmailDomainNotAllowedError struct {
+	Domain string
+}
+
+func (e *EmailDomainNotAllowedError) Error() string {
+	return fmt.Sprintf("email domain %q is not allowed", e.Domain)
+}
+
+// Is 使 errors.Is 能以 ErrEmailDomainNotAllowed 匹配
+func (e *EmailDomainNotAllowedError) Is(target error) bool {
+	return target == ErrEmailDomainNotAllowed
+}
+
+// emailDomainPolicy 注册邮箱域名限制：允许列表非空时只放行其中的域名，禁止列表始终生效
+// 域名统一转为小写，列表中的域名同时匹配其子域名
+type emailDomainPolicy struct {
+	allowed []string
+	blocked []string
+}
+
+func newEmailDomainPolicy(cfg *config.SecurityConfig) emailDomainPolicy {
+	return emailDomainPolicy{
+		allowed: normalizeDomains(cfg.AllowedEmailDomains),
+		blocked: normalizeDomains(cfg.BlockedEmailDomains),
+	}
+}
+
+// check 返回 nil 或 *EmailDomainNotAllowedError
+func (p emailDomainPolicy) check(email string) error {
+	if len(p.allowed) == 0 && len(p.blocked) == 0 {
+		return nil
+	}
+	domain := strings.ToLower(email[strings.LastIndex(email, "@")+1:])
+	if matchesDomain(p.blocked, domain) || (len(p.allowed) > 0 && !matchesDomain(p.allowed, domain)) {
+		return &EmailDomainNotAllowedError{Domain: domain}
+	}
+	return nil
+}
+
+// normalizeEmail 去除首尾空白并转为小写；邮箱写入和按邮箱查询前都需要规范化，
+// 否则大小写不同的同一邮箱会被当作不同账号
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+func normalizeDomains(domains []string) []string {
+	normalized := make([]string, 0, len(domains))
+	for _, d := range domains {
+		if d = strings.ToLower(strings.TrimSpace(d)); d != "" {
+			normalized = append(normalized, d)
+		}
+	}
+	return normalized
+}
+
+// matchesDomain 域名等于列表中的某一项或是其子域名
+func matchesDomain(list []string, domain string) bool {
+	for _, d := range list {
+		if domain == d || strings.HasSuffix(domain, "."+d) {
+			return true
+		}
+	}
+	return false
+}
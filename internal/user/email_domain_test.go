@@ -0,0 +1,84 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/yeegeek/uyou-go-api-starter/internal/testutil"
+)
+
+func TestService_RegisterUser_EmailDomains(t *testing.T) {
+	tests := []struct {
+		name        string
+		allowed     []string
+		blocked     []string
+		email       string
+		wantBlocked string
+	}{
+		{name: "no restrictions", email: "jane@anything.test"},
+		{name: "allowlist hit", allowed: []string{"example.com"}, email: "jane@example.com"},
+		{name: "allowlist hit is case-insensitive", allowed: []string{"Example.COM"}, email: "Jane@EXAMPLE.com"},
+		{name: "allowlist covers subdomains", allowed: []string{"example.com"}, email: "jane@eng.example.com"},
+		{name: "allowlist miss", allowed: []string{"example.com"}, email: "jane@other.com", wantBlocked: "other.com"},
+		{name: "lookalike is not a subdomain", allowed: []string{"example.com"}, email: "jane@badexample.com", wantBlocked: "badexample.com"},
+		{name: "blocklist hit", blocked: []string{"mailinator.com"}, email: "jane@MAILINATOR.com", wantBlocked: "mailinator.com"},
+		{name: "blocklist only allows other domains", blocked: []string{"mailinator.com"}, email: "jane@example.com"},
+		{name: "blocklist wins over allowlist", allowed: []string{"example.com"}, blocked: []string{"temp.example.com"}, email: "jane@temp.example.com", wantBlocked: "temp.example.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := newTestSecurityConfig()
+			cfg.AllowedEmailDomains = tt.allowed
+			cfg.BlockedEmailDomains = tt.blocked
+			repo := NewRepository(testutil.NewSQLiteDB(t))
+			service := NewService(repo, cfg)
+
+			user, err := service.RegisterUser(context.Background(), RegisterRequest{Name: "Jane Doe", Email: tt.email, Password: "Password123!"})
+
+			if tt.wantBlocked == "" {
+				require.NoError(t, err)
+				assert.Equal(t, strings.ToLower(tt.email), user.Email)
+				return
+			}
+			assert.ErrorIs(t, err, ErrEmailDomainNotAllowed)
+			var domainErr *EmailDomainNotAllowedError
+			require.True(t, errors.As(err, &domainErr))
+			assert.Equal(t, tt.wantBlocked, domainErr.Domain)
+			found, err := repo.FindByEmail(context.Background(), tt.email)
+			require.NoError(t, err)
+			assert.Nil(t, found)
+		})
+	}
+}
+
+func TestService_EmailsAreCaseInsensitive(t *testing.T) {
+	cfg := newTestSecurityConfig()
+	cfg.BcryptCost = bcrypt.MinCost
+	db := testutil.NewSQLiteDB(t)
+	service := NewService(NewRepository(db), cfg)
+	ctx := context.Background()
+
+	registered, err := service.RegisterUser(ctx, RegisterRequest{Name: "Jane Doe", Email: " Jane@Example.COM ", Password: "Password123!"})
+	require.NoError(t, err)
+	assert.Equal(t, "jane@example.com", registered.Email)
+
+	_, err = service.RegisterUser(ctx, RegisterRequest{Name: "Jane Again", Email: "JANE@example.com", Password: "Password123!"})
+	assert.ErrorIs(t, err, ErrEmailExists)
+
+	for _, email := range []string{"jane@example.com", "Jane@Example.COM", "  JANE@EXAMPLE.COM"} {
+		_, err := service.AuthenticateUser(ctx, LoginRequest{Email: email, Password: "Password123!"})
+		assert.NoError(t, err, email)
+	}
+
+	newEmail := "Jane.Doe@Example.com"
+	updated, err := service.PatchUser(ctx, registered.ID, PatchUserRequest{Email: &newEmail})
+	require.NoError(t, err)
+	assert.Equal(t, "jane.doe@example.com", updated.Email)
+}
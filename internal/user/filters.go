@@ -1,22 +1,84 @@
 package user
 
 import (
+	"errors"
+	"sort"
 	"strings"
 	"unicode/utf8"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm/clause"
 )
 
+var (
+	// ErrInvalidSortField is returned when the requested sort field is not in the whitelist
+	ErrInvalidSortField = errors.New("invalid sort field")
+	// ErrInvalidCountMode is returned when the count query parameter is not a known CountMode
+	ErrInvalidCountMode = errors.New("invalid count mode")
+	// ErrInvalidStatusFilter is returned when the status query parameter is neither active nor disabled
+	ErrInvalidStatusFilter = errors.New("invalid status filter")
+)
+
+// CountMode controls how the total of a user list is computed
+type CountMode string
+
+const (
+	// CountExact runs COUNT(*) with the list filters
+	CountExact CountMode = "exact"
+	// CountEstimated uses planner statistics for unfiltered PostgreSQL queries and falls back to exact
+	CountEstimated CountMode = "estimated"
+	// CountNone skips counting; the repository returns up to perPage+1 rows so callers can detect a next page
+	CountNone CountMode = "none"
+)
+
+// TotalUnknown is the total returned by ListAllUsers when counting was skipped
+const TotalUnknown int64 = -1
+
+// DefaultUserSort is the sort field used when none is requested
+const DefaultUserSort = "created_at"
+
+// roleSortTable is the alias of the per-user role subquery joined when sorting by role
+const roleSortTable = "user_role_sort"
+
+// userSortColumns maps the public sort fields to database columns.
+// Adding a sortable field only requires a new entry here.
+var userSortColumns = map[string]clause.Column{
+	"name":       {Table: "users", Name: "name"},
+	"email":      {Table: "users", Name: "email"},
+	"created_at": {Table: "users", Name: "created_at"},
+	"updated_at": {Table: "users", Name: "updated_at"},
+	// role sorts by the user's alphabetically first role name
+	"role": {Table: roleSortTable, Name: "role_name"},
+}
+
+// SortableUserFields returns the accepted sort fields in alphabetical order
+func SortableUserFields() []string {
+	fields := make([]string, 0, len(userSortColumns))
+	for field := range userSortColumns {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
 // UserFilterParams represents filtering parameters for user list
 type UserFilterParams struct {
 	Role   string
 	Search string
-	Sort   string
-	Order  string
+	// NameOnly matches Search against names only, so callers who may not see emails cannot probe for them
+	NameOnly bool
+	Sort     string
+	Order    string
+	// Status is UserStatusActive, UserStatusDisabled or empty for all users
+	Status string
+	// CountMode defaults to CountExact when empty
+	CountMode CountMode
 }
 
-// ParseUserFilters parses and validates user filter parameters from request
-func ParseUserFilters(c *gin.Context) UserFilterParams {
+// ParseUserFilters parses and validates user filter parameters from request.
+// An unknown sort field returns ErrInvalidSortField, an unknown count mode ErrInvalidCountMode
+// and an unknown status ErrInvalidStatusFilter.
+func ParseUserFilters(c *gin.Context) (UserFilterParams, error) {
 	role := c.Query("role")
 	if role != "" && role != RoleUser && role != RoleAdmin {
 		role = ""
@@ -33,15 +95,9 @@ func ParseUserFilters(c *gin.Context) UserFilterParams {
 		search = strings.TrimSpace(search)
 	}
 
-	sort := c.DefaultQuery("sort", "created_at")
-	validSorts := map[string]bool{
-		"name":       true,
-		"email":      true,
-		"created_at": true,
-		"updated_at": true,
-	}
-	if !validSorts[sort] {
-		sort = "created_at"
+	sortField := c.DefaultQuery("sort", DefaultUserSort)
+	if _, ok := userSortColumns[sortField]; !ok {
+		return UserFilterParams{}, ErrInvalidSortField
 	}
 
 	order := c.DefaultQuery("order", "desc")
@@ -49,10 +105,24 @@ func ParseUserFilters(c *gin.Context) UserFilterParams {
 		order = "desc"
 	}
 
-	return UserFilterParams{
-		Role:   role,
-		Search: search,
-		Sort:   sort,
-		Order:  order,
+	countMode := CountMode(c.DefaultQuery("count", string(CountExact)))
+	switch countMode {
+	case CountExact, CountEstimated, CountNone:
+	default:
+		return UserFilterParams{}, ErrInvalidCountMode
 	}
+
+	status := c.Query("status")
+	if status != "" && status != UserStatusActive && status != UserStatusDisabled {
+		return UserFilterParams{}, ErrInvalidStatusFilter
+	}
+
+	return UserFilterParams{
+		Role:      role,
+		Search:    search,
+		Sort:      sortField,
+		Order:     order,
+		Status:    status,
+		CountMode: countMode,
+	}, nil
 }
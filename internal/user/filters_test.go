@@ -1,14 +1,17 @@
 package user
 
 import (
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"strings"
 	"testing"
+	"unicode/utf8"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestParseUserFilters(t *testing.T) {
@@ -130,12 +133,12 @@ func TestParseUserFilters(t *testing.T) {
 			},
 		},
 		{
-			name:  "invalid sort defaults to created_at",
-			query: "sort=invalid_column",
+			name:  "valid sort by role",
+			query: "sort=role",
 			expected: UserFilterParams{
 				Role:   "",
 				Search: "",
-				Sort:   "created_at",
+				Sort:   "role",
 				Order:  "desc",
 			},
 		},
@@ -181,7 +184,7 @@ func TestParseUserFilters(t *testing.T) {
 		},
 		{
 			name:  "mixed valid and invalid parameters",
-			query: "role=invalid&search=test&sort=invalid&order=invalid",
+			query: "role=invalid&search=test&order=invalid",
 			expected: UserFilterParams{
 				Role:   "",
 				Search: "test",
@@ -227,7 +230,8 @@ func TestParseUserFilters(t *testing.T) {
 			c, _ := gin.CreateTestContext(w)
 			c.Request = httptest.NewRequest(http.MethodGet, "/?"+tt.query, nil)
 
-			result := ParseUserFilters(c)
+			result, err := ParseUserFilters(c)
+			require.NoError(t, err)
 
 			assert.Equal(t, tt.expected.Role, result.Role)
 			assert.Equal(t, tt.expected.Search, result.Search)
@@ -236,3 +240,150 @@ func TestParseUserFilters(t *testing.T) {
 		})
 	}
 }
+
+func TestParseUserFilters_InvalidSort(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	for _, query := range []string{"sort=invalid_column", "sort=password_hash", "sort=" + url.QueryEscape("name; DROP TABLE users")} {
+		t.Run(query, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/?"+query, nil)
+
+			_, err := ParseUserFilters(c)
+			assert.ErrorIs(t, err, ErrInvalidSortField)
+		})
+	}
+}
+
+func TestParseUserFilters_CountMode(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		query   string
+		want    CountMode
+		wantErr bool
+	}{
+		{query: "", want: CountExact},
+		{query: "count=exact", want: CountExact},
+		{query: "count=estimated", want: CountEstimated},
+		{query: "count=none", want: CountNone},
+		{query: "count=approx", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/?"+tt.query, nil)
+
+			result, err := ParseUserFilters(c)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidCountMode)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, result.CountMode)
+		})
+	}
+}
+
+func TestParseUserFilters_Status(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		query   string
+		want    string
+		wantErr bool
+	}{
+		{query: "", want: ""},
+		{query: "status=active", want: UserStatusActive},
+		{query: "status=disabled", want: UserStatusDisabled},
+		{query: "status=deleted", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/?"+tt.query, nil)
+
+			result, err := ParseUserFilters(c)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidStatusFilter)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, result.Status)
+		})
+	}
+}
+
+func TestSortableUserFields(t *testing.T) {
+	assert.Equal(t, []string{"created_at", "email", "name", "role", "updated_at"}, SortableUserFields())
+}
+
+func FuzzParseUserFilters(f *testing.F) {
+	gin.SetMode(gin.TestMode)
+	for _, seed := range []string{
+		"",
+		"role=admin&search=john&sort=email&order=asc",
+		"role=invalid&search=test&order=invalid",
+		"search=" + url.QueryEscape("  john  "),
+		"search=" + strings.Repeat("用", 150),
+		"search=" + strings.Repeat(" ", 99) + "ab",
+		"search=%ff%fe",
+		"sort=password_hash",
+		"sort=" + url.QueryEscape("name; DROP TABLE users"),
+		"sort=",
+		"sort=role&sort=name",
+		"count=approx",
+		"count=none&status=disabled",
+		"status=deleted",
+		"%zz=1&;&&=",
+	} {
+		f.Add(seed)
+	}
+
+	sortable := map[string]bool{}
+	for _, field := range SortableUserFields() {
+		sortable[field] = true
+	}
+
+	f.Fuzz(func(t *testing.T, rawQuery string) {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		// Build the request directly: httptest.NewRequest panics on URLs a client could still send
+		c.Request = &http.Request{Method: http.MethodGet, URL: &url.URL{Path: "/", RawQuery: rawQuery}}
+
+		result, err := ParseUserFilters(c)
+		if err != nil {
+			if !errors.Is(err, ErrInvalidSortField) && !errors.Is(err, ErrInvalidCountMode) && !errors.Is(err, ErrInvalidStatusFilter) {
+				t.Fatalf("unexpected error %v", err)
+			}
+			return
+		}
+		if !sortable[result.Sort] {
+			t.Fatalf("sort %q is not whitelisted", result.Sort)
+		}
+		if result.Order != "asc" && result.Order != "desc" {
+			t.Fatalf("order %q", result.Order)
+		}
+		if result.Role != "" && result.Role != RoleUser && result.Role != RoleAdmin {
+			t.Fatalf("role %q", result.Role)
+		}
+		if result.Status != "" && result.Status != UserStatusActive && result.Status != UserStatusDisabled {
+			t.Fatalf("status %q", result.Status)
+		}
+		switch result.CountMode {
+		case CountExact, CountEstimated, CountNone:
+		default:
+			t.Fatalf("count mode %q", result.CountMode)
+		}
+		if n := utf8.RuneCountInString(result.Search); n > 100 {
+			t.Fatalf("search has %d runes", n)
+		}
+		if result.Search != strings.TrimSpace(result.Search) {
+			t.Fatalf("search %q is not trimmed", result.Search)
+		}
+	})
+}
@@ -2,30 +2,84 @@
 package user
 
 import (
+	"context"
 	"errors"
+	"io"
+	"log/slog"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 
 	"github.com/yeegeek/uyou-go-api-starter/internal/auth"
+	"github.com/yeegeek/uyou-go-api-starter/internal/config"
 	"github.com/yeegeek/uyou-go-api-starter/internal/contextutil"
 	apiErrors "github.com/yeegeek/uyou-go-api-starter/internal/errors"
 	"github.com/yeegeek/uyou-go-api-starter/internal/middleware"
+	"github.com/yeegeek/uyou-go-api-starter/internal/oauth"
+)
+
+// refreshRetryAfterSeconds is the Retry-After hint when refresh token rotation fails on a storage error
+const refreshRetryAfterSeconds = 1
+
+const (
+	// userSearchLimit caps the results of GET /users/search
+	userSearchLimit = 10
+	// maxUserSearchLength is the longest accepted search query in characters
+	maxUserSearchLength = 100
 )
 
 // Handler handles user-related HTTP requests
 type Handler struct {
-	userService Service
-	authService auth.Service
+	userService   Service
+	authService   auth.Service
+	refreshCookie *auth.RefreshCookie
+	// accessCookie also delivers access tokens to cookie clients; nil when disabled
+	accessCookie *auth.AccessCookie
+	// oauthProviders social login providers; nil when social login is disabled
+	oauthProviders oauth.Registry
+	// loginFailures counts failed logins per client IP to flag brute-force attempts in the logs
+	loginFailures *loginFailureTracker
+	// policies tracks policy acceptance; nil when policy tracking is not wired in
+	policies PolicyService
+	// preferences stores per-user settings; nil when the preference endpoints are not wired in
+	preferences PreferenceService
+}
+
+// HandlerOption configures optional Handler behaviour
+type HandlerOption func(*Handler)
+
+// WithRefreshCookie delivers refresh tokens to browser clients in an HttpOnly cookie
+// instead of the JSON body when cookie mode is enabled in the JWT config
+func WithRefreshCookie(rc *auth.RefreshCookie) HandlerOption {
+	return func(h *Handler) {
+		h.refreshCookie = rc
+	}
+}
+
+// WithAccessCookie also sets the access token as an HttpOnly cookie for clients receiving the
+// refresh cookie, so browsers never need to keep tokens in script-accessible storage
+func WithAccessCookie(ac *auth.AccessCookie) HandlerOption {
+	return func(h *Handler) {
+		h.accessCookie = ac
+	}
 }
 
 // NewHandler creates a new user handler
-func NewHandler(userService Service, authService auth.Service) *Handler {
-	return &Handler{
-		userService: userService,
-		authService: authService,
+func NewHandler(userService Service, authService auth.Service, opts ...HandlerOption) *Handler {
+	h := &Handler{
+		userService:   userService,
+		authService:   authService,
+		loginFailures: newLoginFailureTracker(loginFailureWindow),
 	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
 }
 
 // Register godoc
@@ -35,8 +89,11 @@ func NewHandler(userService Service, authService auth.Service) *Handler {
 // @Accept json
 // @Produce json
 // @Param request body RegisterRequest true "Registration request"
+// @Param X-Refresh-Token-Transport header string false "Set to \"body\" to receive the refresh token in the response body when cookie mode is enabled"
+// @Param X-Client-Id header string false "Registered client (jwt.clients) whose token lifetimes apply; the client_id body field takes precedence"
 // @Success 200 {object} errors.Response{success=bool,data=AuthResponse} "Success response with user data and tokens"
-// @Failure 400 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Validation error"
+// @Failure 400 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Validation error, including accept_terms missing while policies are configured or an email domain rejected by the security settings"
+// @Failure 403 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Client is not allowed to register"
 // @Failure 409 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Email already exists"
 // @Failure 500 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Failed to register user or generate token"
 // @Router /api/v1/auth/register [post]
@@ -46,18 +103,42 @@ func (h *Handler) Register(c *gin.Context) {
 		_ = c.Error(apiErrors.FromGinValidation(err))
 		return
 	}
+	clientID := requestClientID(c, req.ClientID)
+	if !h.checkClientGrant(c, clientID, config.ClientGrantRegister) {
+		return
+	}
 
-	user, err := h.userService.RegisterUser(c.Request.Context(), req)
+	user, err := h.userService.RegisterUser(clientContext(c), req)
 	if err != nil {
 		if errors.Is(err, ErrEmailExists) {
-			_ = c.Error(apiErrors.Conflict("Email already exists"))
+			_ = c.Error(apiErrors.Conflict(apiErrors.MsgEmailExists))
+			return
+		}
+		if errors.Is(err, ErrTermsNotAccepted) {
+			apiErr := apiErrors.ValidationError(map[string]string{"AcceptTerms": "AcceptTerms must be true"})
+			apiErr.Fields = map[string]string{"accept_terms": "must be true"}
+			_ = c.Error(apiErr)
 			return
 		}
+		if errors.Is(err, ErrEmailDomainNotAllowed) {
+			apiErr := apiErrors.ValidationError(map[string]string{"Email": "Email domain is not allowed"})
+			apiErr.Fields = map[string]string{"email": "domain not allowed"}
+			_ = c.Error(apiErr)
+			return
+		}
+		_ = c.Error(apiErrors.InternalServerError(err))
+		return
+	}
+
+	tokenPair, err := h.authService.GenerateTokenPair(clientContext(c), user.ID, user.Email, user.Name,
+		auth.WithClient(clientID, config.ClientGrantRegister),
+	)
+	if err != nil {
 		_ = c.Error(apiErrors.InternalServerError(err))
 		return
 	}
 
-	tokenPair, err := h.authService.GenerateTokenPair(c.Request.Context(), user.ID, user.Email, user.Name)
+	refreshToken, err := h.deliverTokens(c, tokenPair, h.refreshCookie.WantsCookie(c))
 	if err != nil {
 		_ = c.Error(apiErrors.InternalServerError(err))
 		return
@@ -65,7 +146,7 @@ func (h *Handler) Register(c *gin.Context) {
 
 	c.JSON(http.StatusOK, apiErrors.Success(AuthResponse{
 		AccessToken:  tokenPair.AccessToken,
-		RefreshToken: tokenPair.RefreshToken,
+		RefreshToken: refreshToken,
 		TokenType:    tokenPair.TokenType,
 		ExpiresIn:    tokenPair.ExpiresIn,
 		User:         ToUserResponse(user),
@@ -74,14 +155,18 @@ func (h *Handler) Register(c *gin.Context) {
 
 // Login godoc
 // @Summary Login user
-// @Description Authenticate user with email and password, returns access and refresh tokens
+// @Description Authenticate user with email and password, returns access and refresh tokens. Set remember_me to receive a longer-lived refresh token.
 // @Tags auth
 // @Accept json
 // @Produce json
 // @Param request body LoginRequest true "Login request"
+// @Param X-Refresh-Token-Transport header string false "Set to \"body\" to receive the refresh token in the response body when cookie mode is enabled"
+// @Param X-Client-Id header string false "Registered client (jwt.clients) whose token lifetimes apply; the client_id body field takes precedence"
 // @Success 200 {object} errors.Response{success=bool,data=AuthResponse} "Success response with user data and tokens"
 // @Failure 400 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Validation error"
 // @Failure 401 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Invalid email or password"
+// @Failure 403 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Account is disabled or client is not allowed to log in with a password"
+// @Failure 429 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Account is temporarily locked after too many failed logins"
 // @Failure 500 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Failed to authenticate user or generate token"
 // @Router /api/v1/auth/login [post]
 func (h *Handler) Login(c *gin.Context) {
@@ -90,18 +175,44 @@ func (h *Handler) Login(c *gin.Context) {
 		_ = c.Error(apiErrors.FromGinValidation(err))
 		return
 	}
+	clientID := requestClientID(c, req.ClientID)
+	if !h.checkClientGrant(c, clientID, config.ClientGrantPassword) {
+		return
+	}
 
-	user, err := h.userService.AuthenticateUser(c.Request.Context(), req)
+	user, err := h.userService.AuthenticateUser(clientContext(c), req)
 	if err != nil {
 		if errors.Is(err, ErrInvalidCredentials) {
+			h.recordLoginFailure(c, err)
 			_ = c.Error(apiErrors.Unauthorized("Invalid email or password"))
 			return
 		}
+		if errors.Is(err, ErrAccountDisabled) {
+			_ = c.Error(apiErrors.AccountDisabled())
+			return
+		}
+		var locked *AccountLockedError
+		if errors.As(err, &locked) {
+			h.recordLoginFailure(c, err)
+			retryAfter := retryAfterSeconds(time.Until(locked.Until))
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+			_ = c.Error(apiErrors.AccountLocked(retryAfter))
+			return
+		}
+		_ = c.Error(apiErrors.InternalServerError(err))
+		return
+	}
+
+	tokenPair, err := h.authService.GenerateTokenPair(clientContext(c), user.ID, user.Email, user.Name,
+		auth.WithRememberMe(req.RememberMe),
+		auth.WithClient(clientID, config.ClientGrantPassword),
+	)
+	if err != nil {
 		_ = c.Error(apiErrors.InternalServerError(err))
 		return
 	}
 
-	tokenPair, err := h.authService.GenerateTokenPair(c.Request.Context(), user.ID, user.Email, user.Name)
+	refreshToken, err := h.deliverTokens(c, tokenPair, h.refreshCookie.WantsCookie(c))
 	if err != nil {
 		_ = c.Error(apiErrors.InternalServerError(err))
 		return
@@ -109,7 +220,7 @@ func (h *Handler) Login(c *gin.Context) {
 
 	c.JSON(http.StatusOK, apiErrors.Success(AuthResponse{
 		AccessToken:  tokenPair.AccessToken,
-		RefreshToken: tokenPair.RefreshToken,
+		RefreshToken: refreshToken,
 		TokenType:    tokenPair.TokenType,
 		ExpiresIn:    tokenPair.ExpiresIn,
 		User:         ToUserResponse(user),
@@ -134,31 +245,86 @@ func (h *Handler) Login(c *gin.Context) {
 func (h *Handler) GetUser(c *gin.Context) {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
-		_ = c.Error(apiErrors.BadRequest("Invalid user ID"))
+		_ = c.Error(apiErrors.BadRequest(apiErrors.MsgInvalidUserID))
 		return
 	}
 
-	if !contextutil.CanAccessUser(c, uint(id)) {
-		_ = c.Error(apiErrors.Forbidden("Forbidden user ID"))
+	if !contextutil.CanAccessUser(c, uint(id), contextutil.AllowPermission(PermissionUsersRead)) {
+		_ = c.Error(apiErrors.Forbidden(apiErrors.MsgForbiddenUserID))
 		return
 	}
 
 	user, err := h.userService.GetUserByID(c.Request.Context(), uint(id))
 	if err != nil {
 		if errors.Is(err, ErrUserNotFound) {
-			_ = c.Error(apiErrors.NotFound("User not found"))
+			_ = c.Error(apiErrors.NotFound(apiErrors.MsgUserNotFound))
 			return
 		}
 		_ = c.Error(apiErrors.InternalServerError(err))
 		return
 	}
 
-	c.JSON(http.StatusOK, apiErrors.Success(ToUserResponse(user)))
+	c.JSON(http.StatusOK, apiErrors.Success(shapedUserResponse(c, user)))
+}
+
+// GetAdminUser godoc
+// @Summary Get user detail (Admin only)
+// @Description Get a user together with their failed login lockout state
+// @Tags admin
+// @Produce json
+// @Param id path int true "User ID"
+// @Security BearerAuth
+// @Success 200 {object} errors.Response{success=bool,data=AdminUserResponse} "User detail with lockout state"
+// @Failure 400 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Invalid user ID"
+// @Failure 401 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Unauthorized"
+// @Failure 403 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Admin access required"
+// @Failure 404 {object} errors.Response{success=bool,error=errors.ErrorInfo} "User not found"
+// @Failure 500 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Failed to get user"
+// @Router /api/v1/admin/users/{id} [get]
+func (h *Handler) GetAdminUser(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		_ = c.Error(apiErrors.BadRequest(apiErrors.MsgInvalidUserID))
+		return
+	}
+
+	ctx := c.Request.Context()
+	user, err := h.userService.GetUserByID(ctx, uint(id))
+	if err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			_ = c.Error(apiErrors.NotFound(apiErrors.MsgUserNotFound))
+			return
+		}
+		_ = c.Error(apiErrors.InternalServerError(err))
+		return
+	}
+
+	lockout, err := h.userService.GetLockoutStatus(ctx, user.ID)
+	if err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			_ = c.Error(apiErrors.NotFound(apiErrors.MsgUserNotFound))
+			return
+		}
+		_ = c.Error(apiErrors.InternalServerError(err))
+		return
+	}
+
+	if contextutil.IsAPIVersion(c, contextutil.APIVersionV2) {
+		c.JSON(http.StatusOK, apiErrors.Success(AdminUserResponseV2{
+			UserResponseV2: ToUserResponseV2(user),
+			Lockout:        ToLockoutStatusResponse(lockout),
+		}))
+		return
+	}
+	c.JSON(http.StatusOK, apiErrors.Success(AdminUserResponse{
+		UserResponse: ToUserResponse(user),
+		Lockout:      ToLockoutStatusResponse(lockout),
+	}))
 }
 
 // UpdateUser godoc
 // @Summary Update user
-// @Description Update user information (requires authentication)
+// @Description Update user information (requires authentication). With security.email_change_verification a new email is returned as pending_email until confirmed via /auth/verify-email-change
 // @Tags users
 // @Accept json
 // @Produce json
@@ -173,17 +339,18 @@ func (h *Handler) GetUser(c *gin.Context) {
 // @Failure 429 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Rate limit exceeded"
 // @Failure 500 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Failed to update user"
 // @Router /api/v1/users/{id} [put]
+// @Router /api/v1/admin/users/{id} [put]
 func (h *Handler) UpdateUser(c *gin.Context) {
 	// Parse ID from URL
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
-		_ = c.Error(apiErrors.BadRequest("Invalid user ID"))
+		_ = c.Error(apiErrors.BadRequest(apiErrors.MsgInvalidUserID))
 		return
 	}
 
 	// Authorization check
-	if !contextutil.CanAccessUser(c, uint(id)) {
-		_ = c.Error(apiErrors.Forbidden("Forbidden user ID"))
+	if !contextutil.CanAccessUser(c, uint(id), contextutil.AllowPermission(PermissionUsersWrite)) {
+		_ = c.Error(apiErrors.Forbidden(apiErrors.MsgForbiddenUserID))
 		return
 	}
 
@@ -196,18 +363,71 @@ func (h *Handler) UpdateUser(c *gin.Context) {
 	user, err := h.userService.UpdateUser(c.Request.Context(), uint(id), req)
 	if err != nil {
 		if errors.Is(err, ErrUserNotFound) {
-			_ = c.Error(apiErrors.NotFound("User not found"))
+			_ = c.Error(apiErrors.NotFound(apiErrors.MsgUserNotFound))
 			return
 		}
 		if errors.Is(err, ErrEmailExists) {
-			_ = c.Error(apiErrors.Conflict("Email already exists"))
+			_ = c.Error(apiErrors.Conflict(apiErrors.MsgEmailExists))
 			return
 		}
 		_ = c.Error(apiErrors.InternalServerError(err))
 		return
 	}
 
-	c.JSON(http.StatusOK, apiErrors.Success(ToUserResponse(user)))
+	c.JSON(http.StatusOK, apiErrors.Success(versionedUserResponse(c, user)))
+}
+
+// PatchUser godoc
+// @Summary Partially update user
+// @Description Update only the provided user fields; omitted fields are left unchanged (requires authentication). With security.email_change_verification a new email is returned as pending_email until confirmed via /auth/verify-email-change
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path int true "User ID"
+// @Param request body PatchUserRequest true "Partial update request"
+// @Security BearerAuth
+// @Success 200 {object} errors.Response{success=bool,data=UserResponse} "Success response with updated user data"
+// @Failure 400 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Invalid user ID or Validation error"
+// @Failure 403 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Forbidden user ID"
+// @Failure 404 {object} errors.Response{success=bool,error=errors.ErrorInfo} "User not found"
+// @Failure 409 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Email already exists"
+// @Failure 429 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Rate limit exceeded"
+// @Failure 500 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Failed to update user"
+// @Router /api/v1/users/{id} [patch]
+// @Router /api/v1/admin/users/{id} [patch]
+func (h *Handler) PatchUser(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		_ = c.Error(apiErrors.BadRequest(apiErrors.MsgInvalidUserID))
+		return
+	}
+
+	if !contextutil.CanAccessUser(c, uint(id), contextutil.AllowPermission(PermissionUsersWrite)) {
+		_ = c.Error(apiErrors.Forbidden(apiErrors.MsgForbiddenUserID))
+		return
+	}
+
+	var req PatchUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(apiErrors.FromGinValidation(err))
+		return
+	}
+
+	user, err := h.userService.PatchUser(c.Request.Context(), uint(id), req)
+	if err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			_ = c.Error(apiErrors.NotFound(apiErrors.MsgUserNotFound))
+			return
+		}
+		if errors.Is(err, ErrEmailExists) {
+			_ = c.Error(apiErrors.Conflict(apiErrors.MsgEmailExists))
+			return
+		}
+		_ = c.Error(apiErrors.InternalServerError(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, apiErrors.Success(versionedUserResponse(c, user)))
 }
 
 // DeleteUser godoc
@@ -225,23 +445,24 @@ func (h *Handler) UpdateUser(c *gin.Context) {
 // @Failure 429 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Rate limit exceeded"
 // @Failure 500 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Failed to delete user"
 // @Router /api/v1/users/{id} [delete]
+// @Router /api/v1/admin/users/{id} [delete]
 func (h *Handler) DeleteUser(c *gin.Context) {
 	// Parse ID from URL
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
-		_ = c.Error(apiErrors.BadRequest("Invalid user ID"))
+		_ = c.Error(apiErrors.BadRequest(apiErrors.MsgInvalidUserID))
 		return
 	}
 
 	// Authorization check
-	if !contextutil.CanAccessUser(c, uint(id)) {
-		_ = c.Error(apiErrors.Forbidden("Forbidden user ID"))
+	if !contextutil.CanAccessUser(c, uint(id), contextutil.AllowPermission(PermissionUsersDelete)) {
+		_ = c.Error(apiErrors.Forbidden(apiErrors.MsgForbiddenUserID))
 		return
 	}
 
 	if err := h.userService.DeleteUser(c.Request.Context(), uint(id)); err != nil {
 		if errors.Is(err, ErrUserNotFound) {
-			_ = c.Error(apiErrors.NotFound("User not found"))
+			_ = c.Error(apiErrors.NotFound(apiErrors.MsgUserNotFound))
 			return
 		}
 		_ = c.Error(apiErrors.InternalServerError(err))
@@ -253,26 +474,45 @@ func (h *Handler) DeleteUser(c *gin.Context) {
 
 // RefreshToken godoc
 // @Summary Refresh access token
-// @Description Exchange refresh token for new access and refresh tokens with automatic rotation
+// @Description Exchange refresh token for new access and refresh tokens with automatic rotation.
+// @Description In cookie mode the body may be omitted: the token is read from the refresh cookie, X-CSRF-Token must echo the CSRF cookie, and the rotated token is returned as a cookie.
 // @Tags auth
 // @Accept json
 // @Produce json
-// @Param request body auth.RefreshTokenRequest true "Refresh token request"
+// @Param request body auth.RefreshTokenRequest false "Refresh token request (optional in cookie mode)"
+// @Param X-CSRF-Token header string false "CSRF cookie value, required when using the refresh cookie"
 // @Success 200 {object} errors.Response{success=bool,data=auth.TokenPairResponse} "Success response with new token pair"
 // @Failure 400 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Validation error"
 // @Failure 401 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Invalid or expired refresh token"
-// @Failure 403 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Token reuse detected - all tokens revoked"
+// @Failure 403 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Token reuse or session anomaly detected - all tokens revoked, invalid CSRF token, client not allowed to refresh, or account disabled (ACCOUNT_DISABLED)"
+// @Failure 429 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Too many refresh attempts from this client or token family"
 // @Failure 500 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Failed to refresh token"
+// @Failure 503 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Token store temporarily unavailable - the refresh token is still valid, retry after Retry-After seconds"
 // @Router /api/v1/auth/refresh [post]
 func (h *Handler) RefreshToken(c *gin.Context) {
-	var req auth.RefreshTokenRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		_ = c.Error(apiErrors.FromGinValidation(err))
+	refreshToken, fromCookie, apiErr := h.readRefreshToken(c)
+	if apiErr != nil {
+		_ = c.Error(apiErr)
 		return
 	}
 
-	tokenPair, err := h.authService.RefreshAccessToken(c.Request.Context(), req.RefreshToken)
+	tokenPair, err := h.authService.RefreshAccessToken(clientContext(c), refreshToken)
 	if err != nil {
+		if errors.Is(err, auth.ErrRotationFailed) {
+			// The presented token was not consumed, so keep the cookie and let the client retry
+			slog.Warn("Refresh token rotation failed", "error", err)
+			c.Header("Retry-After", strconv.Itoa(refreshRetryAfterSeconds))
+			_ = c.Error(apiErrors.RetryableUnavailable("Token refresh is temporarily unavailable", refreshRetryAfterSeconds))
+			return
+		}
+		// The session of a delayed-activation token starts later, so keep the cookie as well
+		if errors.Is(err, auth.ErrTokenNotYetValid) {
+			_ = c.Error(apiErrors.Unauthorized("Refresh token is not yet valid"))
+			return
+		}
+		if fromCookie {
+			h.clearCookies(c)
+		}
 		if errors.Is(err, auth.ErrInvalidToken) || errors.Is(err, auth.ErrExpiredToken) {
 			_ = c.Error(apiErrors.Unauthorized("Invalid or expired refresh token"))
 			return
@@ -281,17 +521,35 @@ func (h *Handler) RefreshToken(c *gin.Context) {
 			_ = c.Error(apiErrors.Forbidden("Token reuse detected. All tokens have been revoked for security."))
 			return
 		}
+		if errors.Is(err, auth.ErrGrantNotAllowed) {
+			_ = c.Error(apiErrors.Forbidden("This client is not allowed to refresh tokens"))
+			return
+		}
+		if errors.Is(err, auth.ErrSessionAnomaly) {
+			_ = c.Error(apiErrors.Forbidden("Session used from an unrecognized client. Please log in again."))
+			return
+		}
 		if errors.Is(err, auth.ErrTokenRevoked) {
 			_ = c.Error(apiErrors.Unauthorized("Token has been revoked"))
 			return
 		}
+		if errors.Is(err, auth.ErrAccountDisabled) {
+			_ = c.Error(apiErrors.AccountDisabled())
+			return
+		}
+		_ = c.Error(apiErrors.InternalServerError(err))
+		return
+	}
+
+	newRefreshToken, err := h.deliverTokens(c, tokenPair, fromCookie)
+	if err != nil {
 		_ = c.Error(apiErrors.InternalServerError(err))
 		return
 	}
 
 	c.JSON(http.StatusOK, apiErrors.Success(auth.TokenPairResponse{
 		AccessToken:  tokenPair.AccessToken,
-		RefreshToken: tokenPair.RefreshToken,
+		RefreshToken: newRefreshToken,
 		TokenType:    tokenPair.TokenType,
 		ExpiresIn:    tokenPair.ExpiresIn,
 	}))
@@ -299,32 +557,34 @@ func (h *Handler) RefreshToken(c *gin.Context) {
 
 // Logout godoc
 // @Summary Logout user
-// @Description Revoke refresh token and invalidate user session
+// @Description Revoke refresh token and invalidate user session.
+// @Description In cookie mode the body may be omitted: the token is read from the refresh cookie, X-CSRF-Token must echo the CSRF cookie, and the cookie is cleared.
 // @Tags auth
 // @Accept json
 // @Produce json
 // @Security BearerAuth
-// @Param request body auth.RefreshTokenRequest true "Refresh token to revoke"
-// @Success 200 {object} errors.Response{success=bool,data=object} "Successfully logged out"
+// @Param request body auth.RefreshTokenRequest false "Refresh token to revoke (optional in cookie mode)"
+// @Param X-CSRF-Token header string false "CSRF cookie value, required when using the refresh cookie"
+// @Success 200 {object} errors.Response{success=bool,data=MessageResponse} "Successfully logged out"
 // @Failure 400 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Validation error"
 // @Failure 401 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Unauthorized"
-// @Failure 403 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Token does not belong to user"
+// @Failure 403 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Token does not belong to user or invalid CSRF token"
 // @Failure 500 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Failed to logout"
 // @Router /api/v1/auth/logout [post]
 func (h *Handler) Logout(c *gin.Context) {
 	userID := contextutil.GetUserID(c)
 	if userID == 0 {
-		_ = c.Error(apiErrors.Unauthorized("user not authenticated"))
+		_ = c.Error(apiErrors.Unauthorized(apiErrors.MsgUserNotAuthenticated))
 		return
 	}
 
-	var req auth.RefreshTokenRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		_ = c.Error(apiErrors.FromGinValidation(err))
+	refreshToken, fromCookie, apiErr := h.readRefreshToken(c)
+	if apiErr != nil {
+		_ = c.Error(apiErr)
 		return
 	}
 
-	if err := h.authService.RevokeUserRefreshToken(c.Request.Context(), userID, req.RefreshToken); err != nil {
+	if err := h.authService.RevokeUserRefreshToken(c.Request.Context(), userID, refreshToken); err != nil {
 		if errors.Is(err, auth.ErrTokenDoesNotBelongToUser) {
 			_ = c.Error(apiErrors.Forbidden("token does not belong to user"))
 			return
@@ -333,89 +593,1204 @@ func (h *Handler) Logout(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, apiErrors.Success(gin.H{"message": "Successfully logged out"}))
+	if fromCookie {
+		h.clearCookies(c)
+	}
+
+	c.JSON(http.StatusOK, apiErrors.Success(MessageResponse{Message: "Successfully logged out"}))
+}
+
+// LogoutAll godoc
+// @Summary Logout everywhere
+// @Description Revoke every refresh token of the current user so no session can be refreshed. Access tokens already issued, including the one used for this request, remain valid until they expire.
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} errors.Response{success=bool,data=RevokeSessionsResponse} "Number of sessions revoked"
+// @Failure 401 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Unauthorized"
+// @Failure 403 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Not allowed from an impersonation session"
+// @Failure 500 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Failed to revoke sessions"
+// @Failure 503 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Session storage not available"
+// @Router /api/v1/auth/logout-all [post]
+func (h *Handler) LogoutAll(c *gin.Context) {
+	userID := contextutil.GetUserID(c)
+	if userID == 0 {
+		_ = c.Error(apiErrors.Unauthorized(apiErrors.MsgUserNotAuthenticated))
+		return
+	}
+	if contextutil.IsImpersonating(c) {
+		_ = c.Error(apiErrors.Forbidden("Cannot revoke sessions from an impersonation session"))
+		return
+	}
+
+	revoked, apiErr := h.revokeAllSessions(c, userID)
+	if apiErr != nil {
+		_ = c.Error(apiErr)
+		return
+	}
+
+	if h.refreshCookie.Enabled() {
+		h.clearCookies(c)
+	}
+
+	c.JSON(http.StatusOK, apiErrors.Success(RevokeSessionsResponse{RevokedSessions: revoked}))
 }
 
 // GetMe godoc
 // @Summary Get current user
-// @Description Get the currently authenticated user's information with roles
+// @Description Get the currently authenticated user's information with roles, the time of their last successful password login and, when policies are configured, which policy versions they have accepted
 // @Tags auth
 // @Accept json
 // @Produce json
 // @Security BearerAuth
-// @Success 200 {object} errors.Response{success=bool,data=UserResponse} "Success response with current user data"
+// @Success 200 {object} errors.Response{success=bool,data=MeResponse} "Success response with current user data"
 // @Failure 401 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Unauthorized"
+// @Failure 403 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Account disabled (ACCOUNT_DISABLED)"
+// @Failure 404 {object} errors.Response{success=bool,error=errors.ErrorInfo} "User no longer exists"
 // @Failure 500 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Failed to get user"
+// @Failure 503 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Database temporarily unavailable"
 // @Router /api/v1/auth/me [get]
 func (h *Handler) GetMe(c *gin.Context) {
 	userID := contextutil.GetUserID(c)
 	if userID == 0 {
-		_ = c.Error(apiErrors.Unauthorized("User not authenticated"))
+		_ = c.Error(apiErrors.Unauthorized(apiErrors.MsgUserNotAuthenticated))
 		return
 	}
 
 	user, err := h.userService.GetUserByID(c.Request.Context(), userID)
 	if err != nil {
 		if errors.Is(err, ErrUserNotFound) {
-			_ = c.Error(apiErrors.NotFound("User not found"))
+			_ = c.Error(apiErrors.NotFound(apiErrors.MsgUserNotFound))
 			return
 		}
 		_ = c.Error(apiErrors.InternalServerError(err))
 		return
 	}
+	// WHY: Access tokens issued before the account was disabled stay valid until they expire unless version checks are on
+	if !user.Active {
+		_ = c.Error(apiErrors.AccountDisabled())
+		return
+	}
 
-	c.JSON(http.StatusOK, apiErrors.Success(ToUserResponse(user)))
+	lastLoginAt, err := h.userService.GetLastLoginAt(c.Request.Context(), userID)
+	if err != nil {
+		_ = c.Error(apiErrors.InternalServerError(err))
+		return
+	}
+	var lastLogin *string
+	if lastLoginAt != nil {
+		formatted := lastLoginAt.UTC().Format(time.RFC3339)
+		lastLogin = &formatted
+	}
+
+	var policies []PolicyStatusResponse
+	if h.policies != nil {
+		statuses, err := h.policies.GetPolicyStatus(c.Request.Context(), userID)
+		if err != nil {
+			_ = c.Error(apiErrors.InternalServerError(err))
+			return
+		}
+		policies = ToPolicyStatusResponses(statuses)
+	}
+
+	if contextutil.IsAPIVersion(c, contextutil.APIVersionV2) {
+		c.JSON(http.StatusOK, apiErrors.Success(MeResponseV2{UserResponseV2: ToUserResponseV2(user), LastLoginAt: lastLogin, Policies: policies}))
+		return
+	}
+	c.JSON(http.StatusOK, apiErrors.Success(MeResponse{UserResponse: ToUserResponse(user), LastLoginAt: lastLogin, Policies: policies}))
 }
 
-// ListUsers godoc
-// @Summary List all users (Admin only)
-// @Description Get paginated list of all users with optional filtering (requires admin role)
-// @Tags admin
-// @Accept json
+// GetLoginHistory godoc
+// @Summary List my login history
+// @Description List the authenticated user's password login attempts, successful and failed, most recent first. Attempts are kept for the configured retention period.
+// @Tags auth
 // @Produce json
 // @Security BearerAuth
 // @Param page query int false "Page number" default(1)
-// @Param per_page query int false "Items per page (max 100)" default(20)
-// @Param role query string false "Filter by role (user or admin)"
-// @Param search query string false "Search by name or email"
-// @Param sort query string false "Sort by field (created_at, updated_at, name, email)" default(created_at)
-// @Param order query string false "Sort order (asc or desc)" default(desc)
-// @Success 200 {object} errors.Response{success=bool,data=UserListResponse} "Success response with paginated user list"
-// @Failure 400 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Invalid parameters"
-// @Failure 403 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Admin access required"
-// @Failure 500 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Failed to list users"
-// @Router /api/v1/admin/users [get]
-func (h *Handler) ListUsers(c *gin.Context) {
+// @Param per_page query int false "Items per page" default(20)
+// @Success 200 {object} errors.Response{success=bool,data=LoginHistoryResponse} "Login attempts"
+// @Failure 401 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Unauthorized"
+// @Failure 500 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Failed to list login history"
+// @Router /api/v1/auth/login-history [get]
+func (h *Handler) GetLoginHistory(c *gin.Context) {
+	userID := contextutil.GetUserID(c)
+	if userID == 0 {
+		_ = c.Error(apiErrors.Unauthorized(apiErrors.MsgUserNotAuthenticated))
+		return
+	}
+
 	pagination := middleware.ParsePaginationParams(c)
-	filters := ParseUserFilters(c)
+	// Fetch one extra attempt to detect the next page
+	attempts, err := h.userService.ListLoginHistory(c.Request.Context(), userID, pagination.PerPage+1, (pagination.Page-1)*pagination.PerPage)
+	if err != nil {
+		_ = c.Error(apiErrors.InternalServerError(err))
+		return
+	}
 
-	users, total, err := h.userService.ListUsers(c.Request.Context(), filters, pagination.Page, pagination.PerPage)
+	response := LoginHistoryResponse{
+		Page:    pagination.Page,
+		PerPage: pagination.PerPage,
+		HasNext: len(attempts) > pagination.PerPage,
+	}
+	if response.HasNext {
+		attempts = attempts[:pagination.PerPage]
+	}
+	response.Attempts = make([]LoginAttemptResponse, len(attempts))
+	for i := range attempts {
+		response.Attempts[i] = ToLoginAttemptResponse(&attempts[i])
+	}
+
+	c.JSON(http.StatusOK, apiErrors.Success(response))
+}
+
+// GetMyPermissions godoc
+// @Summary Get current user's roles and permissions
+// @Description Get the authenticated user's current roles and the flattened set of permissions granted by them. Read from the database, so role changes are reflected immediately even before the access token is refreshed.
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} errors.Response{success=bool,data=EffectivePermissions} "Success response with roles and permissions"
+// @Failure 401 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Unauthorized"
+// @Failure 404 {object} errors.Response{success=bool,error=errors.ErrorInfo} "User not found"
+// @Failure 500 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Failed to get permissions"
+// @Router /api/v1/auth/me/permissions [get]
+func (h *Handler) GetMyPermissions(c *gin.Context) {
+	userID := contextutil.GetUserID(c)
+	if userID == 0 {
+		_ = c.Error(apiErrors.Unauthorized(apiErrors.MsgUserNotAuthenticated))
+		return
+	}
+
+	permissions, err := h.userService.GetEffectivePermissions(c.Request.Context(), userID)
 	if err != nil {
-		if errors.Is(err, ErrInvalidRole) {
-			_ = c.Error(apiErrors.BadRequest("Invalid role filter"))
+		if errors.Is(err, ErrUserNotFound) {
+			_ = c.Error(apiErrors.NotFound(apiErrors.MsgUserNotFound))
 			return
 		}
 		_ = c.Error(apiErrors.InternalServerError(err))
 		return
 	}
 
-	userResponses := make([]UserResponse, len(users))
-	for i, user := range users {
-		userResponses[i] = ToUserResponse(&user)
+	c.JSON(http.StatusOK, apiErrors.Success(permissions))
+}
+
+// UpdateMe godoc
+// @Summary Update current user
+// @Description Partially update the currently authenticated user's name and/or email; omitted fields are left unchanged. With security.email_change_verification a new email is returned as pending_email until confirmed via /auth/verify-email-change
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body PatchUserRequest true "Partial update request"
+// @Success 200 {object} errors.Response{success=bool,data=UserResponse} "Success response with updated user data"
+// @Failure 400 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Validation error"
+// @Failure 401 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Unauthorized"
+// @Failure 404 {object} errors.Response{success=bool,error=errors.ErrorInfo} "User not found"
+// @Failure 409 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Email already exists"
+// @Failure 500 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Failed to update user"
+// @Router /api/v1/auth/me [patch]
+func (h *Handler) UpdateMe(c *gin.Context) {
+	userID := contextutil.GetUserID(c)
+	if userID == 0 {
+		_ = c.Error(apiErrors.Unauthorized(apiErrors.MsgUserNotAuthenticated))
+		return
 	}
 
-	totalPages := int(total) / pagination.PerPage
-	if int(total)%pagination.PerPage > 0 {
-		totalPages++
+	var req PatchUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(apiErrors.FromGinValidation(err))
+		return
 	}
 
-	response := UserListResponse{
-		Users:      userResponses,
-		Total:      total,
-		Page:       pagination.Page,
-		PerPage:    pagination.PerPage,
-		TotalPages: totalPages,
+	user, err := h.userService.PatchUser(c.Request.Context(), userID, req)
+	if err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			_ = c.Error(apiErrors.NotFound(apiErrors.MsgUserNotFound))
+			return
+		}
+		if errors.Is(err, ErrEmailExists) {
+			_ = c.Error(apiErrors.Conflict(apiErrors.MsgEmailExists))
+			return
+		}
+		_ = c.Error(apiErrors.InternalServerError(err))
+		return
 	}
 
-	c.JSON(http.StatusOK, apiErrors.Success(response))
+	c.JSON(http.StatusOK, apiErrors.Success(versionedUserResponse(c, user)))
+}
+
+// VerifyEmailChange godoc
+// @Summary Confirm email change
+// @Description Confirm a pending email change with the token sent to the new address. The new email replaces the current one and is used for login from then on; the token can only be used once.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body VerifyEmailChangeRequest true "Email change token"
+// @Success 200 {object} errors.Response{success=bool,data=UserResponse} "Success response with the updated user"
+// @Failure 400 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Validation error or invalid, used or expired token"
+// @Failure 409 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Email already exists"
+// @Failure 429 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Rate limit exceeded"
+// @Failure 500 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Failed to confirm email change"
+// @Router /api/v1/auth/verify-email-change [post]
+func (h *Handler) VerifyEmailChange(c *gin.Context) {
+	var req VerifyEmailChangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(apiErrors.FromGinValidation(err))
+		return
+	}
+
+	user, err := h.userService.ConfirmEmailChange(c.Request.Context(), req.Token)
+	if err != nil {
+		if errors.Is(err, ErrInvalidEmailChangeToken) {
+			_ = c.Error(apiErrors.BadRequest("Invalid or expired email change token"))
+			return
+		}
+		if errors.Is(err, ErrEmailExists) {
+			_ = c.Error(apiErrors.Conflict(apiErrors.MsgEmailExists))
+			return
+		}
+		_ = c.Error(apiErrors.InternalServerError(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, apiErrors.Success(versionedUserResponse(c, user)))
+}
+
+// DeleteMe godoc
+// @Summary Delete current user
+// @Description Permanently delete the authenticated user's account. The current password is required; all refresh tokens are revoked before deletion.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body DeleteMeRequest true "Current password"
+// @Success 204
+// @Failure 400 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Validation error"
+// @Failure 401 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Unauthorized"
+// @Failure 403 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Incorrect password or impersonation session"
+// @Failure 404 {object} errors.Response{success=bool,error=errors.ErrorInfo} "User not found"
+// @Failure 500 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Failed to delete user"
+// @Router /api/v1/auth/me [delete]
+func (h *Handler) DeleteMe(c *gin.Context) {
+	userID := contextutil.GetUserID(c)
+	if userID == 0 {
+		_ = c.Error(apiErrors.Unauthorized(apiErrors.MsgUserNotAuthenticated))
+		return
+	}
+	// WHY: An admin acting as the user must not be able to destroy the account
+	if contextutil.IsImpersonating(c) {
+		_ = c.Error(apiErrors.Forbidden("Cannot delete an account from an impersonation session"))
+		return
+	}
+
+	var req DeleteMeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(apiErrors.FromGinValidation(err))
+		return
+	}
+
+	ctx := c.Request.Context()
+	if err := h.userService.VerifyPassword(ctx, userID, req.Password); err != nil {
+		if errors.Is(err, ErrInvalidCredentials) {
+			_ = c.Error(apiErrors.Forbidden("Incorrect password"))
+			return
+		}
+		if errors.Is(err, ErrUserNotFound) {
+			_ = c.Error(apiErrors.NotFound(apiErrors.MsgUserNotFound))
+			return
+		}
+		_ = c.Error(apiErrors.InternalServerError(err))
+		return
+	}
+
+	if _, err := h.authService.RevokeAllUserTokens(ctx, userID); err != nil {
+		_ = c.Error(apiErrors.InternalServerError(err))
+		return
+	}
+
+	if err := h.userService.DeleteUser(ctx, userID); err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			_ = c.Error(apiErrors.NotFound(apiErrors.MsgUserNotFound))
+			return
+		}
+		_ = c.Error(apiErrors.InternalServerError(err))
+		return
+	}
+
+	slog.InfoContext(ctx, "User deleted own account", "user_id", userID)
+	c.Status(http.StatusNoContent)
+}
+
+// ChangePassword godoc
+// @Summary Change current user's password
+// @Description Replace the authenticated user's password. The current password is required and the new one must satisfy the password policy; with security.password_history_depth it may not match any of the user's recent passwords. All refresh tokens are revoked, so other sessions must sign in again.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body ChangePasswordRequest true "Current and new password"
+// @Success 204
+// @Failure 400 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Validation error, weak or recently used password"
+// @Failure 401 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Unauthorized"
+// @Failure 403 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Incorrect password or impersonation session"
+// @Failure 404 {object} errors.Response{success=bool,error=errors.ErrorInfo} "User not found"
+// @Failure 500 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Failed to change password"
+// @Router /api/v1/auth/me/password [put]
+func (h *Handler) ChangePassword(c *gin.Context) {
+	userID := contextutil.GetUserID(c)
+	if userID == 0 {
+		_ = c.Error(apiErrors.Unauthorized(apiErrors.MsgUserNotAuthenticated))
+		return
+	}
+	// WHY: An admin acting as the user must not be able to take over the account
+	if contextutil.IsImpersonating(c) {
+		_ = c.Error(apiErrors.Forbidden("Cannot change the password from an impersonation session"))
+		return
+	}
+
+	var req ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(apiErrors.FromGinValidation(err))
+		return
+	}
+
+	ctx := c.Request.Context()
+	if err := h.userService.ChangePassword(ctx, userID, req.CurrentPassword, req.NewPassword); err != nil {
+		if errors.Is(err, ErrInvalidCredentials) {
+			_ = c.Error(apiErrors.Forbidden("Incorrect password"))
+			return
+		}
+		if errors.Is(err, ErrUserNotFound) {
+			_ = c.Error(apiErrors.NotFound(apiErrors.MsgUserNotFound))
+			return
+		}
+		if reason, ok := newPasswordError(err); ok {
+			apiErr := apiErrors.ValidationError(map[string]string{"NewPassword": "NewPassword " + reason})
+			apiErr.Fields = map[string]string{"new_password": reason}
+			_ = c.Error(apiErr)
+			return
+		}
+		_ = c.Error(apiErrors.InternalServerError(err))
+		return
+	}
+
+	if _, err := h.authService.RevokeAllUserTokens(ctx, userID); err != nil {
+		_ = c.Error(apiErrors.InternalServerError(err))
+		return
+	}
+
+	slog.InfoContext(ctx, "User changed own password", "user_id", userID)
+	c.Status(http.StatusNoContent)
+}
+
+// newPasswordReasons maps rejected new passwords to client-facing reasons
+var newPasswordReasons = []struct {
+	err    error
+	reason string
+}{
+	{ErrPasswordReused, "must differ from recently used passwords"},
+	{ErrPasswordTooShort, "is too short"},
+	{ErrPasswordMissingUppercase, "must contain at least one uppercase letter"},
+	{ErrPasswordMissingLowercase, "must contain at least one lowercase letter"},
+	{ErrPasswordMissingNumber, "must contain at least one digit"},
+	{ErrPasswordMissingSpecial, "must contain at least one special character"},
+}
+
+// newPasswordError returns why a new password was rejected, or false for other errors
+func newPasswordError(err error) (string, bool) {
+	for _, r := range newPasswordReasons {
+		if errors.Is(err, r.err) {
+			return r.reason, true
+		}
+	}
+	return "", false
+}
+
+// ListUsers godoc
+// @Summary List all users (Admin only)
+// @Description Get paginated list of all users with optional filtering (requires the users:read permission)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "Page number" default(1)
+// @Param per_page query int false "Items per page (default and max set by pagination config)" default(20)
+// @Param role query string false "Filter by role (user or admin)"
+// @Param search query string false "Search by name or email"
+// @Param status query string false "Filter by account status" Enums(active, disabled)
+// @Param sort query string false "Sort by field (created_at, updated_at, name, email, role); id is always the tiebreaker" default(created_at)
+// @Param order query string false "Sort order (asc or desc)" default(desc)
+// @Param count query string false "Total computation: exact, estimated (planner statistics, unfiltered lists only) or none (omits total and total_pages)" Enums(exact, estimated, none) default(exact)
+// @Success 200 {object} errors.Response{success=bool,data=UserListResponse} "Success response with paginated user list"
+// @Failure 400 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Invalid parameters"
+// @Failure 403 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Admin access required"
+// @Failure 500 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Failed to list users"
+// @Router /api/v1/admin/users [get]
+func (h *Handler) ListUsers(c *gin.Context) {
+	pagination := middleware.ParsePaginationParams(c)
+	filters, err := ParseUserFilters(c)
+	if err != nil {
+		if errors.Is(err, ErrInvalidCountMode) {
+			_ = c.Error(apiErrors.BadRequest("Invalid count mode, allowed: exact, estimated, none"))
+			return
+		}
+		if errors.Is(err, ErrInvalidStatusFilter) {
+			_ = c.Error(apiErrors.BadRequest("Invalid status filter, allowed: active, disabled"))
+			return
+		}
+		_ = c.Error(invalidSortFieldError())
+		return
+	}
+
+	users, total, err := h.userService.ListUsers(c.Request.Context(), filters, pagination.Page, pagination.PerPage)
+	if err != nil {
+		if errors.Is(err, ErrInvalidRole) {
+			_ = c.Error(apiErrors.BadRequest("Invalid role filter"))
+			return
+		}
+		if errors.Is(err, ErrInvalidSortField) {
+			_ = c.Error(invalidSortFieldError())
+			return
+		}
+		_ = c.Error(apiErrors.InternalServerError(err))
+		return
+	}
+
+	page := UserPageInfo{
+		Page:    pagination.Page,
+		PerPage: pagination.PerPage,
+	}
+
+	if total == TotalUnknown {
+		// The repository fetched one extra row to detect the next page
+		page.HasNext = len(users) > pagination.PerPage
+		if page.HasNext {
+			users = users[:pagination.PerPage]
+		}
+	} else {
+		totalPages := int(total) / pagination.PerPage
+		if int(total)%pagination.PerPage > 0 {
+			totalPages++
+		}
+		page.Total = &total
+		page.TotalPages = &totalPages
+		page.HasNext = pagination.Page < totalPages
+	}
+
+	// WHY: The route already requires users:read; shaping here as well keeps emails out of the
+	// response if the listing is ever mounted behind a weaker guard
+	if !contextutil.HasPermission(c, PermissionUsersRead) {
+		response := PublicUserListResponse{Users: make([]PublicUserResponse, len(users)), UserPageInfo: page}
+		for i, user := range users {
+			response.Users[i] = ToPublicUserResponse(&user)
+		}
+		c.JSON(http.StatusOK, apiErrors.Success(response))
+		return
+	}
+
+	response := UserListResponse{Users: make([]UserResponse, len(users)), UserPageInfo: page}
+	for i, user := range users {
+		response.Users[i] = ToUserResponse(&user)
+	}
+
+	c.JSON(http.StatusOK, apiErrors.Success(response))
+}
+
+// SearchUsers godoc
+// @Summary Search users by name
+// @Description Find active users whose name contains q, e.g. for mention pickers. Results use the public user shape without email and are capped at 10; the endpoint has its own per-user rate limit (ratelimit.search_requests per ratelimit.search_window)
+// @Tags users
+// @Produce json
+// @Security BearerAuth
+// @Param q query string true "Part of the user's name (1-100 characters)"
+// @Success 200 {object} errors.Response{success=bool,data=UserSearchResponse} "Matching users ordered by name"
+// @Failure 400 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Missing or too long query"
+// @Failure 401 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Unauthorized"
+// @Failure 429 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Rate limit exceeded"
+// @Failure 500 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Failed to search users"
+// @Router /api/v1/users/search [get]
+func (h *Handler) SearchUsers(c *gin.Context) {
+	query := strings.TrimSpace(c.Query("q"))
+	if query == "" || utf8.RuneCountInString(query) > maxUserSearchLength {
+		_ = c.Error(apiErrors.BadRequest("Search query q must be 1-100 characters"))
+		return
+	}
+
+	// WHY: Matching emails would let any user confirm whether an address is registered
+	filters := UserFilterParams{
+		Search:    query,
+		NameOnly:  true,
+		Status:    UserStatusActive,
+		Sort:      "name",
+		Order:     "asc",
+		CountMode: CountNone,
+	}
+	users, _, err := h.userService.ListUsers(c.Request.Context(), filters, 1, userSearchLimit)
+	if err != nil {
+		_ = c.Error(apiErrors.InternalServerError(err))
+		return
+	}
+	// CountNone fetches one extra row to detect a next page, which search does not report
+	if len(users) > userSearchLimit {
+		users = users[:userSearchLimit]
+	}
+
+	if contextutil.IsAPIVersion(c, contextutil.APIVersionV2) {
+		response := UserSearchResponseV2{Users: make([]PublicUserResponseV2, len(users))}
+		for i, user := range users {
+			response.Users[i] = ToPublicUserResponseV2(&user)
+		}
+		c.JSON(http.StatusOK, apiErrors.Success(response))
+		return
+	}
+	response := UserSearchResponse{Users: make([]PublicUserResponse, len(users))}
+	for i, user := range users {
+		response.Users[i] = ToPublicUserResponse(&user)
+	}
+	c.JSON(http.StatusOK, apiErrors.Success(response))
+}
+
+// invalidSortFieldError lists the accepted sort fields so clients can correct the request
+func invalidSortFieldError() *apiErrors.APIError {
+	return apiErrors.BadRequest("Invalid sort field, allowed: " + strings.Join(SortableUserFields(), ", "))
+}
+
+// GetStats godoc
+// @Summary Get user statistics (Admin only)
+// @Description Get total users, admins, recent registrations and active sessions count (requires the stats:read permission)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} errors.Response{success=bool,data=UserStatsResponse} "Success response with user statistics"
+// @Failure 401 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Unauthorized"
+// @Failure 403 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Admin access required"
+// @Failure 500 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Failed to get statistics"
+// @Router /api/v1/admin/stats [get]
+func (h *Handler) GetStats(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	stats, err := h.userService.GetUserStatistics(ctx)
+	if err != nil {
+		_ = c.Error(apiErrors.InternalServerError(err))
+		return
+	}
+
+	activeSessions, err := h.authService.CountActiveSessions(ctx)
+	if err != nil {
+		_ = c.Error(apiErrors.InternalServerError(err))
+		return
+	}
+
+	response := UserStatsResponse{
+		TotalUsers:      stats.TotalUsers,
+		AdminUsers:      stats.AdminUsers,
+		NewUsersLast24h: stats.NewUsersLast24h,
+		NewUsersLast7d:  stats.NewUsersLast7d,
+		NewUsersLast30d: stats.NewUsersLast30d,
+		ActiveSessions:  activeSessions,
+	}
+
+	c.JSON(http.StatusOK, apiErrors.Success(response))
+}
+
+// versionedUserResponse renders a user in the shape of the resolved API version
+func versionedUserResponse(c *gin.Context, user *User) any {
+	if contextutil.IsAPIVersion(c, contextutil.APIVersionV2) {
+		return ToUserResponseV2(user)
+	}
+	return ToUserResponse(user)
+}
+
+// shapedUserResponse renders a user in full for the user themselves, admins and holders of
+// users:read, and in the public shape without email for everyone else
+func shapedUserResponse(c *gin.Context, user *User) any {
+	// WHY: GetUser currently refuses other users before this point; the shape is still chosen
+	// per caller so relaxing that check can never expose emails
+	if contextutil.CanAccessUser(c, user.ID, contextutil.AllowPermission(PermissionUsersRead)) {
+		return versionedUserResponse(c, user)
+	}
+	if contextutil.IsAPIVersion(c, contextutil.APIVersionV2) {
+		return ToPublicUserResponseV2(user)
+	}
+	return ToPublicUserResponse(user)
+}
+
+// ForceLogout godoc
+// @Summary Force logout a user (Admin only)
+// @Description Revoke every refresh token of a user. Access tokens already issued remain valid until they expire.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Success 200 {object} errors.Response{success=bool,data=RevokeSessionsResponse} "Number of sessions revoked"
+// @Failure 400 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Invalid user ID"
+// @Failure 401 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Unauthorized"
+// @Failure 403 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Admin access required"
+// @Failure 404 {object} errors.Response{success=bool,error=errors.ErrorInfo} "User not found"
+// @Failure 500 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Failed to revoke sessions"
+// @Failure 503 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Session storage not available"
+// @Router /api/v1/admin/users/{id}/force-logout [post]
+func (h *Handler) ForceLogout(c *gin.Context) {
+	adminID := contextutil.GetUserID(c)
+	if adminID == 0 {
+		_ = c.Error(apiErrors.Unauthorized(apiErrors.MsgUserNotAuthenticated))
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		_ = c.Error(apiErrors.BadRequest(apiErrors.MsgInvalidUserID))
+		return
+	}
+
+	ctx := c.Request.Context()
+	user, err := h.userService.GetUserByID(ctx, uint(id))
+	if err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			_ = c.Error(apiErrors.NotFound(apiErrors.MsgUserNotFound))
+			return
+		}
+		_ = c.Error(apiErrors.InternalServerError(err))
+		return
+	}
+
+	revoked, apiErr := h.revokeAllSessions(c, user.ID)
+	if apiErr != nil {
+		_ = c.Error(apiErr)
+		return
+	}
+
+	slog.InfoContext(ctx, "User sessions force-revoked by admin",
+		"admin_id", adminID,
+		"impersonator_id", contextutil.GetImpersonatorID(c),
+		"target_user_id", user.ID,
+		"revoked_sessions", revoked,
+	)
+
+	c.JSON(http.StatusOK, apiErrors.Success(RevokeSessionsResponse{RevokedSessions: revoked}))
+}
+
+// DeactivateUser godoc
+// @Summary Deactivate a user (Admin only)
+// @Description Disable an account without deleting it. The user can no longer sign in, refresh or read their profile (403 ACCOUNT_DISABLED) and every refresh token is revoked; access tokens already issued remain valid until they expire unless token version checks are enabled.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Success 200 {object} errors.Response{success=bool,data=UserResponse} "Deactivated user"
+// @Failure 400 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Invalid user ID or deactivating own account"
+// @Failure 401 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Unauthorized"
+// @Failure 403 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Admin access required"
+// @Failure 404 {object} errors.Response{success=bool,error=errors.ErrorInfo} "User not found"
+// @Failure 500 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Failed to deactivate user"
+// @Failure 503 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Session storage not available"
+// @Router /api/v1/admin/users/{id}/deactivate [post]
+// @Router /api/v1/admin/users/{id}/disable [post]
+func (h *Handler) DeactivateUser(c *gin.Context) {
+	h.setUserActive(c, false)
+}
+
+// ReactivateUser godoc
+// @Summary Reactivate a user (Admin only)
+// @Description Re-enable a deactivated account so the user can sign in again
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Success 200 {object} errors.Response{success=bool,data=UserResponse} "Reactivated user"
+// @Failure 400 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Invalid user ID"
+// @Failure 401 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Unauthorized"
+// @Failure 403 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Admin access required"
+// @Failure 404 {object} errors.Response{success=bool,error=errors.ErrorInfo} "User not found"
+// @Failure 500 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Failed to reactivate user"
+// @Router /api/v1/admin/users/{id}/reactivate [post]
+// @Router /api/v1/admin/users/{id}/enable [post]
+func (h *Handler) ReactivateUser(c *gin.Context) {
+	h.setUserActive(c, true)
+}
+
+// GetLockout godoc
+// @Summary Get a user's lockout state (Admin only)
+// @Description Failed login count inside the lockout window, when the lock expires and the recent failed login times
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Success 200 {object} errors.Response{success=bool,data=LockoutStatusResponse} "Lockout state"
+// @Failure 400 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Invalid user ID"
+// @Failure 401 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Unauthorized"
+// @Failure 403 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Admin access required"
+// @Failure 404 {object} errors.Response{success=bool,error=errors.ErrorInfo} "User not found"
+// @Failure 500 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Failed to get lockout state"
+// @Router /api/v1/admin/users/{id}/lockout [get]
+func (h *Handler) GetLockout(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		_ = c.Error(apiErrors.BadRequest(apiErrors.MsgInvalidUserID))
+		return
+	}
+
+	status, err := h.userService.GetLockoutStatus(c.Request.Context(), uint(id))
+	if err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			_ = c.Error(apiErrors.NotFound(apiErrors.MsgUserNotFound))
+			return
+		}
+		_ = c.Error(apiErrors.InternalServerError(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, apiErrors.Success(ToLockoutStatusResponse(status)))
+}
+
+// ClearLockout godoc
+// @Summary Clear a user's lockout (Admin only)
+// @Description Reset the failed login count and lift the lock so the user can sign in again. Clearing an account that is not locked succeeds without changes.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Success 200 {object} errors.Response{success=bool,data=LockoutStatusResponse} "Lockout state after clearing"
+// @Failure 400 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Invalid user ID"
+// @Failure 401 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Unauthorized"
+// @Failure 403 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Admin access required"
+// @Failure 404 {object} errors.Response{success=bool,error=errors.ErrorInfo} "User not found"
+// @Failure 500 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Failed to clear lockout"
+// @Router /api/v1/admin/users/{id}/lockout [delete]
+func (h *Handler) ClearLockout(c *gin.Context) {
+	adminID := contextutil.GetUserID(c)
+	if adminID == 0 {
+		_ = c.Error(apiErrors.Unauthorized(apiErrors.MsgUserNotAuthenticated))
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		_ = c.Error(apiErrors.BadRequest(apiErrors.MsgInvalidUserID))
+		return
+	}
+
+	ctx := c.Request.Context()
+	previous, err := h.userService.ClearLockout(ctx, uint(id))
+	if err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			_ = c.Error(apiErrors.NotFound(apiErrors.MsgUserNotFound))
+			return
+		}
+		_ = c.Error(apiErrors.InternalServerError(err))
+		return
+	}
+
+	slog.InfoContext(ctx, "User lockout cleared by admin",
+		"admin_id", adminID,
+		"impersonator_id", contextutil.GetImpersonatorID(c),
+		"target_user_id", uint(id),
+		"was_locked", previous.Locked,
+		"cleared_attempts", previous.FailedAttempts,
+	)
+
+	c.JSON(http.StatusOK, apiErrors.Success(ToLockoutStatusResponse(&LockoutStatus{
+		MaxAttempts:    previous.MaxAttempts,
+		RecentFailures: []time.Time{},
+	})))
+}
+
+// recordLoginFailure counts a failed login for the client IP and logs a warning once failures repeat
+func (h *Handler) recordLoginFailure(c *gin.Context, cause error) {
+	ip := contextutil.ClientIP(c)
+	failures := h.loginFailures.record(ip)
+	if failures < repeatedLoginFailureThreshold {
+		return
+	}
+	slog.WarnContext(c.Request.Context(), "Security event: repeated failed logins from client",
+		"event", "login_bruteforce",
+		"client_ip", ip,
+		"failures", failures,
+		"window", loginFailureWindow.String(),
+		"error", cause.Error(),
+	)
+}
+
+// requestClientID returns the client named in the request body, falling back to the X-Client-Id header
+func requestClientID(c *gin.Context, bodyClientID string) string {
+	if bodyClientID != "" {
+		return bodyClientID
+	}
+	return strings.TrimSpace(c.GetHeader(auth.ClientIDHeader))
+}
+
+// checkClientGrant rejects the request with 403 when a registered client may not use grant
+func (h *Handler) checkClientGrant(c *gin.Context, clientID, grant string) bool {
+	if clientID == "" {
+		return true
+	}
+	if err := h.authService.CheckClientGrant(clientID, grant); err != nil {
+		_ = c.Error(apiErrors.Forbidden("This client is not allowed to use this sign-in method"))
+		return false
+	}
+	return true
+}
+
+// clientContext attaches the requesting client to the request context so issued refresh tokens record it
+func clientContext(c *gin.Context) context.Context {
+	return auth.WithClientInfo(c.Request.Context(), auth.ClientInfo{
+		IP:        contextutil.ClientIP(c),
+		UserAgent: c.Request.UserAgent(),
+	})
+}
+
+// retryAfterSeconds rounds a wait up to whole seconds for Retry-After, never below one
+func retryAfterSeconds(d time.Duration) int {
+	seconds := int((d + time.Second - 1) / time.Second)
+	if seconds < 1 {
+		return 1
+	}
+	return seconds
+}
+
+// setUserActive applies an admin's deactivate or reactivate request; deactivation also signs the user out everywhere
+func (h *Handler) setUserActive(c *gin.Context, active bool) {
+	adminID := contextutil.GetUserID(c)
+	if adminID == 0 {
+		_ = c.Error(apiErrors.Unauthorized(apiErrors.MsgUserNotAuthenticated))
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		_ = c.Error(apiErrors.BadRequest(apiErrors.MsgInvalidUserID))
+		return
+	}
+	// WHY: An admin locking themselves out could leave the system without any administrator
+	if !active && uint(id) == adminID {
+		_ = c.Error(apiErrors.BadRequest("You cannot deactivate your own account"))
+		return
+	}
+
+	ctx := c.Request.Context()
+	user, err := h.userService.SetUserActive(ctx, uint(id), active)
+	if err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			_ = c.Error(apiErrors.NotFound(apiErrors.MsgUserNotFound))
+			return
+		}
+		_ = c.Error(apiErrors.InternalServerError(err))
+		return
+	}
+
+	var revoked int64
+	if !active {
+		var apiErr *apiErrors.APIError
+		revoked, apiErr = h.revokeAllSessions(c, user.ID)
+		if apiErr != nil {
+			_ = c.Error(apiErr)
+			return
+		}
+	}
+
+	slog.InfoContext(ctx, "User account status changed by admin",
+		"admin_id", adminID,
+		"impersonator_id", contextutil.GetImpersonatorID(c),
+		"target_user_id", user.ID,
+		"active", active,
+		"revoked_sessions", revoked,
+	)
+
+	c.JSON(http.StatusOK, apiErrors.Success(ToUserResponse(user)))
+}
+
+// revokeAllSessions revokes every refresh token of userID and maps failures to API errors
+func (h *Handler) revokeAllSessions(c *gin.Context, userID uint) (int64, *apiErrors.APIError) {
+	revoked, err := h.authService.RevokeAllUserTokens(c.Request.Context(), userID)
+	if err != nil {
+		if errors.Is(err, auth.ErrRefreshStoreUnavailable) {
+			return 0, apiErrors.ServiceUnavailable("Session management is not available")
+		}
+		return 0, apiErrors.InternalServerError(err)
+	}
+	return revoked, nil
+}
+
+// Impersonate godoc
+// @Summary Impersonate a user (Admin only)
+// @Description Issue a short-lived access token that acts as the target user. The token carries the admin's ID in the impersonator_id and impersonated_by claims, requests made with it get an X-Impersonating header, and no refresh token is issued. Impersonating another admin requires jwt.allow_admin_impersonation.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Param request body ImpersonateRequest true "Impersonation reason (recorded for auditing)"
+// @Success 200 {object} errors.Response{success=bool,data=ImpersonationResponse} "Impersonation access token"
+// @Failure 400 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Invalid user ID, validation error or self impersonation"
+// @Failure 401 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Unauthorized"
+// @Failure 403 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Admin access required or target is an admin"
+// @Failure 404 {object} errors.Response{success=bool,error=errors.ErrorInfo} "User not found"
+// @Failure 500 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Failed to impersonate user"
+// @Router /api/v1/admin/users/{id}/impersonate [post]
+func (h *Handler) Impersonate(c *gin.Context) {
+	adminID := contextutil.GetUserID(c)
+	if adminID == 0 {
+		_ = c.Error(apiErrors.Unauthorized(apiErrors.MsgUserNotAuthenticated))
+		return
+	}
+	// WHY: Chained impersonation would hide the real admin behind another identity
+	if contextutil.IsImpersonating(c) {
+		_ = c.Error(apiErrors.Forbidden("Cannot impersonate from an impersonation session"))
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		_ = c.Error(apiErrors.BadRequest(apiErrors.MsgInvalidUserID))
+		return
+	}
+
+	var req ImpersonateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(apiErrors.FromGinValidation(err))
+		return
+	}
+
+	ctx := c.Request.Context()
+	user, err := h.userService.GetUserByID(ctx, uint(id))
+	if err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			_ = c.Error(apiErrors.NotFound(apiErrors.MsgUserNotFound))
+			return
+		}
+		_ = c.Error(apiErrors.InternalServerError(err))
+		return
+	}
+
+	token, err := h.authService.GenerateImpersonationToken(ctx, adminID, user.ID, user.Email, user.Name, req.Reason)
+	if err != nil {
+		if errors.Is(err, auth.ErrImpersonateSelf) {
+			_ = c.Error(apiErrors.BadRequest("Cannot impersonate yourself"))
+			return
+		}
+		if errors.Is(err, auth.ErrImpersonateAdmin) {
+			_ = c.Error(apiErrors.Forbidden("Impersonating an admin is not allowed"))
+			return
+		}
+		_ = c.Error(apiErrors.InternalServerError(err))
+		return
+	}
+
+	slog.InfoContext(ctx, "Impersonation started",
+		"impersonator_id", adminID,
+		"target_user_id", user.ID,
+		"grant_id", token.GrantID.String(),
+		"expires_at", token.ExpiresAt,
+		"reason", req.Reason,
+	)
+
+	c.JSON(http.StatusOK, apiErrors.Success(ImpersonationResponse{
+		AccessToken:    token.AccessToken,
+		TokenType:      token.TokenType,
+		ExpiresIn:      token.ExpiresIn,
+		ExpiresAt:      token.ExpiresAt.UTC().Format(time.RFC3339),
+		GrantID:        token.GrantID.String(),
+		ImpersonatorID: adminID,
+		User:           ToUserResponse(user),
+	}))
+}
+
+// ListImpersonations godoc
+// @Summary List active impersonation grants (Admin only)
+// @Description List impersonation sessions whose tokens have not expired yet. Sessions end on token expiry.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} errors.Response{success=bool,data=[]ImpersonationGrantResponse} "Active impersonation grants"
+// @Failure 401 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Unauthorized"
+// @Failure 403 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Admin access required"
+// @Failure 500 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Failed to list impersonation grants"
+// @Router /api/v1/admin/impersonations [get]
+func (h *Handler) ListImpersonations(c *gin.Context) {
+	grants, err := h.authService.ListActiveImpersonations(c.Request.Context())
+	if err != nil {
+		_ = c.Error(apiErrors.InternalServerError(err))
+		return
+	}
+
+	responses := make([]ImpersonationGrantResponse, len(grants))
+	for i := range grants {
+		responses[i] = ToImpersonationGrantResponse(&grants[i])
+	}
+
+	c.JSON(http.StatusOK, apiErrors.Success(responses))
+}
+
+// ListSessions godoc
+// @Summary List login sessions (Admin only)
+// @Description List refresh token families across users, newest login first. Each family is one login session with its rotation history summarized.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param user_id query int false "Only sessions of this user"
+// @Param active query bool false "Only sessions that still hold a usable refresh token"
+// @Param page query int false "Page number" default(1)
+// @Param per_page query int false "Items per page" default(20)
+// @Success 200 {object} errors.Response{success=bool,data=SessionListResponse} "Login sessions"
+// @Failure 400 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Invalid filter"
+// @Failure 401 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Unauthorized"
+// @Failure 403 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Admin access required"
+// @Failure 500 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Failed to list sessions"
+// @Failure 503 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Session storage not available"
+// @Router /api/v1/admin/sessions [get]
+func (h *Handler) ListSessions(c *gin.Context) {
+	pagination := middleware.ParsePaginationParams(c)
+	filter := auth.SessionFilter{
+		// Fetch one extra family to detect the next page
+		Limit:  pagination.PerPage + 1,
+		Offset: (pagination.Page - 1) * pagination.PerPage,
+	}
+	if raw := c.Query("user_id"); raw != "" {
+		userID, err := strconv.ParseUint(raw, 10, 32)
+		if err != nil || userID == 0 {
+			_ = c.Error(apiErrors.BadRequest("Invalid user_id filter"))
+			return
+		}
+		filter.UserID = uint(userID)
+	}
+	if raw := c.Query("active"); raw != "" {
+		active, err := strconv.ParseBool(raw)
+		if err != nil {
+			_ = c.Error(apiErrors.BadRequest("Invalid active filter, expected true or false"))
+			return
+		}
+		filter.ActiveOnly = active
+	}
+
+	sessions, err := h.authService.ListSessions(c.Request.Context(), filter)
+	if err != nil {
+		if errors.Is(err, auth.ErrRefreshStoreUnavailable) {
+			_ = c.Error(apiErrors.ServiceUnavailable("Session management is not available"))
+			return
+		}
+		_ = c.Error(apiErrors.InternalServerError(err))
+		return
+	}
+
+	response := SessionListResponse{
+		Page:    pagination.Page,
+		PerPage: pagination.PerPage,
+		HasNext: len(sessions) > pagination.PerPage,
+	}
+	if response.HasNext {
+		sessions = sessions[:pagination.PerPage]
+	}
+	response.Sessions = make([]SessionResponse, len(sessions))
+	for i := range sessions {
+		response.Sessions[i] = ToSessionResponse(&sessions[i])
+	}
+
+	c.JSON(http.StatusOK, apiErrors.Success(response))
+}
+
+// RevokeSession godoc
+// @Summary Revoke a login session (Admin only)
+// @Description Revoke every refresh token of a token family, signing out that one session. Access tokens already issued remain valid until they expire.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param family path string true "Token family ID"
+// @Success 204 "Session revoked"
+// @Failure 400 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Invalid token family"
+// @Failure 401 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Unauthorized"
+// @Failure 403 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Admin access required"
+// @Failure 404 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Session not found"
+// @Failure 500 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Failed to revoke session"
+// @Failure 503 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Session storage not available"
+// @Router /api/v1/admin/sessions/{family} [delete]
+func (h *Handler) RevokeSession(c *gin.Context) {
+	adminID := contextutil.GetUserID(c)
+	if adminID == 0 {
+		_ = c.Error(apiErrors.Unauthorized(apiErrors.MsgUserNotAuthenticated))
+		return
+	}
+
+	family, err := uuid.Parse(c.Param("family"))
+	if err != nil {
+		_ = c.Error(apiErrors.BadRequest("Invalid token family"))
+		return
+	}
+
+	ctx := c.Request.Context()
+	if err := h.authService.RevokeSession(ctx, family); err != nil {
+		switch {
+		case errors.Is(err, auth.ErrSessionNotFound):
+			_ = c.Error(apiErrors.NotFound("Session not found"))
+		case errors.Is(err, auth.ErrRefreshStoreUnavailable):
+			_ = c.Error(apiErrors.ServiceUnavailable("Session management is not available"))
+		default:
+			_ = c.Error(apiErrors.InternalServerError(err))
+		}
+		return
+	}
+
+	slog.InfoContext(ctx, "Session revoked by admin",
+		"admin_id", adminID,
+		"impersonator_id", contextutil.GetImpersonatorID(c),
+		"token_family", family.String(),
+	)
+
+	c.Status(http.StatusNoContent)
+}
+
+// readRefreshToken returns the refresh token from the JSON body, falling back to the
+// refresh cookie in cookie mode. Cookie-sourced tokens require a matching CSRF header.
+func (h *Handler) readRefreshToken(c *gin.Context) (string, bool, *apiErrors.APIError) {
+	if !h.refreshCookie.Enabled() {
+		var req auth.RefreshTokenRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			return "", false, apiErrors.FromGinValidation(err)
+		}
+		return req.RefreshToken, false, nil
+	}
+
+	var req struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		return "", false, apiErrors.FromGinValidation(err)
+	}
+	if req.RefreshToken != "" {
+		return req.RefreshToken, false, nil
+	}
+
+	token, ok := h.refreshCookie.Token(c)
+	if !ok {
+		return "", false, apiErrors.BadRequest("Refresh token is required")
+	}
+	if !h.refreshCookie.VerifyCSRF(c) {
+		return "", false, apiErrors.Forbidden("Invalid CSRF token")
+	}
+	return token, true, nil
+}
+
+// deliverTokens sets the refresh cookie, and the access cookie when enabled, if useCookie is
+// true and returns the refresh token for the JSON body, which is empty for cookie clients.
+// The access token stays in the JSON body so existing clients keep working.
+func (h *Handler) deliverTokens(c *gin.Context, tokenPair *auth.TokenPair, useCookie bool) (string, error) {
+	if !useCookie {
+		return tokenPair.RefreshToken, nil
+	}
+	if err := h.refreshCookie.Set(c, tokenPair.RefreshToken); err != nil {
+		return "", err
+	}
+	if h.accessCookie.Enabled() {
+		h.accessCookie.Set(c, tokenPair.AccessToken)
+	}
+	return "", nil
+}
+
+// clearCookies removes the refresh cookie and, when enabled, the access cookie
+func (h *Handler) clearCookies(c *gin.Context) {
+	h.refreshCookie.Clear(c)
+	if h.accessCookie.Enabled() {
+		h.accessCookie.Clear(c)
+	}
 }
@@ -2,25 +2,34 @@ package user
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 
 	"github.com/yeegeek/uyou-go-api-starter/internal/auth"
+	"github.com/yeegeek/uyou-go-api-starter/internal/config"
 	apiErrors "github.com/yeegeek/uyou-go-api-starter/internal/errors"
+	"github.com/yeegeek/uyou-go-api-starter/internal/openapitest"
+	"github.com/yeegeek/uyou-go-api-starter/internal/testutil"
 )
 
 func TestHandler_RefreshToken(t *testing.T) {
 	tests := []struct {
 		name           string
 		requestBody    interface{}
-		setupMocks     func(*MockAuthService)
+		setupMocks     func(*testutil.MockAuthService)
 		expectedStatus int
 		checkResponse  func(*testing.T, *httptest.ResponseRecorder)
 	}{
@@ -29,7 +38,7 @@ func TestHandler_RefreshToken(t *testing.T) {
 			requestBody: auth.RefreshTokenRequest{
 				RefreshToken: "valid-refresh-token",
 			},
-			setupMocks: func(mas *MockAuthService) {
+			setupMocks: func(mas *testutil.MockAuthService) {
 				tokenPair := &auth.TokenPair{
 					AccessToken:  "new-access-token",
 					RefreshToken: "new-refresh-token",
@@ -55,7 +64,7 @@ func TestHandler_RefreshToken(t *testing.T) {
 		{
 			name:           "missing refresh token",
 			requestBody:    map[string]string{},
-			setupMocks:     func(mas *MockAuthService) {},
+			setupMocks:     func(mas *testutil.MockAuthService) {},
 			expectedStatus: http.StatusBadRequest,
 			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
 				var response map[string]interface{}
@@ -72,7 +81,7 @@ func TestHandler_RefreshToken(t *testing.T) {
 			requestBody: auth.RefreshTokenRequest{
 				RefreshToken: "invalid-token",
 			},
-			setupMocks: func(mas *MockAuthService) {
+			setupMocks: func(mas *testutil.MockAuthService) {
 				mas.On("RefreshAccessToken", mock.Anything, "invalid-token").Return(nil, auth.ErrInvalidToken)
 			},
 			expectedStatus: http.StatusUnauthorized,
@@ -92,7 +101,7 @@ func TestHandler_RefreshToken(t *testing.T) {
 			requestBody: auth.RefreshTokenRequest{
 				RefreshToken: "expired-token",
 			},
-			setupMocks: func(mas *MockAuthService) {
+			setupMocks: func(mas *testutil.MockAuthService) {
 				mas.On("RefreshAccessToken", mock.Anything, "expired-token").Return(nil, auth.ErrExpiredToken)
 			},
 			expectedStatus: http.StatusUnauthorized,
@@ -107,12 +116,30 @@ func TestHandler_RefreshToken(t *testing.T) {
 				assert.Contains(t, errorInfo["message"], "Invalid or expired")
 			},
 		},
+		{
+			name: "account disabled",
+			requestBody: auth.RefreshTokenRequest{
+				RefreshToken: "disabled-user-token",
+			},
+			setupMocks: func(mas *testutil.MockAuthService) {
+				mas.On("RefreshAccessToken", mock.Anything, "disabled-user-token").Return(nil, auth.ErrAccountDisabled)
+			},
+			expectedStatus: http.StatusForbidden,
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				var response map[string]interface{}
+				err := json.Unmarshal(w.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				errorInfo, ok := response["error"].(map[string]interface{})
+				assert.True(t, ok, "error should be a map")
+				assert.Equal(t, apiErrors.CodeAccountDisabled, errorInfo["code"])
+			},
+		},
 		{
 			name: "token reuse detected",
 			requestBody: auth.RefreshTokenRequest{
 				RefreshToken: "reused-token",
 			},
-			setupMocks: func(mas *MockAuthService) {
+			setupMocks: func(mas *testutil.MockAuthService) {
 				mas.On("RefreshAccessToken", mock.Anything, "reused-token").Return(nil, auth.ErrTokenReuse)
 			},
 			expectedStatus: http.StatusForbidden,
@@ -127,12 +154,31 @@ func TestHandler_RefreshToken(t *testing.T) {
 				assert.Contains(t, errorInfo["message"], "Token reuse detected")
 			},
 		},
+		{
+			name: "session used from an unrecognized client",
+			requestBody: auth.RefreshTokenRequest{
+				RefreshToken: "stolen-token",
+			},
+			setupMocks: func(mas *testutil.MockAuthService) {
+				mas.On("RefreshAccessToken", mock.Anything, "stolen-token").Return(nil, auth.ErrSessionAnomaly)
+			},
+			expectedStatus: http.StatusForbidden,
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				var response map[string]interface{}
+				err := json.Unmarshal(w.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				errorInfo, ok := response["error"].(map[string]interface{})
+				assert.True(t, ok, "error should be a map")
+				assert.Equal(t, "FORBIDDEN", errorInfo["code"])
+				assert.Contains(t, errorInfo["message"], "log in again")
+			},
+		},
 		{
 			name: "revoked token",
 			requestBody: auth.RefreshTokenRequest{
 				RefreshToken: "revoked-token",
 			},
-			setupMocks: func(mas *MockAuthService) {
+			setupMocks: func(mas *testutil.MockAuthService) {
 				mas.On("RefreshAccessToken", mock.Anything, "revoked-token").Return(nil, auth.ErrTokenRevoked)
 			},
 			expectedStatus: http.StatusUnauthorized,
@@ -152,7 +198,7 @@ func TestHandler_RefreshToken(t *testing.T) {
 			requestBody: auth.RefreshTokenRequest{
 				RefreshToken: "some-token",
 			},
-			setupMocks: func(mas *MockAuthService) {
+			setupMocks: func(mas *testutil.MockAuthService) {
 				mas.On("RefreshAccessToken", mock.Anything, "some-token").Return(nil, errors.New("database error"))
 			},
 			expectedStatus: http.StatusInternalServerError,
@@ -166,6 +212,28 @@ func TestHandler_RefreshToken(t *testing.T) {
 				assert.Equal(t, "INTERNAL_ERROR", errorInfo["code"])
 			},
 		},
+		{
+			name: "token store failure mid-rotation is retryable",
+			requestBody: auth.RefreshTokenRequest{
+				RefreshToken: "valid-refresh-token",
+			},
+			setupMocks: func(mas *testutil.MockAuthService) {
+				err := fmt.Errorf("%w: %w", auth.ErrRotationFailed, errors.New("connection reset"))
+				mas.On("RefreshAccessToken", mock.Anything, "valid-refresh-token").Return(nil, err)
+			},
+			expectedStatus: http.StatusServiceUnavailable,
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, "1", w.Header().Get("Retry-After"))
+				var response map[string]interface{}
+				err := json.Unmarshal(w.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				errorInfo, ok := response["error"].(map[string]interface{})
+				assert.True(t, ok, "error should be a map")
+				assert.Equal(t, "SERVICE_UNAVAILABLE", errorInfo["code"])
+				assert.Equal(t, float64(1), errorInfo["retry_after"])
+				assert.NotContains(t, w.Body.String(), "connection reset")
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -174,7 +242,7 @@ func TestHandler_RefreshToken(t *testing.T) {
 			w := httptest.NewRecorder()
 			c, _ := gin.CreateTestContext(w)
 
-			mockAuthService := new(MockAuthService)
+			mockAuthService := new(testutil.MockAuthService)
 			tt.setupMocks(mockAuthService)
 
 			handler := &Handler{
@@ -191,6 +259,7 @@ func TestHandler_RefreshToken(t *testing.T) {
 
 			assert.Equal(t, tt.expectedStatus, w.Code)
 			tt.checkResponse(t, w)
+			openapitest.AssertResponse(t, http.MethodPost, "/api/v1/auth/refresh", w)
 
 			mockAuthService.AssertExpectations(t)
 		})
@@ -201,7 +270,7 @@ func TestHandler_Logout(t *testing.T) {
 	tests := []struct {
 		name           string
 		requestBody    interface{}
-		setupMocks     func(*MockAuthService)
+		setupMocks     func(*testutil.MockAuthService)
 		setupContext   func(*gin.Context)
 		expectedStatus int
 		checkResponse  func(*testing.T, *httptest.ResponseRecorder)
@@ -211,7 +280,7 @@ func TestHandler_Logout(t *testing.T) {
 			requestBody: auth.RefreshTokenRequest{
 				RefreshToken: "valid-refresh-token",
 			},
-			setupMocks: func(mas *MockAuthService) {
+			setupMocks: func(mas *testutil.MockAuthService) {
 				mas.On("RevokeUserRefreshToken", mock.Anything, uint(1), "valid-refresh-token").Return(nil)
 			},
 			setupContext: func(c *gin.Context) {
@@ -232,7 +301,7 @@ func TestHandler_Logout(t *testing.T) {
 		{
 			name:        "missing refresh token",
 			requestBody: map[string]string{},
-			setupMocks:  func(mas *MockAuthService) {},
+			setupMocks:  func(mas *testutil.MockAuthService) {},
 			setupContext: func(c *gin.Context) {
 				claims := &auth.Claims{UserID: 1}
 				c.Set(auth.KeyUser, claims)
@@ -253,7 +322,7 @@ func TestHandler_Logout(t *testing.T) {
 			requestBody: auth.RefreshTokenRequest{
 				RefreshToken: "some-token",
 			},
-			setupMocks: func(mas *MockAuthService) {
+			setupMocks: func(mas *testutil.MockAuthService) {
 				mas.On("RevokeUserRefreshToken", mock.Anything, uint(1), "some-token").Return(errors.New("database error"))
 			},
 			setupContext: func(c *gin.Context) {
@@ -276,7 +345,7 @@ func TestHandler_Logout(t *testing.T) {
 			requestBody: auth.RefreshTokenRequest{
 				RefreshToken: "non-existent-token",
 			},
-			setupMocks: func(mas *MockAuthService) {
+			setupMocks: func(mas *testutil.MockAuthService) {
 				mas.On("RevokeUserRefreshToken", mock.Anything, uint(1), "non-existent-token").Return(nil)
 			},
 			setupContext: func(c *gin.Context) {
@@ -299,7 +368,7 @@ func TestHandler_Logout(t *testing.T) {
 			requestBody: auth.RefreshTokenRequest{
 				RefreshToken: "some-token",
 			},
-			setupMocks: func(mas *MockAuthService) {},
+			setupMocks: func(mas *testutil.MockAuthService) {},
 			setupContext: func(c *gin.Context) {
 			},
 			expectedStatus: http.StatusUnauthorized,
@@ -321,7 +390,7 @@ func TestHandler_Logout(t *testing.T) {
 			w := httptest.NewRecorder()
 			c, _ := gin.CreateTestContext(w)
 
-			mockAuthService := new(MockAuthService)
+			mockAuthService := new(testutil.MockAuthService)
 			tt.setupMocks(mockAuthService)
 
 			handler := &Handler{
@@ -345,3 +414,819 @@ func TestHandler_Logout(t *testing.T) {
 		})
 	}
 }
+
+func TestHandler_RefreshToken_CookieMode(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	refreshCookie := auth.NewRefreshCookie(&config.JWTConfig{RefreshCookie: config.RefreshCookieConfig{Enabled: true}})
+	newPair := &auth.TokenPair{AccessToken: "new-access", RefreshToken: "new-refresh", TokenType: "Bearer", ExpiresIn: 900}
+
+	tests := []struct {
+		name           string
+		body           string
+		cookies        []*http.Cookie
+		csrfHeader     string
+		setupMocks     func(*testutil.MockAuthService)
+		expectedStatus int
+		checkResponse  func(*testing.T, *httptest.ResponseRecorder)
+	}{
+		{
+			name:       "cookie token with matching csrf header",
+			cookies:    []*http.Cookie{{Name: "refresh_token", Value: "old-refresh"}, {Name: "csrf_token", Value: "csrf"}},
+			csrfHeader: "csrf",
+			setupMocks: func(mas *testutil.MockAuthService) {
+				mas.On("RefreshAccessToken", mock.Anything, "old-refresh").Return(newPair, nil)
+			},
+			expectedStatus: http.StatusOK,
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				var response map[string]interface{}
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				data := response["data"].(map[string]interface{})
+				assert.Equal(t, "new-access", data["access_token"])
+				assert.NotContains(t, data, "refresh_token")
+
+				cookies := map[string]*http.Cookie{}
+				for _, ck := range w.Result().Cookies() {
+					cookies[ck.Name] = ck
+				}
+				if assert.Contains(t, cookies, "refresh_token") {
+					assert.Equal(t, "new-refresh", cookies["refresh_token"].Value)
+					assert.True(t, cookies["refresh_token"].HttpOnly)
+				}
+				if assert.Contains(t, cookies, "csrf_token") {
+					assert.NotEqual(t, "csrf", cookies["csrf_token"].Value, "csrf token should rotate")
+				}
+			},
+		},
+		{
+			name:           "cookie token without csrf header",
+			cookies:        []*http.Cookie{{Name: "refresh_token", Value: "old-refresh"}, {Name: "csrf_token", Value: "csrf"}},
+			setupMocks:     func(mas *testutil.MockAuthService) {},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "cookie token with mismatched csrf header",
+			cookies:        []*http.Cookie{{Name: "refresh_token", Value: "old-refresh"}, {Name: "csrf_token", Value: "csrf"}},
+			csrfHeader:     "other",
+			setupMocks:     func(mas *testutil.MockAuthService) {},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:    "body token takes precedence and stays in body",
+			body:    `{"refresh_token":"body-refresh"}`,
+			cookies: []*http.Cookie{{Name: "refresh_token", Value: "old-refresh"}},
+			setupMocks: func(mas *testutil.MockAuthService) {
+				mas.On("RefreshAccessToken", mock.Anything, "body-refresh").Return(newPair, nil)
+			},
+			expectedStatus: http.StatusOK,
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				var response map[string]interface{}
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				data := response["data"].(map[string]interface{})
+				assert.Equal(t, "new-refresh", data["refresh_token"])
+				assert.Empty(t, w.Result().Cookies())
+			},
+		},
+		{
+			name:           "no body and no cookie",
+			setupMocks:     func(mas *testutil.MockAuthService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "invalid cookie token clears cookies",
+			cookies:    []*http.Cookie{{Name: "refresh_token", Value: "old-refresh"}, {Name: "csrf_token", Value: "csrf"}},
+			csrfHeader: "csrf",
+			setupMocks: func(mas *testutil.MockAuthService) {
+				mas.On("RefreshAccessToken", mock.Anything, "old-refresh").Return(nil, auth.ErrInvalidToken)
+			},
+			expectedStatus: http.StatusUnauthorized,
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				for _, ck := range w.Result().Cookies() {
+					assert.Less(t, ck.MaxAge, 0, "cookie %s should be expired", ck.Name)
+				}
+				assert.Len(t, w.Result().Cookies(), 2)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockAuthService := new(testutil.MockAuthService)
+			tt.setupMocks(mockAuthService)
+			handler := NewHandler(new(MockService), mockAuthService, WithRefreshCookie(refreshCookie))
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPost, "/api/v1/auth/refresh", bytes.NewBufferString(tt.body))
+			c.Request.Header.Set("Content-Type", "application/json")
+			for _, ck := range tt.cookies {
+				c.Request.AddCookie(ck)
+			}
+			if tt.csrfHeader != "" {
+				c.Request.Header.Set(auth.CSRFTokenHeader, tt.csrfHeader)
+			}
+
+			handler.RefreshToken(c)
+			apiErrors.ErrorHandler()(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.checkResponse != nil {
+				tt.checkResponse(t, w)
+			}
+			mockAuthService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandler_LogoutAll(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name            string
+		claims          *auth.Claims
+		setupMocks      func(*testutil.MockAuthService)
+		expectedStatus  int
+		expectedRevoked float64
+	}{
+		{
+			name:   "revokes all sessions",
+			claims: &auth.Claims{UserID: 1},
+			setupMocks: func(mas *testutil.MockAuthService) {
+				mas.On("RevokeAllUserTokens", mock.Anything, uint(1)).Return(int64(3), nil)
+			},
+			expectedStatus:  http.StatusOK,
+			expectedRevoked: 3,
+		},
+		{
+			name:           "not authenticated",
+			setupMocks:     func(mas *testutil.MockAuthService) {},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "impersonation session",
+			claims:         &auth.Claims{UserID: 1, ImpersonatorID: 9},
+			setupMocks:     func(mas *testutil.MockAuthService) {},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:   "refresh token storage not configured",
+			claims: &auth.Claims{UserID: 1},
+			setupMocks: func(mas *testutil.MockAuthService) {
+				mas.On("RevokeAllUserTokens", mock.Anything, uint(1)).Return(int64(0), auth.ErrRefreshStoreUnavailable)
+			},
+			expectedStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name:   "internal server error",
+			claims: &auth.Claims{UserID: 1},
+			setupMocks: func(mas *testutil.MockAuthService) {
+				mas.On("RevokeAllUserTokens", mock.Anything, uint(1)).Return(int64(0), errors.New("database error"))
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockAuthService := new(testutil.MockAuthService)
+			tt.setupMocks(mockAuthService)
+			handler := NewHandler(new(MockService), mockAuthService)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPost, "/api/v1/auth/logout-all", nil)
+			if tt.claims != nil {
+				c.Set(auth.KeyUser, tt.claims)
+			}
+
+			handler.LogoutAll(c)
+			apiErrors.ErrorHandler()(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusOK {
+				var response map[string]interface{}
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				data := response["data"].(map[string]interface{})
+				assert.Equal(t, tt.expectedRevoked, data["revoked_sessions"])
+			}
+			mockAuthService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandler_ForceLogout(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name            string
+		userID          string
+		setupMocks      func(*MockService, *testutil.MockAuthService)
+		expectedStatus  int
+		expectedRevoked float64
+	}{
+		{
+			name:   "revokes target user sessions",
+			userID: "2",
+			setupMocks: func(ms *MockService, mas *testutil.MockAuthService) {
+				ms.On("GetUserByID", mock.Anything, uint(2)).Return(&User{ID: 2, Email: "jane@example.com"}, nil)
+				mas.On("RevokeAllUserTokens", mock.Anything, uint(2)).Return(int64(2), nil)
+			},
+			expectedStatus:  http.StatusOK,
+			expectedRevoked: 2,
+		},
+		{
+			name:           "invalid user ID",
+			userID:         "abc",
+			setupMocks:     func(ms *MockService, mas *testutil.MockAuthService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "user not found",
+			userID: "404",
+			setupMocks: func(ms *MockService, mas *testutil.MockAuthService) {
+				ms.On("GetUserByID", mock.Anything, uint(404)).Return(nil, ErrUserNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:   "refresh token storage not configured",
+			userID: "2",
+			setupMocks: func(ms *MockService, mas *testutil.MockAuthService) {
+				ms.On("GetUserByID", mock.Anything, uint(2)).Return(&User{ID: 2}, nil)
+				mas.On("RevokeAllUserTokens", mock.Anything, uint(2)).Return(int64(0), auth.ErrRefreshStoreUnavailable)
+			},
+			expectedStatus: http.StatusServiceUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockService)
+			mockAuthService := new(testutil.MockAuthService)
+			tt.setupMocks(mockService, mockAuthService)
+			handler := NewHandler(mockService, mockAuthService)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPost, "/api/v1/admin/users/"+tt.userID+"/force-logout", nil)
+			c.Params = gin.Params{{Key: "id", Value: tt.userID}}
+			c.Set(auth.KeyUser, &auth.Claims{UserID: 1, Roles: []string{RoleAdmin}})
+
+			handler.ForceLogout(c)
+			apiErrors.ErrorHandler()(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusOK {
+				var response map[string]interface{}
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				data := response["data"].(map[string]interface{})
+				assert.Equal(t, tt.expectedRevoked, data["revoked_sessions"])
+			}
+			mockService.AssertExpectations(t)
+			mockAuthService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandler_SetUserActive(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		userID         string
+		activate       bool
+		setupMocks     func(*MockService, *testutil.MockAuthService)
+		expectedStatus int
+		expectedActive bool
+	}{
+		{
+			name:   "deactivate revokes sessions",
+			userID: "2",
+			setupMocks: func(ms *MockService, mas *testutil.MockAuthService) {
+				ms.On("SetUserActive", mock.Anything, uint(2), false).Return(&User{ID: 2, Email: "jane@example.com"}, nil)
+				mas.On("RevokeAllUserTokens", mock.Anything, uint(2)).Return(int64(3), nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:     "reactivate does not touch sessions",
+			userID:   "2",
+			activate: true,
+			setupMocks: func(ms *MockService, mas *testutil.MockAuthService) {
+				ms.On("SetUserActive", mock.Anything, uint(2), true).Return(&User{ID: 2, Email: "jane@example.com", Active: true}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedActive: true,
+		},
+		{
+			name:           "cannot deactivate own account",
+			userID:         "1",
+			setupMocks:     func(ms *MockService, mas *testutil.MockAuthService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid user ID",
+			userID:         "abc",
+			setupMocks:     func(ms *MockService, mas *testutil.MockAuthService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "user not found",
+			userID: "404",
+			setupMocks: func(ms *MockService, mas *testutil.MockAuthService) {
+				ms.On("SetUserActive", mock.Anything, uint(404), false).Return(nil, ErrUserNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:   "refresh token storage not configured",
+			userID: "2",
+			setupMocks: func(ms *MockService, mas *testutil.MockAuthService) {
+				ms.On("SetUserActive", mock.Anything, uint(2), false).Return(&User{ID: 2}, nil)
+				mas.On("RevokeAllUserTokens", mock.Anything, uint(2)).Return(int64(0), auth.ErrRefreshStoreUnavailable)
+			},
+			expectedStatus: http.StatusServiceUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockService)
+			mockAuthService := new(testutil.MockAuthService)
+			tt.setupMocks(mockService, mockAuthService)
+			handler := NewHandler(mockService, mockAuthService)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPost, "/api/v1/admin/users/"+tt.userID+"/deactivate", nil)
+			c.Params = gin.Params{{Key: "id", Value: tt.userID}}
+			c.Set(auth.KeyUser, &auth.Claims{UserID: 1, Roles: []string{RoleAdmin}})
+
+			if tt.activate {
+				handler.ReactivateUser(c)
+			} else {
+				handler.DeactivateUser(c)
+			}
+			apiErrors.ErrorHandler()(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusOK {
+				var response map[string]interface{}
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				data := response["data"].(map[string]interface{})
+				assert.Equal(t, tt.expectedActive, data["active"])
+			}
+			mockService.AssertExpectations(t)
+			mockAuthService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandler_Login_AccountDisabled(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockService)
+	mockService.On("AuthenticateUser", mock.Anything, mock.Anything).Return(nil, ErrAccountDisabled)
+	handler := NewHandler(mockService, new(testutil.MockAuthService))
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/api/v1/auth/login",
+		bytes.NewBufferString(`{"email":"jane@example.com","password":"Password123!"}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	handler.Login(c)
+	apiErrors.ErrorHandler()(c)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "Account is disabled")
+	assert.Contains(t, w.Body.String(), apiErrors.CodeAccountDisabled)
+}
+
+func TestHandler_Login_AccountLocked(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockService)
+	mockService.On("AuthenticateUser", mock.Anything, mock.Anything).
+		Return(nil, &AccountLockedError{Until: time.Now().Add(90 * time.Second)})
+	handler := NewHandler(mockService, new(testutil.MockAuthService))
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/api/v1/auth/login",
+		bytes.NewBufferString(`{"email":"jane@example.com","password":"Password123!"}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	handler.Login(c)
+	apiErrors.ErrorHandler()(c)
+
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "90", w.Header().Get("Retry-After"))
+	assert.Contains(t, w.Body.String(), apiErrors.CodeAccountLocked)
+}
+
+func TestHandler_Login_WarnsOnRepeatedFailuresFromIP(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var buf bytes.Buffer
+	oldDefault := slog.Default()
+	defer slog.SetDefault(oldDefault)
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&buf, nil)))
+
+	mockService := new(MockService)
+	mockService.On("AuthenticateUser", mock.Anything, mock.Anything).Return(nil, ErrInvalidCredentials)
+	handler := NewHandler(mockService, new(testutil.MockAuthService))
+
+	login := func() {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodPost, "/api/v1/auth/login",
+			bytes.NewBufferString(`{"email":"jane@example.com","password":"guess"}`))
+		c.Request.Header.Set("Content-Type", "application/json")
+		c.Request.RemoteAddr = "203.0.113.7:4242"
+		handler.Login(c)
+		apiErrors.ErrorHandler()(c)
+		require.Equal(t, http.StatusUnauthorized, w.Code)
+	}
+
+	for i := 1; i < repeatedLoginFailureThreshold; i++ {
+		login()
+	}
+	assert.NotContains(t, buf.String(), "login_bruteforce", "isolated failures are not reported")
+
+	login()
+	var entry map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, "WARN", entry["level"])
+	assert.Equal(t, "login_bruteforce", entry["event"])
+	assert.Equal(t, "203.0.113.7", entry["client_ip"])
+	assert.Equal(t, float64(repeatedLoginFailureThreshold), entry["failures"])
+}
+
+func TestHandler_Lockout(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	lockedUntil := time.Date(2026, 2, 18, 9, 15, 0, 0, time.UTC)
+	failures := []time.Time{lockedUntil.Add(-15 * time.Minute), lockedUntil.Add(-16 * time.Minute)}
+	locked := &LockoutStatus{FailedAttempts: 2, MaxAttempts: 2, Locked: true, LockedUntil: &lockedUntil, RecentFailures: failures}
+	unlocked := &LockoutStatus{MaxAttempts: 2, RecentFailures: []time.Time{}}
+
+	tests := []struct {
+		name           string
+		method         string
+		userID         string
+		setupMocks     func(*MockService)
+		expectedStatus int
+		expectedData   map[string]interface{}
+	}{
+		{
+			name:   "view locked account",
+			method: http.MethodGet,
+			userID: "2",
+			setupMocks: func(ms *MockService) {
+				ms.On("GetLockoutStatus", mock.Anything, uint(2)).Return(locked, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedData: map[string]interface{}{
+				"locked":          true,
+				"failed_attempts": float64(2),
+				"max_attempts":    float64(2),
+				"locked_until":    "2026-02-18T09:15:00Z",
+				"recent_failures": []interface{}{"2026-02-18T09:00:00Z", "2026-02-18T08:59:00Z"},
+			},
+		},
+		{
+			name:   "clear locked account",
+			method: http.MethodDelete,
+			userID: "2",
+			setupMocks: func(ms *MockService) {
+				ms.On("ClearLockout", mock.Anything, uint(2)).Return(locked, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedData: map[string]interface{}{
+				"locked":          false,
+				"failed_attempts": float64(0),
+				"max_attempts":    float64(2),
+				"locked_until":    nil,
+				"recent_failures": []interface{}{},
+			},
+		},
+		{
+			name:   "clear account that is not locked",
+			method: http.MethodDelete,
+			userID: "2",
+			setupMocks: func(ms *MockService) {
+				ms.On("ClearLockout", mock.Anything, uint(2)).Return(unlocked, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedData: map[string]interface{}{
+				"locked":          false,
+				"failed_attempts": float64(0),
+				"max_attempts":    float64(2),
+				"locked_until":    nil,
+				"recent_failures": []interface{}{},
+			},
+		},
+		{
+			name:   "user not found",
+			method: http.MethodDelete,
+			userID: "404",
+			setupMocks: func(ms *MockService) {
+				ms.On("ClearLockout", mock.Anything, uint(404)).Return(nil, ErrUserNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "invalid user ID",
+			method:         http.MethodGet,
+			userID:         "abc",
+			setupMocks:     func(ms *MockService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockService)
+			tt.setupMocks(mockService)
+			handler := NewHandler(mockService, new(testutil.MockAuthService))
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(tt.method, "/api/v1/admin/users/"+tt.userID+"/lockout", nil)
+			c.Params = gin.Params{{Key: "id", Value: tt.userID}}
+			c.Set(auth.KeyUser, &auth.Claims{UserID: 1, Roles: []string{RoleAdmin}})
+
+			if tt.method == http.MethodGet {
+				handler.GetLockout(c)
+			} else {
+				handler.ClearLockout(c)
+			}
+			apiErrors.ErrorHandler()(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedData != nil {
+				var response map[string]interface{}
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, tt.expectedData, response["data"])
+			}
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandler_GetAdminUser_IncludesLockout(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	lockedUntil := time.Now().Add(10 * time.Minute)
+	mockService := new(MockService)
+	mockService.On("GetUserByID", mock.Anything, uint(2)).Return(&User{ID: 2, Email: "jane@example.com", Active: true}, nil)
+	mockService.On("GetLockoutStatus", mock.Anything, uint(2)).
+		Return(&LockoutStatus{FailedAttempts: 5, MaxAttempts: 5, Locked: true, LockedUntil: &lockedUntil}, nil)
+	handler := NewHandler(mockService, new(testutil.MockAuthService))
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/admin/users/2", nil)
+	c.Params = gin.Params{{Key: "id", Value: "2"}}
+	c.Set(auth.KeyUser, &auth.Claims{UserID: 1, Roles: []string{RoleAdmin}})
+
+	handler.GetAdminUser(c)
+	apiErrors.ErrorHandler()(c)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var response struct {
+		Data AdminUserResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, uint(2), response.Data.ID)
+	assert.Equal(t, "jane@example.com", response.Data.Email)
+	assert.True(t, response.Data.Lockout.Locked)
+	assert.Equal(t, 5, response.Data.Lockout.FailedAttempts)
+	require.NotNil(t, response.Data.Lockout.LockedUntil)
+	mockService.AssertExpectations(t)
+}
+
+func TestHandler_Login_RememberMe(t *testing.T) {
+	db := testutil.NewSQLiteDB(t)
+
+	userService := NewService(NewRepository(db), newTestSecurityConfig())
+	authService := auth.NewServiceWithRepo(&config.JWTConfig{
+		Secret:                    "test-secret-that-is-long-enough-123",
+		AccessTokenTTL:            15 * time.Minute,
+		RefreshTokenTTL:           24 * time.Hour,
+		RememberMeRefreshTokenTTL: 30 * 24 * time.Hour,
+	}, db)
+	handler := NewHandler(userService, authService)
+
+	_, err := userService.RegisterUser(context.Background(), RegisterRequest{
+		Name:     "Jane Doe",
+		Email:    "jane@example.com",
+		Password: "Password123!",
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		rememberMe bool
+		wantTTL    time.Duration
+	}{
+		{name: "default lifetime", rememberMe: false, wantTTL: 24 * time.Hour},
+		{name: "remember me", rememberMe: true, wantTTL: 30 * 24 * time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+
+			body, _ := json.Marshal(LoginRequest{Email: "jane@example.com", Password: "Password123!", RememberMe: tt.rememberMe})
+			c.Request = httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", bytes.NewBuffer(body))
+			c.Request.Header.Set("Content-Type", "application/json")
+
+			handler.Login(c)
+			apiErrors.ErrorHandler()(c)
+			require.Equal(t, http.StatusOK, w.Code)
+
+			var response struct {
+				Data AuthResponse `json:"data"`
+			}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+
+			var stored auth.RefreshToken
+			require.NoError(t, db.Where("token_hash = ?", auth.HashToken(response.Data.RefreshToken)).First(&stored).Error)
+			assert.Equal(t, tt.rememberMe, stored.RememberMe)
+			assert.WithinDuration(t, time.Now().Add(tt.wantTTL), stored.ExpiresAt, time.Minute)
+		})
+	}
+}
+
+func TestHandler_ListSessions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	created := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	family := uuid.New()
+	sessions := []auth.SessionFamily{
+		{TokenFamily: family, UserID: 7, ClientID: "mobile", CreatedAt: created, LastUsedAt: &created, ExpiresAt: created.Add(time.Hour), Active: true},
+		{TokenFamily: uuid.New(), UserID: 7, CreatedAt: created, ExpiresAt: created.Add(time.Hour), RevokedAt: &created},
+	}
+
+	tests := []struct {
+		name           string
+		query          string
+		setupMocks     func(*testutil.MockAuthService)
+		expectedStatus int
+		checkResponse  func(*testing.T, *httptest.ResponseRecorder)
+	}{
+		{
+			name:  "filters by user and active",
+			query: "?user_id=7&active=true&per_page=1",
+			setupMocks: func(mas *testutil.MockAuthService) {
+				filter := auth.SessionFilter{UserID: 7, ActiveOnly: true, Limit: 2, Offset: 0}
+				mas.On("ListSessions", mock.Anything, filter).Return(sessions, nil)
+			},
+			expectedStatus: http.StatusOK,
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				var response struct {
+					Data SessionListResponse `json:"data"`
+				}
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				require.Len(t, response.Data.Sessions, 1)
+				assert.True(t, response.Data.HasNext)
+				session := response.Data.Sessions[0]
+				assert.Equal(t, family.String(), session.TokenFamily)
+				assert.Equal(t, uint(7), session.UserID)
+				assert.Equal(t, "mobile", session.ClientID)
+				assert.Equal(t, "2026-01-02T03:04:05Z", session.CreatedAt)
+				require.NotNil(t, session.LastUsedAt)
+				assert.False(t, session.Revoked)
+				assert.True(t, session.Active)
+			},
+		},
+		{
+			name:           "invalid user filter",
+			query:          "?user_id=abc",
+			setupMocks:     func(mas *testutil.MockAuthService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid active filter",
+			query:          "?active=maybe",
+			setupMocks:     func(mas *testutil.MockAuthService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "refresh token storage not configured",
+			query: "",
+			setupMocks: func(mas *testutil.MockAuthService) {
+				mas.On("ListSessions", mock.Anything, mock.Anything).Return(nil, auth.ErrRefreshStoreUnavailable)
+			},
+			expectedStatus: http.StatusServiceUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockAuthService := new(testutil.MockAuthService)
+			tt.setupMocks(mockAuthService)
+			handler := NewHandler(new(MockService), mockAuthService)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/admin/sessions"+tt.query, nil)
+			c.Set(auth.KeyUser, &auth.Claims{UserID: 1, Roles: []string{RoleAdmin}})
+
+			handler.ListSessions(c)
+			apiErrors.ErrorHandler()(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.checkResponse != nil {
+				tt.checkResponse(t, w)
+			}
+			openapitest.AssertResponse(t, http.MethodGet, "/api/v1/admin/sessions", w)
+			mockAuthService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandler_RevokeSession(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	family := uuid.New()
+	tests := []struct {
+		name           string
+		family         string
+		setupMocks     func(*testutil.MockAuthService)
+		expectedStatus int
+	}{
+		{
+			name:   "revokes the family",
+			family: family.String(),
+			setupMocks: func(mas *testutil.MockAuthService) {
+				mas.On("RevokeSession", mock.Anything, family).Return(nil)
+			},
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:           "invalid family",
+			family:         "not-a-uuid",
+			setupMocks:     func(mas *testutil.MockAuthService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "unknown family",
+			family: family.String(),
+			setupMocks: func(mas *testutil.MockAuthService) {
+				mas.On("RevokeSession", mock.Anything, family).Return(auth.ErrSessionNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockAuthService := new(testutil.MockAuthService)
+			tt.setupMocks(mockAuthService)
+			handler := NewHandler(new(MockService), mockAuthService)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodDelete, "/api/v1/admin/sessions/"+tt.family, nil)
+			c.Params = gin.Params{{Key: "family", Value: tt.family}}
+			c.Set(auth.KeyUser, &auth.Claims{UserID: 1, Roles: []string{RoleAdmin}})
+
+			handler.RevokeSession(c)
+			apiErrors.ErrorHandler()(c)
+			c.Writer.WriteHeaderNow()
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			mockAuthService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandler_RefreshToken_PassesClientInfo(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockAuthService := new(testutil.MockAuthService)
+	fromClient := mock.MatchedBy(func(ctx context.Context) bool {
+		client := auth.ClientInfoFromContext(ctx)
+		return client.IP == "203.0.113.9" && client.UserAgent == "okhttp/4.12.0"
+	})
+	mockAuthService.On("RefreshAccessToken", fromClient, "valid-refresh-token").
+		Return(&auth.TokenPair{AccessToken: "access", RefreshToken: "refresh", TokenType: "Bearer"}, nil)
+	handler := NewHandler(new(MockService), mockAuthService)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/api/v1/auth/refresh", bytes.NewBufferString(`{"refresh_token":"valid-refresh-token"}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Request.Header.Set("User-Agent", "okhttp/4.12.0")
+	c.Request.RemoteAddr = "203.0.113.9:41234"
+
+	handler.RefreshToken(c)
+	apiErrors.ErrorHandler()(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockAuthService.AssertExpectations(t)
+}
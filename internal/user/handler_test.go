@@ -2,75 +2,34 @@ package user
 
 import (
 	"bytes"
-	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 
 	"github.com/yeegeek/uyou-go-api-starter/internal/auth"
+	"github.com/yeegeek/uyou-go-api-starter/internal/config"
+	"github.com/yeegeek/uyou-go-api-starter/internal/contextutil"
 	apiErrors "github.com/yeegeek/uyou-go-api-starter/internal/errors"
+	"github.com/yeegeek/uyou-go-api-starter/internal/middleware"
+	"github.com/yeegeek/uyou-go-api-starter/internal/openapitest"
+	"github.com/yeegeek/uyou-go-api-starter/internal/testutil"
 )
 
-// MockAuthService is a mock implementation of the auth service
-type MockAuthService struct {
-	mock.Mock
-}
-
-func (m *MockAuthService) ValidateToken(tokenString string) (*auth.Claims, error) {
-	args := m.Called(tokenString)
-	if args.Get(0) == nil {
-		return nil, args.Error(1)
-	}
-	return args.Get(0).(*auth.Claims), args.Error(1)
-}
-
-func (m *MockAuthService) GenerateToken(userID uint, email string, name string) (string, error) {
-	args := m.Called(userID, email, name)
-	return args.String(0), args.Error(1)
-}
-
-func (m *MockAuthService) GenerateTokenPair(ctx context.Context, userID uint, email string, name string) (*auth.TokenPair, error) {
-	args := m.Called(ctx, userID, email, name)
-	if args.Get(0) == nil {
-		return nil, args.Error(1)
-	}
-	return args.Get(0).(*auth.TokenPair), args.Error(1)
-}
-
-func (m *MockAuthService) RefreshAccessToken(ctx context.Context, refreshToken string) (*auth.TokenPair, error) {
-	args := m.Called(ctx, refreshToken)
-	if args.Get(0) == nil {
-		return nil, args.Error(1)
-	}
-	return args.Get(0).(*auth.TokenPair), args.Error(1)
-}
-
-func (m *MockAuthService) RevokeRefreshToken(ctx context.Context, refreshToken string) error {
-	args := m.Called(ctx, refreshToken)
-	return args.Error(0)
-}
-
-func (m *MockAuthService) RevokeUserRefreshToken(ctx context.Context, userID uint, refreshToken string) error {
-	args := m.Called(ctx, userID, refreshToken)
-	return args.Error(0)
-}
-
-func (m *MockAuthService) RevokeAllUserTokens(ctx context.Context, userID uint) error {
-	args := m.Called(ctx, userID)
-	return args.Error(0)
-}
-
 func TestHandler_Register(t *testing.T) {
 	tests := []struct {
 		name           string
 		requestBody    interface{}
-		setupMocks     func(*MockService, *MockAuthService)
+		setupMocks     func(*MockService, *testutil.MockAuthService)
 		expectedStatus int
 		checkResponse  func(*testing.T, *httptest.ResponseRecorder)
 	}{
@@ -81,7 +40,7 @@ func TestHandler_Register(t *testing.T) {
 				Email:    "john@example.com",
 				Password: "password123",
 			},
-			setupMocks: func(ms *MockService, mas *MockAuthService) {
+			setupMocks: func(ms *MockService, mas *testutil.MockAuthService) {
 				user := &User{
 					ID:    1,
 					Name:  "John Doe",
@@ -112,7 +71,7 @@ func TestHandler_Register(t *testing.T) {
 		{
 			name:        "invalid JSON format",
 			requestBody: `{"name": "John", "email": invalid-json`,
-			setupMocks: func(ms *MockService, mas *MockAuthService) {
+			setupMocks: func(ms *MockService, mas *testutil.MockAuthService) {
 			},
 			expectedStatus: http.StatusBadRequest,
 			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
@@ -130,7 +89,7 @@ func TestHandler_Register(t *testing.T) {
 			requestBody: RegisterRequest{
 				Name: "John Doe",
 			},
-			setupMocks: func(ms *MockService, mas *MockAuthService) {
+			setupMocks: func(ms *MockService, mas *testutil.MockAuthService) {
 			},
 			expectedStatus: http.StatusBadRequest,
 			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
@@ -143,6 +102,31 @@ func TestHandler_Register(t *testing.T) {
 				assert.Equal(t, "VALIDATION_ERROR", errorInfo["code"])
 			},
 		},
+		{
+			name: "multiple invalid fields are reported individually",
+			requestBody: RegisterRequest{
+				Name:     "J",
+				Email:    "not-an-email",
+				Password: "",
+			},
+			setupMocks: func(ms *MockService, mas *testutil.MockAuthService) {
+			},
+			expectedStatus: http.StatusBadRequest,
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				var response map[string]interface{}
+				err := json.Unmarshal(w.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				errorInfo, ok := response["error"].(map[string]interface{})
+				assert.True(t, ok, "error should be a map")
+				assert.Equal(t, "VALIDATION_ERROR", errorInfo["code"])
+				assert.Equal(t, "Validation failed", errorInfo["message"])
+				fields, ok := errorInfo["fields"].(map[string]interface{})
+				assert.True(t, ok, "fields should be a map")
+				assert.Equal(t, "is too short (minimum 2)", fields["name"])
+				assert.Equal(t, "must be a valid email address", fields["email"])
+				assert.Equal(t, "is required", fields["password"])
+			},
+		},
 		{
 			name: "email already exists",
 			requestBody: RegisterRequest{
@@ -150,7 +134,7 @@ func TestHandler_Register(t *testing.T) {
 				Email:    "john@example.com",
 				Password: "password123",
 			},
-			setupMocks: func(ms *MockService, mas *MockAuthService) {
+			setupMocks: func(ms *MockService, mas *testutil.MockAuthService) {
 				ms.On("RegisterUser", mock.Anything, mock.AnythingOfType("user.RegisterRequest")).Return(nil, ErrEmailExists)
 			},
 			expectedStatus: http.StatusConflict,
@@ -164,6 +148,30 @@ func TestHandler_Register(t *testing.T) {
 				assert.Equal(t, "Email already exists", errorInfo["message"])
 			},
 		},
+		{
+			name: "email domain not allowed",
+			requestBody: RegisterRequest{
+				Name:     "Jane Doe",
+				Email:    "jane@mailinator.com",
+				Password: "password123",
+			},
+			setupMocks: func(ms *MockService, mas *testutil.MockAuthService) {
+				ms.On("RegisterUser", mock.Anything, mock.AnythingOfType("user.RegisterRequest")).
+					Return(nil, &EmailDomainNotAllowedError{Domain: "mailinator.com"})
+			},
+			expectedStatus: http.StatusBadRequest,
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				var response map[string]interface{}
+				err := json.Unmarshal(w.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				errorInfo, ok := response["error"].(map[string]interface{})
+				assert.True(t, ok, "error should be a map")
+				assert.Equal(t, "VALIDATION_ERROR", errorInfo["code"])
+				fields, ok := errorInfo["fields"].(map[string]interface{})
+				assert.True(t, ok, "fields should be a map")
+				assert.Equal(t, "domain not allowed", fields["email"])
+			},
+		},
 		{
 			name: "service database error",
 			requestBody: RegisterRequest{
@@ -171,7 +179,7 @@ func TestHandler_Register(t *testing.T) {
 				Email:    "john@example.com",
 				Password: "password123",
 			},
-			setupMocks: func(ms *MockService, mas *MockAuthService) {
+			setupMocks: func(ms *MockService, mas *testutil.MockAuthService) {
 				ms.On("RegisterUser", mock.Anything, mock.AnythingOfType("user.RegisterRequest")).Return(nil, errors.New("database connection error"))
 			},
 			expectedStatus: http.StatusInternalServerError,
@@ -182,7 +190,7 @@ func TestHandler_Register(t *testing.T) {
 				assert.Equal(t, false, response["success"])
 				errorInfo, ok := response["error"].(map[string]interface{})
 				assert.True(t, ok, "error should be a map")
-				assert.Equal(t, "database connection error", errorInfo["details"])
+				assert.NotContains(t, errorInfo, "details", "internal error details are hidden outside debug mode")
 			},
 		},
 		{
@@ -192,7 +200,7 @@ func TestHandler_Register(t *testing.T) {
 				Email:    "john@example.com",
 				Password: "password123",
 			},
-			setupMocks: func(ms *MockService, mas *MockAuthService) {
+			setupMocks: func(ms *MockService, mas *testutil.MockAuthService) {
 				user := &User{
 					ID:    1,
 					Name:  "John Doe",
@@ -209,13 +217,13 @@ func TestHandler_Register(t *testing.T) {
 				assert.Equal(t, false, response["success"])
 				errorInfo, ok := response["error"].(map[string]interface{})
 				assert.True(t, ok, "error should be a map")
-				assert.Equal(t, "token generation failed", errorInfo["details"])
+				assert.NotContains(t, errorInfo, "details", "internal error details are hidden outside debug mode")
 			},
 		},
 		{
 			name:        "empty request body",
 			requestBody: `{}`,
-			setupMocks: func(ms *MockService, mas *MockAuthService) {
+			setupMocks: func(ms *MockService, mas *testutil.MockAuthService) {
 			},
 			expectedStatus: http.StatusBadRequest,
 			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
@@ -234,7 +242,7 @@ func TestHandler_Register(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			mockService := &MockService{}
-			mockAuthService := &MockAuthService{}
+			mockAuthService := &testutil.MockAuthService{}
 			tt.setupMocks(mockService, mockAuthService)
 
 			handler := NewHandler(mockService, mockAuthService)
@@ -258,6 +266,7 @@ func TestHandler_Register(t *testing.T) {
 
 			assert.Equal(t, tt.expectedStatus, w.Code)
 			tt.checkResponse(t, w)
+			openapitest.AssertResponse(t, http.MethodPost, "/api/v1/auth/register", w)
 
 			mockService.AssertExpectations(t)
 			mockAuthService.AssertExpectations(t)
@@ -269,7 +278,7 @@ func TestHandler_GetUser(t *testing.T) {
 	tests := []struct {
 		name           string
 		userID         string
-		setupMocks     func(*MockService, *MockAuthService)
+		setupMocks     func(*MockService, *testutil.MockAuthService)
 		setupContext   func(*gin.Context)
 		expectedStatus int
 		checkResponse  func(*testing.T, *httptest.ResponseRecorder)
@@ -277,7 +286,7 @@ func TestHandler_GetUser(t *testing.T) {
 		{
 			name:   "successful get user",
 			userID: "1",
-			setupMocks: func(ms *MockService, mas *MockAuthService) {
+			setupMocks: func(ms *MockService, mas *testutil.MockAuthService) {
 				user := &User{
 					ID:    1,
 					Name:  "John Doe",
@@ -305,7 +314,7 @@ func TestHandler_GetUser(t *testing.T) {
 		{
 			name:   "invalid user ID format",
 			userID: "invalid",
-			setupMocks: func(ms *MockService, mas *MockAuthService) {
+			setupMocks: func(ms *MockService, mas *testutil.MockAuthService) {
 			},
 			setupContext: func(c *gin.Context) {
 				claims := &auth.Claims{UserID: 1}
@@ -326,7 +335,7 @@ func TestHandler_GetUser(t *testing.T) {
 		{
 			name:   "unauthenticated user - no context",
 			userID: "1",
-			setupMocks: func(ms *MockService, mas *MockAuthService) {
+			setupMocks: func(ms *MockService, mas *testutil.MockAuthService) {
 			},
 			setupContext: func(c *gin.Context) {
 			},
@@ -341,10 +350,29 @@ func TestHandler_GetUser(t *testing.T) {
 				assert.Equal(t, "Forbidden user ID", errorInfo["message"])
 			},
 		},
+		{
+			name:   "admin can access another user",
+			userID: "2",
+			setupMocks: func(ms *MockService, mas *testutil.MockAuthService) {
+				ms.On("GetUserByID", mock.Anything, uint(2)).Return(&User{ID: 2, Name: "Jane Doe", Email: "jane@example.com"}, nil)
+			},
+			setupContext: func(c *gin.Context) {
+				c.Set(auth.KeyUser, &auth.Claims{UserID: 1, Roles: []string{RoleAdmin}})
+			},
+			expectedStatus: http.StatusOK,
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				var response map[string]interface{}
+				err := json.Unmarshal(w.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				data, ok := response["data"].(map[string]interface{})
+				assert.True(t, ok, "data should be a map")
+				assert.Equal(t, float64(2), data["id"])
+			},
+		},
 		{
 			name:   "forbidden access - different user",
 			userID: "2",
-			setupMocks: func(ms *MockService, mas *MockAuthService) {
+			setupMocks: func(ms *MockService, mas *testutil.MockAuthService) {
 			},
 			setupContext: func(c *gin.Context) {
 				claims := &auth.Claims{UserID: 1}
@@ -364,7 +392,7 @@ func TestHandler_GetUser(t *testing.T) {
 		{
 			name:   "user not found",
 			userID: "999",
-			setupMocks: func(ms *MockService, mas *MockAuthService) {
+			setupMocks: func(ms *MockService, mas *testutil.MockAuthService) {
 				ms.On("GetUserByID", mock.Anything, uint(999)).Return(nil, ErrUserNotFound)
 			},
 			setupContext: func(c *gin.Context) {
@@ -385,7 +413,7 @@ func TestHandler_GetUser(t *testing.T) {
 		{
 			name:   "database service error",
 			userID: "1",
-			setupMocks: func(ms *MockService, mas *MockAuthService) {
+			setupMocks: func(ms *MockService, mas *testutil.MockAuthService) {
 				ms.On("GetUserByID", mock.Anything, uint(1)).Return(nil, errors.New("database connection error"))
 			},
 			setupContext: func(c *gin.Context) {
@@ -400,13 +428,13 @@ func TestHandler_GetUser(t *testing.T) {
 				assert.Equal(t, false, response["success"])
 				errorInfo, ok := response["error"].(map[string]interface{})
 				assert.True(t, ok, "error should be a map")
-				assert.Equal(t, "database connection error", errorInfo["details"])
+				assert.NotContains(t, errorInfo, "details", "internal error details are hidden outside debug mode")
 			},
 		},
 		{
 			name:   "zero user ID",
 			userID: "0",
-			setupMocks: func(ms *MockService, mas *MockAuthService) {
+			setupMocks: func(ms *MockService, mas *testutil.MockAuthService) {
 			},
 			setupContext: func(c *gin.Context) {
 				claims := &auth.Claims{UserID: 1}
@@ -428,7 +456,7 @@ func TestHandler_GetUser(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			mockService := &MockService{}
-			mockAuthService := &MockAuthService{}
+			mockAuthService := &testutil.MockAuthService{}
 			tt.setupMocks(mockService, mockAuthService)
 
 			handler := NewHandler(mockService, mockAuthService)
@@ -454,11 +482,96 @@ func TestHandler_GetUser(t *testing.T) {
 	}
 }
 
+func TestHandler_GetUser_Versions(t *testing.T) {
+	createdAt := time.Date(2026, 3, 1, 10, 30, 0, 0, time.FixedZone("CST", 8*3600))
+	user := &User{
+		ID:        7,
+		Name:      "John Doe",
+		Email:     "john@example.com",
+		CreatedAt: createdAt,
+		UpdatedAt: createdAt,
+	}
+
+	mockService := &MockService{}
+	mockService.On("GetUserByID", mock.Anything, uint(7)).Return(user, nil)
+	handler := NewHandler(mockService, &testutil.MockAuthService{})
+
+	authenticate := func(c *gin.Context) {
+		c.Set(auth.KeyUser, &auth.Claims{UserID: 7})
+	}
+
+	router := testutil.NewTestRouter(t)
+	router.GET("/api/v1/users/:id", middleware.APIVersion(contextutil.APIVersionV1), authenticate, handler.GetUser)
+	router.GET("/api/v2/users/:id", middleware.APIVersion(contextutil.APIVersionV2), authenticate, handler.GetUser)
+
+	fetch := func(path string) map[string]interface{} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response map[string]interface{}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		data, ok := response["data"].(map[string]interface{})
+		assert.True(t, ok, "data should be a map")
+		return data
+	}
+
+	t.Run("v1 returns id", func(t *testing.T) {
+		data := fetch("/api/v1/users/7")
+		assert.Equal(t, float64(7), data["id"])
+		assert.NotContains(t, data, "user_id")
+		assert.Equal(t, "2026-03-01T10:30:00Z", data["created_at"])
+	})
+
+	t.Run("v2 returns user_id with RFC3339 timestamps", func(t *testing.T) {
+		data := fetch("/api/v2/users/7")
+		assert.Equal(t, float64(7), data["user_id"])
+		assert.NotContains(t, data, "id")
+		assert.Equal(t, "2026-03-01T02:30:00Z", data["created_at"])
+		assert.Equal(t, "2026-03-01T02:30:00Z", data["updated_at"])
+	})
+
+	mockService.AssertExpectations(t)
+}
+
+func TestShapedUserResponse(t *testing.T) {
+	user := &User{ID: 7, Name: "John Doe", Email: "john@example.com", PendingEmail: "new@example.com"}
+	shape := func(version string, claims *auth.Claims) map[string]interface{} {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		contextutil.SetAPIVersion(c, version)
+		c.Set(auth.KeyUser, claims)
+
+		body, err := json.Marshal(shapedUserResponse(c, user))
+		require.NoError(t, err)
+		var fields map[string]interface{}
+		require.NoError(t, json.Unmarshal(body, &fields))
+		return fields
+	}
+
+	full := []*auth.Claims{
+		{UserID: 7},
+		{UserID: 1, Roles: []string{RoleAdmin}},
+		{UserID: 1, Roles: []string{"support"}, Permissions: []string{PermissionUsersRead}},
+	}
+	for _, claims := range full {
+		fields := shape(contextutil.APIVersionV1, claims)
+		assert.Equal(t, "john@example.com", fields["email"])
+		assert.Equal(t, "new@example.com", fields["pending_email"])
+	}
+
+	fields := shape(contextutil.APIVersionV1, &auth.Claims{UserID: 1, Roles: []string{RoleUser}})
+	assert.Equal(t, map[string]interface{}{"id": float64(7), "name": "John Doe"}, fields)
+	assert.NotContains(t, fields, "email", "email must be absent, not empty")
+
+	fields = shape(contextutil.APIVersionV2, &auth.Claims{UserID: 1, Roles: []string{RoleUser}})
+	assert.Equal(t, map[string]interface{}{"user_id": float64(7), "name": "John Doe"}, fields)
+}
+
 func TestHandler_Login(t *testing.T) {
 	tests := []struct {
 		name           string
 		requestBody    interface{}
-		setupMocks     func(*MockService, *MockAuthService)
+		setupMocks     func(*MockService, *testutil.MockAuthService)
 		expectedStatus int
 		checkResponse  func(*testing.T, *httptest.ResponseRecorder)
 	}{
@@ -468,7 +581,7 @@ func TestHandler_Login(t *testing.T) {
 				Email:    "john@example.com",
 				Password: "password123",
 			},
-			setupMocks: func(ms *MockService, mas *MockAuthService) {
+			setupMocks: func(ms *MockService, mas *testutil.MockAuthService) {
 				user := &User{
 					ID:    1,
 					Name:  "John Doe",
@@ -506,7 +619,7 @@ func TestHandler_Login(t *testing.T) {
 				Email:    "john@example.com",
 				Password: "wrongpassword",
 			},
-			setupMocks: func(ms *MockService, mas *MockAuthService) {
+			setupMocks: func(ms *MockService, mas *testutil.MockAuthService) {
 				ms.On("AuthenticateUser", mock.Anything, mock.AnythingOfType("user.LoginRequest")).Return(nil, ErrInvalidCredentials)
 			},
 			expectedStatus: http.StatusUnauthorized,
@@ -526,7 +639,7 @@ func TestHandler_Login(t *testing.T) {
 				Email:    "john@example.com",
 				Password: "password123",
 			},
-			setupMocks: func(ms *MockService, mas *MockAuthService) {
+			setupMocks: func(ms *MockService, mas *testutil.MockAuthService) {
 				ms.On("AuthenticateUser", mock.Anything, mock.AnythingOfType("user.LoginRequest")).Return(nil, errors.New("failed to authenticate user"))
 			},
 			expectedStatus: http.StatusInternalServerError,
@@ -537,7 +650,7 @@ func TestHandler_Login(t *testing.T) {
 				assert.Equal(t, false, response["success"])
 				errorInfo, ok := response["error"].(map[string]interface{})
 				assert.True(t, ok, "error should be a map")
-				assert.Equal(t, "failed to authenticate user", errorInfo["details"])
+				assert.NotContains(t, errorInfo, "details", "internal error details are hidden outside debug mode")
 			},
 		},
 		{
@@ -546,7 +659,7 @@ func TestHandler_Login(t *testing.T) {
 				Email:    "john@example.com",
 				Password: "password123",
 			},
-			setupMocks: func(ms *MockService, mas *MockAuthService) {
+			setupMocks: func(ms *MockService, mas *testutil.MockAuthService) {
 				user := &User{
 					ID:    1,
 					Name:  "John Doe",
@@ -563,13 +676,13 @@ func TestHandler_Login(t *testing.T) {
 				assert.Equal(t, false, response["success"])
 				errorInfo, ok := response["error"].(map[string]interface{})
 				assert.True(t, ok, "error should be a map")
-				assert.Equal(t, "failed to generate token", errorInfo["details"])
+				assert.NotContains(t, errorInfo, "details", "internal error details are hidden outside debug mode")
 			},
 		},
 		{
 			name:           "invalid request body",
 			requestBody:    `{invalid-json}`,
-			setupMocks:     func(ms *MockService, mas *MockAuthService) {},
+			setupMocks:     func(ms *MockService, mas *testutil.MockAuthService) {},
 			expectedStatus: http.StatusBadRequest,
 			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
 				var response map[string]interface{}
@@ -587,7 +700,7 @@ func TestHandler_Login(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			mockService := &MockService{}
-			mockAuthService := &MockAuthService{}
+			mockAuthService := &testutil.MockAuthService{}
 			tt.setupMocks(mockService, mockAuthService)
 
 			handler := NewHandler(mockService, mockAuthService)
@@ -615,6 +728,7 @@ func TestHandler_Login(t *testing.T) {
 
 			assert.Equal(t, tt.expectedStatus, w.Code)
 			tt.checkResponse(t, w)
+			openapitest.AssertResponse(t, http.MethodPost, "/api/v1/auth/login", w)
 
 			mockService.AssertExpectations(t)
 			mockAuthService.AssertExpectations(t)
@@ -622,12 +736,67 @@ func TestHandler_Login(t *testing.T) {
 	}
 }
 
+func TestHandler_Login_ClientGrant(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	login := func(body LoginRequest, header string, setupMocks func(*MockService, *testutil.MockAuthService)) *httptest.ResponseRecorder {
+		mockService := new(MockService)
+		mockAuthService := new(testutil.MockAuthService)
+		setupMocks(mockService, mockAuthService)
+		handler := NewHandler(mockService, mockAuthService)
+
+		payload, err := json.Marshal(body)
+		require.NoError(t, err)
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", bytes.NewReader(payload))
+		c.Request.Header.Set("Content-Type", "application/json")
+		if header != "" {
+			c.Request.Header.Set(auth.ClientIDHeader, header)
+		}
+
+		handler.Login(c)
+		apiErrors.ErrorHandler()(c)
+
+		openapitest.AssertResponse(t, http.MethodPost, "/api/v1/auth/login", w)
+		mockService.AssertExpectations(t)
+		mockAuthService.AssertExpectations(t)
+		return w
+	}
+
+	t.Run("client not allowed to log in with a password", func(t *testing.T) {
+		w := login(LoginRequest{Email: "john@example.com", Password: "password123", ClientID: "kiosk"}, "", func(ms *MockService, mas *testutil.MockAuthService) {
+			mas.On("CheckClientGrant", "kiosk", config.ClientGrantPassword).Return(auth.ErrGrantNotAllowed)
+		})
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("body client_id takes precedence over the header", func(t *testing.T) {
+		w := login(LoginRequest{Email: "john@example.com", Password: "password123", ClientID: "mobile"}, "web", func(ms *MockService, mas *testutil.MockAuthService) {
+			mas.On("CheckClientGrant", "mobile", config.ClientGrantPassword).Return(nil)
+			ms.On("AuthenticateUser", mock.Anything, mock.AnythingOfType("user.LoginRequest")).Return(&User{ID: 1, Name: "John Doe", Email: "john@example.com"}, nil)
+			mas.On("GenerateTokenPair", mock.Anything, uint(1), "john@example.com", "John Doe").Return(&auth.TokenPair{
+				AccessToken: "mock-access-token", RefreshToken: "mock-refresh-token", TokenType: "Bearer", ExpiresIn: 1800,
+			}, nil)
+		})
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"expires_in":1800`)
+	})
+
+	t.Run("header names the client when the body does not", func(t *testing.T) {
+		w := login(LoginRequest{Email: "john@example.com", Password: "password123"}, "kiosk", func(ms *MockService, mas *testutil.MockAuthService) {
+			mas.On("CheckClientGrant", "kiosk", config.ClientGrantPassword).Return(auth.ErrGrantNotAllowed)
+		})
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+}
+
 func TestHandler_UpdateUser(t *testing.T) {
 	tests := []struct {
 		name           string
 		userID         string
 		requestBody    interface{}
-		setupMocks     func(*MockService, *MockAuthService)
+		setupMocks     func(*MockService, *testutil.MockAuthService)
 		setupContext   func(*gin.Context)
 		expectedStatus int
 		checkResponse  func(*testing.T, *httptest.ResponseRecorder)
@@ -639,7 +808,7 @@ func TestHandler_UpdateUser(t *testing.T) {
 				Name:  "John Updated",
 				Email: "john.updated@example.com",
 			},
-			setupMocks: func(ms *MockService, mas *MockAuthService) {
+			setupMocks: func(ms *MockService, mas *testutil.MockAuthService) {
 				updatedUser := &User{
 					ID:    1,
 					Name:  "John Updated",
@@ -668,7 +837,7 @@ func TestHandler_UpdateUser(t *testing.T) {
 			name:           "invalid user ID",
 			userID:         "invalid",
 			requestBody:    UpdateUserRequest{Name: "Test"},
-			setupMocks:     func(ms *MockService, mas *MockAuthService) {},
+			setupMocks:     func(ms *MockService, mas *testutil.MockAuthService) {},
 			setupContext:   func(c *gin.Context) {},
 			expectedStatus: http.StatusBadRequest,
 			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
@@ -681,13 +850,36 @@ func TestHandler_UpdateUser(t *testing.T) {
 				assert.Equal(t, "Invalid user ID", errorInfo["message"])
 			},
 		},
+		{
+			name:   "admin can update another user",
+			userID: "2",
+			requestBody: UpdateUserRequest{
+				Name: "Admin Update",
+			},
+			setupMocks: func(ms *MockService, mas *testutil.MockAuthService) {
+				ms.On("UpdateUser", mock.Anything, uint(2), mock.AnythingOfType("user.UpdateUserRequest")).
+					Return(&User{ID: 2, Name: "Admin Update", Email: "jane@example.com"}, nil)
+			},
+			setupContext: func(c *gin.Context) {
+				c.Set(auth.KeyUser, &auth.Claims{UserID: 1, Roles: []string{RoleAdmin}})
+			},
+			expectedStatus: http.StatusOK,
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				var response map[string]interface{}
+				err := json.Unmarshal(w.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				data, ok := response["data"].(map[string]interface{})
+				assert.True(t, ok, "data should be a map")
+				assert.Equal(t, "Admin Update", data["name"])
+			},
+		},
 		{
 			name:   "forbidden access",
 			userID: "2",
 			requestBody: UpdateUserRequest{
 				Name: "Unauthorized Update",
 			},
-			setupMocks: func(ms *MockService, mas *MockAuthService) {},
+			setupMocks: func(ms *MockService, mas *testutil.MockAuthService) {},
 			setupContext: func(c *gin.Context) {
 				claims := &auth.Claims{UserID: 1}
 				c.Set(auth.KeyUser, claims)
@@ -710,7 +902,7 @@ func TestHandler_UpdateUser(t *testing.T) {
 				Name:  "John Updated",
 				Email: "john.updated@example.com",
 			},
-			setupMocks: func(ms *MockService, mas *MockAuthService) {
+			setupMocks: func(ms *MockService, mas *testutil.MockAuthService) {
 				ms.On("UpdateUser", mock.Anything, uint(999), mock.AnythingOfType("user.UpdateUserRequest")).Return(nil, ErrUserNotFound)
 			},
 			setupContext: func(c *gin.Context) {
@@ -735,7 +927,7 @@ func TestHandler_UpdateUser(t *testing.T) {
 				Name:  "John Updated",
 				Email: "existing@example.com",
 			},
-			setupMocks: func(ms *MockService, mas *MockAuthService) {
+			setupMocks: func(ms *MockService, mas *testutil.MockAuthService) {
 				ms.On("UpdateUser", mock.Anything, uint(1), mock.AnythingOfType("user.UpdateUserRequest")).Return(nil, ErrEmailExists)
 			},
 			setupContext: func(c *gin.Context) {
@@ -760,7 +952,7 @@ func TestHandler_UpdateUser(t *testing.T) {
 				Name:  "John Updated",
 				Email: "john.updated@example.com",
 			},
-			setupMocks: func(ms *MockService, mas *MockAuthService) {
+			setupMocks: func(ms *MockService, mas *testutil.MockAuthService) {
 				ms.On("UpdateUser", mock.Anything, uint(1), mock.AnythingOfType("user.UpdateUserRequest")).Return(nil, errors.New("failed to update user"))
 			},
 			setupContext: func(c *gin.Context) {
@@ -775,14 +967,14 @@ func TestHandler_UpdateUser(t *testing.T) {
 				assert.Equal(t, false, response["success"])
 				errorInfo, ok := response["error"].(map[string]interface{})
 				assert.True(t, ok, "error should be a map")
-				assert.Equal(t, "failed to update user", errorInfo["details"])
+				assert.NotContains(t, errorInfo, "details", "internal error details are hidden outside debug mode")
 			},
 		},
 		{
 			name:        "invalid request body",
 			userID:      "1",
 			requestBody: `{invalid-json}`,
-			setupMocks:  func(ms *MockService, mas *MockAuthService) {},
+			setupMocks:  func(ms *MockService, mas *testutil.MockAuthService) {},
 			setupContext: func(c *gin.Context) {
 				claims := &auth.Claims{UserID: 1}
 				c.Set(auth.KeyUser, claims)
@@ -804,7 +996,7 @@ func TestHandler_UpdateUser(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			mockService := &MockService{}
-			mockAuthService := &MockAuthService{}
+			mockAuthService := &testutil.MockAuthService{}
 			tt.setupMocks(mockService, mockAuthService)
 
 			handler := NewHandler(mockService, mockAuthService)
@@ -846,7 +1038,7 @@ func TestHandler_DeleteUser(t *testing.T) {
 	tests := []struct {
 		name           string
 		userID         string
-		setupMocks     func(*MockService, *MockAuthService)
+		setupMocks     func(*MockService, *testutil.MockAuthService)
 		setupContext   func(*gin.Context)
 		expectedStatus int
 		checkResponse  func(*testing.T, *httptest.ResponseRecorder)
@@ -854,7 +1046,7 @@ func TestHandler_DeleteUser(t *testing.T) {
 		{
 			name:   "successful deletion",
 			userID: "1",
-			setupMocks: func(ms *MockService, mas *MockAuthService) {
+			setupMocks: func(ms *MockService, mas *testutil.MockAuthService) {
 				ms.On("DeleteUser", mock.Anything, uint(1)).Return(nil)
 			},
 			setupContext: func(c *gin.Context) {
@@ -869,7 +1061,7 @@ func TestHandler_DeleteUser(t *testing.T) {
 		{
 			name:           "invalid user ID",
 			userID:         "invalid",
-			setupMocks:     func(ms *MockService, mas *MockAuthService) {},
+			setupMocks:     func(ms *MockService, mas *testutil.MockAuthService) {},
 			setupContext:   func(c *gin.Context) {},
 			expectedStatus: http.StatusBadRequest,
 			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
@@ -882,10 +1074,24 @@ func TestHandler_DeleteUser(t *testing.T) {
 				assert.Equal(t, "Invalid user ID", errorInfo["message"])
 			},
 		},
+		{
+			name:   "admin can delete another user",
+			userID: "2",
+			setupMocks: func(ms *MockService, mas *testutil.MockAuthService) {
+				ms.On("DeleteUser", mock.Anything, uint(2)).Return(nil)
+			},
+			setupContext: func(c *gin.Context) {
+				c.Set(auth.KeyUser, &auth.Claims{UserID: 1, Roles: []string{RoleUser, RoleAdmin}})
+			},
+			expectedStatus: http.StatusOK, // Note: Gin test recorder returns 200 for c.Status(204) without response body
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, "", w.Body.String())
+			},
+		},
 		{
 			name:       "forbidden access",
 			userID:     "2",
-			setupMocks: func(ms *MockService, mas *MockAuthService) {},
+			setupMocks: func(ms *MockService, mas *testutil.MockAuthService) {},
 			setupContext: func(c *gin.Context) {
 				claims := &auth.Claims{UserID: 1}
 				c.Set(auth.KeyUser, claims)
@@ -904,7 +1110,7 @@ func TestHandler_DeleteUser(t *testing.T) {
 		{
 			name:   "user not found",
 			userID: "1",
-			setupMocks: func(ms *MockService, mas *MockAuthService) {
+			setupMocks: func(ms *MockService, mas *testutil.MockAuthService) {
 				ms.On("DeleteUser", mock.Anything, uint(1)).Return(ErrUserNotFound)
 			},
 			setupContext: func(c *gin.Context) {
@@ -925,7 +1131,7 @@ func TestHandler_DeleteUser(t *testing.T) {
 		{
 			name:   "service error",
 			userID: "1",
-			setupMocks: func(ms *MockService, mas *MockAuthService) {
+			setupMocks: func(ms *MockService, mas *testutil.MockAuthService) {
 				ms.On("DeleteUser", mock.Anything, uint(1)).Return(errors.New("failed to delete user"))
 			},
 			setupContext: func(c *gin.Context) {
@@ -940,7 +1146,7 @@ func TestHandler_DeleteUser(t *testing.T) {
 				assert.Equal(t, false, response["success"])
 				errorInfo, ok := response["error"].(map[string]interface{})
 				assert.True(t, ok, "error should be a map")
-				assert.Equal(t, "failed to delete user", errorInfo["details"])
+				assert.NotContains(t, errorInfo, "details", "internal error details are hidden outside debug mode")
 			},
 		},
 	}
@@ -948,7 +1154,7 @@ func TestHandler_DeleteUser(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			mockService := &MockService{}
-			mockAuthService := &MockAuthService{}
+			mockAuthService := &testutil.MockAuthService{}
 			tt.setupMocks(mockService, mockAuthService)
 
 			handler := NewHandler(mockService, mockAuthService)
@@ -977,23 +1183,49 @@ func TestHandler_DeleteUser(t *testing.T) {
 func TestHandler_GetMe(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
+	lastLogin := time.Date(2026, 2, 20, 8, 30, 0, 0, time.UTC)
+
 	tests := []struct {
-		name           string
-		userID         uint
-		setupMocks     func(*MockService)
-		expectedStatus int
+		name              string
+		userID            uint
+		setupMocks        func(*MockService)
+		expectedStatus    int
+		expectedLastLogin string
+		expectedCode      string
 	}{
 		{
 			name:   "successful get current user",
 			userID: 1,
 			setupMocks: func(ms *MockService) {
 				ms.On("GetUserByID", mock.Anything, uint(1)).Return(&User{
-					ID:    1,
-					Name:  "John Doe",
-					Email: "john@example.com",
+					ID:     1,
+					Name:   "John Doe",
+					Email:  "john@example.com",
+					Active: true,
 				}, nil)
+				ms.On("GetLastLoginAt", mock.Anything, uint(1)).Return(&lastLogin, nil)
 			},
-			expectedStatus: http.StatusOK,
+			expectedStatus:    http.StatusOK,
+			expectedLastLogin: `"last_login_at":"2026-02-20T08:30:00Z"`,
+		},
+		{
+			name:   "no login on record",
+			userID: 1,
+			setupMocks: func(ms *MockService) {
+				ms.On("GetUserByID", mock.Anything, uint(1)).Return(&User{ID: 1, Name: "John Doe", Email: "john@example.com", Active: true}, nil)
+				ms.On("GetLastLoginAt", mock.Anything, uint(1)).Return(nil, nil)
+			},
+			expectedStatus:    http.StatusOK,
+			expectedLastLogin: `"last_login_at":null`,
+		},
+		{
+			name:   "account disabled",
+			userID: 1,
+			setupMocks: func(ms *MockService) {
+				ms.On("GetUserByID", mock.Anything, uint(1)).Return(&User{ID: 1, Name: "John Doe", Email: "john@example.com"}, nil)
+			},
+			expectedStatus: http.StatusForbidden,
+			expectedCode:   apiErrors.CodeAccountDisabled,
 		},
 		{
 			name:   "user not authenticated",
@@ -1023,7 +1255,7 @@ func TestHandler_GetMe(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			mockService := new(MockService)
-			mockAuthService := new(MockAuthService)
+			mockAuthService := new(testutil.MockAuthService)
 			handler := NewHandler(mockService, mockAuthService)
 
 			tt.setupMocks(mockService)
@@ -1045,122 +1277,726 @@ func TestHandler_GetMe(t *testing.T) {
 			apiErrors.ErrorHandler()(c)
 
 			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedLastLogin != "" {
+				assert.Contains(t, w.Body.String(), tt.expectedLastLogin)
+				openapitest.AssertResponse(t, http.MethodGet, "/api/v1/auth/me", w)
+			}
+			if tt.expectedCode != "" {
+				assert.Contains(t, w.Body.String(), tt.expectedCode)
+				openapitest.AssertResponse(t, http.MethodGet, "/api/v1/auth/me", w)
+			}
 			mockService.AssertExpectations(t)
 		})
 	}
 }
 
-func TestHandler_ListUsers(t *testing.T) {
+func TestHandler_GetMe_DatabaseUnavailable(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db := testutil.NewSQLiteDB(t)
+	sqlDB, _ := db.DB()
+	_ = sqlDB.Close()
+
+	handler := NewHandler(NewService(NewRepository(db), newTestSecurityConfig()), new(testutil.MockAuthService))
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/auth/me", nil)
+	c.Set(auth.KeyUser, &auth.Claims{UserID: 1, Email: "test@example.com"})
+
+	handler.GetMe(c)
+	apiErrors.ErrorHandler()(c)
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Contains(t, w.Body.String(), apiErrors.CodeServiceUnavailable)
+	assert.NotContains(t, w.Body.String(), "database is closed")
+	assert.NotContains(t, w.Body.String(), `"details"`)
+}
+
+func TestHandler_InternalErrorDetails(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name        string
+		debug       bool
+		wantDetails bool
+	}{
+		{name: "redacted in production mode", debug: false, wantDetails: false},
+		{name: "verbose in debug mode", debug: true, wantDetails: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			apiErrors.SetDebug(tt.debug)
+			t.Cleanup(func() { apiErrors.SetDebug(false) })
+
+			mockService := new(MockService)
+			mockService.On("GetUserByID", mock.Anything, uint(1)).Return(nil, errors.New("pq: relation \"users\" does not exist"))
+			handler := NewHandler(mockService, new(testutil.MockAuthService))
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/users/1", nil)
+			c.Params = gin.Params{{Key: "id", Value: "1"}}
+			c.Set(auth.KeyUser, &auth.Claims{UserID: 1, Email: "john@example.com"})
+
+			handler.GetUser(c)
+			apiErrors.ErrorHandler()(c)
+
+			assert.Equal(t, http.StatusInternalServerError, w.Code)
+			assert.Contains(t, w.Body.String(), apiErrors.CodeInternal)
+			if tt.wantDetails {
+				assert.Contains(t, w.Body.String(), `relation \"users\" does not exist`)
+			} else {
+				assert.NotContains(t, w.Body.String(), "relation")
+				assert.NotContains(t, w.Body.String(), `"details"`)
+			}
+		})
+	}
+}
+
+func TestHandler_GetLoginHistory(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
+	userID := uint(7)
+	attempts := []LoginAttempt{
+		{UserID: &userID, IP: "203.0.113.5", UserAgent: "curl/8.0", Outcome: LoginOutcomeSuccess, CreatedAt: time.Date(2026, 2, 20, 9, 0, 0, 0, time.UTC)},
+		{UserID: &userID, IP: "198.51.100.7", UserAgent: "Mozilla/5.0", Outcome: LoginOutcomeInvalidCredentials, CreatedAt: time.Date(2026, 2, 20, 8, 0, 0, 0, time.UTC)},
+		{UserID: &userID, IP: "198.51.100.7", UserAgent: "Mozilla/5.0", Outcome: LoginOutcomeInvalidCredentials, CreatedAt: time.Date(2026, 2, 20, 7, 0, 0, 0, time.UTC)},
+	}
+
 	tests := []struct {
 		name           string
-		queryParams    string
+		userID         uint
+		query          string
 		setupMocks     func(*MockService)
 		expectedStatus int
-		checkResponse  func(*testing.T, *httptest.ResponseRecorder)
+		expectedCount  int
+		expectedNext   bool
 	}{
 		{
-			name:        "successful list with defaults",
-			queryParams: "",
-			setupMocks: func(ms *MockService) {
-				users := []User{
-					{ID: 1, Name: "User 1", Email: "user1@example.com"},
-					{ID: 2, Name: "User 2", Email: "user2@example.com"},
-				}
-				ms.On("ListUsers", mock.Anything, mock.MatchedBy(func(f UserFilterParams) bool {
-					return f.Sort == "created_at" && f.Order == "desc"
-				}), 1, 20).Return(users, int64(2), nil)
-			},
-			expectedStatus: http.StatusOK,
-			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
-				var response map[string]interface{}
-				err := json.Unmarshal(w.Body.Bytes(), &response)
-				assert.NoError(t, err)
-				assert.True(t, response["success"].(bool))
-				data := response["data"].(map[string]interface{})
-				assert.Equal(t, float64(2), data["total"])
-			},
-		},
-		{
-			name:        "list with role filter",
-			queryParams: "?role=admin&page=1&per_page=10",
+			name:   "first page with more attempts",
+			userID: userID,
+			query:  "?per_page=2",
 			setupMocks: func(ms *MockService) {
-				users := []User{
-					{ID: 1, Name: "Admin User", Email: "admin@example.com"},
-				}
-				ms.On("ListUsers", mock.Anything, mock.MatchedBy(func(f UserFilterParams) bool {
-					return f.Role == "admin"
-				}), 1, 10).Return(users, int64(1), nil)
+				ms.On("ListLoginHistory", mock.Anything, userID, 3, 0).Return(attempts, nil)
 			},
 			expectedStatus: http.StatusOK,
-			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
-				var response map[string]interface{}
-				err := json.Unmarshal(w.Body.Bytes(), &response)
-				assert.NoError(t, err)
-				data := response["data"].(map[string]interface{})
-				assert.Equal(t, float64(1), data["total"])
-			},
+			expectedCount:  2,
+			expectedNext:   true,
 		},
 		{
-			name:        "list with search",
-			queryParams: "?search=john",
+			name:   "last page",
+			userID: userID,
+			query:  "?page=2&per_page=2",
 			setupMocks: func(ms *MockService) {
-				users := []User{
-					{ID: 1, Name: "John Doe", Email: "john@example.com"},
-				}
-				ms.On("ListUsers", mock.Anything, mock.MatchedBy(func(f UserFilterParams) bool {
-					return f.Search == "john"
-				}), 1, 20).Return(users, int64(1), nil)
+				ms.On("ListLoginHistory", mock.Anything, userID, 3, 2).Return(attempts[2:], nil)
 			},
 			expectedStatus: http.StatusOK,
-			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
-				var response map[string]interface{}
-				err := json.Unmarshal(w.Body.Bytes(), &response)
-				assert.NoError(t, err)
-			},
+			expectedCount:  1,
 		},
 		{
-			name:        "empty result set",
-			queryParams: "",
-			setupMocks: func(ms *MockService) {
-				ms.On("ListUsers", mock.Anything, mock.Anything, 1, 20).Return([]User{}, int64(0), nil)
-			},
-			expectedStatus: http.StatusOK,
-			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
-				var response map[string]interface{}
-				err := json.Unmarshal(w.Body.Bytes(), &response)
-				assert.NoError(t, err)
-				data := response["data"].(map[string]interface{})
-				assert.Equal(t, float64(0), data["total"])
-			},
+			name:           "not authenticated",
+			setupMocks:     func(ms *MockService) {},
+			expectedStatus: http.StatusUnauthorized,
 		},
 		{
-			name:        "service error",
-			queryParams: "",
+			name:   "service error",
+			userID: userID,
 			setupMocks: func(ms *MockService) {
-				ms.On("ListUsers", mock.Anything, mock.Anything, 1, 20).Return(nil, int64(0), errors.New("database error"))
+				ms.On("ListLoginHistory", mock.Anything, userID, 21, 0).Return(nil, errors.New("database error"))
 			},
 			expectedStatus: http.StatusInternalServerError,
-			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
-			},
 		},
-		{
-			name:        "invalid role filter",
-			queryParams: "",
-			setupMocks: func(ms *MockService) {
-				ms.On("ListUsers", mock.Anything, mock.Anything, 1, 20).Return(nil, int64(0), ErrInvalidRole)
-			},
-			expectedStatus: http.StatusBadRequest,
-			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockService)
+			handler := NewHandler(mockService, new(testutil.MockAuthService))
+			tt.setupMocks(mockService)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/auth/login-history"+tt.query, nil)
+			if tt.userID > 0 {
+				c.Set(auth.KeyUser, &auth.Claims{UserID: tt.userID})
+			}
+
+			handler.GetLoginHistory(c)
+			apiErrors.ErrorHandler()(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			openapitest.AssertResponse(t, http.MethodGet, "/api/v1/auth/login-history", w)
+			if tt.expectedStatus == http.StatusOK {
+				var resp struct {
+					Data LoginHistoryResponse `json:"data"`
+				}
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+				assert.Len(t, resp.Data.Attempts, tt.expectedCount)
+				assert.Equal(t, tt.expectedNext, resp.Data.HasNext)
+				assert.True(t, strings.HasSuffix(resp.Data.Attempts[0].CreatedAt, "Z"))
+				assert.NotContains(t, w.Body.String(), "email")
+			}
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandler_GetMyPermissions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		userID         uint
+		setupMocks     func(*MockService)
+		expectedStatus int
+		expected       *EffectivePermissions
+	}{
+		{
+			name:   "plain user",
+			userID: 1,
+			setupMocks: func(ms *MockService) {
+				ms.On("GetEffectivePermissions", mock.Anything, uint(1)).Return(&EffectivePermissions{
+					Roles:       []string{RoleUser},
+					Permissions: []string{},
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expected:       &EffectivePermissions{Roles: []string{RoleUser}, Permissions: []string{}},
+		},
+		{
+			name:   "admin",
+			userID: 2,
+			setupMocks: func(ms *MockService) {
+				ms.On("GetEffectivePermissions", mock.Anything, uint(2)).Return(&EffectivePermissions{
+					Roles:       []string{RoleAdmin, RoleUser},
+					Permissions: []string{PermissionRolesManage, PermissionStatsRead, PermissionUsersDelete, PermissionUsersRead, PermissionUsersWrite},
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expected: &EffectivePermissions{
+				Roles:       []string{RoleAdmin, RoleUser},
+				Permissions: []string{PermissionRolesManage, PermissionStatsRead, PermissionUsersDelete, PermissionUsersRead, PermissionUsersWrite},
+			},
+		},
+		{
+			name:           "user not authenticated",
+			setupMocks:     func(ms *MockService) {},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:   "user not found",
+			userID: 999,
+			setupMocks: func(ms *MockService) {
+				ms.On("GetEffectivePermissions", mock.Anything, uint(999)).Return(nil, ErrUserNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockService)
+			handler := NewHandler(mockService, new(testutil.MockAuthService))
+			tt.setupMocks(mockService)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/auth/me/permissions", nil)
+			if tt.userID > 0 {
+				c.Set(auth.KeyUser, &auth.Claims{UserID: tt.userID})
+			}
+
+			handler.GetMyPermissions(c)
+			apiErrors.ErrorHandler()(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expected != nil {
+				var response struct {
+					Data EffectivePermissions `json:"data"`
+				}
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, *tt.expected, response.Data)
+			}
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandler_UpdateMe(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		userID         uint
+		requestBody    interface{}
+		setupMocks     func(*MockService)
+		expectedStatus int
+		checkResponse  func(*testing.T, *httptest.ResponseRecorder)
+	}{
+		{
+			name:        "partial name-only update",
+			userID:      1,
+			requestBody: map[string]string{"name": "John Updated"},
+			setupMocks: func(ms *MockService) {
+				ms.On("PatchUser", mock.Anything, uint(1), mock.MatchedBy(func(req PatchUserRequest) bool {
+					return req.Name != nil && *req.Name == "John Updated" && req.Email == nil
+				})).Return(&User{
+					ID:    1,
+					Name:  "John Updated",
+					Email: "john@example.com",
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				var response map[string]interface{}
+				err := json.Unmarshal(w.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				data, ok := response["data"].(map[string]interface{})
+				assert.True(t, ok, "data should be a map")
+				assert.Equal(t, "John Updated", data["name"])
+				assert.Equal(t, "john@example.com", data["email"])
+			},
+		},
+		{
+			name:        "email conflict",
+			userID:      1,
+			requestBody: map[string]string{"email": "taken@example.com"},
+			setupMocks: func(ms *MockService) {
+				ms.On("PatchUser", mock.Anything, uint(1), mock.MatchedBy(func(req PatchUserRequest) bool {
+					return req.Name == nil && req.Email != nil && *req.Email == "taken@example.com"
+				})).Return(nil, ErrEmailExists)
+			},
+			expectedStatus: http.StatusConflict,
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				var response map[string]interface{}
+				err := json.Unmarshal(w.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				errorInfo, ok := response["error"].(map[string]interface{})
+				assert.True(t, ok, "error should be a map")
+				assert.Equal(t, "CONFLICT", errorInfo["code"])
+			},
+		},
+		{
+			name:           "user not authenticated",
+			userID:         0,
+			requestBody:    map[string]string{"name": "John Updated"},
+			setupMocks:     func(ms *MockService) {},
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockService)
+			mockAuthService := new(testutil.MockAuthService)
+			handler := NewHandler(mockService, mockAuthService)
+
+			tt.setupMocks(mockService)
+
+			body, _ := json.Marshal(tt.requestBody)
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPatch, "/api/v1/auth/me", bytes.NewBuffer(body))
+			c.Request.Header.Set("Content-Type", "application/json")
+
+			if tt.userID > 0 {
+				c.Set(auth.KeyUser, &auth.Claims{UserID: tt.userID})
+			}
+
+			handler.UpdateMe(c)
+			apiErrors.ErrorHandler()(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.checkResponse != nil {
+				tt.checkResponse(t, w)
+			}
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandler_DeleteMe(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		claims         *auth.Claims
+		requestBody    interface{}
+		setupMocks     func(*MockService, *testutil.MockAuthService)
+		expectedStatus int
+	}{
+		{
+			name:        "successful deletion revokes tokens first",
+			claims:      &auth.Claims{UserID: 1},
+			requestBody: map[string]string{"password": "CorrectPass123!"},
+			setupMocks: func(ms *MockService, mas *testutil.MockAuthService) {
+				ms.On("VerifyPassword", mock.Anything, uint(1), "CorrectPass123!").Return(nil)
+				revoke := mas.On("RevokeAllUserTokens", mock.Anything, uint(1)).Return(int64(1), nil)
+				ms.On("DeleteUser", mock.Anything, uint(1)).Return(nil).NotBefore(revoke)
+			},
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:        "wrong password",
+			claims:      &auth.Claims{UserID: 1},
+			requestBody: map[string]string{"password": "WrongPass123!"},
+			setupMocks: func(ms *MockService, mas *testutil.MockAuthService) {
+				ms.On("VerifyPassword", mock.Anything, uint(1), "WrongPass123!").Return(ErrInvalidCredentials)
+			},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "missing password",
+			claims:         &auth.Claims{UserID: 1},
+			requestBody:    map[string]string{},
+			setupMocks:     func(ms *MockService, mas *testutil.MockAuthService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "impersonation session",
+			claims:         &auth.Claims{UserID: 1, ImpersonatorID: 2},
+			requestBody:    map[string]string{"password": "CorrectPass123!"},
+			setupMocks:     func(ms *MockService, mas *testutil.MockAuthService) {},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "user not authenticated",
+			requestBody:    map[string]string{"password": "CorrectPass123!"},
+			setupMocks:     func(ms *MockService, mas *testutil.MockAuthService) {},
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockService)
+			mockAuthService := new(testutil.MockAuthService)
+			handler := NewHandler(mockService, mockAuthService)
+
+			tt.setupMocks(mockService, mockAuthService)
+
+			body, _ := json.Marshal(tt.requestBody)
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodDelete, "/api/v1/auth/me", bytes.NewBuffer(body))
+			c.Request.Header.Set("Content-Type", "application/json")
+
+			if tt.claims != nil {
+				c.Set(auth.KeyUser, tt.claims)
+			}
+
+			handler.DeleteMe(c)
+			apiErrors.ErrorHandler()(c)
+			c.Writer.WriteHeaderNow()
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			mockService.AssertExpectations(t)
+			mockAuthService.AssertExpectations(t)
+			if tt.expectedStatus != http.StatusNoContent {
+				mockService.AssertNotCalled(t, "DeleteUser", mock.Anything, mock.Anything)
+				mockAuthService.AssertNotCalled(t, "RevokeAllUserTokens", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestHandler_ChangePassword(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	validBody := map[string]string{"current_password": "CorrectPass123!", "new_password": "NewPass456!"}
+	tests := []struct {
+		name           string
+		claims         *auth.Claims
+		requestBody    interface{}
+		setupMocks     func(*MockService, *testutil.MockAuthService)
+		expectedStatus int
+		expectedField  string
+	}{
+		{
+			name:        "successful change revokes tokens",
+			claims:      &auth.Claims{UserID: 1},
+			requestBody: validBody,
+			setupMocks: func(ms *MockService, mas *testutil.MockAuthService) {
+				change := ms.On("ChangePassword", mock.Anything, uint(1), "CorrectPass123!", "NewPass456!").Return(nil)
+				mas.On("RevokeAllUserTokens", mock.Anything, uint(1)).Return(int64(2), nil).NotBefore(change)
+			},
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:        "wrong current password",
+			claims:      &auth.Claims{UserID: 1},
+			requestBody: validBody,
+			setupMocks: func(ms *MockService, mas *testutil.MockAuthService) {
+				ms.On("ChangePassword", mock.Anything, uint(1), "CorrectPass123!", "NewPass456!").Return(ErrInvalidCredentials)
+			},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:        "recently used password",
+			claims:      &auth.Claims{UserID: 1},
+			requestBody: validBody,
+			setupMocks: func(ms *MockService, mas *testutil.MockAuthService) {
+				ms.On("ChangePassword", mock.Anything, uint(1), "CorrectPass123!", "NewPass456!").Return(ErrPasswordReused)
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedField:  "must differ from recently used passwords",
+		},
+		{
+			name:        "weak password",
+			claims:      &auth.Claims{UserID: 1},
+			requestBody: validBody,
+			setupMocks: func(ms *MockService, mas *testutil.MockAuthService) {
+				ms.On("ChangePassword", mock.Anything, uint(1), "CorrectPass123!", "NewPass456!").
+					Return(fmt.Errorf("password validation failed: %w", ErrPasswordMissingSpecial))
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedField:  "must contain at least one special character",
+		},
+		{
+			name:           "missing new password",
+			claims:         &auth.Claims{UserID: 1},
+			requestBody:    map[string]string{"current_password": "CorrectPass123!"},
+			setupMocks:     func(ms *MockService, mas *testutil.MockAuthService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "impersonation session",
+			claims:         &auth.Claims{UserID: 1, ImpersonatorID: 2},
+			requestBody:    validBody,
+			setupMocks:     func(ms *MockService, mas *testutil.MockAuthService) {},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "user not authenticated",
+			requestBody:    validBody,
+			setupMocks:     func(ms *MockService, mas *testutil.MockAuthService) {},
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockService)
+			mockAuthService := new(testutil.MockAuthService)
+			handler := NewHandler(mockService, mockAuthService)
+
+			tt.setupMocks(mockService, mockAuthService)
+
+			body, _ := json.Marshal(tt.requestBody)
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPut, "/api/v1/auth/me/password", bytes.NewBuffer(body))
+			c.Request.Header.Set("Content-Type", "application/json")
+
+			if tt.claims != nil {
+				c.Set(auth.KeyUser, tt.claims)
+			}
+
+			handler.ChangePassword(c)
+			apiErrors.ErrorHandler()(c)
+			c.Writer.WriteHeaderNow()
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedField != "" {
+				var resp apiErrors.Response
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+				assert.Equal(t, tt.expectedField, resp.Error.Fields["new_password"])
+			}
+			mockService.AssertExpectations(t)
+			mockAuthService.AssertExpectations(t)
+			if tt.expectedStatus != http.StatusNoContent {
+				mockAuthService.AssertNotCalled(t, "RevokeAllUserTokens", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestHandler_ListUsers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		queryParams    string
+		setupMocks     func(*MockService)
+		expectedStatus int
+		checkResponse  func(*testing.T, *httptest.ResponseRecorder)
+	}{
+		{
+			name:        "successful list with defaults",
+			queryParams: "",
+			setupMocks: func(ms *MockService) {
+				users := []User{
+					{ID: 1, Name: "User 1", Email: "user1@example.com"},
+					{ID: 2, Name: "User 2", Email: "user2@example.com"},
+				}
+				ms.On("ListUsers", mock.Anything, mock.MatchedBy(func(f UserFilterParams) bool {
+					return f.Sort == "created_at" && f.Order == "desc"
+				}), 1, 20).Return(users, int64(2), nil)
+			},
+			expectedStatus: http.StatusOK,
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				var response map[string]interface{}
+				err := json.Unmarshal(w.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				assert.True(t, response["success"].(bool))
+				data := response["data"].(map[string]interface{})
+				assert.Equal(t, float64(2), data["total"])
+				assert.Equal(t, float64(1), data["total_pages"])
+				assert.Equal(t, false, data["has_next"])
+			},
+		},
+		{
+			name:        "list with role filter",
+			queryParams: "?role=admin&page=1&per_page=10",
+			setupMocks: func(ms *MockService) {
+				users := []User{
+					{ID: 1, Name: "Admin User", Email: "admin@example.com"},
+				}
+				ms.On("ListUsers", mock.Anything, mock.MatchedBy(func(f UserFilterParams) bool {
+					return f.Role == "admin"
+				}), 1, 10).Return(users, int64(1), nil)
+			},
+			expectedStatus: http.StatusOK,
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				var response map[string]interface{}
+				err := json.Unmarshal(w.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				data := response["data"].(map[string]interface{})
+				assert.Equal(t, float64(1), data["total"])
+			},
+		},
+		{
+			name:        "list with search",
+			queryParams: "?search=john",
+			setupMocks: func(ms *MockService) {
+				users := []User{
+					{ID: 1, Name: "John Doe", Email: "john@example.com"},
+				}
+				ms.On("ListUsers", mock.Anything, mock.MatchedBy(func(f UserFilterParams) bool {
+					return f.Search == "john"
+				}), 1, 20).Return(users, int64(1), nil)
+			},
+			expectedStatus: http.StatusOK,
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				var response map[string]interface{}
+				err := json.Unmarshal(w.Body.Bytes(), &response)
+				assert.NoError(t, err)
+			},
+		},
+		{
+			name:        "empty result set",
+			queryParams: "",
+			setupMocks: func(ms *MockService) {
+				ms.On("ListUsers", mock.Anything, mock.Anything, 1, 20).Return([]User{}, int64(0), nil)
+			},
+			expectedStatus: http.StatusOK,
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				var response map[string]interface{}
+				err := json.Unmarshal(w.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				data := response["data"].(map[string]interface{})
+				assert.Equal(t, float64(0), data["total"])
+			},
+		},
+		{
+			name:        "service error",
+			queryParams: "",
+			setupMocks: func(ms *MockService) {
+				ms.On("ListUsers", mock.Anything, mock.Anything, 1, 20).Return(nil, int64(0), errors.New("database error"))
+			},
+			expectedStatus: http.StatusInternalServerError,
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+			},
+		},
+		{
+			name:        "invalid role filter",
+			queryParams: "",
+			setupMocks: func(ms *MockService) {
+				ms.On("ListUsers", mock.Anything, mock.Anything, 1, 20).Return(nil, int64(0), ErrInvalidRole)
+			},
+			expectedStatus: http.StatusBadRequest,
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+			},
+		},
+		{
+			name:           "invalid sort field rejected before service call",
+			queryParams:    "?sort=password_hash",
+			setupMocks:     func(ms *MockService) {},
+			expectedStatus: http.StatusBadRequest,
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				var response map[string]interface{}
+				err := json.Unmarshal(w.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				errorInfo := response["error"].(map[string]interface{})
+				assert.Contains(t, errorInfo["message"], "role")
+			},
+		},
+		{
+			name:        "count none omits totals and reports next page",
+			queryParams: "?count=none&per_page=2",
+			setupMocks: func(ms *MockService) {
+				users := []User{
+					{ID: 1, Name: "User 1", Email: "user1@example.com"},
+					{ID: 2, Name: "User 2", Email: "user2@example.com"},
+					{ID: 3, Name: "User 3", Email: "user3@example.com"},
+				}
+				ms.On("ListUsers", mock.Anything, mock.MatchedBy(func(f UserFilterParams) bool {
+					return f.CountMode == CountNone
+				}), 1, 2).Return(users, TotalUnknown, nil)
 			},
+			expectedStatus: http.StatusOK,
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				var response map[string]interface{}
+				err := json.Unmarshal(w.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				data := response["data"].(map[string]interface{})
+				assert.NotContains(t, data, "total")
+				assert.NotContains(t, data, "total_pages")
+				assert.Equal(t, true, data["has_next"])
+				assert.Len(t, data["users"], 2)
+			},
+		},
+		{
+			name:        "count none on last page",
+			queryParams: "?count=none&per_page=2",
+			setupMocks: func(ms *MockService) {
+				users := []User{{ID: 1, Name: "User 1", Email: "user1@example.com"}}
+				ms.On("ListUsers", mock.Anything, mock.Anything, 1, 2).Return(users, TotalUnknown, nil)
+			},
+			expectedStatus: http.StatusOK,
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				var response map[string]interface{}
+				err := json.Unmarshal(w.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				data := response["data"].(map[string]interface{})
+				assert.Equal(t, false, data["has_next"])
+				assert.Len(t, data["users"], 1)
+			},
+		},
+		{
+			name:           "invalid count mode",
+			queryParams:    "?count=approx",
+			setupMocks:     func(ms *MockService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:        "sort by role",
+			queryParams: "?sort=role&order=asc",
+			setupMocks: func(ms *MockService) {
+				ms.On("ListUsers", mock.Anything, mock.MatchedBy(func(f UserFilterParams) bool {
+					return f.Sort == "role" && f.Order == "asc"
+				}), 1, 20).Return([]User{}, int64(0), nil)
+			},
+			expectedStatus: http.StatusOK,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			mockService := new(MockService)
-			mockAuthService := new(MockAuthService)
+			mockAuthService := new(testutil.MockAuthService)
 			handler := NewHandler(mockService, mockAuthService)
 
 			tt.setupMocks(mockService)
@@ -1169,6 +2005,7 @@ func TestHandler_ListUsers(t *testing.T) {
 			c, _ := gin.CreateTestContext(w)
 			req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/users"+tt.queryParams, nil)
 			c.Request = req
+			c.Set(auth.KeyUser, &auth.Claims{UserID: 99, Permissions: []string{PermissionUsersRead}})
 
 			handler.ListUsers(c)
 			apiErrors.ErrorHandler()(c)
@@ -1177,7 +2014,362 @@ func TestHandler_ListUsers(t *testing.T) {
 			if tt.checkResponse != nil {
 				tt.checkResponse(t, w)
 			}
+			openapitest.AssertResponse(t, http.MethodGet, "/api/v1/admin/users", w)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+// decodeUsers returns the users array of a list or search response as raw JSON objects
+func decodeUsers(t *testing.T, w *httptest.ResponseRecorder) []map[string]interface{} {
+	t.Helper()
+	var response struct {
+		Data struct {
+			Users []map[string]interface{} `json:"users"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	return response.Data.Users
+}
+
+func TestHandler_ListUsers_ShapeByPermission(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	users := []User{{ID: 1, Name: "User 1", Email: "user1@example.com", PendingEmail: "new1@example.com"}}
+
+	list := func(claims *auth.Claims) *httptest.ResponseRecorder {
+		mockService := new(MockService)
+		mockService.On("ListUsers", mock.Anything, mock.Anything, 1, 20).Return(users, int64(1), nil)
+		handler := NewHandler(mockService, new(testutil.MockAuthService))
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/admin/users", nil)
+		c.Set(auth.KeyUser, claims)
+		handler.ListUsers(c)
+		require.Equal(t, http.StatusOK, w.Code)
+		return w
+	}
+
+	t.Run("users:read gets the full shape", func(t *testing.T) {
+		got := decodeUsers(t, list(&auth.Claims{UserID: 99, Roles: []string{"support"}, Permissions: []string{PermissionUsersRead}}))
+		require.Len(t, got, 1)
+		assert.Equal(t, "user1@example.com", got[0]["email"])
+		assert.Equal(t, "new1@example.com", got[0]["pending_email"])
+	})
+
+	t.Run("others get the public shape", func(t *testing.T) {
+		w := list(&auth.Claims{UserID: 99, Roles: []string{RoleUser}})
+		got := decodeUsers(t, w)
+		require.Len(t, got, 1)
+		assert.Equal(t, map[string]interface{}{"id": float64(1), "name": "User 1"}, got[0])
+		assert.NotContains(t, got[0], "email", "email must be absent, not empty")
+		assert.NotContains(t, w.Body.String(), "example.com")
+
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, float64(1), response["data"].(map[string]interface{})["total"], "pagination is kept")
+	})
+}
+
+func TestHandler_SearchUsers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	newRouter := func(ms *MockService) *gin.Engine {
+		handler := NewHandler(ms, new(testutil.MockAuthService))
+		// Admins search too, and still get the public shape
+		authenticate := func(c *gin.Context) {
+			c.Set(auth.KeyUser, &auth.Claims{UserID: 1, Roles: []string{RoleAdmin}})
+		}
+		router := testutil.NewTestRouter(t)
+		router.GET("/api/v1/users/search", middleware.APIVersion(contextutil.APIVersionV1), authenticate, handler.SearchUsers)
+		router.GET("/api/v2/users/search", middleware.APIVersion(contextutil.APIVersionV2), authenticate, handler.SearchUsers)
+		return router
+	}
+	search := func(router *gin.Engine, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	t.Run("returns public shapes", func(t *testing.T) {
+		ms := new(MockService)
+		ms.On("ListUsers", mock.Anything, mock.MatchedBy(func(f UserFilterParams) bool {
+			return f.Search == "jan" && f.NameOnly && f.Status == UserStatusActive && f.Sort == "name" && f.Order == "asc" && f.CountMode == CountNone
+		}), 1, userSearchLimit).Return([]User{
+			{ID: 7, Name: "Jane Doe", Email: "jane@example.com"},
+			{ID: 8, Name: "Janet Roe", Email: "janet@example.com"},
+		}, TotalUnknown, nil)
+		router := newRouter(ms)
+
+		w := search(router, "/api/v1/users/search?q=+jan+")
+		require.Equal(t, http.StatusOK, w.Code)
+		got := decodeUsers(t, w)
+		require.Len(t, got, 2)
+		assert.Equal(t, map[string]interface{}{"id": float64(7), "name": "Jane Doe"}, got[0])
+		for _, u := range got {
+			assert.NotContains(t, u, "email", "email must be absent, not empty")
+		}
+		assert.NotContains(t, w.Body.String(), "example.com")
+		openapitest.AssertResponse(t, http.MethodGet, "/api/v1/users/search", w)
+
+		w = search(router, "/api/v2/users/search?q=jan")
+		require.Equal(t, http.StatusOK, w.Code)
+		got = decodeUsers(t, w)
+		require.Len(t, got, 2)
+		assert.Equal(t, map[string]interface{}{"user_id": float64(7), "name": "Jane Doe"}, got[0])
+		ms.AssertExpectations(t)
+	})
+
+	t.Run("caps results", func(t *testing.T) {
+		users := make([]User, userSearchLimit+1)
+		for i := range users {
+			users[i] = User{ID: uint(i + 1), Name: "Jan"}
+		}
+		ms := new(MockService)
+		ms.On("ListUsers", mock.Anything, mock.Anything, 1, userSearchLimit).Return(users, TotalUnknown, nil)
+
+		w := search(newRouter(ms), "/api/v1/users/search?q=jan")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Len(t, decodeUsers(t, w), userSearchLimit)
+	})
+
+	t.Run("invalid query", func(t *testing.T) {
+		ms := new(MockService)
+		router := newRouter(ms)
+
+		for _, path := range []string{
+			"/api/v1/users/search",
+			"/api/v1/users/search?q=++",
+			"/api/v1/users/search?q=" + strings.Repeat("a", maxUserSearchLength+1),
+		} {
+			assert.Equal(t, http.StatusBadRequest, search(router, path).Code, path)
+		}
+		ms.AssertNotCalled(t, "ListUsers", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("service error", func(t *testing.T) {
+		ms := new(MockService)
+		ms.On("ListUsers", mock.Anything, mock.Anything, 1, userSearchLimit).Return(nil, int64(0), errors.New("database error"))
+
+		assert.Equal(t, http.StatusInternalServerError, search(newRouter(ms), "/api/v1/users/search?q=jan").Code)
+	})
+}
+
+func TestHandler_GetStats(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		setupMocks     func(*MockService, *testutil.MockAuthService)
+		expectedStatus int
+		checkResponse  func(*testing.T, *httptest.ResponseRecorder)
+	}{
+		{
+			name: "successful stats",
+			setupMocks: func(ms *MockService, mas *testutil.MockAuthService) {
+				ms.On("GetUserStatistics", mock.Anything).Return(&UserStatistics{
+					TotalUsers:      120,
+					AdminUsers:      3,
+					NewUsersLast24h: 2,
+					NewUsersLast7d:  10,
+					NewUsersLast30d: 40,
+				}, nil)
+				mas.On("CountActiveSessions", mock.Anything).Return(int64(57), nil)
+			},
+			expectedStatus: http.StatusOK,
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				var response map[string]interface{}
+				err := json.Unmarshal(w.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				assert.True(t, response["success"].(bool))
+				assert.Equal(t, map[string]interface{}{
+					"total_users":        float64(120),
+					"admin_users":        float64(3),
+					"new_users_last_24h": float64(2),
+					"new_users_last_7d":  float64(10),
+					"new_users_last_30d": float64(40),
+					"active_sessions":    float64(57),
+				}, response["data"])
+			},
+		},
+		{
+			name: "user statistics error",
+			setupMocks: func(ms *MockService, mas *testutil.MockAuthService) {
+				ms.On("GetUserStatistics", mock.Anything).Return(nil, errors.New("database error"))
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+		{
+			name: "active sessions error",
+			setupMocks: func(ms *MockService, mas *testutil.MockAuthService) {
+				ms.On("GetUserStatistics", mock.Anything).Return(&UserStatistics{}, nil)
+				mas.On("CountActiveSessions", mock.Anything).Return(int64(0), errors.New("database error"))
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockService)
+			mockAuthService := new(testutil.MockAuthService)
+			handler := NewHandler(mockService, mockAuthService)
+
+			tt.setupMocks(mockService, mockAuthService)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/admin/stats", nil)
+
+			handler.GetStats(c)
+			apiErrors.ErrorHandler()(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.checkResponse != nil {
+				tt.checkResponse(t, w)
+			}
+			mockService.AssertExpectations(t)
+			mockAuthService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandler_Impersonate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	target := &User{ID: 5, Name: "Jane Doe", Email: "jane@example.com"}
+	expiresAt := time.Now().Add(15 * time.Minute)
+
+	tests := []struct {
+		name           string
+		userID         string
+		body           string
+		claims         *auth.Claims
+		setupMocks     func(*MockService, *testutil.MockAuthService)
+		expectedStatus int
+		checkResponse  func(*testing.T, *httptest.ResponseRecorder)
+	}{
+		{
+			name:   "successful impersonation returns no refresh token",
+			userID: "5",
+			body:   `{"reason":"ticket #42"}`,
+			claims: &auth.Claims{UserID: 1, Roles: []string{"admin"}},
+			setupMocks: func(ms *MockService, mas *testutil.MockAuthService) {
+				ms.On("GetUserByID", mock.Anything, uint(5)).Return(target, nil)
+				mas.On("GenerateImpersonationToken", mock.Anything, uint(1), uint(5), "jane@example.com", "Jane Doe", "ticket #42").
+					Return(&auth.ImpersonationToken{
+						AccessToken: "impersonation-token",
+						TokenType:   "Bearer",
+						ExpiresIn:   900,
+						ExpiresAt:   expiresAt,
+					}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				var response map[string]interface{}
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				data, ok := response["data"].(map[string]interface{})
+				assert.True(t, ok, "data should be a map")
+				assert.Equal(t, "impersonation-token", data["access_token"])
+				assert.Equal(t, float64(1), data["impersonator_id"])
+				assert.NotContains(t, data, "refresh_token")
+				user, ok := data["user"].(map[string]interface{})
+				assert.True(t, ok, "user should be a map")
+				assert.Equal(t, float64(5), user["id"])
+			},
+		},
+		{
+			name:           "reason is required",
+			userID:         "5",
+			body:           `{}`,
+			claims:         &auth.Claims{UserID: 1, Roles: []string{"admin"}},
+			setupMocks:     func(ms *MockService, mas *testutil.MockAuthService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "impersonating an admin is forbidden",
+			userID: "5",
+			body:   `{"reason":"ticket #42"}`,
+			claims: &auth.Claims{UserID: 1, Roles: []string{"admin"}},
+			setupMocks: func(ms *MockService, mas *testutil.MockAuthService) {
+				ms.On("GetUserByID", mock.Anything, uint(5)).Return(target, nil)
+				mas.On("GenerateImpersonationToken", mock.Anything, uint(1), uint(5), "jane@example.com", "Jane Doe", "ticket #42").
+					Return(nil, auth.ErrImpersonateAdmin)
+			},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "nested impersonation is forbidden",
+			userID:         "5",
+			body:           `{"reason":"ticket #42"}`,
+			claims:         &auth.Claims{UserID: 3, Roles: []string{"admin"}, ImpersonatorID: 1},
+			setupMocks:     func(ms *MockService, mas *testutil.MockAuthService) {},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:   "target not found",
+			userID: "5",
+			body:   `{"reason":"ticket #42"}`,
+			claims: &auth.Claims{UserID: 1, Roles: []string{"admin"}},
+			setupMocks: func(ms *MockService, mas *testutil.MockAuthService) {
+				ms.On("GetUserByID", mock.Anything, uint(5)).Return(nil, ErrUserNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &MockService{}
+			mockAuthService := &testutil.MockAuthService{}
+			tt.setupMocks(mockService, mockAuthService)
+
+			handler := NewHandler(mockService, mockAuthService)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPost, "/api/v1/admin/users/"+tt.userID+"/impersonate", bytes.NewBufferString(tt.body))
+			c.Request.Header.Set("Content-Type", "application/json")
+			c.Params = gin.Params{{Key: "id", Value: tt.userID}}
+			c.Set(auth.KeyUser, tt.claims)
+
+			handler.Impersonate(c)
+			apiErrors.ErrorHandler()(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.checkResponse != nil {
+				tt.checkResponse(t, w)
+			}
+
 			mockService.AssertExpectations(t)
+			mockAuthService.AssertExpectations(t)
 		})
 	}
 }
+
+func TestHandler_ListImpersonations(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockAuthService := &testutil.MockAuthService{}
+	mockAuthService.On("ListActiveImpersonations", mock.Anything).Return([]auth.ImpersonationGrant{
+		{ImpersonatorID: 1, TargetUserID: 5, Reason: "ticket #42", ExpiresAt: time.Now().Add(10 * time.Minute)},
+	}, nil)
+
+	handler := NewHandler(&MockService{}, mockAuthService)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/admin/impersonations", nil)
+
+	handler.ListImpersonations(c)
+	apiErrors.ErrorHandler()(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response map[string]interface{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	grants, ok := response["data"].([]interface{})
+	assert.True(t, ok, "data should be a list")
+	assert.Len(t, grants, 1)
+	mockAuthService.AssertExpectations(t)
+}
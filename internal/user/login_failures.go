@@ -0,0 +1,41 @@
+package user
+
+import (
+	"sync"
+	"time"
+
+	"github.com/hashicorp/golang-lru/v2/expirable"
+)
+
+const (
+	// repeatedLoginFailureThreshold 同一 IP 登录失败达到该次数后每次失败都输出 warn 日志
+	repeatedLoginFailureThreshold = 3
+	// loginFailureWindow 失败计数在最后一次失败后保留的时间
+	loginFailureWindow = 15 * time.Minute
+	// loginFailureCacheSize 最多跟踪的 IP 数量
+	loginFailureCacheSize = 10000
+)
+
+// loginFailureTracker 按客户端 IP 统计登录失败次数，用于发现跨账户的暴力破解
+// 只用于告警日志，不拦截请求；账户级别的锁定见 account_lockout.go
+type loginFailureTracker struct {
+	mu     sync.Mutex
+	counts *expirable.LRU[string, int]
+}
+
+func newLoginFailureTracker(window time.Duration) *loginFailureTracker {
+	return &loginFailureTracker{
+		counts: expirable.NewLRU[string, int](loginFailureCacheSize, nil, window),
+	}
+}
+
+// record 记录一次失败并返回该 IP 在窗口内的累计失败次数
+func (t *loginFailureTracker) record(ip string) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	count, _ := t.counts.Get(ip)
+	count++
+	t.counts.Add(ip, count)
+	return count
+}
@@ -0,0 +1,233 @@
+// Package user 提供登录历史记录功能
+package user
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yeegeek/uyou-go-api-starter/internal/auth"
+)
+
+// 登录尝试结果
+const (
+	LoginOutcomeSuccess            = "success"
+	LoginOutcomeInvalidCredentials = "invalid_credentials"
+	LoginOutcomeLocked             = "locked"
+	LoginOutcomeDisabled           = "disabled"
+)
+
+const (
+	// 登录历史写入队列的默认值
+	defaultLoginAttemptQueueSize = 1000
+	loginAttemptBatchSize        = 100
+	loginAttemptWriteTimeout     = 5 * time.Second
+	maxLoginAttemptUserAgent     = 512
+)
+
+var (
+	// ErrLoginAttemptQueueFull 登录历史写入队列已满，本次记录被丢弃
+	ErrLoginAttemptQueueFull = errors.New("login attempt queue is full")
+	// ErrLoginAttemptWriterClosed 登录历史写入器已关闭
+	ErrLoginAttemptWriterClosed = errors.New("login attempt writer is closed")
+)
+
+// LoginAttempt 一次密码登录尝试，成功和失败都会记录
+type LoginAttempt struct {
+	ID uint `gorm:"primaryKey"`
+	// UserID 尝试登录的账户，邮箱不存在时为 nil
+	UserID *uint `gorm:"index"`
+	// EmailHash 仅在邮箱不存在时填写：以进程级随机密钥计算的 HMAC，同一进程内可归并同一邮箱的尝试，但无法与真实用户关联
+	EmailHash string    `gorm:"size:64;not null;default:''"`
+	IP        string    `gorm:"size:45;not null;default:''"`
+	UserAgent string    `gorm:"size:512;not null;default:''"`
+	Outcome   string    `gorm:"size:32;not null"`
+	CreatedAt time.Time `gorm:"not null"`
+}
+
+// TableName 指定登录历史对应的数据库表名
+func (LoginAttempt) TableName() string {
+	return "login_attempts"
+}
+
+// LoginAttemptRecorder 记录登录尝试，实现不得阻塞登录请求
+type LoginAttemptRecorder interface {
+	Record(attempt LoginAttempt) error
+}
+
+type noopLoginAttemptRecorder struct{}
+
+func (noopLoginAttemptRecorder) Record(LoginAttempt) error { return nil }
+
+// WithLoginAttemptRecorder records every password login attempt through recorder,
+// typically a LoginAttemptWriter so the login request never waits on the insert
+func WithLoginAttemptRecorder(recorder LoginAttemptRecorder) ServiceOption {
+	return func(s *service) {
+		if recorder != nil {
+			s.loginAttempts = recorder
+		}
+	}
+}
+
+// LoginAttemptWriter 异步批量写入登录历史，实现 LoginAttemptRecorder
+// Record 只负责入队，单个协程从有界队列中取出记录批量写入；队列已满时丢弃记录并输出警告，登录不受影响
+type LoginAttemptWriter struct {
+	repo   Repository
+	queue  chan LoginAttempt
+	logger *slog.Logger
+
+	mu     sync.RWMutex
+	closed bool
+	done   chan struct{}
+}
+
+// NewLoginAttemptWriter 创建并启动登录历史写入器，queueSize <= 0 时使用默认队列长度
+func NewLoginAttemptWriter(repo Repository, queueSize int) *LoginAttemptWriter {
+	if queueSize <= 0 {
+		queueSize = defaultLoginAttemptQueueSize
+	}
+	w := &LoginAttemptWriter{
+		repo:   repo,
+		queue:  make(chan LoginAttempt, queueSize),
+		logger: slog.Default(),
+		done:   make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+// Record 将登录尝试加入写入队列，队列已满时立即返回 ErrLoginAttemptQueueFull
+func (w *LoginAttemptWriter) Record(attempt LoginAttempt) error {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if w.closed {
+		return ErrLoginAttemptWriterClosed
+	}
+	select {
+	case w.queue <- attempt:
+		return nil
+	default:
+		return ErrLoginAttemptQueueFull
+	}
+}
+
+// run 每次取出队列中已有的记录（最多 loginAttemptBatchSize 条）一次写入
+func (w *LoginAttemptWriter) run() {
+	defer close(w.done)
+	for attempt := range w.queue {
+		batch := []LoginAttempt{attempt}
+	drain:
+		for len(batch) < loginAttemptBatchSize {
+			select {
+			case next, ok := <-w.queue:
+				if !ok {
+					break drain
+				}
+				batch = append(batch, next)
+			default:
+				break drain
+			}
+		}
+		w.write(batch)
+	}
+}
+
+// write 请求上下文此时可能已结束，因此使用独立的上下文
+func (w *LoginAttemptWriter) write(batch []LoginAttempt) {
+	ctx, cancel := context.WithTimeout(context.Background(), loginAttemptWriteTimeout)
+	defer cancel()
+	if err := w.repo.CreateLoginAttempts(ctx, batch); err != nil {
+		w.logger.Warn("Failed to write login history", "attempts", len(batch), "error", err)
+	}
+}
+
+// Close 停止接收新记录并等待已入队的记录写入完成，ctx 结束时直接返回
+func (w *LoginAttemptWriter) Close(ctx context.Context) error {
+	w.mu.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.queue)
+	}
+	w.mu.Unlock()
+
+	select {
+	case <-w.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// LoginHistoryPruner 返回供清理任务调用的函数，删除早于 retention 的登录历史并返回删除的行数
+func LoginHistoryPruner(repo Repository, retention time.Duration) func(ctx context.Context) (int64, error) {
+	return func(ctx context.Context) (int64, error) {
+		return repo.DeleteLoginAttemptsBefore(ctx, time.Now().Add(-retention))
+	}
+}
+
+// ListLoginHistory returns the user's login attempts, most recent first
+func (s *service) ListLoginHistory(ctx context.Context, userID uint, limit, offset int) ([]LoginAttempt, error) {
+	attempts, err := s.repo.ListLoginAttempts(ctx, userID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list login history: %w", err)
+	}
+	return attempts, nil
+}
+
+// GetLastLoginAt returns the time of the user's latest successful password login, nil when none is recorded
+func (s *service) GetLastLoginAt(ctx context.Context, userID uint) (*time.Time, error) {
+	at, err := s.repo.FindLastSuccessfulLogin(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find last login: %w", err)
+	}
+	return at, nil
+}
+
+// recordLoginAttempt hands the attempt to the recorder without waiting for it to be stored.
+// userID is nil when the email matched no account; only then is the email kept, as an unlinkable hash.
+func (s *service) recordLoginAttempt(ctx context.Context, userID *uint, email, outcome string) {
+	client := auth.ClientInfoFromContext(ctx)
+	attempt := LoginAttempt{
+		UserID:    userID,
+		IP:        client.IP,
+		UserAgent: client.UserAgent,
+		Outcome:   outcome,
+		CreatedAt: time.Now(),
+	}
+	if len(attempt.UserAgent) > maxLoginAttemptUserAgent {
+		attempt.UserAgent = attempt.UserAgent[:maxLoginAttemptUserAgent]
+	}
+	if userID == nil {
+		attempt.EmailHash = hashUnknownEmail(email)
+	}
+
+	if err := s.loginAttempts.Record(attempt); err != nil {
+		slog.WarnContext(ctx, "Login attempt not recorded", "outcome", outcome, "error", err)
+	}
+}
+
+// unknownEmailKey 进程启动时随机生成且从不持久化，重启后同一邮箱的哈希也会变化
+var unknownEmailKey = func() []byte {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		panic(fmt.Sprintf("failed to generate login history key: %v", err))
+	}
+	return key
+}()
+
+// hashUnknownEmail returns a keyed hash of the normalized email
+func hashUnknownEmail(email string) string {
+	// WHY: A plain hash could be recomputed from a user's email later and tie earlier probes to the account
+	mac := hmac.New(sha256.New, unknownEmailKey)
+	mac.Write([]byte(strings.ToLower(strings.TrimSpace(email))))
+	return hex.EncodeToString(mac.Sum(nil))
+}
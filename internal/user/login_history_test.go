@@ -0,0 +1,111 @@
+package user
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yeegeek/uyou-go-api-starter/internal/auth"
+	"github.com/yeegeek/uyou-go-api-starter/internal/testutil"
+)
+
+type fakeLoginAttemptRecorder struct {
+	mu       sync.Mutex
+	attempts []LoginAttempt
+}
+
+func (f *fakeLoginAttemptRecorder) Record(attempt LoginAttempt) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.attempts = append(f.attempts, attempt)
+	return nil
+}
+
+func TestService_AuthenticateUser_RecordsLoginAttempts(t *testing.T) {
+	db := testutil.NewSQLiteDB(t)
+	recorder := &fakeLoginAttemptRecorder{}
+	service := NewService(NewRepository(db), newTestSecurityConfig(), WithLoginAttemptRecorder(recorder))
+	ctx := auth.WithClientInfo(context.Background(), auth.ClientInfo{IP: "203.0.113.5", UserAgent: "curl/8.0"})
+
+	credentials := LoginRequest{Email: "jane@example.com", Password: "Password123!"}
+	registered, err := service.RegisterUser(ctx, RegisterRequest{Name: "Jane Doe", Email: credentials.Email, Password: credentials.Password})
+	require.NoError(t, err)
+
+	_, err = service.AuthenticateUser(ctx, LoginRequest{Email: credentials.Email, Password: "WrongPassword1!"})
+	require.ErrorIs(t, err, ErrInvalidCredentials)
+	_, err = service.AuthenticateUser(ctx, credentials)
+	require.NoError(t, err)
+	_, err = service.AuthenticateUser(ctx, LoginRequest{Email: "nobody@example.com", Password: "Password123!"})
+	require.ErrorIs(t, err, ErrInvalidCredentials)
+
+	require.Len(t, recorder.attempts, 3)
+
+	failed := recorder.attempts[0]
+	require.NotNil(t, failed.UserID)
+	assert.Equal(t, registered.ID, *failed.UserID)
+	assert.Equal(t, LoginOutcomeInvalidCredentials, failed.Outcome)
+	assert.Equal(t, "203.0.113.5", failed.IP)
+	assert.Equal(t, "curl/8.0", failed.UserAgent)
+	assert.Empty(t, failed.EmailHash, "the email is not stored for known users")
+
+	assert.Equal(t, LoginOutcomeSuccess, recorder.attempts[1].Outcome)
+
+	unknown := recorder.attempts[2]
+	assert.Nil(t, unknown.UserID)
+	assert.Equal(t, LoginOutcomeInvalidCredentials, unknown.Outcome)
+	require.NotEmpty(t, unknown.EmailHash)
+	plain := sha256.Sum256([]byte("nobody@example.com"))
+	assert.NotEqual(t, hex.EncodeToString(plain[:]), unknown.EmailHash, "a plain hash could be recomputed from the email")
+	assert.Equal(t, hashUnknownEmail(" Nobody@Example.com "), unknown.EmailHash, "attempts against the same email share a hash within the process")
+}
+
+func TestLoginAttemptWriter_FlushesOnClose(t *testing.T) {
+	db := testutil.NewSQLiteDB(t)
+	repo := NewRepository(db)
+	writer := NewLoginAttemptWriter(repo, 0)
+
+	for i := 0; i < 5; i++ {
+		require.NoError(t, writer.Record(LoginAttempt{EmailHash: "abc", Outcome: LoginOutcomeInvalidCredentials, CreatedAt: time.Now()}))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, writer.Close(ctx))
+	require.NoError(t, writer.Close(ctx), "Close is idempotent")
+
+	var stored int64
+	require.NoError(t, db.Model(&LoginAttempt{}).Count(&stored).Error)
+	assert.Equal(t, int64(5), stored)
+
+	assert.ErrorIs(t, writer.Record(LoginAttempt{Outcome: LoginOutcomeSuccess}), ErrLoginAttemptWriterClosed)
+}
+
+func TestLoginAttemptWriter_DropsWhenQueueFull(t *testing.T) {
+	// 不启动写入协程，直接填满队列
+	writer := &LoginAttemptWriter{queue: make(chan LoginAttempt, 1), done: make(chan struct{})}
+
+	require.NoError(t, writer.Record(LoginAttempt{Outcome: LoginOutcomeSuccess}))
+	assert.ErrorIs(t, writer.Record(LoginAttempt{Outcome: LoginOutcomeSuccess}), ErrLoginAttemptQueueFull)
+}
+
+func TestLoginHistoryPruner(t *testing.T) {
+	db := testutil.NewSQLiteDB(t)
+	repo := NewRepository(db)
+	ctx := context.Background()
+
+	now := time.Now()
+	require.NoError(t, repo.CreateLoginAttempts(ctx, []LoginAttempt{
+		{EmailHash: "old", Outcome: LoginOutcomeInvalidCredentials, CreatedAt: now.Add(-31 * 24 * time.Hour)},
+		{EmailHash: "new", Outcome: LoginOutcomeInvalidCredentials, CreatedAt: now.Add(-time.Hour)},
+	}))
+
+	deleted, err := LoginHistoryPruner(repo, 30*24*time.Hour)(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+}
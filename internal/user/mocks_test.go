@@ -2,11 +2,15 @@ package user
 
 import (
 	"context"
+	"time"
 
 	"github.com/stretchr/testify/mock"
+
+	"github.com/yeegeek/uyou-go-api-starter/internal/oauth"
 )
 
 // MockService is a mock implementation of the user service for testing handlers
+// WHY: Tests inside this package cannot import testutil/usertest (import cycle); keep both copies in sync
 type MockService struct {
 	mock.Mock
 }
@@ -43,6 +47,35 @@ func (m *MockService) UpdateUser(ctx context.Context, id uint, req UpdateUserReq
 	return args.Get(0).(*User), args.Error(1)
 }
 
+func (m *MockService) PatchUser(ctx context.Context, id uint, req PatchUserRequest) (*User, error) {
+	args := m.Called(ctx, id, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*User), args.Error(1)
+}
+
+func (m *MockService) VerifyPassword(ctx context.Context, id uint, password string) error {
+	args := m.Called(ctx, id, password)
+	return args.Error(0)
+}
+
+func (m *MockService) LoginWithOAuth(ctx context.Context, provider string, profile *oauth.Profile) (*User, error) {
+	args := m.Called(ctx, provider, profile)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*User), args.Error(1)
+}
+
+func (m *MockService) GetEffectivePermissions(ctx context.Context, id uint) (*EffectivePermissions, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*EffectivePermissions), args.Error(1)
+}
+
 func (m *MockService) DeleteUser(ctx context.Context, id uint) error {
 	args := m.Called(ctx, id)
 	return args.Error(0)
@@ -61,6 +94,72 @@ func (m *MockService) PromoteToAdmin(ctx context.Context, userID uint) error {
 	return args.Error(0)
 }
 
+func (m *MockService) SetUserActive(ctx context.Context, id uint, active bool) (*User, error) {
+	args := m.Called(ctx, id, active)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*User), args.Error(1)
+}
+
+func (m *MockService) ResetPassword(ctx context.Context, id uint) (string, error) {
+	args := m.Called(ctx, id)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockService) ChangePassword(ctx context.Context, id uint, currentPassword, newPassword string) error {
+	args := m.Called(ctx, id, currentPassword, newPassword)
+	return args.Error(0)
+}
+
+func (m *MockService) GetLockoutStatus(ctx context.Context, id uint) (*LockoutStatus, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*LockoutStatus), args.Error(1)
+}
+
+func (m *MockService) ClearLockout(ctx context.Context, id uint) (*LockoutStatus, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*LockoutStatus), args.Error(1)
+}
+
+func (m *MockService) GetUserStatistics(ctx context.Context) (*UserStatistics, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*UserStatistics), args.Error(1)
+}
+
+func (m *MockService) ListLoginHistory(ctx context.Context, userID uint, limit, offset int) ([]LoginAttempt, error) {
+	args := m.Called(ctx, userID, limit, offset)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]LoginAttempt), args.Error(1)
+}
+
+func (m *MockService) GetLastLoginAt(ctx context.Context, userID uint) (*time.Time, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*time.Time), args.Error(1)
+}
+
+func (m *MockService) ConfirmEmailChange(ctx context.Context, token string) (*User, error) {
+	args := m.Called(ctx, token)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*User), args.Error(1)
+}
+
 // MockRepository is a mock implementation of the user repository for testing services
 type MockRepository struct {
 	mock.Mock
@@ -87,6 +186,32 @@ func (m *MockRepository) FindByID(ctx context.Context, id uint) (*User, error) {
 	return args.Get(0).(*User), args.Error(1)
 }
 
+func (m *MockRepository) FindPreferences(ctx context.Context, userID uint) ([]UserPreference, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]UserPreference), args.Error(1)
+}
+
+func (m *MockRepository) UpsertPreferences(ctx context.Context, prefs []UserPreference) error {
+	args := m.Called(ctx, prefs)
+	return args.Error(0)
+}
+
+func (m *MockRepository) PruneOrphanedRoleAssignments(ctx context.Context) (int, error) {
+	args := m.Called(ctx)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockRepository) FindByEmailChangeToken(ctx context.Context, tokenHash string) (*User, error) {
+	args := m.Called(ctx, tokenHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*User), args.Error(1)
+}
+
 func (m *MockRepository) Update(ctx context.Context, user *User) error {
 	args := m.Called(ctx, user)
 	return args.Error(0)
@@ -115,6 +240,118 @@ func (m *MockRepository) RemoveRole(ctx context.Context, userID uint, roleName s
 	return args.Error(0)
 }
 
+func (m *MockRepository) AssignRoleBulk(ctx context.Context, roleID uint, userIDs []uint) ([]uint, error) {
+	args := m.Called(ctx, roleID, userIDs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]uint), args.Error(1)
+}
+
+func (m *MockRepository) RemoveRoleBulk(ctx context.Context, roleID uint, userIDs []uint) ([]uint, error) {
+	args := m.Called(ctx, roleID, userIDs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]uint), args.Error(1)
+}
+
+func (m *MockRepository) FindExistingUserIDs(ctx context.Context, ids []uint) ([]uint, error) {
+	args := m.Called(ctx, ids)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]uint), args.Error(1)
+}
+
+func (m *MockRepository) SetActive(ctx context.Context, id uint, active bool) error {
+	args := m.Called(ctx, id, active)
+	return args.Error(0)
+}
+
+func (m *MockRepository) UpdatePassword(ctx context.Context, id uint, passwordHash string) error {
+	args := m.Called(ctx, id, passwordHash)
+	return args.Error(0)
+}
+
+func (m *MockRepository) ListPasswordHistory(ctx context.Context, userID uint, limit int) ([]string, error) {
+	args := m.Called(ctx, userID, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockRepository) RecordPasswordHistory(ctx context.Context, userID uint, passwordHash string, keep int) error {
+	args := m.Called(ctx, userID, passwordHash, keep)
+	return args.Error(0)
+}
+
+func (m *MockRepository) RecordLoginFailure(ctx context.Context, userID uint, at, pruneBefore time.Time) error {
+	args := m.Called(ctx, userID, at, pruneBefore)
+	return args.Error(0)
+}
+
+func (m *MockRepository) ListLoginFailures(ctx context.Context, userID uint, since time.Time) ([]time.Time, error) {
+	args := m.Called(ctx, userID, since)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]time.Time), args.Error(1)
+}
+
+func (m *MockRepository) ClearLoginFailures(ctx context.Context, userID uint) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockRepository) CreateLoginAttempts(ctx context.Context, attempts []LoginAttempt) error {
+	args := m.Called(ctx, attempts)
+	return args.Error(0)
+}
+
+func (m *MockRepository) ListLoginAttempts(ctx context.Context, userID uint, limit, offset int) ([]LoginAttempt, error) {
+	args := m.Called(ctx, userID, limit, offset)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]LoginAttempt), args.Error(1)
+}
+
+func (m *MockRepository) CreatePolicyAcceptances(ctx context.Context, acceptances []PolicyAcceptance) error {
+	args := m.Called(ctx, acceptances)
+	return args.Error(0)
+}
+
+func (m *MockRepository) FindPolicyAcceptances(ctx context.Context, userID uint) ([]PolicyAcceptance, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]PolicyAcceptance), args.Error(1)
+}
+
+func (m *MockRepository) ListPolicyAcceptances(ctx context.Context, filter PolicyAcceptanceFilter, page, perPage int) ([]PolicyAcceptance, int64, error) {
+	args := m.Called(ctx, filter, page, perPage)
+	if args.Get(0) == nil {
+		return nil, 0, args.Error(2)
+	}
+	return args.Get(0).([]PolicyAcceptance), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockRepository) FindLastSuccessfulLogin(ctx context.Context, userID uint) (*time.Time, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*time.Time), args.Error(1)
+}
+
+func (m *MockRepository) DeleteLoginAttemptsBefore(ctx context.Context, before time.Time) (int64, error) {
+	args := m.Called(ctx, before)
+	return args.Get(0).(int64), args.Error(1)
+}
+
 func (m *MockRepository) FindRoleByName(ctx context.Context, roleName string) (*Role, error) {
 	args := m.Called(ctx, roleName)
 	if args.Get(0) == nil {
@@ -131,7 +368,100 @@ func (m *MockRepository) GetUserRoles(ctx context.Context, userID uint) ([]Role,
 	return args.Get(0).([]Role), args.Error(1)
 }
 
+func (m *MockRepository) FindRoleByID(ctx context.Context, id uint) (*Role, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Role), args.Error(1)
+}
+
+func (m *MockRepository) CreateRole(ctx context.Context, role *Role) error {
+	args := m.Called(ctx, role)
+	return args.Error(0)
+}
+
+func (m *MockRepository) ListRoles(ctx context.Context) ([]Role, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]Role), args.Error(1)
+}
+
+func (m *MockRepository) UpdateRole(ctx context.Context, role *Role) error {
+	args := m.Called(ctx, role)
+	return args.Error(0)
+}
+
+func (m *MockRepository) DeleteRole(ctx context.Context, id uint) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockRepository) ListPermissions(ctx context.Context) ([]Permission, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]Permission), args.Error(1)
+}
+
+func (m *MockRepository) FindPermissionsByNames(ctx context.Context, names []string) ([]Permission, error) {
+	args := m.Called(ctx, names)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]Permission), args.Error(1)
+}
+
+func (m *MockRepository) SetRolePermissions(ctx context.Context, roleID uint, permissionIDs []uint) error {
+	args := m.Called(ctx, roleID, permissionIDs)
+	return args.Error(0)
+}
+
+func (m *MockRepository) CountUsers(ctx context.Context) (int64, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockRepository) CountUsersByRole(ctx context.Context, roleName string) (int64, error) {
+	args := m.Called(ctx, roleName)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockRepository) CountUsersSince(ctx context.Context, since time.Time) (int64, error) {
+	args := m.Called(ctx, since)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockRepository) FindIdentity(ctx context.Context, provider, subject string) (*UserIdentity, error) {
+	args := m.Called(ctx, provider, subject)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*UserIdentity), args.Error(1)
+}
+
+func (m *MockRepository) CreateIdentity(ctx context.Context, identity *UserIdentity) error {
+	args := m.Called(ctx, identity)
+	return args.Error(0)
+}
+
 func (m *MockRepository) Transaction(ctx context.Context, fn func(context.Context) error) error {
 	// Execute the transaction function directly for testing
 	return fn(ctx)
 }
+
+// MockRoleCacheInvalidator is a mock implementation of RoleCacheInvalidator
+type MockRoleCacheInvalidator struct {
+	mock.Mock
+}
+
+func (m *MockRoleCacheInvalidator) InvalidateUserRoles(userID uint) {
+	m.Called(userID)
+}
+
+func (m *MockRoleCacheInvalidator) InvalidateAllRoles() {
+	m.Called()
+}
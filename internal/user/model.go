@@ -11,8 +11,11 @@ import (
 type User struct {
 	ID             uint           `gorm:"primaryKey" json:"id"`                      // 用户唯一标识
 	Name           string         `gorm:"not null" json:"name"`                      // 用户姓名
-	Username       string         `gorm:"uniqueIndex" json:"username,omitempty"`     // 用户名（唯一）
+	Username       *string        `gorm:"uniqueIndex" json:"username,omitempty"`     // 用户名（唯一），未设置时为 NULL，避免多个空字符串违反唯一约束
 	Email          string         `gorm:"uniqueIndex;not null" json:"email"`         // 用户邮箱（唯一）
+	PendingEmail   string         `gorm:"column:pending_email" json:"-"`            // 待验证的新邮箱，确认前登录仍使用 Email
+	EmailChangeTokenHash string   `gorm:"column:email_change_token_hash;index" json:"-"` // 邮箱变更验证令牌的 SHA-256 哈希
+	EmailChangeExpiresAt *time.Time `gorm:"column:email_change_expires_at" json:"-"`   // 邮箱变更验证令牌过期时间
 	Phone          string         `gorm:"index" json:"phone,omitempty"`              // 手机号
 	PasswordHash   string         `gorm:"not null" json:"-"`                         // 密码哈希（不返回给客户端）
 	AvatarURL      string         `json:"avatar_url,omitempty"`                       // 头像URL
@@ -27,19 +30,43 @@ type User struct {
 	IsOnline       bool           `gorm:"column:is_online;default:false" json:"is_online"`            // 是否在线
 	LastActiveAt   *time.Time     `gorm:"column:last_active_at" json:"last_active_at,omitempty"`                  // 最后活跃时间
 	Status         string         `gorm:"default:active" json:"status"`              // 用户状态
+	Active         bool           `gorm:"not null;default:true" json:"active"`       // 是否启用（管理员停用后无法登录）
 	Coins          int            `gorm:"default:0" json:"coins"`                    // 虚拟货币余额
-	Fingerprint    string         `json:"-"`                       // 设备指纹
+	Fingerprint    *string        `json:"-"`                       // 设备指纹（JSONB），未记录时为 NULL，空字符串不是合法 JSON
+	TokenVersion   int            `gorm:"column:token_version;default:0" json:"-"`  // 令牌版本（角色变更时递增，用于使旧访问令牌失效）
 	Roles          []Role         `gorm:"many2many:user_roles;" json:"-"`            // 用户角色列表（多对多关系）
 	CreatedAt      time.Time      `json:"created_at"`                                // 创建时间
 	UpdatedAt      time.Time      `json:"updated_at"`                                // 更新时间
 	DeletedAt      gorm.DeletedAt `gorm:"index" json:"-"`                            // 软删除时间
 }
 
+// 账户状态，status 列与 active 列同步维护；认证以 active 为准，停用与软删除相互独立
+const (
+	UserStatusActive   = "active"   // 正常
+	UserStatusDisabled = "disabled" // 管理员停用，可重新启用
+)
+
+// AccountStatus 根据 active 返回账户状态
+func (u *User) AccountStatus() string {
+	if u.Active {
+		return UserStatusActive
+	}
+	return UserStatusDisabled
+}
+
 // TableName 指定用户模型对应的数据库表名
 func (User) TableName() string {
 	return "users"
 }
 
+// GetUsername 返回用户名，未设置时返回空字符串
+func (u *User) GetUsername() string {
+	if u.Username == nil {
+		return ""
+	}
+	return *u.Username
+}
+
 // HasRole 检查用户是否拥有指定角色
 func (u *User) HasRole(roleName string) bool {
 	for _, role := range u.Roles {
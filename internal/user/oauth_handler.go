@@ -0,0 +1,124 @@
+package user
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	apiErrors "github.com/yeegeek/uyou-go-api-starter/internal/errors"
+	"github.com/yeegeek/uyou-go-api-starter/internal/oauth"
+)
+
+// WithOAuthProviders enables social login through the given providers
+func WithOAuthProviders(providers oauth.Registry) HandlerOption {
+	return func(h *Handler) {
+		h.oauthProviders = providers
+	}
+}
+
+// OAuthLogin godoc
+// @Summary Start social login
+// @Description Redirect to the provider's consent page. A state cookie protects the callback against CSRF.
+// @Tags auth
+// @Param provider path string true "OAuth provider" Enums(google)
+// @Success 302 "Redirect to the provider"
+// @Failure 404 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Provider not enabled"
+// @Failure 500 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Failed to start login"
+// @Router /api/v1/auth/oauth/{provider}/login [get]
+func (h *Handler) OAuthLogin(c *gin.Context) {
+	provider, ok := h.oauthProviders.Get(c.Param("provider"))
+	if !ok {
+		_ = c.Error(apiErrors.NotFound("OAuth provider not found"))
+		return
+	}
+
+	state, err := oauth.NewState(c)
+	if err != nil {
+		_ = c.Error(apiErrors.InternalServerError(err))
+		return
+	}
+
+	c.Redirect(http.StatusFound, provider.AuthCodeURL(state))
+}
+
+// OAuthCallback godoc
+// @Summary Complete social login
+// @Description Exchange the authorization code, sign in the linked user (linking by verified email or creating a new user on first login) and return access and refresh tokens
+// @Tags auth
+// @Produce json
+// @Param provider path string true "OAuth provider" Enums(google)
+// @Param code query string true "Authorization code"
+// @Param state query string true "State issued by the login endpoint"
+// @Success 200 {object} errors.Response{success=bool,data=AuthResponse} "Success response with user data and tokens"
+// @Failure 400 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Invalid state or missing code"
+// @Failure 401 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Provider sign-in failed"
+// @Failure 403 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Provider email not verified or account disabled"
+// @Failure 404 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Provider not enabled"
+// @Failure 500 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Failed to sign in or generate token"
+// @Router /api/v1/auth/oauth/{provider}/callback [get]
+func (h *Handler) OAuthCallback(c *gin.Context) {
+	provider, ok := h.oauthProviders.Get(c.Param("provider"))
+	if !ok {
+		_ = c.Error(apiErrors.NotFound("OAuth provider not found"))
+		return
+	}
+
+	if !oauth.VerifyState(c) {
+		_ = c.Error(apiErrors.BadRequest("Invalid OAuth state"))
+		return
+	}
+	if c.Query("error") != "" {
+		_ = c.Error(apiErrors.Unauthorized("OAuth sign-in was not completed"))
+		return
+	}
+	code := c.Query("code")
+	if code == "" {
+		_ = c.Error(apiErrors.BadRequest("Authorization code is required"))
+		return
+	}
+
+	profile, err := provider.Exchange(c.Request.Context(), code)
+	if err != nil {
+		if errors.Is(err, oauth.ErrExchangeFailed) {
+			_ = c.Error(apiErrors.Unauthorized("OAuth sign-in failed"))
+			return
+		}
+		_ = c.Error(apiErrors.InternalServerError(err))
+		return
+	}
+
+	user, err := h.userService.LoginWithOAuth(c.Request.Context(), provider.Name(), profile)
+	if err != nil {
+		if errors.Is(err, ErrOAuthEmailNotVerified) {
+			_ = c.Error(apiErrors.Forbidden("Provider account email is not verified"))
+			return
+		}
+		if errors.Is(err, ErrAccountDisabled) {
+			_ = c.Error(apiErrors.AccountDisabled())
+			return
+		}
+		_ = c.Error(apiErrors.InternalServerError(err))
+		return
+	}
+
+	tokenPair, err := h.authService.GenerateTokenPair(clientContext(c), user.ID, user.Email, user.Name)
+	if err != nil {
+		_ = c.Error(apiErrors.InternalServerError(err))
+		return
+	}
+
+	refreshToken, err := h.deliverTokens(c, tokenPair, h.refreshCookie.WantsCookie(c))
+	if err != nil {
+		_ = c.Error(apiErrors.InternalServerError(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, apiErrors.Success(AuthResponse{
+		AccessToken:  tokenPair.AccessToken,
+		RefreshToken: refreshToken,
+		TokenType:    tokenPair.TokenType,
+		ExpiresIn:    tokenPair.ExpiresIn,
+		User:         ToUserResponse(user),
+	}))
+}
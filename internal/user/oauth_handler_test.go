@@ -0,0 +1,191 @@
+package user
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yeegeek/uyou-go-api-starter/internal/auth"
+	apiErrors "github.com/yeegeek/uyou-go-api-starter/internal/errors"
+	"github.com/yeegeek/uyou-go-api-starter/internal/oauth"
+	"github.com/yeegeek/uyou-go-api-starter/internal/testutil"
+)
+
+// fakeOAuthProvider returns the profile registered for each authorization code
+type fakeOAuthProvider struct {
+	profiles map[string]*oauth.Profile
+}
+
+func (p *fakeOAuthProvider) Name() string {
+	return "fake"
+}
+
+func (p *fakeOAuthProvider) AuthCodeURL(state string) string {
+	return "https://provider.example.com/authorize?state=" + url.QueryEscape(state)
+}
+
+func (p *fakeOAuthProvider) Exchange(ctx context.Context, code string) (*oauth.Profile, error) {
+	profile, ok := p.profiles[code]
+	if !ok {
+		return nil, oauth.ErrExchangeFailed
+	}
+	return profile, nil
+}
+
+func TestHandler_OAuthLogin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	registry := oauth.Registry{}
+	registry.Register(&fakeOAuthProvider{})
+	handler := NewHandler(new(MockService), new(testutil.MockAuthService), WithOAuthProviders(registry))
+
+	t.Run("redirects with state cookie", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/auth/oauth/fake/login", nil)
+		c.Params = gin.Params{{Key: "provider", Value: "fake"}}
+
+		handler.OAuthLogin(c)
+
+		assert.Equal(t, http.StatusFound, w.Code)
+		location, err := url.Parse(w.Header().Get("Location"))
+		require.NoError(t, err)
+
+		var stateCookie *http.Cookie
+		for _, cookie := range w.Result().Cookies() {
+			if cookie.Name == oauth.StateCookieName {
+				stateCookie = cookie
+			}
+		}
+		require.NotNil(t, stateCookie)
+		assert.True(t, stateCookie.HttpOnly)
+		assert.Equal(t, stateCookie.Value, location.Query().Get("state"))
+	})
+
+	t.Run("unknown provider", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/auth/oauth/github/login", nil)
+		c.Params = gin.Params{{Key: "provider", Value: "github"}}
+
+		handler.OAuthLogin(c)
+		apiErrors.ErrorHandler()(c)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestHandler_OAuthCallback(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db := testutil.NewSQLiteDB(t)
+	repo := NewRepository(db)
+	service := NewService(repo, newTestSecurityConfig())
+
+	existing := &User{Name: "Existing", Email: "existing@example.com", PasswordHash: "hash"}
+	require.NoError(t, repo.Create(context.Background(), existing))
+	require.NoError(t, repo.AssignRole(context.Background(), existing.ID, RoleUser))
+
+	provider := &fakeOAuthProvider{profiles: map[string]*oauth.Profile{
+		"new-code":        {Subject: "sub-new", Email: "new@example.com", EmailVerified: true, Name: "New User"},
+		"existing-code":   {Subject: "sub-existing", Email: "existing@example.com", EmailVerified: true, Name: "Google Name"},
+		"renamed-code":    {Subject: "sub-existing", Email: "changed@example.com", EmailVerified: true},
+		"unverified-code": {Subject: "sub-unverified", Email: "existing@example.com", EmailVerified: false},
+	}}
+	registry := oauth.Registry{}
+	registry.Register(provider)
+
+	mockAuthService := new(testutil.MockAuthService)
+	mockAuthService.On("GenerateTokenPair", mock.Anything, mock.AnythingOfType("uint"), mock.Anything, mock.Anything).
+		Return(&auth.TokenPair{AccessToken: "access", RefreshToken: "refresh", TokenType: "Bearer", ExpiresIn: 900}, nil)
+	handler := NewHandler(service, mockAuthService, WithOAuthProviders(registry))
+
+	callback := func(code, state, cookieState string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/auth/oauth/fake/callback?code="+code+"&state="+state, nil)
+		if cookieState != "" {
+			c.Request.AddCookie(&http.Cookie{Name: oauth.StateCookieName, Value: cookieState})
+		}
+		c.Params = gin.Params{{Key: "provider", Value: "fake"}}
+
+		handler.OAuthCallback(c)
+		apiErrors.ErrorHandler()(c)
+		return w
+	}
+	responseUser := func(t *testing.T, w *httptest.ResponseRecorder) UserResponse {
+		var response struct {
+			Data AuthResponse `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "access", response.Data.AccessToken)
+		assert.Equal(t, "refresh", response.Data.RefreshToken)
+		return response.Data.User
+	}
+
+	t.Run("creates a new user on first login", func(t *testing.T) {
+		w := callback("new-code", "s1", "s1")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		u := responseUser(t, w)
+		assert.Equal(t, "new@example.com", u.Email)
+		assert.Equal(t, "New User", u.Name)
+		assert.NotEqual(t, existing.ID, u.ID)
+
+		identity, err := repo.FindIdentity(context.Background(), "fake", "sub-new")
+		require.NoError(t, err)
+		require.NotNil(t, identity)
+		assert.Equal(t, u.ID, identity.UserID)
+
+		roles, err := repo.GetUserRoles(context.Background(), u.ID)
+		require.NoError(t, err)
+		require.Len(t, roles, 1)
+		assert.Equal(t, RoleUser, roles[0].Name)
+	})
+
+	t.Run("links an existing user by verified email", func(t *testing.T) {
+		w := callback("existing-code", "s2", "s2")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		u := responseUser(t, w)
+		assert.Equal(t, existing.ID, u.ID)
+		assert.Equal(t, "Existing", u.Name, "linking must not overwrite the profile")
+
+		identity, err := repo.FindIdentity(context.Background(), "fake", "sub-existing")
+		require.NoError(t, err)
+		require.NotNil(t, identity)
+		assert.Equal(t, existing.ID, identity.UserID)
+	})
+
+	t.Run("linked identity is matched by subject", func(t *testing.T) {
+		w := callback("renamed-code", "s3", "s3")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, existing.ID, responseUser(t, w).ID)
+	})
+
+	t.Run("unverified email cannot claim an account", func(t *testing.T) {
+		w := callback("unverified-code", "s4", "s4")
+		assert.Equal(t, http.StatusForbidden, w.Code)
+
+		identity, err := repo.FindIdentity(context.Background(), "fake", "sub-unverified")
+		require.NoError(t, err)
+		assert.Nil(t, identity)
+	})
+
+	t.Run("state mismatch", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, callback("new-code", "s5", "other").Code)
+		assert.Equal(t, http.StatusBadRequest, callback("new-code", "s5", "").Code)
+	})
+
+	t.Run("provider exchange failure", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, callback("bad-code", "s6", "s6").Code)
+	})
+}
@@ -0,0 +1,98 @@
+package user
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/yeegeek/uyou-go-api-starter/internal/testutil"
+)
+
+func TestService_ChangePassword_History(t *testing.T) {
+	db := testutil.NewSQLiteDB(t)
+	cfg := newTestSecurityConfig()
+	cfg.BcryptCost = bcrypt.MinCost
+	cfg.PasswordHistoryDepth = 3
+	service := NewService(NewRepository(db), cfg)
+	ctx := context.Background()
+
+	registered, err := service.RegisterUser(ctx, RegisterRequest{Name: "Jane Doe", Email: "jane@example.com", Password: "First123!"})
+	require.NoError(t, err)
+	historyRows := func() int64 {
+		var count int64
+		require.NoError(t, db.Model(&PasswordHistory{}).Where("user_id = ?", registered.ID).Count(&count).Error)
+		return count
+	}
+
+	require.NoError(t, service.ChangePassword(ctx, registered.ID, "First123!", "Second123!"))
+	require.NoError(t, service.ChangePassword(ctx, registered.ID, "Second123!", "Third123!"))
+
+	t.Run("current and recent passwords are rejected", func(t *testing.T) {
+		for _, password := range []string{"Third123!", "Second123!", "First123!"} {
+			err := service.ChangePassword(ctx, registered.ID, "Third123!", password)
+			assert.ErrorIs(t, err, ErrPasswordReused, password)
+		}
+	})
+
+	t.Run("password older than the depth is allowed", func(t *testing.T) {
+		require.NoError(t, service.ChangePassword(ctx, registered.ID, "Third123!", "Fourth123!"))
+		assert.Equal(t, int64(2), historyRows(), "history is trimmed to the depth minus the current password")
+
+		require.NoError(t, service.ChangePassword(ctx, registered.ID, "Fourth123!", "First123!"))
+		_, err := service.AuthenticateUser(ctx, LoginRequest{Email: "jane@example.com", Password: "First123!"})
+		assert.NoError(t, err)
+	})
+
+	t.Run("reset password is recorded in history", func(t *testing.T) {
+		password, err := service.ResetPassword(ctx, registered.ID)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), historyRows())
+
+		err = service.ChangePassword(ctx, registered.ID, password, "First123!")
+		assert.ErrorIs(t, err, ErrPasswordReused, "the password replaced by the reset stays in history")
+	})
+}
+
+func TestService_ChangePassword(t *testing.T) {
+	db := testutil.NewSQLiteDB(t)
+	cfg := newTestSecurityConfig()
+	cfg.BcryptCost = bcrypt.MinCost
+	service := NewService(NewRepository(db), cfg)
+	ctx := context.Background()
+
+	registered, err := service.RegisterUser(ctx, RegisterRequest{Name: "Jane Doe", Email: "jane@example.com", Password: "Password123!"})
+	require.NoError(t, err)
+
+	t.Run("wrong current password", func(t *testing.T) {
+		err := service.ChangePassword(ctx, registered.ID, "Wrong123!", "NewPass456!")
+		assert.ErrorIs(t, err, ErrInvalidCredentials)
+	})
+
+	t.Run("new password must satisfy the policy", func(t *testing.T) {
+		err := service.ChangePassword(ctx, registered.ID, "Password123!", "short")
+		assert.ErrorIs(t, err, ErrPasswordTooShort)
+	})
+
+	t.Run("history disabled allows keeping the password", func(t *testing.T) {
+		require.NoError(t, service.ChangePassword(ctx, registered.ID, "Password123!", "Password123!"))
+
+		var count int64
+		require.NoError(t, db.Model(&PasswordHistory{}).Count(&count).Error)
+		assert.Zero(t, count, "nothing is recorded without a history depth")
+	})
+}
+
+func TestService_ChangePassword_UserNotFound(t *testing.T) {
+	mockRepo := &MockRepository{}
+	mockRepo.On("FindByID", mock.Anything, uint(999)).Return(nil, nil)
+
+	service := NewService(mockRepo, newTestSecurityConfig())
+	err := service.ChangePassword(context.Background(), 999, "Password123!", "NewPass456!")
+
+	assert.ErrorIs(t, err, ErrUserNotFound)
+	mockRepo.AssertNotCalled(t, "UpdatePassword", mock.Anything, mock.Anything, mock.Anything)
+}
@@ -0,0 +1,58 @@
+package user
+
+import (
+	"regexp"
+	"sort"
+	"time"
+)
+
+// Built-in permission names seeded by migrations
+const (
+	PermissionUsersRead   = "users:read"
+	PermissionUsersWrite  = "users:write"
+	PermissionUsersDelete = "users:delete"
+	PermissionRolesManage = "roles:manage"
+	PermissionStatsRead   = "stats:read"
+	// PermissionAdminAll grants every permission, including admin-only operations
+	// such as impersonation, feature flags and the audit log
+	PermissionAdminAll = "admin:*"
+)
+
+// permissionNamePattern restricts permission names to "resource:action"; action "*" covers every action on resource
+var permissionNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_-]*:([a-z][a-z0-9_-]*|\*)$`)
+
+// Permission represents a named capability that can be granted to roles
+type Permission struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	Name        string    `gorm:"uniqueIndex;not null" json:"name"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name for Permission model
+func (Permission) TableName() string {
+	return "permissions"
+}
+
+// IsValidPermissionName reports whether name has the "resource:action" or "resource:*" form
+func IsValidPermissionName(name string) bool {
+	return len(name) <= 100 && permissionNamePattern.MatchString(name)
+}
+
+// ResolvePermissions flattens the permissions granted by roles into a sorted, de-duplicated list
+func ResolvePermissions(roles []Role) []string {
+	seen := make(map[string]struct{})
+	permissions := make([]string, 0)
+	for _, role := range roles {
+		for _, p := range role.Permissions {
+			if _, ok := seen[p.Name]; ok {
+				continue
+			}
+			seen[p.Name] = struct{}{}
+			permissions = append(permissions, p.Name)
+		}
+	}
+	sort.Strings(permissions)
+	return permissions
+}
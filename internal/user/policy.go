@@ -0,0 +1,191 @@
+// Package user 提供协议接受记录功能
+package user
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/yeegeek/uyou-go-api-starter/internal/auth"
+	"github.com/yeegeek/uyou-go-api-starter/internal/config"
+)
+
+var (
+	// ErrTermsNotAccepted 配置了协议但注册请求没有 accept_terms: true
+	ErrTermsNotAccepted = errors.New("terms must be accepted")
+	// ErrUnknownPolicyDocument 接受的协议类型未在 policies.documents 中配置
+	ErrUnknownPolicyDocument = errors.New("unknown policy document")
+)
+
+// PolicyAcceptance 用户接受某个协议版本的记录，只追加不修改，用于审计导出
+type PolicyAcceptance struct {
+	ID           uint      `gorm:"primaryKey"`
+	UserID       uint      `gorm:"not null;index"`
+	DocumentType string    `gorm:"size:50;not null"`
+	Version      string    `gorm:"size:50;not null"`
+	IP           string    `gorm:"column:ip_address;size:45;not null;default:''"`
+	AcceptedAt   time.Time `gorm:"not null"`
+}
+
+// TableName 指定协议接受记录对应的数据库表名
+func (PolicyAcceptance) TableName() string {
+	return "policy_acceptances"
+}
+
+// PolicyAcceptanceFilter 管理员导出接受记录的筛选条件，空字段不筛选
+type PolicyAcceptanceFilter struct {
+	DocumentType string
+	Version      string
+}
+
+// PolicyStatus 用户对单个已配置协议的接受情况
+type PolicyStatus struct {
+	DocumentType    string
+	RequiredVersion string
+	Enforcement     string
+	// Accepted 是否接受过当前要求的版本
+	Accepted bool
+	// AcceptedVersion 和 AcceptedAt 为最近一次接受的版本和时间，从未接受时为空
+	AcceptedVersion string
+	AcceptedAt      *time.Time
+}
+
+// PolicyService 查询和记录用户的协议接受情况
+type PolicyService interface {
+	// GetPolicyStatus 返回每个已配置协议的接受情况，顺序与配置一致
+	GetPolicyStatus(ctx context.Context, userID uint) ([]PolicyStatus, error)
+	// AcceptPolicies 记录用户接受指定协议的当前版本，documentTypes 为空时接受全部已配置协议
+	AcceptPolicies(ctx context.Context, userID uint, documentTypes []string) ([]PolicyStatus, error)
+	// BlockingPolicies 返回用户尚未接受当前版本且配置为 block 的协议类型
+	BlockingPolicies(ctx context.Context, userID uint) ([]string, error)
+	// ListAcceptances 分页返回接受记录，按接受时间倒序
+	ListAcceptances(ctx context.Context, filter PolicyAcceptanceFilter, page, perPage int) ([]PolicyAcceptance, int64, error)
+}
+
+type policyService struct {
+	repo      Repository
+	documents []config.PolicyDocumentConfig
+	// blocking 是否有协议配置为 block；没有时 BlockingPolicies 不查询数据库
+	blocking bool
+}
+
+// NewPolicyService creates a policy service for the documents declared in cfg
+func NewPolicyService(repo Repository, cfg config.PoliciesConfig) PolicyService {
+	s := &policyService{repo: repo, documents: cfg.Documents}
+	for _, d := range cfg.Documents {
+		s.blocking = s.blocking || d.Blocks()
+	}
+	return s
+}
+
+// WithPolicies makes RegisterUser require accept_terms when documents are configured and record
+// acceptance of their current versions together with the new account
+func WithPolicies(cfg config.PoliciesConfig) ServiceOption {
+	return func(s *service) {
+		s.policyDocuments = cfg.Documents
+	}
+}
+
+func (s *policyService) GetPolicyStatus(ctx context.Context, userID uint) ([]PolicyStatus, error) {
+	if len(s.documents) == 0 {
+		return []PolicyStatus{}, nil
+	}
+	acceptances, err := s.repo.FindPolicyAcceptances(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find policy acceptances: %w", err)
+	}
+	return policyStatuses(s.documents, acceptances), nil
+}
+
+func (s *policyService) AcceptPolicies(ctx context.Context, userID uint, documentTypes []string) ([]PolicyStatus, error) {
+	documents := s.documents
+	if len(documentTypes) > 0 {
+		documents = make([]config.PolicyDocumentConfig, 0, len(documentTypes))
+		for _, documentType := range documentTypes {
+			document, ok := s.findDocument(documentType)
+			if !ok {
+				return nil, fmt.Errorf("%w: %s", ErrUnknownPolicyDocument, documentType)
+			}
+			documents = append(documents, document)
+		}
+	}
+
+	if err := s.repo.CreatePolicyAcceptances(ctx, newPolicyAcceptances(ctx, userID, documents)); err != nil {
+		return nil, fmt.Errorf("failed to record policy acceptance: %w", err)
+	}
+	return s.GetPolicyStatus(ctx, userID)
+}
+
+func (s *policyService) BlockingPolicies(ctx context.Context, userID uint) ([]string, error) {
+	if !s.blocking {
+		return nil, nil
+	}
+	statuses, err := s.GetPolicyStatus(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	var blocking []string
+	for _, status := range statuses {
+		if !status.Accepted && status.Enforcement == config.PolicyEnforcementBlock {
+			blocking = append(blocking, status.DocumentType)
+		}
+	}
+	return blocking, nil
+}
+
+func (s *policyService) ListAcceptances(ctx context.Context, filter PolicyAcceptanceFilter, page, perPage int) ([]PolicyAcceptance, int64, error) {
+	acceptances, total, err := s.repo.ListPolicyAcceptances(ctx, filter, page, perPage)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list policy acceptances: %w", err)
+	}
+	return acceptances, total, nil
+}
+
+func (s *policyService) findDocument(documentType string) (config.PolicyDocumentConfig, bool) {
+	for _, d := range s.documents {
+		if d.Type == documentType {
+			return d, true
+		}
+	}
+	return config.PolicyDocumentConfig{}, false
+}
+
+// newPolicyAcceptances 为每个协议的当前版本生成一条接受记录，IP 取自请求上下文
+func newPolicyAcceptances(ctx context.Context, userID uint, documents []config.PolicyDocumentConfig) []PolicyAcceptance {
+	now := time.Now()
+	ip := auth.ClientInfoFromContext(ctx).IP
+	acceptances := make([]PolicyAcceptance, len(documents))
+	for i, d := range documents {
+		acceptances[i] = PolicyAcceptance{
+			UserID:       userID,
+			DocumentType: d.Type,
+			Version:      d.Version,
+			IP:           ip,
+			AcceptedAt:   now,
+		}
+	}
+	return acceptances
+}
+
+// policyStatuses 按配置顺序汇总接受情况；acceptances 按接受时间正序，后面的记录覆盖最近接受的版本
+func policyStatuses(documents []config.PolicyDocumentConfig, acceptances []PolicyAcceptance) []PolicyStatus {
+	statuses := make([]PolicyStatus, len(documents))
+	for i, d := range documents {
+		enforcement := d.Enforcement
+		if enforcement == "" {
+			enforcement = config.PolicyEnforcementFlag
+		}
+		statuses[i] = PolicyStatus{DocumentType: d.Type, RequiredVersion: d.Version, Enforcement: enforcement}
+		for j := range acceptances {
+			a := &acceptances[j]
+			if a.DocumentType != d.Type {
+				continue
+			}
+			statuses[i].AcceptedVersion = a.Version
+			statuses[i].AcceptedAt = &a.AcceptedAt
+			statuses[i].Accepted = statuses[i].Accepted || a.Version == d.Version
+		}
+	}
+	return statuses
+}
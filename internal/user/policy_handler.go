@@ -0,0 +1,145 @@
+package user
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/yeegeek/uyou-go-api-starter/internal/contextutil"
+	apiErrors "github.com/yeegeek/uyou-go-api-starter/internal/errors"
+	"github.com/yeegeek/uyou-go-api-starter/internal/middleware"
+)
+
+// WithPolicyService reports policy acceptance in GetMe and enables the accept-policy and
+// acceptance export endpoints
+func WithPolicyService(policies PolicyService) HandlerOption {
+	return func(h *Handler) {
+		h.policies = policies
+	}
+}
+
+// RequirePolicyAcceptance rejects requests from users who have not accepted the current version
+// of a policy configured with block enforcement. Without block policies no query is made.
+func (h *Handler) RequirePolicyAcceptance() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := contextutil.GetUserID(c)
+		// WHY: Support staff impersonating a user must see what the user sees, not accept on their behalf
+		if h.policies == nil || userID == 0 || contextutil.IsImpersonating(c) {
+			c.Next()
+			return
+		}
+
+		blocking, err := h.policies.BlockingPolicies(c.Request.Context(), userID)
+		if err != nil {
+			_ = c.Error(apiErrors.InternalServerError(err))
+			c.Abort()
+			return
+		}
+		if len(blocking) > 0 {
+			_ = c.Error(apiErrors.PolicyNotAccepted(blocking))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// AcceptPolicy godoc
+// @Summary Accept policy documents
+// @Description Record that the user accepts the current version of the listed policy documents, or of every configured document when the list is empty. The client IP is stored with each acceptance. Only the user themself can accept.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Param request body AcceptPolicyRequest false "Documents to accept"
+// @Success 200 {object} errors.Response{success=bool,data=[]PolicyStatusResponse} "Acceptance state of every configured document"
+// @Failure 400 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Invalid user ID or unknown document"
+// @Failure 401 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Unauthorized"
+// @Failure 403 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Accepting for another user or during impersonation"
+// @Failure 404 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Policy tracking is not enabled"
+// @Failure 500 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Failed to record acceptance"
+// @Router /api/v1/users/{id}/accept-policy [post]
+func (h *Handler) AcceptPolicy(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		_ = c.Error(apiErrors.BadRequest(apiErrors.MsgInvalidUserID))
+		return
+	}
+	// WHY: Acceptance is a legal statement by the user, so admins and impersonators cannot record it
+	if contextutil.GetUserID(c) != uint(id) || contextutil.IsImpersonating(c) {
+		_ = c.Error(apiErrors.Forbidden("Policies can only be accepted by the user themself"))
+		return
+	}
+	if h.policies == nil {
+		_ = c.Error(apiErrors.NotFound("Policy tracking is not enabled"))
+		return
+	}
+
+	var req AcceptPolicyRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			_ = c.Error(apiErrors.FromGinValidation(err))
+			return
+		}
+	}
+
+	statuses, err := h.policies.AcceptPolicies(clientContext(c), uint(id), req.Documents)
+	if err != nil {
+		if errors.Is(err, ErrUnknownPolicyDocument) {
+			_ = c.Error(apiErrors.BadRequest("Unknown policy document"))
+			return
+		}
+		_ = c.Error(apiErrors.InternalServerError(err))
+		return
+	}
+
+	slog.InfoContext(c.Request.Context(), "Policies accepted", "user_id", id, "documents", req.Documents)
+	c.JSON(http.StatusOK, apiErrors.Success(ToPolicyStatusResponses(statuses)))
+}
+
+// ListPolicyAcceptances godoc
+// @Summary List policy acceptances (Admin only)
+// @Description Export recorded policy acceptances for audits, most recent first, optionally filtered by document and version
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param document query string false "Filter by policy document type"
+// @Param version query string false "Filter by accepted version"
+// @Param page query int false "Page number" default(1)
+// @Param per_page query int false "Items per page (default and max set by pagination config)" default(20)
+// @Success 200 {object} errors.Response{success=bool,data=PolicyAcceptanceListResponse} "Policy acceptances"
+// @Failure 401 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Unauthorized"
+// @Failure 403 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Admin access required"
+// @Failure 404 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Policy tracking is not enabled"
+// @Failure 500 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Failed to list acceptances"
+// @Router /api/v1/admin/policy-acceptances [get]
+func (h *Handler) ListPolicyAcceptances(c *gin.Context) {
+	if h.policies == nil {
+		_ = c.Error(apiErrors.NotFound("Policy tracking is not enabled"))
+		return
+	}
+
+	pagination := middleware.ParsePaginationParams(c)
+	filter := PolicyAcceptanceFilter{DocumentType: c.Query("document"), Version: c.Query("version")}
+	acceptances, total, err := h.policies.ListAcceptances(c.Request.Context(), filter, pagination.Page, pagination.PerPage)
+	if err != nil {
+		_ = c.Error(apiErrors.InternalServerError(err))
+		return
+	}
+
+	response := PolicyAcceptanceListResponse{
+		Acceptances: make([]PolicyAcceptanceResponse, len(acceptances)),
+		Total:       total,
+		Page:        pagination.Page,
+		PerPage:     pagination.PerPage,
+		TotalPages:  int((total + int64(pagination.PerPage) - 1) / int64(pagination.PerPage)),
+	}
+	for i := range acceptances {
+		response.Acceptances[i] = ToPolicyAcceptanceResponse(&acceptances[i])
+	}
+	c.JSON(http.StatusOK, apiErrors.Success(response))
+}
@@ -0,0 +1,163 @@
+package user
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yeegeek/uyou-go-api-starter/internal/auth"
+	"github.com/yeegeek/uyou-go-api-starter/internal/config"
+	apiErrors "github.com/yeegeek/uyou-go-api-starter/internal/errors"
+	"github.com/yeegeek/uyou-go-api-starter/internal/testutil"
+)
+
+func testPolicies(termsVersion string) config.PoliciesConfig {
+	return config.PoliciesConfig{Documents: []config.PolicyDocumentConfig{
+		{Type: "terms", Version: termsVersion, Enforcement: config.PolicyEnforcementBlock},
+		{Type: "privacy", Version: "1"},
+	}}
+}
+
+func TestService_RegisterUser_RecordsPolicyAcceptance(t *testing.T) {
+	db := testutil.NewSQLiteDB(t)
+	repo := NewRepository(db)
+	service := NewService(repo, newTestSecurityConfig(), WithPolicies(testPolicies("2026-01-01")))
+	ctx := auth.WithClientInfo(context.Background(), auth.ClientInfo{IP: "203.0.113.5"})
+	req := RegisterRequest{Name: "Jane Doe", Email: "jane@example.com", Password: "Password123!"}
+
+	_, err := service.RegisterUser(ctx, req)
+	assert.ErrorIs(t, err, ErrTermsNotAccepted)
+	found, err := repo.FindByEmail(ctx, req.Email)
+	require.NoError(t, err)
+	assert.Nil(t, found, "no account is created without accepting the terms")
+
+	req.AcceptTerms = true
+	registered, err := service.RegisterUser(ctx, req)
+	require.NoError(t, err)
+
+	acceptances, err := repo.FindPolicyAcceptances(ctx, registered.ID)
+	require.NoError(t, err)
+	require.Len(t, acceptances, 2)
+	assert.Equal(t, "terms", acceptances[0].DocumentType)
+	assert.Equal(t, "2026-01-01", acceptances[0].Version)
+	assert.Equal(t, "203.0.113.5", acceptances[0].IP)
+	assert.Equal(t, "privacy", acceptances[1].DocumentType)
+}
+
+func TestService_RegisterUser_NoPoliciesConfigured(t *testing.T) {
+	db := testutil.NewSQLiteDB(t)
+	service := NewService(NewRepository(db), newTestSecurityConfig())
+
+	_, err := service.RegisterUser(context.Background(), RegisterRequest{Name: "Jane Doe", Email: "jane@example.com", Password: "Password123!"})
+	assert.NoError(t, err, "accept_terms is only required when policies are configured")
+}
+
+func TestPolicyService_VersionBumpRequiresReacceptance(t *testing.T) {
+	db := testutil.NewSQLiteDB(t)
+	repo := NewRepository(db)
+	ctx := context.Background()
+	registered, err := NewService(repo, newTestSecurityConfig(), WithPolicies(testPolicies("2026-01-01"))).
+		RegisterUser(ctx, RegisterRequest{Name: "Jane Doe", Email: "jane@example.com", Password: "Password123!", AcceptTerms: true})
+	require.NoError(t, err)
+
+	current := NewPolicyService(repo, testPolicies("2026-01-01"))
+	blocking, err := current.BlockingPolicies(ctx, registered.ID)
+	require.NoError(t, err)
+	assert.Empty(t, blocking)
+
+	bumped := NewPolicyService(repo, testPolicies("2026-06-01"))
+	statuses, err := bumped.GetPolicyStatus(ctx, registered.ID)
+	require.NoError(t, err)
+	require.Len(t, statuses, 2)
+	assert.False(t, statuses[0].Accepted)
+	assert.Equal(t, "2026-01-01", statuses[0].AcceptedVersion)
+	assert.Equal(t, config.PolicyEnforcementBlock, statuses[0].Enforcement)
+	assert.True(t, statuses[1].Accepted)
+	assert.Equal(t, config.PolicyEnforcementFlag, statuses[1].Enforcement, "enforcement defaults to flag")
+
+	blocking, err = bumped.BlockingPolicies(ctx, registered.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"terms"}, blocking)
+
+	_, err = bumped.AcceptPolicies(ctx, registered.ID, []string{"cookies"})
+	assert.ErrorIs(t, err, ErrUnknownPolicyDocument)
+
+	statuses, err = bumped.AcceptPolicies(ctx, registered.ID, []string{"terms"})
+	require.NoError(t, err)
+	assert.True(t, statuses[0].Accepted)
+	assert.Equal(t, "2026-06-01", statuses[0].AcceptedVersion)
+
+	blocking, err = bumped.BlockingPolicies(ctx, registered.ID)
+	require.NoError(t, err)
+	assert.Empty(t, blocking)
+
+	acceptances, total, err := bumped.ListAcceptances(ctx, PolicyAcceptanceFilter{DocumentType: "terms", Version: "2026-01-01"}, 1, 20)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	require.Len(t, acceptances, 1)
+	assert.Equal(t, registered.ID, acceptances[0].UserID)
+
+	_, total, err = bumped.ListAcceptances(ctx, PolicyAcceptanceFilter{}, 1, 20)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), total, "older acceptances are kept for audits")
+}
+
+func TestHandler_PolicyAcceptance(t *testing.T) {
+
+	db := testutil.NewSQLiteDB(t)
+	repo := NewRepository(db)
+	registered, err := NewService(repo, newTestSecurityConfig(), WithPolicies(testPolicies("2026-01-01"))).
+		RegisterUser(context.Background(), RegisterRequest{Name: "Jane Doe", Email: "jane@example.com", Password: "Password123!", AcceptTerms: true})
+	require.NoError(t, err)
+
+	handler := NewHandler(nil, nil, WithPolicyService(NewPolicyService(repo, testPolicies("2026-06-01"))))
+	router := testutil.NewTestRouter(t, testutil.WithClaims(&auth.Claims{UserID: registered.ID, Roles: []string{RoleUser}}))
+	router.POST("/users/:id/accept-policy", handler.AcceptPolicy)
+	router.GET("/friends", handler.RequirePolicyAcceptance(), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	get := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/friends", nil))
+		return w
+	}
+	accept := func(userID uint, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/users/"+strconv.FormatUint(uint64(userID), 10)+"/accept-policy", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := get()
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), apiErrors.CodePolicyNotAccepted)
+	assert.Contains(t, w.Body.String(), `"documents":["terms"]`)
+
+	w = accept(registered.ID+1, `{"documents":["terms"]}`)
+	assert.Equal(t, http.StatusForbidden, w.Code, "users can only accept for themselves")
+
+	w = accept(registered.ID, `{"documents":["cookies"]}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = accept(registered.ID, `{"documents":["terms"]}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	var response struct {
+		Data []PolicyStatusResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	require.Len(t, response.Data, 2)
+	assert.Equal(t, "terms", response.Data[0].Document)
+	assert.True(t, response.Data[0].Accepted)
+	require.NotNil(t, response.Data[0].AcceptedVersion)
+	assert.Equal(t, "2026-06-01", *response.Data[0].AcceptedVersion)
+
+	assert.Equal(t, http.StatusOK, get().Code)
+}
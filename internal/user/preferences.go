@@ -0,0 +1,241 @@
+// Package user 提供用户偏好设置功能
+package user
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrUnknownPreference 偏好设置键未在注册表中定义
+	ErrUnknownPreference = errors.New("unknown preference")
+	// ErrInvalidPreferences 偏好设置更新包含未知键或不合法的值，具体错误为 *PreferenceValidationError
+	ErrInvalidPreferences = errors.New("invalid preferences")
+)
+
+// PreferenceValidationError 按键列出被拒绝的偏好设置，errors.Is(err, ErrInvalidPreferences) 成立
+type PreferenceValidationError struct {
+	Fields map[string]string
+}
+
+func (e *PreferenceValidationError) Error() string {
+	keys := slices.Sorted(maps.Keys(e.Fields))
+	return "invalid preferences: " + strings.Join(keys, ", ")
+}
+
+// Is 使 errors.Is 能以 ErrInvalidPreferences 匹配
+func (e *PreferenceValidationError) Is(target error) bool {
+	return target == ErrInvalidPreferences
+}
+
+// 偏好设置值类型
+const (
+	PreferenceTypeBoolean = "boolean"
+	PreferenceTypeEnum    = "enum"
+	PreferenceTypeInteger = "integer"
+)
+
+// PreferenceDefinition 描述一个允许的偏好设置键：类型、默认值和取值范围
+type PreferenceDefinition struct {
+	Key     string
+	Type    string
+	Default any
+	// Values 为 enum 类型允许的取值
+	Values []string
+	// Min 和 Max 为 integer 类型的闭区间
+	Min, Max int
+}
+
+// preferenceDefinitions 是允许的偏好设置注册表；新增设置只需在这里追加，无需修改表结构
+var preferenceDefinitions = []PreferenceDefinition{
+	{Key: "notifications.email", Type: PreferenceTypeBoolean, Default: true},
+	{Key: "notifications.marketing", Type: PreferenceTypeBoolean, Default: false},
+	{Key: "notifications.digest_frequency", Type: PreferenceTypeEnum, Default: "weekly", Values: []string{"never", "daily", "weekly"}},
+	{Key: "ui.theme", Type: PreferenceTypeEnum, Default: "system", Values: []string{"system", "light", "dark"}},
+	{Key: "ui.page_size", Type: PreferenceTypeInteger, Default: 20, Min: 10, Max: 100},
+}
+
+// PreferenceDefinitions 返回所有允许的偏好设置，顺序与注册表一致
+func PreferenceDefinitions() []PreferenceDefinition {
+	return slices.Clone(preferenceDefinitions)
+}
+
+// LookupPreference 按键查找偏好设置定义
+func LookupPreference(key string) (PreferenceDefinition, bool) {
+	for _, d := range preferenceDefinitions {
+		if d.Key == key {
+			return d, true
+		}
+	}
+	return PreferenceDefinition{}, false
+}
+
+// Parse 校验请求中的 JSON 值并返回规范化后的值（bool、string 或 int）
+func (d PreferenceDefinition) Parse(raw json.RawMessage) (any, error) {
+	// WHY: json.Unmarshal accepts null for any type and leaves the zero value, which would pass as false or 0
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, errors.New("must not be null")
+	}
+	switch d.Type {
+	case PreferenceTypeBoolean:
+		var v bool
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return nil, errors.New("must be a boolean")
+		}
+		return v, nil
+	case PreferenceTypeEnum:
+		var v string
+		if err := json.Unmarshal(raw, &v); err != nil || !slices.Contains(d.Values, v) {
+			return nil, errors.New("must be one of: " + strings.Join(d.Values, ", "))
+		}
+		return v, nil
+	case PreferenceTypeInteger:
+		var v int
+		if err := json.Unmarshal(raw, &v); err != nil || v < d.Min || v > d.Max {
+			return nil, errors.New("must be an integer between " + strconv.Itoa(d.Min) + " and " + strconv.Itoa(d.Max))
+		}
+		return v, nil
+	default:
+		return nil, fmt.Errorf("unsupported preference type %q", d.Type)
+	}
+}
+
+// normalize converts a stored value back to the definition's type; values that no longer
+// satisfy the definition (e.g. an enum option that was removed) fall back to the default
+func (d PreferenceDefinition) normalize(stored any) any {
+	raw, err := json.Marshal(stored)
+	if err != nil {
+		return d.Default
+	}
+	v, err := d.Parse(raw)
+	if err != nil {
+		return d.Default
+	}
+	return v
+}
+
+// UserPreference 用户的一项偏好设置，只保存与默认值不同或用户显式设置过的键
+type UserPreference struct {
+	UserID    uint      `gorm:"primaryKey"`
+	Key       string    `gorm:"primaryKey;size:100"`
+	Value     any       `gorm:"type:jsonb;serializer:json;not null"`
+	UpdatedAt time.Time `gorm:"not null"`
+}
+
+// TableName 指定偏好设置对应的数据库表名
+func (UserPreference) TableName() string {
+	return "user_preferences"
+}
+
+// PreferenceService 读取和修改用户偏好设置
+type PreferenceService interface {
+	// GetPreferences 返回所有已定义的偏好设置，未设置的键取默认值
+	GetPreferences(ctx context.Context, userID uint) (map[string]any, error)
+	// UpdatePreferences 部分更新偏好设置并返回更新后的全部设置；任一键不合法时不做任何修改
+	UpdatePreferences(ctx context.Context, userID uint, changes map[string]json.RawMessage) (map[string]any, error)
+	// GetPreference 返回单个偏好设置，未设置时为默认值，供其他功能（如邮件通知）判断用户选择
+	GetPreference(ctx context.Context, userID uint, key string) (any, error)
+}
+
+type preferenceService struct {
+	repo Repository
+	now  func() time.Time
+}
+
+// NewPreferenceService creates a preference service backed by repo
+func NewPreferenceService(repo Repository) PreferenceService {
+	return &preferenceService{repo: repo, now: time.Now}
+}
+
+func (s *preferenceService) GetPreferences(ctx context.Context, userID uint) (map[string]any, error) {
+	if err := s.requireUser(ctx, userID); err != nil {
+		return nil, err
+	}
+	return s.load(ctx, userID)
+}
+
+func (s *preferenceService) UpdatePreferences(ctx context.Context, userID uint, changes map[string]json.RawMessage) (map[string]any, error) {
+	if err := s.requireUser(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	fields := make(map[string]string)
+	prefs := make([]UserPreference, 0, len(changes))
+	for key, raw := range changes {
+		def, ok := LookupPreference(key)
+		if !ok {
+			fields[key] = "unknown preference"
+			continue
+		}
+		value, err := def.Parse(raw)
+		if err != nil {
+			fields[key] = err.Error()
+			continue
+		}
+		prefs = append(prefs, UserPreference{UserID: userID, Key: key, Value: value, UpdatedAt: now})
+	}
+	if len(fields) > 0 {
+		return nil, &PreferenceValidationError{Fields: fields}
+	}
+
+	if err := s.repo.UpsertPreferences(ctx, prefs); err != nil {
+		return nil, fmt.Errorf("failed to save preferences: %w", err)
+	}
+	return s.load(ctx, userID)
+}
+
+func (s *preferenceService) GetPreference(ctx context.Context, userID uint, key string) (any, error) {
+	def, ok := LookupPreference(key)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownPreference, key)
+	}
+	stored, err := s.repo.FindPreferences(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find preferences: %w", err)
+	}
+	for _, p := range stored {
+		if p.Key == key {
+			return def.normalize(p.Value), nil
+		}
+	}
+	return def.Default, nil
+}
+
+// load merges the user's stored preferences over the defaults; stored keys that are no
+// longer defined are ignored
+func (s *preferenceService) load(ctx context.Context, userID uint) (map[string]any, error) {
+	stored, err := s.repo.FindPreferences(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find preferences: %w", err)
+	}
+
+	values := make(map[string]any, len(preferenceDefinitions))
+	for _, def := range preferenceDefinitions {
+		values[def.Key] = def.Default
+	}
+	for _, p := range stored {
+		if def, ok := LookupPreference(p.Key); ok {
+			values[p.Key] = def.normalize(p.Value)
+		}
+	}
+	return values, nil
+}
+
+func (s *preferenceService) requireUser(ctx context.Context, userID uint) error {
+	user, err := s.repo.FindByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to find user: %w", err)
+	}
+	if user == nil {
+		return ErrUserNotFound
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package user
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/yeegeek/uyou-go-api-starter/internal/contextutil"
+	apiErrors "github.com/yeegeek/uyou-go-api-starter/internal/errors"
+)
+
+// WithPreferenceService enables the user preference endpoints
+func WithPreferenceService(preferences PreferenceService) HandlerOption {
+	return func(h *Handler) {
+		h.preferences = preferences
+	}
+}
+
+// GetPreferences godoc
+// @Summary Get user preferences
+// @Description Return every defined preference of the user; keys the user never set carry their default value
+// @Tags users
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Success 200 {object} errors.Response{success=bool,data=object} "Preference values keyed by preference key"
+// @Failure 400 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Invalid user ID"
+// @Failure 401 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Unauthorized"
+// @Failure 403 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Forbidden user ID"
+// @Failure 404 {object} errors.Response{success=bool,error=errors.ErrorInfo} "User not found or preferences not enabled"
+// @Failure 500 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Failed to load preferences"
+// @Router /api/v1/users/{id}/preferences [get]
+func (h *Handler) GetPreferences(c *gin.Context) {
+	id, ok := h.preferenceUserID(c)
+	if !ok {
+		return
+	}
+
+	values, err := h.preferences.GetPreferences(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			_ = c.Error(apiErrors.NotFound(apiErrors.MsgUserNotFound))
+			return
+		}
+		_ = c.Error(apiErrors.InternalServerError(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, apiErrors.Success(values))
+}
+
+// UpdatePreferences godoc
+// @Summary Update user preferences
+// @Description Set the given preferences; omitted keys are left unchanged. The whole update is rejected when a key is unknown or a value does not match its type, with the reason for each key in fields.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Param request body object true "Preference values keyed by preference key, e.g. {\"ui.theme\": \"dark\"}"
+// @Success 200 {object} errors.Response{success=bool,data=object} "All preference values after the update"
+// @Failure 400 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Invalid user ID, unknown key or invalid value"
+// @Failure 401 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Unauthorized"
+// @Failure 403 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Forbidden user ID"
+// @Failure 404 {object} errors.Response{success=bool,error=errors.ErrorInfo} "User not found or preferences not enabled"
+// @Failure 500 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Failed to save preferences"
+// @Router /api/v1/users/{id}/preferences [patch]
+func (h *Handler) UpdatePreferences(c *gin.Context) {
+	id, ok := h.preferenceUserID(c)
+	if !ok {
+		return
+	}
+
+	var changes map[string]json.RawMessage
+	if err := c.ShouldBindJSON(&changes); err != nil {
+		_ = c.Error(apiErrors.FromGinValidation(err))
+		return
+	}
+
+	values, err := h.preferences.UpdatePreferences(c.Request.Context(), id, changes)
+	if err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			_ = c.Error(apiErrors.NotFound(apiErrors.MsgUserNotFound))
+			return
+		}
+		var invalid *PreferenceValidationError
+		if errors.As(err, &invalid) {
+			apiErr := apiErrors.ValidationError(invalid.Fields)
+			apiErr.Fields = invalid.Fields
+			_ = c.Error(apiErr)
+			return
+		}
+		_ = c.Error(apiErrors.InternalServerError(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, apiErrors.Success(values))
+}
+
+// preferenceUserID parses the user ID and checks the caller may access it; on failure the
+// error is recorded and false returned
+func (h *Handler) preferenceUserID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		_ = c.Error(apiErrors.BadRequest(apiErrors.MsgInvalidUserID))
+		return 0, false
+	}
+	if !contextutil.CanAccessUser(c, uint(id)) {
+		_ = c.Error(apiErrors.Forbidden(apiErrors.MsgForbiddenUserID))
+		return 0, false
+	}
+	if h.preferences == nil {
+		_ = c.Error(apiErrors.NotFound("User preferences are not enabled"))
+		return 0, false
+	}
+	return uint(id), true
+}
@@ -0,0 +1,223 @@
+package user
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yeegeek/uyou-go-api-starter/internal/auth"
+	apiErrors "github.com/yeegeek/uyou-go-api-starter/internal/errors"
+	"github.com/yeegeek/uyou-go-api-starter/internal/openapitest"
+	"github.com/yeegeek/uyou-go-api-starter/internal/testutil"
+)
+
+func defaultPreferences() map[string]any {
+	values := make(map[string]any)
+	for _, def := range PreferenceDefinitions() {
+		values[def.Key] = def.Default
+	}
+	return values
+}
+
+func TestPreferenceDefinition_Parse(t *testing.T) {
+	theme, _ := LookupPreference("ui.theme")
+	pageSize, _ := LookupPreference("ui.page_size")
+	email, _ := LookupPreference("notifications.email")
+
+	tests := []struct {
+		name    string
+		def     PreferenceDefinition
+		raw     string
+		want    any
+		wantErr string
+	}{
+		{name: "boolean", def: email, raw: `false`, want: false},
+		{name: "boolean rejects string", def: email, raw: `"false"`, wantErr: "must be a boolean"},
+		{name: "null is rejected", def: email, raw: `null`, wantErr: "must not be null"},
+		{name: "enum", def: theme, raw: `"dark"`, want: "dark"},
+		{name: "enum rejects unknown option", def: theme, raw: `"pink"`, wantErr: "must be one of: system, light, dark"},
+		{name: "integer", def: pageSize, raw: `50`, want: 50},
+		{name: "integer lower bound", def: pageSize, raw: `10`, want: 10},
+		{name: "integer below range", def: pageSize, raw: `9`, wantErr: "must be an integer between 10 and 100"},
+		{name: "integer above range", def: pageSize, raw: `101`, wantErr: "must be an integer between 10 and 100"},
+		{name: "integer rejects fraction", def: pageSize, raw: `20.5`, wantErr: "must be an integer between 10 and 100"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.def.Parse(json.RawMessage(tt.raw))
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestPreferenceService(t *testing.T) {
+	db := testutil.NewSQLiteDB(t)
+	repo := NewRepository(db)
+	svc := NewPreferenceService(repo)
+	ctx := context.Background()
+
+	user := &User{Name: "Jane Doe", Email: "jane@example.com", PasswordHash: "hash"}
+	require.NoError(t, repo.Create(ctx, user))
+
+	t.Run("defaults when nothing is stored", func(t *testing.T) {
+		values, err := svc.GetPreferences(ctx, user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, defaultPreferences(), values)
+
+		optIn, err := svc.GetPreference(ctx, user.ID, "notifications.email")
+		require.NoError(t, err)
+		assert.Equal(t, true, optIn)
+	})
+
+	t.Run("partial update merges with defaults", func(t *testing.T) {
+		values, err := svc.UpdatePreferences(ctx, user.ID, map[string]json.RawMessage{
+			"notifications.email": json.RawMessage(`false`),
+			"ui.page_size":        json.RawMessage(`50`),
+		})
+		require.NoError(t, err)
+
+		want := defaultPreferences()
+		want["notifications.email"] = false
+		want["ui.page_size"] = 50
+		assert.Equal(t, want, values)
+
+		values, err = svc.UpdatePreferences(ctx, user.ID, map[string]json.RawMessage{"ui.theme": json.RawMessage(`"dark"`)})
+		require.NoError(t, err)
+		want["ui.theme"] = "dark"
+		assert.Equal(t, want, values, "earlier changes are kept")
+
+		optIn, err := svc.GetPreference(ctx, user.ID, "notifications.email")
+		require.NoError(t, err)
+		assert.Equal(t, false, optIn)
+		pageSize, err := svc.GetPreference(ctx, user.ID, "ui.page_size")
+		require.NoError(t, err)
+		assert.Equal(t, 50, pageSize, "stored integers come back as int")
+	})
+
+	t.Run("invalid update is rejected per key and saves nothing", func(t *testing.T) {
+		before, err := svc.GetPreferences(ctx, user.ID)
+		require.NoError(t, err)
+
+		_, err = svc.UpdatePreferences(ctx, user.ID, map[string]json.RawMessage{
+			"ui.theme":            json.RawMessage(`"light"`),
+			"ui.font":             json.RawMessage(`"serif"`),
+			"notifications.email": json.RawMessage(`"yes"`),
+		})
+		require.ErrorIs(t, err, ErrInvalidPreferences)
+		var invalid *PreferenceValidationError
+		require.ErrorAs(t, err, &invalid)
+		assert.Equal(t, map[string]string{
+			"ui.font":             "unknown preference",
+			"notifications.email": "must be a boolean",
+		}, invalid.Fields)
+
+		after, err := svc.GetPreferences(ctx, user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, before, after)
+	})
+
+	t.Run("unknown key", func(t *testing.T) {
+		_, err := svc.GetPreference(ctx, user.ID, "ui.font")
+		assert.ErrorIs(t, err, ErrUnknownPreference)
+	})
+
+	t.Run("missing user", func(t *testing.T) {
+		_, err := svc.GetPreferences(ctx, 999999)
+		assert.ErrorIs(t, err, ErrUserNotFound)
+		_, err = svc.UpdatePreferences(ctx, 999999, map[string]json.RawMessage{"ui.theme": json.RawMessage(`"dark"`)})
+		assert.ErrorIs(t, err, ErrUserNotFound)
+	})
+}
+
+func TestRepository_UpsertPreferences_ConcurrentKeys(t *testing.T) {
+	db := testutil.NewSQLiteDB(t)
+	repo := NewRepository(db)
+	ctx := context.Background()
+
+	user := &User{Name: "Jane Doe", Email: "jane@example.com", PasswordHash: "hash"}
+	require.NoError(t, repo.Create(ctx, user))
+
+	const writers, rounds = 8, 10
+	var wg sync.WaitGroup
+	for w := range writers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for r := range rounds {
+				pref := UserPreference{UserID: user.ID, Key: fmt.Sprintf("key.%d", w), Value: r, UpdatedAt: time.Now()}
+				assert.NoError(t, repo.UpsertPreferences(ctx, []UserPreference{pref}))
+			}
+		}()
+	}
+	wg.Wait()
+
+	prefs, err := repo.FindPreferences(ctx, user.ID)
+	require.NoError(t, err)
+	require.Len(t, prefs, writers, "each key has exactly one row")
+	for w, p := range prefs {
+		assert.Equal(t, fmt.Sprintf("key.%d", w), p.Key)
+		assert.EqualValues(t, rounds-1, p.Value, "the last write of every key survives")
+	}
+}
+
+func TestHandler_Preferences(t *testing.T) {
+	db := testutil.NewSQLiteDB(t)
+	repo := NewRepository(db)
+	owner := &User{Name: "Jane Doe", Email: "jane@example.com", PasswordHash: "hash"}
+	require.NoError(t, repo.Create(context.Background(), owner))
+	handler := NewHandler(nil, nil, WithPreferenceService(NewPreferenceService(repo)))
+
+	router := testutil.NewTestRouter(t, testutil.WithClaims(&auth.Claims{UserID: owner.ID, Email: owner.Email}))
+	router.GET("/api/v1/users/:id/preferences", handler.GetPreferences)
+	router.PATCH("/api/v1/users/:id/preferences", handler.UpdatePreferences)
+
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	path := fmt.Sprintf("/api/v1/users/%d/preferences", owner.ID)
+
+	w := serve(http.MethodGet, path, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"ui.theme":"system"`)
+	openapitest.AssertResponse(t, http.MethodGet, "/api/v1/users/{id}/preferences", w)
+
+	w = serve(http.MethodPatch, path, `{"ui.theme":"dark","notifications.marketing":true}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"ui.theme":"dark"`)
+	assert.Contains(t, w.Body.String(), `"notifications.marketing":true`)
+	openapitest.AssertResponse(t, http.MethodPatch, "/api/v1/users/{id}/preferences", w)
+
+	w = serve(http.MethodPatch, path, `{"ui.theme":"pink","ui.font":"serif"}`)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	var response apiErrors.Response
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	require.NotNil(t, response.Error)
+	assert.Equal(t, apiErrors.CodeValidation, response.Error.Code)
+	assert.Equal(t, map[string]string{
+		"ui.theme": "must be one of: system, light, dark",
+		"ui.font":  "unknown preference",
+	}, response.Error.Fields)
+	openapitest.AssertResponse(t, http.MethodPatch, "/api/v1/users/{id}/preferences", w)
+
+	w = serve(http.MethodGet, "/api/v1/users/999999/preferences", "")
+	assert.Equal(t, http.StatusForbidden, w.Code, "users can only read their own preferences")
+}
@@ -0,0 +1,228 @@
+package user
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yeegeek/uyou-go-api-starter/internal/auth"
+	"github.com/yeegeek/uyou-go-api-starter/internal/config"
+	"github.com/yeegeek/uyou-go-api-starter/internal/testutil"
+)
+
+// setupAuthFlowRouter wires the auth endpoints against a real database so the
+// full login -> refresh -> logout cycle runs through token persistence and rotation
+func setupAuthFlowRouter(t *testing.T, cookieMode bool) *gin.Engine {
+	t.Helper()
+
+	db := testutil.NewSQLiteDB(t)
+
+	jwtCfg := &config.JWTConfig{
+		Secret:          "test-secret-that-is-long-enough-123",
+		AccessTokenTTL:  15 * time.Minute,
+		RefreshTokenTTL: 24 * time.Hour,
+		RefreshCookie:   config.RefreshCookieConfig{Enabled: cookieMode, Path: "/auth"},
+		AccessCookie:    config.AccessCookieConfig{Enabled: cookieMode},
+	}
+	authService := auth.NewServiceWithRepo(jwtCfg, db)
+	userService := NewService(NewRepository(db), newTestSecurityConfig())
+	accessCookie := auth.NewAccessCookie(jwtCfg)
+	handler := NewHandler(userService, authService,
+		WithRefreshCookie(auth.NewRefreshCookie(jwtCfg)),
+		WithAccessCookie(accessCookie),
+	)
+
+	router := testutil.NewTestRouter(t)
+	router.POST("/auth/register", handler.Register)
+	router.POST("/auth/login", handler.Login)
+	router.POST("/auth/refresh", handler.RefreshToken)
+	router.POST("/auth/logout", auth.AuthMiddleware(authService, auth.WithAccessCookie(accessCookie)), handler.Logout)
+	router.GET("/auth/me", auth.AuthMiddleware(authService, auth.WithAccessCookie(accessCookie)), handler.GetMe)
+	return router
+}
+
+type authFlowClient struct {
+	t       *testing.T
+	router  *gin.Engine
+	cookies map[string]*http.Cookie
+}
+
+func (c *authFlowClient) do(path string, body interface{}, header map[string]string) (*httptest.ResponseRecorder, map[string]interface{}) {
+	c.t.Helper()
+	return c.send(http.MethodPost, path, body, header)
+}
+
+func (c *authFlowClient) send(method, path string, body interface{}, header map[string]string) (*httptest.ResponseRecorder, map[string]interface{}) {
+	c.t.Helper()
+
+	var buf bytes.Buffer
+	if body != nil {
+		require.NoError(c.t, json.NewEncoder(&buf).Encode(body))
+	}
+	req := httptest.NewRequest(method, path, &buf)
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	for _, ck := range c.cookies {
+		req.AddCookie(ck)
+	}
+
+	w := httptest.NewRecorder()
+	c.router.ServeHTTP(w, req)
+
+	// Mimic a browser cookie jar: store new cookies and drop expired ones
+	for _, ck := range w.Result().Cookies() {
+		if ck.MaxAge < 0 {
+			delete(c.cookies, ck.Name)
+			continue
+		}
+		c.cookies[ck.Name] = ck
+	}
+
+	var response map[string]interface{}
+	_ = json.Unmarshal(w.Body.Bytes(), &response)
+	data, _ := response["data"].(map[string]interface{})
+	return w, data
+}
+
+func (c *authFlowClient) cookie(name string) string {
+	if ck, ok := c.cookies[name]; ok {
+		return ck.Value
+	}
+	return ""
+}
+
+func TestAuthFlow_RefreshTokenTransport(t *testing.T) {
+	registerReq := RegisterRequest{Name: "Flow User", Email: "flow@example.com", Password: "Password123!"}
+	loginReq := LoginRequest{Email: "flow@example.com", Password: "Password123!"}
+
+	t.Run("body mode", func(t *testing.T) {
+		client := &authFlowClient{t: t, router: setupAuthFlowRouter(t, false), cookies: map[string]*http.Cookie{}}
+
+		w, _ := client.do("/auth/register", registerReq, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		w, data := client.do("/auth/login", loginReq, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		refreshToken, _ := data["refresh_token"].(string)
+		require.NotEmpty(t, refreshToken)
+		assert.Empty(t, client.cookies)
+
+		w, data = client.do("/auth/refresh", gin.H{"refresh_token": refreshToken}, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		rotated, _ := data["refresh_token"].(string)
+		require.NotEmpty(t, rotated)
+		assert.NotEqual(t, refreshToken, rotated)
+		accessToken := data["access_token"].(string)
+
+		w, _ = client.do("/auth/logout", gin.H{"refresh_token": rotated}, map[string]string{"Authorization": "Bearer " + accessToken})
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		w, _ = client.do("/auth/refresh", gin.H{"refresh_token": rotated}, nil)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("cookie mode", func(t *testing.T) {
+		client := &authFlowClient{t: t, router: setupAuthFlowRouter(t, true), cookies: map[string]*http.Cookie{}}
+
+		w, data := client.do("/auth/register", registerReq, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.NotContains(t, data, "refresh_token")
+
+		w, data = client.do("/auth/login", loginReq, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.NotContains(t, data, "refresh_token")
+		loginRefresh := client.cookie("refresh_token")
+		require.NotEmpty(t, loginRefresh)
+		require.NotEmpty(t, client.cookie("csrf_token"))
+		assert.Equal(t, "/auth", client.cookies["refresh_token"].Path)
+
+		w, _ = client.do("/auth/refresh", nil, nil)
+		assert.Equal(t, http.StatusForbidden, w.Code, "refresh without csrf header must be rejected")
+
+		w, data = client.do("/auth/refresh", nil, map[string]string{auth.CSRFTokenHeader: client.cookie("csrf_token")})
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.NotContains(t, data, "refresh_token")
+		assert.NotEqual(t, loginRefresh, client.cookie("refresh_token"))
+		accessToken := data["access_token"].(string)
+
+		w, _ = client.do("/auth/logout", nil, map[string]string{
+			"Authorization":      "Bearer " + accessToken,
+			auth.CSRFTokenHeader: client.cookie("csrf_token"),
+		})
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, client.cookies, "logout should clear the refresh, access and csrf cookies")
+	})
+
+	t.Run("cookie mode authenticates via access cookie", func(t *testing.T) {
+		client := &authFlowClient{t: t, router: setupAuthFlowRouter(t, true), cookies: map[string]*http.Cookie{}}
+
+		w, _ := client.do("/auth/register", registerReq, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		w, data := client.do("/auth/login", loginReq, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		access := client.cookies["access_token"]
+		require.NotNil(t, access, "login should set the access cookie")
+		assert.Equal(t, data["access_token"], access.Value, "the access token stays in the body for existing clients")
+		assert.Equal(t, "/", access.Path)
+		assert.True(t, access.HttpOnly)
+		assert.True(t, access.Secure)
+		assert.Equal(t, http.SameSiteStrictMode, access.SameSite)
+		assert.Equal(t, 900, access.MaxAge)
+
+		w, data = client.send(http.MethodGet, "/auth/me", nil, nil)
+		require.Equal(t, http.StatusOK, w.Code, "the access cookie should authenticate without an Authorization header")
+		assert.Equal(t, "flow@example.com", data["email"])
+
+		w, _ = client.do("/auth/refresh", nil, map[string]string{auth.CSRFTokenHeader: client.cookie("csrf_token")})
+		require.Equal(t, http.StatusOK, w.Code)
+		refreshed := map[string]*http.Cookie{}
+		for _, ck := range w.Result().Cookies() {
+			refreshed[ck.Name] = ck
+		}
+		require.Contains(t, refreshed, "access_token", "refresh should reissue the access cookie")
+		assert.NotEmpty(t, refreshed["access_token"].Value)
+
+		w, _ = client.do("/auth/logout", nil, nil)
+		assert.Equal(t, http.StatusForbidden, w.Code, "cookie-authenticated POST without csrf header must be rejected")
+
+		w, _ = client.do("/auth/logout", nil, map[string]string{auth.CSRFTokenHeader: client.cookie("csrf_token")})
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, client.cookies, "logout should clear every auth cookie")
+
+		w, _ = client.send(http.MethodGet, "/auth/me", nil, nil)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("cookie mode with body transport for mobile clients", func(t *testing.T) {
+		client := &authFlowClient{t: t, router: setupAuthFlowRouter(t, true), cookies: map[string]*http.Cookie{}}
+		bodyTransport := map[string]string{auth.RefreshTokenTransportHeader: auth.RefreshTokenTransportBody}
+
+		w, _ := client.do("/auth/register", registerReq, bodyTransport)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		w, data := client.do("/auth/login", loginReq, bodyTransport)
+		require.Equal(t, http.StatusOK, w.Code)
+		refreshToken, _ := data["refresh_token"].(string)
+		require.NotEmpty(t, refreshToken)
+		assert.Empty(t, client.cookies)
+
+		w, data = client.do("/auth/refresh", gin.H{"refresh_token": refreshToken}, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		rotated, _ := data["refresh_token"].(string)
+		require.NotEmpty(t, rotated)
+		assert.Empty(t, client.cookies)
+
+		w, _ = client.do("/auth/logout", gin.H{"refresh_token": rotated}, map[string]string{"Authorization": "Bearer " + data["access_token"].(string)})
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+}
@@ -1,4 +1,6 @@
 // Package user 提供用户数据访问层，封装数据库操作
+// 数据库错误统一包装为 RepositoryError（errors.Is(err, ErrRepository) 成立），连接丢失、超时等临时性失败
+// 由错误处理中间件映射为 503，其他数据库错误为 500
 package user
 
 import (
@@ -9,22 +11,94 @@ import (
 
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
+
+	database "github.com/yeegeek/uyou-go-api-starter/internal/db"
 )
 
 type txKey struct{}
 
+// ErrRepository matches every error returned by the user repository for a failed database call
+var ErrRepository = errors.New("user repository error")
+
+// RepositoryError wraps a database failure with the repository operation that produced it.
+// It unwraps to both ErrRepository and the cause, so driver errors such as unique violations stay matchable.
+type RepositoryError struct {
+	Op  string
+	Err error
+}
+
+func (e *RepositoryError) Error() string {
+	return "user repository: " + e.Op + ": " + e.Err.Error()
+}
+
+func (e *RepositoryError) Unwrap() []error {
+	return []error{ErrRepository, e.Err}
+}
+
+// Transient reports whether retrying may succeed: a lost connection, a timeout, a deadlock or a
+// serialization failure. Anything else is a bug or a data problem and should surface as 500.
+func (e *RepositoryError) Transient() bool {
+	return database.IsTransientError(e.Err)
+}
+
+// repositoryError wraps err for op; nil stays nil and an already wrapped error is returned as is
+func repositoryError(op string, err error) error {
+	if err == nil {
+		return nil
+	}
+	var repoErr *RepositoryError
+	if errors.As(err, &repoErr) {
+		return err
+	}
+	return &RepositoryError{Op: op, Err: database.WrapError(err)}
+}
+
 // Repository defines user repository interface
 type Repository interface {
 	Create(ctx context.Context, user *User) error
 	FindByEmail(ctx context.Context, email string) (*User, error)
 	FindByID(ctx context.Context, id uint) (*User, error)
+	FindByEmailChangeToken(ctx context.Context, tokenHash string) (*User, error)
 	Update(ctx context.Context, user *User) error
 	Delete(ctx context.Context, id uint) error
 	ListAllUsers(ctx context.Context, filters UserFilterParams, page, perPage int) ([]User, int64, error)
 	AssignRole(ctx context.Context, userID uint, roleName string) error
 	RemoveRole(ctx context.Context, userID uint, roleName string) error
+	AssignRoleBulk(ctx context.Context, roleID uint, userIDs []uint) ([]uint, error)
+	RemoveRoleBulk(ctx context.Context, roleID uint, userIDs []uint) ([]uint, error)
+	FindExistingUserIDs(ctx context.Context, ids []uint) ([]uint, error)
+	PruneOrphanedRoleAssignments(ctx context.Context) (int, error)
+	SetActive(ctx context.Context, id uint, active bool) error
+	UpdatePassword(ctx context.Context, id uint, passwordHash string) error
+	ListPasswordHistory(ctx context.Context, userID uint, limit int) ([]string, error)
+	RecordPasswordHistory(ctx context.Context, userID uint, passwordHash string, keep int) error
+	RecordLoginFailure(ctx context.Context, userID uint, at, pruneBefore time.Time) error
+	ListLoginFailures(ctx context.Context, userID uint, since time.Time) ([]time.Time, error)
+	ClearLoginFailures(ctx context.Context, userID uint) error
+	CreateLoginAttempts(ctx context.Context, attempts []LoginAttempt) error
+	ListLoginAttempts(ctx context.Context, userID uint, limit, offset int) ([]LoginAttempt, error)
+	FindLastSuccessfulLogin(ctx context.Context, userID uint) (*time.Time, error)
+	DeleteLoginAttemptsBefore(ctx context.Context, before time.Time) (int64, error)
 	FindRoleByName(ctx context.Context, name string) (*Role, error)
+	FindRoleByID(ctx context.Context, id uint) (*Role, error)
+	CreateRole(ctx context.Context, role *Role) error
+	ListRoles(ctx context.Context) ([]Role, error)
+	UpdateRole(ctx context.Context, role *Role) error
+	DeleteRole(ctx context.Context, id uint) error
+	ListPermissions(ctx context.Context) ([]Permission, error)
+	FindPermissionsByNames(ctx context.Context, names []string) ([]Permission, error)
+	SetRolePermissions(ctx context.Context, roleID uint, permissionIDs []uint) error
 	GetUserRoles(ctx context.Context, userID uint) ([]Role, error)
+	CountUsers(ctx context.Context) (int64, error)
+	CountUsersByRole(ctx context.Context, roleName string) (int64, error)
+	CountUsersSince(ctx context.Context, since time.Time) (int64, error)
+	CreatePolicyAcceptances(ctx context.Context, acceptances []PolicyAcceptance) error
+	FindPolicyAcceptances(ctx context.Context, userID uint) ([]PolicyAcceptance, error)
+	ListPolicyAcceptances(ctx context.Context, filter PolicyAcceptanceFilter, page, perPage int) ([]PolicyAcceptance, int64, error)
+	FindPreferences(ctx context.Context, userID uint) ([]UserPreference, error)
+	UpsertPreferences(ctx context.Context, prefs []UserPreference) error
+	FindIdentity(ctx context.Context, provider, subject string) (*UserIdentity, error)
+	CreateIdentity(ctx context.Context, identity *UserIdentity) error
 	Transaction(ctx context.Context, fn func(context.Context) error) error
 }
 
@@ -49,20 +123,20 @@ func (r *repository) getDB(ctx context.Context) *gorm.DB {
 func (r *repository) Create(ctx context.Context, user *User) error {
 	result := r.getDB(ctx).WithContext(ctx).Create(user)
 	if result.Error != nil {
-		return result.Error
+		return repositoryError("Create", result.Error)
 	}
 	return nil
 }
 
-// FindByEmail finds a user by email
+// FindByEmail finds a user by email; the lookup is case-insensitive because stored emails are lower case
 func (r *repository) FindByEmail(ctx context.Context, email string) (*User, error) {
 	var user User
-	result := r.getDB(ctx).WithContext(ctx).Preload("Roles").Where("email = ?", email).First(&user)
+	result := r.getDB(ctx).WithContext(ctx).Preload("Roles").Where("email = ?", normalizeEmail(email)).First(&user)
 	if result.Error != nil {
 		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
 			return nil, nil
 		}
-		return nil, result.Error
+		return nil, repositoryError("FindByEmail", result.Error)
 	}
 	return &user, nil
 }
@@ -75,7 +149,23 @@ func (r *repository) FindByID(ctx context.Context, id uint) (*User, error) {
 		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
 			return nil, nil
 		}
-		return nil, result.Error
+		return nil, repositoryError("FindByID", result.Error)
+	}
+	return &user, nil
+}
+
+// FindByEmailChangeToken finds the user with a pending email change for tokenHash
+func (r *repository) FindByEmailChangeToken(ctx context.Context, tokenHash string) (*User, error) {
+	if tokenHash == "" {
+		return nil, nil
+	}
+	var user User
+	result := r.getDB(ctx).WithContext(ctx).Preload("Roles").Where("email_change_token_hash = ?", tokenHash).First(&user)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, repositoryError("FindByEmailChangeToken", result.Error)
 	}
 	return &user, nil
 }
@@ -83,9 +173,9 @@ func (r *repository) FindByID(ctx context.Context, id uint) (*User, error) {
 // Update updates a user in the database
 func (r *repository) Update(ctx context.Context, user *User) error {
 	// WHY: Save() syncs associations, potentially clearing roles
-	result := r.getDB(ctx).WithContext(ctx).Select("name", "email", "password_hash", "updated_at").Save(user)
+	result := r.getDB(ctx).WithContext(ctx).Select("name", "email", "password_hash", "pending_email", "email_change_token_hash", "email_change_expires_at", "updated_at").Save(user)
 	if result.Error != nil {
-		return result.Error
+		return repositoryError("Update", result.Error)
 	}
 	return nil
 }
@@ -94,7 +184,7 @@ func (r *repository) Update(ctx context.Context, user *User) error {
 func (r *repository) Delete(ctx context.Context, id uint) error {
 	result := r.getDB(ctx).WithContext(ctx).Delete(&User{}, id)
 	if result.Error != nil {
-		return result.Error
+		return repositoryError("Delete", result.Error)
 	}
 	if result.RowsAffected == 0 {
 		return gorm.ErrRecordNotFound
@@ -102,64 +192,377 @@ func (r *repository) Delete(ctx context.Context, id uint) error {
 	return nil
 }
 
-// ListAllUsers retrieves paginated list of users with filters
+// ListAllUsers retrieves paginated list of users with filters.
+// With CountNone the total is TotalUnknown and up to perPage+1 users are returned;
+// the extra row only signals that a next page exists.
 func (r *repository) ListAllUsers(ctx context.Context, filters UserFilterParams, page, perPage int) ([]User, int64, error) {
 	var users []User
 	var total int64
 
-	query := r.getDB(ctx).WithContext(ctx).Model(&User{}).Preload("Roles")
+	// WHY: Roles are hydrated in one batch after paging instead of preloading through the filtered query
+	query := r.getDB(ctx).WithContext(ctx).Model(&User{})
 
+	// WHY: Role and search filters are ANDed in one query; the role JOIN is only added when
+	// filtering by role so unfiltered listings stay a plain scan of users
 	if filters.Role != "" {
+		// WHY: A single JOIN filters by role; DISTINCT below keeps multi-role users to one row
 		query = query.Joins("JOIN user_roles ON user_roles.user_id = users.id").
 			Joins("JOIN roles ON roles.id = user_roles.role_id").
 			Where("roles.name = ?", filters.Role)
 	}
 
+	// WHY: Filter on active rather than status; active is the flag authentication checks
+	switch filters.Status {
+	case UserStatusActive:
+		query = query.Where("users.active = ?", true)
+	case UserStatusDisabled:
+		query = query.Where("users.active = ?", false)
+	}
+
 	if filters.Search != "" {
 		// WHY: Escape SQL LIKE wildcards to prevent incorrect matches
 		escapedSearch := strings.ReplaceAll(filters.Search, "%", "\\%")
 		escapedSearch = strings.ReplaceAll(escapedSearch, "_", "\\_")
 		searchPattern := "%" + escapedSearch + "%"
-		query = query.Where("users.name LIKE ? OR users.email LIKE ?", searchPattern, searchPattern)
+		// WHY: Parenthesize the OR so it cannot widen the role predicate; ESCAPE makes the
+		// backslash escapes above behave the same on PostgreSQL and SQLite
+		if filters.NameOnly {
+			query = query.Where("users.name LIKE ? ESCAPE '\\'", searchPattern)
+		} else {
+			query = query.Where("(users.name LIKE ? ESCAPE '\\' OR users.email LIKE ? ESCAPE '\\')", searchPattern, searchPattern)
+		}
 	}
 
-	// WHY: Count distinct user IDs when using JOINs to avoid inflated totals
-	if err := query.Distinct("users.id").Count(&total).Error; err != nil {
-		return nil, 0, err
+	limit := perPage
+	switch filters.CountMode {
+	case CountNone:
+		total = TotalUnknown
+		limit = perPage + 1
+	case CountEstimated:
+		if filters.Role == "" && filters.Search == "" && filters.Status == "" {
+			if estimate, ok := r.estimateUserCount(ctx); ok {
+				total = estimate
+				break
+			}
+		}
+		fallthrough
+	default:
+		// WHY: Count distinct user IDs when using JOINs to avoid inflated totals
+		if err := query.Distinct("users.id").Count(&total).Error; err != nil {
+			return nil, 0, repositoryError("ListAllUsers", err)
+		}
 	}
 
 	offset := (page - 1) * perPage
 
-	// Defense-in-depth: Validate sort parameters at repository layer
-	validSorts := map[string]bool{
-		"name": true, "email": true, "created_at": true, "updated_at": true,
+	// Defense-in-depth: Validate sort parameters at repository layer.
+	// Zero-value filters (e.g. from gRPC) use the default ordering.
+	if filters.Sort == "" {
+		filters.Sort = DefaultUserSort
+	}
+	if filters.Order == "" {
+		filters.Order = "desc"
 	}
-	if !validSorts[filters.Sort] {
-		return nil, 0, errors.New("invalid sort field")
+	sortColumn, ok := userSortColumns[filters.Sort]
+	if !ok {
+		return nil, 0, ErrInvalidSortField
 	}
 	if filters.Order != "asc" && filters.Order != "desc" {
 		return nil, 0, errors.New("invalid sort order")
 	}
 
-	// Use type-safe GORM clause to prevent SQL injection
-	orderColumn := clause.OrderByColumn{
-		Column: clause.Column{Table: "users", Name: filters.Sort},
-		Desc:   filters.Order == "desc",
+	// WHY: Use Distinct with explicit columns to avoid duplicate users with JOINs
+	selects := []interface{}{"users.*"}
+	if sortColumn.Table == roleSortTable {
+		// WHY: Aggregate roles per user so a user with several roles still appears once
+		query = query.Joins("LEFT JOIN (SELECT user_roles.user_id, MIN(roles.name) AS role_name FROM user_roles JOIN roles ON roles.id = user_roles.role_id GROUP BY user_roles.user_id) AS " + roleSortTable + " ON " + roleSortTable + ".user_id = users.id")
+		// WHY: PostgreSQL requires ORDER BY expressions of a SELECT DISTINCT to be selected
+		selects = append(selects, roleSortTable+".role_name")
 	}
 
-	// WHY: Use Distinct with explicit columns to avoid duplicate users with JOINs
-	if err := query.Distinct("users.*").Order(orderColumn).Limit(perPage).Offset(offset).Find(&users).Error; err != nil {
+	// Use type-safe GORM clause to prevent SQL injection.
+	// WHY: id breaks ties so rows sharing the sort value paginate deterministically
+	desc := filters.Order == "desc"
+	query = query.Distinct(selects...).
+		Order(clause.OrderByColumn{Column: sortColumn, Desc: desc}).
+		Order(clause.OrderByColumn{Column: clause.Column{Table: "users", Name: "id"}, Desc: desc})
+
+	if err := query.Limit(limit).Offset(offset).Find(&users).Error; err != nil {
+		return nil, 0, repositoryError("ListAllUsers", err)
+	}
+
+	if err := r.hydrateRoles(ctx, users); err != nil {
 		return nil, 0, err
 	}
 
 	return users, total, nil
 }
 
+// estimateUserCount reads the planner's row estimate for the users table.
+// Only PostgreSQL keeps these statistics; other dialects and never-analyzed tables report ok=false.
+func (r *repository) estimateUserCount(ctx context.Context) (int64, bool) {
+	db := r.getDB(ctx)
+	if db.Dialector.Name() != "postgres" {
+		return 0, false
+	}
+	var estimate float64
+	err := db.WithContext(ctx).
+		Raw("SELECT reltuples FROM pg_class WHERE oid = 'users'::regclass").
+		Scan(&estimate).Error
+	if err != nil || estimate < 0 {
+		return 0, false
+	}
+	return int64(estimate), true
+}
+
+// hydrateRoles loads the roles of all given users with a single query keyed by user ID
+func (r *repository) hydrateRoles(ctx context.Context, users []User) error {
+	if len(users) == 0 {
+		return nil
+	}
+
+	userIDs := make([]uint, len(users))
+	index := make(map[uint]int, len(users))
+	for i := range users {
+		userIDs[i] = users[i].ID
+		index[users[i].ID] = i
+		users[i].Roles = []Role{}
+	}
+
+	var rows []struct {
+		UserID      uint
+		ID          uint
+		Name        string
+		Description string
+		CreatedAt   time.Time
+		UpdatedAt   time.Time
+	}
+	err := r.getDB(ctx).WithContext(ctx).
+		Table("roles").
+		Select("user_roles.user_id, roles.id, roles.name, roles.description, roles.created_at, roles.updated_at").
+		Joins("JOIN user_roles ON user_roles.role_id = roles.id").
+		Where("user_roles.user_id IN ?", userIDs).
+		Order("roles.id").
+		Scan(&rows).Error
+	if err != nil {
+		return repositoryError("hydrateRoles", err)
+	}
+
+	for _, row := range rows {
+		i := index[row.UserID]
+		users[i].Roles = append(users[i].Roles, Role{
+			ID:          row.ID,
+			Name:        row.Name,
+			Description: row.Description,
+			CreatedAt:   row.CreatedAt,
+			UpdatedAt:   row.UpdatedAt,
+		})
+	}
+	return nil
+}
+
+// SetActive enables or disables a user account and bumps its token version,
+// so access tokens issued before the change are rejected when version checks are on
+func (r *repository) SetActive(ctx context.Context, id uint, active bool) error {
+	return repositoryError("SetActive", r.getDB(ctx).WithContext(ctx).Exec(
+		"UPDATE users SET active = ?, status = ?, token_version = token_version + 1, updated_at = ? WHERE id = ? AND deleted_at IS NULL",
+		active, (&User{Active: active}).AccountStatus(), time.Now(), id,
+	).Error)
+}
+
+// UpdatePassword replaces the user's password hash and bumps its token version,
+// so access tokens issued with the old password are rejected when version checks are on
+func (r *repository) UpdatePassword(ctx context.Context, id uint, passwordHash string) error {
+	return repositoryError("UpdatePassword", r.getDB(ctx).WithContext(ctx).Exec(
+		"UPDATE users SET password_hash = ?, token_version = token_version + 1, updated_at = ? WHERE id = ? AND deleted_at IS NULL",
+		passwordHash, time.Now(), id,
+	).Error)
+}
+
+// ListPasswordHistory returns up to limit of the user's prior password hashes, most recent first
+func (r *repository) ListPasswordHistory(ctx context.Context, userID uint, limit int) ([]string, error) {
+	var hashes []string
+	err := r.getDB(ctx).WithContext(ctx).Model(&PasswordHistory{}).
+		Where("user_id = ?", userID).
+		Order("created_at DESC, id DESC").
+		Limit(limit).
+		Pluck("password_hash", &hashes).Error
+	if err != nil {
+		return nil, repositoryError("ListPasswordHistory", err)
+	}
+	return hashes, nil
+}
+
+// RecordPasswordHistory stores a replaced password hash and keeps only the user's keep most recent
+// entries; with keep <= 0 nothing is stored and the user's history is cleared
+func (r *repository) RecordPasswordHistory(ctx context.Context, userID uint, passwordHash string, keep int) error {
+	db := r.getDB(ctx).WithContext(ctx)
+	if keep > 0 {
+		if err := db.Create(&PasswordHistory{UserID: userID, PasswordHash: passwordHash, CreatedAt: time.Now()}).Error; err != nil {
+			return repositoryError("RecordPasswordHistory", err)
+		}
+	}
+	recent := db.Model(&PasswordHistory{}).Select("id").
+		Where("user_id = ?", userID).
+		Order("created_at DESC, id DESC").
+		Limit(max(keep, 0))
+	return repositoryError("RecordPasswordHistory", db.
+		Where("user_id = ? AND id NOT IN (?)", userID, recent).
+		Delete(&PasswordHistory{}).Error)
+}
+
+// RecordLoginFailure stores a failed login at the given time and drops the user's failures older than pruneBefore
+func (r *repository) RecordLoginFailure(ctx context.Context, userID uint, at, pruneBefore time.Time) error {
+	db := r.getDB(ctx).WithContext(ctx)
+	if err := db.Create(&LoginFailure{UserID: userID, CreatedAt: at}).Error; err != nil {
+		return repositoryError("RecordLoginFailure", err)
+	}
+	return repositoryError("RecordLoginFailure", db.Where("user_id = ? AND created_at <= ?", userID, pruneBefore).Delete(&LoginFailure{}).Error)
+}
+
+// ListLoginFailures returns the times of the user's failed logins after since, most recent first
+func (r *repository) ListLoginFailures(ctx context.Context, userID uint, since time.Time) ([]time.Time, error) {
+	var times []time.Time
+	err := r.getDB(ctx).WithContext(ctx).Model(&LoginFailure{}).
+		Where("user_id = ? AND created_at > ?", userID, since).
+		Order("created_at DESC").
+		Pluck("created_at", &times).Error
+	if err != nil {
+		return nil, repositoryError("ListLoginFailures", err)
+	}
+	return times, nil
+}
+
+// ClearLoginFailures deletes all recorded failed logins of the user
+func (r *repository) ClearLoginFailures(ctx context.Context, userID uint) error {
+	return repositoryError("ClearLoginFailures", r.getDB(ctx).WithContext(ctx).Where("user_id = ?", userID).Delete(&LoginFailure{}).Error)
+}
+
+// CreateLoginAttempts stores a batch of login attempts with a single insert
+func (r *repository) CreateLoginAttempts(ctx context.Context, attempts []LoginAttempt) error {
+	if len(attempts) == 0 {
+		return nil
+	}
+	return repositoryError("CreateLoginAttempts", r.getDB(ctx).WithContext(ctx).Create(&attempts).Error)
+}
+
+// ListLoginAttempts returns the user's login attempts, most recent first
+func (r *repository) ListLoginAttempts(ctx context.Context, userID uint, limit, offset int) ([]LoginAttempt, error) {
+	var attempts []LoginAttempt
+	err := r.getDB(ctx).WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("created_at DESC, id DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&attempts).Error
+	if err != nil {
+		return nil, repositoryError("ListLoginAttempts", err)
+	}
+	return attempts, nil
+}
+
+// CreatePolicyAcceptances stores policy acceptance records in one insert
+func (r *repository) CreatePolicyAcceptances(ctx context.Context, acceptances []PolicyAcceptance) error {
+	if len(acceptances) == 0 {
+		return nil
+	}
+	return repositoryError("CreatePolicyAcceptances", r.getDB(ctx).WithContext(ctx).Create(&acceptances).Error)
+}
+
+// FindPolicyAcceptances returns all of the user's policy acceptances, oldest first
+func (r *repository) FindPolicyAcceptances(ctx context.Context, userID uint) ([]PolicyAcceptance, error) {
+	var acceptances []PolicyAcceptance
+	err := r.getDB(ctx).WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("accepted_at ASC, id ASC").
+		Find(&acceptances).Error
+	if err != nil {
+		return nil, repositoryError("FindPolicyAcceptances", err)
+	}
+	return acceptances, nil
+}
+
+// FindPreferences returns the user's stored preferences ordered by key
+func (r *repository) FindPreferences(ctx context.Context, userID uint) ([]UserPreference, error) {
+	var prefs []UserPreference
+	err := r.getDB(ctx).WithContext(ctx).Where("user_id = ?", userID).Order("key").Find(&prefs).Error
+	if err != nil {
+		return nil, repositoryError("FindPreferences", err)
+	}
+	return prefs, nil
+}
+
+// UpsertPreferences inserts or overwrites each (user, key) row in one statement.
+// Rows are keyed per preference, so concurrent updates of different keys never overwrite each other.
+func (r *repository) UpsertPreferences(ctx context.Context, prefs []UserPreference) error {
+	if len(prefs) == 0 {
+		return nil
+	}
+	err := r.getDB(ctx).WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "key"}},
+		DoUpdates: clause.AssignmentColumns([]string{"value", "updated_at"}),
+	}).Create(&prefs).Error
+	return repositoryError("UpsertPreferences", err)
+}
+
+// ListPolicyAcceptances returns a page of policy acceptances matching filter, most recent first
+func (r *repository) ListPolicyAcceptances(ctx context.Context, filter PolicyAcceptanceFilter, page, perPage int) ([]PolicyAcceptance, int64, error) {
+	query := r.getDB(ctx).WithContext(ctx).Model(&PolicyAcceptance{})
+	if filter.DocumentType != "" {
+		query = query.Where("document_type = ?", filter.DocumentType)
+	}
+	if filter.Version != "" {
+		query = query.Where("version = ?", filter.Version)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, repositoryError("ListPolicyAcceptances", err)
+	}
+
+	var acceptances []PolicyAcceptance
+	err := query.
+		Order("accepted_at DESC, id DESC").
+		Limit(perPage).
+		Offset((page - 1) * perPage).
+		Find(&acceptances).Error
+	if err != nil {
+		return nil, 0, repositoryError("ListPolicyAcceptances", err)
+	}
+	return acceptances, total, nil
+}
+
+// FindLastSuccessfulLogin returns the time of the user's latest successful login, nil when there is none
+func (r *repository) FindLastSuccessfulLogin(ctx context.Context, userID uint) (*time.Time, error) {
+	var attempts []LoginAttempt
+	err := r.getDB(ctx).WithContext(ctx).
+		Where("user_id = ? AND outcome = ?", userID, LoginOutcomeSuccess).
+		Order("created_at DESC").
+		Limit(1).
+		Find(&attempts).Error
+	if err != nil {
+		return nil, repositoryError("FindLastSuccessfulLogin", err)
+	}
+	if len(attempts) == 0 {
+		return nil, nil
+	}
+	return &attempts[0].CreatedAt, nil
+}
+
+// DeleteLoginAttemptsBefore deletes login attempts older than before and returns how many were removed
+func (r *repository) DeleteLoginAttemptsBefore(ctx context.Context, before time.Time) (int64, error) {
+	result := r.getDB(ctx).WithContext(ctx).Where("created_at < ?", before).Delete(&LoginAttempt{})
+	if result.Error != nil {
+		return 0, repositoryError("DeleteLoginAttemptsBefore", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
 // AssignRole assigns a role to a user
 func (r *repository) AssignRole(ctx context.Context, userID uint, roleName string) error {
 	role, err := r.FindRoleByName(ctx, roleName)
 	if err != nil {
-		return err
+		return repositoryError("AssignRole", err)
 	}
 	if role == nil {
 		return errors.New("role not found")
@@ -167,61 +570,320 @@ func (r *repository) AssignRole(ctx context.Context, userID uint, roleName strin
 
 	// Use database-level conflict handling for race-safe, idempotent role assignment
 	// Works with both PostgreSQL and SQLite
-	return r.getDB(ctx).WithContext(ctx).Exec(`
+	result := r.getDB(ctx).WithContext(ctx).Exec(`
 		INSERT INTO user_roles (user_id, role_id, assigned_at)
 		VALUES (?, ?, ?)
 		ON CONFLICT (user_id, role_id) DO NOTHING
-	`, userID, role.ID, time.Now()).Error
+	`, userID, role.ID, time.Now())
+	if result.Error != nil {
+		return repositoryError("AssignRole", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		// WHY: The user already held the role, so permissions are unchanged and issued tokens stay valid
+		return nil
+	}
+
+	return r.bumpTokenVersion(ctx, userID)
 }
 
 // RemoveRole removes a role from a user
 func (r *repository) RemoveRole(ctx context.Context, userID uint, roleName string) error {
 	role, err := r.FindRoleByName(ctx, roleName)
 	if err != nil {
-		return err
+		return repositoryError("RemoveRole", err)
 	}
 	if role == nil {
 		return errors.New("role not found")
 	}
 
-	return r.getDB(ctx).WithContext(ctx).Exec(
+	result := r.getDB(ctx).WithContext(ctx).Exec(
 		"DELETE FROM user_roles WHERE user_id = ? AND role_id = ?",
 		userID, role.ID,
-	).Error
+	)
+	if result.Error != nil {
+		return repositoryError("RemoveRole", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil
+	}
+
+	return r.bumpTokenVersion(ctx, userID)
+}
+
+// AssignRoleBulk grants a role to many users with a single multi-row insert.
+// It returns the users that did not already hold the role; only their token versions are bumped.
+func (r *repository) AssignRoleBulk(ctx context.Context, roleID uint, userIDs []uint) ([]uint, error) {
+	if len(userIDs) == 0 {
+		return nil, nil
+	}
+
+	now := time.Now()
+	placeholders := make([]string, len(userIDs))
+	args := make([]any, 0, len(userIDs)*3)
+	for i, userID := range userIDs {
+		placeholders[i] = "(?, ?, ?)"
+		args = append(args, userID, roleID, now)
+	}
+
+	var assigned []uint
+	err := r.getDB(ctx).WithContext(ctx).Raw(
+		"INSERT INTO user_roles (user_id, role_id, assigned_at) VALUES "+strings.Join(placeholders, ", ")+
+			" ON CONFLICT (user_id, role_id) DO NOTHING RETURNING user_id",
+		args...,
+	).Scan(&assigned).Error
+	if err != nil {
+		return nil, repositoryError("AssignRoleBulk", err)
+	}
+
+	return assigned, r.bumpTokenVersions(ctx, assigned)
+}
+
+// RemoveRoleBulk revokes a role from many users with a single delete.
+// It returns the users that actually held the role; only their token versions are bumped.
+func (r *repository) RemoveRoleBulk(ctx context.Context, roleID uint, userIDs []uint) ([]uint, error) {
+	if len(userIDs) == 0 {
+		return nil, nil
+	}
+
+	var removed []uint
+	err := r.getDB(ctx).WithContext(ctx).Raw(
+		"DELETE FROM user_roles WHERE role_id = ? AND user_id IN ? RETURNING user_id",
+		roleID, userIDs,
+	).Scan(&removed).Error
+	if err != nil {
+		return nil, repositoryError("RemoveRoleBulk", err)
+	}
+
+	return removed, r.bumpTokenVersions(ctx, removed)
+}
+
+// FindExistingUserIDs returns the subset of ids that belong to users that exist and are not deleted
+func (r *repository) FindExistingUserIDs(ctx context.Context, ids []uint) ([]uint, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	var existing []uint
+	if err := r.getDB(ctx).WithContext(ctx).Model(&User{}).Where("id IN ?", ids).Pluck("id", &existing).Error; err != nil {
+		return nil, repositoryError("FindExistingUserIDs", err)
+	}
+	return existing, nil
+}
+
+// PruneOrphanedRoleAssignments deletes user_roles rows whose user or role row no longer exists and
+// returns how many were removed. Assignments of soft-deleted users are kept, since the user row
+// still exists and can be restored.
+func (r *repository) PruneOrphanedRoleAssignments(ctx context.Context) (int, error) {
+	// WHY: Foreign keys normally cascade, but rows deleted while they were not enforced (SQLite,
+	// manual cleanup, restored dumps) leave assignments behind
+	result := r.getDB(ctx).WithContext(ctx).Exec(`DELETE FROM user_roles
+		WHERE NOT EXISTS (SELECT 1 FROM users WHERE users.id = user_roles.user_id)
+		OR NOT EXISTS (SELECT 1 FROM roles WHERE roles.id = user_roles.role_id)`)
+	if result.Error != nil {
+		return 0, repositoryError("PruneOrphanedRoleAssignments", result.Error)
+	}
+	return int(result.RowsAffected), nil
+}
+
+// bumpTokenVersions increments the token version of every user in userIDs with one statement
+func (r *repository) bumpTokenVersions(ctx context.Context, userIDs []uint) error {
+	if len(userIDs) == 0 {
+		return nil
+	}
+	return repositoryError("bumpTokenVersions", r.getDB(ctx).WithContext(ctx).Exec(
+		"UPDATE users SET token_version = token_version + 1 WHERE id IN ?",
+		userIDs,
+	).Error)
+}
+
+// bumpTokenVersion increments the user's token version so access tokens issued
+// before a role change can be detected as stale
+func (r *repository) bumpTokenVersion(ctx context.Context, userID uint) error {
+	return repositoryError("bumpTokenVersion", r.getDB(ctx).WithContext(ctx).Exec(
+		"UPDATE users SET token_version = token_version + 1 WHERE id = ?",
+		userID,
+	).Error)
 }
 
 // FindRoleByName finds a role by name
 func (r *repository) FindRoleByName(ctx context.Context, name string) (*Role, error) {
 	var role Role
-	result := r.getDB(ctx).WithContext(ctx).Where("name = ?", name).First(&role)
+	result := r.getDB(ctx).WithContext(ctx).Preload("Permissions").Where("name = ?", name).First(&role)
 	if result.Error != nil {
 		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
 			return nil, nil
 		}
-		return nil, result.Error
+		return nil, repositoryError("FindRoleByName", result.Error)
 	}
 	return &role, nil
 }
 
-// GetUserRoles retrieves all roles for a user
+// FindRoleByID finds a role by ID
+func (r *repository) FindRoleByID(ctx context.Context, id uint) (*Role, error) {
+	var role Role
+	result := r.getDB(ctx).WithContext(ctx).Preload("Permissions").First(&role, id)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, repositoryError("FindRoleByID", result.Error)
+	}
+	return &role, nil
+}
+
+// CreateRole creates a new role
+func (r *repository) CreateRole(ctx context.Context, role *Role) error {
+	return repositoryError("CreateRole", r.getDB(ctx).WithContext(ctx).Create(role).Error)
+}
+
+// ListRoles retrieves all roles ordered by ID
+func (r *repository) ListRoles(ctx context.Context) ([]Role, error) {
+	var roles []Role
+	if err := r.getDB(ctx).WithContext(ctx).Preload("Permissions").Order("id").Find(&roles).Error; err != nil {
+		return nil, repositoryError("ListRoles", err)
+	}
+	return roles, nil
+}
+
+// UpdateRole updates a role's description
+func (r *repository) UpdateRole(ctx context.Context, role *Role) error {
+	// WHY: Role names are referenced by code and tokens, so only the description is mutable
+	return repositoryError("UpdateRole", r.getDB(ctx).WithContext(ctx).Select("description", "updated_at").Save(role).Error)
+}
+
+// DeleteRole deletes a role by ID
+func (r *repository) DeleteRole(ctx context.Context, id uint) error {
+	result := r.getDB(ctx).WithContext(ctx).Delete(&Role{}, id)
+	if result.Error != nil {
+		return repositoryError("DeleteRole", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// ListPermissions retrieves all permissions ordered by name
+func (r *repository) ListPermissions(ctx context.Context) ([]Permission, error) {
+	var permissions []Permission
+	if err := r.getDB(ctx).WithContext(ctx).Order("name").Find(&permissions).Error; err != nil {
+		return nil, repositoryError("ListPermissions", err)
+	}
+	return permissions, nil
+}
+
+// FindPermissionsByNames retrieves the permissions matching the given names
+func (r *repository) FindPermissionsByNames(ctx context.Context, names []string) ([]Permission, error) {
+	var permissions []Permission
+	if len(names) == 0 {
+		return permissions, nil
+	}
+	if err := r.getDB(ctx).WithContext(ctx).Where("name IN ?", names).Find(&permissions).Error; err != nil {
+		return nil, repositoryError("FindPermissionsByNames", err)
+	}
+	return permissions, nil
+}
+
+// SetRolePermissions replaces the permissions granted to a role
+func (r *repository) SetRolePermissions(ctx context.Context, roleID uint, permissionIDs []uint) error {
+	db := r.getDB(ctx).WithContext(ctx)
+
+	if err := db.Exec("DELETE FROM role_permissions WHERE role_id = ?", roleID).Error; err != nil {
+		return repositoryError("SetRolePermissions", err)
+	}
+	for _, permissionID := range permissionIDs {
+		if err := db.Exec(`
+			INSERT INTO role_permissions (role_id, permission_id, granted_at)
+			VALUES (?, ?, ?)
+			ON CONFLICT (role_id, permission_id) DO NOTHING
+		`, roleID, permissionID, time.Now()).Error; err != nil {
+			return repositoryError("SetRolePermissions", err)
+		}
+	}
+
+	// WHY: Permissions are embedded in access tokens, so holders of the role must re-authenticate
+	return repositoryError("SetRolePermissions", db.Exec(
+		"UPDATE users SET token_version = token_version + 1 WHERE id IN (SELECT user_id FROM user_roles WHERE role_id = ?)",
+		roleID,
+	).Error)
+}
+
+// GetUserRoles retrieves all roles for a user with their granted permissions
 func (r *repository) GetUserRoles(ctx context.Context, userID uint) ([]Role, error) {
 	var roles []Role
 	err := r.getDB(ctx).WithContext(ctx).
 		Table("roles").
+		Preload("Permissions").
 		Joins("JOIN user_roles ON user_roles.role_id = roles.id").
 		Where("user_roles.user_id = ?", userID).
+		Order("roles.id").
 		Find(&roles).Error
 	if err != nil {
-		return nil, err
+		return nil, repositoryError("GetUserRoles", err)
 	}
 	return roles, nil
 }
 
+// CountUsers returns the number of users that are not soft deleted
+func (r *repository) CountUsers(ctx context.Context) (int64, error) {
+	var count int64
+	if err := r.getDB(ctx).WithContext(ctx).Model(&User{}).Count(&count).Error; err != nil {
+		return 0, repositoryError("CountUsers", err)
+	}
+	return count, nil
+}
+
+// CountUsersByRole returns the number of users holding the given role
+func (r *repository) CountUsersByRole(ctx context.Context, roleName string) (int64, error) {
+	var count int64
+	err := r.getDB(ctx).WithContext(ctx).Model(&User{}).
+		Joins("JOIN user_roles ON user_roles.user_id = users.id").
+		Joins("JOIN roles ON roles.id = user_roles.role_id").
+		Where("roles.name = ?", roleName).
+		Distinct("users.id").
+		Count(&count).Error
+	if err != nil {
+		return 0, repositoryError("CountUsersByRole", err)
+	}
+	return count, nil
+}
+
+// CountUsersSince returns the number of users registered at or after since
+func (r *repository) CountUsersSince(ctx context.Context, since time.Time) (int64, error) {
+	var count int64
+	err := r.getDB(ctx).WithContext(ctx).Model(&User{}).
+		Where("created_at >= ?", since).
+		Count(&count).Error
+	if err != nil {
+		return 0, repositoryError("CountUsersSince", err)
+	}
+	return count, nil
+}
+
+// FindIdentity finds the user identity linked to a provider account
+func (r *repository) FindIdentity(ctx context.Context, provider, subject string) (*UserIdentity, error) {
+	var identity UserIdentity
+	result := r.getDB(ctx).WithContext(ctx).Where("provider = ? AND subject = ?", provider, subject).First(&identity)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, repositoryError("FindIdentity", result.Error)
+	}
+	return &identity, nil
+}
+
+// CreateIdentity links a provider account to a user
+func (r *repository) CreateIdentity(ctx context.Context, identity *UserIdentity) error {
+	return repositoryError("CreateIdentity", r.getDB(ctx).WithContext(ctx).Create(identity).Error)
+}
+
 // Transaction executes a function within a database transaction
 func (r *repository) Transaction(ctx context.Context, fn func(context.Context) error) error {
-	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		// Inject transaction into context
 		txCtx := context.WithValue(ctx, txKey{}, tx)
 		return fn(txCtx)
 	})
+	return repositoryError("Transaction", err)
 }
@@ -0,0 +1,192 @@
+//go:build integration
+
+package user
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yeegeek/uyou-go-api-starter/internal/testutil/pgtest"
+)
+
+func TestMain(m *testing.M) {
+	os.Exit(pgtest.Main(m))
+}
+
+func TestRepository_Postgres_ListAllUsers(t *testing.T) {
+	db := pgtest.NewDB(t)
+	repo := NewRepository(db)
+	ctx := context.Background()
+
+	seed := []struct {
+		name   string
+		email  string
+		roles  []string
+		active bool
+	}{
+		{name: "Alice Admin", email: "alice@example.com", roles: []string{RoleAdmin, RoleUser}, active: true},
+		{name: "Bob User", email: "bob@example.com", roles: []string{RoleUser}, active: true},
+		{name: "Carol_Underscore", email: "carol@example.org", roles: []string{RoleUser}, active: false},
+		{name: "Dave NoRole", email: "dave@example.com", active: true},
+	}
+	for _, s := range seed {
+		u := &User{Name: s.name, Email: s.email, PasswordHash: "hash"}
+		require.NoError(t, repo.Create(ctx, u))
+		for _, role := range s.roles {
+			require.NoError(t, repo.AssignRole(ctx, u.ID, role))
+		}
+		if !s.active {
+			require.NoError(t, repo.SetActive(ctx, u.ID, false))
+		}
+	}
+
+	tests := []struct {
+		name       string
+		filters    UserFilterParams
+		wantEmails []string
+		wantTotal  int64
+	}{
+		{
+			name:       "sort by email asc",
+			filters:    UserFilterParams{Sort: "email", Order: "asc"},
+			wantEmails: []string{"alice@example.com", "bob@example.com", "carol@example.org", "dave@example.com"},
+			wantTotal:  4,
+		},
+		{
+			name:       "sort by name desc",
+			filters:    UserFilterParams{Sort: "name", Order: "desc"},
+			wantEmails: []string{"dave@example.com", "carol@example.org", "bob@example.com", "alice@example.com"},
+			wantTotal:  4,
+		},
+		{
+			// PostgreSQL requires the ORDER BY expression of SELECT DISTINCT to be selected; users without roles sort last
+			name:       "sort by role asc",
+			filters:    UserFilterParams{Sort: "role", Order: "asc"},
+			wantEmails: []string{"alice@example.com", "bob@example.com", "carol@example.org", "dave@example.com"},
+			wantTotal:  4,
+		},
+		{
+			name:       "multi-role user appears once with a role filter",
+			filters:    UserFilterParams{Role: RoleUser, Sort: "email", Order: "asc"},
+			wantEmails: []string{"alice@example.com", "bob@example.com", "carol@example.org"},
+			wantTotal:  3,
+		},
+		{
+			name:       "role and search are combined",
+			filters:    UserFilterParams{Role: RoleUser, Search: "example.com", Sort: "email", Order: "asc"},
+			wantEmails: []string{"alice@example.com", "bob@example.com"},
+			wantTotal:  2,
+		},
+		{
+			name:       "underscore in search is literal",
+			filters:    UserFilterParams{Search: "l_U", Sort: "email", Order: "asc"},
+			wantEmails: []string{"carol@example.org"},
+			wantTotal:  1,
+		},
+		{
+			name:       "percent in search is literal",
+			filters:    UserFilterParams{Search: "%", Sort: "email", Order: "asc"},
+			wantEmails: []string{},
+			wantTotal:  0,
+		},
+		{
+			name:       "disabled users",
+			filters:    UserFilterParams{Status: UserStatusDisabled, Sort: "email", Order: "asc"},
+			wantEmails: []string{"carol@example.org"},
+			wantTotal:  1,
+		},
+		{
+			name:       "active users",
+			filters:    UserFilterParams{Status: UserStatusActive, Sort: "email", Order: "desc"},
+			wantEmails: []string{"dave@example.com", "bob@example.com", "alice@example.com"},
+			wantTotal:  3,
+		},
+		{
+			name:       "count none",
+			filters:    UserFilterParams{Sort: "email", Order: "asc", CountMode: CountNone},
+			wantEmails: []string{"alice@example.com", "bob@example.com", "carol@example.org", "dave@example.com"},
+			wantTotal:  TotalUnknown,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users, total, err := repo.ListAllUsers(ctx, tt.filters, 1, 10)
+			require.NoError(t, err)
+			emails := make([]string, 0, len(users))
+			for _, u := range users {
+				emails = append(emails, u.Email)
+			}
+			assert.Equal(t, tt.wantEmails, emails)
+			assert.Equal(t, tt.wantTotal, total)
+		})
+	}
+
+	t.Run("estimated count falls back to exact on a never-analyzed table", func(t *testing.T) {
+		_, total, err := repo.ListAllUsers(ctx, UserFilterParams{CountMode: CountEstimated}, 1, 10)
+		require.NoError(t, err)
+		assert.Positive(t, total)
+	})
+
+	t.Run("pages do not overlap", func(t *testing.T) {
+		seen := map[uint]bool{}
+		for page := 1; page <= 2; page++ {
+			users, _, err := repo.ListAllUsers(ctx, UserFilterParams{Sort: "created_at", Order: "desc"}, page, 2)
+			require.NoError(t, err)
+			require.Len(t, users, 2)
+			for _, u := range users {
+				assert.False(t, seen[u.ID], "user %d returned twice", u.ID)
+				seen[u.ID] = true
+			}
+		}
+	})
+}
+
+func TestService_Postgres_ConcurrentRegistration(t *testing.T) {
+	db := pgtest.NewDB(t)
+	service := NewService(NewRepository(db), newTestSecurityConfig())
+
+	const attempts = 8
+	var wg sync.WaitGroup
+	errs := make([]error, attempts)
+	start := make(chan struct{})
+	for i := range attempts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			_, errs[i] = service.RegisterUser(context.Background(), RegisterRequest{
+				Name:     fmt.Sprintf("Racer %d", i),
+				Email:    "race@example.com",
+				Password: "Password123!",
+			})
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	var created, conflicts int
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			created++
+		case errors.Is(err, ErrEmailExists):
+			conflicts++
+		default:
+			t.Errorf("unexpected error: %v", err)
+		}
+	}
+	assert.Equal(t, 1, created)
+	assert.Equal(t, attempts-1, conflicts)
+
+	var count int64
+	require.NoError(t, db.Model(&User{}).Where("email = ?", "race@example.com").Count(&count).Error)
+	assert.Equal(t, int64(1), count)
+}
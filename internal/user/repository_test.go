@@ -3,65 +3,24 @@ package user
 import (
 	"context"
 	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
-	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
-)
-
-func setupTestDB(t *testing.T) *gorm.DB {
-	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
-	require.NoError(t, err)
-
-	sqlDB, err := db.DB()
-	require.NoError(t, err)
-
-	_, err = sqlDB.Exec(`
-		CREATE TABLE users (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			name TEXT NOT NULL,
-			email TEXT UNIQUE NOT NULL,
-			password_hash TEXT NOT NULL,
-			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-			deleted_at DATETIME
-		);
-		CREATE INDEX idx_users_email ON users(email);
-		CREATE INDEX idx_users_deleted_at ON users(deleted_at);
-
-		CREATE TABLE roles (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			name TEXT UNIQUE NOT NULL,
-			description TEXT,
-			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
-		);
-		CREATE INDEX idx_roles_name ON roles(name);
-
-		CREATE TABLE user_roles (
-			user_id INTEGER NOT NULL,
-			role_id INTEGER NOT NULL,
-			assigned_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
-			PRIMARY KEY (user_id, role_id),
-			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
-			FOREIGN KEY (role_id) REFERENCES roles(id) ON DELETE CASCADE
-		);
-		CREATE INDEX idx_user_roles_user_id ON user_roles(user_id);
-		CREATE INDEX idx_user_roles_role_id ON user_roles(role_id);
-
-		INSERT INTO roles (id, name, description) VALUES 
-			(1, 'user', 'Standard user with basic permissions'),
-			(2, 'admin', 'Administrator with full system access');
-	`)
-	require.NoError(t, err)
 
-	return db
-}
+	apiErrors "github.com/yeegeek/uyou-go-api-starter/internal/errors"
+	"github.com/yeegeek/uyou-go-api-starter/internal/testutil"
+)
 
 func TestNewRepository(t *testing.T) {
-	db := setupTestDB(t)
+	db := testutil.NewSQLiteDB(t)
 	repo := NewRepository(db)
 
 	assert.NotNil(t, repo)
@@ -69,7 +28,7 @@ func TestNewRepository(t *testing.T) {
 }
 
 func TestRepository_Create(t *testing.T) {
-	db := setupTestDB(t)
+	db := testutil.NewSQLiteDB(t)
 	repo := NewRepository(db)
 
 	user := &User{
@@ -86,7 +45,7 @@ func TestRepository_Create(t *testing.T) {
 }
 
 func TestRepository_Create_DuplicateEmail(t *testing.T) {
-	db := setupTestDB(t)
+	db := testutil.NewSQLiteDB(t)
 	repo := NewRepository(db)
 
 	user1 := &User{
@@ -106,8 +65,31 @@ func TestRepository_Create_DuplicateEmail(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestRepository_UnsetUsernameAndFingerprintAreNull(t *testing.T) {
+	db := testutil.NewSQLiteDB(t)
+	repo := NewRepository(db)
+	ctx := context.Background()
+
+	// WHY: PostgreSQL rejects a second empty username under the UNIQUE constraint and an empty
+	// fingerprint as invalid JSONB, so unset values must be written as NULL
+	for _, email := range []string{"john@example.com", "jane@example.com"} {
+		user := &User{Name: "User", Email: email, PasswordHash: "hashed_password"}
+		require.NoError(t, repo.Create(ctx, user))
+		user.Name = "Renamed"
+		require.NoError(t, repo.Update(ctx, user))
+	}
+
+	var nulls int64
+	require.NoError(t, db.Table("users").Where("username IS NULL AND fingerprint IS NULL").Count(&nulls).Error)
+	assert.Equal(t, int64(2), nulls)
+
+	found, err := repo.FindByEmail(ctx, "john@example.com")
+	require.NoError(t, err)
+	assert.Empty(t, found.GetUsername())
+}
+
 func TestRepository_FindByEmail(t *testing.T) {
-	db := setupTestDB(t)
+	db := testutil.NewSQLiteDB(t)
 	repo := NewRepository(db)
 
 	originalUser := &User{
@@ -134,7 +116,7 @@ func TestRepository_FindByEmail(t *testing.T) {
 }
 
 func TestRepository_FindByID(t *testing.T) {
-	db := setupTestDB(t)
+	db := testutil.NewSQLiteDB(t)
 	repo := NewRepository(db)
 
 	originalUser := &User{
@@ -162,7 +144,7 @@ func TestRepository_FindByID(t *testing.T) {
 }
 
 func TestRepository_Update(t *testing.T) {
-	db := setupTestDB(t)
+	db := testutil.NewSQLiteDB(t)
 	repo := NewRepository(db)
 
 	user := &User{
@@ -186,7 +168,7 @@ func TestRepository_Update(t *testing.T) {
 }
 
 func TestRepository_Update_NonExistentUser(t *testing.T) {
-	db := setupTestDB(t)
+	db := testutil.NewSQLiteDB(t)
 	repo := NewRepository(db)
 
 	user := &User{
@@ -202,7 +184,7 @@ func TestRepository_Update_NonExistentUser(t *testing.T) {
 }
 
 func TestRepository_Delete(t *testing.T) {
-	db := setupTestDB(t)
+	db := testutil.NewSQLiteDB(t)
 	repo := NewRepository(db)
 
 	user := &User{
@@ -222,7 +204,7 @@ func TestRepository_Delete(t *testing.T) {
 }
 
 func TestRepository_Delete_NonExistentUser(t *testing.T) {
-	db := setupTestDB(t)
+	db := testutil.NewSQLiteDB(t)
 	repo := NewRepository(db)
 
 	err := repo.Delete(context.Background(), 999999)
@@ -232,7 +214,7 @@ func TestRepository_Delete_NonExistentUser(t *testing.T) {
 }
 
 func TestRepository_FindRoleByName(t *testing.T) {
-	db := setupTestDB(t)
+	db := testutil.NewSQLiteDB(t)
 	repo := NewRepository(db)
 
 	t.Run("role found", func(t *testing.T) {
@@ -255,7 +237,7 @@ func TestRepository_FindRoleByName(t *testing.T) {
 }
 
 func TestRepository_AssignRole(t *testing.T) {
-	db := setupTestDB(t)
+	db := testutil.NewSQLiteDB(t)
 	repo := NewRepository(db)
 
 	user := &User{Name: "John Doe", Email: "john@example.com", PasswordHash: "hash"}
@@ -294,7 +276,7 @@ func TestRepository_AssignRole(t *testing.T) {
 }
 
 func TestRepository_RemoveRole(t *testing.T) {
-	db := setupTestDB(t)
+	db := testutil.NewSQLiteDB(t)
 	repo := NewRepository(db)
 
 	user := &User{Name: "John Doe", Email: "john@example.com", PasswordHash: "hash"}
@@ -331,8 +313,229 @@ func TestRepository_RemoveRole(t *testing.T) {
 	})
 }
 
+func createBulkUsers(t *testing.T, repo Repository, n int) []uint {
+	t.Helper()
+	ids := make([]uint, n)
+	for i := range ids {
+		user := &User{Name: fmt.Sprintf("Bulk %03d", i), Email: fmt.Sprintf("bulk%03d@example.com", i), PasswordHash: "hash"}
+		require.NoError(t, repo.Create(context.Background(), user))
+		ids[i] = user.ID
+	}
+	return ids
+}
+
+func tokenVersions(t *testing.T, db *gorm.DB, ids []uint) []int {
+	t.Helper()
+	var versions []int
+	require.NoError(t, db.Table("users").Where("id IN ?", ids).Order("id").Pluck("token_version", &versions).Error)
+	return versions
+}
+
+func TestRepository_AssignRoleBulk(t *testing.T) {
+	db := testutil.NewSQLiteDB(t)
+	repo := NewRepository(db)
+	ctx := context.Background()
+
+	admin, err := repo.FindRoleByName(ctx, RoleAdmin)
+	require.NoError(t, err)
+	ids := createBulkUsers(t, repo, 150)
+	require.NoError(t, repo.AssignRole(ctx, ids[0], RoleAdmin))
+
+	queries := countQueries(t, db)
+	assigned, err := repo.AssignRoleBulk(ctx, admin.ID, ids)
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, queries(), "all rows are inserted with a single statement")
+	assert.ElementsMatch(t, ids[1:], assigned, "users that already held the role are not reported")
+
+	var count int64
+	require.NoError(t, db.Table("user_roles").Where("role_id = ?", admin.ID).Count(&count).Error)
+	assert.Equal(t, int64(150), count)
+
+	versions := tokenVersions(t, db, ids[:2])
+	assert.Equal(t, []int{1, 1}, versions, "the pre-existing holder is bumped once by AssignRole only")
+
+	t.Run("empty input", func(t *testing.T) {
+		assigned, err := repo.AssignRoleBulk(ctx, admin.ID, nil)
+		assert.NoError(t, err)
+		assert.Empty(t, assigned)
+	})
+}
+
+func TestRepository_RemoveRoleBulk(t *testing.T) {
+	db := testutil.NewSQLiteDB(t)
+	repo := NewRepository(db)
+	ctx := context.Background()
+
+	admin, err := repo.FindRoleByName(ctx, RoleAdmin)
+	require.NoError(t, err)
+	ids := createBulkUsers(t, repo, 3)
+	_, err = repo.AssignRoleBulk(ctx, admin.ID, ids[:2])
+	require.NoError(t, err)
+
+	removed, err := repo.RemoveRoleBulk(ctx, admin.ID, ids)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, ids[:2], removed)
+
+	var count int64
+	require.NoError(t, db.Table("user_roles").Where("role_id = ?", admin.ID).Count(&count).Error)
+	assert.Zero(t, count)
+	assert.Equal(t, []int{2, 2, 0}, tokenVersions(t, db, ids))
+}
+
+func TestRepository_FindExistingUserIDs(t *testing.T) {
+	db := testutil.NewSQLiteDB(t)
+	repo := NewRepository(db)
+	ctx := context.Background()
+
+	ids := createBulkUsers(t, repo, 3)
+	require.NoError(t, repo.Delete(ctx, ids[2]))
+
+	existing, err := repo.FindExistingUserIDs(ctx, append(ids, 999999))
+	require.NoError(t, err)
+	assert.ElementsMatch(t, ids[:2], existing, "missing and soft-deleted users are excluded")
+}
+
+func TestRepository_PruneOrphanedRoleAssignments(t *testing.T) {
+	db := testutil.NewSQLiteDB(t)
+	repo := NewRepository(db)
+	ctx := context.Background()
+
+	admin, err := repo.FindRoleByName(ctx, RoleAdmin)
+	require.NoError(t, err)
+	ids := createBulkUsers(t, repo, 3)
+	_, err = repo.AssignRoleBulk(ctx, admin.ID, ids)
+	require.NoError(t, err)
+	// A soft-deleted user still exists and keeps their assignment
+	require.NoError(t, repo.Delete(ctx, ids[2]))
+
+	temp := &Role{Name: "temp_role"}
+	require.NoError(t, repo.CreateRole(ctx, temp))
+	require.NoError(t, repo.AssignRole(ctx, ids[0], temp.Name))
+
+	// Foreign keys are not enforced by this SQLite connection, so dangling rows can be created directly
+	require.NoError(t, db.Exec("DELETE FROM users WHERE id = ?", ids[1]).Error)
+	require.NoError(t, db.Exec("DELETE FROM roles WHERE id = ?", temp.ID).Error)
+	require.NoError(t, db.Exec("INSERT INTO user_roles (user_id, role_id) VALUES (?, ?)", 999999, admin.ID).Error)
+
+	pruned, err := repo.PruneOrphanedRoleAssignments(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 3, pruned, "missing user, missing role and unknown user rows are removed")
+
+	var remaining []struct{ UserID, RoleID uint }
+	require.NoError(t, db.Table("user_roles").Order("user_id").Find(&remaining).Error)
+	assert.Equal(t, []struct{ UserID, RoleID uint }{{ids[0], admin.ID}, {ids[2], admin.ID}}, remaining)
+
+	pruned, err = repo.PruneOrphanedRoleAssignments(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, pruned)
+}
+
+func TestRepository_Active(t *testing.T) {
+	db := testutil.NewSQLiteDB(t)
+	repo := NewRepository(db)
+	ctx := context.Background()
+
+	user := &User{Name: "John Doe", Email: "john@example.com", PasswordHash: "hash"}
+	require.NoError(t, repo.Create(ctx, user))
+
+	found, err := repo.FindByID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.True(t, found.Active, "new users are active by default")
+
+	require.NoError(t, repo.SetActive(ctx, user.ID, false))
+	found, err = repo.FindByID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.False(t, found.Active)
+	assert.Equal(t, 1, found.TokenVersion, "deactivation invalidates issued access tokens")
+
+	require.NoError(t, repo.SetActive(ctx, user.ID, true))
+	found, err = repo.FindByID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.True(t, found.Active)
+}
+
+func TestRepository_LoginFailures(t *testing.T) {
+	db := testutil.NewSQLiteDB(t)
+	repo := NewRepository(db)
+	ctx := context.Background()
+
+	user := &User{Name: "John Doe", Email: "john@example.com", PasswordHash: "hash"}
+	require.NoError(t, repo.Create(ctx, user))
+
+	now := time.Now()
+	stale := now.Add(-2 * time.Hour)
+	require.NoError(t, repo.RecordLoginFailure(ctx, user.ID, stale, stale.Add(-time.Hour)))
+	require.NoError(t, repo.RecordLoginFailure(ctx, user.ID, now.Add(-time.Minute), now.Add(-time.Hour)))
+	require.NoError(t, repo.RecordLoginFailure(ctx, user.ID, now, now.Add(-time.Hour)))
+
+	var stored int64
+	require.NoError(t, db.Model(&LoginFailure{}).Count(&stored).Error)
+	assert.Equal(t, int64(2), stored, "failures outside the window are pruned on write")
+
+	failures, err := repo.ListLoginFailures(ctx, user.ID, now.Add(-30*time.Second))
+	require.NoError(t, err)
+	require.Len(t, failures, 1)
+	assert.WithinDuration(t, now, failures[0], time.Second)
+
+	failures, err = repo.ListLoginFailures(ctx, user.ID, now.Add(-time.Hour))
+	require.NoError(t, err)
+	require.Len(t, failures, 2)
+	assert.True(t, failures[0].After(failures[1]), "most recent failure first")
+
+	require.NoError(t, repo.ClearLoginFailures(ctx, user.ID))
+	failures, err = repo.ListLoginFailures(ctx, user.ID, now.Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Empty(t, failures)
+}
+
+func TestRepository_LoginAttempts(t *testing.T) {
+	db := testutil.NewSQLiteDB(t)
+	repo := NewRepository(db)
+	ctx := context.Background()
+
+	user := &User{Name: "John Doe", Email: "john@example.com", PasswordHash: "hash"}
+	require.NoError(t, repo.Create(ctx, user))
+
+	lastLogin, err := repo.FindLastSuccessfulLogin(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Nil(t, lastLogin, "no login on record yet")
+
+	now := time.Now().UTC()
+	require.NoError(t, repo.CreateLoginAttempts(ctx, []LoginAttempt{
+		{UserID: &user.ID, Outcome: LoginOutcomeSuccess, IP: "203.0.113.5", CreatedAt: now.Add(-100 * 24 * time.Hour)},
+		{UserID: &user.ID, Outcome: LoginOutcomeSuccess, IP: "203.0.113.5", CreatedAt: now.Add(-time.Hour)},
+		{UserID: &user.ID, Outcome: LoginOutcomeInvalidCredentials, IP: "198.51.100.7", CreatedAt: now},
+		{EmailHash: "abc", Outcome: LoginOutcomeInvalidCredentials, CreatedAt: now},
+	}))
+	require.NoError(t, repo.CreateLoginAttempts(ctx, nil))
+
+	attempts, err := repo.ListLoginAttempts(ctx, user.ID, 2, 0)
+	require.NoError(t, err)
+	require.Len(t, attempts, 2)
+	assert.Equal(t, LoginOutcomeInvalidCredentials, attempts[0].Outcome, "most recent attempt first")
+	assert.Equal(t, LoginOutcomeSuccess, attempts[1].Outcome)
+
+	attempts, err = repo.ListLoginAttempts(ctx, user.ID, 10, 2)
+	require.NoError(t, err)
+	assert.Len(t, attempts, 1, "unknown-email attempts are not listed for the user")
+
+	lastLogin, err = repo.FindLastSuccessfulLogin(ctx, user.ID)
+	require.NoError(t, err)
+	require.NotNil(t, lastLogin)
+	assert.WithinDuration(t, now.Add(-time.Hour), *lastLogin, time.Second)
+
+	deleted, err := repo.DeleteLoginAttemptsBefore(ctx, now.Add(-90*24*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+
+	var remaining int64
+	require.NoError(t, db.Model(&LoginAttempt{}).Count(&remaining).Error)
+	assert.Equal(t, int64(3), remaining)
+}
+
 func TestRepository_GetUserRoles(t *testing.T) {
-	db := setupTestDB(t)
+	db := testutil.NewSQLiteDB(t)
 	repo := NewRepository(db)
 
 	user := &User{Name: "John Doe", Email: "john@example.com", PasswordHash: "hash"}
@@ -372,7 +575,7 @@ func TestRepository_GetUserRoles(t *testing.T) {
 }
 
 func TestRepository_ListAllUsers(t *testing.T) {
-	db := setupTestDB(t)
+	db := testutil.NewSQLiteDB(t)
 	repo := NewRepository(db)
 
 	user1 := &User{Name: "Alice Admin", Email: "alice@example.com", PasswordHash: "hash"}
@@ -435,6 +638,20 @@ func TestRepository_ListAllUsers(t *testing.T) {
 		assert.Equal(t, "bob@example.com", users[0].Email)
 	})
 
+	t.Run("name only search ignores email", func(t *testing.T) {
+		filters := UserFilterParams{Search: "bob@", NameOnly: true, Sort: "created_at", Order: "desc"}
+		users, total, err := repo.ListAllUsers(context.Background(), filters, 1, 20)
+		assert.NoError(t, err)
+		assert.Empty(t, users)
+		assert.Equal(t, int64(0), total)
+
+		filters.Search = "bob"
+		users, total, err = repo.ListAllUsers(context.Background(), filters, 1, 20)
+		assert.NoError(t, err)
+		assert.Len(t, users, 1)
+		assert.Equal(t, int64(1), total)
+	})
+
 	t.Run("pagination - page 1", func(t *testing.T) {
 		filters := UserFilterParams{Sort: "created_at", Order: "asc"}
 		users, total, err := repo.ListAllUsers(context.Background(), filters, 1, 2)
@@ -506,8 +723,471 @@ func TestRepository_ListAllUsers(t *testing.T) {
 	})
 }
 
+func TestRepository_ListAllUsers_StableOrderingAcrossPages(t *testing.T) {
+	db := testutil.NewSQLiteDB(t)
+	repo := NewRepository(db)
+	ctx := context.Background()
+
+	sharedTime := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	const userCount = 25
+	for i := 0; i < userCount; i++ {
+		u := &User{Name: "Same Name", Email: fmt.Sprintf("user%02d@example.com", i), PasswordHash: "hash"}
+		require.NoError(t, repo.Create(ctx, u))
+		require.NoError(t, db.Model(&User{}).Where("id = ?", u.ID).
+			Updates(map[string]interface{}{"created_at": sharedTime, "updated_at": sharedTime}).Error)
+	}
+
+	for _, sortField := range []string{"created_at", "name", "role"} {
+		for _, order := range []string{"asc", "desc"} {
+			t.Run(sortField+"_"+order, func(t *testing.T) {
+				filters := UserFilterParams{Sort: sortField, Order: order}
+				seen := make(map[uint]bool)
+				var ids []uint
+				for page := 1; page <= 3; page++ {
+					users, total, err := repo.ListAllUsers(ctx, filters, page, 10)
+					require.NoError(t, err)
+					assert.Equal(t, int64(userCount), total)
+					for _, u := range users {
+						assert.False(t, seen[u.ID], "user %d returned on more than one page", u.ID)
+						seen[u.ID] = true
+						ids = append(ids, u.ID)
+					}
+				}
+				assert.Len(t, ids, userCount)
+
+				sorted := sort.SliceIsSorted(ids, func(a, b int) bool {
+					if order == "desc" {
+						return ids[a] > ids[b]
+					}
+					return ids[a] < ids[b]
+				})
+				assert.True(t, sorted, "ties must be broken by id: %v", ids)
+			})
+		}
+	}
+}
+
+func TestRepository_ListAllUsers_CountModes(t *testing.T) {
+	db := testutil.NewSQLiteDB(t)
+	repo := NewRepository(db)
+	ctx := context.Background()
+
+	for i := 0; i < 5; i++ {
+		require.NoError(t, repo.Create(ctx, &User{Name: fmt.Sprintf("User %d", i), Email: fmt.Sprintf("user%d@example.com", i), PasswordHash: "hash"}))
+	}
+
+	t.Run("none skips the count and fetches one extra row", func(t *testing.T) {
+		count := countQueries(t, db)
+		users, total, err := repo.ListAllUsers(ctx, UserFilterParams{Sort: "created_at", Order: "asc", CountMode: CountNone}, 1, 2)
+		require.NoError(t, err)
+		assert.Equal(t, TotalUnknown, total)
+		assert.Len(t, users, 3)
+		assert.Equal(t, 2, count(), "page and role hydration only")
+	})
+
+	t.Run("none on last page returns no extra row", func(t *testing.T) {
+		users, total, err := repo.ListAllUsers(ctx, UserFilterParams{Sort: "created_at", Order: "asc", CountMode: CountNone}, 3, 2)
+		require.NoError(t, err)
+		assert.Equal(t, TotalUnknown, total)
+		assert.Len(t, users, 1)
+	})
+
+	t.Run("estimated falls back to exact on sqlite", func(t *testing.T) {
+		users, total, err := repo.ListAllUsers(ctx, UserFilterParams{Sort: "created_at", Order: "asc", CountMode: CountEstimated}, 1, 2)
+		require.NoError(t, err)
+		assert.Equal(t, int64(5), total)
+		assert.Len(t, users, 2)
+	})
+
+	t.Run("zero-value filters use defaults", func(t *testing.T) {
+		users, total, err := repo.ListAllUsers(ctx, UserFilterParams{}, 1, 10)
+		require.NoError(t, err)
+		assert.Equal(t, int64(5), total)
+		assert.Len(t, users, 5)
+	})
+}
+
+func TestRepository_ListAllUsers_SortByRole(t *testing.T) {
+	db := testutil.NewSQLiteDB(t)
+	repo := NewRepository(db)
+	ctx := context.Background()
+
+	plain := &User{Name: "Plain", Email: "plain@example.com", PasswordHash: "hash"}
+	require.NoError(t, repo.Create(ctx, plain))
+	require.NoError(t, repo.AssignRole(ctx, plain.ID, RoleUser))
+
+	admin := &User{Name: "Admin", Email: "admin@example.com", PasswordHash: "hash"}
+	require.NoError(t, repo.Create(ctx, admin))
+	require.NoError(t, repo.AssignRole(ctx, admin.ID, RoleUser))
+	require.NoError(t, repo.AssignRole(ctx, admin.ID, RoleAdmin))
+
+	users, total, err := repo.ListAllUsers(ctx, UserFilterParams{Sort: "role", Order: "asc"}, 1, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	require.Len(t, users, 2, "users with several roles must appear once")
+	assert.Equal(t, "admin@example.com", users[0].Email)
+	assert.Equal(t, "plain@example.com", users[1].Email)
+	assert.Len(t, users[0].Roles, 2)
+}
+
+// countQueries registers callbacks counting SELECT statements issued through db
+func countQueries(t *testing.T, db *gorm.DB) (count func() int) {
+	t.Helper()
+	var n atomic.Int64
+	inc := func(*gorm.DB) { n.Add(1) }
+	require.NoError(t, db.Callback().Query().After("gorm:query").Register("test:count_query", inc))
+	require.NoError(t, db.Callback().Row().After("gorm:row").Register("test:count_row", inc))
+	t.Cleanup(func() {
+		_ = db.Callback().Query().Remove("test:count_query")
+		_ = db.Callback().Row().Remove("test:count_row")
+	})
+	return func() int { return int(n.Load()) }
+}
+
+func TestRepository_ListAllUsers_ConstantQueryCount(t *testing.T) {
+	db := testutil.NewSQLiteDB(t)
+	repo := NewRepository(db)
+	ctx := context.Background()
+
+	var userRoleID, adminRoleID uint
+	require.NoError(t, db.Table("roles").Select("id").Where("name = ?", RoleUser).Scan(&userRoleID).Error)
+	require.NoError(t, db.Table("roles").Select("id").Where("name = ?", RoleAdmin).Scan(&adminRoleID).Error)
+
+	const userCount = 1000
+	users := make([]User, userCount)
+	for i := range users {
+		users[i] = User{Name: fmt.Sprintf("User %04d", i), Email: fmt.Sprintf("user%04d@example.com", i), PasswordHash: "hash"}
+	}
+	require.NoError(t, db.CreateInBatches(users, 200).Error)
+
+	links := make([]map[string]interface{}, 0, userCount+userCount/10)
+	for i, u := range users {
+		links = append(links, map[string]interface{}{"user_id": u.ID, "role_id": userRoleID, "assigned_at": time.Now()})
+		if i%10 == 0 {
+			links = append(links, map[string]interface{}{"user_id": u.ID, "role_id": adminRoleID, "assigned_at": time.Now()})
+		}
+	}
+	require.NoError(t, db.Table("user_roles").CreateInBatches(links, 200).Error)
+
+	count := countQueries(t, db)
+	queriesFor := func(filters UserFilterParams, perPage int) int {
+		before := count()
+		result, _, err := repo.ListAllUsers(ctx, filters, 1, perPage)
+		require.NoError(t, err)
+		require.Len(t, result, perPage)
+		for _, u := range result {
+			require.NotEmpty(t, u.Roles, "roles must be hydrated for user %d", u.ID)
+		}
+		return count() - before
+	}
+
+	for _, filters := range []UserFilterParams{
+		{Sort: "created_at", Order: "desc"},
+		{Role: RoleUser, Sort: "created_at", Order: "desc"},
+		{Role: RoleAdmin, Sort: "email", Order: "asc"},
+		{Sort: "role", Order: "asc"},
+	} {
+		small := queriesFor(filters, 10)
+		large := queriesFor(filters, 100)
+		assert.Equal(t, small, large, "query count must not grow with page size (filters %+v)", filters)
+		assert.LessOrEqual(t, large, 3, "count, page and role hydration only (filters %+v)", filters)
+	}
+}
+
+func TestRepository_ListAllUsers_MultiRoleUserAppearsOnce(t *testing.T) {
+	db := testutil.NewSQLiteDB(t)
+	repo := NewRepository(db)
+	ctx := context.Background()
+
+	both := &User{Name: "Both Roles", Email: "both@example.com", PasswordHash: "hash"}
+	require.NoError(t, repo.Create(ctx, both))
+	require.NoError(t, repo.AssignRole(ctx, both.ID, RoleUser))
+	require.NoError(t, repo.AssignRole(ctx, both.ID, RoleAdmin))
+
+	for _, role := range []string{RoleUser, RoleAdmin} {
+		t.Run(role, func(t *testing.T) {
+			users, total, err := repo.ListAllUsers(ctx, UserFilterParams{Role: role, Sort: "created_at", Order: "desc"}, 1, 20)
+			require.NoError(t, err)
+			assert.Equal(t, int64(1), total)
+			require.Len(t, users, 1)
+			assert.Equal(t, both.ID, users[0].ID)
+			assert.ElementsMatch(t, []string{RoleUser, RoleAdmin}, users[0].GetRoleNames())
+		})
+	}
+}
+
+func TestRepository_ListAllUsers_StatusFilter(t *testing.T) {
+	db := testutil.NewSQLiteDB(t)
+	repo := NewRepository(db)
+	ctx := context.Background()
+
+	active := &User{Name: "Active", Email: "active@example.com", PasswordHash: "hash"}
+	require.NoError(t, repo.Create(ctx, active))
+	disabled := &User{Name: "Disabled", Email: "disabled@example.com", PasswordHash: "hash"}
+	require.NoError(t, repo.Create(ctx, disabled))
+	require.NoError(t, repo.SetActive(ctx, disabled.ID, false))
+
+	found, err := repo.FindByID(ctx, disabled.ID)
+	require.NoError(t, err)
+	assert.Equal(t, UserStatusDisabled, found.Status, "status column mirrors active")
+
+	for status, want := range map[string][]uint{
+		"":                 {active.ID, disabled.ID},
+		UserStatusActive:   {active.ID},
+		UserStatusDisabled: {disabled.ID},
+	} {
+		users, total, err := repo.ListAllUsers(ctx, UserFilterParams{Status: status, Sort: "email", Order: "asc"}, 1, 20)
+		require.NoError(t, err)
+		assert.Equal(t, int64(len(want)), total, "status %q", status)
+		ids := make([]uint, len(users))
+		for i := range users {
+			ids[i] = users[i].ID
+		}
+		assert.ElementsMatch(t, want, ids, "status %q", status)
+	}
+
+	require.NoError(t, repo.SetActive(ctx, disabled.ID, true))
+	found, err = repo.FindByID(ctx, disabled.ID)
+	require.NoError(t, err)
+	assert.Equal(t, UserStatusActive, found.Status)
+}
+
+func TestRepository_ListAllUsers_RoleAndSearch(t *testing.T) {
+	db := testutil.NewSQLiteDB(t)
+	repo := NewRepository(db)
+	ctx := context.Background()
+
+	sharedTime := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	seed := []struct {
+		name  string
+		email string
+		roles []string
+	}{
+		{"Smith Admin", "smith.admin1@example.com", []string{RoleAdmin}},
+		{"Smith Both", "smith.both@example.com", []string{RoleUser, RoleAdmin}},
+		{"Smith User", "smith.user@example.com", []string{RoleUser}},
+		{"Jones Admin", "jones.admin@example.com", []string{RoleAdmin}},
+		{"Smith Admin", "smith.admin2@example.com", []string{RoleAdmin}},
+		{"Nobody", "smith_norole@example.com", nil},
+		{"Jones", "jones.smith@example.com", []string{RoleAdmin, RoleUser}},
+	}
+	var expected []uint
+	for _, s := range seed {
+		u := &User{Name: s.name, Email: s.email, PasswordHash: "hash"}
+		require.NoError(t, repo.Create(ctx, u))
+		require.NoError(t, db.Model(&User{}).Where("id = ?", u.ID).
+			Updates(map[string]interface{}{"created_at": sharedTime, "updated_at": sharedTime}).Error)
+		for _, role := range s.roles {
+			require.NoError(t, repo.AssignRole(ctx, u.ID, role))
+		}
+		isAdmin := false
+		for _, role := range s.roles {
+			isAdmin = isAdmin || role == RoleAdmin
+		}
+		if isAdmin && strings.Contains(strings.ToLower(s.name+" "+s.email), "smith") {
+			expected = append(expected, u.ID)
+		}
+	}
+	require.Len(t, expected, 4)
+
+	t.Run("intersection in stable order across pages", func(t *testing.T) {
+		filters := UserFilterParams{Role: RoleAdmin, Search: "smith", Sort: "created_at", Order: "asc"}
+		var ids []uint
+		for page := 1; page <= 3; page++ {
+			users, total, err := repo.ListAllUsers(ctx, filters, page, 2)
+			require.NoError(t, err)
+			assert.Equal(t, int64(len(expected)), total)
+			for _, u := range users {
+				ids = append(ids, u.ID)
+			}
+		}
+		assert.Equal(t, expected, ids)
+
+		// Repeating the query returns the same first page
+		again, _, err := repo.ListAllUsers(ctx, filters, 1, 2)
+		require.NoError(t, err)
+		require.Len(t, again, 2)
+		assert.Equal(t, expected[:2], []uint{again[0].ID, again[1].ID})
+	})
+
+	t.Run("search wildcards match literally", func(t *testing.T) {
+		users, total, err := repo.ListAllUsers(ctx, UserFilterParams{Search: "smith_", Sort: "created_at", Order: "asc"}, 1, 20)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), total)
+		require.Len(t, users, 1)
+		assert.Equal(t, "smith_norole@example.com", users[0].Email)
+	})
+}
+
+func TestRepository_CountUsers(t *testing.T) {
+	db := testutil.NewSQLiteDB(t)
+	repo := NewRepository(db)
+	ctx := context.Background()
+
+	count, err := repo.CountUsers(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), count)
+
+	for _, email := range []string{"a@example.com", "b@example.com", "c@example.com"} {
+		require.NoError(t, repo.Create(ctx, &User{Name: "User", Email: email, PasswordHash: "hash"}))
+	}
+	deleted := &User{Name: "Deleted", Email: "deleted@example.com", PasswordHash: "hash"}
+	require.NoError(t, repo.Create(ctx, deleted))
+	require.NoError(t, repo.Delete(ctx, deleted.ID))
+
+	count, err = repo.CountUsers(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), count, "soft deleted users should not be counted")
+}
+
+func TestRepository_CountUsersByRole(t *testing.T) {
+	db := testutil.NewSQLiteDB(t)
+	repo := NewRepository(db)
+	ctx := context.Background()
+
+	admin := &User{Name: "Admin", Email: "admin@example.com", PasswordHash: "hash"}
+	require.NoError(t, repo.Create(ctx, admin))
+	require.NoError(t, repo.AssignRole(ctx, admin.ID, RoleUser))
+	require.NoError(t, repo.AssignRole(ctx, admin.ID, RoleAdmin))
+
+	regular := &User{Name: "Regular", Email: "regular@example.com", PasswordHash: "hash"}
+	require.NoError(t, repo.Create(ctx, regular))
+	require.NoError(t, repo.AssignRole(ctx, regular.ID, RoleUser))
+
+	admins, err := repo.CountUsersByRole(ctx, RoleAdmin)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), admins)
+
+	users, err := repo.CountUsersByRole(ctx, RoleUser)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), users)
+
+	none, err := repo.CountUsersByRole(ctx, "nonexistent")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), none)
+}
+
+func TestRepository_CountUsersSince(t *testing.T) {
+	db := testutil.NewSQLiteDB(t)
+	repo := NewRepository(db)
+	ctx := context.Background()
+
+	now := time.Now()
+	for i, age := range []time.Duration{time.Hour, 3 * 24 * time.Hour, 20 * 24 * time.Hour, 60 * 24 * time.Hour} {
+		u := &User{
+			Name:         "User",
+			Email:        fmt.Sprintf("user%d@example.com", i),
+			PasswordHash: "hash",
+			CreatedAt:    now.Add(-age),
+		}
+		require.NoError(t, repo.Create(ctx, u))
+	}
+
+	tests := []struct {
+		name  string
+		since time.Time
+		want  int64
+	}{
+		{name: "last 24 hours", since: now.Add(-24 * time.Hour), want: 1},
+		{name: "last 7 days", since: now.AddDate(0, 0, -7), want: 2},
+		{name: "last 30 days", since: now.AddDate(0, 0, -30), want: 3},
+		{name: "future", since: now.Add(time.Hour), want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			count, err := repo.CountUsersSince(ctx, tt.since)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, count)
+		})
+	}
+}
+
+func TestRepository_RoleCRUD(t *testing.T) {
+	db := testutil.NewSQLiteDB(t)
+	repo := NewRepository(db)
+	ctx := context.Background()
+
+	role := &Role{Name: "moderator", Description: "Moderates content"}
+	require.NoError(t, repo.CreateRole(ctx, role))
+	assert.NotZero(t, role.ID)
+
+	t.Run("duplicate name", func(t *testing.T) {
+		err := repo.CreateRole(ctx, &Role{Name: "moderator"})
+		assert.Error(t, err)
+	})
+
+	t.Run("list includes seeded and created roles", func(t *testing.T) {
+		roles, err := repo.ListRoles(ctx)
+		assert.NoError(t, err)
+		require.Len(t, roles, 3)
+		assert.Equal(t, RoleUser, roles[0].Name)
+		assert.Equal(t, RoleAdmin, roles[1].Name)
+		assert.Equal(t, "moderator", roles[2].Name)
+	})
+
+	t.Run("update description only", func(t *testing.T) {
+		found, err := repo.FindRoleByID(ctx, role.ID)
+		require.NoError(t, err)
+		found.Name = "renamed"
+		found.Description = "Updated"
+		require.NoError(t, repo.UpdateRole(ctx, found))
+
+		reloaded, err := repo.FindRoleByID(ctx, role.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, "moderator", reloaded.Name)
+		assert.Equal(t, "Updated", reloaded.Description)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		require.NoError(t, repo.DeleteRole(ctx, role.ID))
+
+		found, err := repo.FindRoleByID(ctx, role.ID)
+		assert.NoError(t, err)
+		assert.Nil(t, found)
+
+		err = repo.DeleteRole(ctx, role.ID)
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	})
+}
+
+func TestRepository_SetRolePermissions(t *testing.T) {
+	db := testutil.NewSQLiteDB(t)
+	repo := NewRepository(db)
+	ctx := context.Background()
+
+	role := &Role{Name: "moderator"}
+	require.NoError(t, repo.CreateRole(ctx, role))
+	holder := &User{Name: "Mod", Email: "mod@example.com", PasswordHash: "hash"}
+	require.NoError(t, repo.Create(ctx, holder))
+	require.NoError(t, repo.AssignRole(ctx, holder.ID, "moderator"))
+
+	before, err := repo.FindByID(ctx, holder.ID)
+	require.NoError(t, err)
+
+	perms, err := repo.FindPermissionsByNames(ctx, []string{PermissionUsersRead, PermissionUsersDelete})
+	require.NoError(t, err)
+	require.Len(t, perms, 2)
+
+	require.NoError(t, repo.SetRolePermissions(ctx, role.ID, []uint{perms[0].ID, perms[1].ID}))
+	// Replacing the set drops permissions that are no longer listed
+	require.NoError(t, repo.SetRolePermissions(ctx, role.ID, []uint{perms[0].ID}))
+
+	roles, err := repo.ListRoles(ctx)
+	require.NoError(t, err)
+	for _, r := range roles {
+		if r.Name == "moderator" {
+			assert.Equal(t, []string{perms[0].Name}, r.GetPermissionNames())
+		}
+	}
+
+	after, err := repo.FindByID(ctx, holder.ID)
+	require.NoError(t, err)
+	assert.Equal(t, before.TokenVersion+2, after.TokenVersion, "role holders must get a new token version")
+}
+
 func TestRepository_Transaction(t *testing.T) {
-	db := setupTestDB(t)
+	db := testutil.NewSQLiteDB(t)
 	repo := NewRepository(db)
 
 	t.Run("successful transaction", func(t *testing.T) {
@@ -549,7 +1229,7 @@ func TestRepository_Transaction(t *testing.T) {
 }
 
 func TestRepository_FindByEmail_Error(t *testing.T) {
-	db := setupTestDB(t)
+	db := testutil.NewSQLiteDB(t)
 	repo := NewRepository(db)
 
 	t.Run("returns error when email is empty", func(t *testing.T) {
@@ -560,7 +1240,7 @@ func TestRepository_FindByEmail_Error(t *testing.T) {
 }
 
 func TestRepository_FindByID_Error(t *testing.T) {
-	db := setupTestDB(t)
+	db := testutil.NewSQLiteDB(t)
 	repo := NewRepository(db)
 
 	t.Run("returns nil when ID is 0", func(t *testing.T) {
@@ -571,7 +1251,7 @@ func TestRepository_FindByID_Error(t *testing.T) {
 }
 
 func TestRepository_Update_Error(t *testing.T) {
-	db := setupTestDB(t)
+	db := testutil.NewSQLiteDB(t)
 	repo := NewRepository(db)
 
 	t.Run("successfully updates with empty password hash", func(t *testing.T) {
@@ -595,7 +1275,7 @@ func TestRepository_Update_Error(t *testing.T) {
 }
 
 func TestRepository_ListAllUsers_ErrorCases(t *testing.T) {
-	db := setupTestDB(t)
+	db := testutil.NewSQLiteDB(t)
 	repo := NewRepository(db)
 
 	user1 := &User{Name: "Alice", Email: "alice@example.com", PasswordHash: "hash"}
@@ -620,7 +1300,7 @@ func TestRepository_ListAllUsers_ErrorCases(t *testing.T) {
 }
 
 func TestRepository_GetUserRoles_EmptyResult(t *testing.T) {
-	db := setupTestDB(t)
+	db := testutil.NewSQLiteDB(t)
 	repo := NewRepository(db)
 
 	t.Run("user with ID 0", func(t *testing.T) {
@@ -631,7 +1311,7 @@ func TestRepository_GetUserRoles_EmptyResult(t *testing.T) {
 }
 
 func TestRepository_AssignRole_RoleNotFound(t *testing.T) {
-	db := setupTestDB(t)
+	db := testutil.NewSQLiteDB(t)
 	repo := NewRepository(db)
 
 	user := &User{
@@ -648,7 +1328,7 @@ func TestRepository_AssignRole_RoleNotFound(t *testing.T) {
 }
 
 func TestRepository_RemoveRole_RoleNotFound(t *testing.T) {
-	db := setupTestDB(t)
+	db := testutil.NewSQLiteDB(t)
 	repo := NewRepository(db)
 
 	user := &User{
@@ -665,7 +1345,7 @@ func TestRepository_RemoveRole_RoleNotFound(t *testing.T) {
 }
 
 func TestRepository_ListAllUsers_InvalidSortField(t *testing.T) {
-	db := setupTestDB(t)
+	db := testutil.NewSQLiteDB(t)
 	repo := NewRepository(db)
 
 	filters := UserFilterParams{
@@ -679,7 +1359,7 @@ func TestRepository_ListAllUsers_InvalidSortField(t *testing.T) {
 }
 
 func TestRepository_ListAllUsers_InvalidSortOrder(t *testing.T) {
-	db := setupTestDB(t)
+	db := testutil.NewSQLiteDB(t)
 	repo := NewRepository(db)
 
 	filters := UserFilterParams{
@@ -693,7 +1373,7 @@ func TestRepository_ListAllUsers_InvalidSortOrder(t *testing.T) {
 }
 
 func TestRepository_FindRoleByName_Error(t *testing.T) {
-	db := setupTestDB(t)
+	db := testutil.NewSQLiteDB(t)
 	sqlDB, _ := db.DB()
 	_ = sqlDB.Close()
 
@@ -704,8 +1384,76 @@ func TestRepository_FindRoleByName_Error(t *testing.T) {
 	assert.Nil(t, role)
 }
 
+func TestRepositoryError(t *testing.T) {
+	t.Run("connection failure is transient", func(t *testing.T) {
+		db := testutil.NewSQLiteDB(t)
+		sqlDB, _ := db.DB()
+		_ = sqlDB.Close()
+
+		_, err := NewRepository(db).FindByID(context.Background(), 1)
+
+		var repoErr *RepositoryError
+		require.ErrorAs(t, err, &repoErr)
+		assert.Equal(t, "FindByID", repoErr.Op)
+		assert.True(t, repoErr.Transient())
+		assert.ErrorIs(t, err, ErrRepository)
+		assert.ErrorIs(t, err, apiErrors.ErrDatabaseUnavailable)
+		assert.True(t, apiErrors.IsTransient(err))
+	})
+
+	t.Run("query failure is not transient", func(t *testing.T) {
+		db := testutil.NewSQLiteDB(t)
+		require.NoError(t, db.Migrator().DropTable(&User{}))
+
+		_, err := NewRepository(db).FindByEmail(context.Background(), "missing-table@example.com")
+
+		var repoErr *RepositoryError
+		require.ErrorAs(t, err, &repoErr)
+		assert.Equal(t, "FindByEmail", repoErr.Op)
+		assert.False(t, repoErr.Transient())
+		assert.ErrorIs(t, err, ErrRepository)
+		assert.NotErrorIs(t, err, apiErrors.ErrDatabaseUnavailable)
+		assert.Contains(t, err.Error(), "user repository: FindByEmail: ")
+
+		apiErr := apiErrors.InternalServerError(err)
+		assert.Equal(t, http.StatusInternalServerError, apiErr.Status)
+		assert.ErrorIs(t, apiErr, ErrRepository, "the API error keeps the repository error as its cause")
+	})
+
+	t.Run("nil stays nil", func(t *testing.T) {
+		assert.NoError(t, repositoryError("FindByID", nil))
+	})
+}
+
+func TestRepository_ClosedDB_ReturnsDatabaseUnavailable(t *testing.T) {
+	db := testutil.NewSQLiteDB(t)
+	sqlDB, _ := db.DB()
+	_ = sqlDB.Close()
+
+	repo := NewRepository(db)
+	ctx := context.Background()
+
+	_, err := repo.FindByID(ctx, 1)
+	assert.ErrorIs(t, err, apiErrors.ErrDatabaseUnavailable)
+
+	_, err = repo.FindRoleByName(ctx, "user")
+	assert.ErrorIs(t, err, apiErrors.ErrDatabaseUnavailable)
+
+	err = repo.Create(ctx, &User{Name: "Jane", Email: "jane@example.com", PasswordHash: "hash"})
+	assert.ErrorIs(t, err, apiErrors.ErrDatabaseUnavailable)
+
+	err = repo.AssignRole(ctx, 1, "user")
+	assert.ErrorIs(t, err, apiErrors.ErrDatabaseUnavailable)
+
+	_, _, err = repo.ListAllUsers(ctx, UserFilterParams{Sort: "created_at", Order: "desc"}, 1, 10)
+	assert.ErrorIs(t, err, apiErrors.ErrDatabaseUnavailable)
+
+	_, err = repo.CountUsers(ctx)
+	assert.ErrorIs(t, err, apiErrors.ErrDatabaseUnavailable)
+}
+
 func TestRepository_GetUserRoles_Error(t *testing.T) {
-	db := setupTestDB(t)
+	db := testutil.NewSQLiteDB(t)
 	sqlDB, _ := db.DB()
 	_ = sqlDB.Close()
 
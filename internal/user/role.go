@@ -1,23 +1,71 @@
 // Package user 定义用户角色常量和相关功能
 package user
 
-import "time"
+import (
+	"context"
+	"regexp"
+	"slices"
+	"time"
+)
 
 const (
 	RoleUser  = "user"
 	RoleAdmin = "admin"
 )
 
+// roleNamePattern restricts role names to lowercase slugs that fit the roles.name column
+var roleNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_-]{1,49}$`)
+
 // Role represents a user role in the system
 type Role struct {
-	ID          uint      `gorm:"primaryKey" json:"id"`
-	Name        string    `gorm:"uniqueIndex;not null" json:"name"`
-	Description string    `json:"description"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID          uint         `gorm:"primaryKey" json:"id"`
+	Name        string       `gorm:"uniqueIndex;not null" json:"name"`
+	Description string       `json:"description"`
+	Permissions []Permission `gorm:"many2many:role_permissions;" json:"permissions,omitempty"`
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
 }
 
 // TableName specifies the table name for Role model
 func (Role) TableName() string {
 	return "roles"
 }
+
+// IsBuiltIn reports whether the role is one of the roles the application depends on
+func (r *Role) IsBuiltIn() bool {
+	return IsBuiltInRole(r.Name)
+}
+
+// IsBuiltInRole reports whether name refers to a built-in role
+func IsBuiltInRole(name string) bool {
+	return name == RoleUser || name == RoleAdmin
+}
+
+// IsValidRoleName reports whether name is a well-formed role name:
+// 2-50 characters, starting with a lowercase letter, followed by lowercase letters, digits, '_' or '-'
+func IsValidRoleName(name string) bool {
+	return roleNamePattern.MatchString(name)
+}
+
+// GrantsAdmin reports whether holding the role makes a user an admin: the built-in admin role,
+// or any role granted admin:*. Requires Permissions to be loaded.
+func (r *Role) GrantsAdmin() bool {
+	return r.Name == RoleAdmin || slices.Contains(r.GetPermissionNames(), PermissionAdminAll)
+}
+
+// GetPermissionNames returns the names of the permissions granted to the role
+func (r *Role) GetPermissionNames() []string {
+	names := make([]string, len(r.Permissions))
+	for i, p := range r.Permissions {
+		names[i] = p.Name
+	}
+	return names
+}
+
+// OrphanedRoleAssignmentsPruner 返回供清理任务调用的函数，删除用户或角色已不存在的 user_roles 记录并返回删除的行数
+func OrphanedRoleAssignmentsPruner(repo Repository) func(ctx context.Context) (int64, error) {
+	return func(ctx context.Context) (int64, error) {
+		pruned, err := repo.PruneOrphanedRoleAssignments(ctx)
+		return int64(pruned), err
+	}
+}
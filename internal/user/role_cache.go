@@ -0,0 +1,34 @@
+package user
+
+// RoleCacheInvalidator is notified after role assignments or role permissions change,
+// so cached role lookups (such as the auth service's token role cache) are not served stale.
+// auth.Service satisfies this interface.
+type RoleCacheInvalidator interface {
+	InvalidateUserRoles(userID uint)
+	InvalidateAllRoles()
+}
+
+type noopRoleCacheInvalidator struct{}
+
+func (noopRoleCacheInvalidator) InvalidateUserRoles(uint) {}
+func (noopRoleCacheInvalidator) InvalidateAllRoles()      {}
+
+// ServiceOption configures optional user service behaviour
+type ServiceOption func(*service)
+
+// WithRoleCacheInvalidator invalidates the user's cached roles after the service changes them
+func WithRoleCacheInvalidator(invalidator RoleCacheInvalidator) ServiceOption {
+	return func(s *service) {
+		s.roleCache = invalidator
+	}
+}
+
+// RoleServiceOption configures optional role service behaviour
+type RoleServiceOption func(*roleService)
+
+// WithRoleServiceCacheInvalidator invalidates all cached roles after a role's permissions change
+func WithRoleServiceCacheInvalidator(invalidator RoleCacheInvalidator) RoleServiceOption {
+	return func(s *roleService) {
+		s.roleCache = invalidator
+	}
+}
@@ -0,0 +1,304 @@
+package user
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/yeegeek/uyou-go-api-starter/internal/contextutil"
+	apiErrors "github.com/yeegeek/uyou-go-api-starter/internal/errors"
+)
+
+// RoleHandler handles role management HTTP requests
+type RoleHandler struct {
+	roleService RoleService
+}
+
+// NewRoleHandler creates a new role handler
+func NewRoleHandler(roleService RoleService) *RoleHandler {
+	return &RoleHandler{roleService: roleService}
+}
+
+// ListRoles godoc
+// @Summary List roles (Admin only)
+// @Description Get all roles (requires the roles:manage permission)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} errors.Response{success=bool,data=[]RoleResponse} "Success response with role list"
+// @Failure 403 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Admin access required"
+// @Failure 500 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Failed to list roles"
+// @Router /api/v1/admin/roles [get]
+func (h *RoleHandler) ListRoles(c *gin.Context) {
+	roles, err := h.roleService.ListRoles(c.Request.Context())
+	if err != nil {
+		_ = c.Error(apiErrors.InternalServerError(err))
+		return
+	}
+
+	responses := make([]RoleResponse, len(roles))
+	for i := range roles {
+		responses[i] = ToRoleResponse(&roles[i])
+	}
+
+	c.JSON(http.StatusOK, apiErrors.Success(responses))
+}
+
+// CreateRole godoc
+// @Summary Create role (Admin only)
+// @Description Create a new role; names must be 2-50 lowercase letters, digits, '_' or '-' starting with a letter (requires the roles:manage permission)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body CreateRoleRequest true "Role data"
+// @Success 201 {object} errors.Response{success=bool,data=RoleResponse} "Success response with created role"
+// @Failure 400 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Validation error or invalid role name"
+// @Failure 403 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Admin access required"
+// @Failure 409 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Role already exists"
+// @Failure 500 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Failed to create role"
+// @Router /api/v1/admin/roles [post]
+func (h *RoleHandler) CreateRole(c *gin.Context) {
+	var req CreateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(apiErrors.FromGinValidation(err))
+		return
+	}
+
+	role, err := h.roleService.CreateRole(c.Request.Context(), req)
+	if err != nil {
+		if errors.Is(err, ErrInvalidRoleName) {
+			_ = c.Error(apiErrors.BadRequest("Invalid role name"))
+			return
+		}
+		if errors.Is(err, ErrRoleExists) {
+			_ = c.Error(apiErrors.Conflict("Role already exists"))
+			return
+		}
+		_ = c.Error(apiErrors.InternalServerError(err))
+		return
+	}
+
+	c.JSON(http.StatusCreated, apiErrors.Success(ToRoleResponse(role)))
+}
+
+// UpdateRole godoc
+// @Summary Update role description (Admin only)
+// @Description Update a role's description; role names cannot be changed (requires the roles:manage permission)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Role ID"
+// @Param request body UpdateRoleRequest true "Role update data"
+// @Success 200 {object} errors.Response{success=bool,data=RoleResponse} "Success response with updated role"
+// @Failure 400 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Invalid role ID or validation error"
+// @Failure 403 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Admin access required"
+// @Failure 404 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Role not found"
+// @Failure 500 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Failed to update role"
+// @Router /api/v1/admin/roles/{id} [put]
+func (h *RoleHandler) UpdateRole(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		_ = c.Error(apiErrors.BadRequest(apiErrors.MsgInvalidRoleID))
+		return
+	}
+
+	var req UpdateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(apiErrors.FromGinValidation(err))
+		return
+	}
+
+	role, err := h.roleService.UpdateRole(c.Request.Context(), uint(id), req)
+	if err != nil {
+		if errors.Is(err, ErrRoleNotFound) {
+			_ = c.Error(apiErrors.NotFound(apiErrors.MsgRoleNotFound))
+			return
+		}
+		_ = c.Error(apiErrors.InternalServerError(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, apiErrors.Success(ToRoleResponse(role)))
+}
+
+// DeleteRole godoc
+// @Summary Delete role (Admin only)
+// @Description Delete a role; built-in roles and roles assigned to users cannot be deleted (requires the roles:manage permission)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Role ID"
+// @Success 204
+// @Failure 400 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Invalid role ID"
+// @Failure 403 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Admin access required or built-in role"
+// @Failure 404 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Role not found"
+// @Failure 409 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Role is assigned to users"
+// @Failure 500 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Failed to delete role"
+// @Router /api/v1/admin/roles/{id} [delete]
+func (h *RoleHandler) DeleteRole(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		_ = c.Error(apiErrors.BadRequest(apiErrors.MsgInvalidRoleID))
+		return
+	}
+
+	if err := h.roleService.DeleteRole(c.Request.Context(), uint(id)); err != nil {
+		if errors.Is(err, ErrRoleNotFound) {
+			_ = c.Error(apiErrors.NotFound(apiErrors.MsgRoleNotFound))
+			return
+		}
+		if errors.Is(err, ErrBuiltInRole) {
+			_ = c.Error(apiErrors.Forbidden("Built-in role cannot be deleted"))
+			return
+		}
+		if errors.Is(err, ErrRoleInUse) {
+			_ = c.Error(apiErrors.Conflict("Role is assigned to users"))
+			return
+		}
+		_ = c.Error(apiErrors.InternalServerError(err))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ListPermissions godoc
+// @Summary List permissions (Admin only)
+// @Description Get all permissions that can be granted to roles (requires the roles:manage permission)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} errors.Response{success=bool,data=[]Permission} "Success response with permission list"
+// @Failure 403 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Admin access required"
+// @Failure 500 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Failed to list permissions"
+// @Router /api/v1/admin/permissions [get]
+func (h *RoleHandler) ListPermissions(c *gin.Context) {
+	permissions, err := h.roleService.ListPermissions(c.Request.Context())
+	if err != nil {
+		_ = c.Error(apiErrors.InternalServerError(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, apiErrors.Success(permissions))
+}
+
+// SetRolePermissions godoc
+// @Summary Replace role permissions (Admin only)
+// @Description Replace the permissions granted to a role; holders of the role must refresh their tokens (requires the roles:manage permission). Granting admin:* or changing a role that already grants admin privileges additionally requires admin:*.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Role ID"
+// @Param request body SetRolePermissionsRequest true "Permission names"
+// @Success 200 {object} errors.Response{success=bool,data=RoleResponse} "Success response with updated role"
+// @Failure 400 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Invalid role ID, validation error or unknown permission"
+// @Failure 403 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Admin access required, or admin:* required to grant admin privileges"
+// @Failure 404 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Role not found"
+// @Failure 500 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Failed to update role permissions"
+// @Router /api/v1/admin/roles/{id}/permissions [put]
+func (h *RoleHandler) SetRolePermissions(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		_ = c.Error(apiErrors.BadRequest(apiErrors.MsgInvalidRoleID))
+		return
+	}
+
+	var req SetRolePermissionsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(apiErrors.FromGinValidation(err))
+		return
+	}
+
+	role, err := h.roleService.SetRolePermissions(c.Request.Context(), uint(id), req, contextutil.HasPermission(c, PermissionAdminAll))
+	if err != nil {
+		if errors.Is(err, ErrRoleNotFound) {
+			_ = c.Error(apiErrors.NotFound(apiErrors.MsgRoleNotFound))
+			return
+		}
+		if errors.Is(err, ErrAdminGrantForbidden) {
+			_ = c.Error(apiErrors.Forbidden(apiErrors.MsgAdminGrantForbidden))
+			return
+		}
+		if errors.Is(err, ErrInvalidPermission) {
+			_ = c.Error(apiErrors.BadRequest(err.Error()))
+			return
+		}
+		_ = c.Error(apiErrors.InternalServerError(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, apiErrors.Success(ToRoleResponse(role)))
+}
+
+// BulkUpdateUserRoles godoc
+// @Summary Assign or remove a role for many users (Admin only)
+// @Description Apply one role change to up to 200 users in a single transaction. An unknown role, an empty or oversized list or an unknown action rejects the whole request; users that do not exist are reported per item as not_found without failing the others. Assigning or removing a role that grants admin privileges requires admin:*.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body BulkRoleRequest true "User IDs, role name and action"
+// @Success 200 {object} errors.Response{success=bool,data=BulkRoleResponse} "Per-user results"
+// @Failure 400 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Validation error, unknown role or too many users"
+// @Failure 401 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Unauthorized"
+// @Failure 403 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Admin access required, or admin:* required for admin roles"
+// @Failure 500 {object} errors.Response{success=bool,error=errors.ErrorInfo} "Failed to update roles"
+// @Router /api/v1/admin/users/bulk/roles [post]
+func (h *RoleHandler) BulkUpdateUserRoles(c *gin.Context) {
+	adminID := contextutil.GetUserID(c)
+	if adminID == 0 {
+		_ = c.Error(apiErrors.Unauthorized(apiErrors.MsgUserNotAuthenticated))
+		return
+	}
+
+	var req BulkRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(apiErrors.FromGinValidation(err))
+		return
+	}
+
+	ctx := c.Request.Context()
+	results, err := h.roleService.BulkUpdateUserRoles(ctx, req, contextutil.HasPermission(c, PermissionAdminAll))
+	if err != nil {
+		if errors.Is(err, ErrRoleNotFound) {
+			_ = c.Error(apiErrors.BadRequest("Unknown role: " + req.Role))
+			return
+		}
+		if errors.Is(err, ErrAdminGrantForbidden) {
+			_ = c.Error(apiErrors.Forbidden(apiErrors.MsgAdminGrantForbidden))
+			return
+		}
+		if errors.Is(err, ErrInvalidBulkRoleRequest) {
+			_ = c.Error(apiErrors.BadRequest(err.Error()))
+			return
+		}
+		_ = c.Error(apiErrors.InternalServerError(err))
+		return
+	}
+
+	response := BulkRoleResponse{Role: req.Role, Action: req.Action, Results: results}
+	for _, result := range results {
+		if result.Status != BulkRoleStatusSucceeded {
+			continue
+		}
+		response.Succeeded++
+		slog.InfoContext(ctx, "User role changed by admin",
+			"admin_id", adminID,
+			"impersonator_id", contextutil.GetImpersonatorID(c),
+			"target_user_id", result.UserID,
+			"role", req.Role,
+			"action", req.Action,
+		)
+	}
+
+	c.JSON(http.StatusOK, apiErrors.Success(response))
+}
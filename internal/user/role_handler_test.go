@@ -0,0 +1,324 @@
+package user
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/yeegeek/uyou-go-api-starter/internal/auth"
+	apiErrors "github.com/yeegeek/uyou-go-api-starter/internal/errors"
+)
+
+// MockRoleService is a mock implementation of the role service for testing handlers
+type MockRoleService struct {
+	mock.Mock
+}
+
+func (m *MockRoleService) CreateRole(ctx context.Context, req CreateRoleRequest) (*Role, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Role), args.Error(1)
+}
+
+func (m *MockRoleService) ListRoles(ctx context.Context) ([]Role, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]Role), args.Error(1)
+}
+
+func (m *MockRoleService) UpdateRole(ctx context.Context, id uint, req UpdateRoleRequest) (*Role, error) {
+	args := m.Called(ctx, id, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Role), args.Error(1)
+}
+
+func (m *MockRoleService) DeleteRole(ctx context.Context, id uint) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockRoleService) ListPermissions(ctx context.Context) ([]Permission, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]Permission), args.Error(1)
+}
+
+func (m *MockRoleService) SetRolePermissions(ctx context.Context, id uint, req SetRolePermissionsRequest, callerIsAdmin bool) (*Role, error) {
+	args := m.Called(ctx, id, req, callerIsAdmin)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Role), args.Error(1)
+}
+
+func (m *MockRoleService) BulkUpdateUserRoles(ctx context.Context, req BulkRoleRequest, callerIsAdmin bool) ([]BulkRoleResult, error) {
+	args := m.Called(ctx, req, callerIsAdmin)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]BulkRoleResult), args.Error(1)
+}
+
+func (m *MockRoleService) AssignRoleBulk(ctx context.Context, userIDs []uint, roleName string) ([]BulkRoleResult, error) {
+	args := m.Called(ctx, userIDs, roleName)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]BulkRoleResult), args.Error(1)
+}
+
+func TestRoleHandler_CreateRole(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		body           string
+		setupMocks     func(*MockRoleService)
+		expectedStatus int
+	}{
+		{
+			name: "successful creation",
+			body: `{"name":"moderator","description":"Moderates content"}`,
+			setupMocks: func(ms *MockRoleService) {
+				ms.On("CreateRole", mock.Anything, CreateRoleRequest{Name: "moderator", Description: "Moderates content"}).
+					Return(&Role{ID: 3, Name: "moderator", Description: "Moderates content"}, nil)
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "missing name",
+			body:           `{"description":"x"}`,
+			setupMocks:     func(ms *MockRoleService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "invalid name",
+			body: `{"name":"Bad Role"}`,
+			setupMocks: func(ms *MockRoleService) {
+				ms.On("CreateRole", mock.Anything, mock.Anything).Return(nil, ErrInvalidRoleName)
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "duplicate role",
+			body: `{"name":"admin"}`,
+			setupMocks: func(ms *MockRoleService) {
+				ms.On("CreateRole", mock.Anything, mock.Anything).Return(nil, ErrRoleExists)
+			},
+			expectedStatus: http.StatusConflict,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockRoleService)
+			tt.setupMocks(mockService)
+			handler := NewRoleHandler(mockService)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPost, "/api/v1/admin/roles", bytes.NewBufferString(tt.body))
+			c.Request.Header.Set("Content-Type", "application/json")
+
+			handler.CreateRole(c)
+			apiErrors.ErrorHandler()(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRoleHandler_DeleteRole(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		roleID         string
+		setupMocks     func(*MockRoleService)
+		expectedStatus int
+	}{
+		{
+			name:   "successful deletion",
+			roleID: "3",
+			setupMocks: func(ms *MockRoleService) {
+				ms.On("DeleteRole", mock.Anything, uint(3)).Return(nil)
+			},
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:   "built-in role",
+			roleID: "2",
+			setupMocks: func(ms *MockRoleService) {
+				ms.On("DeleteRole", mock.Anything, uint(2)).Return(ErrBuiltInRole)
+			},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:   "role in use",
+			roleID: "3",
+			setupMocks: func(ms *MockRoleService) {
+				ms.On("DeleteRole", mock.Anything, uint(3)).Return(ErrRoleInUse)
+			},
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:   "role not found",
+			roleID: "999",
+			setupMocks: func(ms *MockRoleService) {
+				ms.On("DeleteRole", mock.Anything, uint(999)).Return(ErrRoleNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "invalid id",
+			roleID:         "abc",
+			setupMocks:     func(ms *MockRoleService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "service error",
+			roleID: "3",
+			setupMocks: func(ms *MockRoleService) {
+				ms.On("DeleteRole", mock.Anything, uint(3)).Return(errors.New("database error"))
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockRoleService)
+			tt.setupMocks(mockService)
+			handler := NewRoleHandler(mockService)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodDelete, "/api/v1/admin/roles/"+tt.roleID, nil)
+			c.Params = gin.Params{{Key: "id", Value: tt.roleID}}
+
+			handler.DeleteRole(c)
+			apiErrors.ErrorHandler()(c)
+
+			assert.Equal(t, tt.expectedStatus, c.Writer.Status())
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRoleHandler_BulkUpdateUserRoles(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		body           string
+		permissions    []string
+		setupMocks     func(*MockRoleService)
+		expectedStatus int
+		expectedBody   []string
+	}{
+		{
+			name:        "mixed results",
+			body:        `{"user_ids":[1,2,3],"role":"admin","action":"assign"}`,
+			permissions: []string{PermissionAdminAll},
+			setupMocks: func(ms *MockRoleService) {
+				ms.On("BulkUpdateUserRoles", mock.Anything, BulkRoleRequest{UserIDs: []uint{1, 2, 3}, Role: "admin", Action: "assign"}, true).
+					Return([]BulkRoleResult{
+						{UserID: 1, Status: BulkRoleStatusSucceeded},
+						{UserID: 2, Status: BulkRoleStatusAlreadyHadRole},
+						{UserID: 3, Status: BulkRoleStatusNotFound},
+					}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   []string{`"succeeded":1`, `"status":"already_had_role"`, `"status":"not_found"`},
+		},
+		{
+			name:        "admin role without admin:*",
+			body:        `{"user_ids":[99],"role":"admin","action":"assign"}`,
+			permissions: []string{PermissionRolesManage},
+			setupMocks: func(ms *MockRoleService) {
+				ms.On("BulkUpdateUserRoles", mock.Anything, mock.Anything, false).Return(nil, ErrAdminGrantForbidden)
+			},
+			expectedStatus: http.StatusForbidden,
+			expectedBody:   []string{"requires admin:*"},
+		},
+		{
+			name:           "empty user list",
+			body:           `{"user_ids":[],"role":"admin","action":"assign"}`,
+			setupMocks:     func(ms *MockRoleService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "unknown action",
+			body:           `{"user_ids":[1],"role":"admin","action":"toggle"}`,
+			setupMocks:     func(ms *MockRoleService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "unknown role",
+			body: `{"user_ids":[1],"role":"ghost","action":"assign"}`,
+			setupMocks: func(ms *MockRoleService) {
+				ms.On("BulkUpdateUserRoles", mock.Anything, mock.Anything, false).Return(nil, ErrRoleNotFound)
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   []string{"Unknown role: ghost"},
+		},
+		{
+			name: "too many users",
+			body: `{"user_ids":[1],"role":"admin","action":"assign"}`,
+			setupMocks: func(ms *MockRoleService) {
+				ms.On("BulkUpdateUserRoles", mock.Anything, mock.Anything, false).
+					Return(nil, fmt.Errorf("%w: at most %d users per request", ErrInvalidBulkRoleRequest, MaxBulkRoleUsers))
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   []string{"at most 200 users"},
+		},
+		{
+			name: "service error",
+			body: `{"user_ids":[1],"role":"admin","action":"remove"}`,
+			setupMocks: func(ms *MockRoleService) {
+				ms.On("BulkUpdateUserRoles", mock.Anything, mock.Anything, false).Return(nil, errors.New("database error"))
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockRoleService)
+			tt.setupMocks(mockService)
+			handler := NewRoleHandler(mockService)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPost, "/api/v1/admin/users/bulk/roles", bytes.NewBufferString(tt.body))
+			c.Request.Header.Set("Content-Type", "application/json")
+			c.Set(auth.KeyUser, &auth.Claims{UserID: 99, Permissions: tt.permissions})
+
+			handler.BulkUpdateUserRoles(c)
+			apiErrors.ErrorHandler()(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			for _, fragment := range tt.expectedBody {
+				assert.Contains(t, w.Body.String(), fragment)
+			}
+			mockService.AssertExpectations(t)
+		})
+	}
+}
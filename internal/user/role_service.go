@@ -0,0 +1,317 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+var (
+	// ErrRoleNotFound is returned when role is not found
+	ErrRoleNotFound = errors.New("role not found")
+	// ErrRoleExists is returned when a role with the same name already exists
+	ErrRoleExists = errors.New("role already exists")
+	// ErrInvalidRoleName is returned when role name format is invalid
+	ErrInvalidRoleName = errors.New("invalid role name")
+	// ErrBuiltInRole is returned when attempting to delete a built-in role
+	ErrBuiltInRole = errors.New("built-in role cannot be deleted")
+	// ErrRoleInUse is returned when attempting to delete a role that is assigned to users
+	ErrRoleInUse = errors.New("role is assigned to users")
+	// ErrInvalidPermission is returned when a permission name is malformed or unknown
+	ErrInvalidPermission = errors.New("invalid permission")
+	// ErrInvalidBulkRoleRequest is returned when a bulk role request is empty, too large or has an unknown action
+	ErrInvalidBulkRoleRequest = errors.New("invalid bulk role request")
+	// ErrAdminGrantForbidden is returned when a caller without admin:* grants admin:*, changes the
+	// permissions of an admin role or assigns or removes an admin role
+	ErrAdminGrantForbidden = errors.New("admin:* is required to grant or change admin privileges")
+)
+
+// RoleService defines role management interface
+type RoleService interface {
+	CreateRole(ctx context.Context, req CreateRoleRequest) (*Role, error)
+	ListRoles(ctx context.Context) ([]Role, error)
+	UpdateRole(ctx context.Context, id uint, req UpdateRoleRequest) (*Role, error)
+	DeleteRole(ctx context.Context, id uint) error
+	ListPermissions(ctx context.Context) ([]Permission, error)
+	SetRolePermissions(ctx context.Context, id uint, req SetRolePermissionsRequest, callerIsAdmin bool) (*Role, error)
+	BulkUpdateUserRoles(ctx context.Context, req BulkRoleRequest, callerIsAdmin bool) ([]BulkRoleResult, error)
+	AssignRoleBulk(ctx context.Context, userIDs []uint, roleName string) ([]BulkRoleResult, error)
+}
+
+type roleService struct {
+	repo      Repository
+	roleCache RoleCacheInvalidator
+}
+
+// NewRoleService creates a new role service
+func NewRoleService(repo Repository, opts ...RoleServiceOption) RoleService {
+	s := &roleService{repo: repo, roleCache: noopRoleCacheInvalidator{}}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// CreateRole creates a new role after validating its name
+func (s *roleService) CreateRole(ctx context.Context, req CreateRoleRequest) (*Role, error) {
+	if !IsValidRoleName(req.Name) {
+		return nil, ErrInvalidRoleName
+	}
+
+	existing, err := s.repo.FindRoleByName(ctx, req.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check existing role: %w", err)
+	}
+	if existing != nil {
+		return nil, ErrRoleExists
+	}
+
+	role := &Role{
+		Name:        req.Name,
+		Description: req.Description,
+	}
+	if err := s.repo.CreateRole(ctx, role); err != nil {
+		return nil, fmt.Errorf("failed to create role: %w", err)
+	}
+
+	return role, nil
+}
+
+// ListRoles retrieves all roles
+func (s *roleService) ListRoles(ctx context.Context) ([]Role, error) {
+	roles, err := s.repo.ListRoles(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list roles: %w", err)
+	}
+	return roles, nil
+}
+
+// UpdateRole updates a role's description
+func (s *roleService) UpdateRole(ctx context.Context, id uint, req UpdateRoleRequest) (*Role, error) {
+	role, err := s.repo.FindRoleByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find role: %w", err)
+	}
+	if role == nil {
+		return nil, ErrRoleNotFound
+	}
+
+	role.Description = req.Description
+	if err := s.repo.UpdateRole(ctx, role); err != nil {
+		return nil, fmt.Errorf("failed to update role: %w", err)
+	}
+
+	return role, nil
+}
+
+// DeleteRole deletes a role unless it is built-in or still assigned to users
+func (s *roleService) DeleteRole(ctx context.Context, id uint) error {
+	return s.repo.Transaction(ctx, func(txCtx context.Context) error {
+		role, err := s.repo.FindRoleByID(txCtx, id)
+		if err != nil {
+			return fmt.Errorf("failed to find role: %w", err)
+		}
+		if role == nil {
+			return ErrRoleNotFound
+		}
+		if role.IsBuiltIn() {
+			return ErrBuiltInRole
+		}
+
+		assigned, err := s.repo.CountUsersByRole(txCtx, role.Name)
+		if err != nil {
+			return fmt.Errorf("failed to count role assignments: %w", err)
+		}
+		if assigned > 0 {
+			return ErrRoleInUse
+		}
+
+		if err := s.repo.DeleteRole(txCtx, id); err != nil {
+			return fmt.Errorf("failed to delete role: %w", err)
+		}
+		return nil
+	})
+}
+
+// ListPermissions retrieves all known permissions
+func (s *roleService) ListPermissions(ctx context.Context) ([]Permission, error) {
+	permissions, err := s.repo.ListPermissions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list permissions: %w", err)
+	}
+	return permissions, nil
+}
+
+// SetRolePermissions replaces the permissions granted to a role.
+// Every name must refer to an existing permission; unknown names reject the whole request.
+// Unless callerIsAdmin (the caller holds admin:*), admin:* cannot be granted and roles that
+// already grant admin privileges cannot be changed.
+func (s *roleService) SetRolePermissions(ctx context.Context, id uint, req SetRolePermissionsRequest, callerIsAdmin bool) (*Role, error) {
+	for _, name := range req.Permissions {
+		if !IsValidPermissionName(name) {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidPermission, name)
+		}
+	}
+	// WHY: roles:manage must not be a stepping stone to admin:*
+	if !callerIsAdmin && slices.Contains(req.Permissions, PermissionAdminAll) {
+		return nil, ErrAdminGrantForbidden
+	}
+
+	var role *Role
+	err := s.repo.Transaction(ctx, func(txCtx context.Context) error {
+		var err error
+		role, err = s.repo.FindRoleByID(txCtx, id)
+		if err != nil {
+			return fmt.Errorf("failed to find role: %w", err)
+		}
+		if role == nil {
+			return ErrRoleNotFound
+		}
+		if !callerIsAdmin && role.GrantsAdmin() {
+			return ErrAdminGrantForbidden
+		}
+
+		permissions, err := s.repo.FindPermissionsByNames(txCtx, req.Permissions)
+		if err != nil {
+			return fmt.Errorf("failed to find permissions: %w", err)
+		}
+		found := make(map[string]bool, len(permissions))
+		ids := make([]uint, 0, len(permissions))
+		for _, p := range permissions {
+			found[p.Name] = true
+			ids = append(ids, p.ID)
+		}
+		for _, name := range req.Permissions {
+			if !found[name] {
+				return fmt.Errorf("%w: %s", ErrInvalidPermission, name)
+			}
+		}
+
+		if err := s.repo.SetRolePermissions(txCtx, role.ID, ids); err != nil {
+			return fmt.Errorf("failed to set role permissions: %w", err)
+		}
+		role.Permissions = permissions
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	// WHY: Only after commit, otherwise a concurrent lookup could re-cache the old permissions
+	s.roleCache.InvalidateAllRoles()
+
+	return role, nil
+}
+
+// BulkUpdateUserRoles assigns or removes a role for many users in one transaction.
+// Request-level problems (unknown role, empty or oversized list, unknown action) reject the whole
+// request; users that do not exist are reported per item and do not affect the others.
+// Results follow the order of the request with duplicate IDs collapsed.
+// Roles that grant admin privileges can only be assigned or removed when callerIsAdmin.
+func (s *roleService) BulkUpdateUserRoles(ctx context.Context, req BulkRoleRequest, callerIsAdmin bool) ([]BulkRoleResult, error) {
+	if req.Action != BulkRoleActionAssign && req.Action != BulkRoleActionRemove {
+		return nil, fmt.Errorf("%w: unknown action %q", ErrInvalidBulkRoleRequest, req.Action)
+	}
+	userIDs := uniqueIDs(req.UserIDs)
+	if len(userIDs) == 0 {
+		return nil, fmt.Errorf("%w: user_ids must not be empty", ErrInvalidBulkRoleRequest)
+	}
+	if len(userIDs) > MaxBulkRoleUsers {
+		return nil, fmt.Errorf("%w: at most %d users per request", ErrInvalidBulkRoleRequest, MaxBulkRoleUsers)
+	}
+
+	return s.applyBulkRole(ctx, userIDs, req.Role, req.Action, callerIsAdmin)
+}
+
+// AssignRoleBulk grants roleName to many users at once, e.g. when importing accounts.
+// It runs in one transaction and resolves the role once; like AssignRole it is idempotent, so users
+// already holding the role are reported as already_had_role and no duplicate assignment is created.
+// Unlike the admin endpoint it is not capped at MaxBulkRoleUsers, and callers are trusted code
+// (imports, CLI tools), so admin roles may be assigned.
+func (s *roleService) AssignRoleBulk(ctx context.Context, userIDs []uint, roleName string) ([]BulkRoleResult, error) {
+	userIDs = uniqueIDs(userIDs)
+	if len(userIDs) == 0 {
+		return nil, fmt.Errorf("%w: user_ids must not be empty", ErrInvalidBulkRoleRequest)
+	}
+	return s.applyBulkRole(ctx, userIDs, roleName, BulkRoleActionAssign, true)
+}
+
+// applyBulkRole assigns or removes roleName for the deduplicated userIDs in one transaction
+// and returns one result per user in input order
+func (s *roleService) applyBulkRole(ctx context.Context, userIDs []uint, roleName, action string, callerIsAdmin bool) ([]BulkRoleResult, error) {
+	var existing, changed []uint
+	err := s.repo.Transaction(ctx, func(txCtx context.Context) error {
+		role, err := s.repo.FindRoleByName(txCtx, roleName)
+		if err != nil {
+			return fmt.Errorf("failed to find role: %w", err)
+		}
+		if role == nil {
+			return ErrRoleNotFound
+		}
+		if !callerIsAdmin && role.GrantsAdmin() {
+			return ErrAdminGrantForbidden
+		}
+
+		existing, err = s.repo.FindExistingUserIDs(txCtx, userIDs)
+		if err != nil {
+			return fmt.Errorf("failed to find users: %w", err)
+		}
+
+		if action == BulkRoleActionAssign {
+			changed, err = s.repo.AssignRoleBulk(txCtx, role.ID, existing)
+		} else {
+			changed, err = s.repo.RemoveRoleBulk(txCtx, role.ID, existing)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to %s role: %w", action, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, id := range changed {
+		s.roleCache.InvalidateUserRoles(id)
+	}
+
+	unchanged := BulkRoleStatusAlreadyHadRole
+	if action == BulkRoleActionRemove {
+		unchanged = BulkRoleStatusDidNotHaveRole
+	}
+	found := idSet(existing)
+	succeeded := idSet(changed)
+	results := make([]BulkRoleResult, len(userIDs))
+	for i, id := range userIDs {
+		status := BulkRoleStatusNotFound
+		switch {
+		case succeeded[id]:
+			status = BulkRoleStatusSucceeded
+		case found[id]:
+			status = unchanged
+		}
+		results[i] = BulkRoleResult{UserID: id, Status: status}
+	}
+	return results, nil
+}
+
+// uniqueIDs drops duplicate IDs while keeping the first occurrence order
+func uniqueIDs(ids []uint) []uint {
+	seen := make(map[uint]bool, len(ids))
+	unique := make([]uint, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
+
+func idSet(ids []uint) map[uint]bool {
+	set := make(map[uint]bool, len(ids))
+	for _, id := range ids {
+		set[id] = true
+	}
+	return set
+}
@@ -0,0 +1,500 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yeegeek/uyou-go-api-starter/internal/testutil"
+)
+
+func TestRoleService_CreateRole(t *testing.T) {
+	tests := []struct {
+		name        string
+		request     CreateRoleRequest
+		setupMock   func(*MockRepository)
+		expectedErr error
+	}{
+		{
+			name:    "successful creation",
+			request: CreateRoleRequest{Name: "moderator", Description: "Moderates content"},
+			setupMock: func(m *MockRepository) {
+				m.On("FindRoleByName", mock.Anything, "moderator").Return(nil, nil)
+				m.On("CreateRole", mock.Anything, mock.MatchedBy(func(r *Role) bool {
+					return r.Name == "moderator" && r.Description == "Moderates content"
+				})).Return(nil)
+			},
+		},
+		{
+			name:        "invalid name format",
+			request:     CreateRoleRequest{Name: "Bad Role!"},
+			setupMock:   func(m *MockRepository) {},
+			expectedErr: ErrInvalidRoleName,
+		},
+		{
+			name:        "name must start with a letter",
+			request:     CreateRoleRequest{Name: "1admin"},
+			setupMock:   func(m *MockRepository) {},
+			expectedErr: ErrInvalidRoleName,
+		},
+		{
+			name:    "role already exists",
+			request: CreateRoleRequest{Name: "admin"},
+			setupMock: func(m *MockRepository) {
+				m.On("FindRoleByName", mock.Anything, "admin").Return(&Role{ID: 2, Name: "admin"}, nil)
+			},
+			expectedErr: ErrRoleExists,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := &MockRepository{}
+			tt.setupMock(mockRepo)
+
+			service := NewRoleService(mockRepo)
+			role, err := service.CreateRole(context.Background(), tt.request)
+
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				assert.Nil(t, role)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.request.Name, role.Name)
+			}
+
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRoleService_UpdateRole(t *testing.T) {
+	t.Run("updates description", func(t *testing.T) {
+		mockRepo := &MockRepository{}
+		mockRepo.On("FindRoleByID", mock.Anything, uint(3)).Return(&Role{ID: 3, Name: "moderator"}, nil)
+		mockRepo.On("UpdateRole", mock.Anything, mock.MatchedBy(func(r *Role) bool {
+			return r.ID == 3 && r.Description == "New description"
+		})).Return(nil)
+
+		service := NewRoleService(mockRepo)
+		role, err := service.UpdateRole(context.Background(), 3, UpdateRoleRequest{Description: "New description"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "moderator", role.Name)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("granting admin:* requires an admin caller", func(t *testing.T) {
+		mockRepo := &MockRepository{}
+
+		service := NewRoleService(mockRepo)
+		role, err := service.SetRolePermissions(context.Background(), 3, SetRolePermissionsRequest{
+			Permissions: []string{PermissionUsersRead, PermissionAdminAll},
+		}, false)
+
+		assert.ErrorIs(t, err, ErrAdminGrantForbidden)
+		assert.Nil(t, role)
+		mockRepo.AssertNotCalled(t, "FindRoleByID", mock.Anything, mock.Anything)
+	})
+
+	t.Run("roles granting admin can only be changed by admins", func(t *testing.T) {
+		for _, role := range []*Role{
+			{ID: 2, Name: RoleAdmin},
+			{ID: 4, Name: "superuser", Permissions: []Permission{{ID: 9, Name: PermissionAdminAll}}},
+		} {
+			mockRepo := &MockRepository{}
+			mockRepo.On("FindRoleByID", mock.Anything, role.ID).Return(role, nil)
+
+			service := NewRoleService(mockRepo)
+			_, err := service.SetRolePermissions(context.Background(), role.ID, SetRolePermissionsRequest{
+				Permissions: []string{PermissionUsersRead},
+			}, false)
+
+			assert.ErrorIs(t, err, ErrAdminGrantForbidden, role.Name)
+			mockRepo.AssertNotCalled(t, "SetRolePermissions", mock.Anything, mock.Anything, mock.Anything)
+		}
+	})
+
+	t.Run("role not found", func(t *testing.T) {
+		mockRepo := &MockRepository{}
+		mockRepo.On("FindRoleByID", mock.Anything, uint(999)).Return(nil, nil)
+
+		service := NewRoleService(mockRepo)
+		role, err := service.UpdateRole(context.Background(), 999, UpdateRoleRequest{})
+
+		assert.ErrorIs(t, err, ErrRoleNotFound)
+		assert.Nil(t, role)
+	})
+}
+
+func TestRoleService_DeleteRole(t *testing.T) {
+	tests := []struct {
+		name        string
+		roleID      uint
+		setupMock   func(*MockRepository)
+		expectedErr error
+	}{
+		{
+			name:   "successful deletion",
+			roleID: 3,
+			setupMock: func(m *MockRepository) {
+				m.On("FindRoleByID", mock.Anything, uint(3)).Return(&Role{ID: 3, Name: "moderator"}, nil)
+				m.On("CountUsersByRole", mock.Anything, "moderator").Return(int64(0), nil)
+				m.On("DeleteRole", mock.Anything, uint(3)).Return(nil)
+			},
+		},
+		{
+			name:   "built-in user role",
+			roleID: 1,
+			setupMock: func(m *MockRepository) {
+				m.On("FindRoleByID", mock.Anything, uint(1)).Return(&Role{ID: 1, Name: RoleUser}, nil)
+			},
+			expectedErr: ErrBuiltInRole,
+		},
+		{
+			name:   "built-in admin role",
+			roleID: 2,
+			setupMock: func(m *MockRepository) {
+				m.On("FindRoleByID", mock.Anything, uint(2)).Return(&Role{ID: 2, Name: RoleAdmin}, nil)
+			},
+			expectedErr: ErrBuiltInRole,
+		},
+		{
+			name:   "role assigned to users",
+			roleID: 3,
+			setupMock: func(m *MockRepository) {
+				m.On("FindRoleByID", mock.Anything, uint(3)).Return(&Role{ID: 3, Name: "moderator"}, nil)
+				m.On("CountUsersByRole", mock.Anything, "moderator").Return(int64(2), nil)
+			},
+			expectedErr: ErrRoleInUse,
+		},
+		{
+			name:   "role not found",
+			roleID: 999,
+			setupMock: func(m *MockRepository) {
+				m.On("FindRoleByID", mock.Anything, uint(999)).Return(nil, nil)
+			},
+			expectedErr: ErrRoleNotFound,
+		},
+		{
+			name:   "count error",
+			roleID: 3,
+			setupMock: func(m *MockRepository) {
+				m.On("FindRoleByID", mock.Anything, uint(3)).Return(&Role{ID: 3, Name: "moderator"}, nil)
+				m.On("CountUsersByRole", mock.Anything, "moderator").Return(int64(0), errors.New("database error"))
+			},
+			expectedErr: errors.New("failed to count role assignments"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := &MockRepository{}
+			tt.setupMock(mockRepo)
+
+			service := NewRoleService(mockRepo)
+			err := service.DeleteRole(context.Background(), tt.roleID)
+
+			if tt.expectedErr != nil {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedErr.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+
+			mockRepo.AssertExpectations(t)
+			mockRepo.AssertNotCalled(t, "DeleteRole", mock.Anything, uint(1))
+			mockRepo.AssertNotCalled(t, "DeleteRole", mock.Anything, uint(2))
+		})
+	}
+}
+
+func TestRoleService_SetRolePermissions(t *testing.T) {
+	t.Run("replaces permissions", func(t *testing.T) {
+		mockRepo := &MockRepository{}
+		perms := []Permission{{ID: 1, Name: PermissionUsersRead}, {ID: 3, Name: PermissionUsersDelete}}
+		mockRepo.On("FindRoleByID", mock.Anything, uint(3)).Return(&Role{ID: 3, Name: "moderator"}, nil)
+		mockRepo.On("FindPermissionsByNames", mock.Anything, []string{PermissionUsersRead, PermissionUsersDelete}).Return(perms, nil)
+		mockRepo.On("SetRolePermissions", mock.Anything, uint(3), []uint{1, 3}).Return(nil)
+		invalidator := new(MockRoleCacheInvalidator)
+		invalidator.On("InvalidateAllRoles").Return()
+
+		service := NewRoleService(mockRepo, WithRoleServiceCacheInvalidator(invalidator))
+		role, err := service.SetRolePermissions(context.Background(), 3, SetRolePermissionsRequest{
+			Permissions: []string{PermissionUsersRead, PermissionUsersDelete},
+		}, false)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{PermissionUsersRead, PermissionUsersDelete}, role.GetPermissionNames())
+		mockRepo.AssertExpectations(t)
+		invalidator.AssertExpectations(t)
+	})
+
+	t.Run("malformed permission name", func(t *testing.T) {
+		mockRepo := &MockRepository{}
+
+		service := NewRoleService(mockRepo)
+		role, err := service.SetRolePermissions(context.Background(), 3, SetRolePermissionsRequest{
+			Permissions: []string{"delete-everything"},
+		}, false)
+
+		assert.ErrorIs(t, err, ErrInvalidPermission)
+		assert.Nil(t, role)
+		mockRepo.AssertNotCalled(t, "FindRoleByID", mock.Anything, mock.Anything)
+	})
+
+	t.Run("unknown permission", func(t *testing.T) {
+		mockRepo := &MockRepository{}
+		mockRepo.On("FindRoleByID", mock.Anything, uint(3)).Return(&Role{ID: 3, Name: "moderator"}, nil)
+		mockRepo.On("FindPermissionsByNames", mock.Anything, []string{"users:read", "users:fly"}).
+			Return([]Permission{{ID: 1, Name: PermissionUsersRead}}, nil)
+
+		service := NewRoleService(mockRepo)
+		role, err := service.SetRolePermissions(context.Background(), 3, SetRolePermissionsRequest{
+			Permissions: []string{"users:read", "users:fly"},
+		}, false)
+
+		assert.ErrorIs(t, err, ErrInvalidPermission)
+		assert.Contains(t, err.Error(), "users:fly")
+		assert.Nil(t, role)
+		mockRepo.AssertNotCalled(t, "SetRolePermissions", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("granting admin:* requires an admin caller", func(t *testing.T) {
+		mockRepo := &MockRepository{}
+
+		service := NewRoleService(mockRepo)
+		role, err := service.SetRolePermissions(context.Background(), 3, SetRolePermissionsRequest{
+			Permissions: []string{PermissionUsersRead, PermissionAdminAll},
+		}, false)
+
+		assert.ErrorIs(t, err, ErrAdminGrantForbidden)
+		assert.Nil(t, role)
+		mockRepo.AssertNotCalled(t, "FindRoleByID", mock.Anything, mock.Anything)
+	})
+
+	t.Run("roles granting admin can only be changed by admins", func(t *testing.T) {
+		for _, role := range []*Role{
+			{ID: 2, Name: RoleAdmin},
+			{ID: 4, Name: "superuser", Permissions: []Permission{{ID: 9, Name: PermissionAdminAll}}},
+		} {
+			mockRepo := &MockRepository{}
+			mockRepo.On("FindRoleByID", mock.Anything, role.ID).Return(role, nil)
+
+			service := NewRoleService(mockRepo)
+			_, err := service.SetRolePermissions(context.Background(), role.ID, SetRolePermissionsRequest{
+				Permissions: []string{PermissionUsersRead},
+			}, false)
+
+			assert.ErrorIs(t, err, ErrAdminGrantForbidden, role.Name)
+			mockRepo.AssertNotCalled(t, "SetRolePermissions", mock.Anything, mock.Anything, mock.Anything)
+		}
+	})
+
+	t.Run("role not found", func(t *testing.T) {
+		mockRepo := &MockRepository{}
+		mockRepo.On("FindRoleByID", mock.Anything, uint(999)).Return(nil, nil)
+		invalidator := new(MockRoleCacheInvalidator)
+
+		service := NewRoleService(mockRepo, WithRoleServiceCacheInvalidator(invalidator))
+		role, err := service.SetRolePermissions(context.Background(), 999, SetRolePermissionsRequest{}, false)
+
+		assert.ErrorIs(t, err, ErrRoleNotFound)
+		assert.Nil(t, role)
+		invalidator.AssertNotCalled(t, "InvalidateAllRoles")
+	})
+}
+
+func TestRoleService_BulkUpdateUserRoles(t *testing.T) {
+	adminRole := &Role{ID: 2, Name: RoleAdmin}
+
+	t.Run("assign reports per-user results", func(t *testing.T) {
+		mockRepo := &MockRepository{}
+		mockRepo.On("FindRoleByName", mock.Anything, RoleAdmin).Return(adminRole, nil)
+		mockRepo.On("FindExistingUserIDs", mock.Anything, []uint{1, 2, 3}).Return([]uint{1, 2}, nil)
+		mockRepo.On("AssignRoleBulk", mock.Anything, uint(2), []uint{1, 2}).Return([]uint{2}, nil)
+		invalidator := new(MockRoleCacheInvalidator)
+		invalidator.On("InvalidateUserRoles", uint(2)).Return()
+
+		service := NewRoleService(mockRepo, WithRoleServiceCacheInvalidator(invalidator))
+		results, err := service.BulkUpdateUserRoles(context.Background(), BulkRoleRequest{
+			UserIDs: []uint{1, 2, 3, 2},
+			Role:    RoleAdmin,
+			Action:  BulkRoleActionAssign,
+		}, true)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []BulkRoleResult{
+			{UserID: 1, Status: BulkRoleStatusAlreadyHadRole},
+			{UserID: 2, Status: BulkRoleStatusSucceeded},
+			{UserID: 3, Status: BulkRoleStatusNotFound},
+		}, results)
+		mockRepo.AssertExpectations(t)
+		invalidator.AssertExpectations(t)
+	})
+
+	t.Run("remove reports users without the role", func(t *testing.T) {
+		mockRepo := &MockRepository{}
+		mockRepo.On("FindRoleByName", mock.Anything, RoleAdmin).Return(adminRole, nil)
+		mockRepo.On("FindExistingUserIDs", mock.Anything, []uint{1, 2}).Return([]uint{1, 2}, nil)
+		mockRepo.On("RemoveRoleBulk", mock.Anything, uint(2), []uint{1, 2}).Return([]uint{1}, nil)
+
+		service := NewRoleService(mockRepo)
+		results, err := service.BulkUpdateUserRoles(context.Background(), BulkRoleRequest{
+			UserIDs: []uint{1, 2},
+			Role:    RoleAdmin,
+			Action:  BulkRoleActionRemove,
+		}, true)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []BulkRoleResult{
+			{UserID: 1, Status: BulkRoleStatusSucceeded},
+			{UserID: 2, Status: BulkRoleStatusDidNotHaveRole},
+		}, results)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("admin role requires an admin caller", func(t *testing.T) {
+		for _, action := range []string{BulkRoleActionAssign, BulkRoleActionRemove} {
+			mockRepo := &MockRepository{}
+			mockRepo.On("FindRoleByName", mock.Anything, RoleAdmin).Return(adminRole, nil)
+
+			service := NewRoleService(mockRepo)
+			results, err := service.BulkUpdateUserRoles(context.Background(), BulkRoleRequest{
+				UserIDs: []uint{1},
+				Role:    RoleAdmin,
+				Action:  action,
+			}, false)
+
+			assert.ErrorIs(t, err, ErrAdminGrantForbidden, action)
+			assert.Nil(t, results)
+			mockRepo.AssertNotCalled(t, "FindExistingUserIDs", mock.Anything, mock.Anything)
+		}
+	})
+
+	tooMany := make([]uint, MaxBulkRoleUsers+1)
+	for i := range tooMany {
+		tooMany[i] = uint(i + 1)
+	}
+	validation := []struct {
+		name string
+		req  BulkRoleRequest
+	}{
+		{name: "empty list", req: BulkRoleRequest{Role: RoleAdmin, Action: BulkRoleActionAssign}},
+		{name: "too many users", req: BulkRoleRequest{UserIDs: tooMany, Role: RoleAdmin, Action: BulkRoleActionAssign}},
+		{name: "unknown action", req: BulkRoleRequest{UserIDs: []uint{1}, Role: RoleAdmin, Action: "toggle"}},
+	}
+	for _, tt := range validation {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := &MockRepository{}
+
+			service := NewRoleService(mockRepo)
+			results, err := service.BulkUpdateUserRoles(context.Background(), tt.req, true)
+
+			assert.ErrorIs(t, err, ErrInvalidBulkRoleRequest)
+			assert.Nil(t, results)
+			mockRepo.AssertNotCalled(t, "FindRoleByName", mock.Anything, mock.Anything)
+		})
+	}
+
+	t.Run("unknown role rejects the whole request", func(t *testing.T) {
+		mockRepo := &MockRepository{}
+		mockRepo.On("FindRoleByName", mock.Anything, "ghost").Return(nil, nil)
+
+		service := NewRoleService(mockRepo)
+		_, err := service.BulkUpdateUserRoles(context.Background(), BulkRoleRequest{
+			UserIDs: []uint{1},
+			Role:    "ghost",
+			Action:  BulkRoleActionAssign,
+		}, true)
+
+		assert.ErrorIs(t, err, ErrRoleNotFound)
+		mockRepo.AssertNotCalled(t, "AssignRoleBulk", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("repository error does not invalidate", func(t *testing.T) {
+		mockRepo := &MockRepository{}
+		mockRepo.On("FindRoleByName", mock.Anything, RoleAdmin).Return(adminRole, nil)
+		mockRepo.On("FindExistingUserIDs", mock.Anything, []uint{1}).Return([]uint{1}, nil)
+		mockRepo.On("AssignRoleBulk", mock.Anything, uint(2), []uint{1}).Return(nil, errors.New("database error"))
+		invalidator := new(MockRoleCacheInvalidator)
+
+		service := NewRoleService(mockRepo, WithRoleServiceCacheInvalidator(invalidator))
+		_, err := service.BulkUpdateUserRoles(context.Background(), BulkRoleRequest{
+			UserIDs: []uint{1},
+			Role:    RoleAdmin,
+			Action:  BulkRoleActionAssign,
+		}, true)
+
+		assert.Error(t, err)
+		invalidator.AssertNotCalled(t, "InvalidateUserRoles", mock.Anything)
+	})
+}
+
+func TestRoleService_AssignRoleBulk(t *testing.T) {
+	db := testutil.NewSQLiteDB(t)
+	repo := NewRepository(db)
+	ctx := context.Background()
+
+	ids := createBulkUsers(t, repo, 4)
+	require.NoError(t, repo.AssignRole(ctx, ids[0], RoleAdmin))
+	require.NoError(t, repo.AssignRole(ctx, ids[2], RoleAdmin))
+	require.NoError(t, repo.Delete(ctx, ids[3]))
+
+	invalidator := new(MockRoleCacheInvalidator)
+	invalidator.On("InvalidateUserRoles", ids[1]).Return()
+	service := NewRoleService(repo, WithRoleServiceCacheInvalidator(invalidator))
+
+	results, err := service.AssignRoleBulk(ctx, []uint{ids[0], ids[1], ids[2], ids[1], ids[3], 999999}, RoleAdmin)
+	require.NoError(t, err)
+	assert.Equal(t, []BulkRoleResult{
+		{UserID: ids[0], Status: BulkRoleStatusAlreadyHadRole},
+		{UserID: ids[1], Status: BulkRoleStatusSucceeded},
+		{UserID: ids[2], Status: BulkRoleStatusAlreadyHadRole},
+		{UserID: ids[3], Status: BulkRoleStatusNotFound},
+		{UserID: 999999, Status: BulkRoleStatusNotFound},
+	}, results)
+	invalidator.AssertExpectations(t)
+
+	countAssignments := func() map[uint]int64 {
+		var rows []struct {
+			UserID uint
+			Count  int64
+		}
+		require.NoError(t, db.Table("user_roles").
+			Select("user_roles.user_id, COUNT(*) AS count").
+			Joins("JOIN roles ON roles.id = user_roles.role_id").
+			Where("roles.name = ?", RoleAdmin).
+			Group("user_roles.user_id").
+			Scan(&rows).Error)
+		counts := make(map[uint]int64, len(rows))
+		for _, row := range rows {
+			counts[row.UserID] = row.Count
+		}
+		return counts
+	}
+	assert.Equal(t, map[uint]int64{ids[0]: 1, ids[1]: 1, ids[2]: 1}, countAssignments(), "no duplicate user_roles rows")
+
+	t.Run("repeating the call is idempotent", func(t *testing.T) {
+		results, err := service.AssignRoleBulk(ctx, ids[:3], RoleAdmin)
+		require.NoError(t, err)
+		for _, result := range results {
+			assert.Equal(t, BulkRoleStatusAlreadyHadRole, result.Status, "user %d", result.UserID)
+		}
+		assert.Equal(t, map[uint]int64{ids[0]: 1, ids[1]: 1, ids[2]: 1}, countAssignments())
+	})
+
+	t.Run("unknown role assigns nobody", func(t *testing.T) {
+		_, err := service.AssignRoleBulk(ctx, ids[:1], "ghost")
+		assert.ErrorIs(t, err, ErrRoleNotFound)
+	})
+
+	t.Run("empty list", func(t *testing.T) {
+		_, err := service.AssignRoleBulk(ctx, nil, RoleAdmin)
+		assert.ErrorIs(t, err, ErrInvalidBulkRoleRequest)
+	})
+}
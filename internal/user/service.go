@@ -5,11 +5,18 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 
 	"github.com/yeegeek/uyou-go-api-starter/internal/config"
+	database "github.com/yeegeek/uyou-go-api-starter/internal/db"
+	"github.com/yeegeek/uyou-go-api-starter/internal/metrics"
+	"github.com/yeegeek/uyou-go-api-starter/internal/oauth"
 )
 
 var (
@@ -21,42 +28,108 @@ var (
 	ErrInvalidCredentials = errors.New("invalid credentials")
 	// ErrInvalidRole is returned when role is invalid
 	ErrInvalidRole = errors.New("invalid role")
+	// ErrOAuthEmailNotVerified is returned when a provider account without a verified email signs in for the first time
+	ErrOAuthEmailNotVerified = errors.New("oauth email not verified")
+	// ErrAccountDisabled is returned when a deactivated user tries to sign in
+	ErrAccountDisabled = errors.New("account disabled")
+	// ErrAccountLocked is returned while too many failed logins lock the account; the concrete error is *AccountLockedError
+	ErrAccountLocked = errors.New("account locked")
 )
 
 // Service defines user service interface
 type Service interface {
 	RegisterUser(ctx context.Context, req RegisterRequest) (*User, error)
 	AuthenticateUser(ctx context.Context, req LoginRequest) (*User, error)
+	LoginWithOAuth(ctx context.Context, provider string, profile *oauth.Profile) (*User, error)
+	VerifyPassword(ctx context.Context, id uint, password string) error
 	GetUserByID(ctx context.Context, id uint) (*User, error)
+	GetEffectivePermissions(ctx context.Context, id uint) (*EffectivePermissions, error)
 	UpdateUser(ctx context.Context, id uint, req UpdateUserRequest) (*User, error)
+	PatchUser(ctx context.Context, id uint, req PatchUserRequest) (*User, error)
 	DeleteUser(ctx context.Context, id uint) error
 	ListUsers(ctx context.Context, filters UserFilterParams, page, perPage int) ([]User, int64, error)
 	PromoteToAdmin(ctx context.Context, userID uint) error
+	SetUserActive(ctx context.Context, id uint, active bool) (*User, error)
+	ResetPassword(ctx context.Context, id uint) (string, error)
+	ChangePassword(ctx context.Context, id uint, currentPassword, newPassword string) error
+	GetLockoutStatus(ctx context.Context, id uint) (*LockoutStatus, error)
+	ClearLockout(ctx context.Context, id uint) (*LockoutStatus, error)
+	GetUserStatistics(ctx context.Context) (*UserStatistics, error)
+	ListLoginHistory(ctx context.Context, userID uint, limit, offset int) ([]LoginAttempt, error)
+	GetLastLoginAt(ctx context.Context, userID uint) (*time.Time, error)
+	ConfirmEmailChange(ctx context.Context, token string) (*User, error)
 }
 
 type service struct {
 	repo              Repository
+	uow               UnitOfWork
 	passwordValidator *PasswordValidator
 	bcryptCost        int
+	maxPerPage        int
+	roleCache         RoleCacheInvalidator
+	lockout           lockoutPolicy
+	emailDomains      emailDomainPolicy
+	emailChange       emailChangePolicy
+	loginAttempts     LoginAttemptRecorder
+	// comparePassword checks a password against a bcrypt hash; replaceable in tests
+	comparePassword func(hashedPassword, password string) error
+	// dummyHash is compared against when no user matches the login email
+	dummyHash func() string
+	// policyDocuments policies new users must accept at registration; empty when none are configured
+	policyDocuments []config.PolicyDocumentConfig
+	// historyDepth how many recent passwords, including the current one, a new password may not match; 0 disables the check
+	historyDepth int
 }
 
 // NewService creates a new user service
-func NewService(repo Repository, cfg *config.SecurityConfig) Service {
-	// 设置默认值
-	bcryptCost := 12
-	if cfg.BcryptCost > 0 {
-		bcryptCost = cfg.BcryptCost
-	}
+func NewService(repo Repository, cfg *config.SecurityConfig, opts ...ServiceOption) Service {
+	return NewServiceWithPagination(repo, cfg, config.PaginationConfig{}, opts...)
+}
+
+// NewServiceWithPagination creates a new user service whose ListUsers rejects
+// page sizes above the configured pagination.max_page_size
+func NewServiceWithPagination(repo Repository, cfg *config.SecurityConfig, pagination config.PaginationConfig, opts ...ServiceOption) Service {
+	bcryptCost := cfg.GetBcryptCost()
 
-	return &service{
+	s := &service{
 		repo:              repo,
+		uow:               NewUnitOfWork(repo),
 		passwordValidator: NewPasswordValidator(cfg),
 		bcryptCost:        bcryptCost,
+		maxPerPage:        pagination.GetMaxPageSize(),
+		historyDepth:      max(cfg.PasswordHistoryDepth, 0),
+		roleCache:         noopRoleCacheInvalidator{},
+		lockout:           newLockoutPolicy(cfg),
+		emailDomains:      newEmailDomainPolicy(cfg),
+		emailChange:       newEmailChangePolicy(cfg),
+		loginAttempts:     noopLoginAttemptRecorder{},
+		comparePassword:   verifyPassword,
 	}
+	// WHY: Generated lazily at the configured cost so the dummy comparison takes as long as a real one
+	s.dummyHash = sync.OnceValue(func() string {
+		hash, err := s.hashPassword("dummy-password-for-timing")
+		if err != nil {
+			return ""
+		}
+		return hash
+	})
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
-// RegisterUser registers a new user
+// RegisterUser registers a new user; the email is stored trimmed and in lower case
 func (s *service) RegisterUser(ctx context.Context, req RegisterRequest) (*User, error) {
+	req.Email = normalizeEmail(req.Email)
+	if len(s.policyDocuments) > 0 && !req.AcceptTerms {
+		return nil, ErrTermsNotAccepted
+	}
+	// WHY: Checked before the email lookup so a rejected domain never reveals whether an account exists
+	if err := s.emailDomains.check(req.Email); err != nil {
+		return nil, err
+	}
+
 	existingUser, err := s.repo.FindByEmail(ctx, req.Email)
 	if err != nil {
 		return nil, fmt.Errorf("failed to check existing email: %w", err)
@@ -81,52 +154,202 @@ func (s *service) RegisterUser(ctx context.Context, req RegisterRequest) (*User,
 		PasswordHash: hashedPassword,
 	}
 
-	// Use transaction to ensure atomic user creation and role assignment
-	err = s.repo.Transaction(ctx, func(txCtx context.Context) error {
-		if err := s.repo.Create(txCtx, user); err != nil {
+	// WHY: The reload runs in the transaction too, so a failure anywhere leaves no partial account behind
+	var registered *User
+	err = s.uow.WithTransaction(ctx, func(txCtx context.Context, repo Repository) error {
+		if err := repo.Create(txCtx, user); err != nil {
 			return fmt.Errorf("failed to create user: %w", err)
 		}
 
-		if err := s.repo.AssignRole(txCtx, user.ID, RoleUser); err != nil {
+		if err := repo.AssignRole(txCtx, user.ID, RoleUser); err != nil {
 			return fmt.Errorf("failed to assign default role: %w", err)
 		}
 
+		// WHY: Recorded in the same transaction so no account exists without its acceptance record
+		if len(s.policyDocuments) > 0 {
+			if err := repo.CreatePolicyAcceptances(txCtx, newPolicyAcceptances(txCtx, user.ID, s.policyDocuments)); err != nil {
+				return fmt.Errorf("failed to record policy acceptance: %w", err)
+			}
+		}
+
+		// Reload user with roles
+		reloaded, err := repo.FindByID(txCtx, user.ID)
+		if err != nil {
+			return fmt.Errorf("failed to reload user: %w", err)
+		}
+		if reloaded == nil {
+			return fmt.Errorf("failed to reload user: user not found after creation")
+		}
+		registered = reloaded
 		return nil
 	})
-
 	if err != nil {
+		// WHY: A concurrent registration with the same email passes the lookup above and loses on the unique constraint
+		if database.IsUniqueViolation(err) {
+			return nil, ErrEmailExists
+		}
 		return nil, err
 	}
 
-	// Reload user with roles after successful transaction
-	user, err = s.repo.FindByID(ctx, user.ID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to reload user: %w", err)
-	}
-	if user == nil {
-		return nil, fmt.Errorf("failed to reload user: user not found after creation")
-	}
-
-	return user, nil
+	return registered, nil
 }
 
-// AuthenticateUser authenticates a user with email and password
+// AuthenticateUser authenticates a user with email and password; the email match ignores case
 func (s *service) AuthenticateUser(ctx context.Context, req LoginRequest) (*User, error) {
+	req.Email = normalizeEmail(req.Email)
 	user, err := s.repo.FindByEmail(ctx, req.Email)
 	if err != nil {
 		return nil, fmt.Errorf("failed to find user: %w", err)
 	}
 	if user == nil {
+		// WHY: Comparing against a dummy hash keeps the response time close to a wrong password,
+		// so timing does not reveal which emails have accounts
+		_ = s.comparePassword(s.dummyHash(), req.Password)
+		metrics.RecordLoginFailure(metrics.LoginFailureUnknownUser)
+		s.recordLoginAttempt(ctx, nil, req.Email, LoginOutcomeInvalidCredentials)
 		return nil, ErrInvalidCredentials
 	}
 
-	if err := verifyPassword(user.PasswordHash, req.Password); err != nil {
+	// WHY: Checked before the password so a locked account cannot keep being probed
+	lockout, err := s.lockoutStatus(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if lockout.Locked {
+		metrics.RecordLoginFailure(metrics.LoginFailureLocked)
+		s.recordLoginAttempt(ctx, &user.ID, req.Email, LoginOutcomeLocked)
+		return nil, &AccountLockedError{Until: *lockout.LockedUntil}
+	}
+
+	if err := s.comparePassword(user.PasswordHash, req.Password); err != nil {
+		metrics.RecordLoginFailure(metrics.LoginFailureBadPassword)
+		s.recordLoginAttempt(ctx, &user.ID, req.Email, LoginOutcomeInvalidCredentials)
+		if err := s.recordLoginFailure(ctx, user.ID); err != nil {
+			return nil, err
+		}
 		return nil, ErrInvalidCredentials
 	}
+	// WHY: Checked after the password so the disabled state is not revealed to someone guessing credentials
+	if !user.Active {
+		metrics.RecordLoginFailure(metrics.LoginFailureDisabled)
+		s.recordLoginAttempt(ctx, &user.ID, req.Email, LoginOutcomeDisabled)
+		return nil, ErrAccountDisabled
+	}
 
+	if lockout.FailedAttempts > 0 {
+		if err := s.repo.ClearLoginFailures(ctx, user.ID); err != nil {
+			return nil, fmt.Errorf("failed to clear login failures: %w", err)
+		}
+	}
+
+	metrics.RecordLoginSuccess()
+	s.recordLoginAttempt(ctx, &user.ID, req.Email, LoginOutcomeSuccess)
 	return user, nil
 }
 
+// LoginWithOAuth signs in with an external provider account.
+// A previously linked account is matched by provider subject; otherwise the account
+// is linked to the user with the same verified email, or a new user is created.
+func (s *service) LoginWithOAuth(ctx context.Context, provider string, profile *oauth.Profile) (*User, error) {
+	identity, err := s.repo.FindIdentity(ctx, provider, profile.Subject)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find identity: %w", err)
+	}
+	if identity != nil {
+		user, err := s.repo.FindByID(ctx, identity.UserID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find user: %w", err)
+		}
+		if user == nil {
+			return nil, ErrUserNotFound
+		}
+		if !user.Active {
+			return nil, ErrAccountDisabled
+		}
+		return user, nil
+	}
+
+	// WHY: Linking by an unverified email would let anyone claim an existing account
+	if profile.Email == "" || !profile.EmailVerified {
+		return nil, ErrOAuthEmailNotVerified
+	}
+
+	email := normalizeEmail(profile.Email)
+	var userID uint
+	err = s.repo.Transaction(ctx, func(txCtx context.Context) error {
+		user, err := s.repo.FindByEmail(txCtx, email)
+		if err != nil {
+			return fmt.Errorf("failed to check existing email: %w", err)
+		}
+		if user != nil && !user.Active {
+			return ErrAccountDisabled
+		}
+
+		if user == nil {
+			// OAuth-only users have no password, so password login always fails for them
+			user = &User{
+				Name:      oauthDisplayName(profile),
+				Email:     email,
+				AvatarURL: profile.AvatarURL,
+			}
+			if err := s.repo.Create(txCtx, user); err != nil {
+				return fmt.Errorf("failed to create user: %w", err)
+			}
+			if err := s.repo.AssignRole(txCtx, user.ID, RoleUser); err != nil {
+				return fmt.Errorf("failed to assign default role: %w", err)
+			}
+		}
+
+		userID = user.ID
+		if err := s.repo.CreateIdentity(txCtx, &UserIdentity{
+			UserID:   user.ID,
+			Provider: provider,
+			Subject:  profile.Subject,
+			Email:    profile.Email,
+		}); err != nil {
+			return fmt.Errorf("failed to link identity: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.repo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reload user: %w", err)
+	}
+	if user == nil {
+		return nil, fmt.Errorf("failed to reload user: user not found after linking")
+	}
+	return user, nil
+}
+
+// oauthDisplayName falls back to the email local part when the provider has no name
+func oauthDisplayName(profile *oauth.Profile) string {
+	if name := strings.TrimSpace(profile.Name); name != "" {
+		return name
+	}
+	name, _, _ := strings.Cut(profile.Email, "@")
+	return name
+}
+
+// VerifyPassword re-authenticates a signed-in user before a sensitive operation
+func (s *service) VerifyPassword(ctx context.Context, id uint, password string) error {
+	user, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to find user: %w", err)
+	}
+	if user == nil {
+		return ErrUserNotFound
+	}
+
+	if err := s.comparePassword(user.PasswordHash, password); err != nil {
+		return ErrInvalidCredentials
+	}
+	return nil
+}
+
 // GetUserByID retrieves a user by ID
 func (s *service) GetUserByID(ctx context.Context, id uint) (*User, error) {
 	user, err := s.repo.FindByID(ctx, id)
@@ -139,8 +362,10 @@ func (s *service) GetUserByID(ctx context.Context, id uint) (*User, error) {
 	return user, nil
 }
 
-// UpdateUser updates a user's information
-func (s *service) UpdateUser(ctx context.Context, id uint, req UpdateUserRequest) (*User, error) {
+// GetEffectivePermissions resolves the user's current roles and the flattened set of
+// permissions granted through them. It reads the database rather than token claims so
+// role assignments are reflected immediately.
+func (s *service) GetEffectivePermissions(ctx context.Context, id uint) (*EffectivePermissions, error) {
 	user, err := s.repo.FindByID(ctx, id)
 	if err != nil {
 		return nil, fmt.Errorf("failed to find user: %w", err)
@@ -149,18 +374,77 @@ func (s *service) UpdateUser(ctx context.Context, id uint, req UpdateUserRequest
 		return nil, ErrUserNotFound
 	}
 
+	roles, err := s.repo.GetUserRoles(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user roles: %w", err)
+	}
+
+	roleNames := make([]string, 0, len(roles))
+	for _, role := range roles {
+		roleNames = append(roleNames, role.Name)
+	}
+	sort.Strings(roleNames)
+
+	return &EffectivePermissions{
+		Roles:       roleNames,
+		Permissions: ResolvePermissions(roles),
+	}, nil
+}
+
+// UpdateUser updates a user's information.
+// Empty fields are treated as "not provided" to keep PUT backward compatible.
+func (s *service) UpdateUser(ctx context.Context, id uint, req UpdateUserRequest) (*User, error) {
+	var patch PatchUserRequest
 	if req.Name != "" {
-		user.Name = req.Name
+		patch.Name = &req.Name
 	}
 	if req.Email != "" {
-		existingUser, err := s.repo.FindByEmail(ctx, req.Email)
+		patch.Email = &req.Email
+	}
+	return s.PatchUser(ctx, id, patch)
+}
+
+// PatchUser applies a partial update, changing only the fields that were provided
+func (s *service) PatchUser(ctx context.Context, id uint, req PatchUserRequest) (*User, error) {
+	user, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find user: %w", err)
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+
+	if req.Name != nil {
+		user.Name = *req.Name
+	}
+	var pendingEmail string
+	if req.Email != nil {
+		email := normalizeEmail(*req.Email)
+		req.Email = &email
+		existingUser, err := s.repo.FindByEmail(ctx, *req.Email)
 		if err != nil {
 			return nil, fmt.Errorf("failed to check existing email: %w", err)
 		}
 		if existingUser != nil && existingUser.ID != user.ID {
 			return nil, ErrEmailExists
 		}
-		user.Email = req.Email
+		switch {
+		case *req.Email == user.Email:
+			// WHY: Setting the current email again withdraws a pending change
+			clearPendingEmail(user)
+		case s.emailChange.verify:
+			pendingEmail = *req.Email
+		default:
+			user.Email = *req.Email
+			clearPendingEmail(user)
+		}
+	}
+
+	if pendingEmail != "" {
+		if err := s.requestEmailChange(ctx, user, pendingEmail); err != nil {
+			return nil, err
+		}
+		return user, nil
 	}
 
 	if err := s.repo.Update(ctx, user); err != nil {
@@ -190,8 +474,8 @@ func (s *service) ListUsers(ctx context.Context, filters UserFilterParams, page,
 	if perPage < 1 {
 		return nil, 0, fmt.Errorf("perPage must be >= 1")
 	}
-	if perPage > 100 {
-		return nil, 0, fmt.Errorf("perPage must be <= 100")
+	if perPage > s.maxPerPage {
+		return nil, 0, fmt.Errorf("perPage must be <= %d", s.maxPerPage)
 	}
 
 	if filters.Role != "" && filters.Role != RoleUser && filters.Role != RoleAdmin {
@@ -223,10 +507,32 @@ func (s *service) PromoteToAdmin(ctx context.Context, userID uint) error {
 	if err := s.repo.AssignRole(ctx, userID, RoleAdmin); err != nil {
 		return fmt.Errorf("failed to assign admin role: %w", err)
 	}
+	s.roleCache.InvalidateUserRoles(userID)
 
 	return nil
 }
 
+// SetUserActive deactivates or reactivates a user account. Deactivated users cannot sign in;
+// callers are responsible for revoking their existing sessions. Setting the current state is a no-op.
+func (s *service) SetUserActive(ctx context.Context, id uint, active bool) (*User, error) {
+	user, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find user: %w", err)
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	if user.Active == active {
+		return user, nil
+	}
+
+	if err := s.repo.SetActive(ctx, id, active); err != nil {
+		return nil, fmt.Errorf("failed to update account status: %w", err)
+	}
+	user.Active = active
+	return user, nil
+}
+
 // hashPassword hashes a plain text password using bcrypt
 func (s *service) hashPassword(password string) (string, error) {
 	hashedBytes, err := bcrypt.GenerateFromPassword([]byte(password), s.bcryptCost)
@@ -240,3 +546,29 @@ func (s *service) hashPassword(password string) (string, error) {
 func verifyPassword(hashedPassword, password string) error {
 	return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
 }
+
+// GetUserStatistics aggregates user counts for the admin dashboard
+func (s *service) GetUserStatistics(ctx context.Context) (*UserStatistics, error) {
+	var stats UserStatistics
+	var err error
+
+	if stats.TotalUsers, err = s.repo.CountUsers(ctx); err != nil {
+		return nil, fmt.Errorf("failed to count users: %w", err)
+	}
+	if stats.AdminUsers, err = s.repo.CountUsersByRole(ctx, RoleAdmin); err != nil {
+		return nil, fmt.Errorf("failed to count admin users: %w", err)
+	}
+
+	now := time.Now()
+	if stats.NewUsersLast24h, err = s.repo.CountUsersSince(ctx, now.Add(-24*time.Hour)); err != nil {
+		return nil, fmt.Errorf("failed to count new users: %w", err)
+	}
+	if stats.NewUsersLast7d, err = s.repo.CountUsersSince(ctx, now.AddDate(0, 0, -7)); err != nil {
+		return nil, fmt.Errorf("failed to count new users: %w", err)
+	}
+	if stats.NewUsersLast30d, err = s.repo.CountUsersSince(ctx, now.AddDate(0, 0, -30)); err != nil {
+		return nil, fmt.Errorf("failed to count new users: %w", err)
+	}
+
+	return &stats, nil
+}
@@ -4,13 +4,18 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 
 	"github.com/yeegeek/uyou-go-api-starter/internal/config"
+	"github.com/yeegeek/uyou-go-api-starter/internal/metrics"
+	"github.com/yeegeek/uyou-go-api-starter/internal/testutil"
 )
 
 // newTestSecurityConfig 创建测试用的安全配置
@@ -146,11 +151,71 @@ func TestService_AuthenticateUser(t *testing.T) {
 					ID:           1,
 					Email:        "john@example.com",
 					PasswordHash: string(hashedPassword),
+					Active:       true,
 				}
 				m.On("FindByEmail", mock.Anything, "john@example.com").Return(user, nil)
+				m.On("ListLoginFailures", mock.Anything, uint(1), mock.Anything).Return([]time.Time{}, nil)
 			},
 			expectedErr: nil,
 		},
+		{
+			name: "deactivated account",
+			request: LoginRequest{
+				Email:    "john@example.com",
+				Password: "Password123!",
+			},
+			setupMock: func(m *MockRepository) {
+				user := &User{
+					ID:           1,
+					Email:        "john@example.com",
+					PasswordHash: string(hashedPassword),
+				}
+				m.On("FindByEmail", mock.Anything, "john@example.com").Return(user, nil)
+				m.On("ListLoginFailures", mock.Anything, uint(1), mock.Anything).Return([]time.Time{}, nil)
+			},
+			expectedErr: ErrAccountDisabled,
+		},
+		{
+			name: "success clears earlier failures",
+			request: LoginRequest{
+				Email:    "john@example.com",
+				Password: "Password123!",
+			},
+			setupMock: func(m *MockRepository) {
+				user := &User{
+					ID:           1,
+					Email:        "john@example.com",
+					PasswordHash: string(hashedPassword),
+					Active:       true,
+				}
+				m.On("FindByEmail", mock.Anything, "john@example.com").Return(user, nil)
+				m.On("ListLoginFailures", mock.Anything, uint(1), mock.Anything).Return([]time.Time{time.Now()}, nil)
+				m.On("ClearLoginFailures", mock.Anything, uint(1)).Return(nil)
+			},
+			expectedErr: nil,
+		},
+		{
+			name: "locked account rejects correct password",
+			request: LoginRequest{
+				Email:    "john@example.com",
+				Password: "Password123!",
+			},
+			setupMock: func(m *MockRepository) {
+				user := &User{
+					ID:           1,
+					Email:        "john@example.com",
+					PasswordHash: string(hashedPassword),
+					Active:       true,
+				}
+				failures := make([]time.Time, 5)
+				for i := range failures {
+					failures[i] = time.Now()
+				}
+				m.On("FindByEmail", mock.Anything, "john@example.com").Return(user, nil)
+				m.On("ListLoginFailures", mock.Anything, uint(1), mock.Anything).Return(failures, nil)
+			},
+			expectedErr: ErrAccountLocked,
+		},
 		{
 			name: "user not found",
 			request: LoginRequest{
@@ -175,6 +240,8 @@ func TestService_AuthenticateUser(t *testing.T) {
 					PasswordHash: string(hashedPassword),
 				}
 				m.On("FindByEmail", mock.Anything, "john@example.com").Return(user, nil)
+				m.On("ListLoginFailures", mock.Anything, uint(1), mock.Anything).Return([]time.Time{}, nil)
+				m.On("RecordLoginFailure", mock.Anything, uint(1), mock.Anything, mock.Anything).Return(nil)
 			},
 			expectedErr: ErrInvalidCredentials,
 		},
@@ -214,6 +281,59 @@ func TestService_AuthenticateUser(t *testing.T) {
 	}
 }
 
+func TestService_VerifyPassword(t *testing.T) {
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("Password123!"), bcrypt.DefaultCost)
+	user := &User{ID: 1, Email: "john@example.com", PasswordHash: string(hashedPassword)}
+
+	tests := []struct {
+		name        string
+		password    string
+		setupMock   func(*MockRepository)
+		expectedErr error
+	}{
+		{
+			name:     "correct password",
+			password: "Password123!",
+			setupMock: func(m *MockRepository) {
+				m.On("FindByID", mock.Anything, uint(1)).Return(user, nil)
+			},
+		},
+		{
+			name:     "wrong password",
+			password: "wrongpassword",
+			setupMock: func(m *MockRepository) {
+				m.On("FindByID", mock.Anything, uint(1)).Return(user, nil)
+			},
+			expectedErr: ErrInvalidCredentials,
+		},
+		{
+			name:     "user not found",
+			password: "Password123!",
+			setupMock: func(m *MockRepository) {
+				m.On("FindByID", mock.Anything, uint(1)).Return(nil, nil)
+			},
+			expectedErr: ErrUserNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := &MockRepository{}
+			tt.setupMock(mockRepo)
+
+			service := NewService(mockRepo, newTestSecurityConfig())
+			err := service.VerifyPassword(context.Background(), 1, tt.password)
+
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
 func TestService_GetUserByID(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -349,6 +469,66 @@ func TestService_UpdateUser(t *testing.T) {
 	}
 }
 
+func TestService_PatchUser(t *testing.T) {
+	name := "Updated Name"
+	email := "updated@example.com"
+	taken := "existing@example.com"
+
+	t.Run("omitted fields are left unchanged", func(t *testing.T) {
+		mockRepo := &MockRepository{}
+		mockRepo.On("FindByID", mock.Anything, uint(1)).Return(&User{ID: 1, Name: "John Doe", Email: "john@example.com"}, nil)
+		mockRepo.On("Update", mock.Anything, mock.AnythingOfType("*user.User")).Return(nil)
+
+		service := NewService(mockRepo, newTestSecurityConfig())
+		user, err := service.PatchUser(context.Background(), 1, PatchUserRequest{Name: &name})
+
+		assert.NoError(t, err)
+		assert.Equal(t, name, user.Name)
+		assert.Equal(t, "john@example.com", user.Email)
+		mockRepo.AssertNotCalled(t, "FindByEmail", mock.Anything, mock.Anything)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("provided email is applied", func(t *testing.T) {
+		mockRepo := &MockRepository{}
+		mockRepo.On("FindByID", mock.Anything, uint(1)).Return(&User{ID: 1, Name: "John Doe", Email: "john@example.com"}, nil)
+		mockRepo.On("FindByEmail", mock.Anything, email).Return(nil, nil)
+		mockRepo.On("Update", mock.Anything, mock.AnythingOfType("*user.User")).Return(nil)
+
+		service := NewService(mockRepo, newTestSecurityConfig())
+		user, err := service.PatchUser(context.Background(), 1, PatchUserRequest{Email: &email})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "John Doe", user.Name)
+		assert.Equal(t, email, user.Email)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("email already exists", func(t *testing.T) {
+		mockRepo := &MockRepository{}
+		mockRepo.On("FindByID", mock.Anything, uint(1)).Return(&User{ID: 1, Name: "John Doe", Email: "john@example.com"}, nil)
+		mockRepo.On("FindByEmail", mock.Anything, taken).Return(&User{ID: 2, Email: taken}, nil)
+
+		service := NewService(mockRepo, newTestSecurityConfig())
+		user, err := service.PatchUser(context.Background(), 1, PatchUserRequest{Email: &taken})
+
+		assert.ErrorIs(t, err, ErrEmailExists)
+		assert.Nil(t, user)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("user not found", func(t *testing.T) {
+		mockRepo := &MockRepository{}
+		mockRepo.On("FindByID", mock.Anything, uint(999)).Return(nil, nil)
+
+		service := NewService(mockRepo, newTestSecurityConfig())
+		user, err := service.PatchUser(context.Background(), 999, PatchUserRequest{Name: &name})
+
+		assert.ErrorIs(t, err, ErrUserNotFound)
+		assert.Nil(t, user)
+	})
+}
+
 func TestService_DeleteUser(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -594,6 +774,7 @@ func TestService_PromoteToAdmin(t *testing.T) {
 		userID      uint
 		setupMocks  func(*MockRepository)
 		expectedErr error
+		invalidates bool
 	}{
 		{
 			name:   "successful promotion",
@@ -604,6 +785,7 @@ func TestService_PromoteToAdmin(t *testing.T) {
 				m.On("AssignRole", mock.Anything, uint(1), RoleAdmin).Return(nil)
 			},
 			expectedErr: nil,
+			invalidates: true,
 		},
 		{
 			name:   "user not found",
@@ -652,8 +834,12 @@ func TestService_PromoteToAdmin(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			mockRepo := new(MockRepository)
 			tt.setupMocks(mockRepo)
+			invalidator := new(MockRoleCacheInvalidator)
+			if tt.invalidates {
+				invalidator.On("InvalidateUserRoles", tt.userID).Return()
+			}
 
-			service := NewService(mockRepo, newTestSecurityConfig())
+			service := NewService(mockRepo, newTestSecurityConfig(), WithRoleCacheInvalidator(invalidator))
 			err := service.PromoteToAdmin(context.Background(), tt.userID)
 
 			if tt.expectedErr != nil {
@@ -666,6 +852,7 @@ func TestService_PromoteToAdmin(t *testing.T) {
 			}
 
 			mockRepo.AssertExpectations(t)
+			invalidator.AssertExpectations(t)
 		})
 	}
 }
@@ -730,6 +917,19 @@ func TestService_RegisterUser_ErrorPaths(t *testing.T) {
 			},
 			expectedErr: "user not found after creation",
 		},
+		{
+			name: "concurrent registration loses on the unique constraint",
+			request: RegisterRequest{
+				Name:     "John Doe",
+				Email:    "john@example.com",
+				Password: "Password123!",
+			},
+			setupMock: func(m *MockRepository) {
+				m.On("FindByEmail", mock.Anything, "john@example.com").Return(nil, nil)
+				m.On("Create", mock.Anything, mock.AnythingOfType("*user.User")).Return(repositoryError("Create", gorm.ErrDuplicatedKey))
+			},
+			expectedErr: ErrEmailExists.Error(),
+		},
 	}
 
 	for _, tt := range tests {
@@ -797,6 +997,24 @@ func TestService_ListUsers_PaginationErrors(t *testing.T) {
 	}
 }
 
+func TestService_ListUsers_ConfiguredMaxPageSize(t *testing.T) {
+	mockRepo := &MockRepository{}
+	filters := UserFilterParams{Sort: "created_at", Order: "desc"}
+	mockRepo.On("ListAllUsers", mock.Anything, filters, 1, 50).Return([]User{}, int64(0), nil)
+
+	service := NewServiceWithPagination(mockRepo, newTestSecurityConfig(), config.PaginationConfig{MaxPageSize: 50})
+
+	users, total, err := service.ListUsers(context.Background(), filters, 1, 51)
+	assert.EqualError(t, err, "perPage must be <= 50")
+	assert.Nil(t, users)
+	assert.Equal(t, int64(0), total)
+
+	_, _, err = service.ListUsers(context.Background(), filters, 1, 50)
+	assert.NoError(t, err)
+
+	mockRepo.AssertExpectations(t)
+}
+
 func TestService_ListUsers_RepositoryError(t *testing.T) {
 	mockRepo := &MockRepository{}
 	filters := UserFilterParams{Sort: "created_at", Order: "desc"}
@@ -905,3 +1123,180 @@ func TestService_UpdateUser_ErrorPaths(t *testing.T) {
 		})
 	}
 }
+
+func TestService_GetEffectivePermissions_ReflectsRoleChanges(t *testing.T) {
+	db := testutil.NewSQLiteDB(t)
+	require.NoError(t, db.Exec("INSERT INTO role_permissions (role_id, permission_id) VALUES (2, 1), (2, 3)").Error)
+
+	repo := NewRepository(db)
+	service := NewService(repo, newTestSecurityConfig())
+	ctx := context.Background()
+
+	user, err := service.RegisterUser(ctx, RegisterRequest{Name: "Perm User", Email: "perm@example.com", Password: "Password123!"})
+	require.NoError(t, err)
+
+	got, err := service.GetEffectivePermissions(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{RoleUser}, got.Roles)
+	assert.Empty(t, got.Permissions)
+	assert.NotNil(t, got.Permissions)
+
+	require.NoError(t, service.PromoteToAdmin(ctx, user.ID))
+
+	got, err = service.GetEffectivePermissions(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{RoleAdmin, RoleUser}, got.Roles)
+	assert.Equal(t, []string{PermissionUsersDelete, PermissionUsersRead}, got.Permissions)
+
+	_, err = service.GetEffectivePermissions(ctx, 999999)
+	assert.ErrorIs(t, err, ErrUserNotFound)
+}
+
+func TestService_SetUserActive_LoginBlockedUntilReactivated(t *testing.T) {
+	db := testutil.NewSQLiteDB(t)
+	service := NewService(NewRepository(db), newTestSecurityConfig())
+	ctx := context.Background()
+
+	credentials := LoginRequest{Email: "jane@example.com", Password: "Password123!"}
+	registered, err := service.RegisterUser(ctx, RegisterRequest{Name: "Jane Doe", Email: credentials.Email, Password: credentials.Password})
+	require.NoError(t, err)
+
+	deactivated, err := service.SetUserActive(ctx, registered.ID, false)
+	require.NoError(t, err)
+	assert.False(t, deactivated.Active)
+
+	_, err = service.AuthenticateUser(ctx, credentials)
+	assert.ErrorIs(t, err, ErrAccountDisabled)
+
+	_, err = service.AuthenticateUser(ctx, LoginRequest{Email: credentials.Email, Password: "WrongPassword1!"})
+	assert.ErrorIs(t, err, ErrInvalidCredentials, "a wrong password does not reveal the disabled state")
+
+	reactivated, err := service.SetUserActive(ctx, registered.ID, true)
+	require.NoError(t, err)
+	assert.True(t, reactivated.Active)
+
+	user, err := service.AuthenticateUser(ctx, credentials)
+	require.NoError(t, err)
+	assert.Equal(t, registered.ID, user.ID)
+}
+
+func TestService_SetUserActive(t *testing.T) {
+	t.Run("user not found", func(t *testing.T) {
+		mockRepo := &MockRepository{}
+		mockRepo.On("FindByID", mock.Anything, uint(999)).Return(nil, nil)
+
+		service := NewService(mockRepo, newTestSecurityConfig())
+		user, err := service.SetUserActive(context.Background(), 999, false)
+
+		assert.ErrorIs(t, err, ErrUserNotFound)
+		assert.Nil(t, user)
+	})
+
+	t.Run("already in requested state", func(t *testing.T) {
+		mockRepo := &MockRepository{}
+		mockRepo.On("FindByID", mock.Anything, uint(1)).Return(&User{ID: 1, Active: true}, nil)
+
+		service := NewService(mockRepo, newTestSecurityConfig())
+		user, err := service.SetUserActive(context.Background(), 1, true)
+
+		assert.NoError(t, err)
+		assert.True(t, user.Active)
+		mockRepo.AssertNotCalled(t, "SetActive", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("repository error", func(t *testing.T) {
+		mockRepo := &MockRepository{}
+		mockRepo.On("FindByID", mock.Anything, uint(1)).Return(&User{ID: 1, Active: true}, nil)
+		mockRepo.On("SetActive", mock.Anything, uint(1), false).Return(errors.New("db error"))
+
+		service := NewService(mockRepo, newTestSecurityConfig())
+		_, err := service.SetUserActive(context.Background(), 1, false)
+
+		assert.Error(t, err)
+	})
+}
+
+func TestService_AuthenticateUser_RecordsFailureReasons(t *testing.T) {
+	db := testutil.NewSQLiteDB(t)
+	service := NewService(NewRepository(db), newTestSecurityConfig())
+	ctx := context.Background()
+
+	credentials := LoginRequest{Email: "jane@example.com", Password: "Password123!"}
+	_, err := service.RegisterUser(ctx, RegisterRequest{Name: "Jane Doe", Email: credentials.Email, Password: credentials.Password})
+	require.NoError(t, err)
+
+	failures := func(reason string) float64 {
+		return counterValue(t, "auth_login_failures_total", "reason", reason)
+	}
+	badPassword := failures(metrics.LoginFailureBadPassword)
+	unknownUser := failures(metrics.LoginFailureUnknownUser)
+	locked := failures(metrics.LoginFailureLocked)
+	successes := counterValue(t, "auth_login_success_total", "", "")
+
+	_, err = service.AuthenticateUser(ctx, credentials)
+	require.NoError(t, err)
+	for i := 0; i < 2; i++ {
+		_, err = service.AuthenticateUser(ctx, LoginRequest{Email: "nobody@example.com", Password: "Password123!"})
+		require.ErrorIs(t, err, ErrInvalidCredentials)
+	}
+	for i := 0; i < 5; i++ {
+		_, err = service.AuthenticateUser(ctx, LoginRequest{Email: credentials.Email, Password: "WrongPassword1!"})
+		require.ErrorIs(t, err, ErrInvalidCredentials)
+	}
+	_, err = service.AuthenticateUser(ctx, credentials)
+	require.ErrorIs(t, err, ErrAccountLocked)
+
+	assert.Equal(t, successes+1, counterValue(t, "auth_login_success_total", "", ""))
+	assert.Equal(t, unknownUser+2, failures(metrics.LoginFailureUnknownUser))
+	assert.Equal(t, badPassword+5, failures(metrics.LoginFailureBadPassword))
+	assert.Equal(t, locked+1, failures(metrics.LoginFailureLocked))
+}
+
+func TestService_AuthenticateUser_ComparesPasswordForUnknownUsers(t *testing.T) {
+	db := testutil.NewSQLiteDB(t)
+	svc := NewService(NewRepository(db), newTestSecurityConfig()).(*service)
+	ctx := context.Background()
+
+	_, err := svc.RegisterUser(ctx, RegisterRequest{Name: "Jane Doe", Email: "jane@example.com", Password: "Password123!"})
+	require.NoError(t, err)
+
+	var compared []string
+	svc.comparePassword = func(hashedPassword, password string) error {
+		compared = append(compared, hashedPassword)
+		return verifyPassword(hashedPassword, password)
+	}
+
+	_, err = svc.AuthenticateUser(ctx, LoginRequest{Email: "nobody@example.com", Password: "Password123!"})
+	require.ErrorIs(t, err, ErrInvalidCredentials)
+	require.Len(t, compared, 1, "unknown users still pay for a bcrypt comparison")
+	cost, err := bcrypt.Cost([]byte(compared[0]))
+	require.NoError(t, err)
+	assert.Equal(t, svc.bcryptCost, cost, "the dummy hash uses the configured cost")
+
+	_, err = svc.AuthenticateUser(ctx, LoginRequest{Email: "jane@example.com", Password: "WrongPassword1!"})
+	require.ErrorIs(t, err, ErrInvalidCredentials)
+	assert.Len(t, compared, 2)
+}
+
+// counterValue reads a counter from the default Prometheus registry; label selects one series of a vector
+func counterValue(t *testing.T, name, label, value string) float64 {
+	t.Helper()
+	families, err := prometheus.DefaultGatherer.Gather()
+	require.NoError(t, err)
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			if label == "" {
+				return metric.GetCounter().GetValue()
+			}
+			for _, pair := range metric.GetLabel() {
+				if pair.GetName() == label && pair.GetValue() == value {
+					return metric.GetCounter().GetValue()
+				}
+			}
+		}
+	}
+	return 0
+}
@@ -0,0 +1,64 @@
+package user
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yeegeek/uyou-go-api-starter/internal/testutil"
+)
+
+func TestService_ResetPassword(t *testing.T) {
+	db := testutil.NewSQLiteDB(t)
+	service := NewService(NewRepository(db), newTestSecurityConfig())
+	ctx := context.Background()
+
+	registered, err := service.RegisterUser(ctx, RegisterRequest{Name: "Jane Doe", Email: "jane@example.com", Password: "Password123!"})
+	require.NoError(t, err)
+
+	tokenVersion := func() int {
+		var version int
+		require.NoError(t, db.Raw("SELECT token_version FROM users WHERE id = ?", registered.ID).Scan(&version).Error)
+		return version
+	}
+	before := tokenVersion()
+
+	password, err := service.ResetPassword(ctx, registered.ID)
+	require.NoError(t, err)
+	assert.Len(t, password, temporaryPasswordLength)
+	assert.NoError(t, NewPasswordValidator(newTestSecurityConfig()).Validate(password))
+
+	_, err = service.AuthenticateUser(ctx, LoginRequest{Email: "jane@example.com", Password: "Password123!"})
+	assert.ErrorIs(t, err, ErrInvalidCredentials, "the old password no longer works")
+	_, err = service.AuthenticateUser(ctx, LoginRequest{Email: "jane@example.com", Password: password})
+	assert.NoError(t, err)
+
+	assert.Equal(t, before+1, tokenVersion(), "access tokens issued before the reset are invalidated")
+}
+
+func TestService_ResetPassword_UserNotFound(t *testing.T) {
+	mockRepo := &MockRepository{}
+	mockRepo.On("FindByID", mock.Anything, uint(999)).Return(nil, nil)
+
+	service := NewService(mockRepo, newTestSecurityConfig())
+	password, err := service.ResetPassword(context.Background(), 999)
+
+	assert.ErrorIs(t, err, ErrUserNotFound)
+	assert.Empty(t, password)
+	mockRepo.AssertNotCalled(t, "UpdatePassword", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestGenerateTemporaryPassword(t *testing.T) {
+	seen := make(map[string]bool)
+	for i := 0; i < 50; i++ {
+		password, err := generateTemporaryPassword(temporaryPasswordLength)
+		require.NoError(t, err)
+		assert.Len(t, password, temporaryPasswordLength)
+		assert.True(t, containsUppercase(password) && containsLowercase(password) && containsNumber(password) && containsSpecial(password), password)
+		assert.False(t, seen[password], "passwords must not repeat")
+		seen[password] = true
+	}
+}
@@ -0,0 +1,127 @@
+package user
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yeegeek/uyou-go-api-starter/internal/auth"
+	"github.com/yeegeek/uyou-go-api-starter/internal/config"
+	"github.com/yeegeek/uyou-go-api-starter/internal/testutil"
+)
+
+func TestRoleChangePropagation_StaleTokenRejected(t *testing.T) {
+	db := testutil.NewSQLiteDB(t)
+
+	repo := NewRepository(db)
+	authService := auth.NewServiceWithRepo(&config.JWTConfig{
+		Secret:               "test-secret-that-is-long-enough-123",
+		AccessTokenTTL:       15 * time.Minute,
+		RefreshTokenTTL:      24 * time.Hour,
+		EnforceTokenVersion:  true,
+		TokenVersionCacheTTL: 10 * time.Millisecond,
+	}, db)
+	userService := NewService(repo, newTestSecurityConfig(), WithRoleCacheInvalidator(authService))
+	ctx := context.Background()
+
+	registered, err := userService.RegisterUser(ctx, RegisterRequest{
+		Name:     "Jane Doe",
+		Email:    "jane@example.com",
+		Password: "Password123!",
+	})
+	require.NoError(t, err)
+
+	oldPair, err := authService.GenerateTokenPair(ctx, registered.ID, registered.Email, registered.Name)
+	require.NoError(t, err)
+
+	claims, err := authService.ValidateToken(oldPair.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, []string{RoleUser}, claims.Roles)
+
+	require.NoError(t, userService.PromoteToAdmin(ctx, registered.ID))
+	// Old tokens are accepted until the cached version expires
+	time.Sleep(20 * time.Millisecond)
+
+	_, err = authService.ValidateToken(oldPair.AccessToken)
+	assert.ErrorIs(t, err, auth.ErrStaleToken)
+
+	newPair, err := authService.RefreshAccessToken(ctx, oldPair.RefreshToken)
+	require.NoError(t, err)
+
+	claims, err = authService.ValidateToken(newPair.AccessToken)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{RoleUser, RoleAdmin}, claims.Roles)
+}
+
+func TestRepository_RoleChangeBumpsTokenVersionOnlyWhenChanged(t *testing.T) {
+	db := testutil.NewSQLiteDB(t)
+	repo := NewRepository(db)
+	userService := NewService(repo, newTestSecurityConfig())
+	ctx := context.Background()
+
+	registered, err := userService.RegisterUser(ctx, RegisterRequest{
+		Name:     "Jane Doe",
+		Email:    "jane@example.com",
+		Password: "Password123!",
+	})
+	require.NoError(t, err)
+
+	tokenVersion := func() int {
+		var version int
+		require.NoError(t, db.Table("users").Select("token_version").Where("id = ?", registered.ID).Take(&version).Error)
+		return version
+	}
+	initial := tokenVersion()
+
+	require.NoError(t, repo.AssignRole(ctx, registered.ID, RoleUser))
+	assert.Equal(t, initial, tokenVersion(), "assigning a role the user already holds is a no-op")
+
+	require.NoError(t, repo.RemoveRole(ctx, registered.ID, RoleAdmin))
+	assert.Equal(t, initial, tokenVersion(), "removing a role the user does not hold is a no-op")
+
+	require.NoError(t, repo.AssignRole(ctx, registered.ID, RoleAdmin))
+	assert.Equal(t, initial+1, tokenVersion())
+
+	require.NoError(t, repo.RemoveRole(ctx, registered.ID, RoleAdmin))
+	assert.Equal(t, initial+2, tokenVersion())
+}
+
+func TestDeletedUserTokenRejected(t *testing.T) {
+	db := testutil.NewSQLiteDB(t)
+
+	authService := auth.NewServiceWithRepo(&config.JWTConfig{
+		Secret:              "test-secret-that-is-long-enough-123",
+		AccessTokenTTL:      15 * time.Minute,
+		RefreshTokenTTL:     24 * time.Hour,
+		EnforceTokenVersion: true,
+	}, db)
+	userService := NewService(NewRepository(db), newTestSecurityConfig(), WithRoleCacheInvalidator(authService))
+	ctx := context.Background()
+
+	registered, err := userService.RegisterUser(ctx, RegisterRequest{
+		Name:     "Jane Doe",
+		Email:    "jane@example.com",
+		Password: "Password123!",
+	})
+	require.NoError(t, err)
+
+	pair, err := authService.GenerateTokenPair(ctx, registered.ID, registered.Email, registered.Name)
+	require.NoError(t, err)
+	require.NoError(t, userService.DeleteUser(ctx, registered.ID))
+
+	// A fresh service has no cached version, so the lookup reaches the users table
+	freshAuth := auth.NewServiceWithRepo(&config.JWTConfig{
+		Secret:              "test-secret-that-is-long-enough-123",
+		AccessTokenTTL:      15 * time.Minute,
+		RefreshTokenTTL:     24 * time.Hour,
+		EnforceTokenVersion: true,
+	}, db)
+	_, err = freshAuth.ValidateToken(pair.AccessToken)
+	assert.ErrorIs(t, err, auth.ErrInvalidToken)
+
+	_, err = freshAuth.GenerateTokenPair(ctx, 999, "ghost@example.com", "Ghost")
+	assert.ErrorIs(t, err, auth.ErrInvalidToken)
+}
@@ -0,0 +1,29 @@
+package user
+
+import "context"
+
+// UnitOfWork runs multi-step operations atomically: every repository call made inside the
+// closure joins one database transaction, and any error rolls all of them back
+type UnitOfWork interface {
+	// WithTransaction calls fn with a transaction-scoped context and repository. fn must pass the
+	// given context to each repository call; calls made with the outer context are not part of
+	// the transaction.
+	WithTransaction(ctx context.Context, fn func(ctx context.Context, repo Repository) error) error
+}
+
+type unitOfWork struct {
+	repo Repository
+}
+
+// NewUnitOfWork creates a unit of work over the repository's transaction support
+func NewUnitOfWork(repo Repository) UnitOfWork {
+	return &unitOfWork{repo: repo}
+}
+
+func (u *unitOfWork) WithTransaction(ctx context.Context, fn func(ctx context.Context, repo Repository) error) error {
+	// WHY: The repository resolves the transaction from the context, so the same repository
+	// becomes transaction-scoped when handed the transaction context
+	return u.repo.Transaction(ctx, func(txCtx context.Context) error {
+		return fn(txCtx, u.repo)
+	})
+}
@@ -0,0 +1,64 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yeegeek/uyou-go-api-starter/internal/testutil"
+)
+
+// failingRoleRepository is a real repository whose AssignRole always fails
+type failingRoleRepository struct {
+	Repository
+}
+
+func (r failingRoleRepository) AssignRole(context.Context, uint, string) error {
+	return errors.New("role table unavailable")
+}
+
+func TestService_RegisterUser_RollsBackOnRoleFailure(t *testing.T) {
+	db := testutil.NewSQLiteDB(t)
+	service := NewService(failingRoleRepository{NewRepository(db)}, newTestSecurityConfig())
+
+	user, err := service.RegisterUser(context.Background(), RegisterRequest{Name: "Jane Doe", Email: "jane@example.com", Password: "Password123!"})
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to assign default role")
+	assert.Nil(t, user)
+
+	var count int64
+	require.NoError(t, db.Table("users").Count(&count).Error)
+	assert.Zero(t, count, "the created user row must be rolled back")
+}
+
+func TestUnitOfWork_WithTransaction(t *testing.T) {
+	db := testutil.NewSQLiteDB(t)
+	repo := NewRepository(db)
+	uow := NewUnitOfWork(repo)
+	ctx := context.Background()
+
+	err := uow.WithTransaction(ctx, func(txCtx context.Context, repo Repository) error {
+		return repo.Create(txCtx, &User{Name: "Committed", Email: "committed@example.com", PasswordHash: "hash"})
+	})
+	require.NoError(t, err)
+
+	failure := errors.New("second step failed")
+	err = uow.WithTransaction(ctx, func(txCtx context.Context, repo Repository) error {
+		if err := repo.Create(txCtx, &User{Name: "Rolled Back", Email: "rolled-back@example.com", PasswordHash: "hash"}); err != nil {
+			return err
+		}
+		return failure
+	})
+	assert.ErrorIs(t, err, failure)
+
+	committed, err := repo.FindByEmail(ctx, "committed@example.com")
+	require.NoError(t, err)
+	assert.NotNil(t, committed)
+	rolledBack, err := repo.FindByEmail(ctx, "rolled-back@example.com")
+	require.NoError(t, err)
+	assert.Nil(t, rolledBack)
+}
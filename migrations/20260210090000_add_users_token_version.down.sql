@@ -0,0 +1,2 @@
+-- Rollback token_version column
+ALTER TABLE users DROP COLUMN IF EXISTS token_version;
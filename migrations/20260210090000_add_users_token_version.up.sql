@@ -0,0 +1,2 @@
+-- Track role/permission changes so stale access tokens can be rejected
+ALTER TABLE users ADD COLUMN IF NOT EXISTS token_version INTEGER NOT NULL DEFAULT 0;
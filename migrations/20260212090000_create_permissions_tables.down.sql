@@ -0,0 +1,6 @@
+BEGIN;
+
+DROP TABLE IF EXISTS role_permissions;
+DROP TABLE IF EXISTS permissions;
+
+COMMIT;
@@ -0,0 +1,8 @@
+-- Migration: create_impersonation_grants_table (rollback)
+-- Description: Drops impersonation_grants table
+
+BEGIN;
+
+DROP TABLE IF EXISTS impersonation_grants;
+
+COMMIT;
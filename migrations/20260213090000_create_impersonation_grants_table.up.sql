@@ -0,0 +1,25 @@
+-- Migration: create_impersonation_grants_table
+-- Description: Records admin impersonation sessions for auditing and visibility
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS impersonation_grants (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    impersonator_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    target_user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    reason VARCHAR(255) NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_impersonation_grants_impersonator_id ON impersonation_grants(impersonator_id);
+CREATE INDEX IF NOT EXISTS idx_impersonation_grants_target_user_id ON impersonation_grants(target_user_id);
+CREATE INDEX IF NOT EXISTS idx_impersonation_grants_expires_at ON impersonation_grants(expires_at);
+
+COMMENT ON TABLE impersonation_grants IS 'Admin impersonation sessions (audit trail); tokens are access-only and never refreshed';
+COMMENT ON COLUMN impersonation_grants.impersonator_id IS 'Admin who started the impersonation';
+COMMENT ON COLUMN impersonation_grants.target_user_id IS 'User being impersonated';
+COMMENT ON COLUMN impersonation_grants.reason IS 'Support reason supplied by the admin';
+COMMENT ON COLUMN impersonation_grants.expires_at IS 'Expiration of the impersonation access token';
+
+COMMIT;
@@ -0,0 +1,8 @@
+-- Migration: create_feature_flags_table (rollback)
+-- Description: Drops feature_flags table
+
+BEGIN;
+
+DROP TABLE IF EXISTS feature_flags;
+
+COMMIT;
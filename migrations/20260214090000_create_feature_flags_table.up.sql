@@ -0,0 +1,24 @@
+-- Migration: create_feature_flags_table
+-- Description: Feature flags managed through the admin API; rows override flags defined in configuration
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS feature_flags (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL UNIQUE,
+    description VARCHAR(255),
+    enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    percentage INTEGER NOT NULL DEFAULT 0 CHECK (percentage BETWEEN 0 AND 100),
+    allow_users JSONB NOT NULL DEFAULT '[]',
+    deny_users JSONB NOT NULL DEFAULT '[]',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+COMMENT ON TABLE feature_flags IS 'Feature flags; a row overrides the configured flag with the same name';
+COMMENT ON COLUMN feature_flags.enabled IS 'Default state for anonymous users and users outside the rollout';
+COMMENT ON COLUMN feature_flags.percentage IS 'Share of users (0-100) enabled by hashing user ID and flag name';
+COMMENT ON COLUMN feature_flags.allow_users IS 'User IDs that always get the flag';
+COMMENT ON COLUMN feature_flags.deny_users IS 'User IDs that never get the flag; takes precedence over allow_users';
+
+COMMIT;
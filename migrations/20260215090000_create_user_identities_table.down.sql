@@ -0,0 +1,8 @@
+-- Migration: create_user_identities_table (rollback)
+-- Description: Drops user_identities table
+
+BEGIN;
+
+DROP TABLE IF EXISTS user_identities;
+
+COMMIT;
@@ -0,0 +1,24 @@
+-- Migration: create_user_identities_table
+-- Description: Links users to external OAuth/OIDC identities for social login
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS user_identities (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    provider VARCHAR(50) NOT NULL,
+    subject VARCHAR(255) NOT NULL,
+    email VARCHAR(255),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (provider, subject)
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_identities_user_id ON user_identities(user_id);
+
+COMMENT ON TABLE user_identities IS 'External login identities linked to users';
+COMMENT ON COLUMN user_identities.provider IS 'OAuth provider name, e.g. google';
+COMMENT ON COLUMN user_identities.subject IS 'Stable user identifier at the provider (OIDC sub claim)';
+COMMENT ON COLUMN user_identities.email IS 'Email reported by the provider when the link was created';
+
+COMMIT;
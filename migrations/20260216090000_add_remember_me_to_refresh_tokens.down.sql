@@ -0,0 +1,8 @@
+-- Migration: add_remember_me_to_refresh_tokens (rollback)
+-- Description: Drops remember_me column from refresh_tokens
+
+BEGIN;
+
+ALTER TABLE refresh_tokens DROP COLUMN IF EXISTS remember_me;
+
+COMMIT;
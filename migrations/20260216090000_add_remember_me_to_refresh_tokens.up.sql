@@ -0,0 +1,10 @@
+-- Migration: add_remember_me_to_refresh_tokens
+-- Description: Marks refresh tokens issued with "remember me" so rotation keeps the extended lifetime
+
+BEGIN;
+
+ALTER TABLE refresh_tokens ADD COLUMN IF NOT EXISTS remember_me BOOLEAN NOT NULL DEFAULT FALSE;
+
+COMMENT ON COLUMN refresh_tokens.remember_me IS 'Whether the session was issued with the extended remember-me lifetime';
+
+COMMIT;
@@ -0,0 +1,8 @@
+-- Migration: add_active_to_users (rollback)
+-- Description: Drops active column from users
+
+BEGIN;
+
+ALTER TABLE users DROP COLUMN IF EXISTS active;
+
+COMMIT;
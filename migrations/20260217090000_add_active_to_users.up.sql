@@ -0,0 +1,10 @@
+-- Migration: add_active_to_users
+-- Description: Lets admins disable an account without deleting it; inactive users cannot sign in
+
+BEGIN;
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS active BOOLEAN NOT NULL DEFAULT TRUE;
+
+COMMENT ON COLUMN users.active IS 'False when an admin has deactivated the account';
+
+COMMIT;
@@ -0,0 +1,8 @@
+-- Migration: create_login_failures_table (rollback)
+-- Description: Drops login_failures table
+
+BEGIN;
+
+DROP TABLE IF EXISTS login_failures;
+
+COMMIT;
@@ -0,0 +1,17 @@
+-- Migration: create_login_failures_table
+-- Description: Records failed password logins; enough failures within the lockout window lock the account
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS login_failures (
+    id BIGSERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_login_failures_user_id_created_at ON login_failures(user_id, created_at DESC);
+
+COMMENT ON TABLE login_failures IS 'Failed password logins inside the lockout window; cleared on successful login or by an admin';
+COMMENT ON COLUMN login_failures.created_at IS 'Time of the failed attempt';
+
+COMMIT;
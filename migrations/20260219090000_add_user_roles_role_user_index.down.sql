@@ -0,0 +1,9 @@
+-- Migration: add_user_roles_role_user_index (rollback)
+-- Description: Restores the single-column role_id index
+
+BEGIN;
+
+CREATE INDEX IF NOT EXISTS idx_user_roles_role_id ON user_roles(role_id);
+DROP INDEX IF EXISTS idx_user_roles_role_id_user_id;
+
+COMMIT;
@@ -0,0 +1,9 @@
+-- Migration: add_user_roles_role_user_index
+-- Description: Covers the role filter of the admin user list; the join from roles to user_roles reads user_id straight from the index
+
+BEGIN;
+
+CREATE INDEX IF NOT EXISTS idx_user_roles_role_id_user_id ON user_roles(role_id, user_id);
+DROP INDEX IF EXISTS idx_user_roles_role_id;
+
+COMMIT;
@@ -0,0 +1,10 @@
+-- Migration: add_client_metadata_to_refresh_tokens (rollback)
+-- Description: Drops ip, user_agent and geo columns from refresh_tokens
+
+BEGIN;
+
+ALTER TABLE refresh_tokens DROP COLUMN IF EXISTS geo;
+ALTER TABLE refresh_tokens DROP COLUMN IF EXISTS user_agent;
+ALTER TABLE refresh_tokens DROP COLUMN IF EXISTS ip;
+
+COMMIT;
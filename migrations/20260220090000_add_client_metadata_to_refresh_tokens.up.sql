@@ -0,0 +1,14 @@
+-- Migration: add_client_metadata_to_refresh_tokens
+-- Description: Records where each refresh token was issued so rotations from an unfamiliar client can be flagged as possible session hijacking
+
+BEGIN;
+
+ALTER TABLE refresh_tokens ADD COLUMN IF NOT EXISTS ip VARCHAR(45) NOT NULL DEFAULT '';
+ALTER TABLE refresh_tokens ADD COLUMN IF NOT EXISTS user_agent VARCHAR(512) NOT NULL DEFAULT '';
+ALTER TABLE refresh_tokens ADD COLUMN IF NOT EXISTS geo VARCHAR(8) NOT NULL DEFAULT '';
+
+COMMENT ON COLUMN refresh_tokens.ip IS 'Client IP of the login or refresh that issued the token';
+COMMENT ON COLUMN refresh_tokens.user_agent IS 'User-Agent of the login or refresh that issued the token';
+COMMENT ON COLUMN refresh_tokens.geo IS 'Coarse location (ISO country code) resolved from ip, empty when unknown';
+
+COMMIT;
@@ -0,0 +1,8 @@
+-- Migration: create_login_attempts_table (rollback)
+-- Description: Drops login_attempts table
+
+BEGIN;
+
+DROP TABLE IF EXISTS login_attempts;
+
+COMMIT;
@@ -0,0 +1,24 @@
+-- Migration: create_login_attempts_table
+-- Description: Login history of successful and failed password logins, shown to users so they can spot logins that were not theirs
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS login_attempts (
+    id BIGSERIAL PRIMARY KEY,
+    user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+    email_hash VARCHAR(64) NOT NULL DEFAULT '',
+    ip VARCHAR(45) NOT NULL DEFAULT '',
+    user_agent VARCHAR(512) NOT NULL DEFAULT '',
+    outcome VARCHAR(32) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_login_attempts_user_id_created_at ON login_attempts(user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_login_attempts_created_at ON login_attempts(created_at);
+
+COMMENT ON TABLE login_attempts IS 'Password login attempts; rows older than security.login_history_retention_days are deleted by the cleanup task';
+COMMENT ON COLUMN login_attempts.user_id IS 'Account the attempt was made against, NULL when the email matched no user';
+COMMENT ON COLUMN login_attempts.email_hash IS 'Keyed hash of the email for attempts against unknown emails; the key is random per process so rows cannot be linked to users';
+COMMENT ON COLUMN login_attempts.outcome IS 'success, invalid_credentials, locked or disabled';
+
+COMMIT;
@@ -0,0 +1,8 @@
+-- Migration: add_client_id_to_refresh_tokens (rollback)
+-- Description: Drops client_id from refresh_tokens
+
+BEGIN;
+
+ALTER TABLE refresh_tokens DROP COLUMN IF EXISTS client_id;
+
+COMMIT;
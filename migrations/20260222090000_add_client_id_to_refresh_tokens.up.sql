@@ -0,0 +1,10 @@
+-- Migration: add_client_id_to_refresh_tokens
+-- Description: Records which registered client (jwt.clients) a session was issued to, so rotations keep that client's token lifetimes
+
+BEGIN;
+
+ALTER TABLE refresh_tokens ADD COLUMN IF NOT EXISTS client_id VARCHAR(64) NOT NULL DEFAULT '';
+
+COMMENT ON COLUMN refresh_tokens.client_id IS 'Registered client the session was issued to, empty when the request named no known client';
+
+COMMIT;
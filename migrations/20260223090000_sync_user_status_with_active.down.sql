@@ -0,0 +1,11 @@
+-- Migration: sync_user_status_with_active (rollback)
+-- Description: Drops the users.status constraint and makes the column nullable again
+
+BEGIN;
+
+ALTER TABLE users DROP CONSTRAINT IF EXISTS chk_users_status;
+ALTER TABLE users ALTER COLUMN status DROP NOT NULL;
+
+COMMENT ON COLUMN users.status IS NULL;
+
+COMMIT;
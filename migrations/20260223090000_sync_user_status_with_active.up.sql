@@ -0,0 +1,13 @@
+-- Migration: sync_user_status_with_active
+-- Description: Makes users.status mirror users.active ('active' or 'disabled') so reports and admin filters can rely on it
+
+BEGIN;
+
+UPDATE users SET status = CASE WHEN active THEN 'active' ELSE 'disabled' END;
+
+ALTER TABLE users ALTER COLUMN status SET NOT NULL;
+ALTER TABLE users ADD CONSTRAINT chk_users_status CHECK (status IN ('active', 'disabled'));
+
+COMMENT ON COLUMN users.status IS 'Account status mirrored from active: active or disabled; soft deletion is tracked separately in deleted_at';
+
+COMMIT;
@@ -0,0 +1,8 @@
+-- Migration: create_policy_acceptances (rollback)
+-- Description: Drops the policy_acceptances table
+
+BEGIN;
+
+DROP TABLE IF EXISTS policy_acceptances;
+
+COMMIT;
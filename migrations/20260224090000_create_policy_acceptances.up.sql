@@ -0,0 +1,23 @@
+-- Migration: create_policy_acceptances
+-- Description: Records which version of each policy document (terms, privacy, ...) a user accepted, for legal audits
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS policy_acceptances (
+    id BIGSERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    document_type VARCHAR(50) NOT NULL,
+    version VARCHAR(50) NOT NULL,
+    ip_address VARCHAR(45) NOT NULL DEFAULT '',
+    accepted_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_policy_acceptances_user_id ON policy_acceptances(user_id);
+CREATE INDEX IF NOT EXISTS idx_policy_acceptances_document_version ON policy_acceptances(document_type, version);
+
+COMMENT ON TABLE policy_acceptances IS 'Append-only record of users accepting policy document versions';
+COMMENT ON COLUMN policy_acceptances.document_type IS 'Policy document type as configured in policies.documents, e.g. terms';
+COMMENT ON COLUMN policy_acceptances.version IS 'Version of the document that was current when the user accepted';
+COMMENT ON COLUMN policy_acceptances.ip_address IS 'Client IP the acceptance was made from';
+
+COMMIT;
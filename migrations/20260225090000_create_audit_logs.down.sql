@@ -0,0 +1,8 @@
+-- Migration: create_audit_logs (rollback)
+-- Description: Drops the audit_logs table
+
+BEGIN;
+
+DROP TABLE IF EXISTS audit_logs;
+
+COMMIT;
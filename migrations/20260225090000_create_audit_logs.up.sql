@@ -0,0 +1,29 @@
+-- Migration: create_audit_logs
+-- Description: Persists admin requests so they can be filtered, paginated and exported as CSV
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS audit_logs (
+    id BIGSERIAL PRIMARY KEY,
+    actor_id INTEGER NOT NULL,
+    action VARCHAR(150) NOT NULL,
+    target_id VARCHAR(100) NOT NULL DEFAULT '',
+    method VARCHAR(10) NOT NULL,
+    path VARCHAR(255) NOT NULL,
+    status INTEGER NOT NULL,
+    ip_address VARCHAR(45) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Every filter is an equality on one column plus the created_at range and newest-first order
+CREATE INDEX IF NOT EXISTS idx_audit_logs_created_at ON audit_logs(created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_actor_created_at ON audit_logs(actor_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_action_created_at ON audit_logs(action, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_target_created_at ON audit_logs(target_id, created_at DESC);
+
+COMMENT ON TABLE audit_logs IS 'Append-only record of requests to admin endpoints';
+COMMENT ON COLUMN audit_logs.actor_id IS 'Admin user ID; no foreign key so entries outlive deleted accounts';
+COMMENT ON COLUMN audit_logs.action IS 'HTTP method and admin route template, e.g. POST /admin/users/:id/deactivate';
+COMMENT ON COLUMN audit_logs.target_id IS 'Route :id, :name or :family parameter, empty when the route has none';
+
+COMMIT;
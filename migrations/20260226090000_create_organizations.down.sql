@@ -0,0 +1,9 @@
+-- Migration: create_organizations (rollback)
+-- Description: Drops the organization_members and organizations tables
+
+BEGIN;
+
+DROP TABLE IF EXISTS organization_members;
+DROP TABLE IF EXISTS organizations;
+
+COMMIT;
@@ -0,0 +1,31 @@
+-- Migration: create_organizations
+-- Description: Adds organizations and organization memberships with an organization role per member
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS organizations (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    created_by INTEGER NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS organization_members (
+    organization_id INTEGER NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role VARCHAR(20) NOT NULL CHECK (role IN ('owner', 'admin', 'member')),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (organization_id, user_id)
+);
+
+-- Token issuance and "my organizations" look memberships up by user
+CREATE INDEX IF NOT EXISTS idx_organization_members_user_id ON organization_members(user_id);
+
+COMMENT ON TABLE organizations IS 'Tenants that users belong to through organization_members';
+COMMENT ON COLUMN organizations.created_by IS 'User who created the organization; no foreign key so the record outlives the account';
+COMMENT ON TABLE organization_members IS 'Organization memberships; copied into access tokens as the orgs claim';
+COMMENT ON COLUMN organization_members.role IS 'Organization role: owner, admin or member';
+
+COMMIT;
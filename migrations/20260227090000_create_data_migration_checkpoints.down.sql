@@ -0,0 +1,8 @@
+-- Migration: create_data_migration_checkpoints (rollback)
+-- Description: Drops the data_migration_checkpoints table
+
+BEGIN;
+
+DROP TABLE IF EXISTS data_migration_checkpoints;
+
+COMMIT;
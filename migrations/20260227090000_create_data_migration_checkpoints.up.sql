@@ -0,0 +1,20 @@
+-- Migration: create_data_migration_checkpoints
+-- Description: Stores the progress of batched data migrations run with `migrate data-migrate NAME`
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS data_migration_checkpoints (
+    name VARCHAR(100) PRIMARY KEY,
+    last_id BIGINT NOT NULL DEFAULT 0,
+    rows_affected BIGINT NOT NULL DEFAULT 0,
+    completed_at TIMESTAMP WITH TIME ZONE,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+COMMENT ON TABLE data_migration_checkpoints IS 'Progress of batched data migrations, committed with each batch';
+COMMENT ON COLUMN data_migration_checkpoints.name IS 'Registered data migration name';
+COMMENT ON COLUMN data_migration_checkpoints.last_id IS 'Highest row ID processed; a resumed run continues after it';
+COMMENT ON COLUMN data_migration_checkpoints.rows_affected IS 'Rows changed so far';
+COMMENT ON COLUMN data_migration_checkpoints.completed_at IS 'When the migration finished; NULL while in progress';
+
+COMMIT;
@@ -0,0 +1,8 @@
+-- Migration: add_not_before_to_refresh_tokens (rollback)
+-- Description: Drops not_before from refresh_tokens
+
+BEGIN;
+
+ALTER TABLE refresh_tokens DROP COLUMN IF EXISTS not_before;
+
+COMMIT;
@@ -0,0 +1,10 @@
+-- Migration: add_not_before_to_refresh_tokens
+-- Description: Records the activation time of delayed-activation sessions, so their refresh tokens cannot be used early
+
+BEGIN;
+
+ALTER TABLE refresh_tokens ADD COLUMN IF NOT EXISTS not_before TIMESTAMP WITH TIME ZONE;
+
+COMMENT ON COLUMN refresh_tokens.not_before IS 'Time before which the refresh token cannot be used; NULL for sessions active on issue';
+
+COMMIT;
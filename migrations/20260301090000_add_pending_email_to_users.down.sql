@@ -0,0 +1,11 @@
+-- Migration: add_pending_email_to_users (rollback)
+-- Description: Drops the pending email change columns from users
+
+BEGIN;
+
+DROP INDEX IF EXISTS idx_users_email_change_token_hash;
+ALTER TABLE users DROP COLUMN IF EXISTS email_change_expires_at;
+ALTER TABLE users DROP COLUMN IF EXISTS email_change_token_hash;
+ALTER TABLE users DROP COLUMN IF EXISTS pending_email;
+
+COMMIT;
@@ -0,0 +1,16 @@
+-- Migration: add_pending_email_to_users
+-- Description: Stores an email change awaiting verification; the current email stays in use until the token is confirmed
+
+BEGIN;
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS pending_email VARCHAR(255);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email_change_token_hash VARCHAR(64);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email_change_expires_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS idx_users_email_change_token_hash ON users(email_change_token_hash);
+
+COMMENT ON COLUMN users.pending_email IS 'New email address awaiting verification; empty when no change is pending';
+COMMENT ON COLUMN users.email_change_token_hash IS 'SHA-256 hex digest of the email change verification token';
+COMMENT ON COLUMN users.email_change_expires_at IS 'Time after which the email change verification token is rejected';
+
+COMMIT;
@@ -0,0 +1,8 @@
+-- Migration: create_user_preferences (rollback)
+-- Description: Drops the user_preferences table
+
+BEGIN;
+
+DROP TABLE IF EXISTS user_preferences;
+
+COMMIT;
@@ -0,0 +1,18 @@
+-- Migration: create_user_preferences
+-- Description: Per-user key-value settings; allowed keys and their value types are defined in code, so new settings need no schema change
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS user_preferences (
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    key VARCHAR(100) NOT NULL,
+    value JSONB NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (user_id, key)
+);
+
+COMMENT ON TABLE user_preferences IS 'User settings explicitly set by the user; keys without a row use the default from the code registry';
+COMMENT ON COLUMN user_preferences.key IS 'Preference key from the code registry, e.g. notifications.email';
+COMMENT ON COLUMN user_preferences.value IS 'JSON value validated against the key type (boolean, enum string or bounded integer)';
+
+COMMIT;
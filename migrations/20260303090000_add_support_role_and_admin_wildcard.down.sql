@@ -0,0 +1,9 @@
+-- Migration: add_support_role_and_admin_wildcard (rollback)
+-- Description: Removes the support role and the admin:* permission; their user_roles and role_permissions rows go with them by cascade
+
+BEGIN;
+
+DELETE FROM roles WHERE name = 'support';
+DELETE FROM permissions WHERE name = 'admin:*';
+
+COMMIT;
@@ -0,0 +1,24 @@
+-- Migration: add_support_role_and_admin_wildcard
+-- Description: Adds the admin:* permission that covers every permission and a support role that can view users but not change or delete them
+
+BEGIN;
+
+INSERT INTO permissions (name, description) VALUES
+    ('admin:*', 'Every permission, including admin-only operations such as impersonation, feature flags and the audit log')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO roles (name, description) VALUES
+    ('support', 'Support staff who can view users but not change or delete them')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT roles.id, permissions.id FROM roles CROSS JOIN permissions
+WHERE roles.name = 'admin' AND permissions.name = 'admin:*'
+ON CONFLICT (role_id, permission_id) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT roles.id, permissions.id FROM roles CROSS JOIN permissions
+WHERE roles.name = 'support' AND permissions.name = 'users:read'
+ON CONFLICT (role_id, permission_id) DO NOTHING;
+
+COMMIT;
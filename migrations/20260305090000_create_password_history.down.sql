@@ -0,0 +1,8 @@
+-- Migration: create_password_history (rollback)
+-- Description: Drops the password_history table
+
+BEGIN;
+
+DROP TABLE IF EXISTS password_history;
+
+COMMIT;
@@ -0,0 +1,18 @@
+-- Migration: create_password_history
+-- Description: Prior password hashes per user, checked when security.password_history_depth forbids reusing recent passwords
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS password_history (
+    id BIGSERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    password_hash VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_password_history_user_id_created_at ON password_history(user_id, created_at DESC);
+
+COMMENT ON TABLE password_history IS 'Password hashes a user had before each change; rows beyond the configured depth are trimmed on every change';
+COMMENT ON COLUMN password_history.password_hash IS 'bcrypt hash of the replaced password';
+
+COMMIT;
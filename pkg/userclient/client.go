@@ -0,0 +1,223 @@
+package userclient
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	pb "github.com/yeegeek/uyou-go-api-starter/api/proto/user"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+// User 用户信息
+type User struct {
+	ID        uint
+	Name      string
+	Email     string
+	Roles     []string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Client 用户服务 gRPC 客户端，可安全地被多个 goroutine 并发使用
+type Client struct {
+	conn    *grpc.ClientConn
+	rpc     pb.UserServiceClient
+	timeout time.Duration
+}
+
+// New 创建连接到 addr 的客户端。连接是惰性建立的，首次调用时才会拨号
+func New(addr string, opts ...Option) (*Client, error) {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	dialOpts := []grpc.DialOption{
+		grpc.WithChainUnaryInterceptor(retryInterceptor(o.retryAttempts, o.retryBackoff)),
+	}
+	if o.tlsConfig != nil {
+		dialOpts = append(dialOpts, grpc.WithTransportCredentials(credentials.NewTLS(o.tlsConfig)))
+	} else {
+		dialOpts = append(dialOpts, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	}
+	if o.token != "" {
+		dialOpts = append(dialOpts, grpc.WithPerRPCCredentials(bearerToken{token: o.token, secure: o.tlsConfig != nil}))
+	}
+	if o.keepalive != nil {
+		dialOpts = append(dialOpts, grpc.WithKeepaliveParams(*o.keepalive))
+	}
+	dialOpts = append(dialOpts, o.dialOptions...)
+
+	conn, err := grpc.NewClient(addr, dialOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create gRPC client: %w", err)
+	}
+
+	return &Client{
+		conn:    conn,
+		rpc:     pb.NewUserServiceClient(conn),
+		timeout: o.timeout,
+	}, nil
+}
+
+// Close 关闭底层连接
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// GetUser 根据 ID 获取用户
+func (c *Client) GetUser(ctx context.Context, id uint) (*User, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	resp, err := c.rpc.GetUser(ctx, &pb.GetUserRequest{Id: uint32(id)})
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return fromProto(resp.User), nil
+}
+
+// GetUserByEmail 根据邮箱获取用户
+func (c *Client) GetUserByEmail(ctx context.Context, email string) (*User, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	resp, err := c.rpc.GetUserByEmail(ctx, &pb.GetUserByEmailRequest{Email: email})
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return fromProto(resp.User), nil
+}
+
+// ListUsers 分页获取用户列表，返回当前页用户和总数
+func (c *Client) ListUsers(ctx context.Context, page, pageSize int) ([]*User, int, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	resp, err := c.rpc.ListUsers(ctx, &pb.ListUsersRequest{Page: int32(page), PageSize: int32(pageSize)})
+	if err != nil {
+		return nil, 0, translateError(err)
+	}
+
+	users := make([]*User, 0, len(resp.Users))
+	for _, u := range resp.Users {
+		users = append(users, fromProto(u))
+	}
+	return users, int(resp.Total), nil
+}
+
+// UpdateUser 更新用户名称和邮箱，空字符串表示不修改
+func (c *Client) UpdateUser(ctx context.Context, id uint, name, email string) (*User, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	resp, err := c.rpc.UpdateUser(ctx, &pb.UpdateUserRequest{Id: uint32(id), Name: name, Email: email})
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return fromProto(resp.User), nil
+}
+
+// DeleteUser 删除用户
+func (c *Client) DeleteUser(ctx context.Context, id uint) error {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	if _, err := c.rpc.DeleteUser(ctx, &pb.DeleteUserRequest{Id: uint32(id)}); err != nil {
+		return translateError(err)
+	}
+	return nil
+}
+
+// CreateUser 创建用户
+func (c *Client) CreateUser(ctx context.Context, name, email, password string) (*User, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	resp, err := c.rpc.CreateUser(ctx, &pb.CreateUserRequest{Name: name, Email: email, Password: password})
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return fromProto(resp.User), nil
+}
+
+// Authenticate 校验邮箱和密码，凭证无效时返回 ErrUnauthenticated
+func (c *Client) Authenticate(ctx context.Context, email, password string) (*User, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	resp, err := c.rpc.Authenticate(ctx, &pb.AuthenticateRequest{Email: email, Password: password})
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return fromProto(resp.User), nil
+}
+
+// withTimeout 在调用方 context 没有更早截止时间时附加默认超时
+func (c *Client) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.timeout <= 0 {
+		return ctx, func() {}
+	}
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= c.timeout {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, c.timeout)
+}
+
+// retryableMethods 可安全重试的幂等方法。写操作在 Unavailable 时可能已在服务端生效，
+// 重试会导致重复创建等副作用，因此不重试
+var retryableMethods = map[string]bool{
+	"/user.UserService/GetUser":        true,
+	"/user.UserService/GetUserByEmail": true,
+	"/user.UserService/ListUsers":      true,
+	"/user.UserService/Authenticate":   true,
+}
+
+// retryInterceptor 对 retryableMethods 中的方法在返回 Unavailable 时按指数退避重试，context 结束时立即停止
+func retryInterceptor(attempts int, backoff time.Duration) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		if !retryableMethods[method] {
+			return err
+		}
+		wait := backoff
+		for attempt := 1; attempt < attempts && status.Code(err) == codes.Unavailable; attempt++ {
+			timer := time.NewTimer(wait)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return status.FromContextError(ctx.Err()).Err()
+			case <-timer.C:
+			}
+
+			if wait < backoff*maxRetryBackoffFactor {
+				wait *= 2
+			}
+			err = invoker(ctx, method, req, reply, cc, opts...)
+		}
+		return err
+	}
+}
+
+// fromProto 将 protobuf 用户消息转换为 User
+func fromProto(u *pb.User) *User {
+	if u == nil {
+		return nil
+	}
+
+	createdAt, _ := time.Parse(time.RFC3339, u.CreatedAt)
+	updatedAt, _ := time.Parse(time.RFC3339, u.UpdatedAt)
+	return &User{
+		ID:        uint(u.Id),
+		Name:      u.Name,
+		Email:     u.Email,
+		Roles:     u.Roles,
+		CreatedAt: createdAt,
+		UpdatedAt: updatedAt,
+	}
+}
@@ -0,0 +1,216 @@
+package userclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	pb "github.com/yeegeek/uyou-go-api-starter/api/proto/user"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// jsonCodec 用于测试：占位 protobuf 类型不是 proto.Message，无法使用默认编解码器
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v any) ([]byte, error)      { return json.Marshal(v) }
+func (jsonCodec) Unmarshal(data []byte, v any) error { return json.Unmarshal(data, v) }
+func (jsonCodec) Name() string                       { return "json" }
+
+// fakeUserServer 可编程的用户服务实现
+type fakeUserServer struct {
+	pb.UnimplementedUserServiceServer
+	calls      atomic.Int32
+	failures   int32
+	delay      time.Duration
+	lastAuthMD atomic.Value
+}
+
+func (s *fakeUserServer) GetUser(ctx context.Context, req *pb.GetUserRequest) (*pb.GetUserResponse, error) {
+	n := s.calls.Add(1)
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		s.lastAuthMD.Store(md.Get("authorization"))
+	}
+	if n <= s.failures {
+		return nil, status.Error(codes.Unavailable, "temporarily unavailable")
+	}
+	if s.delay > 0 {
+		select {
+		case <-time.After(s.delay):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	if req.Id != 1 {
+		return nil, status.Error(codes.NotFound, "user not found")
+	}
+	return &pb.GetUserResponse{User: &pb.User{
+		Id:        1,
+		Name:      "Test User",
+		Email:     "test@example.com",
+		Roles:     []string{"user"},
+		CreatedAt: "2026-01-02T03:04:05Z",
+		UpdatedAt: "2026-01-02T03:04:05Z",
+	}}, nil
+}
+
+func (s *fakeUserServer) DeleteUser(ctx context.Context, req *pb.DeleteUserRequest) (*pb.DeleteUserResponse, error) {
+	return nil, status.Error(codes.PermissionDenied, "admin role required")
+}
+
+func (s *fakeUserServer) CreateUser(ctx context.Context, req *pb.CreateUserRequest) (*pb.CreateUserResponse, error) {
+	if n := s.calls.Add(1); n <= s.failures {
+		return nil, status.Error(codes.Unavailable, "temporarily unavailable")
+	}
+	return &pb.CreateUserResponse{User: &pb.User{Id: 2, Name: req.Name, Email: req.Email}}, nil
+}
+
+func (s *fakeUserServer) Authenticate(ctx context.Context, req *pb.AuthenticateRequest) (*pb.AuthenticateResponse, error) {
+	if req.Password != "Password123!" {
+		return nil, status.Error(codes.Unauthenticated, "invalid credentials")
+	}
+	return &pb.AuthenticateResponse{User: &pb.User{Id: 1, Email: req.Email}}, nil
+}
+
+func newTestClient(t *testing.T, srv pb.UserServiceServer, opts ...Option) *Client {
+	t.Helper()
+
+	lis := bufconn.Listen(1024 * 1024)
+	grpcServer := grpc.NewServer(grpc.ForceServerCodec(jsonCodec{}))
+	pb.RegisterUserServiceServer(grpcServer, srv)
+	go func() {
+		_ = grpcServer.Serve(lis)
+	}()
+	t.Cleanup(grpcServer.Stop)
+
+	opts = append(opts, WithDialOptions(
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithDefaultCallOptions(grpc.ForceCodec(jsonCodec{})),
+	))
+	client, err := New("passthrough:///bufnet", opts...)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = client.Close() })
+	return client
+}
+
+func TestClient_GetUser(t *testing.T) {
+	client := newTestClient(t, &fakeUserServer{})
+
+	u, err := client.GetUser(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, uint(1), u.ID)
+	assert.Equal(t, "test@example.com", u.Email)
+	assert.Equal(t, []string{"user"}, u.Roles)
+	assert.Equal(t, time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC), u.CreatedAt.UTC())
+}
+
+func TestClient_ErrorTranslation(t *testing.T) {
+	client := newTestClient(t, &fakeUserServer{})
+
+	_, err := client.GetUser(context.Background(), 999)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	err = client.DeleteUser(context.Background(), 1)
+	assert.ErrorIs(t, err, ErrPermissionDenied)
+
+	_, err = client.Authenticate(context.Background(), "test@example.com", "wrong")
+	assert.ErrorIs(t, err, ErrUnauthenticated)
+}
+
+func TestClient_RetryOnUnavailable(t *testing.T) {
+	tests := []struct {
+		name      string
+		failures  int32
+		attempts  int
+		wantErr   error
+		wantCalls int32
+	}{
+		{
+			name:      "succeeds after transient failures",
+			failures:  2,
+			attempts:  3,
+			wantCalls: 3,
+		},
+		{
+			name:      "gives up after max attempts",
+			failures:  5,
+			attempts:  3,
+			wantErr:   ErrUnavailable,
+			wantCalls: 3,
+		},
+		{
+			name:      "no retry when disabled",
+			failures:  1,
+			attempts:  1,
+			wantErr:   ErrUnavailable,
+			wantCalls: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := &fakeUserServer{failures: tt.failures}
+			client := newTestClient(t, srv, WithRetry(tt.attempts, time.Millisecond))
+
+			_, err := client.GetUser(context.Background(), 1)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantCalls, srv.calls.Load())
+		})
+	}
+}
+
+func TestClient_NoRetryForWrites(t *testing.T) {
+	srv := &fakeUserServer{failures: 1}
+	client := newTestClient(t, srv, WithRetry(3, time.Millisecond))
+
+	_, err := client.CreateUser(context.Background(), "New User", "new@example.com", "Password123!")
+	assert.ErrorIs(t, err, ErrUnavailable)
+	assert.Equal(t, int32(1), srv.calls.Load(), "CreateUser may have been applied and must not be retried")
+}
+
+func TestClient_Deadline(t *testing.T) {
+	t.Run("client timeout", func(t *testing.T) {
+		client := newTestClient(t, &fakeUserServer{delay: time.Second}, WithTimeout(50*time.Millisecond))
+
+		start := time.Now()
+		_, err := client.GetUser(context.Background(), 1)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Less(t, time.Since(start), time.Second)
+	})
+
+	t.Run("caller deadline stops retries", func(t *testing.T) {
+		srv := &fakeUserServer{failures: 100}
+		client := newTestClient(t, srv, WithRetry(100, 20*time.Millisecond))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		_, err := client.GetUser(ctx, 1)
+		assert.True(t, errors.Is(err, context.DeadlineExceeded), "got %v", err)
+		assert.Less(t, srv.calls.Load(), int32(100))
+	})
+}
+
+func TestClient_BearerToken(t *testing.T) {
+	srv := &fakeUserServer{}
+	client := newTestClient(t, srv, WithBearerToken("secret-token"))
+
+	_, err := client.GetUser(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Bearer secret-token"}, srv.lastAuthMD.Load())
+}
@@ -0,0 +1,25 @@
+// Package userclient 提供调用用户服务 gRPC 接口的类型化客户端。
+//
+// 客户端封装了连接建立、TLS、Bearer Token 认证、调用超时、
+// 幂等方法遇到 Unavailable 错误时的退避重试以及 keepalive 配置，并把 gRPC 状态码
+// 转换为 ErrNotFound、ErrPermissionDenied 等哨兵错误，调用方无需
+// 直接依赖生成的 protobuf 代码。
+//
+// 示例：
+//
+//	client, err := userclient.New("user-service:9090",
+//		userclient.WithTLS(&tls.Config{MinVersion: tls.VersionTLS12}),
+//		userclient.WithBearerToken(os.Getenv("USER_SERVICE_TOKEN")),
+//		userclient.WithTimeout(3*time.Second),
+//		userclient.WithRetry(3, 100*time.Millisecond),
+//	)
+//	if err != nil {
+//		return err
+//	}
+//	defer client.Close()
+//
+//	u, err := client.GetUser(ctx, 42)
+//	if errors.Is(err, userclient.ErrNotFound) {
+//		// 用户不存在
+//	}
+package userclient
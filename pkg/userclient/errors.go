@@ -0,0 +1,62 @@
+package userclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// 哨兵错误，可通过 errors.Is 判断
+var (
+	// ErrNotFound 用户不存在
+	ErrNotFound = errors.New("user not found")
+	// ErrAlreadyExists 邮箱已被使用
+	ErrAlreadyExists = errors.New("user already exists")
+	// ErrInvalidArgument 请求参数无效
+	ErrInvalidArgument = errors.New("invalid argument")
+	// ErrUnauthenticated 凭证无效或缺失
+	ErrUnauthenticated = errors.New("unauthenticated")
+	// ErrPermissionDenied 没有执行该操作的权限
+	ErrPermissionDenied = errors.New("permission denied")
+	// ErrUnavailable 服务不可用（重试后仍失败）
+	ErrUnavailable = errors.New("user service unavailable")
+)
+
+// translateError 将 gRPC 状态错误转换为哨兵错误，保留服务端消息
+func translateError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	st, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+
+	var sentinel error
+	switch st.Code() {
+	case codes.NotFound:
+		sentinel = ErrNotFound
+	case codes.AlreadyExists:
+		sentinel = ErrAlreadyExists
+	case codes.InvalidArgument:
+		sentinel = ErrInvalidArgument
+	case codes.Unauthenticated:
+		sentinel = ErrUnauthenticated
+	case codes.PermissionDenied:
+		sentinel = ErrPermissionDenied
+	case codes.Unavailable:
+		sentinel = ErrUnavailable
+	case codes.DeadlineExceeded:
+		sentinel = context.DeadlineExceeded
+	case codes.Canceled:
+		sentinel = context.Canceled
+	default:
+		return err
+	}
+
+	return fmt.Errorf("%w: %s", sentinel, st.Message())
+}
@@ -0,0 +1,99 @@
+package userclient
+
+import (
+	"context"
+	"crypto/tls"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/keepalive"
+)
+
+// 默认配置
+const (
+	DefaultTimeout        = 5 * time.Second
+	DefaultRetryAttempts  = 3
+	DefaultRetryBackoff   = 100 * time.Millisecond
+	maxRetryBackoffFactor = 10
+)
+
+// Option 配置 Client 的函数选项
+type Option func(*options)
+
+type options struct {
+	tlsConfig     *tls.Config
+	token         string
+	timeout       time.Duration
+	retryAttempts int
+	retryBackoff  time.Duration
+	keepalive     *keepalive.ClientParameters
+	dialOptions   []grpc.DialOption
+}
+
+func defaultOptions() *options {
+	return &options{
+		timeout:       DefaultTimeout,
+		retryAttempts: DefaultRetryAttempts,
+		retryBackoff:  DefaultRetryBackoff,
+	}
+}
+
+// WithTLS 使用 TLS 连接服务端；未设置时使用明文连接
+func WithTLS(cfg *tls.Config) Option {
+	return func(o *options) {
+		o.tlsConfig = cfg
+	}
+}
+
+// WithBearerToken 为每次调用附加 "authorization: Bearer <token>" 元数据
+func WithBearerToken(token string) Option {
+	return func(o *options) {
+		o.token = token
+	}
+}
+
+// WithTimeout 设置单次调用的超时时间；调用方 context 的截止时间更早时以其为准。
+// 传入 0 表示不设置超时
+func WithTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.timeout = d
+	}
+}
+
+// WithRetry 设置遇到 Unavailable 时的最大尝试次数和初始退避时间，退避按指数增长。
+// 仅 GetUser、GetUserByEmail、ListUsers 和 Authenticate 会重试，写操作只调用一次。
+// attempts 小于等于 1 时不重试
+func WithRetry(attempts int, backoff time.Duration) Option {
+	return func(o *options) {
+		o.retryAttempts = attempts
+		o.retryBackoff = backoff
+	}
+}
+
+// WithKeepalive 设置客户端 keepalive 参数
+func WithKeepalive(params keepalive.ClientParameters) Option {
+	return func(o *options) {
+		o.keepalive = &params
+	}
+}
+
+// WithDialOptions 追加底层 grpc.DialOption，用于自定义拦截器、拨号器等
+func WithDialOptions(opts ...grpc.DialOption) Option {
+	return func(o *options) {
+		o.dialOptions = append(o.dialOptions, opts...)
+	}
+}
+
+// bearerToken 实现 credentials.PerRPCCredentials
+type bearerToken struct {
+	token  string
+	secure bool
+}
+
+func (b bearerToken) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{"authorization": "Bearer " + b.token}, nil
+}
+
+func (b bearerToken) RequireTransportSecurity() bool {
+	return b.secure
+}
@@ -11,113 +11,53 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/stretchr/testify/assert"
-	"gorm.io/gorm"
 
 	"github.com/yeegeek/uyou-go-api-starter/internal/auth"
 	"github.com/yeegeek/uyou-go-api-starter/internal/config"
-	"github.com/yeegeek/uyou-go-api-starter/internal/db"
+	"github.com/yeegeek/uyou-go-api-starter/internal/featureflags"
+	"github.com/yeegeek/uyou-go-api-starter/internal/friend"
 	"github.com/yeegeek/uyou-go-api-starter/internal/server"
+	"github.com/yeegeek/uyou-go-api-starter/internal/testutil"
 	"github.com/yeegeek/uyou-go-api-starter/internal/user"
 )
 
-// createTestSchema creates the SQLite test schema using GORM AutoMigrate for consistency
-func createTestSchema(t *testing.T, database *gorm.DB) {
-	t.Helper()
-
-	err := database.AutoMigrate(&user.User{}, &user.Role{}, &auth.RefreshToken{})
-	assert.NoError(t, err)
-
-	// Drop the auto-created user_roles table (created by GORM for many2many)
-	// and recreate it with our custom schema including assigned_at column
-	database.Exec("DROP TABLE IF EXISTS user_roles")
-
-	// Manually create the user_roles junction table with assigned_at column
-	err = database.Exec(`
-		CREATE TABLE user_roles (
-			user_id INTEGER NOT NULL,
-			role_id INTEGER NOT NULL,
-			assigned_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
-			PRIMARY KEY (user_id, role_id),
-			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
-			FOREIGN KEY (role_id) REFERENCES roles(id) ON DELETE CASCADE
-		)
-	`).Error
-	assert.NoError(t, err)
-
-	// Seed role data - use FirstOrCreate to avoid duplicate errors
-	roles := []user.Role{
-		{ID: 1, Name: "user", Description: "Standard user with basic permissions"},
-		{ID: 2, Name: "admin", Description: "Administrator with full system access"},
-	}
-	for _, role := range roles {
-		var existingRole user.Role
-		result := database.Where("name = ?", role.Name).FirstOrCreate(&existingRole, &role)
-		if result.Error != nil {
-			t.Fatalf("Failed to create role %s: %v", role.Name, result.Error)
-		}
-	}
-}
-
 func setupTestRouter(t *testing.T) *gin.Engine {
-	gin.SetMode(gin.TestMode)
-
-	testCfg := config.NewTestConfig()
-
-	database, err := db.NewSQLiteDB(":memory:")
-	assert.NoError(t, err)
-
-	createTestSchema(t, database)
-
-	authService := auth.NewServiceWithRepo(&testCfg.JWT, database)
-	userRepo := user.NewRepository(database)
-	securityCfg := &config.SecurityConfig{
-		BcryptCost:            12,
-		PasswordMinLength:     8,
-		PasswordRequireUppercase: true,
-		PasswordRequireLowercase: true,
-		PasswordRequireNumber:    true,
-		PasswordRequireSpecial:  true,
-		MaxLoginAttempts:        5,
-		LockoutDuration:         15,
-	}
-	userService := user.NewService(userRepo, securityCfg)
-	userHandler := user.NewHandler(userService, authService)
-
-	router := server.SetupRouter(userHandler, authService, testCfg, database)
-
-	return router
+	return newTestRouter(t, config.NewTestConfig())
 }
 
 func setupRateLimitTestRouter(t *testing.T) *gin.Engine {
-	gin.SetMode(gin.TestMode)
-
 	testCfg := config.NewTestConfig()
 	testCfg.Ratelimit.Enabled = true
 	testCfg.Ratelimit.Requests = 10
 	testCfg.Ratelimit.Window = time.Minute
+	return newTestRouter(t, testCfg)
+}
 
-	database, err := db.NewSQLiteDB(":memory:")
-	assert.NoError(t, err)
+// newTestRouter wires the real handlers and services against an in-memory SQLite database, like cmd/server
+func newTestRouter(t *testing.T, testCfg *config.Config) *gin.Engine {
+	gin.SetMode(gin.TestMode)
 
-	createTestSchema(t, database)
+	database := testutil.NewSQLiteDB(t)
 
 	authService := auth.NewServiceWithRepo(&testCfg.JWT, database)
 	userRepo := user.NewRepository(database)
 	securityCfg := &config.SecurityConfig{
-		BcryptCost:            12,
-		PasswordMinLength:     8,
+		BcryptCost:               12,
+		PasswordMinLength:        8,
 		PasswordRequireUppercase: true,
 		PasswordRequireLowercase: true,
 		PasswordRequireNumber:    true,
-		PasswordRequireSpecial:  true,
-		MaxLoginAttempts:        5,
-		LockoutDuration:         15,
+		PasswordRequireSpecial:   true,
+		MaxLoginAttempts:         5,
+		LockoutDuration:          15,
 	}
-	userService := user.NewService(userRepo, securityCfg)
+	userService := user.NewService(userRepo, securityCfg, user.WithRoleCacheInvalidator(authService))
 	userHandler := user.NewHandler(userService, authService)
+	roleHandler := user.NewRoleHandler(user.NewRoleService(userRepo, user.WithRoleServiceCacheInvalidator(authService)))
+	friendHandler := friend.NewHandler(friend.NewService(friend.NewRepository(database)))
+	flagsHandler := featureflags.NewHandler(featureflags.NewService(featureflags.NewRepository(database), testCfg.FeatureFlags))
 
-	return server.SetupRouter(userHandler, authService, testCfg, database)
+	return server.SetupRouter(userHandler, roleHandler, friendHandler, flagsHandler, authService, testCfg, database)
 }
 
 func TestRegisterHandler(t *testing.T) {